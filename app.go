@@ -5,36 +5,86 @@ import (
 	"errors"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/go-kratos/kratos/v2/health"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 )
 
+// ErrStopTimeout is returned by Run when the servers do not stop within the stop timeout.
+var ErrStopTimeout = errors.New("kratos: app stop timeout")
+
+// AppInfo is the application context value.
+type AppInfo interface {
+	ID() string
+	Name() string
+	Version() string
+	Metadata() map[string]string
+	Endpoint() []string
+}
+
 // App is an application components lifecycle manager
 type App struct {
 	opts   options
 	ctx    context.Context
 	cancel func()
+
+	mu       sync.Mutex
+	instance *registry.ServiceInstance
+	stopping bool
+
+	stopOnce sync.Once
+	stopErr  error
 }
 
 // New create an application lifecycle manager.
 func New(opts ...Option) *App {
 	options := options{
-		ctx:  context.Background(),
-		sigs: []os.Signal{syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT},
+		ctx:              context.Background(),
+		sigs:             []os.Signal{syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT},
+		registrarTimeout: 10 * time.Second,
+	}
+	if id, err := uuid.NewUUID(); err == nil {
+		options.id = id.String()
 	}
 	for _, o := range opts {
 		o(&options)
 	}
-	ctx, cancel := context.WithCancel(options.ctx)
-	return &App{
-		ctx:    ctx,
-		cancel: cancel,
-		opts:   options,
+	app := &App{opts: options}
+	// the hooks receive the app info along with the context.
+	app.opts.ctx = NewContext(options.ctx, app)
+	app.ctx, app.cancel = context.WithCancel(app.opts.ctx)
+	return app
+}
+
+// ID returns app instance id.
+func (a *App) ID() string { return a.opts.id }
+
+// Name returns service name.
+func (a *App) Name() string { return a.opts.name }
+
+// Version returns app version.
+func (a *App) Version() string { return a.opts.version }
+
+// Metadata returns service metadata.
+func (a *App) Metadata() map[string]string { return a.opts.metadata }
+
+// Endpoint returns the registered endpoints, or the endpoints of the Endpoint option
+// before the app is registered.
+func (a *App) Endpoint() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.instance != nil {
+		return a.instance.Endpoints
 	}
+	return a.opts.endpoints
 }
 
 // Logger returns logger.
@@ -47,65 +97,221 @@ func (a *App) Server() []transport.Server {
 	return a.opts.servers
 }
 
-// Registry returns registry.
-func (a *App) Registry() registry.Registry {
-	return a.opts.registry
+// Registrar returns registrar.
+func (a *App) Registrar() registry.Registrar {
+	return a.opts.registrar
 }
 
 // Run executes all OnStart hooks registered with the application's Lifecycle.
 func (a *App) Run() error {
+	for _, fn := range a.opts.beforeStart {
+		if err := fn(a.opts.ctx); err != nil {
+			return err
+		}
+	}
+	instance, err := a.buildInstance()
+	if err != nil {
+		return err
+	}
+	if a.opts.health != nil && len(a.opts.warmups) > 0 {
+		a.opts.health.Set(warmupDependency, health.StatusNotServing)
+	}
 	g, ctx := errgroup.WithContext(a.ctx)
 	for _, srv := range a.opts.servers {
 		srv := srv
-		g.Go(func() error {
-			<-ctx.Done() // wait for stop signal
-			return srv.Stop()
-		})
 		g.Go(func() error {
 			return srv.Start()
 		})
 	}
-	if a.opts.registry != nil {
-		if err := a.opts.registry.Register(a.serviceInstance()); err != nil {
+	if err := a.warmup(ctx); err != nil && ctx.Err() == nil {
+		a.stopServers()
+		g.Wait()
+		return err
+	}
+	if a.opts.registrar != nil && a.ready(ctx) {
+		rctx, rcancel := context.WithTimeout(a.opts.ctx, a.opts.registrarTimeout)
+		err := a.opts.registrar.Register(rctx, instance)
+		rcancel()
+		if err != nil {
+			a.stopServers()
+			g.Wait()
 			return err
 		}
+		a.mu.Lock()
+		a.instance = instance
+		a.mu.Unlock()
+	}
+	var startErr error
+	for _, fn := range a.opts.afterStart {
+		if startErr = fn(a.opts.ctx); startErr != nil {
+			a.Stop()
+			break
+		}
 	}
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, a.opts.sigs...)
+	if len(a.opts.sigs) > 0 {
+		signal.Notify(c, a.opts.sigs...)
+		defer signal.Stop(c)
+	}
 	g.Go(func() error {
 		for {
 			select {
 			case <-ctx.Done():
+				if !a.isStopping() {
+					// the parent context is done or a server failed, stop the app as if
+					// Stop was called, so that the servers still stop after deregistering.
+					a.Stop()
+				}
 				return ctx.Err()
 			case <-c:
 				a.Stop()
 			}
 		}
 	})
-	if err := g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+	if err := a.wait(ctx, g); err != nil && !errors.Is(err, context.Canceled) {
 		return err
 	}
+	if startErr != nil {
+		return startErr
+	}
+	for _, fn := range a.opts.afterStop {
+		if err := fn(a.opts.ctx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Stop gracefully stops the application.
-func (a *App) Stop() error {
-	if a.opts.registry != nil {
-		if err := a.opts.registry.Deregister(a.serviceInstance()); err != nil {
+// warmupDependency is the health dependency which is not serving until the warmups are done.
+const warmupDependency = "warmup"
+
+// warmup runs the warmup funcs once the servers are started, before the instance is registered.
+func (a *App) warmup(ctx context.Context) error {
+	if len(a.opts.warmups) == 0 {
+		return nil
+	}
+	for _, fn := range a.opts.warmups {
+		if err := fn(ctx); err != nil {
 			return err
 		}
 	}
-	if a.cancel != nil {
-		a.cancel()
+	if a.opts.health != nil {
+		a.opts.health.Set(warmupDependency, health.StatusServing)
 	}
 	return nil
 }
 
-func (a *App) serviceInstance() *registry.ServiceInstance {
-	if len(a.opts.endpoints) == 0 {
+// ready waits for the health registry to be ready, it reports false if the app is stopped meanwhile.
+func (a *App) ready(ctx context.Context) bool {
+	if a.opts.health == nil {
+		return true
+	}
+	return a.opts.health.Wait(ctx) == nil
+}
+
+// wait waits for all servers to exit, once the app is stopping it gives up after the stop timeout.
+func (a *App) wait(ctx context.Context, g *errgroup.Group) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	if a.opts.stopTimeout <= 0 {
+		return <-done
+	}
+	timer := time.NewTimer(a.opts.stopTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return ErrStopTimeout
+	}
+}
+
+// Stop gracefully stops the application, it deregisters the service instance, sets the
+// health not serving, waits for the drain period and then stops the servers gracefully
+// in their order. The servers are only stopped by Stop, which Run calls as well if the
+// context is done or a server fails.
+func (a *App) Stop() (err error) {
+	ctx := a.opts.ctx
+	if ctx.Err() != nil {
+		// the parent context is done, the stop funcs still need a live context.
+		ctx = NewContext(context.Background(), a)
+	}
+	for _, fn := range a.opts.beforeStop {
+		if e := fn(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	a.mu.Lock()
+	instance := a.instance
+	a.instance = nil
+	drain := !a.stopping
+	a.stopping = true
+	a.mu.Unlock()
+	if a.opts.registrar != nil && instance != nil {
+		rctx, cancel := context.WithTimeout(ctx, a.opts.registrarTimeout)
+		e := a.opts.registrar.Deregister(rctx, instance)
+		cancel()
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	if a.opts.health != nil {
+		a.opts.health.Shutdown()
+	}
+	if drain && a.opts.drainPeriod > 0 {
+		// the clients and the load balancers still route the requests until they observe
+		// the deregistration and the readiness, the servers keep serving meanwhile.
+		time.Sleep(a.opts.drainPeriod)
+	}
+	if e := a.stopServers(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+func (a *App) isStopping() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stopping
+}
+
+// stopServers stops the servers once in their order, the app context is canceled first
+// so that Run waits for the servers no longer than the stop timeout.
+func (a *App) stopServers() error {
+	a.stopOnce.Do(func() {
+		if a.cancel != nil {
+			a.cancel()
+		}
 		for _, srv := range a.opts.servers {
-			if e, err := srv.Endpoint(); err == nil {
-				a.opts.endpoints = append(a.opts.endpoints, e)
+			if err := srv.Stop(); err != nil && a.stopErr == nil {
+				a.stopErr = err
+			}
+		}
+	})
+	return a.stopErr
+}
+
+// buildInstance collects the endpoints of all servers into the service instance,
+// the explicit Endpoint option takes precedence over the servers. The servers are
+// stopped if one of them fails, so that the listeners of the others are closed.
+func (a *App) buildInstance() (*registry.ServiceInstance, error) {
+	endpoints := a.opts.endpoints
+	if len(endpoints) == 0 {
+		for _, srv := range a.opts.servers {
+			e, err := srv.Endpoint()
+			if err != nil {
+				_ = a.stopServers()
+				return nil, err
+			}
+			if e != "" {
+				endpoints = append(endpoints, e)
 			}
 		}
 	}
@@ -114,6 +320,19 @@ func (a *App) serviceInstance() *registry.ServiceInstance {
 		Name:      a.opts.name,
 		Version:   a.opts.version,
 		Metadata:  a.opts.metadata,
-		Endpoints: a.opts.endpoints,
-	}
+		Endpoints: endpoints,
+	}, nil
+}
+
+type appKey struct{}
+
+// NewContext returns a new Context that carries the app info.
+func NewContext(ctx context.Context, s AppInfo) context.Context {
+	return context.WithValue(ctx, appKey{}, s)
+}
+
+// FromContext returns the app info stored in ctx, if any.
+func FromContext(ctx context.Context) (s AppInfo, ok bool) {
+	s, ok = ctx.Value(appKey{}).(AppInfo)
+	return
 }
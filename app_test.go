@@ -1,9 +1,19 @@
 package kratos
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	nethttp "net/http"
+	"net/url"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/health"
+	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
 	"github.com/go-kratos/kratos/v2/transport/http"
 )
@@ -23,3 +33,470 @@ func TestApp(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestAppHooks(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		hooks []string
+	)
+	hook := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			hooks = append(hooks, name)
+			return nil
+		}
+	}
+	app := New(
+		Name("kratos"),
+		Server(http.NewServer()),
+		BeforeStart(hook("before start 1")),
+		BeforeStart(hook("before start 2")),
+		AfterStart(hook("after start")),
+		BeforeStop(hook("before stop")),
+		AfterStop(hook("after stop")),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"before start 1", "before start 2", "after start", "before stop", "after stop"}
+	if !reflect.DeepEqual(hooks, want) {
+		t.Fatalf("got %v want %v", hooks, want)
+	}
+}
+
+func TestAppBeforeStartError(t *testing.T) {
+	want := errors.New("migration failed")
+	app := New(
+		Server(http.NewServer()),
+		BeforeStart(func(context.Context) error { return want }),
+	)
+	if err := app.Run(); err != want {
+		t.Fatalf("got %v want %v", err, want)
+	}
+}
+
+type mockRegistrar struct {
+	mu    sync.Mutex
+	calls []string
+	ins   *registry.ServiceInstance
+}
+
+func (r *mockRegistrar) Register(ctx context.Context, ins *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("register without timeout")
+	}
+	r.calls = append(r.calls, "register")
+	r.ins = ins
+	return nil
+}
+
+func (r *mockRegistrar) Deregister(ctx context.Context, ins *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "deregister")
+	return nil
+}
+
+func TestAppRegistrar(t *testing.T) {
+	r := &mockRegistrar{}
+	app := New(
+		ID("1"),
+		Name("kratos"),
+		Version("v1.0.0"),
+		Metadata(map[string]string{"zone": "sh"}),
+		Server(http.NewServer(), grpc.NewServer()),
+		Registrar(r),
+		RegistrarTimeout(time.Second),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !reflect.DeepEqual(r.calls, []string{"register", "deregister"}) {
+		t.Fatalf("unexpected calls: %v", r.calls)
+	}
+	if r.ins.ID != "1" || r.ins.Name != "kratos" || r.ins.Version != "v1.0.0" || r.ins.Metadata["zone"] != "sh" {
+		t.Fatalf("unexpected instance: %+v", r.ins)
+	}
+	if len(r.ins.Endpoints) != 2 {
+		t.Fatalf("unexpected endpoints: %v", r.ins.Endpoints)
+	}
+	for _, e := range r.ins.Endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Port() == "" || u.Port() == "0" {
+			t.Fatalf("endpoint without a real port: %s", e)
+		}
+	}
+}
+
+func TestAppRegistrars(t *testing.T) {
+	r1, r2 := &mockRegistrar{}, &mockRegistrar{}
+	app := New(
+		Server(http.NewServer()),
+		Registrar(r1, r2),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*mockRegistrar{r1, r2} {
+		r.mu.Lock()
+		if !reflect.DeepEqual(r.calls, []string{"register", "deregister"}) {
+			t.Errorf("unexpected calls: %v", r.calls)
+		}
+		r.mu.Unlock()
+	}
+}
+
+type hangServer struct {
+	stop chan struct{}
+}
+
+func (s *hangServer) Endpoint() (string, error) { return "", nil }
+func (s *hangServer) Start() error {
+	<-s.stop
+	return nil
+}
+func (s *hangServer) Stop() error {
+	<-s.stop // never returns until the test ends
+	return nil
+}
+
+func TestAppStopTimeout(t *testing.T) {
+	srv := &hangServer{stop: make(chan struct{})}
+	defer close(srv.stop)
+	app := New(
+		Server(srv),
+		Signal(),
+		StopTimeout(100*time.Millisecond),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != ErrStopTimeout {
+		t.Fatalf("got %v want %v", err, ErrStopTimeout)
+	}
+}
+
+func TestAppContext(t *testing.T) {
+	r := &mockRegistrar{}
+	ctx, cancel := context.WithCancel(context.Background())
+	app := New(
+		Context(ctx),
+		Server(http.NewServer()),
+		Registrar(r),
+	)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !reflect.DeepEqual(r.calls, []string{"register", "deregister"}) {
+		t.Fatalf("unexpected calls: %v", r.calls)
+	}
+}
+
+func TestAppHealth(t *testing.T) {
+	r := &mockRegistrar{}
+	h := health.New()
+	h.Set("db", health.StatusNotServing)
+	app := New(
+		Server(http.NewServer(http.Health(h))),
+		Registrar(r),
+		Health(h),
+	)
+	calls := func() []string {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return append([]string(nil), r.calls...)
+	}
+	time.AfterFunc(100*time.Millisecond, func() {
+		if c := calls(); len(c) != 0 {
+			t.Errorf("registered before ready: %v", c)
+		}
+		h.Set("db", health.StatusServing)
+	})
+	time.AfterFunc(200*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if c := calls(); !reflect.DeepEqual(c, []string{"register", "deregister"}) {
+		t.Fatalf("unexpected calls: %v", c)
+	}
+	if h.Status() != health.StatusNotServing {
+		t.Fatalf("expected not serving after stop, got %s", h.Status())
+	}
+}
+
+func TestAppHealthNotReady(t *testing.T) {
+	r := &mockRegistrar{}
+	h := health.New()
+	h.Set("db", health.StatusNotServing)
+	app := New(
+		Server(http.NewServer()),
+		Registrar(r),
+		Health(h),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.calls) != 0 {
+		t.Fatalf("unexpected calls: %v", r.calls)
+	}
+}
+
+type drainServer struct {
+	stop    chan struct{}
+	stopped time.Time
+}
+
+func (s *drainServer) Endpoint() (string, error) { return "http://127.0.0.1:8000", nil }
+func (s *drainServer) Start() error {
+	<-s.stop
+	return nil
+}
+func (s *drainServer) Stop() error {
+	s.stopped = time.Now()
+	close(s.stop)
+	return nil
+}
+
+func TestAppDrainPeriod(t *testing.T) {
+	var (
+		r        = &mockRegistrar{}
+		h        = health.New()
+		srv      = &drainServer{stop: make(chan struct{})}
+		stopping time.Time
+		status   = make(chan health.Status, 1)
+	)
+	app := New(
+		Server(srv),
+		Registrar(r),
+		Health(h),
+		DrainPeriod(200*time.Millisecond),
+		BeforeStop(func(context.Context) error {
+			stopping = time.Now()
+			return nil
+		}),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	time.AfterFunc(200*time.Millisecond, func() {
+		status <- h.Status()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !reflect.DeepEqual(r.calls, []string{"register", "deregister"}) {
+		t.Fatalf("unexpected calls: %v", r.calls)
+	}
+	if s := <-status; s != health.StatusNotServing {
+		t.Errorf("want not serving while draining, got %s", s)
+	}
+	if d := srv.stopped.Sub(stopping); d < 200*time.Millisecond {
+		t.Errorf("the servers are stopped %s after stopping, want the drain period", d)
+	}
+}
+
+// orderServer records its stop into the calls of the registrar.
+type orderServer struct {
+	name string
+	r    *mockRegistrar
+	err  error
+	stop chan struct{}
+}
+
+func (s *orderServer) Endpoint() (string, error) { return "http://127.0.0.1:8000", nil }
+func (s *orderServer) Start() error {
+	if s.err != nil {
+		// the server fails once the instance is registered.
+		time.Sleep(100 * time.Millisecond)
+		return s.err
+	}
+	<-s.stop
+	return nil
+}
+func (s *orderServer) Stop() error {
+	s.r.mu.Lock()
+	s.r.calls = append(s.r.calls, "stop "+s.name)
+	s.r.mu.Unlock()
+	if s.err == nil {
+		close(s.stop)
+	}
+	return nil
+}
+
+func TestAppStopOrder(t *testing.T) {
+	failed := errors.New("listen failed")
+	tests := []struct {
+		name string
+		err  error
+		stop bool
+	}{
+		{"stop", nil, true},
+		{"server failure", failed, false},
+	}
+	for _, test := range tests {
+		r := &mockRegistrar{}
+		app := New(
+			Server(
+				&orderServer{name: "a", r: r, stop: make(chan struct{})},
+				&orderServer{name: "b", r: r, err: test.err, stop: make(chan struct{})},
+			),
+			Registrar(r),
+		)
+		if test.stop {
+			time.AfterFunc(100*time.Millisecond, func() {
+				app.Stop()
+			})
+		}
+		if err := app.Run(); err != test.err {
+			t.Errorf("%s: got %v want %v", test.name, err, test.err)
+		}
+		r.mu.Lock()
+		if want := []string{"register", "deregister", "stop a", "stop b"}; !reflect.DeepEqual(r.calls, want) {
+			t.Errorf("%s: got %v want %v", test.name, r.calls, want)
+		}
+		r.mu.Unlock()
+	}
+}
+
+func TestAppWarmup(t *testing.T) {
+	var (
+		r      = &mockRegistrar{}
+		h      = health.New()
+		srv    = http.NewServer(http.Health(h))
+		status health.Status
+		mu     sync.Mutex
+		calls  []string
+	)
+	srv.HandleFunc("/warmup", func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		mu.Lock()
+		calls = append(calls, "request")
+		mu.Unlock()
+	})
+	app := New(
+		Server(srv),
+		Registrar(r),
+		Health(h),
+		Warmup(func(ctx context.Context) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			mu.Lock()
+			calls = append(calls, fmt.Sprintf("warmup %d", len(r.calls)))
+			mu.Unlock()
+			status = h.Status()
+			return nil
+		}),
+		Warmup(http.Warmup(srv, "/warmup")),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(calls, []string{"warmup 0", "request"}) {
+		t.Fatalf("unexpected warmup calls: %v", calls)
+	}
+	if status != health.StatusNotServing {
+		t.Errorf("want not serving while warming up, got %s", status)
+	}
+	if !reflect.DeepEqual(r.calls, []string{"register", "deregister"}) {
+		t.Fatalf("unexpected calls: %v", r.calls)
+	}
+}
+
+func TestAppWarmupError(t *testing.T) {
+	r := &mockRegistrar{}
+	want := errors.New("warmup failed")
+	app := New(
+		Server(http.NewServer()),
+		Registrar(r),
+		Warmup(func(ctx context.Context) error { return want }),
+	)
+	if err := app.Run(); err != want {
+		t.Fatalf("got %v want %v", err, want)
+	}
+	if len(r.calls) != 0 {
+		t.Fatalf("unexpected calls: %v", r.calls)
+	}
+}
+
+func TestAppEndpointError(t *testing.T) {
+	hs := http.NewServer(http.Address("127.0.0.1:0"))
+	app := New(Server(hs, grpc.NewServer(grpc.Network("bogus"))))
+	if err := app.Run(); err == nil {
+		t.Fatal("got nil want the error of the endpoint")
+	}
+	endpoint, err := hs.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn, err := net.Dial("tcp", u.Host); err == nil {
+		conn.Close()
+		t.Errorf("got the listener of %s open want it closed", u.Host)
+	}
+}
+
+func TestAppInfo(t *testing.T) {
+	var info AppInfo
+	app := New(
+		ID("1"),
+		Name("kratos"),
+		Version("v1.0.0"),
+		Metadata(map[string]string{"env": "dev"}),
+		Endpoint("http://127.0.0.1:8000"),
+		BeforeStart(func(ctx context.Context) error {
+			info, _ = FromContext(ctx)
+			return nil
+		}),
+	)
+	time.AfterFunc(100*time.Millisecond, func() {
+		app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("missing the app info in the hook context")
+	}
+	if info.ID() != "1" || info.Name() != "kratos" || info.Version() != "v1.0.0" ||
+		info.Metadata()["env"] != "dev" || !reflect.DeepEqual(info.Endpoint(), []string{"http://127.0.0.1:8000"}) {
+		t.Errorf("unexpected app info: %v %v %v %v %v", info.ID(), info.Name(), info.Version(), info.Metadata(), info.Endpoint())
+	}
+}
@@ -0,0 +1,63 @@
+// Package broker provides the publishing and subscribing of the message queues, the
+// messages are encoded by the codecs and go through the middleware like the RPC requests,
+// so that the event driven services share the same metadata, tracing and logging.
+package broker
+
+import (
+	"context"
+)
+
+// Message is a message of the broker.
+type Message struct {
+	// Key is the partition or routing key of the message, if any.
+	Key    string
+	Header map[string]string
+	Body   []byte
+}
+
+// Handler handles the messages of a subscription, the error is handled by the broker,
+// i.e. the message is redelivered or logged.
+type Handler func(ctx context.Context, topic string, msg *Message) error
+
+// Subscriber is a subscription of a topic.
+type Subscriber interface {
+	Topic() string
+	Unsubscribe() error
+}
+
+// Broker publishes and subscribes the raw messages, which is implemented by the message queues.
+type Broker interface {
+	// Endpoint returns the address of the broker, i.e. kafka://127.0.0.1:9092.
+	Endpoint() string
+	Publish(ctx context.Context, topic string, msg *Message) error
+	// Subscribe delivers the messages of the topic to the handler in the background
+	// until it is unsubscribed.
+	Subscribe(topic string, h Handler, opts ...SubscribeOption) (Subscriber, error)
+	Close() error
+}
+
+// SubscribeOption is subscribe option.
+type SubscribeOption func(*SubscribeOptions)
+
+// SubscribeOptions is the options of a subscription, which are applied by the brokers.
+type SubscribeOptions struct {
+	// Queue is the group of the subscribers sharing the messages, each message is
+	// delivered to one of them, i.e. the consumer group of kafka.
+	Queue string
+}
+
+// Queue with the queue group of the subscription.
+func Queue(name string) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		o.Queue = name
+	}
+}
+
+// NewSubscribeOptions returns the options applied by the brokers.
+func NewSubscribeOptions(opts ...SubscribeOption) SubscribeOptions {
+	o := SubscribeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,226 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type testBroker struct {
+	mu   sync.Mutex
+	subs map[string][]Handler
+	opts []SubscribeOptions
+	err  error
+}
+
+func newTestBroker() *testBroker {
+	return &testBroker{subs: map[string][]Handler{}}
+}
+
+func (b *testBroker) Endpoint() string {
+	return "test://broker"
+}
+
+func (b *testBroker) Publish(ctx context.Context, topic string, msg *Message) error {
+	b.mu.Lock()
+	hs := b.subs[topic]
+	b.mu.Unlock()
+	for _, h := range hs {
+		if err := h(context.Background(), topic, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *testBroker) Subscribe(topic string, h Handler, opts ...SubscribeOption) (Subscriber, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[topic] = append(b.subs[topic], h)
+	b.opts = append(b.opts, NewSubscribeOptions(opts...))
+	return &testSubscriber{b: b, topic: topic}, nil
+}
+
+func (b *testBroker) Close() error {
+	return nil
+}
+
+type testSubscriber struct {
+	b     *testBroker
+	topic string
+}
+
+func (s *testSubscriber) Topic() string {
+	return s.topic
+}
+
+func (s *testSubscriber) Unsubscribe() error {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	delete(s.b.subs, s.topic)
+	return nil
+}
+
+type event struct {
+	ID string `json:"id"`
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b := newTestBroker()
+	var kinds []string
+	trace := func(kind string) middleware.Middleware {
+		return func(h middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				var (
+					tr transport.Transporter
+					ok bool
+				)
+				if kind == "server" {
+					tr, ok = transport.FromServerContext(ctx)
+				} else {
+					tr, ok = transport.FromClientContext(ctx)
+					tr.RequestHeader().Set("x-md-trace", "1")
+				}
+				if !ok || tr.Kind() != transport.KindBroker || tr.Operation() != "events" {
+					t.Errorf("%s: unexpected transport %v", kind, tr)
+				}
+				kinds = append(kinds, kind)
+				return h(ctx, req)
+			}
+		}
+	}
+	srv := NewServer(b, Middleware(trace("server")))
+	got := make(chan *event, 1)
+	var header string
+	srv.Subscribe("events", func() interface{} { return new(event) }, func(ctx context.Context, req interface{}) error {
+		tr, _ := transport.FromServerContext(ctx)
+		header = tr.RequestHeader().Get("x-md-trace")
+		got <- req.(*event)
+		return nil
+	}, Queue("group"))
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start()
+	}()
+	for {
+		b.mu.Lock()
+		n := len(b.subs["events"])
+		b.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	p := NewPublisher(b, WithMiddleware(trace("client")))
+	if err := p.Publish(context.Background(), "events", &event{ID: "1"}, Key("k")); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-got; e.ID != "1" {
+		t.Errorf("expected event 1, got %+v", e)
+	}
+	if header != "1" {
+		t.Errorf("expected the header of the middleware, got %q", header)
+	}
+	if !reflect.DeepEqual(kinds, []string{"client", "server"}) {
+		t.Errorf("unexpected middleware %v", kinds)
+	}
+	if b.opts[0].Queue != "group" {
+		t.Errorf("expected queue group, got %q", b.opts[0].Queue)
+	}
+	routes := srv.Routes()
+	if len(routes) != 1 || routes[0].Kind != transport.KindBroker || routes[0].Operation != "events" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(b.subs["events"]) != 0 {
+		t.Error("expected the topic to be unsubscribed")
+	}
+}
+
+func TestPublishHeader(t *testing.T) {
+	b := newTestBroker()
+	var msg *Message
+	b.subs["events"] = []Handler{func(ctx context.Context, topic string, m *Message) error {
+		msg = m
+		return nil
+	}}
+	p := NewPublisher(b)
+	if err := p.Publish(context.Background(), "events", &event{ID: "1"}, Key("k"), Header("x-md-a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	want := &Message{
+		Key:    "k",
+		Header: map[string]string{"x-md-a": "b", ContentTypeHeader: "application/json"},
+		Body:   []byte(`{"id":"1"}`),
+	}
+	if !reflect.DeepEqual(msg, want) {
+		t.Errorf("expected %+v, got %+v", want, msg)
+	}
+}
+
+func TestServerHandleError(t *testing.T) {
+	b := newTestBroker()
+	srv := NewServer(b)
+	herr := errors.New("handle")
+	h := srv.handle(&subscription{
+		topic:  "events",
+		newReq: func() interface{} { return new(event) },
+		handler: func(ctx context.Context, req interface{}) error {
+			return herr
+		},
+	})
+	if err := h(context.Background(), "events", &Message{Body: []byte(`{"id":"1"}`)}); err != herr {
+		t.Errorf("expected %v, got %v", herr, err)
+	}
+	if err := h(context.Background(), "events", &Message{Body: []byte(`{`)}); err == nil {
+		t.Error("expected a decode error")
+	}
+}
+
+func TestServerStartError(t *testing.T) {
+	b := newTestBroker()
+	b.err = errors.New("subscribe")
+	srv := NewServer(b)
+	srv.Subscribe("events", func() interface{} { return new(event) }, func(ctx context.Context, req interface{}) error {
+		return nil
+	})
+	if err := srv.Start(); err != b.err {
+		t.Errorf("expected %v, got %v", b.err, err)
+	}
+}
+
+func TestServerTopic(t *testing.T) {
+	srv := NewServer(newTestBroker())
+	var operation, topic string
+	h := srv.handle(&subscription{
+		topic:  "orders.*",
+		newReq: func() interface{} { return new(event) },
+		handler: func(ctx context.Context, req interface{}) error {
+			tr, _ := transport.FromServerContext(ctx)
+			operation, topic = tr.Operation(), tr.(*Transport).Topic()
+			return nil
+		},
+	})
+	if err := h(context.Background(), "orders.created", &Message{Body: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if operation != "orders.*" || topic != "orders.created" {
+		t.Errorf("got %s %s want the subscribed topic and the topic of the message", operation, topic)
+	}
+}
@@ -0,0 +1,92 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/broker"
+)
+
+// ContentType is the content type of the structured mode.
+const ContentType = "application/cloudevents+json"
+
+// DefaultPrefix is the default prefix of the attribute headers of the binary mode, i.e.
+// ce-id, the kafka binding uses "ce_".
+const DefaultPrefix = "ce-"
+
+// ErrNotEvent is returned by decoding a message which is not a cloud event.
+var ErrNotEvent = errors.New("cloudevents: not a cloud event")
+
+// Mode is the content mode of the events.
+type Mode int
+
+const (
+	// Binary carries the attributes in the headers and the data in the body.
+	Binary Mode = iota
+	// Structured carries the JSON encoded event in the body.
+	Structured
+)
+
+// Encode encodes the event as a message of the mode, the attributes of the binary mode
+// are the headers of the prefix.
+func Encode(e *Event, mode Mode, prefix string) (*broker.Message, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	if mode == Structured {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		return &broker.Message{
+			Header: map[string]string{broker.ContentTypeHeader: ContentType},
+			Body:   body,
+		}, nil
+	}
+	attrs := e.attributes()
+	msg := &broker.Message{
+		Header: make(map[string]string, len(attrs)+1),
+		Body:   e.Data,
+	}
+	for k, v := range attrs {
+		msg.Header[prefix+k] = v
+	}
+	if e.DataContentType != "" {
+		msg.Header[broker.ContentTypeHeader] = e.DataContentType
+	}
+	return msg, nil
+}
+
+// Decode decodes the event of the message of either mode, ErrNotEvent is returned if it
+// is neither of them.
+func Decode(msg *broker.Message, prefix string) (*Event, error) {
+	e := &Event{}
+	if IsStructured(msg) {
+		if err := json.Unmarshal(msg.Body, e); err != nil {
+			return nil, err
+		}
+		return e, e.Validate()
+	}
+	if _, ok := msg.Header[prefix+"specversion"]; !ok {
+		return nil, ErrNotEvent
+	}
+	for k, v := range msg.Header {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if err := e.setAttribute(strings.TrimPrefix(k, prefix), v); err != nil {
+			return nil, err
+		}
+	}
+	e.DataContentType = msg.Header[broker.ContentTypeHeader]
+	e.Data = msg.Body
+	return e, e.Validate()
+}
+
+// IsStructured reports whether the message is a structured event.
+func IsStructured(msg *broker.Message) bool {
+	mediaType, _, err := mime.ParseMediaType(msg.Header[broker.ContentTypeHeader])
+	return err == nil && mediaType == ContentType
+}
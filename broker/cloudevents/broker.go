@@ -0,0 +1,170 @@
+package cloudevents
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+
+	"github.com/google/uuid"
+)
+
+var _ broker.Broker = (*Broker)(nil)
+
+// The headers of the trace context, which are set by the tracing middleware and carried
+// by the extensions of the same names.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// Option is cloudevents broker option.
+type Option func(*options)
+
+type options struct {
+	mode   Mode
+	prefix string
+	source string
+}
+
+// WithMode with the content mode of the published events, Binary by default.
+func WithMode(m Mode) Option {
+	return func(o *options) {
+		o.mode = m
+	}
+}
+
+// WithPrefix with the prefix of the attribute headers, DefaultPrefix by default.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// Broker publishes the messages as the cloud events and decodes the subscribed ones.
+//
+// The attributes of a published event are taken from the headers of the prefix, i.e.
+// ce-type, and default to a random id, the source of the broker, the topic as the type
+// and the current time. The content type of the message is the datacontenttype, and the
+// trace context headers are the extensions of the event. The other headers are sent as
+// they are.
+//
+// The decoded messages carry the attributes as the headers of the prefix, the data as
+// the body and the datacontenttype as the content type, along with the trace context
+// headers of the extensions, so that the handlers and the middleware see the same
+// message whatever the mode is. The messages which are not cloud events are delivered
+// as they are.
+type Broker struct {
+	broker.Broker
+	opts options
+}
+
+// NewBroker wraps the broker to publish and subscribe the cloud events, the source is
+// the default source of the published events, i.e. the name of the service.
+func NewBroker(b broker.Broker, source string, opts ...Option) *Broker {
+	if source == "" {
+		panic("cloudevents: empty source")
+	}
+	o := options{
+		prefix: DefaultPrefix,
+		source: source,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Broker{Broker: b, opts: o}
+}
+
+// Publish publishes the message as a cloud event.
+func (b *Broker) Publish(ctx context.Context, topic string, msg *broker.Message) error {
+	e, header, err := b.event(topic, msg)
+	if err != nil {
+		return err
+	}
+	m, err := Encode(e, b.opts.mode, b.opts.prefix)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		m.Header[k] = v
+	}
+	m.Key = msg.Key
+	return b.Broker.Publish(ctx, topic, m)
+}
+
+// Subscribe subscribes the topic, the cloud events are decoded before they are handled.
+func (b *Broker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	return b.Broker.Subscribe(topic, func(ctx context.Context, topic string, msg *broker.Message) error {
+		m, err := b.message(msg)
+		if err != nil {
+			return err
+		}
+		return h(ctx, topic, m)
+	}, opts...)
+}
+
+// event returns the event of the message and the headers which are not attributes.
+func (b *Broker) event(topic string, msg *broker.Message) (*Event, map[string]string, error) {
+	e := &Event{
+		SpecVersion:     SpecVersion,
+		DataContentType: msg.Header[broker.ContentTypeHeader],
+		Data:            msg.Body,
+	}
+	header := make(map[string]string, len(msg.Header))
+	for k, v := range msg.Header {
+		switch {
+		case k == broker.ContentTypeHeader:
+		case k == TraceParentHeader || k == TraceStateHeader:
+			if err := e.setAttribute(k, v); err != nil {
+				return nil, nil, err
+			}
+		case strings.HasPrefix(k, b.opts.prefix):
+			if err := e.setAttribute(strings.TrimPrefix(k, b.opts.prefix), v); err != nil {
+				return nil, nil, err
+			}
+		default:
+			header[k] = v
+		}
+	}
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	if e.Source == "" {
+		e.Source = b.opts.source
+	}
+	if e.Type == "" {
+		e.Type = topic
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	return e, header, nil
+}
+
+// message returns the decoded message of the event, or the message itself if it is not
+// a cloud event.
+func (b *Broker) message(msg *broker.Message) (*broker.Message, error) {
+	e, err := Decode(msg, b.opts.prefix)
+	if err == ErrNotEvent {
+		return msg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, _ := Encode(e, Binary, b.opts.prefix)
+	m.Key = msg.Key
+	structured := IsStructured(msg)
+	for k, v := range msg.Header {
+		if _, ok := m.Header[k]; ok || k == broker.ContentTypeHeader || (!structured && strings.HasPrefix(k, b.opts.prefix)) {
+			continue
+		}
+		m.Header[k] = v
+	}
+	for _, k := range []string{TraceParentHeader, TraceStateHeader} {
+		if v, ok := e.Extensions[k]; ok {
+			m.Header[k] = v
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,183 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func testEvent() *Event {
+	return &Event{
+		ID:              "1",
+		Source:          "/orders",
+		SpecVersion:     SpecVersion,
+		Type:            "order.created",
+		Subject:         "42",
+		DataContentType: "application/json",
+		Time:            time.Date(2021, 7, 30, 10, 0, 0, 0, time.UTC),
+		Extensions:      map[string]string{"traceparent": traceparent},
+		Data:            []byte(`{"id":"42"}`),
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, mode := range []Mode{Binary, Structured} {
+		want := testEvent()
+		msg, err := Encode(want, mode, "ce_")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Decode(msg, "ce_")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: expected %+v, got %+v", mode, want, got)
+		}
+	}
+	msg, _ := Encode(testEvent(), Binary, DefaultPrefix)
+	if msg.Header["ce-traceparent"] != traceparent || msg.Header["ce-specversion"] != "1.0" || string(msg.Body) != `{"id":"42"}` {
+		t.Errorf("unexpected binary message %+v", msg)
+	}
+	if _, err := Decode(&broker.Message{Body: []byte("{}")}, DefaultPrefix); err != ErrNotEvent {
+		t.Errorf("expected ErrNotEvent, got %v", err)
+	}
+	if _, err := Encode(&Event{SpecVersion: SpecVersion, ID: "1", Type: "t"}, Binary, DefaultPrefix); err == nil {
+		t.Error("expected an error of the missing source")
+	}
+}
+
+func TestStructuredJSON(t *testing.T) {
+	msg, err := Encode(testEvent(), Structured, DefaultPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(msg.Body, &m); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header[broker.ContentTypeHeader] != ContentType || m["traceparent"] != traceparent || m["time"] != "2021-07-30T10:00:00Z" {
+		t.Errorf("unexpected structured message %s", msg.Body)
+	}
+	if data, ok := m["data"].(map[string]interface{}); !ok || data["id"] != "42" {
+		t.Errorf("expected the embedded JSON data, got %v", m["data"])
+	}
+	e := testEvent()
+	e.DataContentType, e.Data = "application/octet-stream", []byte{0xff}
+	msg, _ = Encode(e, Structured, DefaultPrefix)
+	got, err := Decode(msg, DefaultPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Data, []byte{0xff}) {
+		t.Errorf("expected data_base64 to be decoded, got %v", got.Data)
+	}
+}
+
+type testBroker struct {
+	msgs       []*broker.Message
+	h          broker.Handler
+	subscribed chan struct{}
+}
+
+func (b *testBroker) Endpoint() string { return "test://" }
+
+func (b *testBroker) Publish(ctx context.Context, topic string, msg *broker.Message) error {
+	b.msgs = append(b.msgs, msg)
+	return b.h(ctx, topic, msg)
+}
+
+func (b *testBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	b.h = h
+	close(b.subscribed)
+	return testSubscriber(topic), nil
+}
+
+func (b *testBroker) Close() error { return nil }
+
+type testSubscriber string
+
+func (s testSubscriber) Topic() string { return string(s) }
+
+func (s testSubscriber) Unsubscribe() error { return nil }
+
+type order struct {
+	ID string `json:"id"`
+}
+
+func TestBroker(t *testing.T) {
+	for _, mode := range []Mode{Binary, Structured} {
+		tb := &testBroker{subscribed: make(chan struct{})}
+		b := NewBroker(tb, "/orders", WithMode(mode))
+		var header transport.Header
+		srv := broker.NewServer(b)
+		srv.Subscribe("orders", func() interface{} { return new(order) }, func(ctx context.Context, req interface{}) error {
+			tr, _ := transport.FromServerContext(ctx)
+			header = tr.RequestHeader()
+			if req.(*order).ID != "42" {
+				t.Errorf("unexpected order %+v", req)
+			}
+			return nil
+		})
+		go func() {
+			_ = srv.Start()
+		}()
+		<-tb.subscribed
+		trace := func(h middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				tr, _ := transport.FromClientContext(ctx)
+				tr.RequestHeader().Set(TraceParentHeader, traceparent)
+				return h(ctx, req)
+			}
+		}
+		p := broker.NewPublisher(b, broker.WithMiddleware(trace))
+		err := p.Publish(context.Background(), "orders", &order{ID: "42"}, broker.Header("ce-type", "order.created"), broker.Header("x-md-a", "b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = srv.Stop()
+
+		raw := tb.msgs[0]
+		if mode == Binary && (raw.Header["ce-traceparent"] != traceparent || raw.Header["ce-source"] != "/orders" || raw.Header[TraceParentHeader] != "") {
+			t.Errorf("unexpected binary message %+v", raw)
+		}
+		if mode == Structured && raw.Header[broker.ContentTypeHeader] != ContentType {
+			t.Errorf("unexpected structured message %+v", raw)
+		}
+		if raw.Header["x-md-a"] != "b" {
+			t.Errorf("expected the other headers to be sent, got %+v", raw.Header)
+		}
+		for k, want := range map[string]string{
+			TraceParentHeader:        traceparent,
+			"ce-traceparent":         traceparent,
+			"ce-type":                "order.created",
+			"ce-source":              "/orders",
+			"x-md-a":                 "b",
+			broker.ContentTypeHeader: "application/json",
+		} {
+			if got := header.Get(k); got != want {
+				t.Errorf("%d: expected the header %s to be %s, got %s", mode, k, want, got)
+			}
+		}
+		if header.Get("ce-id") == "" || header.Get("ce-time") == "" {
+			t.Errorf("expected the default id and time, got %v", header.Keys())
+		}
+	}
+}
+
+func TestBrokerSource(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected the panic of the empty source")
+		}
+	}()
+	NewBroker(&testBroker{}, "")
+}
@@ -0,0 +1,188 @@
+// Package cloudevents encodes the broker messages as the CloudEvents of the binary or the
+// structured mode, the trace context of the messages is carried by the traceparent and
+// tracestate extensions of the distributed tracing extension.
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the supported version of the CloudEvents specification.
+const SpecVersion = "1.0"
+
+// Event is a cloud event.
+type Event struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	DataContentType string
+	DataSchema      string
+	Time            time.Time
+	// Extensions is the extension attributes, i.e. traceparent.
+	Extensions map[string]string
+	Data       []byte
+}
+
+// Validate reports the missing required attributes.
+func (e *Event) Validate() error {
+	switch {
+	case e.ID == "":
+		return errors.New("cloudevents: id is required")
+	case e.Source == "":
+		return errors.New("cloudevents: source is required")
+	case e.SpecVersion != SpecVersion:
+		return fmt.Errorf("cloudevents: unsupported specversion %q", e.SpecVersion)
+	case e.Type == "":
+		return errors.New("cloudevents: type is required")
+	}
+	return nil
+}
+
+// attributes returns the context attributes except the datacontenttype, including the extensions.
+func (e *Event) attributes() map[string]string {
+	attrs := make(map[string]string, len(e.Extensions)+7)
+	for k, v := range e.Extensions {
+		attrs[k] = v
+	}
+	for k, v := range map[string]string{
+		"id":          e.ID,
+		"source":      e.Source,
+		"specversion": e.SpecVersion,
+		"type":        e.Type,
+		"subject":     e.Subject,
+		"dataschema":  e.DataSchema,
+	} {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+	if !e.Time.IsZero() {
+		attrs["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	return attrs
+}
+
+// setAttribute sets a context attribute other than the datacontenttype, the unknown ones
+// are the extensions.
+func (e *Event) setAttribute(k, v string) error {
+	switch k {
+	case "id":
+		e.ID = v
+	case "source":
+		e.Source = v
+	case "specversion":
+		e.SpecVersion = v
+	case "type":
+		e.Type = v
+	case "subject":
+		e.Subject = v
+	case "dataschema":
+		e.DataSchema = v
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("cloudevents: invalid time %q: %w", v, err)
+		}
+		e.Time = t
+	default:
+		if e.Extensions == nil {
+			e.Extensions = map[string]string{}
+		}
+		e.Extensions[k] = v
+	}
+	return nil
+}
+
+// MarshalJSON encodes the event in the JSON format of the structured mode, the data of the
+// JSON content type is embedded, and the others are encoded as data_base64.
+func (e *Event) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(e.Extensions)+9)
+	for k, v := range e.attributes() {
+		m[k] = v
+	}
+	if e.DataContentType != "" {
+		m["datacontenttype"] = e.DataContentType
+	}
+	if e.Data != nil {
+		if isJSON(e.DataContentType) && json.Valid(e.Data) {
+			m["data"] = json.RawMessage(e.Data)
+		} else {
+			m["data_base64"] = e.Data
+		}
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes the event of the JSON format.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*e = Event{}
+	for k, raw := range m {
+		switch k {
+		case "data":
+			if isJSON(e.contentType(m)) {
+				e.Data = []byte(raw)
+				continue
+			}
+			// the data of the other content types is a JSON string.
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				e.Data = []byte(raw)
+				continue
+			}
+			e.Data = []byte(s)
+		case "data_base64":
+			if err := json.Unmarshal(raw, &e.Data); err != nil {
+				return fmt.Errorf("cloudevents: invalid data_base64: %w", err)
+			}
+		case "datacontenttype":
+			if err := json.Unmarshal(raw, &e.DataContentType); err != nil {
+				return fmt.Errorf("cloudevents: invalid datacontenttype: %w", err)
+			}
+		default:
+			var v interface{}
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return err
+			}
+			s, ok := v.(string)
+			if !ok {
+				// the extensions of the other types are kept as their JSON values.
+				s = string(bytes.TrimSpace(raw))
+			}
+			if err := e.setAttribute(k, s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *Event) contentType(m map[string]json.RawMessage) string {
+	var ct string
+	if raw, ok := m["datacontenttype"]; ok {
+		_ = json.Unmarshal(raw, &ct)
+		return ct
+	}
+	// the data is JSON if the content type is absent.
+	return "application/json"
+}
+
+// isJSON reports whether the media type is JSON, i.e. application/json or application/foo+json.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,101 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/internal/httputil"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	// init json encoding
+	_ "github.com/go-kratos/kratos/v2/encoding/json"
+)
+
+// ContentTypeHeader is the header of the content type of the message body.
+const ContentTypeHeader = "content-type"
+
+// PublisherOption is publisher option.
+type PublisherOption func(*Publisher)
+
+// WithCodec with the codec of the published messages, json by default.
+func WithCodec(c encoding.Codec) PublisherOption {
+	return func(p *Publisher) {
+		p.codec = c
+	}
+}
+
+// WithMiddleware with client middleware.
+func WithMiddleware(m middleware.Middleware) PublisherOption {
+	return func(p *Publisher) {
+		p.middleware = m
+	}
+}
+
+// PublishOption is the option of a published message.
+type PublishOption func(*Message)
+
+// Key with the partition or routing key of the message.
+func Key(key string) PublishOption {
+	return func(m *Message) {
+		m.Key = key
+	}
+}
+
+// Header with a header of the message.
+func Header(key, value string) PublishOption {
+	return func(m *Message) {
+		m.Header[key] = value
+	}
+}
+
+// Publisher encodes the messages and publishes them through the client middleware.
+type Publisher struct {
+	b          Broker
+	codec      encoding.Codec
+	middleware middleware.Middleware
+}
+
+// NewPublisher returns a publisher of the broker.
+func NewPublisher(b Broker, opts ...PublisherOption) *Publisher {
+	p := &Publisher{
+		b:     b,
+		codec: encoding.GetCodec("json"),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// Publish encodes v and publishes it to the topic, the headers set by the middleware
+// are sent along with the message.
+func (p *Publisher) Publish(ctx context.Context, topic string, v interface{}, opts ...PublishOption) error {
+	msg := &Message{Header: map[string]string{}}
+	for _, o := range opts {
+		o(msg)
+	}
+	ctx = transport.NewClientContext(ctx, &Transport{
+		endpoint:    p.b.Endpoint(),
+		operation:   topic,
+		topic:       topic,
+		reqHeader:   carrier.Header(msg.Header),
+		replyHeader: carrier.Header{},
+		msg:         msg,
+	})
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		body, err := p.codec.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		msg.Body = body
+		msg.Header[ContentTypeHeader] = httputil.ContentType(p.codec.Name())
+		return nil, p.b.Publish(ctx, topic, msg)
+	}
+	if p.middleware != nil {
+		h = p.middleware(h)
+	}
+	_, err := h(ctx, v)
+	return err
+}
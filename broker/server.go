@@ -0,0 +1,208 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/internal/httputil"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const loggerName = "broker"
+
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
+
+// ServerOption is broker server option.
+type ServerOption func(*Server)
+
+// Codec with the codec of the messages without a known content type, json by default.
+func Codec(c encoding.Codec) ServerOption {
+	return func(s *Server) {
+		s.codec = c
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Middleware with server middleware.
+func Middleware(m middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = m
+	}
+}
+
+// HandlerFunc handles a decoded message.
+type HandlerFunc func(ctx context.Context, req interface{}) error
+
+type subscription struct {
+	topic   string
+	newReq  func() interface{}
+	handler HandlerFunc
+	opts    []SubscribeOption
+}
+
+// Server is a broker server, which decodes the messages of the subscribed topics and
+// handles them through the server middleware.
+type Server struct {
+	b          Broker
+	codec      encoding.Codec
+	log        *log.Helper
+	middleware middleware.Middleware
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu          sync.Mutex
+	subs        []*subscription
+	subscribers []Subscriber
+}
+
+// NewServer creates a broker server by options.
+func NewServer(b Broker, opts ...ServerOption) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &Server{
+		b:          b,
+		codec:      encoding.GetCodec("json"),
+		log:        log.NewHelper(loggerName, log.GetLogger()),
+		middleware: middleware.Named("recovery", recovery.Recovery()),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	return srv
+}
+
+// Subscribe subscribes the topic once the server is started, each message is decoded
+// into the request returned by newReq, i.e. func() interface{} { return new(Event) }.
+func (s *Server) Subscribe(topic string, newReq func() interface{}, h HandlerFunc, opts ...SubscribeOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, &subscription{
+		topic:   topic,
+		newReq:  newReq,
+		handler: h,
+		opts:    opts,
+	})
+}
+
+// Endpoint returns an empty endpoint, the consumers are not registered.
+func (s *Server) Endpoint() (string, error) {
+	return "", nil
+}
+
+// Start subscribes the topics, and blocks until the server is stopped.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	for _, sub := range s.subs {
+		subscriber, err := s.b.Subscribe(sub.topic, s.handle(sub), sub.opts...)
+		if err != nil {
+			s.mu.Unlock()
+			_ = s.Stop()
+			return err
+		}
+		s.subscribers = append(s.subscribers, subscriber)
+	}
+	s.mu.Unlock()
+	s.log.Infof("[Broker] server subscribing on: %s", s.b.Endpoint())
+	<-s.ctx.Done()
+	return nil
+}
+
+// Stop unsubscribes the topics.
+func (s *Server) Stop() error {
+	s.cancel()
+	s.mu.Lock()
+	subscribers := s.subscribers
+	s.subscribers = nil
+	s.mu.Unlock()
+	var err error
+	for _, subscriber := range subscribers {
+		if e := subscriber.Unsubscribe(); e != nil && err == nil {
+			err = e
+		}
+	}
+	s.log.Info("[Broker] server stopping")
+	return err
+}
+
+// Routes returns the subscribed topics and their middleware.
+func (s *Server) Routes() []transport.Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	routes := make([]transport.Route, 0, len(s.subs))
+	for _, sub := range s.subs {
+		ctx := transport.NewServerContext(context.Background(), &Transport{
+			endpoint:    s.b.Endpoint(),
+			operation:   sub.topic,
+			topic:       sub.topic,
+			reqHeader:   carrier.Header{},
+			replyHeader: carrier.Header{},
+		})
+		ms := middleware.Inspect(ctx, s.middleware)
+		routes = append(routes, transport.Route{
+			Kind:       transport.KindBroker,
+			Operation:  sub.topic,
+			Middleware: ms,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Operation < routes[j].Operation
+	})
+	return routes
+}
+
+func (s *Server) handle(sub *subscription) Handler {
+	return func(ctx context.Context, topic string, msg *Message) error {
+		if msg.Header == nil {
+			msg.Header = map[string]string{}
+		}
+		req := sub.newReq()
+		if err := s.codecFor(msg).Unmarshal(msg.Body, req); err != nil {
+			return fmt.Errorf("broker: decode message of %s: %w", topic, err)
+		}
+		// the operation is the subscribed topic, so the middleware selected by the
+		// operation matches the routes.
+		ctx = transport.NewServerContext(ctx, &Transport{
+			endpoint:    s.b.Endpoint(),
+			operation:   sub.topic,
+			topic:       topic,
+			reqHeader:   carrier.Header(msg.Header),
+			replyHeader: carrier.Header{},
+			msg:         msg,
+		})
+		h := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, sub.handler(ctx, req)
+		}
+		if s.middleware != nil {
+			h = s.middleware(h)
+		}
+		_, err := h(ctx, req)
+		return err
+	}
+}
+
+// codecFor returns the codec of the content type of the message, or the server codec
+// if it is unknown.
+func (s *Server) codecFor(msg *Message) encoding.Codec {
+	if codec := encoding.GetCodec(httputil.ContentSubtype(msg.Header[ContentTypeHeader])); codec != nil {
+		return codec
+	}
+	return s.codec
+}
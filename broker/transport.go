@@ -0,0 +1,54 @@
+package broker
+
+import (
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is a broker transport, the operation is the subscribed topic, which may be a
+// pattern, i.e. orders.*, and the request header is the header of the message.
+type Transport struct {
+	endpoint    string
+	operation   string
+	topic       string
+	reqHeader   carrier.Header
+	replyHeader carrier.Header
+	msg         *Message
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindBroker
+}
+
+// Endpoint returns the endpoint of the broker.
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+// Operation returns the subscribed topic of the server, or the topic of the published message.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// Topic returns the topic of the message, i.e. orders.created of the subscribed orders.*.
+func (tr *Transport) Topic() string {
+	return tr.topic
+}
+
+// RequestHeader returns the header of the message.
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+// ReplyHeader returns the reply header, which is not sent.
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+// Message returns the raw message, which is encoded once the publishing middleware is done.
+func (tr *Transport) Message() *Message {
+	return tr.msg
+}
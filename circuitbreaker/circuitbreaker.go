@@ -0,0 +1,16 @@
+package circuitbreaker
+
+import "errors"
+
+// ErrNotAllowed is returned when the circuit breaker is open and the request is rejected.
+var ErrNotAllowed = errors.New("circuitbreaker: not allowed for circuit open")
+
+// CircuitBreaker is a circuit breaker.
+type CircuitBreaker interface {
+	// Allow checks whether the request is allowed.
+	Allow() error
+	// MarkSuccess records a successful request.
+	MarkSuccess()
+	// MarkFailed records a failed request.
+	MarkFailed()
+}
@@ -0,0 +1,122 @@
+package sre
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/circuitbreaker"
+	"github.com/go-kratos/kratos/v2/internal/window"
+)
+
+var _ circuitbreaker.CircuitBreaker = (*Breaker)(nil)
+
+// Option is sre breaker option.
+type Option func(*options)
+
+// WithSuccess with the success ratio, the breaker rejects requests
+// once the success ratio of the window falls below it.
+func WithSuccess(s float64) Option {
+	return func(o *options) {
+		o.success = s
+	}
+}
+
+// WithRequest with the minimum number of requests in the window before the breaker works.
+func WithRequest(r int64) Option {
+	return func(o *options) {
+		o.request = r
+	}
+}
+
+// WithWindow with the duration of the statistic window.
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithBucket with the number of buckets in the statistic window.
+func WithBucket(b int) Option {
+	return func(o *options) {
+		o.bucket = b
+	}
+}
+
+type options struct {
+	success float64
+	request int64
+	window  time.Duration
+	bucket  int
+}
+
+// Breaker is a client-side adaptive throttling breaker, see the Handling
+// Overload chapter of Google SRE, the request is rejected with the probability
+// max(0, (requests - k * accepts) / (requests + 1)), where k = 1 / success.
+type Breaker struct {
+	stat    *window.Window
+	k       float64
+	request int64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewBreaker returns a sre breaker.
+func NewBreaker(opts ...Option) *Breaker {
+	options := options{
+		success: 0.6,
+		request: 100,
+		window:  3 * time.Second,
+		bucket:  10,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Breaker{
+		stat:    window.New(options.bucket, options.window/time.Duration(options.bucket)),
+		k:       1 / options.success,
+		request: options.request,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (b *Breaker) summary() (accepts, total int64) {
+	b.stat.Reduce(func(bucket window.Bucket) {
+		accepts += bucket.Sum
+		total += bucket.Count
+	})
+	return
+}
+
+// Allow checks whether the request is allowed.
+func (b *Breaker) Allow() error {
+	accepts, total := b.summary()
+	requests := b.k * float64(accepts)
+	if total < b.request || float64(total) < requests {
+		return nil
+	}
+	dr := math.Max(0, (float64(total)-requests)/float64(total+1))
+	if dr <= 0 || !b.trueOnProba(dr) {
+		return nil
+	}
+	return circuitbreaker.ErrNotAllowed
+}
+
+// MarkSuccess records a successful request.
+func (b *Breaker) MarkSuccess() {
+	b.stat.Add(1)
+}
+
+// MarkFailed records a failed request.
+func (b *Breaker) MarkFailed() {
+	// the request is counted without being accepted.
+	b.stat.Add(0)
+}
+
+func (b *Breaker) trueOnProba(proba float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rand.Float64() < proba
+}
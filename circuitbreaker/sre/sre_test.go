@@ -0,0 +1,51 @@
+package sre
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/circuitbreaker"
+)
+
+func TestBreakerOpen(t *testing.T) {
+	b := NewBreaker(WithRequest(10), WithWindow(time.Minute))
+	for i := 0; i < 100; i++ {
+		b.MarkFailed()
+	}
+	var rejected int
+	for i := 0; i < 100; i++ {
+		if err := b.Allow(); err == circuitbreaker.ErrNotAllowed {
+			rejected++
+		}
+	}
+	// the reject probability is 100 / 101 as no request is accepted.
+	if rejected < 90 {
+		t.Errorf("expected most requests to be rejected, got %d", rejected)
+	}
+}
+
+func TestBreakerClosed(t *testing.T) {
+	b := NewBreaker(WithRequest(10), WithWindow(time.Minute))
+	for i := 0; i < 70; i++ {
+		b.MarkSuccess()
+	}
+	for i := 0; i < 30; i++ {
+		b.MarkFailed()
+	}
+	// 70% success is above the default 60% success ratio.
+	for i := 0; i < 100; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("request rejected: %v", err)
+		}
+	}
+}
+
+func TestBreakerMinRequest(t *testing.T) {
+	b := NewBreaker(WithRequest(10))
+	for i := 0; i < 9; i++ {
+		b.MarkFailed()
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("request rejected below the minimum requests: %v", err)
+	}
+}
@@ -24,12 +24,23 @@ func NewRepo(url string) *Repo {
 	}
 }
 
+// Path returns the local path of the repository.
+// A url that refers to a local directory is used as is.
 func (r *Repo) Path() string {
+	if r.isLocal() {
+		return r.url
+	}
 	start := strings.LastIndex(r.url, "/")
 	end := strings.LastIndex(r.url, ".git")
 	return path.Join(r.home, r.url[start+1:end])
 }
 
+func (r *Repo) isLocal() bool {
+	fi, err := os.Stat(r.url)
+	return err == nil && fi.IsDir()
+}
+
+// Pull fetches the latest changes of the repository.
 func (r *Repo) Pull(ctx context.Context, url string) error {
 	repo, err := git.PlainOpen(r.Path())
 	if err != nil {
@@ -48,7 +59,11 @@ func (r *Repo) Pull(ctx context.Context, url string) error {
 	return err
 }
 
+// Clone clones the repository into the kratos home, or pulls it if it already exists.
 func (r *Repo) Clone(ctx context.Context) error {
+	if r.isLocal() {
+		return nil
+	}
 	if _, err := os.Stat(r.Path()); !os.IsNotExist(err) {
 		return r.Pull(ctx, r.url)
 	}
@@ -59,6 +74,7 @@ func (r *Repo) Clone(ctx context.Context) error {
 	return err
 }
 
+// CopyTo copies the repository to the project path, and replaces its module path with modPath.
 func (r *Repo) CopyTo(ctx context.Context, to string, modPath string, ignores []string) error {
 	if err := r.Clone(ctx); err != nil {
 		return err
@@ -69,3 +85,9 @@ func (r *Repo) CopyTo(ctx context.Context, to string, modPath string, ignores []
 	}
 	return copyDir(r.Path(), to, []string{mod, modPath}, ignores)
 }
+
+// InitRepo initializes an empty git repository in dir.
+func InitRepo(dir string) error {
+	_, err := git.PlainInit(dir, false)
+	return err
+}
@@ -17,19 +17,36 @@ var CmdNew = &cobra.Command{
 	Run:   run,
 }
 
+var (
+	repoURL string
+	module  string
+	initGit bool
+	timeout time.Duration
+)
+
+func init() {
+	if repoURL = os.Getenv("KRATOS_LAYOUT_REPO"); repoURL == "" {
+		repoURL = serviceLayoutURL
+	}
+	CmdNew.Flags().StringVarP(&repoURL, "repo-url", "r", repoURL, "layout repository url or local directory")
+	CmdNew.Flags().StringVarP(&module, "module", "m", "", "go module path of the project, defaults to the project name")
+	CmdNew.Flags().BoolVarP(&initGit, "git", "g", false, "initialize a git repository in the project")
+	CmdNew.Flags().DurationVarP(&timeout, "timeout", "t", time.Minute, "time limit for fetching the layout")
+}
+
 func run(cmd *cobra.Command, args []string) {
 	wd, err := os.Getwd()
 	if err != nil {
 		panic(err)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	if len(args) == 0 {
 		fmt.Fprintf(os.Stderr, "\033[31mERROR: project name is required.\033[m Example: kratos new helloworld\n")
 		return
 	}
-	p := &Project{Name: args[0]}
-	if err := p.Generate(ctx, wd); err != nil {
+	p := &Project{Name: args[0], Module: module, Git: initGit}
+	if err := p.Generate(ctx, wd, repoURL); err != nil {
 		fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
 		return
 	}
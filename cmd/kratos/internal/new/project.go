@@ -15,24 +15,46 @@ const (
 
 // Project is a project template.
 type Project struct {
+	// Name is the project name, a module path such as github.com/foo/helloworld
+	// creates the project in the helloworld directory.
 	Name string
+	// Module is the go module path, defaults to Name.
+	Module string
+	// Git initializes a git repository in the project.
+	Git bool
 }
 
 // Generate generate template project.
-func (p *Project) Generate(ctx context.Context, dir string) error {
-	to := path.Join(dir, p.Name)
+func (p *Project) Generate(ctx context.Context, dir, layout string) error {
+	name := path.Base(p.Name)
+	to := path.Join(dir, name)
 	if _, err := os.Stat(to); !os.IsNotExist(err) {
-		return fmt.Errorf("%s already exists", p.Name)
+		return fmt.Errorf("%s already exists", name)
 	}
-	fmt.Printf("Creating service %s\n", p.Name)
-	repo := base.NewRepo(serviceLayoutURL)
+	mod := p.Module
+	if mod == "" {
+		mod = p.Name
+	}
+	fmt.Printf("Creating service %s\n", name)
+	repo := base.NewRepo(layout)
 
-	if err := repo.CopyTo(ctx, to, p.Name, []string{".git", ".github"}); err != nil {
+	if err := repo.CopyTo(ctx, to, mod, []string{".git", ".github"}); err != nil {
 		return err
 	}
-	os.Rename(
-		path.Join(to, "cmd", "server"),
-		path.Join(to, "cmd", p.Name),
-	)
+	if _, err := os.Stat(path.Join(to, "cmd", "server")); err == nil {
+		if err := os.Rename(
+			path.Join(to, "cmd", "server"),
+			path.Join(to, "cmd", name),
+		); err != nil {
+			return err
+		}
+	}
+	if p.Git {
+		if err := base.InitRepo(to); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("\nProject %s created, module %s.\n", name, mod)
+	fmt.Printf("  $ cd %s\n  $ go generate ./...\n  $ go build -o ./bin/ ./...\n", name)
 	return nil
 }
@@ -0,0 +1,72 @@
+package new
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestProjectGenerate(t *testing.T) {
+	layout, err := ioutil.TempDir("", "layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(layout)
+	files := map[string]string{
+		"go.mod":                          "module github.com/go-kratos/kratos-layout\n",
+		"cmd/server/main.go":              "package main\n\nimport _ \"github.com/go-kratos/kratos-layout/internal/service\"\n",
+		"internal/service/service.go":     "package service\n",
+		"internal/biz/biz.go":             "package biz\n",
+		"internal/data/data.go":           "package data\n",
+		"configs/config.yaml":             "server:\n",
+		"api/helloworld/v1/greeter.proto": "syntax = \"proto3\";\n",
+		".github/workflows/go.yml":        "name: Go\n",
+	}
+	for name, content := range files {
+		fp := path.Join(layout, name)
+		if err := os.MkdirAll(path.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir, err := ioutil.TempDir("", "project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := &Project{Name: "github.com/foo/helloworld", Git: true}
+	if err := p.Generate(context.Background(), dir, layout); err != nil {
+		t.Fatal(err)
+	}
+	to := path.Join(dir, "helloworld")
+	mod, err := ioutil.ReadFile(path.Join(to, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(mod) != "module github.com/foo/helloworld\n" {
+		t.Errorf("go.mod: got %q", mod)
+	}
+	main, err := ioutil.ReadFile(path.Join(to, "cmd", "helloworld", "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(main) != "package main\n\nimport _ \"github.com/foo/helloworld/internal/service\"\n" {
+		t.Errorf("main.go: got %q", main)
+	}
+	for _, name := range []string{".git", "internal/biz", "internal/data", "configs"} {
+		if _, err := os.Stat(path.Join(to, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(path.Join(to, ".github")); !os.IsNotExist(err) {
+		t.Errorf(".github should be ignored: %v", err)
+	}
+	if err := p.Generate(context.Background(), dir, layout); err == nil {
+		t.Error("expected an error for an existing project")
+	}
+}
@@ -3,6 +3,7 @@ package add
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -13,14 +14,22 @@ import (
 var CmdAdd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a proto API template",
-	Long:  "Add a proto API template. Example: kratos add helloworld/v1/hello.proto",
+	Long:  "Add a proto API template. Example: kratos proto add helloworld/v1/hello.proto",
 	Run:   run,
 }
 
 func run(cmd *cobra.Command, args []string) {
-	// kratos add helloworld/v1/helloworld.proto
+	// kratos proto add helloworld/v1/helloworld.proto
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Please specify the proto file. Example: kratos proto add api/helloworld/v1/hello.proto")
+		return
+	}
 	input := args[0]
 	n := strings.LastIndex(input, "/")
+	if n < 0 {
+		fmt.Fprintln(os.Stderr, "The proto file must be in a package directory. Example: kratos proto add api/helloworld/v1/hello.proto")
+		return
+	}
 	path := input[:n]
 	fileName := input[n+1:]
 	pkgName := strings.ReplaceAll(path, "/", ".")
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CmdClient represents the client command.
+var CmdClient = &cobra.Command{
+	Use:     "client",
+	Aliases: []string{"source"},
+	Short:   "Generate the proto client code",
+	Long:    "Generate the proto client code. Example: kratos proto client helloworld.proto",
+	Run:     run,
+}
+
+var protoPath string
+
+// plugins are the protoc plugins used to generate the client code, a missing plugin is
+// installed with go install, so the plugin modules must not have replace directives.
+var plugins = map[string]string{
+	"protoc-gen-go":        "google.golang.org/protobuf/cmd/protoc-gen-go",
+	"protoc-gen-go-grpc":   "google.golang.org/grpc/cmd/protoc-gen-go-grpc",
+	"protoc-gen-go-http":   "github.com/go-kratos/kratos/cmd/protoc-gen-go-http",
+	"protoc-gen-go-errors": "github.com/go-kratos/kratos/cmd/protoc-gen-go-errors",
+}
+
+func init() {
+	CmdClient.Flags().StringVarP(&protoPath, "proto_path", "p", "./third_party", "proto import path")
+}
+
+func run(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Please specify the proto file or directory. Example: kratos proto client api/xxx.proto")
+		return
+	}
+	if err := look(); err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+		return
+	}
+	for _, input := range args {
+		if err := walk(input); err != nil {
+			fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+			return
+		}
+	}
+}
+
+func look() error {
+	if _, err := exec.LookPath("protoc"); err != nil {
+		return fmt.Errorf("protoc is not found, please install it from https://github.com/protocolbuffers/protobuf/releases")
+	}
+	for name, pkg := range plugins {
+		if _, err := exec.LookPath(name); err == nil {
+			continue
+		}
+		fmt.Printf("Installing %s\n", pkg)
+		install := exec.Command("go", "install", pkg+"@latest")
+		install.Stdout = os.Stdout
+		install.Stderr = os.Stderr
+		if err := install.Run(); err != nil {
+			return fmt.Errorf("failed to install %s: %w", pkg, err)
+		}
+		if _, err := exec.LookPath(name); err != nil {
+			return fmt.Errorf("%s is installed but not found in PATH, please add $(go env GOPATH)/bin to PATH", name)
+		}
+	}
+	return nil
+}
+
+func walk(input string) error {
+	fi, err := os.Stat(input)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return generate(input)
+	}
+	return filepath.Walk(input, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(path) != ".proto" {
+			return nil
+		}
+		return generate(path)
+	})
+}
+
+func generate(proto string) error {
+	input := protocArgs(proto)
+	fmt.Printf("protoc %s\n", strings.Join(input, " "))
+	do := exec.Command("protoc", input...)
+	out, err := do.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to generate %s: %s", proto, out)
+	}
+	if len(out) > 0 {
+		fmt.Print(string(out))
+	}
+	return nil
+}
+
+func protocArgs(proto string) []string {
+	input := []string{"--proto_path=."}
+	if _, err := os.Stat(protoPath); err == nil {
+		input = append(input, "--proto_path="+protoPath)
+	}
+	input = append(input,
+		"--go_out=paths=source_relative:.",
+		"--go-grpc_out=paths=source_relative:.",
+		"--go-http_out=paths=source_relative:.",
+		"--go-errors_out=paths=source_relative:.",
+	)
+	return append(input, proto)
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+// fakeTools writes the fake protoc, protoc-gen-go and go into a directory which becomes
+// the PATH. The fake go logs its arguments, and installs the plugin unless broken.
+func fakeTools(t *testing.T, broken bool) (log string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake tools are shell scripts")
+	}
+	chmod, err := exec.LookPath("chmod")
+	if err != nil {
+		t.Skip(err)
+	}
+	bin := t.TempDir()
+	log = filepath.Join(bin, "go.log")
+	install := `pkg=${2%@*}
+printf '#!/bin/sh\n' > "` + bin + `/${pkg##*/}"
+` + chmod + ` +x "` + bin + `/${pkg##*/}"
+`
+	if broken {
+		install = ""
+	}
+	for name, script := range map[string]string{
+		"protoc":        "",
+		"protoc-gen-go": "",
+		"go":            `echo "$@" >> "` + log + `"` + "\n" + install,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", bin)
+	t.Cleanup(func() { os.Setenv("PATH", path) })
+	return log
+}
+
+func readLog(t *testing.T, log string) []string {
+	b, err := ioutil.ReadFile(log)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestLook(t *testing.T) {
+	log := fakeTools(t, false)
+	if err := look(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"install github.com/go-kratos/kratos/cmd/protoc-gen-go-errors@latest",
+		"install github.com/go-kratos/kratos/cmd/protoc-gen-go-http@latest",
+		"install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest",
+	}
+	if got := readLog(t, log); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q want %q", got, want)
+	}
+	// the installed plugins are found by the next look.
+	if err := look(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readLog(t, log); len(got) != len(want) {
+		t.Errorf("got %q want no more installs", got)
+	}
+}
+
+func TestLookNotInPath(t *testing.T) {
+	fakeTools(t, true)
+	if err := look(); err == nil || !strings.Contains(err.Error(), "not found in PATH") {
+		t.Errorf("got %v want the not found error", err)
+	}
+}
+
+func TestLookNoProtoc(t *testing.T) {
+	fakeTools(t, false)
+	os.Setenv("PATH", t.TempDir())
+	if err := look(); err == nil || !strings.Contains(err.Error(), "protoc is not found") {
+		t.Errorf("got %v want the protoc error", err)
+	}
+}
+
+// TestPluginModules checks the kratos plugins can be installed by go install pkg@latest,
+// which refuses the modules with replace directives.
+func TestPluginModules(t *testing.T) {
+	for name, pkg := range plugins {
+		if !strings.HasPrefix(pkg, "github.com/go-kratos/kratos/") {
+			continue
+		}
+		path := filepath.Join("..", "..", "..", "..", name, "go.mod")
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := modfile.Parse(path, b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Module.Mod.Path != pkg {
+			t.Errorf("%s: got module %s want %s", path, f.Module.Mod.Path, pkg)
+		}
+		if len(f.Replace) > 0 {
+			t.Errorf("%s: got %d replace directives want none", path, len(f.Replace))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/emicklei/proto"
+	"github.com/spf13/cobra"
+)
+
+// CmdServer represents the server command.
+var CmdServer = &cobra.Command{
+	Use:     "server",
+	Aliases: []string{"service"},
+	Short:   "Generate the proto Server implementations",
+	Long:    "Generate the proto Server implementations. Example: kratos proto server api/xxx.proto --target-dir=internal/service",
+	Run:     run,
+}
+var targetDir string
+
+func init() {
+	CmdServer.Flags().StringVarP(&targetDir, "target-dir", "t", "internal/service", "generate target directory")
+}
+
+func run(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Please specify the proto file. Example: kratos proto server api/xxx.proto")
+		return
+	}
+	reader, err := os.Open(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer reader.Close()
+
+	res, err := parse(reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, s := range res {
+		to := path.Join(targetDir, strings.ToLower(s.Service)+".go")
+		_, err := os.Stat(to)
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "%s already exists: %s\n", s.Service, to)
+			continue
+		}
+		if err = os.MkdirAll(targetDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create file directory: %s\n", targetDir)
+			continue
+		}
+		b, err := s.execute()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(to, b, 0644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(to)
+	}
+}
+
+// parse returns the services defined in a proto file.
+func parse(r io.Reader) ([]*Service, error) {
+	definition, err := proto.NewParser(r).Parse()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		pkg string
+		res []*Service
+	)
+	proto.Walk(definition,
+		proto.WithOption(func(o *proto.Option) {
+			if o.Name == "go_package" {
+				pkg = strings.Split(o.Constant.Source, ";")[0]
+			}
+		}),
+		proto.WithService(func(s *proto.Service) {
+			cs := &Service{
+				Package: pkg,
+				Service: s.Name,
+			}
+			for _, e := range s.Elements {
+				r, ok := e.(*proto.RPC)
+				if !ok {
+					continue
+				}
+				cs.Methods = append(cs.Methods, &Method{
+					Service:        s.Name,
+					Name:           r.Name,
+					Request:        r.RequestType,
+					Reply:          r.ReturnsType,
+					StreamsRequest: r.StreamsRequest,
+					StreamsReturns: r.StreamsReturns,
+				})
+			}
+			res = append(res, cs)
+		}),
+	)
+	return res, nil
+}
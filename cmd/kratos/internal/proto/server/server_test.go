@@ -0,0 +1,49 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+const greeterProto = `
+syntax = "proto3";
+
+package helloworld.v1;
+
+option go_package = "github.com/go-kratos/kratos-layout/api/helloworld/v1;v1";
+
+service Greeter {
+	rpc SayHello (HelloRequest) returns (HelloReply);
+	rpc SayHelloStream (stream HelloRequest) returns (stream HelloReply);
+	rpc ListHello (HelloRequest) returns (stream HelloReply);
+}
+
+message HelloRequest {}
+message HelloReply {}
+`
+
+func TestGenerateServer(t *testing.T) {
+	res, err := parse(strings.NewReader(greeterProto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(res))
+	}
+	b, err := res[0].execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(b)
+	for _, want := range []string{
+		`pb "github.com/go-kratos/kratos-layout/api/helloworld/v1"`,
+		"func NewGreeterService() *GreeterService {",
+		"func (s *GreeterService) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {",
+		"func (s *GreeterService) SayHelloStream(conn pb.Greeter_SayHelloStreamServer) error {",
+		"func (s *GreeterService) ListHello(req *pb.HelloRequest, conn pb.Greeter_ListHelloServer) error {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, code)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+var serviceTemplate = `
+package service
+
+import (
+{{- if .UseContext }}
+	"context"
+{{- end }}
+
+	pb "{{.Package}}"
+)
+
+type {{.Service}}Service struct {
+	pb.Unimplemented{{.Service}}Server
+}
+
+func New{{.Service}}Service() *{{.Service}}Service {
+	return &{{.Service}}Service{}
+}
+{{ range .Methods }}
+{{- if .StreamsRequest }}
+func (s *{{.Service}}Service) {{.Name}}(conn pb.{{.Service}}_{{.Name}}Server) error {
+	return nil
+}
+{{- else if .StreamsReturns }}
+func (s *{{.Service}}Service) {{.Name}}(req *pb.{{.Request}}, conn pb.{{.Service}}_{{.Name}}Server) error {
+	return nil
+}
+{{- else }}
+func (s *{{.Service}}Service) {{.Name}}(ctx context.Context, req *pb.{{.Request}}) (*pb.{{.Reply}}, error) {
+	return &pb.{{.Reply}}{}, nil
+}
+{{- end }}
+{{ end }}
+`
+
+// Service is a proto service.
+type Service struct {
+	Package string
+	Service string
+	Methods []*Method
+}
+
+// Method is a proto method.
+type Method struct {
+	Service string
+	Name    string
+	Request string
+	Reply   string
+
+	StreamsRequest bool
+	StreamsReturns bool
+}
+
+// UseContext reports whether any unary method needs the context package.
+func (s *Service) UseContext() bool {
+	for _, m := range s.Methods {
+		if !m.StreamsRequest && !m.StreamsReturns {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) execute() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	tmpl, err := template.New("service").Parse(strings.TrimSpace(serviceTemplate))
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(buf, s); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,65 @@
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// CmdUpgrade represents the upgrade command.
+var CmdUpgrade = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade a running service with zero downtime",
+	Long:  "Signal a running service of the transport/upgrade package to start its new binary and drain. Example: kratos upgrade -p /var/run/helloworld.pid",
+	Run:   run,
+}
+
+var pidFile string
+
+func init() {
+	CmdUpgrade.Flags().StringVarP(&pidFile, "pid-file", "p", "", "pid file of the service, or pass the pid as the argument")
+}
+
+func run(cmd *cobra.Command, args []string) {
+	pid, err := readPid(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31mERROR: %s.\033[m Example: kratos upgrade 1234\n", err)
+		return
+	}
+	p, err := os.FindProcess(pid)
+	if err == nil {
+		err = p.Signal(syscall.SIGHUP)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+		return
+	}
+	fmt.Printf("upgrading the process %d\n", pid)
+}
+
+func readPid(args []string) (int, error) {
+	s := ""
+	switch {
+	case len(args) > 0:
+		s = args[0]
+	case pidFile != "":
+		data, err := ioutil.ReadFile(pidFile)
+		if err != nil {
+			return 0, err
+		}
+		s = strings.TrimSpace(string(data))
+	default:
+		return 0, errors.New("pid or pid file is required")
+	}
+	pid, err := strconv.Atoi(s)
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid %q", s)
+	}
+	return pid, nil
+}
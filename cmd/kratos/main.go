@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-kratos/kratos/cmd/kratos/internal/new"
 	"github.com/go-kratos/kratos/cmd/kratos/internal/proto"
+	"github.com/go-kratos/kratos/cmd/kratos/internal/upgrade"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +19,7 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(new.CmdNew)
 	rootCmd.AddCommand(proto.CmdProto)
+	rootCmd.AddCommand(upgrade.CmdUpgrade)
 }
 
 func main() {
@@ -1,9 +1,11 @@
 package main
 
 import (
-	pb "github.com/go-kratos/kratos/v2/api/kratos/api"
+	"net/http"
+	"strings"
 
 	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -11,7 +13,30 @@ const (
 	errorsPackage = protogen.GoImportPath("github.com/go-kratos/kratos/v2/errors")
 )
 
-// generateFile generates a _http.pb.go file containing kratos errors definitions.
+// The field numbers of the extensions of kratos/api/annotations.proto.
+const (
+	fieldErrors      protowire.Number = 1000
+	fieldDefaultCode protowire.Number = 1001
+	fieldCode        protowire.Number = 1002
+)
+
+// References: https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+var statusMapping = map[int32]int32{
+	http.StatusOK:                  0,
+	http.StatusBadRequest:          3,
+	http.StatusRequestTimeout:      4,
+	http.StatusNotFound:            5,
+	http.StatusConflict:            6,
+	http.StatusForbidden:           7,
+	http.StatusTooManyRequests:     8,
+	http.StatusPreconditionFailed:  9,
+	http.StatusNotImplemented:      12,
+	http.StatusInternalServerError: 13,
+	http.StatusServiceUnavailable:  14,
+	http.StatusUnauthorized:        16,
+}
+
+// generateFile generates a _errors.pb.go file containing kratos errors definitions.
 func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
 	if len(file.Enums) == 0 {
 		return nil
@@ -42,17 +67,84 @@ func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.
 }
 
 func genErrorsReason(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, enum *protogen.Enum) {
-	err := proto.GetExtension(enum.Desc.Options(), pb.E_Errors)
-	if ok := err.(bool); !ok {
+	if enabled, _ := extension(enum.Desc.Options(), fieldErrors); enabled == 0 {
 		return
 	}
+	dc, _ := extension(enum.Desc.Options(), fieldDefaultCode)
+	defaultCode := int32(dc)
+	if defaultCode <= 0 {
+		defaultCode = http.StatusInternalServerError
+	}
 	var ew errorWrapper
 	for _, v := range enum.Values {
-		err := &errorInfo{
-			Name:  string(enum.Desc.Name()),
-			Value: string(v.Desc.Name()),
+		httpCode := defaultCode
+		if c, _ := extension(v.Desc.Options(), fieldCode); int32(c) > 0 {
+			httpCode = int32(c)
+		}
+		code, ok := statusMapping[httpCode]
+		if !ok {
+			code = 2 // unknown
 		}
-		ew.Errors = append(ew.Errors, err)
+		ew.Errors = append(ew.Errors, &errorInfo{
+			Name:       string(enum.Desc.Name()),
+			Value:      string(v.Desc.Name()),
+			CamelValue: camelCase(string(v.Desc.Name())),
+			Enum:       g.QualifiedGoIdent(v.GoIdent),
+			HTTPCode:   httpCode,
+			Code:       code,
+		})
+	}
+	if len(ew.Errors) == 0 {
+		return
 	}
 	g.P(ew.execute())
 }
+
+// extension returns the varint value of the kratos annotation of the options. The
+// annotations are not registered in the plugin, so they are kept in the unknown fields,
+// which keeps the plugin independent of the kratos version of the generated code.
+func extension(opts proto.Message, num protowire.Number) (uint64, bool) {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return 0, false
+	}
+	var (
+		value uint64
+		found bool
+	)
+	b := opts.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		n, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return 0, false
+		}
+		b = b[l:]
+		if n == num && typ == protowire.VarintType {
+			v, l := protowire.ConsumeVarint(b)
+			if l < 0 {
+				return 0, false
+			}
+			// the last value wins as the proto parsing does.
+			value, found = v, true
+			b = b[l:]
+			continue
+		}
+		if l = protowire.ConsumeFieldValue(n, typ, b); l < 0 {
+			return 0, false
+		}
+		b = b[l:]
+	}
+	return value, found
+}
+
+// camelCase converts an upper snake case enum value such as USER_NOT_FOUND into UserNotFound.
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.ToLower(s), "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
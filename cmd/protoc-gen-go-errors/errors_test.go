@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// varint returns the unknown field of the varint annotation.
+func varint(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func enumOptions(fields ...[]byte) *descriptorpb.EnumOptions {
+	opts := &descriptorpb.EnumOptions{}
+	opts.ProtoReflect().SetUnknown(concat(fields...))
+	return opts
+}
+
+func valueOptions(fields ...[]byte) *descriptorpb.EnumValueOptions {
+	opts := &descriptorpb.EnumValueOptions{}
+	opts.ProtoReflect().SetUnknown(concat(fields...))
+	return opts
+}
+
+func concat(fields ...[]byte) []byte {
+	var b []byte
+	for _, f := range fields {
+		b = append(b, f...)
+	}
+	return b
+}
+
+func TestExtension(t *testing.T) {
+	other := protowire.AppendBytes(protowire.AppendTag(nil, 999, protowire.BytesType), []byte("other"))
+	tests := []struct {
+		name  string
+		opts  proto.Message
+		value uint64
+		found bool
+	}{
+		{name: "nil", opts: nil},
+		{name: "unset", opts: (*descriptorpb.EnumOptions)(nil)},
+		{name: "missing", opts: enumOptions(other)},
+		{name: "value", opts: enumOptions(varint(fieldDefaultCode, 404)), value: 404, found: true},
+		{name: "others", opts: enumOptions(other, varint(fieldErrors, 1), varint(fieldDefaultCode, 404)), value: 404, found: true},
+		{name: "last", opts: enumOptions(varint(fieldDefaultCode, 404), varint(fieldDefaultCode, 409)), value: 409, found: true},
+		{name: "type", opts: enumOptions(protowire.AppendFixed32(protowire.AppendTag(nil, fieldDefaultCode, protowire.Fixed32Type), 404))},
+		{name: "truncated", opts: enumOptions(varint(fieldDefaultCode, 404)[:2])},
+	}
+	for _, test := range tests {
+		value, found := extension(test.opts, fieldDefaultCode)
+		if value != test.value || found != test.found {
+			t.Errorf("%s: got %d %v want %d %v", test.name, value, found, test.value, test.found)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"USER_NOT_FOUND", "UserNotFound"},
+		{"UNKNOWN", "Unknown"},
+		{"_LEADING__DOUBLE_", "LeadingDouble"},
+		{"already_lower", "AlreadyLower"},
+	}
+	for _, test := range tests {
+		if out := camelCase(test.in); out != test.out {
+			t.Errorf("got %s want %s", out, test.out)
+		}
+	}
+}
+
+func TestGenerateFile(t *testing.T) {
+	value := func(name string, num int32, opts *descriptorpb.EnumValueOptions) *descriptorpb.EnumValueDescriptorProto {
+		return &descriptorpb.EnumValueDescriptorProto{Name: proto.String(name), Number: proto.Int32(num), Options: opts}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("user/v1/user.proto"),
+		Package: proto.String("user.v1"),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/user/v1;v1")},
+		EnumType: []*descriptorpb.EnumDescriptorProto{
+			{
+				Name:    proto.String("ErrorReason"),
+				Options: enumOptions(varint(fieldErrors, 1), varint(fieldDefaultCode, 404)),
+				Value: []*descriptorpb.EnumValueDescriptorProto{
+					value("USER_NOT_FOUND", 0, nil),
+					value("NOT_LOGIN", 1, valueOptions(varint(fieldCode, 401))),
+					value("TEAPOT", 2, valueOptions(varint(fieldCode, 418))),
+				},
+			},
+			{
+				Name:  proto.String("Status"),
+				Value: []*descriptorpb.EnumValueDescriptorProto{value("STATUS_OK", 0, nil)},
+			},
+		},
+	}
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generateFile(gen, gen.Files[0])
+	res := gen.Response()
+	if res.Error != nil {
+		t.Fatal(res.GetError())
+	}
+	if len(res.File) != 1 || res.File[0].GetName() != "example.com/user/v1/user_errors.pb.go" {
+		t.Fatalf("got %v want the errors file", res.File)
+	}
+	content := res.File[0].GetContent()
+	tests := []struct {
+		want string
+	}{
+		{"func IsUserNotFound(err error) bool {"},
+		{"e.Reason == ErrorReason_USER_NOT_FOUND.String() && e.Code == 5"},
+		{"// HTTP Mapping: 404"},
+		{"return errors.Errorf(16, ErrorReason_NOT_LOGIN.String(), format, args...)"},
+		{"return errors.Errorf(2, ErrorReason_TEAPOT.String(), format, args...)"},
+	}
+	for _, test := range tests {
+		if !strings.Contains(content, test.want) {
+			t.Errorf("got %s want %s", content, test.want)
+		}
+	}
+	if strings.Contains(content, "StatusOk") {
+		t.Errorf("got %s want no errors of the enum without the annotation", content)
+	}
+}
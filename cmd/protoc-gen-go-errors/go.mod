@@ -2,8 +2,4 @@ module github.com/go-kratos/kratos/cmd/protoc-gen-go-errors
 
 go 1.15
 
-require (
-	github.com/go-kratos/kratos/v2 v2.0.0-20210214172044-a42fa7820493
-	github.com/golang/protobuf v1.4.3
-	google.golang.org/protobuf v1.25.0
-)
+require google.golang.org/protobuf v1.25.0
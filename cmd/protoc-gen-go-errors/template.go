@@ -5,24 +5,36 @@ import (
 	"text/template"
 )
 
-var errorsTemplate = `const (
+var errorsTemplate = `
 {{ range .Errors }}
-	Errors_{{.Value}} = "{{.Name}}_{{.Value}}"
-{{- end }}
-)
 
-{{ range .Errors }}
+// Is{{.CamelValue}} reports whether err is {{.Name}}_{{.Value}}.
+// HTTP Mapping: {{.HTTPCode}}
+func Is{{.CamelValue}}(err error) bool {
+	if err == nil {
+		return false
+	}
+	e, ok := errors.FromError(err)
+	return ok && e.Reason == {{.Enum}}.String() && e.Code == {{.Code}}
+}
 
-func Is{{.Value}}(err error) bool {
-	return errors.Reason(err) == Errors_{{.Value}}
+// Error{{.CamelValue}} returns a {{.Name}}_{{.Value}} error.
+// HTTP Mapping: {{.HTTPCode}}
+func Error{{.CamelValue}}(format string, args ...interface{}) error {
+	return errors.Errorf({{.Code}}, {{.Enum}}.String(), format, args...)
 }
 {{- end }}
 `
 
 type errorInfo struct {
-	Name  string
-	Value string
+	Name       string
+	Value      string
+	CamelValue string
+	Enum       string
+	HTTPCode   int32
+	Code       int32
 }
+
 type errorWrapper struct {
 	Errors []*errorInfo
 }
@@ -3,9 +3,7 @@ module github.com/go-kratos/kratos/cmd/protoc-gen-go-http
 go 1.15
 
 require (
-	github.com/go-kratos/kratos/v2 v2.0.0-20210211132943-131c3975fc3d
-	github.com/golang/protobuf v1.4.3
+	github.com/golang/protobuf v1.4.3 // indirect
 	google.golang.org/genproto v0.0.0-20210202153253-cf70463f6119
-	google.golang.org/grpc v1.35.0
 	google.golang.org/protobuf v1.25.0
 )
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"google.golang.org/genproto/googleapis/api/annotations"
@@ -19,7 +20,7 @@ const (
 
 var methodSets = make(map[string]int)
 
-// generateFile generates a _http.pb.go file containing kratos errors definitions.
+// generateFile generates a _http.pb.go file containing kratos http definitions.
 func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
 	if len(file.Services) == 0 {
 		return nil
@@ -34,7 +35,7 @@ func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.Generated
 	return g
 }
 
-// generateFileContent generates the kratos errors definitions, excluding the package statement.
+// generateFileContent generates the kratos http definitions, excluding the package statement.
 func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile) {
 	if len(file.Services) == 0 {
 		return
@@ -62,22 +63,39 @@ func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.Generated
 		Metadata:    file.Desc.Path(),
 	}
 	for _, method := range service.Methods {
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			continue
+		}
 		rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
 		if rule != nil && ok {
+			md, err := buildHTTPRule(g, method, rule)
+			if err != nil {
+				gen.Error(err)
+				return
+			}
+			sd.Methods = append(sd.Methods, md)
 			for _, bind := range rule.AdditionalBindings {
-				sd.Methods = append(sd.Methods, buildHTTPRule(method, bind))
+				md, err := buildHTTPRule(g, method, bind)
+				if err != nil {
+					gen.Error(err)
+					return
+				}
+				sd.Methods = append(sd.Methods, md)
 			}
-			sd.Methods = append(sd.Methods, buildHTTPRule(method, rule))
 		} else {
 			path := fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name())
-			sd.Methods = append(sd.Methods, buildMethodDesc(method, "POST", path))
-
+			md := buildMethodDesc(method, "POST", path)
+			md.Body = "*"
+			sd.Methods = append(sd.Methods, md)
 		}
 	}
+	if len(sd.Methods) == 0 {
+		return
+	}
 	g.P(sd.execute())
 }
 
-func buildHTTPRule(m *protogen.Method, rule *annotations.HttpRule) *methodDesc {
+func buildHTTPRule(g *protogen.GeneratedFile, m *protogen.Method, rule *annotations.HttpRule) (*methodDesc, error) {
 	var (
 		path         string
 		method       string
@@ -107,13 +125,72 @@ func buildHTTPRule(m *protogen.Method, rule *annotations.HttpRule) *methodDesc {
 	body = rule.Body
 	responseBody = rule.ResponseBody
 	md := buildMethodDesc(m, method, path)
-	if body != "" {
-		md.Body = "." + camelCaseVars(body)
+	switch body {
+	case "", "*":
+		md.Body = body
+	default:
+		fields, err := lookupFields(m.Input, body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: body %v", m.Desc.FullName(), err)
+		}
+		md.Body = body
+		md.BodyFields = buildBodyFields(g, fields)
 	}
 	if responseBody != "" {
+		if _, err := lookupFields(m.Output, responseBody); err != nil {
+			return nil, fmt.Errorf("%s: response_body %v", m.Desc.FullName(), err)
+		}
 		md.ResponseBody = "." + camelCaseVars(responseBody)
 	}
-	return md
+	return md, nil
+}
+
+// lookupFields resolves a dotted field path such as "book.author" against msg.
+func lookupFields(msg *protogen.Message, path string) ([]*protogen.Field, error) {
+	var fields []*protogen.Field
+	for _, name := range strings.Split(path, ".") {
+		if msg == nil {
+			return nil, fmt.Errorf("%q is not a message field path", path)
+		}
+		var field *protogen.Field
+		for _, f := range msg.Fields {
+			if string(f.Desc.Name()) == name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil, fmt.Errorf("field %q not found in %s", name, msg.Desc.FullName())
+		}
+		if field.Oneof != nil {
+			return nil, fmt.Errorf("oneof field %q is not supported", name)
+		}
+		fields = append(fields, field)
+		if field.Desc.IsList() || field.Desc.IsMap() {
+			msg = nil
+		} else {
+			msg = field.Message
+		}
+	}
+	return fields, nil
+}
+
+// buildBodyFields returns the accessors of fields, allocating every singular message on the path,
+// so that the decoder always receives a non-nil target.
+func buildBodyFields(g *protogen.GeneratedFile, fields []*protogen.Field) []*bodyField {
+	var (
+		res  []*bodyField
+		expr = "in"
+	)
+	for _, f := range fields {
+		expr += "." + f.GoName
+		bf := &bodyField{Expr: expr}
+		if f.Message != nil && !f.Desc.IsList() && !f.Desc.IsMap() {
+			bf.Type = g.QualifiedGoIdent(f.Message.GoIdent)
+		}
+		res = append(res, bf)
+	}
+	return res
 }
 
 func buildMethodDesc(m *protogen.Method, method, path string) *methodDesc {
@@ -123,22 +200,45 @@ func buildMethodDesc(m *protogen.Method, method, path string) *methodDesc {
 		Num:     methodSets[m.GoName],
 		Request: m.Input.GoIdent.GoName,
 		Reply:   m.Output.GoIdent.GoName,
-		Path:    path,
+		Path:    buildRoutePath(path),
 		Method:  method,
 		Vars:    buildPathVars(m, path),
 	}
 }
 
 func buildPathVars(method *protogen.Method, path string) (res []string) {
-	for _, v := range strings.Split(path, "/") {
-		if strings.HasPrefix(v, "{") && strings.HasSuffix(v, "}") {
-			name := strings.TrimRight(strings.TrimLeft(v, "{"), "}")
-			res = append(res, name)
-		}
+	for _, v := range pathVarPattern.FindAllStringSubmatch(path, -1) {
+		res = append(res, v[1])
 	}
 	return
 }
 
+// pathVarPattern matches a template variable such as {name} or {name=shelves/*/books/**}.
+var pathVarPattern = regexp.MustCompile(`{([^=}]+)(?:=([^}]*))?}`)
+
+// buildRoutePath converts a google.api.http path template into a router path,
+// a variable with a segment pattern is expanded into a regexp variable.
+func buildRoutePath(path string) string {
+	return pathVarPattern.ReplaceAllStringFunc(path, func(v string) string {
+		sub := pathVarPattern.FindStringSubmatch(v)
+		if sub[2] == "" {
+			return "{" + sub[1] + "}"
+		}
+		var segments []string
+		for _, seg := range strings.Split(sub[2], "/") {
+			switch seg {
+			case "*":
+				segments = append(segments, "[^/]+")
+			case "**":
+				segments = append(segments, ".+")
+			default:
+				segments = append(segments, regexp.QuoteMeta(seg))
+			}
+		}
+		return "{" + sub[1] + ":" + strings.Join(segments, "/") + "}"
+	})
+}
+
 func camelCaseVars(s string) string {
 	var (
 		vars []string
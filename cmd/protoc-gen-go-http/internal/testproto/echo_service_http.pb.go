@@ -16,11 +16,8 @@ const _ = http1.SupportPackageIsVersion1
 
 type EchoServiceHTTPServer interface {
 	Echo(context.Context, *SimpleMessage) (*SimpleMessage, error)
-
 	EchoBody(context.Context, *SimpleMessage) (*SimpleMessage, error)
-
 	EchoDelete(context.Context, *SimpleMessage) (*SimpleMessage, error)
-
 	EchoPatch(context.Context, *DynamicMessageUpdate) (*DynamicMessageUpdate, error)
 }
 
@@ -30,17 +27,14 @@ func RegisterEchoServiceHTTPServer(s http1.ServiceRegistrar, srv EchoServiceHTTP
 
 func _HTTP_EchoService_Echo_0(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in SimpleMessage
-
-	if err := http1.BindVars(req, &in); err != nil {
+	if err := http1.BindQuery(req, &in); err != nil {
 		return nil, err
 	}
-
-	if err := http1.BindForm(req, &in); err != nil {
+	if err := http1.BindVars(req, &in); err != nil {
 		return nil, err
 	}
-
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).Echo(ctx, &in)
+		return srv.(EchoServiceHTTPServer).Echo(ctx, req.(*SimpleMessage))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -51,17 +45,14 @@ func _HTTP_EchoService_Echo_0(srv interface{}, ctx context.Context, req *http.Re
 
 func _HTTP_EchoService_Echo_1(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in SimpleMessage
-
-	if err := http1.BindVars(req, &in); err != nil {
+	if err := http1.BindQuery(req, &in); err != nil {
 		return nil, err
 	}
-
-	if err := http1.BindForm(req, &in); err != nil {
+	if err := http1.BindVars(req, &in); err != nil {
 		return nil, err
 	}
-
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).Echo(ctx, &in)
+		return srv.(EchoServiceHTTPServer).Echo(ctx, req.(*SimpleMessage))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -72,17 +63,14 @@ func _HTTP_EchoService_Echo_1(srv interface{}, ctx context.Context, req *http.Re
 
 func _HTTP_EchoService_Echo_2(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in SimpleMessage
-
-	if err := http1.BindVars(req, &in); err != nil {
+	if err := http1.BindQuery(req, &in); err != nil {
 		return nil, err
 	}
-
-	if err := http1.BindForm(req, &in); err != nil {
+	if err := http1.BindVars(req, &in); err != nil {
 		return nil, err
 	}
-
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).Echo(ctx, &in)
+		return srv.(EchoServiceHTTPServer).Echo(ctx, req.(*SimpleMessage))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -93,17 +81,14 @@ func _HTTP_EchoService_Echo_2(srv interface{}, ctx context.Context, req *http.Re
 
 func _HTTP_EchoService_Echo_3(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in SimpleMessage
-
-	if err := http1.BindVars(req, &in); err != nil {
+	if err := http1.BindQuery(req, &in); err != nil {
 		return nil, err
 	}
-
-	if err := http1.BindForm(req, &in); err != nil {
+	if err := http1.BindVars(req, &in); err != nil {
 		return nil, err
 	}
-
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).Echo(ctx, &in)
+		return srv.(EchoServiceHTTPServer).Echo(ctx, req.(*SimpleMessage))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -114,17 +99,14 @@ func _HTTP_EchoService_Echo_3(srv interface{}, ctx context.Context, req *http.Re
 
 func _HTTP_EchoService_Echo_4(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in SimpleMessage
-
-	if err := http1.BindVars(req, &in); err != nil {
+	if err := http1.BindQuery(req, &in); err != nil {
 		return nil, err
 	}
-
-	if err := http1.BindForm(req, &in); err != nil {
+	if err := http1.BindVars(req, &in); err != nil {
 		return nil, err
 	}
-
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).Echo(ctx, &in)
+		return srv.(EchoServiceHTTPServer).Echo(ctx, req.(*SimpleMessage))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -135,13 +117,11 @@ func _HTTP_EchoService_Echo_4(srv interface{}, ctx context.Context, req *http.Re
 
 func _HTTP_EchoService_EchoBody_0(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in SimpleMessage
-
 	if err := dec(&in); err != nil {
 		return nil, err
 	}
-
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).EchoBody(ctx, &in)
+		return srv.(EchoServiceHTTPServer).EchoBody(ctx, req.(*SimpleMessage))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -152,13 +132,11 @@ func _HTTP_EchoService_EchoBody_0(srv interface{}, ctx context.Context, req *htt
 
 func _HTTP_EchoService_EchoDelete_0(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in SimpleMessage
-
-	if err := http1.BindForm(req, &in); err != nil {
+	if err := http1.BindQuery(req, &in); err != nil {
 		return nil, err
 	}
-
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).EchoDelete(ctx, &in)
+		return srv.(EchoServiceHTTPServer).EchoDelete(ctx, req.(*SimpleMessage))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -169,13 +147,17 @@ func _HTTP_EchoService_EchoDelete_0(srv interface{}, ctx context.Context, req *h
 
 func _HTTP_EchoService_EchoPatch_0(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in DynamicMessageUpdate
-
+	if in.Body == nil {
+		in.Body = new(DynamicMessage)
+	}
 	if err := dec(in.Body); err != nil {
 		return nil, err
 	}
-
+	if err := http1.BindQuery(req, &in); err != nil {
+		return nil, err
+	}
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EchoServiceServer).EchoPatch(ctx, &in)
+		return srv.(EchoServiceHTTPServer).EchoPatch(ctx, req.(*DynamicMessageUpdate))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
@@ -187,49 +169,41 @@ func _HTTP_EchoService_EchoPatch_0(srv interface{}, ctx context.Context, req *ht
 var _HTTP_EchoService_serviceDesc = http1.ServiceDesc{
 	ServiceName: "testproto.EchoService",
 	Methods: []http1.MethodDesc{
-
 		{
-			Path:    "/v1/example/echo/{id}/{num}",
-			Method:  "GET",
+			Path:    "/v1/example/echo/{id}",
+			Method:  "POST",
 			Handler: _HTTP_EchoService_Echo_0,
 		},
-
 		{
-			Path:    "/v1/example/echo/{id}/{num}/{lang}",
+			Path:    "/v1/example/echo/{id}/{num}",
 			Method:  "GET",
 			Handler: _HTTP_EchoService_Echo_1,
 		},
-
 		{
-			Path:    "/v1/example/echo1/{id}/{line_num}/{status.note}",
+			Path:    "/v1/example/echo/{id}/{num}/{lang}",
 			Method:  "GET",
 			Handler: _HTTP_EchoService_Echo_2,
 		},
-
 		{
-			Path:    "/v1/example/echo2/{no.note}",
+			Path:    "/v1/example/echo1/{id}/{line_num}/{status.note}",
 			Method:  "GET",
 			Handler: _HTTP_EchoService_Echo_3,
 		},
-
 		{
-			Path:    "/v1/example/echo/{id}",
-			Method:  "POST",
+			Path:    "/v1/example/echo2/{no.note}",
+			Method:  "GET",
 			Handler: _HTTP_EchoService_Echo_4,
 		},
-
 		{
 			Path:    "/v1/example/echo_body",
 			Method:  "POST",
 			Handler: _HTTP_EchoService_EchoBody_0,
 		},
-
 		{
 			Path:    "/v1/example/echo_delete",
 			Method:  "DELETE",
 			Handler: _HTTP_EchoService_EchoDelete_0,
 		},
-
 		{
 			Path:    "/v1/example/echo_patch",
 			Method:  "PATCH",
@@ -0,0 +1,110 @@
+package testproto
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	transport "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type echoService struct{}
+
+func (echoService) Echo(ctx context.Context, in *SimpleMessage) (*SimpleMessage, error) {
+	return in, nil
+}
+
+func (echoService) EchoBody(ctx context.Context, in *SimpleMessage) (*SimpleMessage, error) {
+	return in, nil
+}
+
+func (echoService) EchoDelete(ctx context.Context, in *SimpleMessage) (*SimpleMessage, error) {
+	return in, nil
+}
+
+func (echoService) EchoPatch(ctx context.Context, in *DynamicMessageUpdate) (*DynamicMessageUpdate, error) {
+	return in, nil
+}
+
+func TestEchoServiceHTTPServer(t *testing.T) {
+	srv := transport.NewServer()
+	RegisterEchoServiceHTTPServer(srv, echoService{})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   proto.Message
+		reply  proto.Message
+	}{
+		{"POST", "/v1/example/echo/foo?num=1", "", &SimpleMessage{Id: "foo", Num: 1}, new(SimpleMessage)},
+		{"GET", "/v1/example/echo/foo/2/en", "", &SimpleMessage{Id: "foo", Num: 2, Code: &SimpleMessage_Lang{Lang: "en"}}, new(SimpleMessage)},
+		{"GET", "/v1/example/echo2/bar", "", &SimpleMessage{Ext: &SimpleMessage_No{No: &Embedded{Mark: &Embedded_Note{Note: "bar"}}}}, new(SimpleMessage)},
+		{"POST", "/v1/example/echo_body", `{"id":"foo","num":"3"}`, &SimpleMessage{Id: "foo", Num: 3}, new(SimpleMessage)},
+		{"DELETE", "/v1/example/echo_delete?id=foo", "", &SimpleMessage{Id: "foo"}, new(SimpleMessage)},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, ts.URL+test.path, strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("content-type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s: status %d: %s", test.method, test.path, res.StatusCode, data)
+		}
+		if err := protojson.Unmarshal(data, test.reply); err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(test.reply, test.want) {
+			t.Errorf("%s %s: got %v want %v", test.method, test.path, test.reply, test.want)
+		}
+	}
+}
+
+func TestEchoServiceHTTPServerBodyField(t *testing.T) {
+	srv := transport.NewServer()
+	RegisterEchoServiceHTTPServer(srv, echoService{})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	req, err := http.NewRequest("PATCH", ts.URL+"/v1/example/echo_patch?update_mask=struct_field", strings.NewReader(`{"struct_field":{"name":"kratos"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("content-type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply DynamicMessageUpdate
+	if err := protojson.Unmarshal(data, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if got := reply.GetBody().GetStructField().GetFields()["name"].GetStringValue(); got != "kratos" {
+		t.Errorf("body: got %q want %q", got, "kratos")
+	}
+	if got := reply.GetUpdateMask().GetPaths(); len(got) != 1 || got[0] != "struct_field" {
+		t.Errorf("update_mask: got %v", got)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/go-kratos/kratos/cmd/protoc-gen-go-http/internal/testproto
+
+go 1.15
+
+require (
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	github.com/golang/protobuf v1.4.3
+	google.golang.org/genproto v0.0.0-20210114201628-6edceaf6022f
+	google.golang.org/grpc v1.35.0
+	google.golang.org/protobuf v1.25.0
+)
+
+replace github.com/go-kratos/kratos/v2 => ../../../../
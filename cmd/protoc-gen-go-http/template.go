@@ -2,60 +2,72 @@ package main
 
 import (
 	"bytes"
-	"html/template"
 	"strings"
+	"text/template"
 )
 
 var httpTemplate = `
 type {{.ServiceType}}HTTPServer interface {
-{{range .MethodSets}}
+{{- range .MethodSets}}
 	{{.Name}}(context.Context, *{{.Request}}) (*{{.Reply}}, error)
-{{end}}
+{{- end}}
 }
+
 func Register{{.ServiceType}}HTTPServer(s http1.ServiceRegistrar, srv {{.ServiceType}}HTTPServer) {
 	s.RegisterService(&_HTTP_{{.ServiceType}}_serviceDesc, srv)
 }
 {{range .Methods}}
 func _HTTP_{{$.ServiceType}}_{{.Name}}_{{.Num}}(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
 	var in {{.Request}}
-{{if ne (len .Vars) 0}}
-	if err := http1.BindVars(req, &in); err != nil {
+{{- if eq .Body "*"}}
+	if err := dec(&in); err != nil {
 		return nil, err
 	}
-{{end}}
-{{if eq .Body ""}}
-	if err := http1.BindForm(req, &in); err != nil {
+{{- else}}
+{{- range .BodyFields}}{{if .Type}}
+	if {{.Expr}} == nil {
+		{{.Expr}} = new({{.Type}})
+	}
+{{- end}}{{end}}
+{{- if .BodyFields}}
+	if err := dec({{.BodyTarget}}); err != nil {
 		return nil, err
 	}
-{{else if eq .Body ".*"}}
-	if err := dec(&in); err != nil {
+{{- end}}
+	if err := http1.BindQuery(req, &in); err != nil {
 		return nil, err
 	}
-{{else}}
-	if err := dec(in{{.Body}}); err != nil {
+{{- end}}
+{{- if .Vars}}
+	if err := http1.BindVars(req, &in); err != nil {
 		return nil, err
 	}
-{{end}}
+{{- end}}
 	h := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.({{$.ServiceType}}Server).{{.Name}}(ctx, &in)
+		return srv.({{$.ServiceType}}HTTPServer).{{.Name}}(ctx, req.(*{{.Request}}))
 	}
 	out, err := m(h)(ctx, &in)
 	if err != nil {
 		return nil, err
 	}
-	return out{{.ResponseBody}}, nil
+{{- if .ResponseBody}}
+	reply := out.(*{{.Reply}})
+	return reply{{.ResponseBody}}, nil
+{{- else}}
+	return out, nil
+{{- end}}
 }
 {{end}}
 var _HTTP_{{.ServiceType}}_serviceDesc = http1.ServiceDesc{
 	ServiceName: "{{.ServiceName}}",
 	Methods: []http1.MethodDesc{
-{{range .Methods}}
+{{- range .Methods}}
 		{
 			Path:    "{{.Path}}",
 			Method:  "{{.Method}}",
 			Handler: _HTTP_{{$.ServiceType}}_{{.Name}}_{{.Num}},
 		},
-{{end}}
+{{- end}}
 	},
 	Metadata: "{{.Metadata}}",
 }
@@ -66,7 +78,7 @@ type serviceDesc struct {
 	ServiceName string // helloworld.Greeter
 	Metadata    string // api/helloworld/helloworld.proto
 	Methods     []*methodDesc
-	MethodSets  map[string]*methodDesc
+	MethodSets  []*methodDesc
 }
 
 type methodDesc struct {
@@ -81,13 +93,34 @@ type methodDesc struct {
 	Path         string
 	Method       string
 	Body         string
+	BodyFields   []*bodyField
 	ResponseBody string
 }
 
+// bodyField is an accessor on the path of a body field mapping,
+// Type is set when the field is a singular message that must be allocated.
+type bodyField struct {
+	Expr string
+	Type string
+}
+
+// BodyTarget returns the decoding target of a body field mapping.
+func (m *methodDesc) BodyTarget() string {
+	last := m.BodyFields[len(m.BodyFields)-1]
+	if last.Type != "" {
+		return last.Expr
+	}
+	return "&" + last.Expr
+}
+
 func (s *serviceDesc) execute() string {
-	s.MethodSets = make(map[string]*methodDesc)
+	sets := make(map[string]bool)
 	for _, m := range s.Methods {
-		s.MethodSets[m.Name] = m
+		if sets[m.Name] {
+			continue
+		}
+		sets[m.Name] = true
+		s.MethodSets = append(s.MethodSets, m)
 	}
 	buf := new(bytes.Buffer)
 	tmpl, err := template.New("http").Parse(strings.TrimSpace(httpTemplate))
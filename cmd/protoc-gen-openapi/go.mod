@@ -0,0 +1,8 @@
+module github.com/go-kratos/kratos/cmd/protoc-gen-openapi
+
+go 1.15
+
+require (
+	google.golang.org/genproto v0.0.0-20210202153253-cf70463f6119
+	google.golang.org/protobuf v1.25.0
+)
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const version = "0.0.1"
+
+func main() {
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Printf("protoc-gen-openapi %v\n", version)
+		return
+	}
+
+	var flags flag.FlagSet
+	conf := &config{
+		Title:    flags.String("title", "", "the title of the api document"),
+		Version:  flags.String("version", "0.0.1", "the version of the api document"),
+		Filename: flags.String("filename", "openapi.json", "the name of the generated document"),
+	}
+
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		return generateFile(gen, conf)
+	})
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const statusSchema = "kratos.errors.Status"
+
+type config struct {
+	Title    *string
+	Version  *string
+	Filename *string
+}
+
+// document is an OpenAPI v3 document.
+type document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       info                `json:"info"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components components          `json:"components"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type pathItem map[string]*operation
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type components struct {
+	Schemas map[string]*schema `json:"schemas"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+func jsonContent(s *schema) map[string]*mediaType {
+	return map[string]*mediaType{"application/json": {Schema: s}}
+}
+
+func refSchema(name protoreflect.FullName) *schema {
+	return &schema{Ref: "#/components/schemas/" + string(name)}
+}
+
+// generateFile generates an OpenAPI document of all the http rules in the generated files.
+func generateFile(gen *protogen.Plugin, conf *config) error {
+	g := &generator{
+		doc: &document{
+			OpenAPI: "3.0.3",
+			Info:    info{Title: *conf.Title, Version: *conf.Version},
+			Paths:   make(map[string]pathItem),
+			Components: components{Schemas: map[string]*schema{
+				statusSchema: {
+					Type: "object",
+					Properties: map[string]*schema{
+						"code":     {Type: "integer", Format: "int32"},
+						"reason":   {Type: "string"},
+						"message":  {Type: "string"},
+						"metadata": {Type: "object", AdditionalProperties: &schema{Type: "string"}},
+					},
+				},
+			}},
+		},
+		messages: make(map[protoreflect.FullName]*protogen.Message),
+	}
+	var files int
+	for _, f := range gen.Files {
+		if !f.Generate || len(f.Services) == 0 {
+			continue
+		}
+		files++
+		for _, service := range f.Services {
+			if err := g.addService(service); err != nil {
+				return err
+			}
+		}
+	}
+	if files == 0 {
+		return nil
+	}
+	if g.doc.Info.Title == "" {
+		var names []string
+		for _, f := range gen.Files {
+			if f.Generate {
+				for _, s := range f.Services {
+					names = append(names, string(s.Desc.Name()))
+				}
+			}
+		}
+		g.doc.Info.Title = strings.Join(names, ", ")
+	}
+	g.addSchemas()
+	data, err := json.MarshalIndent(g.doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	out := gen.NewGeneratedFile(*conf.Filename, "")
+	_, err = out.Write(append(data, '\n'))
+	return err
+}
+
+type generator struct {
+	doc *document
+	// messages is the referenced messages which are added to the schemas.
+	messages map[protoreflect.FullName]*protogen.Message
+}
+
+func (g *generator) addService(service *protogen.Service) error {
+	for _, method := range service.Methods {
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			continue
+		}
+		rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		for i, r := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+			if err := g.addOperation(service, method, r, i); err != nil {
+				return fmt.Errorf("%s: %v", method.Desc.FullName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) addOperation(service *protogen.Service, m *protogen.Method, rule *annotations.HttpRule, num int) error {
+	var method, path string
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		method, path = "get", pattern.Get
+	case *annotations.HttpRule_Put:
+		method, path = "put", pattern.Put
+	case *annotations.HttpRule_Post:
+		method, path = "post", pattern.Post
+	case *annotations.HttpRule_Delete:
+		method, path = "delete", pattern.Delete
+	case *annotations.HttpRule_Patch:
+		method, path = "patch", pattern.Patch
+	case *annotations.HttpRule_Custom:
+		method, path = strings.ToLower(pattern.Custom.GetKind()), pattern.Custom.GetPath()
+	}
+	if method == "" {
+		return nil
+	}
+	op := &operation{
+		OperationID: fmt.Sprintf("%s_%s", service.Desc.Name(), m.Desc.Name()),
+		Tags:        []string{string(service.Desc.Name())},
+		Description: comment(m.Comments.Leading),
+		Deprecated:  isDeprecated(m.Desc.Options()),
+		Responses: map[string]*response{
+			"default": {Description: "Error response", Content: jsonContent(refSchema(statusSchema))},
+		},
+	}
+	if num > 0 {
+		op.OperationID += fmt.Sprintf("%d", num)
+	}
+	// the path variables, the field paths bound by the path are not the query parameters.
+	bound := make(map[string]bool)
+	for _, v := range pathVarPattern.FindAllStringSubmatch(path, -1) {
+		field, err := lookupField(m.Input, v[1])
+		if err != nil {
+			return err
+		}
+		bound[strings.Split(v[1], ".")[0]] = true
+		op.Parameters = append(op.Parameters, &parameter{
+			Name:        v[1],
+			In:          "path",
+			Description: comment(field.Comments.Leading),
+			Required:    true,
+			Schema:      g.fieldSchema(field),
+		})
+	}
+	switch rule.Body {
+	case "":
+	case "*":
+		op.RequestBody = &requestBody{Required: true, Content: jsonContent(g.messageSchema(m.Input))}
+	default:
+		field, err := lookupField(m.Input, rule.Body)
+		if err != nil {
+			return err
+		}
+		bound[rule.Body] = true
+		op.RequestBody = &requestBody{Required: true, Content: jsonContent(g.fieldSchema(field))}
+	}
+	if rule.Body != "*" {
+		for _, field := range m.Input.Fields {
+			if bound[string(field.Desc.Name())] || field.Desc.IsMap() || field.Message != nil && wellKnownSchema(field.Message) == nil {
+				continue
+			}
+			op.Parameters = append(op.Parameters, &parameter{
+				Name:        string(field.Desc.Name()),
+				In:          "query",
+				Description: comment(field.Comments.Leading),
+				Schema:      g.fieldSchema(field),
+			})
+		}
+	}
+	var reply *schema
+	if rule.ResponseBody != "" {
+		field, err := lookupField(m.Output, rule.ResponseBody)
+		if err != nil {
+			return err
+		}
+		reply = g.fieldSchema(field)
+	} else {
+		reply = g.messageSchema(m.Output)
+	}
+	op.Responses["200"] = &response{Description: "OK", Content: jsonContent(reply)}
+
+	path = pathVarPattern.ReplaceAllString(path, "{$1}")
+	item, ok := g.doc.Paths[path]
+	if !ok {
+		item = make(pathItem)
+		g.doc.Paths[path] = item
+	}
+	item[method] = op
+	return nil
+}
+
+// pathVarPattern matches a template variable such as {name} or {name=shelves/*/books/**}.
+var pathVarPattern = regexp.MustCompile(`{([^=}]+)(?:=([^}]*))?}`)
+
+// lookupField resolves a dotted field path such as "book.author" against msg.
+func lookupField(msg *protogen.Message, path string) (*protogen.Field, error) {
+	var field *protogen.Field
+	for _, name := range strings.Split(path, ".") {
+		if msg == nil {
+			return nil, fmt.Errorf("%q is not a message field path", path)
+		}
+		field = nil
+		for _, f := range msg.Fields {
+			if string(f.Desc.Name()) == name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil, fmt.Errorf("field %q not found in %s", name, msg.Desc.FullName())
+		}
+		msg = field.Message
+	}
+	return field, nil
+}
+
+func (g *generator) addMessage(m *protogen.Message) {
+	if wellKnownSchema(m) != nil {
+		return
+	}
+	if _, ok := g.messages[m.Desc.FullName()]; ok {
+		return
+	}
+	g.messages[m.Desc.FullName()] = m
+	for _, f := range m.Fields {
+		if f.Message != nil {
+			g.addMessage(f.Message)
+		}
+	}
+}
+
+func (g *generator) addSchemas() {
+	names := make([]string, 0, len(g.messages))
+	for name := range g.messages {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m := g.messages[protoreflect.FullName(name)]
+		s := &schema{
+			Type:        "object",
+			Description: comment(m.Comments.Leading),
+			Properties:  make(map[string]*schema),
+		}
+		for _, f := range m.Fields {
+			fs := g.fieldSchema(f)
+			if d := comment(f.Comments.Leading); d != "" && fs.Ref == "" {
+				fs.Description = d
+			}
+			s.Properties[f.Desc.JSONName()] = fs
+		}
+		g.doc.Components.Schemas[name] = s
+	}
+}
+
+func (g *generator) fieldSchema(f *protogen.Field) *schema {
+	if f.Desc.IsMap() {
+		return &schema{Type: "object", AdditionalProperties: g.singularSchema(f.Message.Fields[1])}
+	}
+	s := g.singularSchema(f)
+	if f.Desc.IsList() {
+		return &schema{Type: "array", Items: s}
+	}
+	return s
+}
+
+func (g *generator) singularSchema(f *protogen.Field) *schema {
+	switch f.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return &schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		s := &schema{Type: "string"}
+		for _, v := range f.Enum.Values {
+			s.Enum = append(s.Enum, string(v.Desc.Name()))
+		}
+		return s
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageSchema(f.Message)
+	}
+	return &schema{}
+}
+
+// messageSchema returns the schema of the well-known type, or a reference to the message schema.
+func (g *generator) messageSchema(m *protogen.Message) *schema {
+	if s := wellKnownSchema(m); s != nil {
+		return s
+	}
+	g.addMessage(m)
+	return refSchema(m.Desc.FullName())
+}
+
+// wellKnownSchema returns the schema of the well-known types by their JSON mapping.
+func wellKnownSchema(m *protogen.Message) *schema {
+	switch m.Desc.FullName() {
+	case "google.protobuf.Timestamp":
+		return &schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration", "google.protobuf.FieldMask", "google.protobuf.StringValue":
+		return &schema{Type: "string"}
+	case "google.protobuf.Int64Value":
+		return &schema{Type: "string", Format: "int64"}
+	case "google.protobuf.UInt64Value":
+		return &schema{Type: "string", Format: "uint64"}
+	case "google.protobuf.Int32Value":
+		return &schema{Type: "integer", Format: "int32"}
+	case "google.protobuf.UInt32Value":
+		return &schema{Type: "integer", Format: "uint32"}
+	case "google.protobuf.FloatValue":
+		return &schema{Type: "number", Format: "float"}
+	case "google.protobuf.DoubleValue":
+		return &schema{Type: "number", Format: "double"}
+	case "google.protobuf.BoolValue":
+		return &schema{Type: "boolean"}
+	case "google.protobuf.BytesValue":
+		return &schema{Type: "string", Format: "byte"}
+	case "google.protobuf.Struct", "google.protobuf.Empty", "google.protobuf.Any":
+		return &schema{Type: "object"}
+	case "google.protobuf.ListValue":
+		return &schema{Type: "array", Items: &schema{}}
+	case "google.protobuf.Value":
+		return &schema{}
+	}
+	return nil
+}
+
+func comment(c protogen.Comments) string {
+	return strings.TrimSpace(string(c))
+}
+
+func isDeprecated(opts proto.Message) bool {
+	type deprecated interface {
+		GetDeprecated() bool
+	}
+	d, ok := opts.(deprecated)
+	return ok && d.GetDeprecated()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func httpRule(rule *annotations.HttpRule) *descriptorpb.MethodOptions {
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, annotations.E_Http, rule)
+	return opts
+}
+
+func field(name, jsonName string, num int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(jsonName),
+		Number:   proto.Int32(num),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func testRequest() *pluginpb.CodeGeneratorRequest {
+	name := field("name", "name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	id := field("id", "id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, "")
+	tags := field("tags", "tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	tags.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	createTime := field("create_time", "createTime", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp")
+	page := field("page", "page", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, "")
+	book := field("book", "book", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".library.v1.Book")
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("library/v1/library.proto"),
+		Package:    proto.String("library.v1"),
+		Dependency: []string{"google/api/annotations.proto", "google/protobuf/timestamp.proto"},
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/library/v1;v1")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Book"), Field: []*descriptorpb.FieldDescriptorProto{name, id, tags, createTime}},
+			{Name: proto.String("GetBookRequest"), Field: []*descriptorpb.FieldDescriptorProto{name, page}},
+			{Name: proto.String("UpdateBookRequest"), Field: []*descriptorpb.FieldDescriptorProto{book}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Library"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("GetBook"),
+					InputType:  proto.String(".library.v1.GetBookRequest"),
+					OutputType: proto.String(".library.v1.Book"),
+					Options:    httpRule(&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=books/*}"}}),
+				},
+				{
+					Name:       proto.String("UpdateBook"),
+					InputType:  proto.String(".library.v1.UpdateBookRequest"),
+					OutputType: proto.String(".library.v1.Book"),
+					Options: httpRule(&annotations.HttpRule{
+						Pattern: &annotations.HttpRule_Patch{Patch: "/v1/{book.name=books/*}"},
+						Body:    "book",
+					}),
+				},
+			},
+		}},
+	}
+	var files []*descriptorpb.FileDescriptorProto
+	for _, fd := range []protoreflect.FileDescriptor{
+		descriptorpb.File_google_protobuf_descriptor_proto,
+		annotations.File_google_api_http_proto,
+		annotations.File_google_api_annotations_proto,
+		timestamppb.File_google_protobuf_timestamp_proto,
+	} {
+		files = append(files, protodesc.ToFileDescriptorProto(fd))
+	}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      append(files, file),
+	}
+}
+
+func TestGenerateFile(t *testing.T) {
+	gen, err := protogen.Options{}.New(testRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	title, version, filename := "", "v1", "openapi.json"
+	if err := generateFile(gen, &config{Title: &title, Version: &version, Filename: &filename}); err != nil {
+		t.Fatal(err)
+	}
+	res := gen.Response()
+	if res.Error != nil {
+		t.Fatal(res.GetError())
+	}
+	if len(res.File) != 1 || res.File[0].GetName() != filename {
+		t.Fatalf("unexpected files: %v", res.File)
+	}
+	var doc document
+	if err := json.Unmarshal([]byte(res.File[0].GetContent()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Info.Title != "Library" || doc.Info.Version != "v1" {
+		t.Errorf("unexpected document: %+v %+v", doc.OpenAPI, doc.Info)
+	}
+
+	get := doc.Paths["/v1/{name}"]["get"]
+	if get == nil || get.OperationID != "Library_GetBook" {
+		t.Fatalf("unexpected paths: %v", doc.Paths)
+	}
+	if len(get.Parameters) != 2 ||
+		get.Parameters[0].Name != "name" || get.Parameters[0].In != "path" || !get.Parameters[0].Required ||
+		get.Parameters[1].Name != "page" || get.Parameters[1].In != "query" || get.Parameters[1].Schema.Format != "int32" {
+		t.Errorf("unexpected parameters: %+v", get.Parameters)
+	}
+	if ref := get.Responses["200"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/library.v1.Book" {
+		t.Errorf("unexpected response: %s", ref)
+	}
+
+	patch := doc.Paths["/v1/{book.name}"]["patch"]
+	if patch == nil || patch.RequestBody == nil {
+		t.Fatalf("unexpected paths: %v", doc.Paths)
+	}
+	if len(patch.Parameters) != 1 || patch.Parameters[0].Name != "book.name" {
+		t.Errorf("unexpected parameters: %+v", patch.Parameters)
+	}
+	if ref := patch.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/library.v1.Book" {
+		t.Errorf("unexpected request body: %s", ref)
+	}
+
+	book := doc.Components.Schemas["library.v1.Book"]
+	if book == nil {
+		t.Fatalf("unexpected schemas: %v", doc.Components.Schemas)
+	}
+	if s := book.Properties["id"]; s == nil || s.Type != "string" || s.Format != "int64" {
+		t.Errorf("unexpected id schema: %+v", s)
+	}
+	if s := book.Properties["tags"]; s == nil || s.Type != "array" || s.Items.Type != "string" {
+		t.Errorf("unexpected tags schema: %+v", s)
+	}
+	if s := book.Properties["createTime"]; s == nil || s.Format != "date-time" {
+		t.Errorf("unexpected createTime schema: %+v", s)
+	}
+	if _, ok := doc.Components.Schemas["library.v1.GetBookRequest"]; ok {
+		t.Error("unexpected schema of the request without body")
+	}
+	if _, ok := doc.Components.Schemas[statusSchema]; !ok {
+		t.Error("missing the error schema")
+	}
+}
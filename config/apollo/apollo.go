@@ -0,0 +1,215 @@
+package apollo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+const (
+	// properties namespace has no format suffix.
+	propertiesFormat = "properties"
+	// the configurations key holding the raw content of non-properties namespaces.
+	contentKey = "content"
+)
+
+var _ config.Source = (*apollo)(nil)
+
+type apollo struct {
+	opts options
+}
+
+// apolloConfig is the response of the apollo configs api.
+type apolloConfig struct {
+	AppID          string            `json:"appId"`
+	Cluster        string            `json:"cluster"`
+	NamespaceName  string            `json:"namespaceName"`
+	Configurations map[string]string `json:"configurations"`
+	ReleaseKey     string            `json:"releaseKey"`
+}
+
+// NewSource new an apollo config source.
+func NewSource(opts ...Option) config.Source {
+	options := options{
+		cluster:    "default",
+		namespaces: []string{"application"},
+		client:     &http.Client{Timeout: 90 * time.Second},
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &apollo{opts: options}
+}
+
+func (a *apollo) Load() ([]*config.KeyValue, error) {
+	kvs := make([]*config.KeyValue, 0, len(a.opts.namespaces))
+	for _, ns := range a.opts.namespaces {
+		kv, err := a.load(context.Background(), ns)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs, nil
+}
+
+func (a *apollo) Watch() (config.Watcher, error) {
+	return newWatcher(a), nil
+}
+
+// load fetches the namespace, falling back to the local backup when apollo is unreachable.
+func (a *apollo) load(ctx context.Context, ns string) (*config.KeyValue, error) {
+	c, err := a.fetch(ctx, ns)
+	if err != nil {
+		var berr error
+		if c, berr = a.readBackup(ns); berr != nil {
+			return nil, err
+		}
+	} else if err = a.writeBackup(ns, c); err != nil {
+		return nil, err
+	}
+	return toKeyValue(ns, c)
+}
+
+func (a *apollo) fetch(ctx context.Context, ns string) (*apolloConfig, error) {
+	path := fmt.Sprintf("/configs/%s/%s/%s?ip=%s",
+		url.PathEscape(a.opts.appID), url.PathEscape(a.opts.cluster), url.PathEscape(ns), url.QueryEscape(a.opts.ip))
+	data, status, err := a.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("apollo: failed to fetch namespace %s: status %d", ns, status)
+	}
+	var c apolloConfig
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (a *apollo) get(ctx context.Context, path string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(a.opts.endpoint, "/")+path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if a.opts.secret != "" {
+		ts := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+		req.Header.Set("Authorization", fmt.Sprintf("Apollo %s:%s", a.opts.appID, sign(a.opts.secret, ts, path)))
+		req.Header.Set("Timestamp", ts)
+	}
+	res, err := a.opts.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, res.StatusCode, nil
+}
+
+func (a *apollo) backupFile(ns string) string {
+	return filepath.Join(a.opts.backupPath, fmt.Sprintf("%s-%s-%s.json", a.opts.appID, a.opts.cluster, ns))
+}
+
+func (a *apollo) writeBackup(ns string, c *apolloConfig) error {
+	if a.opts.backupPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(a.opts.backupPath, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(a.backupFile(ns), data, 0644)
+}
+
+func (a *apollo) readBackup(ns string) (*apolloConfig, error) {
+	if a.opts.backupPath == "" {
+		return nil, os.ErrNotExist
+	}
+	data, err := ioutil.ReadFile(a.backupFile(ns))
+	if err != nil {
+		return nil, err
+	}
+	var c apolloConfig
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// sign signs the request with the apollo access key.
+func sign(secret, timestamp, pathWithQuery string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + pathWithQuery))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// format returns the namespace format by its suffix, such as application.yaml.
+func format(ns string) string {
+	switch ext := strings.TrimPrefix(filepath.Ext(ns), "."); ext {
+	case "yaml", "yml":
+		return "yaml"
+	case "json", "xml", "toml":
+		return ext
+	default:
+		return propertiesFormat
+	}
+}
+
+func toKeyValue(ns string, c *apolloConfig) (*config.KeyValue, error) {
+	kv := &config.KeyValue{
+		Key:      ns,
+		Format:   format(ns),
+		Metadata: map[string]string{"releaseKey": c.ReleaseKey},
+	}
+	if kv.Format != propertiesFormat {
+		kv.Value = []byte(c.Configurations[contentKey])
+		return kv, nil
+	}
+	// properties keys such as server.http.addr are expanded into nested values.
+	values := make(map[string]interface{})
+	for key, value := range c.Configurations {
+		next := values
+		keys := strings.Split(key, ".")
+		for i, k := range keys {
+			if i == len(keys)-1 {
+				if _, ok := next[k].(map[string]interface{}); !ok {
+					next[k] = value
+				}
+				break
+			}
+			child, ok := next[k].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				next[k] = child
+			}
+			next = child
+		}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	kv.Value = data
+	kv.Format = "json"
+	return kv, nil
+}
@@ -0,0 +1,67 @@
+package apollo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+func TestSource(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/configs/kratos/default/application", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(apolloConfig{
+			AppID:          "kratos",
+			Cluster:        "default",
+			NamespaceName:  "application",
+			Configurations: map[string]string{"server.http.addr": "0.0.0.0:8000", "server.http.timeout": "1"},
+			ReleaseKey:     "1",
+		})
+	})
+	mux.HandleFunc("/configs/kratos/default/data.yaml", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(apolloConfig{
+			NamespaceName:  "data.yaml",
+			Configurations: map[string]string{"content": "data:\n  database:\n    driver: mysql\n"},
+		})
+	})
+	srv := httptest.NewServer(mux)
+
+	dir, err := ioutil.TempDir("", "apollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := []Option{
+		WithAppID("kratos"),
+		WithEndpoint(srv.URL),
+		WithNamespace("application", "data.yaml"),
+		WithBackupPath(dir),
+	}
+	testConfig(t, NewSource(opts...))
+
+	// apollo is unreachable, config is loaded from the backup.
+	srv.Close()
+	testConfig(t, NewSource(opts...))
+}
+
+func testConfig(t *testing.T, s config.Source) {
+	c := config.New(config.WithSource(s))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if v, err := c.Value("server.http.addr").String(); err != nil || v != "0.0.0.0:8000" {
+		t.Errorf("unexpected server.http.addr: %v %v", v, err)
+	}
+	if v, err := c.Value("server.http.timeout").Int(); err != nil || v != 1 {
+		t.Errorf("unexpected server.http.timeout: %v %v", v, err)
+	}
+	if v, err := c.Value("data.database.driver").String(); err != nil || v != "mysql" {
+		t.Errorf("unexpected data.database.driver: %v %v", v, err)
+	}
+}
@@ -0,0 +1,75 @@
+package apollo
+
+import (
+	"net/http"
+)
+
+// Option is apollo option.
+type Option func(*options)
+
+type options struct {
+	appID      string
+	cluster    string
+	endpoint   string
+	ip         string
+	secret     string
+	namespaces []string
+	backupPath string
+	client     *http.Client
+}
+
+// WithAppID with apollo app id.
+func WithAppID(appID string) Option {
+	return func(o *options) {
+		o.appID = appID
+	}
+}
+
+// WithCluster with apollo cluster.
+func WithCluster(cluster string) Option {
+	return func(o *options) {
+		o.cluster = cluster
+	}
+}
+
+// WithEndpoint with apollo config service address, such as http://localhost:8080.
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithIP with the client ip reported for gray releases.
+func WithIP(ip string) Option {
+	return func(o *options) {
+		o.ip = ip
+	}
+}
+
+// WithSecret with apollo access key secret.
+func WithSecret(secret string) Option {
+	return func(o *options) {
+		o.secret = secret
+	}
+}
+
+// WithNamespace with apollo namespaces.
+func WithNamespace(ns ...string) Option {
+	return func(o *options) {
+		o.namespaces = ns
+	}
+}
+
+// WithBackupPath with the local failover cache directory.
+func WithBackupPath(path string) Option {
+	return func(o *options) {
+		o.backupPath = path
+	}
+}
+
+// WithHTTPClient with http client.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
@@ -0,0 +1,113 @@
+package apollo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+var _ config.Watcher = (*watcher)(nil)
+
+type notification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationID int64  `json:"notificationId"`
+}
+
+type watcher struct {
+	a      *apollo
+	ctx    context.Context
+	cancel context.CancelFunc
+	// notification id of every namespace, -1 means never notified.
+	ids map[string]int64
+}
+
+func newWatcher(a *apollo) config.Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &watcher{
+		a:      a,
+		ctx:    ctx,
+		cancel: cancel,
+		ids:    make(map[string]int64, len(a.opts.namespaces)),
+	}
+	for _, ns := range a.opts.namespaces {
+		w.ids[ns] = -1
+	}
+	return w
+}
+
+// Next long polls apollo notifications and returns the changed namespaces.
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	for {
+		changed, err := w.poll()
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			return nil, err
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		kvs := make([]*config.KeyValue, 0, len(changed))
+		for _, ns := range changed {
+			kv, err := w.a.load(w.ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			kvs = append(kvs, kv)
+		}
+		return kvs, nil
+	}
+}
+
+func (w *watcher) poll() ([]string, error) {
+	ns := make([]notification, 0, len(w.ids))
+	for name, id := range w.ids {
+		ns = append(ns, notification{NamespaceName: name, NotificationID: id})
+	}
+	data, err := json.Marshal(ns)
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/notifications/v2?appId=%s&cluster=%s&notifications=%s",
+		url.QueryEscape(w.a.opts.appID), url.QueryEscape(w.a.opts.cluster), url.QueryEscape(string(data)))
+	body, status, err := w.a.get(w.ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		// back off before the next long polling
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-time.After(time.Second):
+		}
+		return nil, fmt.Errorf("apollo: failed to poll notifications: status %d", status)
+	}
+	var res []notification
+	if err = json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	changed := make([]string, 0, len(res))
+	for _, n := range res {
+		if id, ok := w.ids[n.NamespaceName]; ok && id != n.NotificationID {
+			w.ids[n.NamespaceName] = n.NotificationID
+			changed = append(changed, n.NamespaceName)
+		}
+	}
+	return changed, nil
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	return nil
+}
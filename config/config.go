@@ -1,13 +1,20 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"reflect"
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/log"
+
+	// init encoding
+	_ "github.com/go-kratos/kratos/v2/encoding/json"
+	_ "github.com/go-kratos/kratos/v2/encoding/toml"
+	_ "github.com/go-kratos/kratos/v2/encoding/yaml"
 )
 
 var (
@@ -43,10 +50,14 @@ type config struct {
 // New new a config with options.
 func New(opts ...Option) Config {
 	options := options{
-		logger: log.DefaultLogger,
+		logger: log.GetLogger(),
 		decoder: func(kv *KeyValue, v map[string]interface{}) error {
+			if codec := encoding.GetCodec(kv.Format); codec != nil {
+				return codec.Unmarshal(kv.Value, &v)
+			}
 			return json.Unmarshal(kv.Value, &v)
 		},
+		resolver: defaultResolver,
 	}
 	for _, o := range opts {
 		o(&options)
@@ -61,6 +72,10 @@ func New(opts ...Option) Config {
 func (c *config) watch(w Watcher) {
 	for {
 		kvs, err := w.Next()
+		if errors.Is(err, context.Canceled) {
+			c.log.Infof("watcher's ctx cancel : %v", err)
+			return
+		}
 		if err != nil {
 			time.Sleep(time.Second)
 			c.log.Errorf("Failed to watch next config: %v", err)
@@ -99,6 +114,7 @@ func (c *config) Load() error {
 			c.log.Errorf("Failed to watch config source: %v", err)
 			return err
 		}
+		c.watchers = append(c.watchers, w)
 		go c.watch(w)
 	}
 	return nil
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+var _ config.Source = (*env)(nil)
+
+type env struct {
+	prefixes []string
+}
+
+// NewSource new an env source, environment variables such as APP_SERVER_GRPC_ADDR
+// are mapped to the nested key server.grpc.addr when prefix APP_ is given.
+// Without prefixes all environment variables are loaded.
+func NewSource(prefixes ...string) config.Source {
+	return &env{prefixes: prefixes}
+}
+
+func (e *env) Load() (kvs []*config.KeyValue, err error) {
+	values := make(map[string]interface{})
+	for _, envstr := range os.Environ() {
+		kv := strings.SplitN(envstr, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+		key, ok := e.trimPrefix(kv[0])
+		if !ok || key == "" {
+			continue
+		}
+		set(values, strings.Split(strings.ToLower(key), "_"), coerce(kv[1]))
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return []*config.KeyValue{{
+		Key:    "env",
+		Value:  data,
+		Format: "json",
+	}}, nil
+}
+
+func (e *env) Watch() (config.Watcher, error) {
+	return newWatcher(), nil
+}
+
+func (e *env) trimPrefix(key string) (string, bool) {
+	if len(e.prefixes) == 0 {
+		return key, true
+	}
+	for _, prefix := range e.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return strings.TrimLeft(strings.TrimPrefix(key, prefix), "_"), true
+		}
+	}
+	return "", false
+}
+
+// set puts the value into the nested map, a shorter key never overrides an existing node.
+func set(values map[string]interface{}, keys []string, value interface{}) {
+	next := values
+	for i, key := range keys {
+		if i == len(keys)-1 {
+			if _, ok := next[key].(map[string]interface{}); !ok {
+				next[key] = value
+			}
+			return
+		}
+		child, ok := next[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			next[key] = child
+		}
+		next = child
+	}
+}
+
+// coerce converts the raw string to a bool or number if possible.
+func coerce(s string) interface{} {
+	if b, err := strconv.ParseBool(s); err == nil && strings.ToLower(s) == strconv.FormatBool(b) {
+		return b
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
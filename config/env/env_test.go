@@ -0,0 +1,49 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+)
+
+func TestEnvWithPrefix(t *testing.T) {
+	os.Setenv("KRATOS_SERVER_GRPC_ADDR", "0.0.0.0:9000")
+	os.Setenv("KRATOS_SERVER_GRPC_TIMEOUT", "2")
+	os.Setenv("KRATOS_DEBUG", "true")
+	defer func() {
+		os.Unsetenv("KRATOS_SERVER_GRPC_ADDR")
+		os.Unsetenv("KRATOS_SERVER_GRPC_TIMEOUT")
+		os.Unsetenv("KRATOS_DEBUG")
+	}()
+
+	path := filepath.Join(os.TempDir(), "test_env_config.json")
+	defer os.Remove(path)
+	data := []byte(`{"server":{"grpc":{"addr":"127.0.0.1:9000","network":"tcp"}}}`)
+	if err := ioutil.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New(config.WithSource(
+		file.NewSource(path),
+		NewSource("KRATOS_"),
+	))
+	defer c.Close()
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Value("server.grpc.addr").String(); err != nil || v != "0.0.0.0:9000" {
+		t.Errorf("expected env override, got %v %v", v, err)
+	}
+	if v, err := c.Value("server.grpc.network").String(); err != nil || v != "tcp" {
+		t.Errorf("expected file value, got %v %v", v, err)
+	}
+	if v, err := c.Value("server.grpc.timeout").Int(); err != nil || v != 2 {
+		t.Errorf("expected int value, got %v %v", v, err)
+	}
+	if v, err := c.Value("debug").Bool(); err != nil || !v {
+		t.Errorf("expected bool value, got %v %v", v, err)
+	}
+}
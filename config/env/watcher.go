@@ -0,0 +1,30 @@
+package env
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+var _ config.Watcher = (*watcher)(nil)
+
+// watcher blocks until closed, since environment variables are fixed once the process started.
+type watcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWatcher() config.Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{ctx: ctx, cancel: cancel}
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	return nil
+}
@@ -36,6 +36,7 @@ func (f *file) loadFile(path string) (*config.KeyValue, error) {
 	}
 	return &config.KeyValue{
 		Key:       info.Name(),
+		Format:    format(info.Name()),
 		Value:     data,
 		Timestamp: info.ModTime(),
 	}, nil
@@ -78,3 +79,13 @@ func (f *file) Load() (kvs []*config.KeyValue, err error) {
 func (f *file) Watch() (config.Watcher, error) {
 	return newWatcher(f)
 }
+
+// format returns the codec name of the file by its extension.
+func format(name string) string {
+	switch ext := strings.TrimPrefix(filepath.Ext(name), "."); ext {
+	case "yml":
+		return "yaml"
+	default:
+		return ext
+	}
+}
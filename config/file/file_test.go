@@ -140,3 +140,63 @@ func testConfig(t *testing.T, c config.Config) {
 	}
 
 }
+
+const _testYAML = `
+test:
+  settings:
+    int_key: 1000
+    float_key: 1000.1
+    duration_key: 10000
+    string_key: string_value
+  server:
+    addr: 127.0.0.1
+    port: 8000
+`
+
+func TestConfigYAML(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "test_config.yaml")
+	defer os.Remove(path)
+	if err := ioutil.WriteFile(path, []byte(_testYAML), 0666); err != nil {
+		t.Error(err)
+	}
+	c := config.New(config.WithSource(
+		NewSource(path),
+	))
+	testConfig(t, c)
+	if err := c.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigWatch(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "test_config_watch.json")
+	defer os.Remove(path)
+	if err := ioutil.WriteFile(path, []byte(`{"server":{"addr":"127.0.0.1"}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New(config.WithSource(
+		NewSource(path),
+	))
+	defer c.Close()
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan string, 1)
+	if err := c.Watch("server.addr", func(key string, value config.Value) {
+		v, _ := value.String()
+		done <- v
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(`{"server":{"addr":"0.0.0.0"}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-done:
+		if v != "0.0.0.0" {
+			t.Fatalf("expected changed value, got %s", v)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatal("timeout waiting for config change")
+	}
+}
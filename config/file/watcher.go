@@ -1,8 +1,10 @@
 package file
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-kratos/kratos/v2/config"
@@ -11,6 +13,9 @@ import (
 type watcher struct {
 	f  *file
 	fw *fsnotify.Watcher
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func newWatcher(f *file) (config.Watcher, error) {
@@ -19,12 +24,18 @@ func newWatcher(f *file) (config.Watcher, error) {
 		return nil, err
 	}
 	fw.Add(f.path)
-	return &watcher{f: f, fw: fw}, nil
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{f: f, fw: fw, ctx: ctx, cancel: cancel}, nil
 }
 
 func (w *watcher) Next() ([]*config.KeyValue, error) {
 	select {
-	case event := <-w.fw.Events:
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case event, ok := <-w.fw.Events:
+		if !ok {
+			return nil, context.Canceled
+		}
 		if event.Op == fsnotify.Rename {
 			if _, err := os.Stat(event.Name); err == nil || os.IsExist(err) {
 				w.fw.Add(event.Name)
@@ -36,7 +47,11 @@ func (w *watcher) Next() ([]*config.KeyValue, error) {
 		}
 		path := w.f.path
 		if fi.IsDir() {
-			path = filepath.Join(w.f.path, event.Name)
+			// ignore hidden files and removed files of the directory
+			if strings.HasPrefix(filepath.Base(event.Name), ".") || event.Op&fsnotify.Remove == fsnotify.Remove {
+				return nil, nil
+			}
+			path = event.Name
 		}
 		kv, err := w.f.loadFile(path)
 		if err != nil {
@@ -49,5 +64,6 @@ func (w *watcher) Next() ([]*config.KeyValue, error) {
 }
 
 func (w *watcher) Close() error {
+	w.cancel()
 	return w.fw.Close()
 }
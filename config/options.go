@@ -1,19 +1,28 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
 	"github.com/go-kratos/kratos/v2/log"
 )
 
 // Decoder is config decoder.
 type Decoder func(*KeyValue, map[string]interface{}) error
 
+// Resolver resolve placeholder in config.
+type Resolver func(map[string]interface{}) error
+
 // Option is config option.
 type Option func(*options)
 
 type options struct {
-	sources []Source
-	decoder Decoder
-	logger  log.Logger
+	sources  []Source
+	decoder  Decoder
+	resolver Resolver
+	logger   log.Logger
 }
 
 // WithSource with config source.
@@ -30,9 +39,118 @@ func WithDecoder(d Decoder) Option {
 	}
 }
 
+// WithResolver with config resolver.
+func WithResolver(r Resolver) Option {
+	return func(o *options) {
+		o.resolver = r
+	}
+}
+
 // WithLogger with config loogger.
 func WithLogger(l log.Logger) Option {
 	return func(o *options) {
 		o.logger = l
 	}
 }
+
+var (
+	// ${ENV_VAR:default} is replaced by the environment variable.
+	envPattern = regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
+	// $(other.key) is replaced by the value of another config key.
+	keyPattern = regexp.MustCompile(`\$\(([^)]+)\)`)
+)
+
+// maxResolveDepth bounds nested $(key) references, which also breaks reference cycles.
+const maxResolveDepth = 10
+
+// defaultResolver resolves ${ENV_VAR:default} and $(other.key) placeholders.
+func defaultResolver(input map[string]interface{}) error {
+	var resolve func(v interface{}, depth int) (interface{}, error)
+	resolve = func(v interface{}, depth int) (interface{}, error) {
+		switch vt := v.(type) {
+		case map[string]interface{}:
+			for k, v := range vt {
+				r, err := resolve(v, depth)
+				if err != nil {
+					return nil, err
+				}
+				vt[k] = r
+			}
+			return vt, nil
+		case []interface{}:
+			for i, v := range vt {
+				r, err := resolve(v, depth)
+				if err != nil {
+					return nil, err
+				}
+				vt[i] = r
+			}
+			return vt, nil
+		case string:
+			if depth > maxResolveDepth {
+				return nil, fmt.Errorf("config: placeholder nested too deep: %s", vt)
+			}
+			s := envPattern.ReplaceAllStringFunc(vt, func(m string) string {
+				sub := envPattern.FindStringSubmatch(m)
+				if env, ok := os.LookupEnv(strings.TrimSpace(sub[1])); ok {
+					return env
+				}
+				return sub[2]
+			})
+			// keep the type of the referenced value when the whole string is a reference.
+			if sub := keyPattern.FindStringSubmatch(s); sub != nil && sub[0] == s {
+				ref, ok := lookup(input, sub[1])
+				if !ok {
+					return nil, fmt.Errorf("config: placeholder key not found: %s", sub[1])
+				}
+				return resolve(ref, depth+1)
+			}
+			var rerr error
+			s = keyPattern.ReplaceAllStringFunc(s, func(m string) string {
+				key := keyPattern.FindStringSubmatch(m)[1]
+				ref, ok := lookup(input, key)
+				if !ok {
+					rerr = fmt.Errorf("config: placeholder key not found: %s", key)
+					return m
+				}
+				r, err := resolve(ref, depth+1)
+				if err != nil {
+					rerr = err
+					return m
+				}
+				return fmt.Sprint(r)
+			})
+			if rerr != nil {
+				return nil, rerr
+			}
+			return s, nil
+		default:
+			return v, nil
+		}
+	}
+	_, err := resolve(input, 0)
+	return err
+}
+
+func lookup(values map[string]interface{}, path string) (interface{}, bool) {
+	var (
+		next = values
+		keys = strings.Split(strings.TrimSpace(path), ".")
+		last = len(keys) - 1
+	)
+	for idx, key := range keys {
+		value, ok := next[key]
+		if !ok {
+			return nil, false
+		}
+		if idx == last {
+			return value, true
+		}
+		vm, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		next = vm
+	}
+	return nil, false
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDefaultResolver(t *testing.T) {
+	os.Setenv("KRATOS_TEST_PORT", "8080")
+	defer os.Unsetenv("KRATOS_TEST_PORT")
+
+	data := map[string]interface{}{
+		"app": map[string]interface{}{
+			"name": "kratos",
+			"port": float64(9000),
+		},
+		"server": map[string]interface{}{
+			"addr":     "0.0.0.0:${KRATOS_TEST_PORT:8000}",
+			"host":     "${KRATOS_TEST_HOST:localhost}",
+			"name":     "$(app.name)-server",
+			"port":     "$(app.port)",
+			"endpoint": "$(server.host):$(app.port)",
+		},
+	}
+	if err := defaultResolver(data); err != nil {
+		t.Fatal(err)
+	}
+	server := data["server"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"addr":     "0.0.0.0:8080",
+		"host":     "localhost",
+		"name":     "kratos-server",
+		"port":     float64(9000),
+		"endpoint": "localhost:9000",
+	}
+	for k, v := range expected {
+		if server[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, server[k])
+		}
+	}
+}
+
+func TestDefaultResolverNotFound(t *testing.T) {
+	data := map[string]interface{}{
+		"name": "$(not.found)",
+	}
+	if err := defaultResolver(data); err == nil {
+		t.Fatal("expected not found error")
+	}
+}
+
+func TestDefaultResolverCycle(t *testing.T) {
+	data := map[string]interface{}{
+		"a": "$(b)",
+		"b": "$(a)",
+	}
+	if err := defaultResolver(data); err == nil {
+		t.Fatal("expected nested too deep error")
+	}
+}
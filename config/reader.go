@@ -18,12 +18,15 @@ type Reader interface {
 type reader struct {
 	opts   options
 	values map[string]interface{}
+	// resolved is the values with placeholders resolved.
+	resolved map[string]interface{}
 }
 
 func newReader(opts options) Reader {
 	return &reader{
-		opts:   opts,
-		values: make(map[string]interface{}),
+		opts:     opts,
+		values:   make(map[string]interface{}),
+		resolved: make(map[string]interface{}),
 	}
 }
 
@@ -41,13 +44,23 @@ func (r *reader) Merge(kvs ...*KeyValue) error {
 			return err
 		}
 	}
+	resolved, err := cloneMap(merged)
+	if err != nil {
+		return err
+	}
+	if r.opts.resolver != nil {
+		if err := r.opts.resolver(resolved); err != nil {
+			return err
+		}
+	}
 	r.values = merged
+	r.resolved = resolved
 	return nil
 }
 
 func (r *reader) Value(path string) (Value, bool) {
 	var (
-		next = r.values
+		next = r.resolved
 		keys = strings.Split(path, ".")
 		last = len(keys) - 1
 	)
@@ -72,7 +85,7 @@ func (r *reader) Value(path string) (Value, bool) {
 }
 
 func (r *reader) Source() ([]byte, error) {
-	return json.Marshal(r.values)
+	return json.Marshal(r.resolved)
 }
 
 func cloneMap(src map[string]interface{}) (map[string]interface{}, error) {
@@ -6,6 +6,7 @@ import "time"
 type KeyValue struct {
 	Key       string
 	Value     []byte
+	Format    string
 	Metadata  map[string]string
 	Timestamp time.Time
 }
@@ -0,0 +1,11 @@
+module github.com/go-kratos/kratos/contrib/apikey/redis/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/alicebob/miniredis/v2 v2.16.0
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	github.com/go-redis/redis/v8 v8.11.4
+)
@@ -0,0 +1,134 @@
+// Package redis provides the API key store backed by Redis, the keys are stored in
+// hashes by the hashes of their secrets, so that the secrets are never kept in Redis.
+// The store counts the quota of the keys as well, so that it is shared by the instances.
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware/apikey"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	_ apikey.Store = (*Store)(nil)
+	_ apikey.Quota = (*Store)(nil)
+)
+
+const metadataPrefix = "metadata."
+
+// Option is redis store option.
+type Option func(*Store)
+
+// Prefix with the prefix of the redis keys, "apikey:" by default.
+func Prefix(prefix string) Option {
+	return func(s *Store) { s.prefix = prefix }
+}
+
+// Store is an API key store of Redis.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewStore new an API key store.
+func NewStore(client redis.UniversalClient, opts ...Option) *Store {
+	s := &Store{
+		client: client,
+		prefix: "apikey:",
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *Store) redisKey(secret string) string {
+	return s.prefix + apikey.Hash(secret)
+}
+
+// Lookup returns the key of the secret.
+func (s *Store) Lookup(ctx context.Context, secret string) (*apikey.Key, error) {
+	fields, err := s.client.HGetAll(ctx, s.redisKey(secret)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, apikey.ErrKeyNotFound
+	}
+	key := &apikey.Key{
+		ID:    fields["id"],
+		Owner: fields["owner"],
+	}
+	key.Rate, _ = strconv.ParseFloat(fields["rate"], 64)
+	key.Burst, _ = strconv.Atoi(fields["burst"])
+	key.Quota, _ = strconv.ParseInt(fields["quota"], 10, 64)
+	key.QuotaPeriod, _ = time.ParseDuration(fields["quota_period"])
+	if exp, err := strconv.ParseInt(fields["expires_at"], 10, 64); err == nil {
+		key.ExpiresAt = time.Unix(exp, 0)
+	}
+	for k, v := range fields {
+		if strings.HasPrefix(k, metadataPrefix) {
+			if key.Metadata == nil {
+				key.Metadata = make(map[string]string)
+			}
+			key.Metadata[strings.TrimPrefix(k, metadataPrefix)] = v
+		}
+	}
+	return key, nil
+}
+
+// Save saves the key of the secret, which expires at the expiry of the key.
+func (s *Store) Save(ctx context.Context, secret string, key *apikey.Key) error {
+	fields := map[string]interface{}{
+		"id":    key.ID,
+		"owner": key.Owner,
+		"rate":  strconv.FormatFloat(key.Rate, 'f', -1, 64),
+		"burst": strconv.Itoa(key.Burst),
+		"quota": strconv.FormatInt(key.Quota, 10),
+	}
+	if key.QuotaPeriod > 0 {
+		fields["quota_period"] = key.QuotaPeriod.String()
+	}
+	if !key.ExpiresAt.IsZero() {
+		fields["expires_at"] = strconv.FormatInt(key.ExpiresAt.Unix(), 10)
+	}
+	for k, v := range key.Metadata {
+		fields[metadataPrefix+k] = v
+	}
+	rk := s.redisKey(secret)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, rk)
+		pipe.HSet(ctx, rk, fields)
+		if !key.ExpiresAt.IsZero() {
+			pipe.ExpireAt(ctx, rk, key.ExpiresAt)
+		}
+		return nil
+	})
+	return err
+}
+
+// Delete revokes the key of the secret.
+func (s *Store) Delete(ctx context.Context, secret string) error {
+	return s.client.Del(ctx, s.redisKey(secret)).Err()
+}
+
+// Incr increments the quota count of the key in the period which starts at start, the
+// count expires at the end of the period.
+func (s *Store) Incr(ctx context.Context, key *apikey.Key, start time.Time) (int64, error) {
+	rk := s.prefix + "quota:" + key.ID + ":" + strconv.FormatInt(start.Unix(), 10)
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, rk)
+		pipe.ExpireAt(ctx, rk, start.Add(key.QuotaPeriod))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/middleware/apikey"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestStore(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	store := NewStore(redis.NewClient(&redis.Options{Addr: s.Addr()}))
+	ctx := context.Background()
+
+	want := &apikey.Key{
+		ID:          "checkout",
+		Owner:       "payments",
+		Rate:        2.5,
+		Burst:       5,
+		Quota:       10000,
+		QuotaPeriod: 24 * time.Hour,
+		ExpiresAt:   time.Unix(time.Now().Add(time.Hour).Unix(), 0),
+		Metadata:    map[string]string{"plan": "pro"},
+	}
+	if err := store.Save(ctx, "s3cret", want); err != nil {
+		t.Fatal(err)
+	}
+	if s.Exists("apikey:s3cret") {
+		t.Error("got the secret kept in redis")
+	}
+	got, err := store.Lookup(ctx, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+	if _, err := store.Lookup(ctx, "wrong"); err != apikey.ErrKeyNotFound {
+		t.Errorf("got %v want %v", err, apikey.ErrKeyNotFound)
+	}
+	if err := store.Delete(ctx, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Lookup(ctx, "s3cret"); err != apikey.ErrKeyNotFound {
+		t.Errorf("got %v want the revoked key", err)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	store := NewStore(redis.NewClient(&redis.Options{Addr: s.Addr()}))
+	ctx := context.Background()
+
+	key := &apikey.Key{ID: "checkout", Quota: 2, QuotaPeriod: time.Hour}
+	start := time.Now().Truncate(time.Hour)
+	for i := int64(1); i <= 3; i++ {
+		if n, err := store.Incr(ctx, key, start); err != nil || n != i {
+			t.Errorf("got %d %v want %d", n, err, i)
+		}
+	}
+	rk := "apikey:quota:checkout:" + strconv.FormatInt(start.Unix(), 10)
+	if ttl := s.TTL(rk); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("got ttl %s want the end of the period", ttl)
+	}
+	if n, err := store.Incr(ctx, key, start.Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("got %d %v want the count of the next period", n, err)
+	}
+}
@@ -0,0 +1,71 @@
+package casbin
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+
+	stdcasbin "github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+var (
+	// ErrNotImplemented is returned when the policy is modified through the read-only adapter.
+	ErrNotImplemented = errors.New("casbin: the config adapter is read-only")
+
+	_ persist.Adapter = (*Adapter)(nil)
+)
+
+// Adapter is a read-only casbin adapter which loads the policy lines,
+// in the format of a casbin policy csv file, from a config key.
+type Adapter struct {
+	config config.Config
+	key    string
+}
+
+// NewAdapter returns a casbin adapter reads the policy from the config key.
+func NewAdapter(c config.Config, key string) *Adapter {
+	return &Adapter{config: c, key: key}
+}
+
+// LoadPolicy loads all policy rules from the config.
+func (a *Adapter) LoadPolicy(m model.Model) error {
+	text, err := a.config.Value(a.key).String()
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		persist.LoadPolicyLine(strings.TrimSpace(scanner.Text()), m)
+	}
+	return scanner.Err()
+}
+
+// SavePolicy is not supported.
+func (a *Adapter) SavePolicy(model.Model) error {
+	return ErrNotImplemented
+}
+
+// AddPolicy is not supported.
+func (a *Adapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return ErrNotImplemented
+}
+
+// RemovePolicy is not supported.
+func (a *Adapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return ErrNotImplemented
+}
+
+// RemoveFilteredPolicy is not supported.
+func (a *Adapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	return ErrNotImplemented
+}
+
+// Watch reloads the policy of the enforcer whenever the config key changes.
+func (a *Adapter) Watch(e stdcasbin.IEnforcer) error {
+	return a.config.Watch(a.key, func(string, config.Value) {
+		_ = e.LoadPolicy()
+	})
+}
@@ -0,0 +1,97 @@
+package casbin
+
+import (
+	"context"
+	stderrors "errors"
+
+	stdcasbin "github.com/casbin/casbin/v2"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+var (
+	// ErrUnauthorized is returned when there is no authenticated subject.
+	ErrUnauthorized = errors.Unauthorized("UNAUTHORIZED", "unauthorized subject")
+	// ErrForbidden is returned when the policy denies the request.
+	ErrForbidden = errors.PermissionDenied("FORBIDDEN", "permission denied")
+)
+
+// Option is casbin option.
+type Option func(*options)
+
+// WithEnforcer with the casbin enforcer, which is required, a synced enforcer is
+// recommended if the policy is reloaded at runtime.
+func WithEnforcer(e stdcasbin.IEnforcer) Option {
+	return func(o *options) {
+		o.enforcer = e
+	}
+}
+
+// WithSubject with the func returns the authenticated subject of the request,
+// the default reads the subject set by NewContext.
+func WithSubject(fn func(ctx context.Context) (string, bool)) Option {
+	return func(o *options) {
+		o.subject = fn
+	}
+}
+
+type options struct {
+	enforcer stdcasbin.IEnforcer
+	subject  func(ctx context.Context) (string, bool)
+}
+
+type subjectKey struct{}
+
+// NewContext returns a new Context that carries the authenticated subject.
+func NewContext(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// FromContext returns the authenticated subject stored in ctx, if any.
+func FromContext(ctx context.Context) (subject string, ok bool) {
+	subject, ok = ctx.Value(subjectKey{}).(string)
+	return
+}
+
+// Server is a server middleware that enforces the policy of (subject, object, action),
+// the object is the gRPC full method or the HTTP path template, and the action is
+// the HTTP method or "*" for gRPC. The errors of the enforcer are internal errors.
+func Server(opts ...Option) (middleware.Middleware, error) {
+	options := options{
+		subject: FromContext,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.enforcer == nil {
+		return nil, stderrors.New("casbin: the enforcer is required")
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var obj, act string
+			if info, ok := grpc.FromContext(ctx); ok {
+				obj, act = info.FullMethod, "*"
+			} else if info, ok := http.FromContext(ctx); ok {
+				obj = info.PathTemplate
+				if obj == "" {
+					obj = info.Request.URL.Path
+				}
+				act = info.Request.Method
+			}
+			sub, ok := options.subject(ctx)
+			if !ok {
+				return nil, ErrUnauthorized
+			}
+			allowed, err := options.enforcer.Enforce(sub, obj, act)
+			if err != nil {
+				return nil, errors.Internal("ENFORCER", "casbin: %v", err)
+			}
+			if !allowed {
+				return nil, ErrForbidden
+			}
+			return handler(ctx, req)
+		}
+	}, nil
+}
@@ -0,0 +1,155 @@
+package casbin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	stdcasbin "github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+const testModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub && keyMatch(r.obj, p.obj) && (r.act == p.act || p.act == "*")
+`
+
+type testSource struct {
+	kvs chan []*config.KeyValue
+}
+
+func (s *testSource) Load() ([]*config.KeyValue, error) {
+	return <-s.kvs, nil
+}
+
+func (s *testSource) Watch() (config.Watcher, error) {
+	return s, nil
+}
+
+func (s *testSource) Next() ([]*config.KeyValue, error) {
+	kvs, ok := <-s.kvs
+	if !ok {
+		return nil, context.Canceled
+	}
+	return kvs, nil
+}
+
+func (s *testSource) Close() error {
+	return nil
+}
+
+func policy(text string) []*config.KeyValue {
+	return []*config.KeyValue{{
+		Key:    "authz",
+		Value:  []byte(`{"authz":{"policy":"` + text + `"}}`),
+		Format: "json",
+	}}
+}
+
+func TestServer(t *testing.T) {
+	source := &testSource{kvs: make(chan []*config.KeyValue, 1)}
+	source.kvs <- policy(`p, alice, /helloworld.Greeter/*, *\np, bob, /v1/users/*, GET`)
+	c := config.New(config.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer close(source.kvs)
+
+	m, err := model.NewModelFromString(testModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := NewAdapter(c, "authz.policy")
+	e, err := stdcasbin.NewSyncedEnforcer(m, adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.Watch(e); err != nil {
+		t.Fatal(err)
+	}
+
+	authz, err := Server(WithEnforcer(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := authz(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	grpcCtx := func(sub string) context.Context {
+		return NewContext(grpc.NewContext(context.Background(), grpc.ServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}), sub)
+	}
+	httpCtx := func(sub, method string) context.Context {
+		req, _ := http.NewRequest(method, "/v1/users/1", nil)
+		return NewContext(khttp.NewContext(context.Background(), khttp.ServerInfo{Request: req}), sub)
+	}
+
+	if reply, err := h(grpcCtx("alice"), nil); err != nil || reply != "reply" {
+		t.Errorf("alice: got reply=%v err=%v", reply, err)
+	}
+	if _, err := h(grpcCtx("bob"), nil); !errors.IsPermissionDenied(err) {
+		t.Errorf("bob: got %v want permission denied", err)
+	}
+	if _, err := h(httpCtx("bob", "GET"), nil); err != nil {
+		t.Errorf("bob GET: got %v", err)
+	}
+	if _, err := h(httpCtx("bob", "DELETE"), nil); !errors.IsPermissionDenied(err) {
+		t.Errorf("bob DELETE: got %v want permission denied", err)
+	}
+	if _, err := h(grpc.NewContext(context.Background(), grpc.ServerInfo{}), nil); !errors.IsUnauthorized(err) {
+		t.Errorf("anonymous: got %v want unauthorized", err)
+	}
+
+	// reload the policy from the config
+	source.kvs <- policy(`p, bob, /helloworld.Greeter/*, *`)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := h(grpcCtx("bob"), nil); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("policy is not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := h(grpcCtx("alice"), nil); !errors.IsPermissionDenied(err) {
+		t.Errorf("alice after reload: got %v want permission denied", err)
+	}
+}
+
+type failingEnforcer struct {
+	stdcasbin.IEnforcer
+}
+
+func (failingEnforcer) Enforce(rvals ...interface{}) (bool, error) {
+	return false, context.DeadlineExceeded
+}
+
+func TestServerErrors(t *testing.T) {
+	if _, err := Server(); err == nil {
+		t.Error("got nil want the error of the missing enforcer")
+	}
+	m, err := Server(WithEnforcer(failingEnforcer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(NewContext(grpc.NewContext(context.Background(), grpc.ServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}), "alice"), nil)
+	if !errors.IsInternal(err) {
+		t.Errorf("got %v want internal", err)
+	}
+}
@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var _ broker.Broker = (*Broker)(nil)
+
+type writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// CommitStrategy is the strategy of committing the offsets of the consumed messages.
+type CommitStrategy int
+
+const (
+	// CommitAfterHandle commits a message once it is handled, retried or dead lettered,
+	// so that the messages are delivered at least once.
+	CommitAfterHandle CommitStrategy = iota
+	// CommitBeforeHandle commits a message before it is handled, so that the messages
+	// are delivered at most once.
+	CommitBeforeHandle
+)
+
+// Option is kafka broker option.
+type Option func(o *options)
+
+type options struct {
+	logger         log.Logger
+	balancer       kafka.Balancer
+	startOffset    int64
+	group          string
+	commit         CommitStrategy
+	commitInterval time.Duration
+	retries        int
+	backoff        time.Duration
+	deadLetter     string
+}
+
+// Logger with the logger of the subscriptions.
+func Logger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Balancer with the balancer of the partitions, the messages of the same key go to the
+// same partition by default.
+func Balancer(b kafka.Balancer) Option {
+	return func(o *options) {
+		o.balancer = b
+	}
+}
+
+// StartOffset with the offset of the new consumer groups, kafka.FirstOffset by default.
+func StartOffset(offset int64) Option {
+	return func(o *options) {
+		o.startOffset = offset
+	}
+}
+
+// Group with the consumer group of the subscriptions without a queue option.
+func Group(name string) Option {
+	return func(o *options) {
+		o.group = name
+	}
+}
+
+// Commit with the commit strategy, CommitAfterHandle by default.
+func Commit(s CommitStrategy) Option {
+	return func(o *options) {
+		o.commit = s
+	}
+}
+
+// CommitInterval with the interval of committing the offsets in batches, the offsets
+// are committed synchronously by default.
+func CommitInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.commitInterval = d
+	}
+}
+
+// Retry with the times of retrying a failed message and the backoff between them,
+// the failed messages are not retried by default.
+func Retry(n int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries = n
+		o.backoff = backoff
+	}
+}
+
+// DeadLetter with the suffix of the dead letter topics, i.e. ".dlq", the messages still
+// failing after the retries are published to their dead letter topic along with the
+// error headers, instead of being dropped.
+func DeadLetter(suffix string) Option {
+	return func(o *options) {
+		o.deadLetter = suffix
+	}
+}
+
+// Broker is a kafka broker, the queue of a subscription is the consumer group.
+type Broker struct {
+	addrs     []string
+	opts      *options
+	log       *log.Helper
+	writer    writer
+	newReader func(kafka.ReaderConfig) reader
+}
+
+// New returns a kafka broker of the addresses.
+func New(addrs []string, opts ...Option) *Broker {
+	o := &options{
+		logger:      log.GetLogger(),
+		balancer:    &kafka.Hash{},
+		startOffset: kafka.FirstOffset,
+		backoff:     time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Broker{
+		addrs: addrs,
+		opts:  o,
+		log:   log.NewHelper("broker/kafka", o.logger),
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(addrs...),
+			Balancer: o.balancer,
+		},
+		newReader: func(c kafka.ReaderConfig) reader {
+			return kafka.NewReader(c)
+		},
+	}
+}
+
+// Endpoint returns the endpoint of the brokers, i.e. kafka://127.0.0.1:9092,127.0.0.1:9093.
+func (b *Broker) Endpoint() string {
+	return "kafka://" + strings.Join(b.addrs, ",")
+}
+
+// Publish writes the message to the topic.
+func (b *Broker) Publish(ctx context.Context, topic string, msg *broker.Message) error {
+	m := kafka.Message{
+		Topic: topic,
+		Value: msg.Body,
+	}
+	if msg.Key != "" {
+		m.Key = []byte(msg.Key)
+	}
+	for k, v := range msg.Header {
+		m.Headers = append(m.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return b.writer.WriteMessages(ctx, m)
+}
+
+// Subscribe consumes the topic by the consumer group of the queue option, or the group
+// of the broker. The failed messages are retried and dead lettered by the options, or
+// logged and committed.
+func (b *Broker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	group := broker.NewSubscribeOptions(opts...).Queue
+	if group == "" {
+		group = b.opts.group
+	}
+	if group == "" {
+		return nil, errors.New("kafka: the consumer group is required")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &subscriber{
+		b:     b,
+		topic: topic,
+		r: b.newReader(kafka.ReaderConfig{
+			Brokers:        b.addrs,
+			GroupID:        group,
+			Topic:          topic,
+			StartOffset:    b.opts.startOffset,
+			CommitInterval: b.opts.commitInterval,
+		}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.run(ctx, h)
+	return s, nil
+}
+
+// Close closes the writer.
+func (b *Broker) Close() error {
+	return b.writer.Close()
+}
@@ -0,0 +1,249 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type testWriter struct {
+	mu   sync.Mutex
+	msgs []kafka.Message
+	errs int
+}
+
+func (w *testWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.errs > 0 {
+		w.errs--
+		return errors.New("write")
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *testWriter) Close() error {
+	return nil
+}
+
+type testReader struct {
+	config    kafka.ReaderConfig
+	msgs      chan kafka.Message
+	mu        sync.Mutex
+	committed []kafka.Message
+	closed    bool
+}
+
+func (r *testReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case m := <-r.msgs:
+		return m, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *testReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *testReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func newTestBroker(opts ...Option) (*Broker, *testWriter, *testReader) {
+	b := New([]string{"127.0.0.1:9092"}, opts...)
+	w := &testWriter{}
+	r := &testReader{msgs: make(chan kafka.Message)}
+	b.writer = w
+	b.newReader = func(c kafka.ReaderConfig) reader {
+		r.config = c
+		return r
+	}
+	return b, w, r
+}
+
+func TestPublish(t *testing.T) {
+	b := New([]string{"127.0.0.1:9092", "127.0.0.1:9093"})
+	w := &testWriter{}
+	b.writer = w
+	if got := b.Endpoint(); got != "kafka://127.0.0.1:9092,127.0.0.1:9093" {
+		t.Errorf("unexpected endpoint %s", got)
+	}
+	err := b.Publish(context.Background(), "events", &broker.Message{
+		Key:    "k",
+		Header: map[string]string{"a": "1", "b": "2"},
+		Body:   []byte("body"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := w.msgs[0]
+	sort.Slice(m.Headers, func(i, j int) bool { return m.Headers[i].Key < m.Headers[j].Key })
+	want := kafka.Message{
+		Topic:   "events",
+		Key:     []byte("k"),
+		Value:   []byte("body"),
+		Headers: []kafka.Header{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("expected %+v, got %+v", want, m)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	b, _, r := newTestBroker()
+	if _, err := b.Subscribe("events", nil); err == nil {
+		t.Error("expected an error without the consumer group")
+	}
+	got := make(chan *broker.Message)
+	s, err := b.Subscribe("events", func(ctx context.Context, topic string, msg *broker.Message) error {
+		got <- msg
+		if string(msg.Body) == "fail" {
+			return errors.New("fail")
+		}
+		return nil
+	}, broker.Queue("group"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.config.GroupID != "group" || r.config.Topic != "events" {
+		t.Errorf("unexpected config %+v", r.config)
+	}
+	for _, body := range []string{"ok", "fail"} {
+		r.msgs <- kafka.Message{
+			Topic:   "events",
+			Key:     []byte("k"),
+			Value:   []byte(body),
+			Headers: []kafka.Header{{Key: "a", Value: []byte("1")}},
+		}
+		msg := <-got
+		want := &broker.Message{Key: "k", Header: map[string]string{"a": "1"}, Body: []byte(body)}
+		if !reflect.DeepEqual(msg, want) {
+			t.Errorf("expected %+v, got %+v", want, msg)
+		}
+	}
+	if err := s.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if !r.closed {
+		t.Error("expected the reader to be closed")
+	}
+	if len(r.committed) != 2 {
+		t.Errorf("expected the handled messages to be committed, got %d", len(r.committed))
+	}
+	if err := s.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryDeadLetter(t *testing.T) {
+	b, w, r := newTestBroker(Group("group"), Retry(2, time.Millisecond), DeadLetter(".dlq"))
+	w.errs = 1
+	var attempts int
+	s, err := b.Subscribe("events", func(ctx context.Context, topic string, msg *broker.Message) error {
+		attempts++
+		return errors.New("fail")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.config.GroupID != "group" {
+		t.Errorf("expected the group of the broker, got %s", r.config.GroupID)
+	}
+	r.msgs <- kafka.Message{Topic: "events", Partition: 1, Offset: 2, Value: []byte("body")}
+	// the next message is fetched once the first one is settled.
+	r.msgs <- kafka.Message{Topic: "events", Partition: 1, Offset: 3, Value: []byte("body")}
+	if err := s.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if attempts < 3 {
+		t.Errorf("expected 3 attempts of the first message, got %d", attempts)
+	}
+	want := kafka.Message{
+		Topic: "events.dlq",
+		Value: []byte("body"),
+		Headers: []kafka.Header{
+			{Key: ErrorHeader, Value: []byte("fail")},
+			{Key: TopicHeader, Value: []byte("events")},
+			{Key: PartitionHeader, Value: []byte("1")},
+			{Key: OffsetHeader, Value: []byte("2")},
+		},
+	}
+	if len(w.msgs) == 0 || !reflect.DeepEqual(w.msgs[0], want) {
+		t.Errorf("expected the dead letter %+v, got %+v", want, w.msgs)
+	}
+	if len(r.committed) == 0 || r.committed[0].Offset != 2 {
+		t.Errorf("expected the dead lettered message to be committed, got %+v", r.committed)
+	}
+}
+
+func TestCommitBeforeHandle(t *testing.T) {
+	b, _, r := newTestBroker(Group("group"), Commit(CommitBeforeHandle))
+	committed := make(chan int)
+	s, err := b.Subscribe("events", func(ctx context.Context, topic string, msg *broker.Message) error {
+		r.mu.Lock()
+		n := len(r.committed)
+		r.mu.Unlock()
+		committed <- n
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.msgs <- kafka.Message{Topic: "events"}
+	if n := <-committed; n != 1 {
+		t.Errorf("expected the message to be committed before handling, got %d", n)
+	}
+	if err := s.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type event struct {
+	ID string `json:"id"`
+}
+
+func TestServer(t *testing.T) {
+	b, _, r := newTestBroker(Group("group"))
+	srv := NewServer(b)
+	got := make(chan *event)
+	srv.Subscribe("events", func() interface{} { return new(event) }, func(ctx context.Context, req interface{}) error {
+		got <- req.(*event)
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start()
+	}()
+	r.msgs <- kafka.Message{
+		Topic:   "events",
+		Value:   []byte(`{"id":"1"}`),
+		Headers: []kafka.Header{{Key: broker.ContentTypeHeader, Value: []byte("application/json")}},
+	}
+	if e := <-got; e.ID != "1" {
+		t.Errorf("expected event 1, got %+v", e)
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !r.closed {
+		t.Error("expected the reader to be closed")
+	}
+}
@@ -0,0 +1,19 @@
+package kafka
+
+import (
+	"github.com/go-kratos/kratos/v2/broker"
+)
+
+// NewServer creates a kafka consumer server run by the app, each subscription runs a
+// consumer group whose messages are decoded and handled through the server middleware.
+// The commit strategy, retries and dead letters are the options of the broker, i.e.
+//
+//	b := kafka.New([]string{"127.0.0.1:9092"}, kafka.Group("helloworld"), kafka.Retry(3, time.Second), kafka.DeadLetter(".dlq"))
+//	srv := kafka.NewServer(b, broker.Middleware(middleware.Chain(recovery.Recovery(), tracing.Server())))
+//	srv.Subscribe("greeted", func() interface{} { return new(pb.Greeted) }, handler)
+//
+// The messages in flight are handled before the server is stopped, the broker is not
+// closed by the server since it may be shared by the publishers.
+func NewServer(b *Broker, opts ...broker.ServerOption) *broker.Server {
+	return broker.NewServer(b, opts...)
+}
@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// The headers of the dead letters, which locate the failed message.
+const (
+	ErrorHeader     = "x-kafka-error"
+	TopicHeader     = "x-kafka-topic"
+	PartitionHeader = "x-kafka-partition"
+	OffsetHeader    = "x-kafka-offset"
+)
+
+type subscriber struct {
+	b      *Broker
+	topic  string
+	r      reader
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *subscriber) run(ctx context.Context, h broker.Handler) {
+	defer close(s.done)
+	for {
+		m, err := s.r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil && err != io.EOF {
+				s.b.log.Errorf("failed to fetch the message of %s: %v", s.topic, err)
+			}
+			return
+		}
+		if s.b.opts.commit == CommitBeforeHandle {
+			s.commit(m)
+		}
+		if !s.handle(ctx, h, m) {
+			// unsubscribed before the message is settled, it is redelivered later.
+			return
+		}
+		if s.b.opts.commit == CommitAfterHandle {
+			s.commit(m)
+		}
+	}
+}
+
+// handle handles the message with the retries, and dead letters it if it still fails,
+// false is returned if it is unsubscribed meanwhile. The message in flight is handled
+// with a background context, so that it is not interrupted by the unsubscribing.
+func (s *subscriber) handle(ctx context.Context, h broker.Handler, m kafka.Message) bool {
+	err := h(context.Background(), m.Topic, message(m))
+	for i := 0; err != nil && i < s.b.opts.retries; i++ {
+		if !s.sleep(ctx) {
+			return false
+		}
+		err = h(context.Background(), m.Topic, message(m))
+	}
+	if err == nil {
+		return true
+	}
+	s.b.log.Errorf("failed to handle the message of %s at %d/%d: %v", m.Topic, m.Partition, m.Offset, err)
+	if s.b.opts.deadLetter == "" {
+		return true
+	}
+	dead := deadLetter(m, s.b.opts.deadLetter, err)
+	for {
+		err := s.b.writer.WriteMessages(context.Background(), dead)
+		if err == nil {
+			return true
+		}
+		s.b.log.Errorf("failed to dead letter the message of %s at %d/%d: %v", m.Topic, m.Partition, m.Offset, err)
+		if !s.sleep(ctx) {
+			return false
+		}
+	}
+}
+
+func (s *subscriber) sleep(ctx context.Context) bool {
+	t := time.NewTimer(s.b.opts.backoff)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *subscriber) commit(m kafka.Message) {
+	if err := s.r.CommitMessages(context.Background(), m); err != nil {
+		s.b.log.Errorf("failed to commit the message of %s at %d/%d: %v", m.Topic, m.Partition, m.Offset, err)
+	}
+}
+
+// Topic returns the topic of the subscription.
+func (s *subscriber) Topic() string {
+	return s.topic
+}
+
+// Unsubscribe waits the message in flight, and closes the reader.
+func (s *subscriber) Unsubscribe() error {
+	var err error
+	s.once.Do(func() {
+		s.cancel()
+		<-s.done
+		err = s.r.Close()
+	})
+	return err
+}
+
+// message returns the broker message of the kafka message, a new one is returned for
+// each attempt since the headers may be changed by the middleware.
+func message(m kafka.Message) *broker.Message {
+	msg := &broker.Message{
+		Key:    string(m.Key),
+		Header: make(map[string]string, len(m.Headers)),
+		Body:   m.Value,
+	}
+	for _, h := range m.Headers {
+		msg.Header[h.Key] = string(h.Value)
+	}
+	return msg
+}
+
+func deadLetter(m kafka.Message, suffix string, err error) kafka.Message {
+	headers := make([]kafka.Header, 0, len(m.Headers)+4)
+	headers = append(headers, m.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: ErrorHeader, Value: []byte(err.Error())},
+		kafka.Header{Key: TopicHeader, Value: []byte(m.Topic)},
+		kafka.Header{Key: PartitionHeader, Value: []byte(strconv.Itoa(m.Partition))},
+		kafka.Header{Key: OffsetHeader, Value: []byte(strconv.FormatInt(m.Offset, 10))},
+	)
+	return kafka.Message{
+		Topic:   m.Topic + suffix,
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+	}
+}
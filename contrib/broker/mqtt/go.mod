@@ -0,0 +1,10 @@
+module github.com/go-kratos/kratos/contrib/broker/mqtt/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/eclipse/paho.mqtt.golang v1.3.5
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,190 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+	"github.com/go-kratos/kratos/v2/log"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+var _ broker.Broker = (*Broker)(nil)
+
+type client interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token
+	Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token
+	Unsubscribe(topics ...string) paho.Token
+}
+
+// Option is mqtt broker option.
+type Option func(o *options)
+
+type options struct {
+	logger   log.Logger
+	qos      byte
+	retained bool
+	timeout  time.Duration
+}
+
+// Logger with the logger of the subscriptions.
+func Logger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// QoS with the QoS of publishing and subscribing, 1 by default.
+func QoS(qos byte) Option {
+	return func(o *options) {
+		o.qos = qos
+	}
+}
+
+// Retained with publishing the messages retained by the server for the new subscribers.
+func Retained(retained bool) Option {
+	return func(o *options) {
+		o.retained = retained
+	}
+}
+
+// Timeout with the timeout of subscribing and unsubscribing, 10 seconds by default.
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// Broker is a mqtt broker of the topic filters, i.e. devices/+/telemetry, the queue of a
+// subscription is the group of the shared subscription, i.e. $share/group/devices/#.
+//
+// MQTT 3.1.1 does not carry the headers, so the messages are decoded by the codec of the
+// server, the headers and the trace context can be carried by the structured cloud events.
+type Broker struct {
+	endpoint string
+	client   client
+	opts     *options
+	log      *log.Helper
+}
+
+// New returns a mqtt broker of the connected client.
+func New(c paho.Client, opts ...Option) *Broker {
+	var endpoint string
+	r := c.OptionsReader()
+	if servers := r.Servers(); len(servers) > 0 {
+		endpoint = servers[0].String()
+	}
+	return newBroker(endpoint, c, opts...)
+}
+
+func newBroker(endpoint string, c client, opts ...Option) *Broker {
+	o := &options{
+		logger:  log.GetLogger(),
+		qos:     1,
+		timeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Broker{
+		endpoint: endpoint,
+		client:   c,
+		opts:     o,
+		log:      log.NewHelper("broker/mqtt", o.logger),
+	}
+}
+
+// Endpoint returns the first server of the client, i.e. tcp://127.0.0.1:1883.
+func (b *Broker) Endpoint() string {
+	return b.endpoint
+}
+
+// Publish publishes the body of the message to the topic, and waits until it is sent by
+// the QoS or ctx is done.
+func (b *Broker) Publish(ctx context.Context, topic string, msg *broker.Message) error {
+	t := b.client.Publish(topic, b.opts.qos, b.opts.retained, msg.Body)
+	select {
+	case <-t.Done():
+		return t.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe subscribes the topic filter, as a shared subscription of the queue group if any.
+func (b *Broker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	filter := topic
+	if queue := broker.NewSubscribeOptions(opts...).Queue; queue != "" {
+		filter = "$share/" + queue + "/" + topic
+	}
+	s := &subscriber{b: b, topic: topic, filter: filter, h: h}
+	if err := b.wait(b.client.Subscribe(filter, b.opts.qos, s.handle)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (b *Broker) wait(t paho.Token) error {
+	if !t.WaitTimeout(b.opts.timeout) {
+		return errors.New("mqtt: timeout")
+	}
+	return t.Error()
+}
+
+// Close does nothing, the client is disconnected by the caller.
+func (b *Broker) Close() error {
+	return nil
+}
+
+type subscriber struct {
+	b      *Broker
+	topic  string
+	filter string
+	h      broker.Handler
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// handle handles the message, which is acked by the client once it returns.
+func (s *subscriber) handle(_ paho.Client, m paho.Message) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	msg := &broker.Message{
+		Header: map[string]string{},
+		Body:   m.Payload(),
+	}
+	if err := s.h(context.Background(), m.Topic(), msg); err != nil {
+		s.b.log.Errorf("failed to handle the message of %s: %v", m.Topic(), err)
+	}
+}
+
+// Topic returns the topic filter of the subscription.
+func (s *subscriber) Topic() string {
+	return s.topic
+}
+
+// Unsubscribe unsubscribes the topic filter, and waits the messages in flight.
+func (s *subscriber) Unsubscribe() error {
+	s.once.Do(func() {
+		s.err = s.b.wait(s.b.client.Unsubscribe(s.filter))
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		s.wg.Wait()
+	})
+	return s.err
+}
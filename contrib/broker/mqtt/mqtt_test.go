@@ -0,0 +1,171 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+type testToken struct {
+	err  error
+	done chan struct{}
+}
+
+func newTestToken(err error) *testToken {
+	t := &testToken{err: err, done: make(chan struct{})}
+	close(t.done)
+	return t
+}
+
+func (t *testToken) Wait() bool                       { return true }
+func (t *testToken) WaitTimeout(d time.Duration) bool { return true }
+func (t *testToken) Done() <-chan struct{}            { return t.done }
+func (t *testToken) Error() error                     { return t.err }
+
+type testMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *testMessage) Duplicate() bool   { return false }
+func (m *testMessage) Qos() byte         { return 1 }
+func (m *testMessage) Retained() bool    { return false }
+func (m *testMessage) Topic() string     { return m.topic }
+func (m *testMessage) MessageID() uint16 { return 1 }
+func (m *testMessage) Payload() []byte   { return m.payload }
+func (m *testMessage) Ack()              {}
+
+type testClient struct {
+	mu        sync.Mutex
+	published []string
+	qos       []byte
+	handlers  map[string]paho.MessageHandler
+	err       error
+}
+
+func (c *testClient) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published = append(c.published, topic+":"+string(payload.([]byte)))
+	c.qos = append(c.qos, qos)
+	return newTestToken(c.err)
+}
+
+func (c *testClient) Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[topic] = callback
+	c.qos = append(c.qos, qos)
+	return newTestToken(c.err)
+}
+
+func (c *testClient) Unsubscribe(topics ...string) paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, topic := range topics {
+		delete(c.handlers, topic)
+	}
+	return newTestToken(nil)
+}
+
+func (c *testClient) deliver(filter, topic, payload string) {
+	c.mu.Lock()
+	h := c.handlers[filter]
+	c.mu.Unlock()
+	h(nil, &testMessage{topic: topic, payload: []byte(payload)})
+}
+
+func TestPublish(t *testing.T) {
+	c := &testClient{}
+	b := newBroker("tcp://127.0.0.1:1883", c, QoS(2))
+	if err := b.Publish(context.Background(), "devices/1/telemetry", &broker.Message{Body: []byte("42")}); err != nil {
+		t.Fatal(err)
+	}
+	if c.published[0] != "devices/1/telemetry:42" || c.qos[0] != 2 {
+		t.Errorf("unexpected published %v %v", c.published, c.qos)
+	}
+	c.err = errors.New("publish")
+	if err := b.Publish(context.Background(), "devices/1/telemetry", &broker.Message{}); err != c.err {
+		t.Errorf("expected %v, got %v", c.err, err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	c := &testClient{handlers: map[string]paho.MessageHandler{}}
+	b := newBroker("tcp://127.0.0.1:1883", c)
+	var topics []string
+	s, err := b.Subscribe("devices/+/telemetry", func(ctx context.Context, topic string, msg *broker.Message) error {
+		topics = append(topics, topic+":"+string(msg.Body))
+		return nil
+	}, broker.Queue("ingest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Topic() != "devices/+/telemetry" {
+		t.Errorf("unexpected topic %s", s.Topic())
+	}
+	c.deliver("$share/ingest/devices/+/telemetry", "devices/1/telemetry", "42")
+	if len(topics) != 1 || topics[0] != "devices/1/telemetry:42" {
+		t.Errorf("unexpected messages %v", topics)
+	}
+	h := c.handlers["$share/ingest/devices/+/telemetry"]
+	if err := s.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.handlers) != 0 {
+		t.Error("expected the filter to be unsubscribed")
+	}
+	// the late messages are dropped once it is unsubscribed.
+	h(nil, &testMessage{topic: "devices/1/telemetry"})
+	if len(topics) != 1 {
+		t.Errorf("unexpected messages %v", topics)
+	}
+	c.err = errors.New("subscribe")
+	if _, err := b.Subscribe("devices/#", nil); err != c.err {
+		t.Errorf("expected %v, got %v", c.err, err)
+	}
+}
+
+type telemetry struct {
+	Value int `json:"value"`
+}
+
+func TestServer(t *testing.T) {
+	c := &testClient{handlers: map[string]paho.MessageHandler{}}
+	srv := NewServer(newBroker("tcp://127.0.0.1:1883", c))
+	got := make(chan int, 1)
+	srv.Subscribe("devices/+/telemetry", func() interface{} { return new(telemetry) }, func(ctx context.Context, req interface{}) error {
+		got <- req.(*telemetry).Value
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start()
+	}()
+	for {
+		c.mu.Lock()
+		_, ok := c.handlers["devices/+/telemetry"]
+		c.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// the messages without the content type are decoded by the codec of the server.
+	c.deliver("devices/+/telemetry", "devices/1/telemetry", `{"value":42}`)
+	if v := <-got; v != 42 {
+		t.Errorf("expected 42, got %d", v)
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
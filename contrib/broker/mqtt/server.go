@@ -0,0 +1,18 @@
+package mqtt
+
+import (
+	"github.com/go-kratos/kratos/v2/broker"
+)
+
+// NewServer creates a mqtt subscriber server run by the app, the messages of the topic
+// filters are decoded and handled through the server middleware, i.e.
+//
+//	b := mqtt.New(client, mqtt.QoS(1))
+//	srv := mqtt.NewServer(b, broker.Codec(encoding.GetCodec("proto")), broker.Middleware(recovery.Recovery()))
+//	srv.Subscribe("devices/+/telemetry", func() interface{} { return new(pb.Telemetry) }, handler, broker.Queue("ingest"))
+//
+// The messages in flight are handled before the server is stopped, the client is not
+// disconnected by the server since it may be shared by the publishers.
+func NewServer(b *Broker, opts ...broker.ServerOption) *broker.Server {
+	return broker.NewServer(b, opts...)
+}
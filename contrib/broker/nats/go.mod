@@ -0,0 +1,13 @@
+module github.com/go-kratos/kratos/contrib/broker/nats/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	github.com/nats-io/jwt/v2 v2.0.3 // indirect
+	github.com/nats-io/nats-server/v2 v2.3.0
+	github.com/nats-io/nats.go v1.11.0
+	golang.org/x/crypto v0.0.0-20210616213533-5ff15b29337e // indirect
+)
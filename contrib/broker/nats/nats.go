@@ -0,0 +1,240 @@
+package nats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/nats-io/nats.go"
+)
+
+var _ broker.Broker = (*Broker)(nil)
+
+// KeyHeader is the header carrying the key of the message, which is not supported by nats.
+const KeyHeader = "x-nats-key"
+
+// AckPolicy is the policy of acknowledging the JetStream messages.
+type AckPolicy int
+
+const (
+	// AckOnSuccess acks the handled messages, and naks the failed ones to be redelivered.
+	AckOnSuccess AckPolicy = iota
+	// AckAlways acks the messages whether they are handled or not.
+	AckAlways
+	// AckNone does not ack the messages, the consumer is created with the none ack policy.
+	AckNone
+)
+
+// Option is nats broker option.
+type Option func(o *options)
+
+type options struct {
+	logger    log.Logger
+	jetStream bool
+	jsOpts    []nats.JSOpt
+	ack       AckPolicy
+	subOpts   []nats.SubOpt
+}
+
+// Logger with the logger of the subscriptions.
+func Logger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// JetStream with publishing and subscribing by JetStream, the streams of the subjects
+// are expected to exist.
+func JetStream(opts ...nats.JSOpt) Option {
+	return func(o *options) {
+		o.jetStream = true
+		o.jsOpts = opts
+	}
+}
+
+// Ack with the ack policy of the JetStream subscriptions, AckOnSuccess by default.
+func Ack(p AckPolicy) Option {
+	return func(o *options) {
+		o.ack = p
+	}
+}
+
+// SubscribeOptions with the options of the JetStream subscriptions, i.e. nats.MaxDeliver(5).
+func SubscribeOptions(opts ...nats.SubOpt) Option {
+	return func(o *options) {
+		o.subOpts = opts
+	}
+}
+
+// Broker is a nats broker, the queue of a subscription is the queue group, which is also
+// the durable name of the JetStream consumer.
+type Broker struct {
+	nc   *nats.Conn
+	js   nats.JetStreamContext
+	opts *options
+	log  *log.Helper
+}
+
+// New returns a nats broker of the connection.
+func New(nc *nats.Conn, opts ...Option) (*Broker, error) {
+	o := &options{
+		logger: log.GetLogger(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	b := &Broker{
+		nc:   nc,
+		opts: o,
+		log:  log.NewHelper("broker/nats", o.logger),
+	}
+	if o.jetStream {
+		js, err := nc.JetStream(o.jsOpts...)
+		if err != nil {
+			return nil, err
+		}
+		b.js = js
+	}
+	return b, nil
+}
+
+// Endpoint returns the endpoint of the connected server, i.e. nats://127.0.0.1:4222.
+func (b *Broker) Endpoint() string {
+	return "nats://" + b.nc.ConnectedAddr()
+}
+
+// Publish publishes the message to the subject, it returns once the message is stored
+// by JetStream.
+func (b *Broker) Publish(ctx context.Context, subject string, msg *broker.Message) error {
+	m := nats.NewMsg(subject)
+	m.Data = msg.Body
+	for k, v := range msg.Header {
+		m.Header.Set(k, v)
+	}
+	if msg.Key != "" {
+		m.Header.Set(KeyHeader, msg.Key)
+	}
+	if b.js != nil {
+		_, err := b.js.PublishMsg(m, nats.Context(ctx))
+		return err
+	}
+	return b.nc.PublishMsg(m)
+}
+
+// Subscribe subscribes the subject by the queue group of the queue option, if any.
+func (b *Broker) Subscribe(subject string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	queue := broker.NewSubscribeOptions(opts...).Queue
+	s := &subscriber{b: b, subject: subject, h: h}
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if b.js != nil {
+		subOpts := append([]nats.SubOpt{}, b.opts.subOpts...)
+		if b.opts.ack == AckNone {
+			subOpts = append(subOpts, nats.AckNone())
+		} else {
+			subOpts = append(subOpts, nats.ManualAck())
+		}
+		if queue != "" {
+			sub, err = b.js.QueueSubscribe(subject, queue, s.handle, append(subOpts, nats.Durable(queue))...)
+		} else {
+			sub, err = b.js.Subscribe(subject, s.handle, subOpts...)
+		}
+	} else if queue != "" {
+		sub, err = b.nc.QueueSubscribe(subject, queue, s.handle)
+	} else {
+		sub, err = b.nc.Subscribe(subject, s.handle)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.sub = sub
+	return s, nil
+}
+
+// Close flushes the published messages, the connection is closed by the caller.
+func (b *Broker) Close() error {
+	return b.nc.Flush()
+}
+
+type subscriber struct {
+	b       *Broker
+	subject string
+	h       broker.Handler
+	sub     *nats.Subscription
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+func (s *subscriber) handle(m *nats.Msg) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	msg := &broker.Message{
+		Header: make(map[string]string, len(m.Header)),
+		Body:   m.Data,
+	}
+	for k, v := range m.Header {
+		if len(v) == 0 {
+			continue
+		}
+		if k == KeyHeader {
+			msg.Key = v[0]
+			continue
+		}
+		msg.Header[k] = v[0]
+	}
+	err := s.h(context.Background(), m.Subject, msg)
+	if err != nil {
+		s.b.log.Errorf("failed to handle the message of %s: %v", m.Subject, err)
+	}
+	if s.b.js == nil {
+		return
+	}
+	var aerr error
+	switch {
+	case s.b.opts.ack == AckNone:
+	case err != nil && s.b.opts.ack == AckOnSuccess:
+		aerr = m.Nak()
+	default:
+		aerr = m.Ack()
+	}
+	if aerr != nil {
+		s.b.log.Errorf("failed to ack the message of %s: %v", m.Subject, aerr)
+	}
+}
+
+// Topic returns the subject of the subscription.
+func (s *subscriber) Topic() string {
+	return s.subject
+}
+
+// Unsubscribe drains the subscription, so that the pending messages are handled and the
+// durable consumer is kept, and waits the messages in flight.
+func (s *subscriber) Unsubscribe() error {
+	s.once.Do(func() {
+		s.err = s.sub.Drain()
+		for s.err == nil && s.sub.IsValid() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		s.wg.Wait()
+	})
+	return s.err
+}
@@ -0,0 +1,185 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/broker"
+
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+)
+
+func newTestConn(t *testing.T) *nats.Conn {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	s := natstest.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func receive(t *testing.T, ch <-chan *broker.Message) *broker.Message {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the message")
+	}
+	return nil
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	nc := newTestConn(t)
+	b, err := New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan *broker.Message, 10)
+	h := func(ctx context.Context, subject string, msg *broker.Message) error {
+		got <- msg
+		return nil
+	}
+	// the queue members share the messages.
+	for i := 0; i < 2; i++ {
+		s, err := b.Subscribe("orders.*", h, broker.Queue("billing"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Unsubscribe()
+	}
+	if err := nc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := &broker.Message{Key: "k", Header: map[string]string{"x-md-a": "b"}, Body: []byte("body")}
+	if err := b.Publish(context.Background(), "orders.created", want); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, got); !reflect.DeepEqual(msg, want) {
+		t.Errorf("expected %+v, got %+v", want, msg)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-got:
+		t.Errorf("expected the message to be delivered once, got %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if got := b.Endpoint(); got != "nats://"+nc.ConnectedAddr() {
+		t.Errorf("unexpected endpoint %s", got)
+	}
+}
+
+func TestJetStreamAck(t *testing.T) {
+	nc := newTestConn(t)
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(nc, JetStream())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan *broker.Message, 10)
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	s, err := b.Subscribe("orders.created", func(ctx context.Context, subject string, msg *broker.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		got <- msg
+		if attempts == 1 {
+			return errors.New("fail")
+		}
+		return nil
+	}, broker.Queue("billing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(context.Background(), "orders.created", &broker.Message{Body: []byte("body")}); err != nil {
+		t.Fatal(err)
+	}
+	// the failed message is naked and redelivered.
+	for i := 0; i < 2; i++ {
+		if msg := receive(t, got); string(msg.Body) != "body" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	}
+	if err := s.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := js.ConsumerInfo("ORDERS", "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50 && info.NumAckPending > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if info, err = js.ConsumerInfo("ORDERS", "billing"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if info.NumAckPending != 0 || info.AckFloor.Stream != 1 {
+		t.Errorf("expected the message to be acked, got %+v", info)
+	}
+}
+
+type order struct {
+	ID string `json:"id"`
+}
+
+func TestServer(t *testing.T) {
+	nc := newTestConn(t)
+	b, err := New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(b)
+	got := make(chan *order, 1)
+	srv.Subscribe("orders.created", func() interface{} { return new(order) }, func(ctx context.Context, req interface{}) error {
+		got <- req.(*order)
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start()
+	}()
+	p := broker.NewPublisher(b)
+	for {
+		if err := p.Publish(context.Background(), "orders.created", &order{ID: "1"}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case o := <-got:
+			if o.ID != "1" {
+				t.Errorf("expected order 1, got %+v", o)
+			}
+		case <-time.After(10 * time.Millisecond):
+			// the subject is not subscribed yet.
+			continue
+		}
+		break
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,18 @@
+package nats
+
+import (
+	"github.com/go-kratos/kratos/v2/broker"
+)
+
+// NewServer creates a nats subscriber server run by the app, the messages of the subjects
+// are decoded and handled through the server middleware, i.e.
+//
+//	b, err := nats.New(nc, nats.JetStream(), nats.SubscribeOptions(natsgo.MaxDeliver(5)))
+//	srv := nats.NewServer(b, broker.Middleware(middleware.Chain(recovery.Recovery(), tracing.Server())))
+//	srv.Subscribe("orders.created", func() interface{} { return new(pb.Order) }, handler, broker.Queue("billing"))
+//
+// The messages in flight are handled before the server is stopped, the connection is not
+// closed by the server since it may be shared by the publishers.
+func NewServer(b *Broker, opts ...broker.ServerOption) *broker.Server {
+	return broker.NewServer(b, opts...)
+}
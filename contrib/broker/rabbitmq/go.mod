@@ -0,0 +1,11 @@
+module github.com/go-kratos/kratos/contrib/broker/rabbitmq/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	github.com/google/uuid v1.3.0
+	github.com/streadway/amqp v1.0.0
+)
@@ -0,0 +1,94 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ config.Source = (*source)(nil)
+
+// Option is etcd config option.
+type Option func(o *options)
+
+type options struct {
+	ctx    context.Context
+	path   string
+	prefix bool
+}
+
+// WithContext with config context.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithPath with config key path.
+func WithPath(p string) Option {
+	return func(o *options) {
+		o.path = p
+	}
+}
+
+// WithPrefix with loading every key under the path.
+func WithPrefix(prefix bool) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+type source struct {
+	client  *clientv3.Client
+	options *options
+}
+
+// New new an etcd config source.
+func New(client *clientv3.Client, opts ...Option) (config.Source, error) {
+	options := &options{
+		ctx:    context.Background(),
+		prefix: false,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	if options.path == "" {
+		return nil, errors.New("path invalid")
+	}
+	return &source{
+		client:  client,
+		options: options,
+	}, nil
+}
+
+// Load return the config values
+func (s *source) Load() ([]*config.KeyValue, error) {
+	var opts []clientv3.OpOption
+	if s.options.prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	rsp, err := s.client.Get(s.options.ctx, s.options.path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	kvs := make([]*config.KeyValue, 0, len(rsp.Kvs))
+	for _, item := range rsp.Kvs {
+		k := string(item.Key)
+		kvs = append(kvs, &config.KeyValue{
+			Key:    k,
+			Value:  item.Value,
+			Format: strings.TrimPrefix(filepath.Ext(k), "."),
+		})
+	}
+	return kvs, nil
+}
+
+// Watch return the watcher
+func (s *source) Watch() (config.Watcher, error) {
+	return newWatcher(s), nil
+}
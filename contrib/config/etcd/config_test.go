@@ -0,0 +1,147 @@
+package etcd
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/config"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type fakeKV struct {
+	clientv3.KV
+
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	prefix := len(clientv3.OpGet(key, opts...).RangeBytes()) > 0
+	rsp := &clientv3.GetResponse{}
+	for k, v := range kv.keys {
+		if k == key || prefix && strings.HasPrefix(k, key) {
+			rsp.Kvs = append(rsp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	sort.Slice(rsp.Kvs, func(i, j int) bool {
+		return string(rsp.Kvs[i].Key) < string(rsp.Kvs[j].Key)
+	})
+	return rsp, nil
+}
+
+func (kv *fakeKV) put(key, val string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.keys[key] = val
+}
+
+type fakeWatcher struct {
+	clientv3.Watcher
+
+	ch chan clientv3.WatchResponse
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	return w.ch
+}
+
+func newTestClient() (*clientv3.Client, *fakeKV, *fakeWatcher) {
+	kv := &fakeKV{keys: map[string]string{
+		"/kratos/app.yaml":      "name: app",
+		"/kratos/db.json":       `{"dsn": "db"}`,
+		"/kratos/redis":         "addr: redis",
+		"/kratos-other/app.yml": "name: other",
+	}}
+	w := &fakeWatcher{ch: make(chan clientv3.WatchResponse, 1)}
+	return &clientv3.Client{KV: kv, Watcher: w}, kv, w
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []*config.KeyValue
+	}{
+		{
+			name: "key",
+			opts: []Option{WithPath("/kratos/app.yaml")},
+			want: []*config.KeyValue{
+				{Key: "/kratos/app.yaml", Value: []byte("name: app"), Format: "yaml"},
+			},
+		},
+		{
+			name: "prefix",
+			opts: []Option{WithPath("/kratos/"), WithPrefix(true)},
+			want: []*config.KeyValue{
+				{Key: "/kratos/app.yaml", Value: []byte("name: app"), Format: "yaml"},
+				{Key: "/kratos/db.json", Value: []byte(`{"dsn": "db"}`), Format: "json"},
+				{Key: "/kratos/redis", Value: []byte("addr: redis"), Format: ""},
+			},
+		},
+		{
+			name: "missing",
+			opts: []Option{WithPath("/missing")},
+			want: []*config.KeyValue{},
+		},
+	}
+	for _, test := range tests {
+		client, _, _ := newTestClient()
+		s, err := New(client, test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kvs, err := s.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(kvs, test.want) {
+			t.Errorf("%s: got %+v want %+v", test.name, kvs, test.want)
+		}
+	}
+
+	if _, err := New(&clientv3.Client{}); err == nil {
+		t.Error("got nil want the error of the empty path")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	client, kv, w := newTestClient()
+	s, err := New(client, WithPath("/kratos/app.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := s.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kv.put("/kratos/app.yaml", "name: changed")
+	w.ch <- clientv3.WatchResponse{}
+	kvs, err := watcher.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 1 || string(kvs[0].Value) != "name: changed" {
+		t.Errorf("got %+v want the changed value", kvs)
+	}
+
+	w.ch <- clientv3.WatchResponse{CompactRevision: 1}
+	if _, err := watcher.Next(); err == nil {
+		t.Error("got nil want the error of the compacted revision")
+	}
+
+	if err := watcher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := watcher.Next(); err != context.Canceled {
+		t.Errorf("got %v want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/go-kratos/kratos/contrib/config/etcd/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	go.etcd.io/etcd/api/v3 v3.5.0
+	go.etcd.io/etcd/client/v3 v3.5.0
+)
@@ -0,0 +1,53 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/config"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ config.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	source *source
+	ch     clientv3.WatchChan
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWatcher(s *source) *watcher {
+	ctx, cancel := context.WithCancel(s.options.ctx)
+	w := &watcher{
+		source: s,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	var opts []clientv3.OpOption
+	if s.options.prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	w.ch = s.client.Watch(ctx, s.options.path, opts...)
+	return w
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	select {
+	case resp, ok := <-w.ch:
+		if !ok {
+			return nil, context.Canceled
+		}
+		if resp.Err() != nil {
+			return nil, resp.Err()
+		}
+		return w.source.Load()
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	}
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,78 @@
+package logrus
+
+import (
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ log.Logger = (*Logger)(nil)
+
+// Logger is a logrus logger adapter.
+type Logger struct {
+	log    *logrus.Logger
+	msgKey string
+}
+
+// Option is logrus logger option.
+type Option func(*Logger)
+
+// WithMessageKey with message key, its value is logged as the logrus message.
+func WithMessageKey(key string) Option {
+	return func(l *Logger) {
+		l.msgKey = key
+	}
+}
+
+// NewLogger new a logrus logger adapter.
+func NewLogger(logger *logrus.Logger, opts ...Option) *Logger {
+	l := &Logger{
+		log:    logger,
+		msgKey: log.DefaultMessageKey,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Log print the kv pairs log.
+func (l *Logger) Log(level log.Level, keyvals ...interface{}) error {
+	lv := logrusLevel(level)
+	if !l.log.IsLevelEnabled(lv) {
+		return nil
+	}
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, "")
+	}
+	var (
+		msg    string
+		fields = make(logrus.Fields, len(keyvals)/2)
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if key == l.msgKey {
+			msg = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+		fields[key] = keyvals[i+1]
+	}
+	l.log.WithFields(fields).Log(lv, msg)
+	return nil
+}
+
+func logrusLevel(level log.Level) logrus.Level {
+	switch level {
+	case log.LevelDebug:
+		return logrus.DebugLevel
+	case log.LevelWarn:
+		return logrus.WarnLevel
+	case log.LevelError:
+		return logrus.ErrorLevel
+	case log.LevelFatal:
+		return logrus.FatalLevel
+	}
+	return logrus.InfoLevel
+}
@@ -0,0 +1,35 @@
+package logrus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	lg := logrus.New()
+	lg.Out = buf
+	lg.Level = logrus.InfoLevel
+	lg.Formatter = &logrus.TextFormatter{DisableColors: true, DisableTimestamp: true}
+
+	h := log.NewHelper("test", NewLogger(lg))
+	h.Debug("dropped")
+	h.Infow("key", "value")
+	h.Errorf("hello %s", "kratos")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	if lines[0] != `level=info key=value module=test` {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if lines[1] != `level=error msg="hello kratos" module=test` {
+		t.Errorf("unexpected line: %q", lines[1])
+	}
+}
@@ -0,0 +1,82 @@
+package zap
+
+import (
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var _ log.Logger = (*Logger)(nil)
+
+// Logger is a zap logger adapter.
+type Logger struct {
+	log    *zap.Logger
+	msgKey string
+}
+
+// Option is zap logger option.
+type Option func(*Logger)
+
+// WithMessageKey with message key, its value is logged as the zap message.
+func WithMessageKey(key string) Option {
+	return func(l *Logger) {
+		l.msgKey = key
+	}
+}
+
+// NewLogger new a zap logger adapter.
+func NewLogger(zlog *zap.Logger, opts ...Option) *Logger {
+	l := &Logger{
+		log:    zlog,
+		msgKey: log.DefaultMessageKey,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Log print the kv pairs log.
+func (l *Logger) Log(level log.Level, keyvals ...interface{}) error {
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, "")
+	}
+	var (
+		msg    string
+		fields = make([]zap.Field, 0, len(keyvals)/2)
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if key == l.msgKey {
+			msg = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+		fields = append(fields, zap.Any(key, keyvals[i+1]))
+	}
+	if ce := l.log.Check(zapLevel(level), msg); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// Sync flushes any buffered log entries.
+func (l *Logger) Sync() error {
+	return l.log.Sync()
+}
+
+func zapLevel(level log.Level) zapcore.Level {
+	switch level {
+	case log.LevelDebug:
+		return zapcore.DebugLevel
+	case log.LevelWarn:
+		return zapcore.WarnLevel
+	case log.LevelError:
+		return zapcore.ErrorLevel
+	case log.LevelFatal:
+		return zapcore.FatalLevel
+	}
+	return zapcore.InfoLevel
+}
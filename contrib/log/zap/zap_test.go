@@ -0,0 +1,32 @@
+package zap
+
+import (
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewLogger(zap.New(core))
+
+	h := log.NewHelper("test", logger)
+	h.Debug("dropped")
+	h.Infow("key", "value")
+	h.Warnf("hello %s", "kratos")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Level != zapcore.InfoLevel || entries[0].ContextMap()["key"] != "value" || entries[0].ContextMap()["module"] != "test" {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].Level != zapcore.WarnLevel || entries[1].Message != "hello kratos" {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+}
@@ -0,0 +1,74 @@
+package zerolog
+
+import (
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/rs/zerolog"
+)
+
+var _ log.Logger = (*Logger)(nil)
+
+// Logger is a zerolog logger adapter.
+type Logger struct {
+	log    *zerolog.Logger
+	msgKey string
+}
+
+// Option is zerolog logger option.
+type Option func(*Logger)
+
+// WithMessageKey with message key, its value is logged as the zerolog message.
+func WithMessageKey(key string) Option {
+	return func(l *Logger) {
+		l.msgKey = key
+	}
+}
+
+// NewLogger new a zerolog logger adapter.
+func NewLogger(logger *zerolog.Logger, opts ...Option) *Logger {
+	l := &Logger{
+		log:    logger,
+		msgKey: log.DefaultMessageKey,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Log print the kv pairs log.
+func (l *Logger) Log(level log.Level, keyvals ...interface{}) error {
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, "")
+	}
+	var (
+		msg   string
+		event = l.log.WithLevel(zerologLevel(level))
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if key == l.msgKey {
+			msg = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+		event = event.Interface(key, keyvals[i+1])
+	}
+	event.Msg(msg)
+	return nil
+}
+
+func zerologLevel(level log.Level) zerolog.Level {
+	switch level {
+	case log.LevelDebug:
+		return zerolog.DebugLevel
+	case log.LevelWarn:
+		return zerolog.WarnLevel
+	case log.LevelError:
+		return zerolog.ErrorLevel
+	case log.LevelFatal:
+		return zerolog.FatalLevel
+	}
+	return zerolog.InfoLevel
+}
@@ -0,0 +1,84 @@
+package zerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		level   log.Level
+		keyvals []interface{}
+		want    map[string]interface{}
+	}{
+		{
+			name:    "debug",
+			level:   log.LevelDebug,
+			keyvals: []interface{}{"key", "value"},
+			want:    map[string]interface{}{"level": "debug", "key": "value"},
+		},
+		{
+			name:    "message",
+			level:   log.LevelInfo,
+			keyvals: []interface{}{log.DefaultMessageKey, "hello", "count", 1},
+			want:    map[string]interface{}{"level": "info", "message": "hello", "count": float64(1)},
+		},
+		{
+			name:    "message key",
+			opts:    []Option{WithMessageKey("text")},
+			level:   log.LevelWarn,
+			keyvals: []interface{}{"text", "hello", "key", "value"},
+			want:    map[string]interface{}{"level": "warn", "message": "hello", "key": "value"},
+		},
+		{
+			name:    "odd",
+			level:   log.LevelError,
+			keyvals: []interface{}{"key"},
+			want:    map[string]interface{}{"level": "error", "key": ""},
+		},
+		{
+			name:    "fatal",
+			level:   log.LevelFatal,
+			keyvals: []interface{}{"key", "value"},
+			want:    map[string]interface{}{"level": "fatal", "key": "value"},
+		},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		zl := zerolog.New(buf)
+		if err := NewLogger(&zl, test.opts...).Log(test.level, test.keyvals...); err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%s: %v of %q", test.name, err, buf.String())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zl := zerolog.New(buf).Level(zerolog.InfoLevel)
+	h := log.NewHelper("test", NewLogger(&zl))
+	h.Debug("dropped")
+	h.Infow("key", "value")
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v of %q", err, buf.String())
+	}
+	want := map[string]interface{}{"level": "info", "key": "value", "module": "test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,27 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCounter(t *testing.T) {
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "server",
+		Subsystem: "requests",
+		Name:      "code_total",
+	}, []string{"kind", "operation", "code", "reason"})
+	c := NewCounter(cv)
+	c.With("GRPC", "/helloworld.Greeter/SayHello", "0", "").Inc()
+	c.With("GRPC", "/helloworld.Greeter/SayHello", "0", "").Add(2)
+	c.With("GRPC", "/helloworld.Greeter/SayHello", "5", "USER_NOT_FOUND").Inc()
+
+	if got := testutil.ToFloat64(cv.WithLabelValues("GRPC", "/helloworld.Greeter/SayHello", "0", "")); got != 3 {
+		t.Errorf("got %v want 3", got)
+	}
+	if got := testutil.CollectAndCount(cv); got != 2 {
+		t.Errorf("got %d series want 2", got)
+	}
+}
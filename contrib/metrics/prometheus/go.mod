@@ -0,0 +1,10 @@
+module github.com/go-kratos/kratos/contrib/metrics/prometheus/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.11.0
+)
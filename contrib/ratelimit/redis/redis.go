@@ -0,0 +1,174 @@
+// Package redis provides the distributed rate limiters backed by Redis, so that the
+// quota of a key is enforced across all the instances of a service.
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/ratelimit"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	_ ratelimit.KeyLimiter = (*TokenBucket)(nil)
+	_ ratelimit.KeyLimiter = (*SlidingWindow)(nil)
+)
+
+// tokenBucket refills the bucket by the elapsed time and takes a token from it.
+// KEYS[1]: bucket, ARGV: rate per second, burst, now in microseconds, ttl in milliseconds.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate / 1000000)
+	ts = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return allowed
+`)
+
+// slidingWindow logs the requests of the window in a sorted set.
+// KEYS[1]: window, ARGV: limit, now in microseconds, window in microseconds, member.
+var slidingWindow = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) >= limit then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now, ARGV[4])
+redis.call("PEXPIRE", KEYS[1], math.ceil(window / 1000))
+return 1
+`)
+
+// Option is redis limiter option.
+type Option func(o *options)
+
+type options struct {
+	prefix   string
+	failOpen bool
+	now      func() time.Time
+}
+
+// Prefix with the prefix of the redis keys, "ratelimit:" by default.
+func Prefix(prefix string) Option {
+	return func(o *options) { o.prefix = prefix }
+}
+
+// FailOpen with whether the requests are allowed when redis fails, true by default.
+func FailOpen(failOpen bool) Option {
+	return func(o *options) { o.failOpen = failOpen }
+}
+
+func newOptions(opts []Option) *options {
+	options := &options{
+		prefix:   "ratelimit:",
+		failOpen: true,
+		now:      time.Now,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	return options
+}
+
+func (o *options) result(allowed int, err error) (ratelimit.DoneFunc, error) {
+	if err != nil {
+		if o.failOpen {
+			return noop, nil
+		}
+		return nil, err
+	}
+	if allowed == 0 {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return noop, nil
+}
+
+func noop(ratelimit.DoneInfo) {}
+
+// TokenBucket is a token bucket limiter, the bucket of a key holds at most burst
+// tokens and is refilled at rate tokens per second, every request takes a token.
+// The clocks of the instances are expected to be synchronized.
+type TokenBucket struct {
+	opts   *options
+	client redis.UniversalClient
+	rate   float64
+	burst  int
+	ttl    int64
+}
+
+// NewTokenBucket new a token bucket limiter.
+func NewTokenBucket(client redis.UniversalClient, rate float64, burst int, opts ...Option) *TokenBucket {
+	// the bucket is full again after burst/rate seconds, so it expires then.
+	ttl := int64(float64(burst)/rate*1000) + 1000
+	return &TokenBucket{
+		opts:   newOptions(opts),
+		client: client,
+		rate:   rate,
+		burst:  burst,
+		ttl:    ttl,
+	}
+}
+
+// AllowKey takes a token from the bucket of the key.
+func (l *TokenBucket) AllowKey(ctx context.Context, key string) (ratelimit.DoneFunc, error) {
+	now := l.opts.now().UnixNano() / int64(time.Microsecond)
+	allowed, err := tokenBucket.Run(ctx, l.client, []string{l.opts.prefix + key}, l.rate, l.burst, now, l.ttl).Int()
+	return l.opts.result(allowed, err)
+}
+
+// SlidingWindow is a sliding window limiter, which allows at most limit requests
+// of a key in any window. The clocks of the instances are expected to be synchronized.
+type SlidingWindow struct {
+	opts   *options
+	client redis.UniversalClient
+	limit  int
+	window time.Duration
+	// the members of the window are unique among the instances by the id and the sequence.
+	id  string
+	seq uint64
+}
+
+// NewSlidingWindow new a sliding window limiter.
+func NewSlidingWindow(client redis.UniversalClient, limit int, window time.Duration, opts ...Option) *SlidingWindow {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &SlidingWindow{
+		id:     hex.EncodeToString(id) + "-",
+		opts:   newOptions(opts),
+		client: client,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// AllowKey logs the request in the window of the key if the window is not full.
+func (l *SlidingWindow) AllowKey(ctx context.Context, key string) (ratelimit.DoneFunc, error) {
+	now := l.opts.now().UnixNano() / int64(time.Microsecond)
+	window := int64(l.window / time.Microsecond)
+	member := l.id + strconv.FormatUint(atomic.AddUint64(&l.seq, 1), 10)
+	allowed, err := slidingWindow.Run(ctx, l.client, []string{l.opts.prefix + key}, l.limit, now, window, member).Int()
+	return l.opts.result(allowed, err)
+}
@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/ratelimit"
+	"github.com/go-redis/redis/v8"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time { return c.t }
+
+func newClient(t *testing.T) redis.UniversalClient {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return redis.NewClient(&redis.Options{Addr: s.Addr()})
+}
+
+func allow(t *testing.T, l ratelimit.KeyLimiter, key string, n int) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		done, err := l.AllowKey(context.Background(), key)
+		if err == ratelimit.ErrLimitExceed {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(ratelimit.DoneInfo{})
+		allowed++
+	}
+	return allowed
+}
+
+func TestTokenBucket(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	l := NewTokenBucket(newClient(t), 10, 5)
+	l.opts.now = c.now
+
+	if n := allow(t, l, "a", 10); n != 5 {
+		t.Errorf("got %d want the burst 5", n)
+	}
+	if n := allow(t, l, "b", 10); n != 5 {
+		t.Errorf("got %d want 5 of another key", n)
+	}
+	c.t = c.t.Add(200 * time.Millisecond)
+	if n := allow(t, l, "a", 10); n != 2 {
+		t.Errorf("got %d want 2 refilled tokens", n)
+	}
+	c.t = c.t.Add(time.Hour)
+	if n := allow(t, l, "a", 10); n != 5 {
+		t.Errorf("got %d want the burst 5", n)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	client := newClient(t)
+	l := NewSlidingWindow(client, 3, time.Second)
+	l.opts.now = c.now
+	// another instance shares the quota.
+	l2 := NewSlidingWindow(client, 3, time.Second)
+	l2.opts.now = c.now
+
+	if n := allow(t, l, "a", 2); n != 2 {
+		t.Errorf("got %d want 2", n)
+	}
+	c.t = c.t.Add(500 * time.Millisecond)
+	if n := allow(t, l2, "a", 5); n != 1 {
+		t.Errorf("got %d want 1", n)
+	}
+	c.t = c.t.Add(600 * time.Millisecond)
+	if n := allow(t, l, "a", 5); n != 2 {
+		t.Errorf("got %d want 2 of the expired requests", n)
+	}
+}
+
+func TestFailOpen(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: s.Addr(), MaxRetries: -1})
+	s.Close()
+
+	if _, err := NewTokenBucket(client, 1, 1).AllowKey(context.Background(), "a"); err != nil {
+		t.Errorf("got %v want the request allowed", err)
+	}
+	_, err = NewSlidingWindow(client, 1, time.Second, FailOpen(false)).AllowKey(context.Background(), "a")
+	if err == nil || err == ratelimit.ErrLimitExceed {
+		t.Errorf("got %v want the redis error", err)
+	}
+}
@@ -0,0 +1,231 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/hashicorp/consul/api"
+)
+
+var (
+	_ registry.Registrar = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+const versionTag = "version="
+
+// Option is consul registry option.
+type Option func(o *options)
+
+type options struct {
+	tags           []string
+	healthCheck    bool
+	ttl            time.Duration
+	grpcInterval   time.Duration
+	deregisterTime time.Duration
+}
+
+// WithTags with service tags.
+func WithTags(tags ...string) Option {
+	return func(o *options) { o.tags = tags }
+}
+
+// WithHealthCheck with registry health check.
+func WithHealthCheck(enable bool) Option {
+	return func(o *options) { o.healthCheck = enable }
+}
+
+// WithTTLCheck with a TTL health check refreshed by the registry itself.
+func WithTTLCheck(ttl time.Duration) Option {
+	return func(o *options) { o.ttl = ttl }
+}
+
+// WithGRPCCheck with a gRPC health check performed by the consul agent
+// against the grpc:// endpoint of the instance.
+func WithGRPCCheck(interval time.Duration) Option {
+	return func(o *options) { o.grpcInterval = interval }
+}
+
+// WithDeregisterCriticalServiceAfter with the time after which a failing instance is deregistered.
+func WithDeregisterCriticalServiceAfter(d time.Duration) Option {
+	return func(o *options) { o.deregisterTime = d }
+}
+
+// Registry is consul registry.
+type Registry struct {
+	opts    options
+	cli     *api.Client
+	lock    sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// New creates consul registry.
+func New(client *api.Client, opts ...Option) *Registry {
+	options := options{
+		healthCheck:    true,
+		ttl:            time.Second * 10,
+		deregisterTime: time.Minute,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Registry{
+		opts:    options,
+		cli:     client,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Register the registration.
+func (r *Registry) Register(ctx context.Context, svc *registry.ServiceInstance) error {
+	asr := &api.AgentServiceRegistration{
+		ID:              svc.ID,
+		Name:            svc.Name,
+		Meta:            svc.Metadata,
+		Tags:            append([]string{versionTag + svc.Version}, r.opts.tags...),
+		TaggedAddresses: make(map[string]api.ServiceAddress),
+	}
+	var grpcAddr string
+	for _, endpoint := range svc.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return err
+		}
+		port, err := strconv.Atoi(u.Port())
+		if err != nil {
+			return err
+		}
+		if asr.Address == "" {
+			asr.Address = u.Hostname()
+			asr.Port = port
+		}
+		if u.Scheme == "grpc" {
+			grpcAddr = u.Host
+		}
+		// the address of the scheme is the endpoint itself, so its query is kept, i.e. ?isSecure=true.
+		asr.TaggedAddresses[u.Scheme] = api.ServiceAddress{Address: endpoint, Port: port}
+	}
+	if r.opts.healthCheck {
+		if r.opts.ttl > 0 {
+			asr.Checks = append(asr.Checks, &api.AgentServiceCheck{
+				CheckID:                        checkID(svc.ID),
+				TTL:                            r.opts.ttl.String(),
+				DeregisterCriticalServiceAfter: r.opts.deregisterTime.String(),
+			})
+		}
+		if r.opts.grpcInterval > 0 && grpcAddr != "" {
+			asr.Checks = append(asr.Checks, &api.AgentServiceCheck{
+				GRPC:                           grpcAddr,
+				Interval:                       r.opts.grpcInterval.String(),
+				DeregisterCriticalServiceAfter: r.opts.deregisterTime.String(),
+			})
+		}
+	}
+	if err := r.cli.Agent().ServiceRegisterOpts(asr, api.ServiceRegisterOpts{}.WithContext(ctx)); err != nil {
+		return err
+	}
+	if r.opts.healthCheck && r.opts.ttl > 0 {
+		if err := r.cli.Agent().UpdateTTL(checkID(svc.ID), "pass", api.HealthPassing); err != nil {
+			return err
+		}
+		hctx, cancel := context.WithCancel(context.Background())
+		r.lock.Lock()
+		if c, ok := r.cancels[svc.ID]; ok {
+			c()
+		}
+		r.cancels[svc.ID] = cancel
+		r.lock.Unlock()
+		go r.heartbeat(hctx, checkID(svc.ID))
+	}
+	return nil
+}
+
+// Deregister the registration.
+func (r *Registry) Deregister(ctx context.Context, svc *registry.ServiceInstance) error {
+	r.lock.Lock()
+	if cancel, ok := r.cancels[svc.ID]; ok {
+		cancel()
+		delete(r.cancels, svc.ID)
+	}
+	r.lock.Unlock()
+	return r.cli.Agent().ServiceDeregister(svc.ID)
+}
+
+// GetService return the service instances in memory according to the service name.
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	ins, _, err := r.service(ctx, name, 0)
+	return ins, err
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return newWatcher(ctx, name, r), nil
+}
+
+// service performs a blocking query when index is greater than zero.
+func (r *Registry) service(ctx context.Context, name string, index uint64) ([]*registry.ServiceInstance, uint64, error) {
+	opts := &api.QueryOptions{WaitIndex: index}
+	entries, meta, err := r.cli.Health().Service(name, "", true, opts.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	ins := make([]*registry.ServiceInstance, 0, len(entries))
+	for _, entry := range entries {
+		ins = append(ins, toInstance(entry.Service))
+	}
+	return ins, meta.LastIndex, nil
+}
+
+func (r *Registry) heartbeat(ctx context.Context, id string) {
+	ticker := time.NewTicker(r.opts.ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = r.cli.Agent().UpdateTTL(id, "pass", api.HealthPassing)
+		}
+	}
+}
+
+func checkID(id string) string {
+	return "service:" + id
+}
+
+func toInstance(svc *api.AgentService) *registry.ServiceInstance {
+	var version string
+	for _, tag := range svc.Tags {
+		if strings.HasPrefix(tag, versionTag) {
+			version = strings.TrimPrefix(tag, versionTag)
+			break
+		}
+	}
+	endpoints := make([]string, 0, len(svc.TaggedAddresses))
+	for scheme, addr := range svc.TaggedAddresses {
+		if scheme == "lan_ipv4" || scheme == "wan_ipv4" || scheme == "lan_ipv6" || scheme == "wan_ipv6" {
+			continue
+		}
+		if u, err := url.Parse(addr.Address); err == nil && u.Scheme == scheme {
+			endpoints = append(endpoints, addr.Address)
+			continue
+		}
+		// the address of the instances registered by the others.
+		endpoints = append(endpoints, fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(addr.Address, strconv.Itoa(addr.Port))))
+	}
+	return &registry.ServiceInstance{
+		ID:        svc.ID,
+		Name:      svc.Service,
+		Version:   version,
+		Metadata:  svc.Meta,
+		Endpoints: endpoints,
+	}
+}
@@ -0,0 +1,215 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// fakeAgent is an in memory consul agent, which serves the registrations and the
+// blocking queries of the health endpoint.
+type fakeAgent struct {
+	mu       sync.Mutex
+	services map[string]*api.AgentServiceRegistration
+	ttls     map[string]int
+	index    uint64
+	changed  chan struct{}
+}
+
+func newFakeAgent(t *testing.T) (*fakeAgent, *api.Client) {
+	a := &fakeAgent{
+		services: make(map[string]*api.AgentServiceRegistration),
+		ttls:     make(map[string]int),
+		index:    1,
+		changed:  make(chan struct{}),
+	}
+	srv := httptest.NewServer(a)
+	t.Cleanup(srv.Close)
+	cli, err := api.NewClient(&api.Config{Address: strings.TrimPrefix(srv.URL, "http://")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, cli
+}
+
+// change bumps the index and wakes up the blocking queries, a.mu is held.
+func (a *fakeAgent) change() {
+	a.index++
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+func (a *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/agent/service/register":
+		var asr api.AgentServiceRegistration
+		if err := json.NewDecoder(r.Body).Decode(&asr); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		a.mu.Lock()
+		a.services[asr.ID] = &asr
+		a.change()
+		a.mu.Unlock()
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/service/deregister/"):
+		a.mu.Lock()
+		delete(a.services, strings.TrimPrefix(r.URL.Path, "/v1/agent/service/deregister/"))
+		a.change()
+		a.mu.Unlock()
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/check/update/"):
+		a.mu.Lock()
+		a.ttls[strings.TrimPrefix(r.URL.Path, "/v1/agent/check/update/")]++
+		a.mu.Unlock()
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		a.health(w, r, strings.TrimPrefix(r.URL.Path, "/v1/health/service/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (a *fakeAgent) health(w http.ResponseWriter, r *http.Request, name string) {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	a.mu.Lock()
+	if index == a.index {
+		changed := a.changed
+		a.mu.Unlock()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+		a.mu.Lock()
+	}
+	entries := make([]*api.ServiceEntry, 0, len(a.services))
+	for _, asr := range a.services {
+		if asr.Name != name {
+			continue
+		}
+		entries = append(entries, &api.ServiceEntry{Service: &api.AgentService{
+			ID:              asr.ID,
+			Service:         asr.Name,
+			Tags:            asr.Tags,
+			Meta:            asr.Meta,
+			Address:         asr.Address,
+			Port:            asr.Port,
+			TaggedAddresses: asr.TaggedAddresses,
+		}})
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(a.index, 10))
+	a.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func TestRegistry(t *testing.T) {
+	agent, cli := newFakeAgent(t)
+	r := New(cli, WithTTLCheck(time.Second), WithGRPCCheck(time.Second))
+	ctx := context.Background()
+	svc := &registry.ServiceInstance{
+		ID:        "1",
+		Name:      "helloworld",
+		Version:   "v1.0.0",
+		Metadata:  map[string]string{"region": "sh"},
+		Endpoints: []string{"http://127.0.0.1:8000", "grpc://127.0.0.1:9000?isSecure=true"},
+	}
+	w, err := r.Watch(ctx, svc.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if ins, err := w.Next(); err != nil || len(ins) != 0 {
+		t.Fatalf("got %v %v want no instances", ins, err)
+	}
+	if err := r.Register(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	agent.mu.Lock()
+	asr := agent.services[svc.ID]
+	ttls := agent.ttls[checkID(svc.ID)]
+	agent.mu.Unlock()
+	if asr.Address != "127.0.0.1" || asr.Port != 8000 || len(asr.Checks) != 2 || asr.Checks[1].GRPC != "127.0.0.1:9000" {
+		t.Errorf("got %+v want the address of the first endpoint and the checks", asr)
+	}
+	if ttls != 1 {
+		t.Errorf("got %d TTL updates want 1", ttls)
+	}
+
+	ins, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ins) != 1 {
+		t.Fatalf("got %v want the instance", ins)
+	}
+	sort.Strings(ins[0].Endpoints)
+	want := &registry.ServiceInstance{
+		ID:        svc.ID,
+		Name:      svc.Name,
+		Version:   svc.Version,
+		Metadata:  svc.Metadata,
+		Endpoints: []string{"grpc://127.0.0.1:9000?isSecure=true", "http://127.0.0.1:8000"},
+	}
+	if !reflect.DeepEqual(ins[0], want) {
+		t.Errorf("got %+v want %+v", ins[0], want)
+	}
+
+	if err := r.Deregister(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	if ins, err := w.Next(); err != nil || len(ins) != 0 {
+		t.Errorf("got %v %v want no instances", ins, err)
+	}
+	if ins, err := r.GetService(ctx, svc.Name); err != nil || len(ins) != 0 {
+		t.Errorf("got %v %v want no instances", ins, err)
+	}
+}
+
+func TestToInstance(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses map[string]api.ServiceAddress
+		endpoints []string
+	}{
+		{
+			name: "endpoints",
+			addresses: map[string]api.ServiceAddress{
+				"grpc": {Address: "grpc://10.0.0.1:9000?isSecure=true", Port: 9000},
+				"http": {Address: "http://10.0.0.1:8000", Port: 8000},
+			},
+			endpoints: []string{"grpc://10.0.0.1:9000?isSecure=true", "http://10.0.0.1:8000"},
+		},
+		{
+			name: "addresses",
+			addresses: map[string]api.ServiceAddress{
+				"grpc":     {Address: "10.0.0.1", Port: 9000},
+				"lan_ipv4": {Address: "10.0.0.1", Port: 9000},
+				"wan_ipv6": {Address: "::1", Port: 9000},
+			},
+			endpoints: []string{"grpc://10.0.0.1:9000"},
+		},
+	}
+	for _, test := range tests {
+		ins := toInstance(&api.AgentService{
+			ID:              "1",
+			Service:         "helloworld",
+			Tags:            []string{"blue", versionTag + "v1"},
+			TaggedAddresses: test.addresses,
+		})
+		sort.Strings(ins.Endpoints)
+		if ins.Version != "v1" || !reflect.DeepEqual(ins.Endpoints, test.endpoints) {
+			t.Errorf("%s: got %s %v want v1 %v", test.name, ins.Version, ins.Endpoints, test.endpoints)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package consul
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	name   string
+	r      *Registry
+	ctx    context.Context
+	cancel context.CancelFunc
+	index  uint64
+	first  bool
+}
+
+func newWatcher(ctx context.Context, name string, r *Registry) *watcher {
+	w := &watcher{
+		name:  name,
+		r:     r,
+		first: true,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w
+}
+
+// Next blocks on a consul blocking query until the service set changes.
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	for {
+		ins, index, err := w.r.service(w.ctx, w.name, w.index)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			return nil, err
+		}
+		// the index went backwards, reset to force a full fetch.
+		if index < w.index {
+			index = 0
+		}
+		changed := index != w.index
+		w.index = index
+		if w.first || changed {
+			w.first = false
+			return ins, nil
+		}
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,171 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	_ registry.Registrar = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+// Option is etcd registry option.
+type Option func(o *options)
+
+type options struct {
+	ctx       context.Context
+	namespace string
+	ttl       time.Duration
+}
+
+// Context with registry context.
+func Context(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// Namespace with registry namespance.
+func Namespace(ns string) Option {
+	return func(o *options) { o.namespace = ns }
+}
+
+// RegisterTTL with register ttl.
+func RegisterTTL(ttl time.Duration) Option {
+	return func(o *options) { o.ttl = ttl }
+}
+
+// Registry is etcd registry.
+type Registry struct {
+	opts     *options
+	client   *clientv3.Client
+	kv       clientv3.KV
+	newLease func() clientv3.Lease
+
+	mu sync.Mutex
+	// leases are the leases of the registered instances by the instance id.
+	leases map[string]clientv3.Lease
+}
+
+// New creates etcd registry
+func New(client *clientv3.Client, opts ...Option) *Registry {
+	options := &options{
+		ctx:       context.Background(),
+		namespace: "/microservices",
+		ttl:       time.Second * 15,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	return &Registry{
+		opts:   options,
+		client: client,
+		kv:     clientv3.NewKV(client),
+		newLease: func() clientv3.Lease {
+			return clientv3.NewLease(client)
+		},
+		leases: make(map[string]clientv3.Lease),
+	}
+}
+
+// Register the registration, each instance keeps its own lease alive, so the instances
+// registered by the same registry are independent.
+func (r *Registry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	key := fmt.Sprintf("%s/%s/%s", r.opts.namespace, service.Name, service.ID)
+	value, err := marshal(service)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, ok := r.leases[service.ID]; ok {
+		lease.Close()
+		delete(r.leases, service.ID)
+	}
+	lease := r.newLease()
+	grant, err := lease.Grant(ctx, int64(r.opts.ttl.Seconds()))
+	if err != nil {
+		lease.Close()
+		return err
+	}
+	if _, err = r.kv.Put(ctx, key, value, clientv3.WithLease(grant.ID)); err != nil {
+		lease.Close()
+		return err
+	}
+	hb, err := lease.KeepAlive(r.opts.ctx, grant.ID)
+	if err != nil {
+		lease.Close()
+		return err
+	}
+	r.leases[service.ID] = lease
+	go func() {
+		for {
+			select {
+			case _, ok := <-hb:
+				if !ok {
+					return
+				}
+			case <-r.opts.ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Deregister the registration.
+func (r *Registry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, ok := r.leases[service.ID]; ok {
+		defer lease.Close()
+		delete(r.leases, service.ID)
+	}
+	key := fmt.Sprintf("%s/%s/%s", r.opts.namespace, service.Name, service.ID)
+	_, err := r.kv.Delete(ctx, key)
+	return err
+}
+
+// GetService return the service instances in memory according to the service name,
+// the key prefix ends with a slash so that the services sharing a name prefix are not mixed.
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	key := fmt.Sprintf("%s/%s/", r.opts.namespace, name)
+	resp, err := r.kv.Get(ctx, key, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*registry.ServiceInstance, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		si, err := unmarshal(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, si)
+	}
+	return items, nil
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	key := fmt.Sprintf("%s/%s/", r.opts.namespace, name)
+	return newWatcher(ctx, key, r.client), nil
+}
+
+func marshal(si *registry.ServiceInstance) (string, error) {
+	data, err := json.Marshal(si)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshal(data []byte) (si *registry.ServiceInstance, err error) {
+	err = json.Unmarshal(data, &si)
+	return
+}
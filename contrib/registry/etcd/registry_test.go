@@ -0,0 +1,203 @@
+package etcd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type fakeKV struct {
+	clientv3.KV
+
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (kv *fakeKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.keys[key] = val
+	return &clientv3.PutResponse{}, nil
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	prefix := len(clientv3.OpGet(key, opts...).RangeBytes()) > 0
+	rsp := &clientv3.GetResponse{}
+	for k, v := range kv.keys {
+		if k == key || prefix && strings.HasPrefix(k, key) {
+			rsp.Kvs = append(rsp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	sort.Slice(rsp.Kvs, func(i, j int) bool {
+		return string(rsp.Kvs[i].Key) < string(rsp.Kvs[j].Key)
+	})
+	return rsp, nil
+}
+
+func (kv *fakeKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	delete(kv.keys, key)
+	return &clientv3.DeleteResponse{}, nil
+}
+
+type fakeLease struct {
+	clientv3.Lease
+
+	id     clientv3.LeaseID
+	mu     sync.Mutex
+	closed bool
+}
+
+func (l *fakeLease) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	return &clientv3.LeaseGrantResponse{ID: l.id, TTL: ttl}, nil
+}
+
+func (l *fakeLease) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	return make(chan *clientv3.LeaseKeepAliveResponse), nil
+}
+
+func (l *fakeLease) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return nil
+}
+
+func (l *fakeLease) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+func newTestRegistry() (*Registry, *fakeKV, func() []*fakeLease) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := New(&clientv3.Client{}, Context(ctx))
+	kv := &fakeKV{keys: make(map[string]string)}
+	var (
+		mu     sync.Mutex
+		leases []*fakeLease
+	)
+	r.kv = kv
+	r.newLease = func() clientv3.Lease {
+		mu.Lock()
+		defer mu.Unlock()
+		l := &fakeLease{id: clientv3.LeaseID(len(leases) + 1)}
+		leases = append(leases, l)
+		return l
+	}
+	return r, kv, func() []*fakeLease {
+		cancel()
+		mu.Lock()
+		defer mu.Unlock()
+		return leases
+	}
+}
+
+func TestRegisterLeases(t *testing.T) {
+	r, kv, leases := newTestRegistry()
+	a := &registry.ServiceInstance{ID: "a", Name: "helloworld"}
+	b := &registry.ServiceInstance{ID: "b", Name: "helloworld"}
+	for _, ins := range []*registry.ServiceInstance{a, b, a} {
+		if err := r.Register(context.Background(), ins); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Deregister(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	ls := leases()
+	if len(ls) != 3 {
+		t.Fatalf("got %d leases want one per registration", len(ls))
+	}
+	tests := []struct {
+		lease  *fakeLease
+		closed bool
+	}{
+		// the lease of a is replaced by the second registration of a.
+		{ls[0], true},
+		// the lease of b is closed by its deregistration only.
+		{ls[1], true},
+		{ls[2], false},
+	}
+	for i, test := range tests {
+		if closed := test.lease.isClosed(); closed != test.closed {
+			t.Errorf("%d: got closed %t want %t", i, closed, test.closed)
+		}
+	}
+	if _, ok := kv.keys["/microservices/helloworld/a"]; !ok || len(kv.keys) != 1 {
+		t.Errorf("got %v want the instance a", kv.keys)
+	}
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	r, kv, leases := newTestRegistry()
+	var wg sync.WaitGroup
+	for _, id := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			ins := &registry.ServiceInstance{ID: id, Name: "helloworld"}
+			if err := r.Register(context.Background(), ins); err != nil {
+				t.Error(err)
+			}
+		}(id)
+	}
+	wg.Wait()
+	if len(kv.keys) != 4 {
+		t.Errorf("got %v want the 4 instances", kv.keys)
+	}
+	for _, l := range leases() {
+		if l.isClosed() {
+			t.Errorf("the lease %d of a registered instance is closed", l.id)
+		}
+	}
+}
+
+func TestGetService(t *testing.T) {
+	r, _, leases := newTestRegistry()
+	defer leases()
+	for _, ins := range []*registry.ServiceInstance{
+		{ID: "a", Name: "hello"},
+		{ID: "b", Name: "helloworld"},
+		{ID: "c", Name: "helloworld"},
+	} {
+		if err := r.Register(context.Background(), ins); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name string
+		ids  []string
+	}{
+		{"hello", []string{"a"}},
+		{"helloworld", []string{"b", "c"}},
+		{"hell", []string{}},
+	}
+	for _, test := range tests {
+		instances, err := r.GetService(context.Background(), test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, 0, len(instances))
+		for _, ins := range instances {
+			if ins.Name != test.name {
+				t.Errorf("%s: got the instance %s of %s", test.name, ins.ID, ins.Name)
+			}
+			ids = append(ids, ins.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(test.ids, ",") {
+			t.Errorf("%s: got %v want %v", test.name, ids, test.ids)
+		}
+	}
+}
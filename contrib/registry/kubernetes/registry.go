@@ -0,0 +1,209 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ registry.Discovery = (*Registry)(nil)
+
+const (
+	// LabelVersion is the endpoint slice label read as the instance version.
+	LabelVersion = "app.kubernetes.io/version"
+)
+
+// ErrCacheNotSynced is returned when the informer cache failed to sync.
+var ErrCacheNotSynced = errors.New("kubernetes: endpoint slice cache not synced")
+
+// Registry is a kubernetes discovery backed by EndpointSlice informers.
+// Instances are registered by kubernetes itself, so it only implements
+// registry.Discovery.
+//
+// The endpoint scheme of a port is its appProtocol, or else the protocol of its name by
+// the Istio convention, i.e. the ports named grpc or grpc-api are the grpc endpoints, and
+// the ports named http, http2 or http-web are the http endpoints. An unnamed port, i.e. the
+// only port of a Service, is both a grpc and an http endpoint. The ports of the other
+// protocols are ignored, and so are the addresses without an endpoint.
+type Registry struct {
+	namespace string
+	factory   informers.SharedInformerFactory
+	informer  cache.SharedIndexInformer
+	lister    listerv1.EndpointSliceLister
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+
+	lock     sync.RWMutex
+	watchers map[string]map[*watcher]struct{}
+}
+
+// NewRegistry creates a kubernetes discovery watching the given namespace.
+func NewRegistry(client kubernetes.Interface, namespace string) *Registry {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace))
+	slices := factory.Discovery().V1().EndpointSlices()
+	r := &Registry{
+		namespace: namespace,
+		factory:   factory,
+		informer:  slices.Informer(),
+		lister:    slices.Lister(),
+		stopCh:    make(chan struct{}),
+		watchers:  make(map[string]map[*watcher]struct{}),
+	}
+	r.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    r.notify,
+		UpdateFunc: func(_, obj interface{}) { r.notify(obj) },
+		DeleteFunc: r.notify,
+	})
+	return r
+}
+
+// Start starts the informer and waits for the cache to be synced.
+func (r *Registry) Start() error {
+	r.factory.Start(r.stopCh)
+	if !cache.WaitForCacheSync(r.stopCh, r.informer.HasSynced) {
+		return ErrCacheNotSynced
+	}
+	return nil
+}
+
+// Close stops the informer, it can be called more than once.
+func (r *Registry) Close() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+}
+
+// GetService return the service instances in memory according to the service name.
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: name})
+	slices, err := r.lister.EndpointSlices(r.namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	var ins []*registry.ServiceInstance
+	for _, slice := range slices {
+		ins = append(ins, toInstances(name, slice)...)
+	}
+	return ins, nil
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	w := newWatcher(ctx, name, r)
+	r.lock.Lock()
+	if _, ok := r.watchers[name]; !ok {
+		r.watchers[name] = make(map[*watcher]struct{})
+	}
+	r.watchers[name][w] = struct{}{}
+	r.lock.Unlock()
+	return w, nil
+}
+
+func (r *Registry) remove(w *watcher) {
+	r.lock.Lock()
+	delete(r.watchers[w.name], w)
+	r.lock.Unlock()
+}
+
+func (r *Registry) notify(obj interface{}) {
+	if tomb, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tomb.Obj
+	}
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return
+	}
+	name := slice.Labels[discoveryv1.LabelServiceName]
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for w := range r.watchers[name] {
+		select {
+		case w.event <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func toInstances(name string, slice *discoveryv1.EndpointSlice) []*registry.ServiceInstance {
+	var ins []*registry.ServiceInstance
+	for _, ep := range slice.Endpoints {
+		if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+			continue
+		}
+		for _, addr := range ep.Addresses {
+			var endpoints []string
+			for _, port := range slice.Ports {
+				if port.Port == nil {
+					continue
+				}
+				host := net.JoinHostPort(addr, strconv.Itoa(int(*port.Port)))
+				for _, scheme := range schemes(port) {
+					endpoints = append(endpoints, fmt.Sprintf("%s://%s", scheme, host))
+				}
+			}
+			if len(endpoints) == 0 {
+				continue
+			}
+			id := addr
+			if ep.TargetRef != nil {
+				id = ep.TargetRef.Name
+			}
+			md := make(map[string]string)
+			if ep.Zone != nil {
+				md["zone"] = *ep.Zone
+			}
+			if ep.NodeName != nil {
+				md["node"] = *ep.NodeName
+			}
+			ins = append(ins, &registry.ServiceInstance{
+				ID:        id,
+				Name:      name,
+				Version:   slice.Labels[LabelVersion],
+				Metadata:  md,
+				Endpoints: endpoints,
+			})
+		}
+	}
+	return ins
+}
+
+// schemes returns the endpoint schemes of the port by its appProtocol or its name.
+func schemes(port discoveryv1.EndpointPort) []string {
+	var protocol string
+	switch {
+	case port.AppProtocol != nil && *port.AppProtocol != "":
+		protocol = *port.AppProtocol
+	case port.Name != nil && *port.Name != "":
+		protocol = *port.Name
+	default:
+		return []string{"grpc", "http"}
+	}
+	protocol = strings.ToLower(protocol)
+	is := func(p string) bool {
+		return protocol == p || strings.HasPrefix(protocol, p+"-")
+	}
+	switch {
+	case is("grpc-web"):
+		return []string{"http"}
+	case is("grpc"):
+		return []string{"grpc"}
+	case is("https"):
+		return []string{"https"}
+	case is("http"), is("http2"):
+		return []string{"http"}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRegistry(t *testing.T) {
+	var (
+		port  = int32(9000)
+		name  = "grpc"
+		ready = true
+	)
+	client := fake.NewSimpleClientset(&discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "helloworld-abc",
+			Namespace: "default",
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: "helloworld",
+				LabelVersion:                 "v1.0.0",
+			},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+		},
+		Ports: []discoveryv1.EndpointPort{{Name: &name, Port: &port}},
+	})
+	r := NewRegistry(client, "default")
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	w, err := r.Watch(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	ins, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ins) != 1 {
+		t.Fatalf("expected 1 instance, got %d", len(ins))
+	}
+	if ins[0].Version != "v1.0.0" || ins[0].Endpoints[0] != "grpc://10.0.0.1:9000" {
+		t.Fatalf("unexpected instance: %+v", ins[0])
+	}
+}
+
+func TestToInstances(t *testing.T) {
+	str := func(s string) *string { return &s }
+	port := int32(8000)
+	tests := []struct {
+		name      string
+		ports     []discoveryv1.EndpointPort
+		endpoints []string
+	}{
+		{"unnamed", []discoveryv1.EndpointPort{{Port: &port}}, []string{"grpc://10.0.0.1:8000", "http://10.0.0.1:8000"}},
+		{"grpc", []discoveryv1.EndpointPort{{Name: str("grpc-api"), Port: &port}}, []string{"grpc://10.0.0.1:8000"}},
+		{"http", []discoveryv1.EndpointPort{{Name: str("http-web"), Port: &port}}, []string{"http://10.0.0.1:8000"}},
+		{"http2", []discoveryv1.EndpointPort{{Name: str("http2"), Port: &port}}, []string{"http://10.0.0.1:8000"}},
+		{"https", []discoveryv1.EndpointPort{{Name: str("https"), Port: &port}}, []string{"https://10.0.0.1:8000"}},
+		{"grpc-web", []discoveryv1.EndpointPort{{Name: str("grpc-web"), Port: &port}}, []string{"http://10.0.0.1:8000"}},
+		{"app protocol", []discoveryv1.EndpointPort{{Name: str("api"), AppProtocol: str("GRPC"), Port: &port}}, []string{"grpc://10.0.0.1:8000"}},
+		{"grpcx", []discoveryv1.EndpointPort{{Name: str("grpcx"), Port: &port}}, nil},
+		{"tcp", []discoveryv1.EndpointPort{{Name: str("tcp-db"), Port: &port}}, nil},
+	}
+	for _, test := range tests {
+		ins := toInstances("helloworld", &discoveryv1.EndpointSlice{
+			Endpoints: []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.1"}}},
+			Ports:     test.ports,
+		})
+		if test.endpoints == nil {
+			if len(ins) != 0 {
+				t.Errorf("%s: got %+v want no instance without the endpoints", test.name, ins[0])
+			}
+			continue
+		}
+		if len(ins) != 1 || !reflect.DeepEqual(ins[0].Endpoints, test.endpoints) {
+			t.Errorf("%s: got %+v want %v", test.name, ins, test.endpoints)
+		}
+	}
+}
+
+func TestRegistryClose(t *testing.T) {
+	r := NewRegistry(fake.NewSimpleClientset(), "default")
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	r.Close()
+}
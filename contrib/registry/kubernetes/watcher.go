@@ -0,0 +1,44 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	name   string
+	r      *Registry
+	ctx    context.Context
+	cancel context.CancelFunc
+	event  chan struct{}
+}
+
+func newWatcher(ctx context.Context, name string, r *Registry) *watcher {
+	w := &watcher{
+		name:  name,
+		r:     r,
+		event: make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	// trigger the first fetch
+	w.event <- struct{}{}
+	return w
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.event:
+	}
+	return w.r.GetService(w.ctx, w.name)
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	w.r.remove(w)
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package nacos implements the kratos registry on top of the nacos naming service.
+// The nacos namespace is bound to the naming client, configure it with
+// constant.ClientConfig.NamespaceId when creating the client.
+package nacos
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+var (
+	_ registry.Registrar = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+// Option is nacos registry option.
+type Option func(o *options)
+
+type options struct {
+	weight    float64
+	cluster   string
+	group     string
+	ephemeral bool
+}
+
+// WithWeight with instance weight.
+func WithWeight(weight float64) Option {
+	return func(o *options) { o.weight = weight }
+}
+
+// WithCluster with instance cluster.
+func WithCluster(cluster string) Option {
+	return func(o *options) { o.cluster = cluster }
+}
+
+// WithGroup with service group.
+func WithGroup(group string) Option {
+	return func(o *options) { o.group = group }
+}
+
+// WithEphemeral with ephemeral instance, which is removed by nacos once heartbeats stop.
+func WithEphemeral(ephemeral bool) Option {
+	return func(o *options) { o.ephemeral = ephemeral }
+}
+
+// Registry is nacos registry.
+type Registry struct {
+	opts options
+	cli  naming_client.INamingClient
+}
+
+// New creates nacos registry.
+func New(cli naming_client.INamingClient, opts ...Option) *Registry {
+	options := options{
+		weight:    100,
+		cluster:   "DEFAULT",
+		group:     "DEFAULT_GROUP",
+		ephemeral: true,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Registry{
+		opts: options,
+		cli:  cli,
+	}
+}
+
+// Register the registration, every endpoint is registered as a nacos instance.
+func (r *Registry) Register(ctx context.Context, svc *registry.ServiceInstance) error {
+	for _, endpoint := range svc.Endpoints {
+		u, host, port, err := parseEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+		md := map[string]string{
+			"id":      svc.ID,
+			"kind":    u.Scheme,
+			"version": svc.Version,
+		}
+		for k, v := range svc.Metadata {
+			md[k] = v
+		}
+		ok, err := r.cli.RegisterInstance(vo.RegisterInstanceParam{
+			Ip:          host,
+			Port:        port,
+			ServiceName: svc.Name,
+			Weight:      r.opts.weight,
+			Enable:      true,
+			Healthy:     true,
+			Ephemeral:   r.opts.ephemeral,
+			Metadata:    md,
+			ClusterName: r.opts.cluster,
+			GroupName:   r.opts.group,
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("nacos: failed to register instance: %s", endpoint)
+		}
+	}
+	return nil
+}
+
+// Deregister the registration.
+func (r *Registry) Deregister(ctx context.Context, svc *registry.ServiceInstance) error {
+	for _, endpoint := range svc.Endpoints {
+		_, host, port, err := parseEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+		if _, err = r.cli.DeregisterInstance(vo.DeregisterInstanceParam{
+			Ip:          host,
+			Port:        port,
+			ServiceName: svc.Name,
+			Cluster:     r.opts.cluster,
+			GroupName:   r.opts.group,
+			Ephemeral:   r.opts.ephemeral,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetService return the service instances in memory according to the service name.
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	res, err := r.cli.SelectInstances(vo.SelectInstancesParam{
+		ServiceName: name,
+		GroupName:   r.opts.group,
+		Clusters:    []string{r.opts.cluster},
+		HealthyOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toInstances(name, res), nil
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return newWatcher(ctx, name, r)
+}
+
+func parseEndpoint(endpoint string) (*url.URL, string, uint64, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	host, p, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	port, err := strconv.ParseUint(p, 10, 64)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return u, host, port, nil
+}
+
+// toInstances merges the nacos instances of each kratos instance by id.
+func toInstances(name string, res []model.Instance) []*registry.ServiceInstance {
+	var (
+		items []*registry.ServiceInstance
+		index = make(map[string]*registry.ServiceInstance)
+	)
+	for _, in := range res {
+		if !in.Enable || !in.Healthy {
+			continue
+		}
+		id := in.Metadata["id"]
+		if id == "" {
+			id = in.InstanceId
+		}
+		kind := in.Metadata["kind"]
+		if kind == "" {
+			kind = "grpc"
+		}
+		endpoint := fmt.Sprintf("%s://%s", kind, net.JoinHostPort(in.Ip, strconv.FormatUint(in.Port, 10)))
+		if si, ok := index[id]; ok {
+			si.Endpoints = append(si.Endpoints, endpoint)
+			continue
+		}
+		md := make(map[string]string, len(in.Metadata))
+		for k, v := range in.Metadata {
+			if k == "id" || k == "kind" || k == "version" {
+				continue
+			}
+			md[k] = v
+		}
+		md["weight"] = strconv.FormatFloat(in.Weight, 'f', -1, 64)
+		si := &registry.ServiceInstance{
+			ID:        id,
+			Name:      name,
+			Version:   in.Metadata["version"],
+			Metadata:  md,
+			Endpoints: []string{endpoint},
+		}
+		index[id] = si
+		items = append(items, si)
+	}
+	return items
+}
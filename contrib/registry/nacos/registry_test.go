@@ -0,0 +1,25 @@
+package nacos
+
+import (
+	"testing"
+
+	"github.com/nacos-group/nacos-sdk-go/model"
+)
+
+func TestToInstances(t *testing.T) {
+	res := []model.Instance{
+		{Ip: "10.0.0.1", Port: 8000, Enable: true, Healthy: true, Weight: 100, Metadata: map[string]string{"id": "1", "kind": "http", "version": "v1"}},
+		{Ip: "10.0.0.1", Port: 9000, Enable: true, Healthy: true, Weight: 100, Metadata: map[string]string{"id": "1", "kind": "grpc", "version": "v1"}},
+		{Ip: "10.0.0.2", Port: 9000, Enable: true, Healthy: false, Metadata: map[string]string{"id": "2", "kind": "grpc"}},
+	}
+	ins := toInstances("helloworld", res)
+	if len(ins) != 1 {
+		t.Fatalf("expected 1 instance, got %d", len(ins))
+	}
+	if ins[0].Version != "v1" || len(ins[0].Endpoints) != 2 {
+		t.Fatalf("unexpected instance: %+v", ins[0])
+	}
+	if ins[0].Metadata["weight"] != "100" {
+		t.Fatalf("expected weight metadata, got %+v", ins[0].Metadata)
+	}
+}
@@ -0,0 +1,62 @@
+package nacos
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	name   string
+	r      *Registry
+	ctx    context.Context
+	cancel context.CancelFunc
+	event  chan struct{}
+	param  *vo.SubscribeParam
+}
+
+func newWatcher(ctx context.Context, name string, r *Registry) (*watcher, error) {
+	w := &watcher{
+		name:  name,
+		r:     r,
+		event: make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	w.param = &vo.SubscribeParam{
+		ServiceName: name,
+		GroupName:   r.opts.group,
+		Clusters:    []string{r.opts.cluster},
+		SubscribeCallback: func(services []model.SubscribeService, err error) {
+			select {
+			case w.event <- struct{}{}:
+			default:
+			}
+		},
+	}
+	if err := r.cli.Subscribe(w.param); err != nil {
+		w.cancel()
+		return nil, err
+	}
+	// trigger the first fetch
+	w.event <- struct{}{}
+	return w, nil
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.event:
+	}
+	return w.r.GetService(w.ctx, w.name)
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	return w.r.cli.Unsubscribe(w.param)
+}
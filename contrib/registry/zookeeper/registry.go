@@ -0,0 +1,213 @@
+package zookeeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/go-zookeeper/zk"
+)
+
+var (
+	_ registry.Registrar = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+// conn is the subset of *zk.Conn used by the registry.
+type conn interface {
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Delete(path string, version int32) error
+	Get(path string) ([]byte, *zk.Stat, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+}
+
+// Option is zookeeper registry option.
+type Option func(o *options)
+
+type options struct {
+	namespace string
+	acl       []zk.ACL
+	// retry is the delay of the retries of the failed watches.
+	retry time.Duration
+}
+
+// Namespace with registry namespace.
+func Namespace(ns string) Option {
+	return func(o *options) { o.namespace = ns }
+}
+
+// ACL with the acl of the created znodes, zk.WorldACL(zk.PermAll) by default.
+func ACL(acl ...zk.ACL) Option {
+	return func(o *options) { o.acl = acl }
+}
+
+// Registry is zookeeper registry, the instances are registered as the ephemeral znodes
+// of {namespace}/{name}/{id}, which are removed by zookeeper once the session expires,
+// so the registry watches the znodes and creates them again in the new session.
+type Registry struct {
+	opts *options
+	conn conn
+
+	mu sync.Mutex
+	// cancels stop the keepers of the registered instances by the instance id.
+	cancels map[string]context.CancelFunc
+}
+
+// New creates zookeeper registry.
+func New(conn *zk.Conn, opts ...Option) *Registry {
+	return newRegistry(conn, opts...)
+}
+
+func newRegistry(c conn, opts ...Option) *Registry {
+	options := &options{
+		namespace: "/microservices",
+		acl:       zk.WorldACL(zk.PermAll),
+		retry:     time.Second,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	return &Registry{
+		opts:    options,
+		conn:    c,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Register the registration, the znode of the instance is kept until it is deregistered.
+func (r *Registry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	data, err := json.Marshal(service)
+	if err != nil {
+		return err
+	}
+	if err = r.ensurePath(path.Join(r.opts.namespace, service.Name)); err != nil {
+		return err
+	}
+	key := r.servicePath(service.Name, service.ID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopKeeper(service.ID)
+	// the znode may be left by the expiring session of the last run, which is replaced
+	// so that it belongs to the current session.
+	if err = r.conn.Delete(key, -1); err != nil && !errors.Is(err, zk.ErrNoNode) {
+		return err
+	}
+	if _, err = r.conn.Create(key, data, zk.FlagEphemeral, r.opts.acl); err != nil {
+		return err
+	}
+	kctx, cancel := context.WithCancel(context.Background())
+	r.cancels[service.ID] = cancel
+	go r.keep(kctx, key, data)
+	return nil
+}
+
+// Deregister the registration.
+func (r *Registry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopKeeper(service.ID)
+	err := r.conn.Delete(r.servicePath(service.Name, service.ID), -1)
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil
+	}
+	return err
+}
+
+// stopKeeper stops the keeper of the instance, the caller must hold r.mu.
+func (r *Registry) stopKeeper(id string) {
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+		delete(r.cancels, id)
+	}
+}
+
+// keep watches the znode of a registered instance and creates it again once it is
+// removed, i.e. by the expiry of the session, until ctx is done.
+func (r *Registry) keep(ctx context.Context, key string, data []byte) {
+	for {
+		var retry <-chan time.Time
+		ok, _, event, err := r.conn.ExistsW(key)
+		if err == nil && !ok {
+			r.mu.Lock()
+			if ctx.Err() == nil {
+				_, err = r.conn.Create(key, data, zk.FlagEphemeral, r.opts.acl)
+			}
+			r.mu.Unlock()
+			if err == nil || errors.Is(err, zk.ErrNodeExists) {
+				// watch the created znode.
+				continue
+			}
+		}
+		if err != nil {
+			// the session is not established yet.
+			retry = time.After(r.opts.retry)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-event:
+		case <-retry:
+		}
+	}
+}
+
+// GetService return the service instances according to the service name.
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	children, _, err := r.conn.Children(path.Join(r.opts.namespace, name))
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.instances(name, children)
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return newWatcher(ctx, r, name), nil
+}
+
+func (r *Registry) servicePath(name, id string) string {
+	return path.Join(r.opts.namespace, name, id)
+}
+
+func (r *Registry) instances(name string, children []string) ([]*registry.ServiceInstance, error) {
+	items := make([]*registry.ServiceInstance, 0, len(children))
+	for _, id := range children {
+		data, _, err := r.conn.Get(r.servicePath(name, id))
+		if errors.Is(err, zk.ErrNoNode) {
+			// deregistered after listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var si *registry.ServiceInstance
+		if err = json.Unmarshal(data, &si); err != nil {
+			return nil, err
+		}
+		items = append(items, si)
+	}
+	return items, nil
+}
+
+// ensurePath creates the persistent znodes of the path and its parents.
+func (r *Registry) ensurePath(p string) error {
+	var cur string
+	for _, part := range strings.Split(strings.Trim(p, "/"), "/") {
+		cur += "/" + part
+		if _, err := r.conn.Create(cur, nil, 0, r.opts.acl); err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	return nil
+}
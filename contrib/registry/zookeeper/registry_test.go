@@ -0,0 +1,339 @@
+package zookeeper
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/go-zookeeper/zk"
+)
+
+// fakeConn is an in memory zookeeper, which expires the session by expire.
+type fakeConn struct {
+	mu        sync.Mutex
+	nodes     map[string][]byte
+	ephemeral map[string]bool
+	children  map[string][]chan zk.Event
+	exists    map[string][]chan zk.Event
+	// err fails the watches of the disconnected session.
+	err error
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		nodes:     map[string][]byte{"/": nil},
+		ephemeral: make(map[string]bool),
+		children:  make(map[string][]chan zk.Event),
+		exists:    make(map[string][]chan zk.Event),
+	}
+}
+
+// expire removes the ephemeral znodes of the session, and fails the watches until the
+// new session is established by reconnect.
+func (c *fakeConn) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = zk.ErrNoServer
+	for p, ephemeral := range c.ephemeral {
+		if ephemeral {
+			delete(c.nodes, p)
+			delete(c.ephemeral, p)
+		}
+	}
+	for _, watches := range []map[string][]chan zk.Event{c.children, c.exists} {
+		for p, chs := range watches {
+			for _, ch := range chs {
+				ch <- zk.Event{Type: zk.EventNotWatching, State: zk.StateExpired, Path: p, Err: zk.ErrSessionExpired}
+			}
+			delete(watches, p)
+		}
+	}
+}
+
+func (c *fakeConn) reconnect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = nil
+}
+
+func (c *fakeConn) node(p string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.nodes[p]
+	return data, ok
+}
+
+func (c *fakeConn) fire(watches map[string][]chan zk.Event, p string, t zk.EventType) {
+	for _, ch := range watches[p] {
+		ch <- zk.Event{Type: t, Path: p}
+	}
+	delete(watches, p)
+}
+
+func (c *fakeConn) Create(p string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.nodes[p]; ok {
+		return "", zk.ErrNodeExists
+	}
+	if _, ok := c.nodes[path.Dir(p)]; !ok {
+		return "", zk.ErrNoNode
+	}
+	c.nodes[p] = data
+	c.ephemeral[p] = flags&zk.FlagEphemeral == zk.FlagEphemeral
+	c.fire(c.exists, p, zk.EventNodeCreated)
+	c.fire(c.children, path.Dir(p), zk.EventNodeChildrenChanged)
+	return p, nil
+}
+
+func (c *fakeConn) Delete(p string, version int32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.nodes[p]; !ok {
+		return zk.ErrNoNode
+	}
+	delete(c.nodes, p)
+	delete(c.ephemeral, p)
+	c.fire(c.exists, p, zk.EventNodeDeleted)
+	c.fire(c.children, path.Dir(p), zk.EventNodeChildrenChanged)
+	return nil
+}
+
+func (c *fakeConn) Get(p string) ([]byte, *zk.Stat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return data, &zk.Stat{}, nil
+}
+
+func (c *fakeConn) Children(p string) ([]string, *zk.Stat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.list(p)
+}
+
+func (c *fakeConn) list(p string) ([]string, *zk.Stat, error) {
+	if _, ok := c.nodes[p]; !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	var children []string
+	for k := range c.nodes {
+		if k != p && path.Dir(k) == p {
+			children = append(children, strings.TrimPrefix(k, p+"/"))
+		}
+	}
+	sort.Strings(children)
+	return children, &zk.Stat{}, nil
+}
+
+func (c *fakeConn) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, nil, nil, c.err
+	}
+	children, stat, err := c.list(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ch := make(chan zk.Event, 1)
+	c.children[p] = append(c.children[p], ch)
+	return children, stat, ch, nil
+}
+
+func (c *fakeConn) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return false, nil, nil, c.err
+	}
+	ch := make(chan zk.Event, 1)
+	c.exists[p] = append(c.exists[p], ch)
+	if _, ok := c.nodes[p]; ok {
+		return true, &zk.Stat{}, ch, nil
+	}
+	return false, nil, ch, nil
+}
+
+func next(t *testing.T, w registry.Watcher) []*registry.ServiceInstance {
+	t.Helper()
+	type result struct {
+		items []*registry.ServiceInstance
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		items, err := w.Next()
+		ch <- result{items, err}
+	}()
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			t.Fatal(res.err)
+		}
+		return res.items
+	case <-time.After(time.Second):
+		t.Fatal("no update of the watcher")
+	}
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	r := newRegistry(conn, Namespace("/kratos/services"))
+	svc := &registry.ServiceInstance{
+		ID:        "1",
+		Name:      "helloworld",
+		Version:   "v1",
+		Endpoints: []string{"grpc://127.0.0.1:9000"},
+	}
+
+	items, err := r.GetService(ctx, svc.Name)
+	if err != nil || len(items) != 0 {
+		t.Fatalf("got %v %v", items, err)
+	}
+	w, err := r.Watch(ctx, svc.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if items := next(t, w); len(items) != 0 {
+		t.Fatalf("got %v want no instances", items)
+	}
+
+	if err = r.Register(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := conn.node("/kratos/services/helloworld/1"); !ok {
+		t.Fatal("the instance is not registered")
+	}
+	items = next(t, w)
+	if len(items) != 1 || items[0].ID != "1" || items[0].Endpoints[0] != "grpc://127.0.0.1:9000" {
+		t.Fatalf("got instances %+v", items)
+	}
+	if err = r.Register(ctx, svc); err != nil {
+		t.Fatalf("register again: %v", err)
+	}
+	items = next(t, w)
+	if len(items) != 1 {
+		t.Fatalf("got instances %+v", items)
+	}
+
+	if err = r.Deregister(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	if items = next(t, w); len(items) != 0 {
+		t.Fatalf("got %v want no instances", items)
+	}
+	if err = r.Deregister(ctx, svc); err != nil {
+		t.Fatalf("deregister again: %v", err)
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	r := newRegistry(newFakeConn())
+	w, err := r.Watch(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Next(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	w.Close()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("want an error after closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the watcher is not closed")
+	}
+}
+
+func TestRegistrySessionExpired(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	r := newRegistry(conn)
+	r.opts.retry = 10 * time.Millisecond
+	svc := &registry.ServiceInstance{ID: "1", Name: "helloworld"}
+	if err := r.Register(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	exists := func() bool {
+		_, ok := conn.node("/microservices/helloworld/1")
+		return ok
+	}
+	eventually := func(want bool) {
+		t.Helper()
+		for i := 0; i < 100 && exists() != want; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if exists() != want {
+			t.Fatalf("got the znode %t want %t", !want, want)
+		}
+	}
+
+	// the znode is created again in the new session.
+	conn.expire()
+	time.Sleep(50 * time.Millisecond)
+	if exists() {
+		t.Fatal("got the znode without a session")
+	}
+	conn.reconnect()
+	eventually(true)
+
+	// and it is not once deregistered.
+	if err := r.Deregister(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	conn.expire()
+	conn.reconnect()
+	time.Sleep(50 * time.Millisecond)
+	eventually(false)
+}
+
+func TestWatcherRetry(t *testing.T) {
+	conn := newFakeConn()
+	r := newRegistry(conn)
+	r.opts.retry = 50 * time.Millisecond
+	w, err := r.Watch(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err = w.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.expire()
+	if _, err = w.Next(); err == nil {
+		t.Fatal("want the error of the expired session")
+	}
+	// the failed watch is retried after the delay rather than at once.
+	start := time.Now()
+	if _, err = w.Next(); err == nil {
+		t.Fatal("want the error of the disconnected session")
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("got the retry after %s want the delay", d)
+	}
+	conn.reconnect()
+	if items := next(t, w); len(items) != 0 {
+		t.Errorf("got %v want no instances", items)
+	}
+}
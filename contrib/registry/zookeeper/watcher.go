@@ -0,0 +1,93 @@
+package zookeeper
+
+import (
+	"context"
+	"errors"
+	"path"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/go-zookeeper/zk"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	r      *Registry
+	name   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	event  <-chan zk.Event
+	// retry fires once the failed watch is retried.
+	retry <-chan time.Time
+	first bool
+}
+
+func newWatcher(ctx context.Context, r *Registry, name string) *watcher {
+	w := &watcher{
+		r:     r,
+		name:  name,
+		first: true,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	if w.first {
+		w.first = false
+		return w.getInstance()
+	}
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.event:
+	case <-w.retry:
+	}
+	return w.getInstance()
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	return nil
+}
+
+// getInstance lists the instances and watches the children of the service, or the
+// service znode itself if it is not created yet. The watch is retried after a delay
+// if it fails, so that the next call does not spin on the error.
+func (w *watcher) getInstance() ([]*registry.ServiceInstance, error) {
+	w.event, w.retry = nil, nil
+	if err := w.ctx.Err(); err != nil {
+		return nil, err
+	}
+	items, err := w.watch()
+	if err != nil {
+		w.event, w.retry = nil, time.After(w.r.opts.retry)
+	}
+	return items, err
+}
+
+func (w *watcher) watch() ([]*registry.ServiceInstance, error) {
+	p := path.Join(w.r.opts.namespace, w.name)
+	for {
+		children, _, event, err := w.r.conn.ChildrenW(p)
+		if errors.Is(err, zk.ErrNoNode) {
+			ok, _, event, err := w.r.conn.ExistsW(p)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				// created after listed.
+				continue
+			}
+			w.event = event
+			return []*registry.ServiceInstance{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		w.event = event
+		return w.r.instances(w.name, children)
+	}
+}
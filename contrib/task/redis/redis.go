@@ -0,0 +1,252 @@
+// Package redis provides the task queue backed by Redis, the tasks are stored by their ids
+// and moved between the pending list and the scheduled, active and dead sorted sets by
+// the scripts atomically. The keys of a queue share the hash tag of the prefix, so the
+// scripts, which also access the task keys by their ids, run on a single Redis Cluster slot.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/task"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var _ task.Queue = (*Queue)(nil)
+
+// enqueue stores the task unless the id exists, and pushes it to the pending list or the
+// scheduled set by the time.
+// KEYS[1]: task, KEYS[2]: pending, KEYS[3]: scheduled, ARGV: id, task, at and now in milliseconds.
+var enqueue = redis.NewScript(`
+if redis.call("SETNX", KEYS[1], ARGV[2]) == 0 then
+	return 0
+end
+if tonumber(ARGV[3]) <= tonumber(ARGV[4]) then
+	redis.call("RPUSH", KEYS[2], ARGV[1])
+else
+	redis.call("ZADD", KEYS[3], ARGV[3], ARGV[1])
+end
+return 1
+`)
+
+// dequeue moves the due scheduled tasks and the expired leases to the pending list, and
+// leases the first pending task until the deadline.
+// KEYS[1]: pending, KEYS[2]: scheduled, KEYS[3]: active, ARGV: now and deadline in milliseconds, task key prefix.
+var dequeue = redis.NewScript(`
+for _, key in ipairs({KEYS[2], KEYS[3]}) do
+	local ids = redis.call("ZRANGEBYSCORE", key, "-inf", ARGV[1], "LIMIT", 0, 100)
+	for _, id in ipairs(ids) do
+		redis.call("ZREM", key, id)
+		redis.call("RPUSH", KEYS[1], id)
+	end
+end
+while true do
+	local id = redis.call("LPOP", KEYS[1])
+	if not id then
+		return false
+	end
+	local t = redis.call("GET", ARGV[3] .. id)
+	if t then
+		redis.call("ZADD", KEYS[3], ARGV[2], id)
+		return t
+	end
+end
+`)
+
+// ack removes the task from the active set and deletes it.
+// KEYS[1]: active, KEYS[2]: task, ARGV: id.
+var ack = redis.NewScript(`
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("DEL", KEYS[2])
+return 1
+`)
+
+// retry moves the task from the active set to the scheduled set at the time.
+// KEYS[1]: active, KEYS[2]: scheduled, KEYS[3]: task, ARGV: id, task, at in milliseconds.
+var retry = redis.NewScript(`
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("SET", KEYS[3], ARGV[2])
+redis.call("ZADD", KEYS[2], ARGV[3], ARGV[1])
+return 1
+`)
+
+// kill moves the task from the active set to the dead set, and deletes the dead tasks
+// older than the retention.
+// KEYS[1]: active, KEYS[2]: dead, KEYS[3]: task, ARGV: id, task, now and expiry in milliseconds, task key prefix.
+var kill = redis.NewScript(`
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("SET", KEYS[3], ARGV[2])
+redis.call("ZADD", KEYS[2], ARGV[3], ARGV[1])
+local ids = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[4], "LIMIT", 0, 100)
+for _, id in ipairs(ids) do
+	redis.call("ZREM", KEYS[2], id)
+	redis.call("DEL", ARGV[5] .. id)
+end
+return 1
+`)
+
+// Option is redis queue option.
+type Option func(o *options)
+
+type options struct {
+	prefix    string
+	lease     time.Duration
+	poll      time.Duration
+	retention time.Duration
+}
+
+// Prefix with the prefix of the keys, "kratos:task" by default, the queues of different
+// prefixes are independent. The prefix is the hash tag of the keys, i.e. {kratos:task}:pending.
+func Prefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// Lease with the lease of the dequeued tasks, 30 minutes by default, the tasks neither
+// acked, retried nor killed within the lease are processed again, i.e. the worker crashed.
+func Lease(d time.Duration) Option {
+	return func(o *options) {
+		o.lease = d
+	}
+}
+
+// PollInterval with the interval of polling the queue if it is empty, a second by default.
+func PollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.poll = d
+	}
+}
+
+// Retention with the retention of the dead tasks, 7 days by default.
+func Retention(d time.Duration) Option {
+	return func(o *options) {
+		o.retention = d
+	}
+}
+
+// Queue is a redis task queue.
+type Queue struct {
+	client redis.UniversalClient
+	opts   *options
+	now    func() time.Time
+}
+
+// New returns a task queue of the client.
+func New(client redis.UniversalClient, opts ...Option) *Queue {
+	o := &options{
+		prefix:    "kratos:task",
+		lease:     30 * time.Minute,
+		poll:      time.Second,
+		retention: 7 * 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Queue{
+		client: client,
+		opts:   o,
+		now:    time.Now,
+	}
+}
+
+func (q *Queue) key(name string) string {
+	return "{" + q.opts.prefix + "}:" + name
+}
+
+func (q *Queue) taskKey(id string) string {
+	return q.key("t:") + id
+}
+
+func ms(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+// Endpoint returns the endpoint of the client, i.e. redis://127.0.0.1:6379.
+func (q *Queue) Endpoint() string {
+	if c, ok := q.client.(*redis.Client); ok {
+		return "redis://" + c.Options().Addr
+	}
+	return "redis://"
+}
+
+// Enqueue stores the task, task.ErrDuplicateTask is returned if the id exists.
+func (q *Queue) Enqueue(ctx context.Context, t *task.Task, at time.Time) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if at.IsZero() {
+		at = q.now()
+	}
+	keys := []string{q.taskKey(t.ID), q.key("pending"), q.key("scheduled")}
+	n, err := enqueue.Run(ctx, q.client, keys, t.ID, data, ms(at), ms(q.now())).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return task.ErrDuplicateTask
+	}
+	return nil
+}
+
+// Dequeue polls the queue until a task is due or ctx is done.
+func (q *Queue) Dequeue(ctx context.Context) (*task.Task, error) {
+	keys := []string{q.key("pending"), q.key("scheduled"), q.key("active")}
+	for {
+		now := q.now()
+		data, err := dequeue.Run(ctx, q.client, keys, ms(now), ms(now.Add(q.opts.lease)), q.taskKey("")).Text()
+		if err == nil {
+			t := &task.Task{}
+			if err := json.Unmarshal([]byte(data), t); err != nil {
+				return nil, err
+			}
+			return t, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		timer := time.NewTimer(q.opts.poll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Ack deletes the processed task.
+func (q *Queue) Ack(ctx context.Context, t *task.Task) error {
+	return ack.Run(ctx, q.client, []string{q.key("active"), q.taskKey(t.ID)}, t.ID).Err()
+}
+
+// Retry schedules the failed task at the time.
+func (q *Queue) Retry(ctx context.Context, t *task.Task, at time.Time) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	keys := []string{q.key("active"), q.key("scheduled"), q.taskKey(t.ID)}
+	return retry.Run(ctx, q.client, keys, t.ID, data, ms(at)).Err()
+}
+
+// Kill moves the failed task to the dead tasks, which are kept for the retention.
+func (q *Queue) Kill(ctx context.Context, t *task.Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	now := q.now()
+	keys := []string{q.key("active"), q.key("dead"), q.taskKey(t.ID)}
+	return kill.Run(ctx, q.client, keys, t.ID, data, ms(now), ms(now.Add(-q.opts.retention)), q.taskKey("")).Err()
+}
+
+// Close does nothing, the client is closed by the caller.
+func (q *Queue) Close() error {
+	return nil
+}
@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/task"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time { return c.t }
+
+func newQueue(t *testing.T, opts ...Option) (*Queue, *clock, *miniredis.Miniredis) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	q := New(redis.NewClient(&redis.Options{Addr: s.Addr()}), append([]Option{PollInterval(time.Millisecond)}, opts...)...)
+	c := &clock{t: time.Unix(1000, 0)}
+	q.now = c.now
+	return q, c, s
+}
+
+func poll(t *testing.T, q *Queue) *task.Task {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	got, err := q.Dequeue(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	q, c, s := newQueue(t)
+	ctx := context.Background()
+	if got := q.Endpoint(); got != "redis://"+s.Addr() {
+		t.Errorf("unexpected endpoint %s", got)
+	}
+	if err := q.Enqueue(ctx, &task.Task{ID: "1", Type: "email", Payload: []byte("a")}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(ctx, &task.Task{ID: "1", Type: "email"}, time.Time{}); err != task.ErrDuplicateTask {
+		t.Errorf("expected the duplicate error, got %v", err)
+	}
+	if err := q.Enqueue(ctx, &task.Task{ID: "2", Type: "email"}, c.t.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	got := poll(t, q)
+	if got == nil || got.ID != "1" || string(got.Payload) != "a" {
+		t.Fatalf("expected the task 1, got %+v", got)
+	}
+	if got := poll(t, q); got != nil {
+		t.Fatalf("expected the delayed task to be scheduled, got %+v", got)
+	}
+	c.t = c.t.Add(time.Minute)
+	if got = poll(t, q); got == nil || got.ID != "2" {
+		t.Fatalf("expected the task 2, got %+v", got)
+	}
+	if err := q.Ack(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if s.Exists(q.taskKey("1")) == false || s.Exists(q.taskKey("2")) {
+		t.Error("expected the acked task to be deleted")
+	}
+	// the keys share the hash tag, so the scripts run on a single cluster slot.
+	for _, key := range s.Keys() {
+		if !strings.HasPrefix(key, "{kratos:task}:") {
+			t.Errorf("expected the hash tag of the key %s", key)
+		}
+	}
+}
+
+func TestLease(t *testing.T) {
+	q, c, _ := newQueue(t, Lease(time.Minute))
+	ctx := context.Background()
+	if err := q.Enqueue(ctx, &task.Task{ID: "1"}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := poll(t, q); got == nil {
+		t.Fatal("expected the task")
+	}
+	if got := poll(t, q); got != nil {
+		t.Fatalf("expected the task to be leased, got %+v", got)
+	}
+	// the task is processed again once the lease expires.
+	c.t = c.t.Add(time.Minute)
+	if got := poll(t, q); got == nil || got.ID != "1" {
+		t.Fatalf("expected the expired task, got %+v", got)
+	}
+}
+
+func TestRetryKill(t *testing.T) {
+	q, c, s := newQueue(t, Retention(time.Hour))
+	ctx := context.Background()
+	for _, id := range []string{"1", "2"} {
+		if err := q.Enqueue(ctx, &task.Task{ID: id, MaxRetry: 1}, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := poll(t, q)
+	got.Retried, got.LastError = 1, "fail"
+	if err := q.Retry(ctx, got, c.t.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	dead := poll(t, q)
+	if err := q.Kill(ctx, dead); err != nil {
+		t.Fatal(err)
+	}
+	c.t = c.t.Add(time.Second)
+	if got := poll(t, q); got == nil || got.ID != "1" || got.Retried != 1 || got.LastError != "fail" {
+		t.Fatalf("expected the retried task, got %+v", got)
+	}
+	if got := poll(t, q); got != nil {
+		t.Fatalf("expected the killed task not to be processed, got %+v", got)
+	}
+	if members, _ := s.ZMembers(q.key("dead")); len(members) != 1 || members[0] != "2" {
+		t.Errorf("unexpected dead tasks %v", members)
+	}
+	// the dead tasks are deleted after the retention.
+	c.t = c.t.Add(2 * time.Hour)
+	if err := q.Kill(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if members, _ := s.ZMembers(q.key("dead")); len(members) != 1 || members[0] != "1" || s.Exists(q.taskKey("2")) {
+		t.Errorf("expected the expired dead task to be deleted, got %v", members)
+	}
+}
+
+type email struct {
+	To string `json:"to"`
+}
+
+func TestServer(t *testing.T) {
+	q, _, _ := newQueue(t)
+	q.now = time.Now
+	srv := NewServer(q)
+	got := make(chan string, 1)
+	srv.Handle("email:send", func() interface{} { return new(email) }, func(ctx context.Context, req interface{}) error {
+		got <- req.(*email).To
+		return nil
+	})
+	go func() {
+		_ = srv.Start()
+	}()
+	if _, err := task.NewClient(q).Enqueue(context.Background(), "email:send", &email{To: "a@b.c"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case to := <-got:
+		if to != "a@b.c" {
+			t.Errorf("unexpected task %s", to)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the task")
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+}
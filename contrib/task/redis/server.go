@@ -0,0 +1,16 @@
+package redis
+
+import (
+	"github.com/go-kratos/kratos/v2/task"
+)
+
+// NewServer creates a worker server of the redis queue run by the app, i.e.
+//
+//	q := redis.New(client, redis.Lease(10*time.Minute))
+//	srv := redis.NewServer(q, task.Concurrency(8), task.Middleware(middleware.Chain(recovery.Recovery(), tracing.Server())))
+//	srv.Handle("email:send", func() interface{} { return new(pb.SendEmail) }, handler)
+//
+// The tasks in process are settled before the server is stopped.
+func NewServer(q *Queue, opts ...task.ServerOption) *task.Server {
+	return task.NewServer(q, opts...)
+}
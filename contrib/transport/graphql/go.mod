@@ -0,0 +1,12 @@
+module github.com/go-kratos/kratos/contrib/transport/graphql/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/99designs/gqlgen v0.17.45
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	github.com/gorilla/websocket v1.5.0
+	github.com/vektah/gqlparser/v2 v2.5.11
+)
@@ -0,0 +1,296 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	gqlgen "github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	gqltransport "github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const loggerName = "transport/graphql"
+
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
+
+// ServerOption is GraphQL server option.
+type ServerOption func(*Server)
+
+// Path with the path of the GraphQL endpoint, /query by default.
+func Path(path string) ServerOption {
+	return func(s *Server) {
+		s.path = path
+	}
+}
+
+// HTTPOptions with the options of the HTTP server which serves the GraphQL
+// endpoint, i.e. the address and the tls config.
+func HTTPOptions(opts ...khttp.ServerOption) ServerOption {
+	return func(s *Server) {
+		s.httpOpts = append(s.httpOpts, opts...)
+	}
+}
+
+// Extension with the gqlgen extensions of the server, i.e. the introspection
+// or the complexity limit.
+func Extension(exts ...gqlgen.HandlerExtension) ServerOption {
+	return func(s *Server) {
+		s.exts = append(s.exts, exts...)
+	}
+}
+
+// KeepAlive with the interval of the keep-alive messages of the subscriptions
+// over WebSocket, 10s by default.
+func KeepAlive(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.keepAlive = d
+	}
+}
+
+// CheckOrigin with the origin check of the WebSocket upgrade requests, the
+// origin of the same host is allowed by default.
+func CheckOrigin(fn func(req *http.Request) bool) ServerOption {
+	return func(s *Server) {
+		s.checkOrigin = fn
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Middleware with server middleware option, which is applied to the fields of
+// the user-specified resolvers.
+func Middleware(m middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = m
+	}
+}
+
+// Server is a GraphQL server wrapper of gqlgen, which serves the queries and
+// mutations over HTTP and the subscriptions over WebSocket.
+type Server struct {
+	*khttp.Server
+	schema      gqlgen.ExecutableSchema
+	handler     *handler.Server
+	path        string
+	httpOpts    []khttp.ServerOption
+	exts        []gqlgen.HandlerExtension
+	keepAlive   time.Duration
+	checkOrigin func(req *http.Request) bool
+	middleware  middleware.Middleware
+	endpoint    string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	log         *log.Helper
+}
+
+// NewServer creates a GraphQL server of the executable schema generated by gqlgen.
+func NewServer(es gqlgen.ExecutableSchema, opts ...ServerOption) *Server {
+	srv := &Server{
+		schema:     es,
+		path:       "/query",
+		keepAlive:  10 * time.Second,
+		middleware: middleware.Named("recovery", recovery.Recovery()),
+		log:        log.NewHelper(loggerName, log.GetLogger()),
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	srv.ctx, srv.cancel = context.WithCancel(context.Background())
+	srv.handler = handler.New(es)
+	srv.handler.AddTransport(gqltransport.Websocket{
+		Upgrader:              websocket.Upgrader{CheckOrigin: srv.checkOrigin},
+		KeepAlivePingInterval: srv.keepAlive,
+		InitFunc:              initHeader,
+	})
+	srv.handler.AddTransport(gqltransport.Options{})
+	srv.handler.AddTransport(gqltransport.GET{})
+	srv.handler.AddTransport(gqltransport.POST{})
+	srv.handler.AddTransport(gqltransport.MultipartForm{})
+	srv.handler.SetQueryCache(lru.New(1000))
+	srv.handler.SetErrorPresenter(presentError)
+	srv.handler.AroundFields(srv.resolve)
+	for _, ext := range srv.exts {
+		srv.handler.Use(ext)
+	}
+	srv.Server = khttp.NewServer(srv.httpOpts...)
+	srv.Server.Handle(srv.path, http.HandlerFunc(srv.serveGraphQL))
+	return srv
+}
+
+type requestKey struct{}
+
+// request is the HTTP request of the operations, shared by their fields.
+type request struct {
+	req    *http.Request
+	header headerCarrier
+	mu     sync.Mutex
+	reply  http.Header
+}
+
+// serveGraphQL carries the request to the resolvers, the subscriptions over WebSocket
+// outlive the timeout of the HTTP server, so they end when the server stops instead.
+func (s *Server) serveGraphQL(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if websocket.IsWebSocketUpgrade(req) {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(s.ctx)
+		defer cancel()
+	}
+	ctx = context.WithValue(ctx, requestKey{}, &request{
+		req:    req,
+		header: headerCarrier(req.Header.Clone()),
+		reply:  res.Header(),
+	})
+	s.handler.ServeHTTP(res, req.WithContext(ctx))
+}
+
+// initHeader merges the string values of the connection init payload into the request
+// header, since the browsers can not set the header of the WebSocket, i.e. Authorization.
+func initHeader(ctx context.Context, payload gqltransport.InitPayload) (context.Context, *gqltransport.InitPayload, error) {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		for k, v := range payload {
+			if s, ok := v.(string); ok {
+				r.header.Set(k, s)
+			}
+		}
+	}
+	return ctx, &payload, nil
+}
+
+// resolve runs the field of a user-specified resolver through the middleware, the fields
+// are resolved concurrently, so each of them has its own header.
+func (s *Server) resolve(ctx context.Context, next gqlgen.Resolver) (interface{}, error) {
+	fc := gqlgen.GetFieldContext(ctx)
+	r, ok := ctx.Value(requestKey{}).(*request)
+	if fc == nil || !fc.IsResolver || !ok {
+		return next(ctx)
+	}
+	reply := headerCarrier{}
+	ctx = transport.NewServerContext(ctx, &Transport{
+		endpoint:    s.endpoint,
+		operation:   fc.Object + "." + fc.Field.Name,
+		request:     r.req,
+		reqHeader:   headerCarrier(http.Header(r.header).Clone()),
+		replyHeader: reply,
+	})
+	h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return next(ctx)
+	}
+	if s.middleware != nil {
+		h = s.middleware(h)
+	}
+	res, err := h(ctx, fc.Args)
+	r.mu.Lock()
+	for k, v := range reply {
+		r.reply[k] = v
+	}
+	r.mu.Unlock()
+	return res, err
+}
+
+// presentError presents the kratos errors with their code, reason and metadata in
+// the extensions of the GraphQL errors.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gerr := gqlgen.DefaultErrorPresenter(ctx, err)
+	se, ok := errors.FromError(err)
+	if !ok {
+		return gerr
+	}
+	gerr.Message = se.Message
+	if gerr.Extensions == nil {
+		gerr.Extensions = make(map[string]interface{})
+	}
+	gerr.Extensions["code"] = se.Code
+	gerr.Extensions["reason"] = se.Reason
+	if len(se.Metadata) > 0 {
+		gerr.Extensions["metadata"] = se.Metadata
+	}
+	return gerr
+}
+
+// Endpoint return a real address to registry endpoint.
+// examples:
+//
+//	http://127.0.0.1:8000
+func (s *Server) Endpoint() (string, error) {
+	endpoint, err := s.Server.Endpoint()
+	if err != nil {
+		return "", err
+	}
+	s.endpoint = endpoint
+	return endpoint, nil
+}
+
+// Start start the GraphQL server.
+func (s *Server) Start() error {
+	endpoint, err := s.Endpoint()
+	if err != nil {
+		return err
+	}
+	s.log.Infof("[GraphQL] server serving on: %s%s", endpoint, s.path)
+	return s.Server.Start()
+}
+
+// Stop stop the GraphQL server, the subscriptions are ended.
+func (s *Server) Stop() error {
+	s.log.Info("[GraphQL] server stopping")
+	s.cancel()
+	return s.Server.Stop()
+}
+
+// Routes returns the fields of the operation types and their middleware, along with
+// the routes of the HTTP server.
+func (s *Server) Routes() []transport.Route {
+	var routes []transport.Route
+	schema := s.schema.Schema()
+	for _, def := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+		if def == nil {
+			continue
+		}
+		for _, f := range def.Fields {
+			if strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+			operation := def.Name + "." + f.Name
+			ctx := transport.NewServerContext(context.Background(), &Transport{
+				endpoint:    s.endpoint,
+				operation:   operation,
+				reqHeader:   headerCarrier{},
+				replyHeader: headerCarrier{},
+			})
+			ms := middleware.Inspect(ctx, s.middleware)
+			routes = append(routes, transport.Route{
+				Kind:       transport.KindGraphQL,
+				Operation:  operation,
+				Middleware: ms,
+			})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Operation < routes[j].Operation
+	})
+	return append(routes, s.Server.Routes()...)
+}
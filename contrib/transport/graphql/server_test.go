@@ -0,0 +1,286 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	gqlgen "github.com/99designs/gqlgen/graphql"
+	"github.com/gorilla/websocket"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type resolver func(ctx context.Context, operation string, args map[string]interface{}) (interface{}, error)
+
+// newSchema simulates the executable schema generated by gqlgen, which resolves the
+// fields of the operation by the resolver.
+func newSchema(r resolver) gqlgen.ExecutableSchema {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query {
+			hello(name: String!): String!
+			fail: String
+		}
+		type Subscription {
+			tick: Int!
+		}
+	`})
+	resolve := func(ctx context.Context, object string, f gqlgen.CollectedField) (interface{}, error) {
+		oc := gqlgen.GetOperationContext(ctx)
+		fc := &gqlgen.FieldContext{
+			Object:     object,
+			Field:      f,
+			Args:       f.ArgumentMap(oc.Variables),
+			IsResolver: true,
+		}
+		ctx = gqlgen.WithFieldContext(ctx, fc)
+		res, err := oc.ResolverMiddleware(ctx, func(ctx context.Context) (interface{}, error) {
+			return r(ctx, object+"."+f.Name, fc.Args)
+		})
+		if err != nil {
+			gqlgen.AddError(ctx, err)
+		}
+		return res, err
+	}
+	return &gqlgen.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema {
+			return schema
+		},
+		ComplexityFunc: func(typeName string, fieldName string, childComplexity int, args map[string]interface{}) (int, bool) {
+			return 0, false
+		},
+		ExecFunc: func(ctx context.Context) gqlgen.ResponseHandler {
+			oc := gqlgen.GetOperationContext(ctx)
+			fields := gqlgen.CollectFields(oc, oc.Operation.SelectionSet, nil)
+			if oc.Operation.Operation == ast.Subscription {
+				var ticks <-chan int
+				return func(ctx context.Context) *gqlgen.Response {
+					if ticks == nil {
+						res, err := resolve(ctx, "Subscription", fields[0])
+						if err != nil {
+							return &gqlgen.Response{Data: []byte(`null`)}
+						}
+						ticks = res.(<-chan int)
+					}
+					select {
+					case <-ctx.Done():
+						return nil
+					case v, ok := <-ticks:
+						if !ok {
+							return nil
+						}
+						data, _ := json.Marshal(map[string]int{fields[0].Alias: v})
+						return &gqlgen.Response{Data: data}
+					}
+				}
+			}
+			ran := false
+			return func(ctx context.Context) *gqlgen.Response {
+				if ran {
+					return nil
+				}
+				ran = true
+				data := make(map[string]interface{})
+				var mu sync.Mutex
+				var wg sync.WaitGroup
+				for _, f := range fields {
+					wg.Add(1)
+					go func(f gqlgen.CollectedField) {
+						defer wg.Done()
+						res, _ := resolve(ctx, "Query", f)
+						mu.Lock()
+						data[f.Alias] = res
+						mu.Unlock()
+					}(f)
+				}
+				wg.Wait()
+				b, _ := json.Marshal(data)
+				return &gqlgen.Response{Data: b}
+			}
+		},
+	}
+}
+
+func newServer(t *testing.T, r resolver, opts ...ServerOption) (*Server, string) {
+	opts = append([]ServerOption{HTTPOptions(khttp.Address("127.0.0.1:0"))}, opts...)
+	srv := NewServer(newSchema(r), opts...)
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() { _ = srv.Stop() })
+	return srv, endpoint
+}
+
+func TestQuery(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		operations []string
+	)
+	m := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, _ := transport.FromServerContext(ctx)
+			if tr.Kind() != transport.KindGraphQL {
+				t.Errorf("got %s want graphql", tr.Kind())
+			}
+			if token := tr.RequestHeader().Get("x-token"); token != "token" {
+				t.Errorf("got %q want the token", token)
+			}
+			tr.ReplyHeader().Set("x-operation", tr.Operation())
+			mu.Lock()
+			operations = append(operations, tr.Operation())
+			mu.Unlock()
+			return next(ctx, req)
+		}
+	}
+	notFound := errors.NotFound("USER_NOT_FOUND", "user not found")
+	_, endpoint := newServer(t, func(ctx context.Context, operation string, args map[string]interface{}) (interface{}, error) {
+		if operation == "Query.fail" {
+			return nil, notFound
+		}
+		return "hello " + args["name"].(string), nil
+	}, Middleware(m))
+
+	body := `{"query": "query($name: String!) { hello(name: $name) fail }", "variables": {"name": "kratos"}}`
+	req, err := http.NewRequest(http.MethodPost, endpoint+"/query", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-token", "token")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var reply struct {
+		Data   map[string]interface{}
+		Errors []struct {
+			Message    string
+			Path       []string
+			Extensions map[string]interface{}
+		}
+	}
+	if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Data["hello"] != "hello kratos" {
+		t.Errorf("got %v want hello kratos", reply.Data["hello"])
+	}
+	if len(reply.Errors) != 1 {
+		t.Fatalf("got %+v want the error of fail", reply.Errors)
+	}
+	e := reply.Errors[0]
+	if e.Message != "user not found" || e.Extensions["code"] != float64(errors.Code(notFound)) || e.Extensions["reason"] != "USER_NOT_FOUND" {
+		t.Errorf("got %+v want the kratos error", e)
+	}
+	if got := res.Header.Values("x-operation"); len(got) != 1 {
+		t.Errorf("got %v want the reply header", got)
+	}
+	sort.Strings(operations)
+	if want := []string{"Query.fail", "Query.hello"}; !reflect.DeepEqual(operations, want) {
+		t.Errorf("got %v want %v", operations, want)
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	srv, endpoint := newServer(t, func(ctx context.Context, operation string, args map[string]interface{}) (interface{}, error) {
+		tr, _ := transport.FromServerContext(ctx)
+		if token := tr.RequestHeader().Get("x-token"); token != "token" {
+			t.Errorf("got %q want the token of the init payload", token)
+		}
+		ticks := make(chan int)
+		go func() {
+			defer close(ticks)
+			for i := 1; ; i++ {
+				select {
+				case ticks <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return (<-chan int)(ticks), nil
+	})
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(endpoint, "http")+"/query", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	send := func(msg string) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func() map[string]interface{} {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		var msg map[string]interface{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	send(`{"type": "connection_init", "payload": {"x-token": "token"}}`)
+	if msg := read(); msg["type"] != "connection_ack" {
+		t.Fatalf("got %v want connection_ack", msg)
+	}
+	send(`{"id": "1", "type": "start", "payload": {"query": "subscription { tick }"}}`)
+	for want := 1; want <= 2; {
+		msg := read()
+		if msg["type"] == "ka" {
+			continue
+		}
+		payload, _ := msg["payload"].(map[string]interface{})
+		data, _ := payload["data"].(map[string]interface{})
+		if data["tick"] != float64(want) {
+			t.Fatalf("got %v want tick %d", msg, want)
+		}
+		want++
+	}
+
+	// the subscription ends when the server stops.
+	go func() { _ = srv.Stop() }()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
+				t.Fatal("got the connection open want it closed")
+			}
+			break
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	srv := NewServer(newSchema(nil))
+	var got []string
+	for _, r := range srv.Routes() {
+		if r.Kind == transport.KindGraphQL {
+			got = append(got, r.Operation)
+			if !reflect.DeepEqual(r.Middleware, []string{"recovery"}) {
+				t.Errorf("got %v want the recovery", r.Middleware)
+			}
+		}
+	}
+	if want := []string{"Query.fail", "Query.hello", "Subscription.tick"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
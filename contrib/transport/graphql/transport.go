@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is a GraphQL transport, the operation is the resolved field of
+// its type, i.e. Query.user.
+type Transport struct {
+	endpoint    string
+	operation   string
+	request     *http.Request
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindGraphQL
+}
+
+// Endpoint returns the transport endpoint.
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+// Operation returns the resolved field, i.e. Query.user.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// Request returns the HTTP request of the operation, which is the upgrade
+// request of the subscriptions over WebSocket.
+func (tr *Transport) Request() *http.Request {
+	return tr.request
+}
+
+// RequestHeader returns the request header, the payload of the connection
+// init message is merged into the header of the subscriptions over WebSocket.
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+// ReplyHeader returns the reply header, which is not sent by the subscriptions
+// over WebSocket.
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+type headerCarrier http.Header
+
+// Get returns the value associated with the passed key.
+func (hc headerCarrier) Get(key string) string {
+	return http.Header(hc).Get(key)
+}
+
+// Set stores the key-value pair.
+func (hc headerCarrier) Set(key string, value string) {
+	http.Header(hc).Set(key, value)
+}
+
+// Keys lists the keys stored in this carrier.
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,183 @@
+package http3
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
+
+// ErrTLSRequired is returned by Endpoint and Start when the HTTP server has no tls config,
+// HTTP/3 is always served over TLS.
+var ErrTLSRequired = errors.New("http3: the HTTP server requires a tls config")
+
+// Option is HTTP/3 server option.
+type Option func(o *options)
+
+type options struct {
+	address string
+	port    int
+	quic    *quic.Config
+	logger  log.Logger
+}
+
+// Address with the UDP address of the HTTP/3 server, such as :8000, the same
+// port of the TCP listener on all the addresses by default.
+func Address(addr string) Option {
+	return func(o *options) {
+		o.address = addr
+	}
+}
+
+// Port with the port advertised by the Alt-Svc header, which overrides the
+// listened one when the reachable port differs, i.e. a mapped port.
+func Port(port int) Option {
+	return func(o *options) {
+		o.port = port
+	}
+}
+
+// QUICConfig with the QUIC config of the HTTP/3 server.
+func QUICConfig(c *quic.Config) Option {
+	return func(o *options) {
+		o.quic = c
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Server serves a HTTP server over HTTP/3 alongside its TCP listener, the
+// responses on TCP advertise the HTTP/3 endpoint by the Alt-Svc header.
+type Server struct {
+	*khttp.Server
+	opts options
+	h3   *http3.Server
+	conn net.PacketConn
+	log  *log.Helper
+}
+
+// NewServer creates a HTTP/3 server of the HTTP server, which is started and
+// stopped by the HTTP/3 server, so only the HTTP/3 server is registered to the app.
+func NewServer(srv *khttp.Server, opts ...Option) *Server {
+	o := options{
+		logger: log.GetLogger(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &Server{
+		Server: srv,
+		opts:   o,
+		h3: &http3.Server{
+			Handler:    srv,
+			Port:       o.port,
+			QUICConfig: o.quic,
+		},
+		log: log.NewHelper("transport/http3", o.logger),
+	}
+	next := srv.Server.Handler
+	srv.Server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// no header is set until the UDP listener is served.
+		_ = s.h3.SetQUICHeaders(w.Header())
+		next.ServeHTTP(w, r)
+	})
+	return s
+}
+
+// Endpoint return a real address to registry endpoint, the HTTPS endpoint of
+// the TCP listener, the clients of HTTP/3 discover the UDP port by Alt-Svc.
+// examples:
+//
+//	https://127.0.0.1:8000
+func (s *Server) Endpoint() (string, error) {
+	endpoint, err := s.Server.Endpoint()
+	if err != nil {
+		return "", err
+	}
+	if err := s.listen(endpoint); err != nil {
+		return "", err
+	}
+	return endpoint, nil
+}
+
+// listen creates the UDP listener once, on the port of the endpoint by default.
+func (s *Server) listen(endpoint string) error {
+	if s.conn != nil {
+		return nil
+	}
+	if s.Server.TLSConfig == nil {
+		return ErrTLSRequired
+	}
+	addr := s.opts.address
+	if addr == "" {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return err
+		}
+		addr = net.JoinHostPort("", u.Port())
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	s.h3.TLSConfig = http3.ConfigureTLSConfig(s.Server.TLSConfig)
+	s.conn = conn
+	return nil
+}
+
+// Start start the HTTP server and the HTTP/3 server.
+func (s *Server) Start() error {
+	if _, err := s.Endpoint(); err != nil {
+		return err
+	}
+	s.log.Infof("[HTTP3] server listening on: %s", s.conn.LocalAddr().String())
+	done := make(chan error, 1)
+	go func() {
+		err := s.h3.Serve(s.conn)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			// stops the HTTP server so that Start returns the error.
+			_ = s.Server.Stop()
+		}
+		done <- err
+	}()
+	if err := s.Server.Start(); err != nil {
+		_ = s.h3.Close()
+		<-done
+		return err
+	}
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop stop the HTTP/3 server and the HTTP server.
+func (s *Server) Stop() error {
+	s.log.Info("[HTTP3] server stopping")
+	err := s.h3.Shutdown(context.Background())
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	if serr := s.Server.Stop(); err == nil {
+		err = serr
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package http3
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func newTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func get(t *testing.T, c *http.Client, url string) *http.Response {
+	res, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func TestServer(t *testing.T) {
+	hs := khttp.NewServer(khttp.Address("127.0.0.1:0"), khttp.TLSConfig(newTLSConfig(t)))
+	hs.HandleFunc("/proto", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	})
+	srv := NewServer(hs)
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(endpoint, "https://127.0.0.1:") {
+		t.Fatalf("got %s want the https endpoint", endpoint)
+	}
+	go func() {
+		if err := srv.Start(); err != nil {
+			t.Error(err)
+		}
+	}()
+	defer srv.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	tlsConf := &tls.Config{InsecureSkipVerify: true}
+	res := get(t, &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConf}}, endpoint+"/proto")
+	port := srv.conn.LocalAddr().(*net.UDPAddr).Port
+	if want := fmt.Sprintf(`h3=":%d"`, port); !strings.Contains(res.Header.Get("Alt-Svc"), want) {
+		t.Errorf("got Alt-Svc %q want %s", res.Header.Get("Alt-Svc"), want)
+	}
+
+	rt := &http3.RoundTripper{TLSClientConfig: tlsConf}
+	defer rt.Close()
+	res = get(t, &http.Client{Transport: rt}, endpoint+"/proto")
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "HTTP/3.0" {
+		t.Errorf("got %s want HTTP/3.0", body)
+	}
+}
+
+func TestServerWithoutTLS(t *testing.T) {
+	srv := NewServer(khttp.NewServer(khttp.Address("127.0.0.1:0")))
+	if _, err := srv.Endpoint(); err != ErrTLSRequired {
+		t.Errorf("got %v want %v", err, ErrTLSRequired)
+	}
+}
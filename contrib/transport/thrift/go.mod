@@ -0,0 +1,10 @@
+module github.com/go-kratos/kratos/contrib/transport/thrift/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/apache/thrift v0.15.0
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,401 @@
+package thrift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+const loggerName = "transport/thrift"
+
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
+
+// ServerOption is thrift server option.
+type ServerOption func(*Server)
+
+// Network with server network.
+func Network(network string) ServerOption {
+	return func(s *Server) {
+		s.network = network
+	}
+}
+
+// Address with server address, such as :9090.
+func Address(addr string) ServerOption {
+	return func(s *Server) {
+		s.address = addr
+	}
+}
+
+// Endpoint with the registered endpoint of the server, which overrides the extracted
+// one when the reachable address differs from the listened one, such as 203.0.113.10:9090.
+func Endpoint(endpoint string) ServerOption {
+	return func(s *Server) {
+		s.advertised = endpoint
+	}
+}
+
+// Timeout with the timeout of each call, 1s by default.
+func Timeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// Protocol with the protocol factory of the server, the THeader protocol by default,
+// which carries the headers and also serves the binary and compact protocol clients.
+func Protocol(f thrift.TProtocolFactory) ServerOption {
+	return func(s *Server) {
+		s.protocol = f
+	}
+}
+
+// TransportFactory with the transport factory of the server, i.e. the framed
+// or the buffered transport of the clients without the THeader protocol.
+func TransportFactory(f thrift.TTransportFactory) ServerOption {
+	return func(s *Server) {
+		s.transport = f
+	}
+}
+
+// Configuration with the thrift configuration of the connections.
+func Configuration(c *thrift.TConfiguration) ServerOption {
+	return func(s *Server) {
+		s.conf = c
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Middleware with server middleware option, the request of the middleware is nil,
+// since the arguments are decoded by the processed function.
+func Middleware(m middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = m
+	}
+}
+
+// Server is a thrift server wrapper, which processes the functions of a thrift
+// processor through the middleware.
+type Server struct {
+	processor  thrift.TProcessor
+	server     *thrift.TSimpleServer
+	trans      *serverTransport
+	lis        net.Listener
+	network    string
+	address    string
+	advertised string
+	endpoint   string
+	timeout    time.Duration
+	protocol   thrift.TProtocolFactory
+	transport  thrift.TTransportFactory
+	conf       *thrift.TConfiguration
+	middleware middleware.Middleware
+	log        *log.Helper
+}
+
+// NewServer creates a thrift server of the processor generated by the thrift compiler,
+// i.e. NewCalculatorProcessor(handler), or a multiplexed processor.
+func NewServer(processor thrift.TProcessor, opts ...ServerOption) *Server {
+	srv := &Server{
+		network:    "tcp",
+		address:    ":0",
+		timeout:    time.Second,
+		middleware: middleware.Named("recovery", recovery.Recovery()),
+		log:        log.NewHelper(loggerName, log.GetLogger()),
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	if srv.conf == nil {
+		srv.conf = &thrift.TConfiguration{}
+	}
+	if srv.protocol == nil {
+		srv.protocol = thrift.NewTHeaderProtocolFactoryConf(srv.conf)
+	}
+	if srv.transport == nil {
+		srv.transport = thrift.NewTTransportFactory()
+	}
+	srv.processor = thrift.WrapProcessor(processor, srv.process)
+	return srv
+}
+
+// process processes the function through the middleware, the call rejected by the
+// middleware before the function is replied with its error.
+func (s *Server) process(name string, next thrift.TProcessorFunction) thrift.TProcessorFunction {
+	return thrift.WrappedTProcessorFunction{
+		Wrapped: func(ctx context.Context, seqID int32, in, out thrift.TProtocol) (bool, thrift.TException) {
+			var cancel context.CancelFunc
+			if s.timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, s.timeout)
+			} else {
+				ctx, cancel = context.WithCancel(ctx)
+			}
+			defer cancel()
+			helper, _ := thrift.GetResponseHelper(ctx)
+			ctx = transport.NewServerContext(ctx, &Transport{
+				endpoint:    s.endpoint,
+				operation:   name,
+				seqID:       seqID,
+				reqHeader:   readHeader(ctx),
+				replyHeader: &replyCarrier{headerCarrier: headerCarrier{}, helper: helper},
+			})
+			var (
+				processed bool
+				ok        bool
+			)
+			h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				processed = true
+				var err thrift.TException
+				ok, err = next.Process(ctx, seqID, in, out)
+				if err != nil {
+					return nil, err
+				}
+				return nil, nil
+			}
+			if s.middleware != nil {
+				h = s.middleware(h)
+			}
+			_, err := h(ctx, nil)
+			if err == nil {
+				return ok, nil
+			}
+			if processed {
+				return ok, thrift.WrapTException(err)
+			}
+			return s.reject(ctx, name, seqID, in, out, err)
+		},
+	}
+}
+
+// reject skips the arguments and replies the application exception of the error,
+// the message of a kratos error is replied.
+func (s *Server) reject(ctx context.Context, name string, seqID int32, in, out thrift.TProtocol, err error) (bool, thrift.TException) {
+	if serr := in.Skip(ctx, thrift.STRUCT); serr != nil {
+		return false, thrift.WrapTException(serr)
+	}
+	if serr := in.ReadMessageEnd(ctx); serr != nil {
+		return false, thrift.WrapTException(serr)
+	}
+	message := err.Error()
+	if se, ok := kerrors.FromError(err); ok {
+		message = se.Message
+	}
+	x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, message)
+	if werr := out.WriteMessageBegin(ctx, name, thrift.EXCEPTION, seqID); werr != nil {
+		return false, thrift.WrapTException(werr)
+	}
+	if werr := x.Write(ctx, out); werr != nil {
+		return false, thrift.WrapTException(werr)
+	}
+	if werr := out.WriteMessageEnd(ctx); werr != nil {
+		return false, thrift.WrapTException(werr)
+	}
+	if werr := out.Flush(ctx); werr != nil {
+		return false, thrift.WrapTException(werr)
+	}
+	return true, thrift.WrapTException(err)
+}
+
+// Endpoint return a real address to registry endpoint.
+// examples:
+//
+//	thrift://127.0.0.1:9090
+func (s *Server) Endpoint() (string, error) {
+	if err := s.listen(); err != nil {
+		return "", err
+	}
+	if s.endpoint != "" {
+		return s.endpoint, nil
+	}
+	addr := s.advertised
+	if addr == "" {
+		var err error
+		if addr, err = extract(s.lis.Addr()); err != nil {
+			return "", err
+		}
+	}
+	s.endpoint = fmt.Sprintf("thrift://%s", addr)
+	return s.endpoint, nil
+}
+
+// listen creates the listener once, so that Endpoint reports the real port before Start.
+func (s *Server) listen() error {
+	if s.lis != nil {
+		return nil
+	}
+	lis, err := net.Listen(s.network, s.address)
+	if err != nil {
+		return err
+	}
+	s.lis = lis
+	s.trans = &serverTransport{lis: lis, conf: s.conf, conns: make(map[*conn]struct{})}
+	s.server = thrift.NewTSimpleServer4(s.processor, s.trans, s.transport, s.protocol)
+	s.server.SetLogger(func(msg string) {
+		s.log.Debug(msg)
+	})
+	return nil
+}
+
+// extract returns the address of the listener, the IP of the first interface
+// when it listens on all the addresses.
+func extract(addr net.Addr) (string, error) {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+		return addr.String(), nil
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return net.JoinHostPort(ipnet.IP.String(), port), nil
+		}
+	}
+	return net.JoinHostPort("127.0.0.1", port), nil
+}
+
+// Start start the thrift server.
+func (s *Server) Start() error {
+	if _, err := s.Endpoint(); err != nil {
+		return err
+	}
+	s.log.Infof("[Thrift] server listening on: %s", s.lis.Addr().String())
+	return s.server.Serve()
+}
+
+// Stop stop the thrift server, the idle connections are closed and the running
+// calls are waited.
+func (s *Server) Stop() error {
+	s.log.Info("[Thrift] server stopping")
+	if s.server == nil {
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.server.Stop()
+	}()
+	s.trans.shutdown()
+	return <-done
+}
+
+// Routes returns the functions of the processor and their middleware.
+func (s *Server) Routes() []transport.Route {
+	routes := make([]transport.Route, 0, len(s.processor.ProcessorMap()))
+	for name := range s.processor.ProcessorMap() {
+		ctx := transport.NewServerContext(context.Background(), &Transport{
+			endpoint:    s.endpoint,
+			operation:   name,
+			reqHeader:   headerCarrier{},
+			replyHeader: &replyCarrier{headerCarrier: headerCarrier{}},
+		})
+		ms := middleware.Inspect(ctx, s.middleware)
+		routes = append(routes, transport.Route{
+			Kind:       transport.KindThrift,
+			Operation:  name,
+			Middleware: ms,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Operation < routes[j].Operation
+	})
+	return routes
+}
+
+var errServerClosed = errors.New("thrift: server closed")
+
+// serverTransport accepts the connections of the listener, and tracks them to
+// close their reading side when the server stops.
+type serverTransport struct {
+	lis    net.Listener
+	conf   *thrift.TConfiguration
+	mu     sync.Mutex
+	conns  map[*conn]struct{}
+	closed bool
+}
+
+func (t *serverTransport) Listen() error {
+	return nil
+}
+
+func (t *serverTransport) Accept() (thrift.TTransport, error) {
+	c, err := t.lis.Accept()
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		c.Close()
+		return nil, errServerClosed
+	}
+	tc := &conn{Conn: c, trans: t}
+	t.conns[tc] = struct{}{}
+	return thrift.NewTSocketFromConnConf(tc, t.conf), nil
+}
+
+func (t *serverTransport) Close() error {
+	return t.lis.Close()
+}
+
+func (t *serverTransport) Interrupt() error {
+	return t.lis.Close()
+}
+
+// shutdown closes the reading side of the connections, so that the idle ones end
+// at once and the running calls write their replies.
+func (t *serverTransport) shutdown() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	for c := range t.conns {
+		c.closeRead()
+	}
+}
+
+type conn struct {
+	net.Conn
+	trans *serverTransport
+	once  sync.Once
+}
+
+func (c *conn) closeRead() {
+	if cr, ok := c.Conn.(interface{ CloseRead() error }); ok {
+		_ = cr.CloseRead()
+		return
+	}
+	_ = c.Conn.SetReadDeadline(time.Now())
+}
+
+func (c *conn) Close() error {
+	c.once.Do(func() {
+		c.trans.mu.Lock()
+		delete(c.trans.conns, c)
+		c.trans.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,222 @@
+package thrift
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// message is a struct of a string field, which simulates the generated arguments
+// and results.
+type message struct {
+	id    int16
+	value string
+}
+
+func (m *message) Write(ctx context.Context, p thrift.TProtocol) error {
+	if err := p.WriteStructBegin(ctx, "message"); err != nil {
+		return err
+	}
+	if err := p.WriteFieldBegin(ctx, "value", thrift.STRING, m.id); err != nil {
+		return err
+	}
+	if err := p.WriteString(ctx, m.value); err != nil {
+		return err
+	}
+	if err := p.WriteFieldEnd(ctx); err != nil {
+		return err
+	}
+	if err := p.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	return p.WriteStructEnd(ctx)
+}
+
+func (m *message) Read(ctx context.Context, p thrift.TProtocol) error {
+	if _, err := p.ReadStructBegin(ctx); err != nil {
+		return err
+	}
+	for {
+		_, typ, id, err := p.ReadFieldBegin(ctx)
+		if err != nil {
+			return err
+		}
+		if typ == thrift.STOP {
+			break
+		}
+		if id == m.id && typ == thrift.STRING {
+			if m.value, err = p.ReadString(ctx); err != nil {
+				return err
+			}
+		} else if err := p.Skip(ctx, typ); err != nil {
+			return err
+		}
+		if err := p.ReadFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+	return p.ReadStructEnd(ctx)
+}
+
+// echo simulates a generated processor function.
+type echo func(ctx context.Context, msg string) string
+
+func (f echo) Process(ctx context.Context, seqID int32, in, out thrift.TProtocol) (bool, thrift.TException) {
+	args := &message{id: 1}
+	if err := args.Read(ctx, in); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	if err := in.ReadMessageEnd(ctx); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	if err := out.WriteMessageBegin(ctx, "echo", thrift.REPLY, seqID); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	if err := (&message{id: 0, value: f(ctx, args.value)}).Write(ctx, out); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	if err := out.WriteMessageEnd(ctx); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	return true, thrift.WrapTException(out.Flush(ctx))
+}
+
+// processor simulates a generated processor.
+type processor struct {
+	functions map[string]thrift.TProcessorFunction
+}
+
+func (p *processor) ProcessorMap() map[string]thrift.TProcessorFunction {
+	return p.functions
+}
+
+func (p *processor) AddToProcessorMap(name string, f thrift.TProcessorFunction) {
+	p.functions[name] = f
+}
+
+func (p *processor) Process(ctx context.Context, in, out thrift.TProtocol) (bool, thrift.TException) {
+	name, _, seqID, err := in.ReadMessageBegin(ctx)
+	if err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	f, ok := p.functions[name]
+	if !ok {
+		return false, thrift.NewTApplicationException(thrift.UNKNOWN_METHOD, name)
+	}
+	return f.Process(ctx, seqID, in, out)
+}
+
+func newServer(t *testing.T, opts ...ServerOption) (*Server, string) {
+	p := &processor{functions: map[string]thrift.TProcessorFunction{
+		"echo": echo(func(ctx context.Context, msg string) string {
+			return msg
+		}),
+	}}
+	srv := NewServer(p, append([]ServerOption{Address("127.0.0.1:0")}, opts...)...)
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() { _ = srv.Stop() })
+	return srv, endpoint
+}
+
+func newClient(t *testing.T, endpoint string) (*thrift.TStandardClient, *thrift.THeaderProtocol) {
+	sock := thrift.NewTSocketConf(strings.TrimPrefix(endpoint, "thrift://"), nil)
+	if err := sock.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sock.Close() })
+	proto := thrift.NewTHeaderProtocolConf(sock, &thrift.TConfiguration{})
+	return thrift.NewTStandardClient(proto, proto), proto
+}
+
+func call(ctx context.Context, c *thrift.TStandardClient, msg string) (string, error) {
+	res := &message{id: 0}
+	_, err := c.Call(ctx, "echo", &message{id: 1, value: msg}, res)
+	return res.value, err
+}
+
+func TestServer(t *testing.T) {
+	m := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, _ := transport.FromServerContext(ctx)
+			if tr.Kind() != transport.KindThrift || tr.Operation() != "echo" {
+				t.Errorf("got %s %s want the thrift echo", tr.Kind(), tr.Operation())
+			}
+			if tr.RequestHeader().Get("x-token") != "token" {
+				return nil, kerrors.Unauthorized("UNAUTHORIZED", "no token")
+			}
+			tr.ReplyHeader().Set("x-reply", "reply")
+			return next(ctx, req)
+		}
+	}
+	_, endpoint := newServer(t, Middleware(m))
+	client, proto := newClient(t, endpoint)
+
+	ctx := thrift.SetHeader(context.Background(), "x-token", "token")
+	ctx = thrift.SetWriteHeaderList(ctx, []string{"x-token"})
+	reply, err := call(ctx, client, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "hello" {
+		t.Errorf("got %s want hello", reply)
+	}
+	if got := proto.GetReadHeaders()["x-reply"]; got != "reply" {
+		t.Errorf("got %q want the reply header", got)
+	}
+
+	// the rejected call is replied, and the connection serves the next call.
+	_, err = call(context.Background(), client, "hello")
+	var x thrift.TApplicationException
+	if !errors.As(err, &x) || x.Error() != "no token" {
+		t.Errorf("got %v want the rejection", err)
+	}
+	if reply, err := call(ctx, client, "again"); err != nil || reply != "again" {
+		t.Errorf("got %s %v want again", reply, err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	srv, endpoint := newServer(t)
+	client, _ := newClient(t, endpoint)
+	if _, err := call(context.Background(), client, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	// the idle connection does not block the stop.
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Stop()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("got the server stopping want it stopped")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	srv := NewServer(&processor{functions: map[string]thrift.TProcessorFunction{"echo": echo(nil)}})
+	want := []transport.Route{{Kind: transport.KindThrift, Operation: "echo", Middleware: []string{"recovery"}}}
+	if got := srv.Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package thrift
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is a thrift transport, the headers are carried by the THeader protocol.
+type Transport struct {
+	endpoint    string
+	operation   string
+	seqID       int32
+	reqHeader   headerCarrier
+	replyHeader *replyCarrier
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindThrift
+}
+
+// Endpoint returns the transport endpoint.
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+// Operation returns the name of the processed function, which is prefixed by
+// the service name of the multiplexed processors, i.e. Calculator:add.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// SeqID returns the sequence id of the call.
+func (tr *Transport) SeqID() int32 {
+	return tr.seqID
+}
+
+// RequestHeader returns the request header.
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+// ReplyHeader returns the reply header, which must be set before the function
+// writes the reply.
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+type headerCarrier map[string]string
+
+// readHeader returns the headers read by the THeader protocol.
+func readHeader(ctx context.Context) headerCarrier {
+	hc := headerCarrier{}
+	for _, k := range thrift.GetReadHeaderList(ctx) {
+		if v, ok := thrift.GetHeader(ctx, k); ok {
+			hc[k] = v
+		}
+	}
+	return hc
+}
+
+// Get returns the value associated with the passed key.
+func (hc headerCarrier) Get(key string) string {
+	return hc[key]
+}
+
+// Set stores the key-value pair.
+func (hc headerCarrier) Set(key string, value string) {
+	hc[key] = value
+}
+
+// Keys lists the keys stored in this carrier.
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// replyCarrier writes the reply header through to the THeader protocol.
+type replyCarrier struct {
+	headerCarrier
+	helper thrift.TResponseHelper
+}
+
+// Set stores the key-value pair, and sets the header of the reply.
+func (rc *replyCarrier) Set(key string, value string) {
+	rc.headerCarrier[key] = value
+	rc.helper.SetHeader(key, value)
+}
@@ -0,0 +1,20 @@
+package xds
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	xdscreds "google.golang.org/grpc/credentials/xds"
+)
+
+// ClientCredentials returns the dial option of the xDS credentials, which use the security
+// configured by the control plane, and the fallback credentials otherwise, insecure when nil.
+// The option replaces the TLS credentials of grpc.Dial, which conflicts with DialInsecure.
+func ClientCredentials(fallback credentials.TransportCredentials) grpc.DialOption {
+	if fallback == nil {
+		fallback = insecure.NewCredentials()
+	}
+	// the credentials fail only without the fallback ones.
+	creds, _ := xdscreds.NewClientCredentials(xdscreds.ClientOptions{FallbackCreds: fallback})
+	return grpc.WithTransportCredentials(creds)
+}
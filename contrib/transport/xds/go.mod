@@ -0,0 +1,10 @@
+module github.com/go-kratos/kratos/contrib/transport/xds/v2
+
+go 1.16
+
+replace github.com/go-kratos/kratos/v2 => ../../../
+
+require (
+	github.com/go-kratos/kratos/v2 v2.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.38.0
+)
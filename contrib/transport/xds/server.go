@@ -0,0 +1,149 @@
+// Package xds provides the proxyless gRPC service mesh of the xDS control planes, such
+// as Istio or Traffic Director. The server serves the services by the xDS server, which
+// configures the listener and the credentials by the control plane, and the clients dial
+// the xds:/// targets by the xDS resolver registered by the package:
+//
+//	srv := xds.NewServer(xds.ServerOptions(grpc.Address(":9000"), grpc.Middleware(m)))
+//	helloworld.RegisterGreeterServer(srv, greeter)
+//
+//	conn, err := grpc.Dial(ctx,
+//		grpc.WithEndpoint("xds:///helloworld"),
+//		grpc.WithOptions(xds.ClientCredentials(nil)),
+//	)
+//
+// The bootstrap file of the control plane is read from GRPC_XDS_BOOTSTRAP.
+package xds
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	kgrpc "github.com/go-kratos/kratos/v2/transport/grpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	xdscreds "google.golang.org/grpc/credentials/xds"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/xds"
+)
+
+const (
+	loggerName = "transport/xds"
+
+	reflectionService = "grpc.reflection.v1alpha.ServerReflection"
+)
+
+var (
+	_ transport.Server      = (*Server)(nil)
+	_ transport.Inspector   = (*Server)(nil)
+	_ grpc.ServiceRegistrar = (*Server)(nil)
+)
+
+// ServerOption is xDS server option.
+type ServerOption func(*Server)
+
+// ServerOptions with the options of the gRPC server, i.e. the address, the middleware and
+// the timeouts, the connection options and the TLS config are not applied to the xDS
+// server, which are configured by Options and FallbackCredentials instead.
+func ServerOptions(opts ...kgrpc.ServerOption) ServerOption {
+	return func(s *Server) {
+		s.serverOpts = append(s.serverOpts, opts...)
+	}
+}
+
+// FallbackCredentials with the credentials used when the control plane configures no
+// security, insecure by default.
+func FallbackCredentials(c credentials.TransportCredentials) ServerOption {
+	return func(s *Server) {
+		s.fallback = c
+	}
+}
+
+// Options with the options of the xDS server, i.e. xds.ServingModeCallback.
+func Options(opts ...grpc.ServerOption) ServerOption {
+	return func(s *Server) {
+		s.grpcOpts = append(s.grpcOpts, opts...)
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Server is a gRPC server wrapper serving the registered services by the xDS server,
+// the wrapped gRPC server provides the middleware, the health and the routes.
+type Server struct {
+	*kgrpc.Server
+	xds        *xds.GRPCServer
+	serverOpts []kgrpc.ServerOption
+	grpcOpts   []grpc.ServerOption
+	fallback   credentials.TransportCredentials
+	log        *log.Helper
+}
+
+// NewServer creates a xDS server by options.
+func NewServer(opts ...ServerOption) *Server {
+	srv := &Server{
+		log: log.NewHelper(loggerName, log.GetLogger()),
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	if srv.fallback == nil {
+		srv.fallback = insecure.NewCredentials()
+	}
+	srv.Server = kgrpc.NewServer(srv.serverOpts...)
+	// the credentials fail only without the fallback ones.
+	creds, _ := xdscreds.NewServerCredentials(xdscreds.ServerOptions{FallbackCreds: srv.fallback})
+	grpcOpts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(srv.Server.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(srv.Server.StreamInterceptor()),
+	}
+	srv.xds = xds.NewGRPCServer(append(grpcOpts, srv.grpcOpts...)...)
+	healthpb.RegisterHealthServer(srv.xds, srv.Server.HealthServer())
+	if _, ok := srv.Server.GetServiceInfo()[reflectionService]; ok {
+		reflection.Register(registrar{srv})
+	}
+	return srv
+}
+
+// RegisterService registers the service on the xDS server, and also on the wrapped
+// server for the routes.
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	s.Server.RegisterService(desc, impl)
+	s.xds.RegisterService(desc, impl)
+}
+
+// Start start the xDS server, which serves once the control plane configures the listener.
+func (s *Server) Start() error {
+	if _, err := s.Endpoint(); err != nil {
+		return err
+	}
+	lis, err := s.Listener()
+	if err != nil {
+		return err
+	}
+	s.log.Infof("[xDS] server listening on: %s", lis.Addr().String())
+	return s.xds.Serve(lis)
+}
+
+// Stop stop the xDS server.
+func (s *Server) Stop() error {
+	s.xds.GracefulStop()
+	return s.Server.Stop()
+}
+
+// registrar registers the services on the xDS server, and lists them by the wrapped
+// server, which has the same services.
+type registrar struct {
+	*Server
+}
+
+func (r registrar) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	r.xds.RegisterService(desc, impl)
+}
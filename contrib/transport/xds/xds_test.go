@@ -0,0 +1,56 @@
+package xds
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	kgrpc "github.com/go-kratos/kratos/v2/transport/grpc"
+)
+
+// unsetBootstrap unsets the bootstrap envs, which are restored after the test.
+func unsetBootstrap(t *testing.T) {
+	for _, key := range []string{"GRPC_XDS_BOOTSTRAP", "GRPC_XDS_BOOTSTRAP_CONFIG"} {
+		if v, ok := os.LookupEnv(key); ok {
+			key := key
+			t.Cleanup(func() { os.Setenv(key, v) })
+		}
+		os.Unsetenv(key)
+	}
+}
+
+func TestServer(t *testing.T) {
+	unsetBootstrap(t)
+	srv := NewServer(ServerOptions(kgrpc.Address("127.0.0.1:0")))
+	var operations []string
+	for _, r := range srv.Routes() {
+		if r.Kind != transport.KindGRPC {
+			t.Errorf("got %s want grpc", r.Kind)
+		}
+		operations = append(operations, r.Operation)
+	}
+	if len(operations) == 0 || operations[0] != "/grpc.health.v1.Health/Check" {
+		t.Errorf("got %v want the health routes", operations)
+	}
+	// the server serves only with the bootstrap of the control plane.
+	if err := srv.Start(); err == nil {
+		t.Error("got started want the bootstrap error")
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	unsetBootstrap(t)
+	// the xds target is resolved by the xDS resolver rather than the passthrough one.
+	conn, err := kgrpc.Dial(context.Background(),
+		kgrpc.WithEndpoint("xds:///helloworld"),
+		kgrpc.WithOptions(ClientCredentials(nil)),
+	)
+	if err == nil {
+		conn.Close()
+		t.Fatal("got dialed want the bootstrap error")
+	}
+}
@@ -0,0 +1,244 @@
+// Package form defines the application/x-www-form-urlencoded codec.
+// Importing this package will register the codec.
+package form
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Name is the name registered for the form codec.
+const Name = "x-www-form-urlencoded"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with form urlencoded, the proto messages are
+// encoded by field paths and the structs are encoded by the form or json tags.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	var values url.Values
+	switch vv := v.(type) {
+	case proto.Message:
+		var err error
+		if values, err = EncodeValues(vv); err != nil {
+			return nil, err
+		}
+	case url.Values:
+		values = vv
+	case map[string]string:
+		values = make(url.Values, len(vv))
+		for k, s := range vv {
+			values.Set(k, s)
+		}
+	default:
+		values = make(url.Values)
+		rv := reflect.Indirect(reflect.ValueOf(v))
+		if rv.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("form: unsupported type %T", v)
+		}
+		if err := encodeStruct(rv, "", values); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(values.Encode()), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch vv := v.(type) {
+	case proto.Message:
+		return DecodeValues(vv, values)
+	case *url.Values:
+		*vv = values
+		return nil
+	case *map[string]string:
+		if *vv == nil {
+			*vv = make(map[string]string, len(values))
+		}
+		for k := range values {
+			(*vv)[k] = values.Get(k)
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: unmarshal requires a non-nil struct pointer, got %T", v)
+	}
+	for key, vs := range values {
+		if err := decodeField(rv.Elem(), strings.Split(key, "."), vs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+// fieldName returns the form name of the struct field, an empty name means the field is skipped.
+func fieldName(f reflect.StructField) (name string, omitempty bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+	tag, ok := f.Tag.Lookup("form")
+	if !ok {
+		tag = f.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	if parts[0] == "" {
+		return f.Name, omitempty
+	}
+	return parts[0], omitempty
+}
+
+func encodeStruct(rv reflect.Value, prefix string, values url.Values) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, omitempty := fieldName(rt.Field(i))
+		if name == "" {
+			continue
+		}
+		fv := rv.Field(i)
+		if omitempty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		key := prefix + name
+		switch fv.Kind() {
+		case reflect.Struct:
+			if err := encodeStruct(fv, key+".", values); err != nil {
+				return err
+			}
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < fv.Len(); j++ {
+				s, err := formatValue(fv.Index(j))
+				if err != nil {
+					return err
+				}
+				values.Add(key, s)
+			}
+		default:
+			s, err := formatValue(fv)
+			if err != nil {
+				return err
+			}
+			values.Set(key, s)
+		}
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	default:
+		return "", fmt.Errorf("form: unsupported kind %s", v.Kind())
+	}
+}
+
+func decodeField(rv reflect.Value, path []string, values []string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if name, _ := fieldName(rt.Field(i)); name != path[0] {
+			continue
+		}
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		if len(path) > 1 {
+			if fv.Kind() != reflect.Struct {
+				return fmt.Errorf("form: invalid path %q is not a struct", path[0])
+			}
+			return decodeField(fv, path[1:], values)
+		}
+		if fv.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+			for j, s := range values {
+				if err := parseValue(slice.Index(j), s); err != nil {
+					return err
+				}
+			}
+			fv.Set(slice)
+			return nil
+		}
+		return parseValue(fv, values[0])
+	}
+	// unknown fields are ignored
+	return nil
+}
+
+func parseValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("form: unsupported kind %s", v.Kind())
+	}
+	return nil
+}
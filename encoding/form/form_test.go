@@ -0,0 +1,99 @@
+package form
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type user struct {
+	Name    string   `json:"name"`
+	Age     int32    `json:"age,omitempty"`
+	Tags    []string `form:"tag"`
+	Address *address `json:"address,omitempty"`
+	Ignored string   `json:"-"`
+}
+
+type address struct {
+	City string `json:"city"`
+}
+
+func TestStruct(t *testing.T) {
+	codec := encoding.GetCodec(Name)
+	in := &user{Name: "kratos", Age: 1, Tags: []string{"a", "b"}, Address: &address{City: "sh"}, Ignored: "x"}
+	data, err := codec.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "address.city=sh&age=1&name=kratos&tag=a&tag=b"; got != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+	out := new(user)
+	if err := codec.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	in.Ignored = ""
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v want %+v", out, in)
+	}
+	if data, _ := codec.Marshal(user{Name: "kratos"}); string(data) != "name=kratos" {
+		t.Errorf("omitempty fields are encoded: %s", data)
+	}
+}
+
+func TestProto(t *testing.T) {
+	codec := encoding.GetCodec(Name)
+	tests := []struct {
+		in   proto.Message
+		out  proto.Message
+		want string
+	}{
+		{
+			in:   &errdetails.ResourceInfo{ResourceType: "user", ResourceName: "kratos"},
+			out:  new(errdetails.ResourceInfo),
+			want: "resourceName=kratos&resourceType=user",
+		},
+		{
+			in:   &errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+			out:  new(errdetails.RetryInfo),
+			want: "retryDelay=1.5s",
+		},
+		{
+			in:   &errdetails.DebugInfo{StackEntries: []string{"a", "b"}, Detail: "detail"},
+			out:  new(errdetails.DebugInfo),
+			want: "detail=detail&stackEntries=a&stackEntries=b",
+		},
+	}
+	for _, test := range tests {
+		data, err := codec.Marshal(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.want {
+			t.Errorf("got %s want %s", data, test.want)
+		}
+		if err := codec.Unmarshal(data, test.out); err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(test.in, test.out) {
+			t.Errorf("got %v want %v", test.out, test.in)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	codec := encoding.GetCodec(Name)
+	var m map[string]string
+	if err := codec.Unmarshal([]byte("a=1&b=2"), &m); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := codec.Marshal(m); string(data) != "a=1&b=2" {
+		t.Errorf("got %s", data)
+	}
+}
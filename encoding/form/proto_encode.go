@@ -0,0 +1,135 @@
+package form
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EncodeValues encodes the populated fields of the proto message into url values,
+// the keys are the field paths of json names separated by dots, the map fields are skipped.
+func EncodeValues(msg proto.Message) (url.Values, error) {
+	values := make(url.Values)
+	if err := encodeMessage(msg.ProtoReflect(), "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func encodeMessage(m protoreflect.Message, prefix string, values url.Values) (err error) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		key := prefix + fd.JSONName()
+		switch {
+		case fd.IsMap():
+			return true
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				var s string
+				if s, err = encodeField(fd, list.Get(i)); err != nil {
+					return false
+				}
+				values.Add(key, s)
+			}
+			return true
+		case fd.Message() != nil && !isWellKnown(fd.Message()):
+			err = encodeMessage(v.Message(), key+".", values)
+			return err == nil
+		}
+		var s string
+		if s, err = encodeField(fd, v); err != nil {
+			return false
+		}
+		values.Set(key, s)
+		return true
+	})
+	return
+}
+
+func encodeField(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(v.Bool()), nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return strconv.FormatInt(int64(v.Enum()), 10), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case protoreflect.StringKind:
+		return v.String(), nil
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return encodeWellKnown(v.Message())
+	default:
+		return "", fmt.Errorf("unsupported field kind: %v", fd.Kind())
+	}
+}
+
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp",
+		"google.protobuf.Duration",
+		"google.protobuf.FieldMask",
+		"google.protobuf.DoubleValue",
+		"google.protobuf.FloatValue",
+		"google.protobuf.Int64Value",
+		"google.protobuf.Int32Value",
+		"google.protobuf.UInt64Value",
+		"google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue",
+		"google.protobuf.StringValue",
+		"google.protobuf.BytesValue":
+		return true
+	}
+	return false
+}
+
+func encodeWellKnown(m protoreflect.Message) (string, error) {
+	md := m.Descriptor()
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		ts := &timestamppb.Timestamp{
+			Seconds: m.Get(md.Fields().ByName("seconds")).Int(),
+			Nanos:   int32(m.Get(md.Fields().ByName("nanos")).Int()),
+		}
+		return ts.AsTime().Format(time.RFC3339Nano), nil
+	case "google.protobuf.Duration":
+		d := &durationpb.Duration{
+			Seconds: m.Get(md.Fields().ByName("seconds")).Int(),
+			Nanos:   int32(m.Get(md.Fields().ByName("nanos")).Int()),
+		}
+		return d.AsDuration().String(), nil
+	case "google.protobuf.FieldMask":
+		list := m.Get(md.Fields().ByName("paths")).List()
+		paths := make([]string, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			paths = append(paths, list.Get(i).String())
+		}
+		return strings.Join(paths, ","), nil
+	default:
+		// the wrappers have a single value field
+		fd := md.Fields().ByName("value")
+		if fd == nil {
+			return "", fmt.Errorf("unsupported message type: %q", string(md.FullName()))
+		}
+		return encodeField(fd, m.Get(fd))
+	}
+}
@@ -3,11 +3,12 @@
 package proto
 
 import (
-	"google.golang.org/grpc/encoding"
+	"github.com/go-kratos/kratos/v2/encoding"
+
 	"google.golang.org/protobuf/proto"
 )
 
-// Name is the name registered for the proto compressor.
+// Name is the name registered for the proto codec.
 const Name = "proto"
 
 func init() {
@@ -0,0 +1,35 @@
+package toml
+
+import (
+	"bytes"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Name is the name registered for the toml codec.
+const Name = "toml"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with toml.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return toml.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return Name
+}
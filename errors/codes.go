@@ -1,7 +1,6 @@
 package errors
 
 import (
-	"errors"
 	"fmt"
 )
 
@@ -16,7 +15,7 @@ func Cancelled(reason, format string, a ...interface{}) error {
 }
 
 func IsCancelled(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 1
 	}
 	return false
@@ -33,7 +32,7 @@ func Unknown(reason, format string, a ...interface{}) error {
 }
 
 func IsUnknown(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 2
 	}
 	return false
@@ -50,7 +49,7 @@ func InvalidArgument(reason, format string, a ...interface{}) error {
 }
 
 func IsInvalidArgument(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 3
 	}
 	return false
@@ -67,7 +66,7 @@ func DeadlineExceeded(reason, format string, a ...interface{}) error {
 }
 
 func IsDeadlineExceeded(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 4
 	}
 	return false
@@ -84,7 +83,7 @@ func NotFound(reason, format string, a ...interface{}) error {
 }
 
 func IsNotFound(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 5
 	}
 	return false
@@ -101,7 +100,7 @@ func AlreadyExists(reason, format string, a ...interface{}) error {
 }
 
 func IsAlreadyExists(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 6
 	}
 	return false
@@ -118,7 +117,7 @@ func PermissionDenied(reason, format string, a ...interface{}) error {
 }
 
 func IsPermissionDenied(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 7
 	}
 	return false
@@ -136,7 +135,7 @@ func ResourceExhausted(reason, format string, a ...interface{}) error {
 }
 
 func IsResourceExhausted(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 8
 	}
 	return false
@@ -154,7 +153,7 @@ func FailedPrecondition(reason, format string, a ...interface{}) error {
 }
 
 func IsFailedPrecondition(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 9
 	}
 	return false
@@ -172,7 +171,7 @@ func Aborted(reason, format string, a ...interface{}) error {
 }
 
 func IsAborted(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 10
 	}
 	return false
@@ -190,7 +189,7 @@ func OutOfRange(reason, format string, a ...interface{}) error {
 }
 
 func IsOutOfRange(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 11
 	}
 	return false
@@ -207,7 +206,7 @@ func Unimplemented(reason, format string, a ...interface{}) error {
 }
 
 func IsUnimplemented(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 12
 	}
 	return false
@@ -227,7 +226,7 @@ func Internal(reason, format string, a ...interface{}) error {
 }
 
 func IsInternal(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 13
 	}
 	return false
@@ -244,7 +243,7 @@ func Unavailable(reason, format string, a ...interface{}) error {
 }
 
 func IsUnavailable(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 14
 	}
 	return false
@@ -261,7 +260,7 @@ func DataLoss(reason, format string, a ...interface{}) error {
 }
 
 func IsDataLoss(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 15
 	}
 	return false
@@ -278,7 +277,7 @@ func Unauthorized(reason, format string, a ...interface{}) error {
 }
 
 func IsUnauthorized(err error) bool {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code == 16
 	}
 	return false
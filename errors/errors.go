@@ -3,6 +3,12 @@ package errors
 import (
 	"errors"
 	"fmt"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -27,7 +33,32 @@ func (e *StatusError) Is(target error) bool {
 }
 
 func (e *StatusError) Error() string {
-	return fmt.Sprintf("error: code = %d reason = %s message = %s details = %+v", e.Code, e.Reason, e.Message, e.Details)
+	return fmt.Sprintf("error: code = %d reason = %s message = %s metadata = %v details = %+v", e.Code, e.Reason, e.Message, e.Metadata, e.Details)
+}
+
+// WithMetadata with an MD formed by the mapping of key, value.
+func (e *StatusError) WithMetadata(md map[string]string) *StatusError {
+	err := proto.Clone(e).(*StatusError)
+	err.Metadata = md
+	return err
+}
+
+// GRPCStatus returns the Status represented by se,
+// the reason and metadata are carried by an errdetails.ErrorInfo detail.
+func (e *StatusError) GRPCStatus() *status.Status {
+	gs, err := status.New(codes.Code(e.Code), e.Message).WithDetails(&errdetails.ErrorInfo{
+		Reason:   e.Reason,
+		Metadata: e.Metadata,
+	})
+	if err != nil {
+		return status.New(codes.Code(e.Code), e.Message)
+	}
+	if len(e.Details) == 0 {
+		return gs
+	}
+	pb := gs.Proto()
+	pb.Details = append(pb.Details, e.Details...)
+	return status.FromProto(pb)
 }
 
 // Error returns a Status representing c and msg.
@@ -49,7 +80,7 @@ func Code(err error) int32 {
 	if err == nil {
 		return 0 // ok
 	}
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Code
 	}
 	return 2 // unknown
@@ -58,16 +89,38 @@ func Code(err error) int32 {
 // Reason returns the status for a particular error.
 // It supports wrapped errors.
 func Reason(err error) string {
-	if se := new(StatusError); errors.As(err, &se) {
+	if se, ok := FromError(err); ok {
 		return se.Reason
 	}
 	return UnknownReason
 }
 
-// FromError returns status error.
+// FromError returns status error, it also converts a gRPC status error.
 func FromError(err error) (*StatusError, bool) {
+	if err == nil {
+		return nil, false
+	}
 	if se := new(StatusError); errors.As(err, &se) {
 		return se, true
 	}
-	return nil, false
+	gs, ok := status.FromError(err)
+	if !ok {
+		return nil, false
+	}
+	se := &StatusError{
+		Code:    int32(gs.Code()),
+		Message: gs.Message(),
+	}
+	for _, detail := range gs.Proto().Details {
+		info := new(errdetails.ErrorInfo)
+		if ptypes.Is(detail, info) && se.Reason == "" {
+			if err := ptypes.UnmarshalAny(detail, info); err == nil {
+				se.Reason = info.Reason
+				se.Metadata = info.Metadata
+				continue
+			}
+		}
+		se.Details = append(se.Details, detail)
+	}
+	return se, true
 }
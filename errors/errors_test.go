@@ -52,3 +52,21 @@ func TestErrorAs(t *testing.T) {
 		t.Errorf("error is not match: %v", err2)
 	}
 }
+
+func TestGRPCStatus(t *testing.T) {
+	err := NotFound("USER_NOT_FOUND", "user %d not found", 1).(*StatusError).WithMetadata(map[string]string{"id": "1"})
+	gs := err.GRPCStatus()
+	if int32(gs.Code()) != err.Code || gs.Message() != err.Message {
+		t.Fatalf("unexpected status: %v", gs)
+	}
+	se, ok := FromError(gs.Err())
+	if !ok {
+		t.Fatalf("expected status error: %v", gs.Err())
+	}
+	if se.Code != err.Code || se.Reason != err.Reason || se.Message != err.Message || se.Metadata["id"] != "1" {
+		t.Fatalf("unexpected status error: %v", se)
+	}
+	if !IsNotFound(gs.Err()) {
+		t.Fatalf("expected not found: %v", se)
+	}
+}
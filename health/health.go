@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Status is the serving status of a dependency.
+type Status int32
+
+const (
+	// StatusUnknown is the status of a dependency which is not checked yet.
+	StatusUnknown Status = iota
+	// StatusServing is the status of a healthy dependency.
+	StatusServing
+	// StatusNotServing is the status of an unhealthy dependency.
+	StatusNotServing
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusServing:
+		return "SERVING"
+	case StatusNotServing:
+		return "NOT_SERVING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Health is the health registry shared by the servers and the application,
+// user code sets the status of each dependency, and the service is ready
+// once all the dependencies are serving.
+type Health struct {
+	mu        sync.RWMutex
+	status    map[string]Status
+	shutdown  bool
+	changed   chan struct{}
+	observers []func()
+}
+
+// New returns a health registry without dependencies, which is ready.
+func New() *Health {
+	return &Health{
+		status:  make(map[string]Status),
+		changed: make(chan struct{}),
+	}
+}
+
+// Set sets the status of the dependency.
+func (h *Health) Set(name string, status Status) {
+	h.mu.Lock()
+	if s, ok := h.status[name]; ok && s == status {
+		h.mu.Unlock()
+		return
+	}
+	h.status[name] = status
+	h.notify()
+}
+
+// Get returns the status of the dependency.
+func (h *Health) Get(name string) Status {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status[name]
+}
+
+// Dependencies returns the names of the dependencies in order.
+func (h *Health) Dependencies() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	names := make([]string, 0, len(h.status))
+	for name := range h.status {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Status returns the overall status, it is serving if all the dependencies are
+// serving, and it is not serving after shutdown.
+func (h *Health) Status() Status {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.overall()
+}
+
+func (h *Health) overall() Status {
+	if h.shutdown {
+		return StatusNotServing
+	}
+	for _, s := range h.status {
+		if s != StatusServing {
+			return StatusNotServing
+		}
+	}
+	return StatusServing
+}
+
+// Wait blocks until the service is ready or the ctx is done.
+func (h *Health) Wait(ctx context.Context) error {
+	for {
+		h.mu.RLock()
+		status, changed := h.overall(), h.changed
+		h.mu.RUnlock()
+		if status == StatusServing {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Watch calls the fn whenever a status changes.
+func (h *Health) Watch(fn func()) {
+	h.mu.Lock()
+	h.observers = append(h.observers, fn)
+	h.mu.Unlock()
+}
+
+// Shutdown sets the service not serving, so that the load balancers and the
+// probes stop sending traffic before the servers stop.
+func (h *Health) Shutdown() {
+	h.mu.Lock()
+	if h.shutdown {
+		h.mu.Unlock()
+		return
+	}
+	h.shutdown = true
+	h.notify()
+}
+
+// Resume sets the service back to the status of the dependencies after shutdown.
+func (h *Health) Resume() {
+	h.mu.Lock()
+	if !h.shutdown {
+		h.mu.Unlock()
+		return
+	}
+	h.shutdown = false
+	h.notify()
+}
+
+// notify wakes up the waiters and calls the observers, it must be called with the lock held.
+func (h *Health) notify() {
+	close(h.changed)
+	h.changed = make(chan struct{})
+	observers := h.observers
+	h.mu.Unlock()
+	for _, fn := range observers {
+		fn()
+	}
+}
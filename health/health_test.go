@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	h := New()
+	if h.Status() != StatusServing {
+		t.Fatalf("expected serving without dependencies, got %s", h.Status())
+	}
+	var changes int
+	h.Watch(func() { changes++ })
+
+	h.Set("db", StatusUnknown)
+	h.Set("redis", StatusServing)
+	if h.Status() != StatusNotServing {
+		t.Errorf("expected not serving with an unknown dependency, got %s", h.Status())
+	}
+	h.Set("db", StatusServing)
+	if h.Status() != StatusServing || h.Get("db") != StatusServing {
+		t.Errorf("expected serving, got %s", h.Status())
+	}
+	if deps := h.Dependencies(); len(deps) != 2 || deps[0] != "db" || deps[1] != "redis" {
+		t.Errorf("unexpected dependencies: %v", deps)
+	}
+
+	h.Shutdown()
+	if h.Status() != StatusNotServing {
+		t.Errorf("expected not serving after shutdown, got %s", h.Status())
+	}
+	h.Resume()
+	if h.Status() != StatusServing {
+		t.Errorf("expected serving after resume, got %s", h.Status())
+	}
+	// db unknown, redis serving, db serving, shutdown and resume.
+	if changes != 5 {
+		t.Errorf("expected 5 changes, got %d", changes)
+	}
+}
+
+func TestWait(t *testing.T) {
+	h := New()
+	h.Set("db", StatusNotServing)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := h.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	time.AfterFunc(10*time.Millisecond, func() {
+		h.Set("db", StatusServing)
+	})
+	if err := h.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
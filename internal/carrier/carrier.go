@@ -0,0 +1,29 @@
+// Package carrier provides the header of the transports whose requests carry a string
+// map as their header, i.e. the broker messages, the cron jobs and the tasks.
+package carrier
+
+import "github.com/go-kratos/kratos/v2/transport"
+
+var _ transport.Header = Header(nil)
+
+// Header is a transport header of a string map.
+type Header map[string]string
+
+// Get returns the value associated with the passed key.
+func (h Header) Get(key string) string {
+	return h[key]
+}
+
+// Set stores the key-value pair.
+func (h Header) Set(key string, value string) {
+	h[key] = value
+}
+
+// Keys lists the keys stored in this carrier.
+func (h Header) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
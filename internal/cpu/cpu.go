@@ -0,0 +1,49 @@
+package cpu
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	interval = 500 * time.Millisecond
+	// decay is the weight of the previous usage in the moving average.
+	decay = 0.95
+)
+
+var (
+	once  sync.Once
+	usage int64
+)
+
+// Usage returns the moving average of the cpu usage in permille (0-1000).
+// The sampling starts on the first call, zero is returned on unsupported platforms.
+func Usage() int64 {
+	once.Do(func() {
+		go sample()
+	})
+	return atomic.LoadInt64(&usage)
+}
+
+func sample() {
+	prevTotal, prevIdle, err := readStat()
+	if err != nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		total, idle, err := readStat()
+		if err != nil {
+			continue
+		}
+		if total <= prevTotal {
+			continue
+		}
+		cur := 1000 - int64((idle-prevIdle)*1000/(total-prevTotal))
+		prevTotal, prevIdle = total, idle
+		prev := atomic.LoadInt64(&usage)
+		atomic.StoreInt64(&usage, int64(float64(prev)*decay+float64(cur)*(1-decay)))
+	}
+}
@@ -0,0 +1,44 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readStat reads the total and idle cpu ticks from /proc/stat.
+func readStat() (total, idle uint64, err error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		for i, field := range fields[1:] {
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, 0, err
+			}
+			total += v
+			// idle and iowait
+			if i == 3 || i == 4 {
+				idle += v
+			}
+		}
+		return total, idle, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, errors.New("cpu: no cpu line in /proc/stat")
+}
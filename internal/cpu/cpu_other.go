@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package cpu
+
+import "errors"
+
+func readStat() (total, idle uint64, err error) {
+	return 0, 0, errors.New("cpu: unsupported platform")
+}
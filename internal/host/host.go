@@ -30,9 +30,63 @@ func Port(lis net.Listener) (int, bool) {
 	return 0, false
 }
 
-// Extract returns a private addr and port.
-func Extract(hostport string, lis net.Listener) (string, error) {
+// Option is extract option.
+type Option func(o *options)
+
+type options struct {
+	interfaces []string
+	cidrs      []*net.IPNet
+	ipv6       bool
+	err        error
+}
+
+// WithInterface with the names of the preferred interfaces in order, such as eth0, the
+// addresses of the other interfaces are ignored.
+func WithInterface(names ...string) Option {
+	return func(o *options) { o.interfaces = names }
+}
+
+// WithCIDR with the preferred ranges of the addresses in order, such as 10.0.0.0/8, the
+// addresses out of the ranges are ignored. The private ranges are used by default.
+func WithCIDR(cidrs ...string) Option {
+	return func(o *options) {
+		o.cidrs = o.cidrs[:0]
+		for _, cidr := range cidrs {
+			_, n, err := net.ParseCIDR(cidr)
+			if err != nil {
+				o.err = err
+				return
+			}
+			o.cidrs = append(o.cidrs, n)
+		}
+	}
+}
+
+// WithIPv6 prefers the IPv6 addresses to the IPv4 ones.
+func WithIPv6(prefer bool) Option {
+	return func(o *options) { o.ipv6 = prefer }
+}
+
+// Extract returns a private addr and port, or the listener address as is
+// if the listener is not a TCP listener.
+func Extract(hostport string, lis net.Listener, opts ...Option) (string, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.err != nil {
+		return "", o.err
+	}
+	if lis != nil {
+		if _, ok := lis.Addr().(*net.TCPAddr); !ok {
+			return lis.Addr().String(), nil
+		}
+	}
 	addr, port, err := net.SplitHostPort(hostport)
+	if err != nil && lis != nil {
+		// the address of the listener which is not passed by a host and port, i.e. systemd://http.
+		addr, port, err = net.SplitHostPort(lis.Addr().String())
+	}
 	if err != nil {
 		return "", err
 	}
@@ -48,8 +102,48 @@ func Extract(hostport string, lis net.Listener) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("Failed to get net interfaces: %v", err)
 	}
+	ip := o.pick(ifaces, func(iface net.Interface) ([]net.Addr, error) {
+		return iface.Addrs()
+	})
+	if ip == nil {
+		return "", nil
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
+
+// rank is the preference of an address, the lower the better.
+type rank struct {
+	iface, cidr, family int
+}
+
+func (r rank) less(o rank) bool {
+	if r.iface != o.iface {
+		return r.iface < o.iface
+	}
+	if r.cidr != o.cidr {
+		return r.cidr < o.cidr
+	}
+	return r.family < o.family
+}
+
+// pick returns the most preferred address of the interfaces which are up, the first one
+// wins the ties.
+func (o *options) pick(ifaces []net.Interface, addrsOf func(net.Interface) ([]net.Addr, error)) net.IP {
+	var (
+		best     net.IP
+		bestRank rank
+	)
 	for _, iface := range ifaces {
-		addrs, err := iface.Addrs()
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		r := rank{}
+		if len(o.interfaces) > 0 {
+			if r.iface = indexOf(o.interfaces, iface.Name); r.iface < 0 {
+				continue
+			}
+		}
+		addrs, err := addrsOf(iface)
 		if err != nil {
 			continue
 		}
@@ -63,10 +157,44 @@ func Extract(hostport string, lis net.Listener) (string, error) {
 			default:
 				continue
 			}
-			if isPrivateIP(ip.String()) {
-				return net.JoinHostPort(ip.String(), port), nil
+			cidr, ok := o.match(ip)
+			if !ok {
+				continue
+			}
+			r.cidr = cidr
+			if r.family = 0; (ip.To4() == nil) != o.ipv6 {
+				r.family = 1
 			}
+			if best == nil || r.less(bestRank) {
+				best, bestRank = ip, r
+			}
+		}
+	}
+	return best
+}
+
+// match returns the index of the range of the address, the addresses of the named
+// interfaces match unless they are loopback or link local.
+func (o *options) match(ip net.IP) (int, bool) {
+	if len(o.cidrs) > 0 {
+		for i, n := range o.cidrs {
+			if n.Contains(ip) {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+	if len(o.interfaces) > 0 {
+		return 0, !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified()
+	}
+	return 0, isPrivateIP(ip.String())
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
 		}
 	}
-	return "", nil
+	return -1
 }
@@ -2,6 +2,8 @@ package host
 
 import (
 	"net"
+	"path/filepath"
+	"strconv"
 	"testing"
 )
 
@@ -61,3 +63,178 @@ func TestPort(t *testing.T) {
 		t.Fatalf("expected: %s got %d", lis.Addr().String(), port)
 	}
 }
+
+func TestExtractListener(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	res, err := Extract(lis.Addr().String(), lis)
+	if err != nil || res != lis.Addr().String() {
+		t.Fatalf("expected %s got %s %v", lis.Addr().String(), res, err)
+	}
+
+	lis, err = net.Listen("unix", filepath.Join(t.TempDir(), "host.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	res, err = Extract("", lis)
+	if err != nil || res != lis.Addr().String() {
+		t.Fatalf("expected %s got %s %v", lis.Addr().String(), res, err)
+	}
+}
+
+func TestUnixPath(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		path     string
+		ok       bool
+	}{
+		{"unix:///tmp/app.sock", "/tmp/app.sock", true},
+		{"http+unix:///tmp/app.sock", "/tmp/app.sock", true},
+		{"grpc+unix:///tmp/app.sock?isSecure=false", "/tmp/app.sock", true},
+		{"grpc://127.0.0.1:9000", "", false},
+		{"unix://", "", false},
+		{"127.0.0.1:9000", "", false},
+	}
+	for _, test := range tests {
+		path, ok := UnixPath(test.endpoint)
+		if path != test.path || ok != test.ok {
+			t.Errorf("%s: got %s %t want %s %t", test.endpoint, path, ok, test.path, test.ok)
+		}
+	}
+}
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listen.sock")
+	lis, err := Listen("tcp", "unix://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	if endpoint, ok := UnixEndpoint("http", lis); !ok || endpoint != "http+unix://"+path {
+		t.Fatalf("got %s %t", endpoint, ok)
+	}
+
+	tcp, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	if _, ok := UnixEndpoint("http", tcp); ok {
+		t.Error("want no unix endpoint of a tcp listener")
+	}
+	if _, err = Listen("tcp", "systemd://http"); err == nil {
+		t.Error("want an error without the systemd sockets")
+	}
+}
+
+func TestPick(t *testing.T) {
+	ifaces := []net.Interface{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Name: "docker0", Flags: 0},
+		{Name: "eth0", Flags: net.FlagUp},
+		{Name: "eth1", Flags: net.FlagUp},
+	}
+	addrs := map[string][]string{
+		"lo":      {"127.0.0.1/8", "::1/128"},
+		"docker0": {"172.17.0.1/16"},
+		"eth0":    {"fd00::10/64", "192.168.1.10/24", "fe80::1/64"},
+		"eth1":    {"10.0.0.10/8", "2001:db8::10/64"},
+	}
+	addrsOf := func(iface net.Interface) ([]net.Addr, error) {
+		var res []net.Addr
+		for _, a := range addrs[iface.Name] {
+			ip, n, err := net.ParseCIDR(a)
+			if err != nil {
+				return nil, err
+			}
+			n.IP = ip
+			res = append(res, n)
+		}
+		return res, nil
+	}
+	tests := []struct {
+		name   string
+		opts   []Option
+		expect string
+	}{
+		{"default", nil, "192.168.1.10"},
+		{"ipv6", []Option{WithIPv6(true)}, "fd00::10"},
+		{"interface", []Option{WithInterface("eth1", "eth0")}, "10.0.0.10"},
+		{"interface ipv6", []Option{WithInterface("eth1"), WithIPv6(true)}, "2001:db8::10"},
+		{"cidr", []Option{WithCIDR("10.0.0.0/8", "192.168.0.0/16")}, "10.0.0.10"},
+		{"down", []Option{WithCIDR("172.17.0.0/16")}, "<nil>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := options{}
+			for _, opt := range test.opts {
+				opt(&o)
+			}
+			if ip := o.pick(ifaces, addrsOf); ip.String() != test.expect {
+				t.Fatalf("expected %s got %s", test.expect, ip)
+			}
+		})
+	}
+	if _, err := Extract(":80", nil, WithCIDR("10.0.0.0")); err == nil {
+		t.Error("want an error of the invalid cidr")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		scheme, address, query string
+		expect                 string
+	}{
+		{"http", "203.0.113.10:8000", "", "http://203.0.113.10:8000"},
+		{"grpc", "203.0.113.10:9000", "isSecure=false", "grpc://203.0.113.10:9000?isSecure=false"},
+		{"grpc", "grpc://lb.example.com:443?isSecure=true", "isSecure=false", "grpc://lb.example.com:443?isSecure=true"},
+		{"grpc", "grpc://lb.example.com:443", "isSecure=true", "grpc://lb.example.com:443?isSecure=true"},
+		{"grpc", "grpc://lb.example.com:443?zone=sh", "isSecure=true", "grpc://lb.example.com:443?isSecure=true&zone=sh"},
+		{"http", "https://api.example.com", "", "https://api.example.com"},
+	}
+	for _, test := range tests {
+		res, err := Endpoint(test.scheme, test.address, test.query)
+		if err != nil || res != test.expect {
+			t.Errorf("expected %s got %s %v", test.expect, res, err)
+		}
+	}
+	if _, err := Endpoint("http", "203.0.113.10", ""); err == nil {
+		t.Error("want an error of the address without a port")
+	}
+}
+
+func TestDialable(t *testing.T) {
+	tests := []struct {
+		network, address string
+		expect           string
+	}{
+		{"tcp", "127.0.0.1:0", "127.0.0.1"},
+		{"tcp", ":0", "127.0.0.1"},
+		{"tcp", "0.0.0.0:0", "127.0.0.1"},
+	}
+	for _, test := range tests {
+		lis, err := net.Listen(test.network, test.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		network, address := Dialable(lis)
+		h, port, _ := net.SplitHostPort(address)
+		if network != "tcp" || h != test.expect || port != strconv.Itoa(lis.Addr().(*net.TCPAddr).Port) {
+			t.Errorf("%s: got %s %s want %s", test.address, network, address, test.expect)
+		}
+		lis.Close()
+	}
+	path := filepath.Join(t.TempDir(), "app.sock")
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	if network, address := Dialable(lis); network != "unix" || address != path {
+		t.Errorf("got %s %s want the socket path", network, address)
+	}
+}
@@ -0,0 +1,152 @@
+package host
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	unixScheme    = "unix://"
+	systemdScheme = "systemd://"
+)
+
+// Listen announces on the network address, the address can also be a unix socket path of
+// unix:///tmp/app.sock, or a socket passed by the systemd socket activation of
+// systemd://name, which is the FileDescriptorName of the socket unit, or its index.
+func Listen(network, address string) (net.Listener, error) {
+	if path, ok := UnixPath(address); ok {
+		return net.Listen("unix", path)
+	}
+	if strings.HasPrefix(address, systemdScheme) {
+		return systemdListener(strings.TrimPrefix(address, systemdScheme))
+	}
+	return net.Listen(network, address)
+}
+
+// UnixPath returns the socket path of the unix endpoint, such as unix:///tmp/app.sock,
+// http+unix:///tmp/app.sock or grpc+unix:///tmp/app.sock.
+func UnixPath(endpoint string) (string, bool) {
+	i := strings.Index(endpoint, "://")
+	if i < 0 {
+		return "", false
+	}
+	if scheme := endpoint[:i]; scheme != "unix" && !strings.HasSuffix(scheme, "+unix") {
+		return "", false
+	}
+	path := endpoint[i+3:]
+	if j := strings.IndexByte(path, '?'); j >= 0 {
+		path = path[:j]
+	}
+	return path, path != ""
+}
+
+// UnixEndpoint returns the endpoint of the scheme of the unix listener, such as
+// http+unix:///tmp/app.sock, or false if it is not a unix listener.
+func UnixEndpoint(scheme string, lis net.Listener) (string, bool) {
+	if lis == nil {
+		return "", false
+	}
+	addr, ok := lis.Addr().(*net.UnixAddr)
+	if !ok {
+		return "", false
+	}
+	path := addr.Name
+	if abs, err := filepath.Abs(path); err == nil && !strings.HasPrefix(path, "@") {
+		path = abs
+	}
+	return scheme + "+" + unixScheme + path, true
+}
+
+// Dialable returns the network and the address to dial the listener on the local host,
+// the unspecified address of a listener is dialed by the IPv4 loopback address, which the
+// dual-stack listeners of the unspecified IPv6 address accept as well.
+func Dialable(lis net.Listener) (network, address string) {
+	switch addr := lis.Addr().(type) {
+	case *net.UnixAddr:
+		return "unix", addr.Name
+	case *net.TCPAddr:
+		ip := addr.IP
+		if ip == nil || ip.IsUnspecified() {
+			ip = net.IPv4(127, 0, 0, 1)
+		}
+		return "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(addr.Port))
+	default:
+		return lis.Addr().Network(), lis.Addr().String()
+	}
+}
+
+var systemd struct {
+	once  sync.Once
+	files []*os.File
+	names []string
+}
+
+// systemdListener returns the listener of the socket passed by systemd, see sd_listen_fds(3).
+func systemdListener(name string) (net.Listener, error) {
+	systemd.once.Do(func() {
+		if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+			return
+		}
+		n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil {
+			return
+		}
+		names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+		for i := 0; i < n; i++ {
+			fdName := ""
+			if i < len(names) {
+				fdName = names[i]
+			}
+			systemd.files = append(systemd.files, os.NewFile(uintptr(3+i), fdName))
+			systemd.names = append(systemd.names, fdName)
+		}
+	})
+	for i, f := range systemd.files {
+		if systemd.names[i] == name || strconv.Itoa(i) == name || (name == "" && i == 0) {
+			return net.FileListener(f)
+		}
+	}
+	return nil, fmt.Errorf("host: no systemd socket of %q", name)
+}
+
+// Endpoint returns the endpoint of the specified address, which is either an endpoint of
+// any scheme, which the parameters of the query it lacks are added to, or a host and port
+// which the scheme and the query are added to.
+func Endpoint(scheme, address, query string) (string, error) {
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return "", err
+		}
+		params, err := url.ParseQuery(query)
+		if err != nil {
+			return "", err
+		}
+		values := u.Query()
+		added := false
+		for k, v := range params {
+			if _, ok := values[k]; !ok {
+				values[k], added = v, true
+			}
+		}
+		if !added {
+			return address, nil
+		}
+		u.RawQuery = values.Encode()
+		return u.String(), nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return "", err
+	}
+	endpoint := scheme + "://" + address
+	if query != "" {
+		endpoint += "?" + query
+	}
+	return endpoint, nil
+}
@@ -0,0 +1,28 @@
+// Package httputil provides the content type helpers shared by the transports, whose
+// codecs are named by the subtypes of the media types.
+package httputil
+
+import (
+	"mime"
+	"strings"
+)
+
+const baseContentType = "application"
+
+// ContentType returns the content type of the codec, i.e. application/json.
+func ContentType(subtype string) string {
+	return strings.Join([]string{baseContentType, subtype}, "/")
+}
+
+// ContentSubtype returns the subtype of the media type, i.e. json for
+// "application/json; charset=utf-8", or an empty string if it is invalid.
+func ContentSubtype(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(contentType))
+	if err != nil {
+		return ""
+	}
+	if i := strings.Index(mediaType, "/"); i >= 0 {
+		return mediaType[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,19 @@
+package httputil
+
+import "testing"
+
+func TestContentSubtype(t *testing.T) {
+	tests := map[string]string{
+		"application/json":                  "json",
+		"application/json; charset=utf-8":   "json",
+		"application/x-www-form-urlencoded": "x-www-form-urlencoded",
+		"text/xml":                          "xml",
+		"":                                  "",
+		"invalid":                           "",
+	}
+	for contentType, want := range tests {
+		if got := ContentSubtype(contentType); got != want {
+			t.Errorf("%q: got %q want %q", contentType, got, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package matcher matches the operations by the patterns of the per operation options,
+// i.e. the timeouts. A pattern is an operation, or a prefix of the operations ending
+// with "*", i.e. /helloworld.Greeter/*, the operation itself wins, then the longest prefix.
+package matcher
+
+import (
+	"sort"
+	"strings"
+)
+
+// Matcher is a matcher of the patterns, which is immutable.
+type Matcher struct {
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+// New returns a matcher of the patterns.
+func New(patterns ...string) *Matcher {
+	m := &Matcher{exact: make(map[string]struct{}, len(patterns))}
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "*") {
+			m.prefixes = append(m.prefixes, p)
+			continue
+		}
+		m.exact[p] = struct{}{}
+	}
+	sort.Slice(m.prefixes, func(i, j int) bool {
+		return len(m.prefixes[i]) > len(m.prefixes[j])
+	})
+	return m
+}
+
+// Match returns the pattern that matches the operation, it reports false if none matches.
+func (m *Matcher) Match(operation string) (string, bool) {
+	if _, ok := m.exact[operation]; ok {
+		return operation, true
+	}
+	for _, p := range m.prefixes {
+		if strings.HasPrefix(operation, p[:len(p)-1]) {
+			return p, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,24 @@
+package matcher
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	m := New("/helloworld.Greeter/SayHello", "/helloworld.Greeter/*", "/helloworld.*", "*")
+	tests := []struct {
+		operation string
+		pattern   string
+	}{
+		{"/helloworld.Greeter/SayHello", "/helloworld.Greeter/SayHello"},
+		{"/helloworld.Greeter/SayHi", "/helloworld.Greeter/*"},
+		{"/helloworld.Other/SayHi", "/helloworld.*"},
+		{"/billing.Billing/Charge", "*"},
+	}
+	for _, test := range tests {
+		if got, ok := m.Match(test.operation); !ok || got != test.pattern {
+			t.Errorf("%s: got %s %t want %s", test.operation, got, ok, test.pattern)
+		}
+	}
+	if got, ok := New("/helloworld.Greeter/*").Match("/billing.Billing/Charge"); ok {
+		t.Errorf("got %s want no match", got)
+	}
+}
@@ -0,0 +1,16 @@
+// Package reload loads the values of the config keys, and reloads them whenever the keys
+// change, i.e. the per operation options.
+package reload
+
+import "github.com/go-kratos/kratos/v2/config"
+
+// Load calls load with the value of the key, and again whenever the key changes. The
+// changed values failing to load are ignored, so the last loaded one is kept.
+func Load(c config.Config, key string, load func(config.Value) error) error {
+	if err := load(c.Value(key)); err != nil {
+		return err
+	}
+	return c.Watch(key, func(_ string, v config.Value) {
+		_ = load(v)
+	})
+}
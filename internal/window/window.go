@@ -0,0 +1,76 @@
+package window
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is the statistic of a time bucket.
+type Bucket struct {
+	Sum   int64
+	Count int64
+}
+
+// Window is a rolling window made of a fixed number of time buckets.
+type Window struct {
+	mu       sync.Mutex
+	buckets  []Bucket
+	duration time.Duration
+	offset   int
+	// start time of the current bucket
+	last time.Time
+}
+
+// New returns a rolling window with size buckets of the duration.
+func New(size int, duration time.Duration) *Window {
+	return &Window{
+		buckets:  make([]Bucket, size),
+		duration: duration,
+		last:     time.Now(),
+	}
+}
+
+// advance moves the current bucket forward and resets the expired buckets.
+func (w *Window) advance(now time.Time) {
+	span := int(now.Sub(w.last) / w.duration)
+	if span <= 0 {
+		return
+	}
+	w.last = w.last.Add(time.Duration(span) * w.duration)
+	if span > len(w.buckets) {
+		span = len(w.buckets)
+	}
+	for i := 1; i <= span; i++ {
+		w.buckets[(w.offset+i)%len(w.buckets)] = Bucket{}
+	}
+	w.offset = (w.offset + span) % len(w.buckets)
+}
+
+// Add adds the value to the current bucket.
+func (w *Window) Add(v int64) {
+	w.mu.Lock()
+	w.advance(time.Now())
+	w.buckets[w.offset].Sum += v
+	w.buckets[w.offset].Count++
+	w.mu.Unlock()
+}
+
+// Reduce iterates over all the buckets, including the current one.
+func (w *Window) Reduce(fn func(b Bucket)) {
+	w.reduce(0, fn)
+}
+
+// ReduceCompleted iterates over the completed buckets, the current bucket is skipped.
+func (w *Window) ReduceCompleted(fn func(b Bucket)) {
+	w.reduce(1, fn)
+}
+
+func (w *Window) reduce(skip int, fn func(b Bucket)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance(time.Now())
+	// from the oldest bucket to the current one
+	for i := 0; i < len(w.buckets)-skip; i++ {
+		fn(w.buckets[(w.offset+1+i)%len(w.buckets)])
+	}
+}
@@ -0,0 +1,47 @@
+package window
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	w := New(5, 100*time.Millisecond)
+	w.Add(1)
+	w.Add(2)
+	time.Sleep(150 * time.Millisecond)
+	w.Add(4)
+
+	var sum, count int64
+	w.ReduceCompleted(func(b Bucket) {
+		sum += b.Sum
+		count += b.Count
+	})
+	if sum != 3 || count != 2 {
+		t.Errorf("completed buckets: got sum=%d count=%d want sum=3 count=2", sum, count)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+	sum = 0
+	w.ReduceCompleted(func(b Bucket) {
+		sum += b.Sum
+	})
+	if sum != 0 {
+		t.Errorf("expired buckets are not reset: %d", sum)
+	}
+}
+
+func TestWindowReduce(t *testing.T) {
+	w := New(3, time.Hour)
+	w.Add(1)
+	w.Add(1)
+
+	var sum, count int64
+	w.Reduce(func(b Bucket) {
+		sum += b.Sum
+		count += b.Count
+	})
+	if sum != 2 || count != 2 {
+		t.Errorf("got sum=%d count=%d want sum=2 count=2", sum, count)
+	}
+}
@@ -0,0 +1,89 @@
+package log
+
+// fuzzyStr is the replacement of a filtered value.
+const fuzzyStr = "***"
+
+// FilterOption is filter option.
+type FilterOption func(*Filter)
+
+// FilterLevel with filter level.
+func FilterLevel(level Level) FilterOption {
+	return func(opts *Filter) {
+		opts.level = level
+	}
+}
+
+// FilterKey with filter key, the values of the keys are masked.
+func FilterKey(key ...string) FilterOption {
+	return func(o *Filter) {
+		for _, v := range key {
+			o.key[v] = struct{}{}
+		}
+	}
+}
+
+// FilterValue with filter value, the values are masked.
+func FilterValue(value ...string) FilterOption {
+	return func(o *Filter) {
+		for _, v := range value {
+			o.value[v] = struct{}{}
+		}
+	}
+}
+
+// FilterFunc with filter func, a log is dropped if the func returns true.
+func FilterFunc(f func(level Level, keyvals ...interface{}) bool) FilterOption {
+	return func(o *Filter) {
+		o.filter = f
+	}
+}
+
+// Filter is a logger filter.
+type Filter struct {
+	logger Logger
+	level  Level
+	key    map[interface{}]struct{}
+	value  map[interface{}]struct{}
+	filter func(level Level, keyvals ...interface{}) bool
+}
+
+// NewFilter new a logger filter.
+func NewFilter(logger Logger, opts ...FilterOption) *Filter {
+	options := Filter{
+		logger: logger,
+		key:    make(map[interface{}]struct{}),
+		value:  make(map[interface{}]struct{}),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &options
+}
+
+// Log Print log by level and keyvals.
+func (f *Filter) Log(level Level, keyvals ...interface{}) error {
+	if !f.level.Enabled(level) {
+		return nil
+	}
+	if f.filter != nil && f.filter(level, keyvals...) {
+		return nil
+	}
+	if len(f.key) > 0 || len(f.value) > 0 {
+		kvs := make([]interface{}, len(keyvals))
+		copy(kvs, keyvals)
+		for i := 0; i < len(kvs); i += 2 {
+			v := i + 1
+			if v >= len(kvs) {
+				continue
+			}
+			if _, ok := f.key[kvs[i]]; ok {
+				kvs[v] = fuzzyStr
+			}
+			if _, ok := f.value[kvs[v]]; ok {
+				kvs[v] = fuzzyStr
+			}
+		}
+		keyvals = kvs
+	}
+	return f.logger.Log(level, keyvals...)
+}
@@ -0,0 +1,26 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := NewFilter(NewStdLogger(buf),
+		FilterLevel(LevelWarn),
+		FilterKey("password"),
+		FilterValue("secret"),
+		FilterFunc(func(level Level, keyvals ...interface{}) bool {
+			return len(keyvals) > 1 && keyvals[1] == "drop"
+		}),
+	)
+	logger.Log(LevelInfo, "msg", "below level")
+	logger.Log(LevelWarn, "msg", "drop")
+	logger.Log(LevelWarn, "password", "123456", "token", "secret")
+	logger.Log(LevelError, "msg", "kept")
+	want := "WARN password=*** token=***\nERROR msg=kept\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
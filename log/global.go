@@ -0,0 +1,35 @@
+package log
+
+import "sync"
+
+// global is the process wide logger, it forwards to the logger set by SetLogger,
+// so loggers derived from GetLogger before SetLogger also log to the new logger.
+var global = &loggerAppliance{Logger: DefaultLogger}
+
+type loggerAppliance struct {
+	mu sync.RWMutex
+	Logger
+}
+
+func (a *loggerAppliance) set(logger Logger) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Logger = logger
+}
+
+func (a *loggerAppliance) Log(level Level, keyvals ...interface{}) error {
+	a.mu.RLock()
+	logger := a.Logger
+	a.mu.RUnlock()
+	return logger.Log(level, keyvals...)
+}
+
+// SetLogger replaces the global logger, typically with a logging adapter at startup.
+func SetLogger(logger Logger) {
+	global.set(logger)
+}
+
+// GetLogger returns the global logger.
+func GetLogger() Logger {
+	return global
+}
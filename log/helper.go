@@ -1,86 +1,112 @@
 package log
 
 import (
+	"context"
 	"fmt"
+	"os"
 )
 
-var nop Logger = new(nopLogger)
+// DefaultMessageKey is the default message key of the helper.
+const DefaultMessageKey = "message"
 
 // Helper is a logger helper.
 type Helper struct {
-	debug Logger
-	info  Logger
-	warn  Logger
-	err   Logger
+	logger Logger
 }
 
 // NewHelper new a logger helper.
 func NewHelper(name string, logger Logger) *Helper {
-	log := With(logger, "module", name)
 	return &Helper{
-		debug: Debug(log),
-		info:  Info(log),
-		warn:  Warn(log),
-		err:   Error(log),
+		logger: With(logger, "module", name),
 	}
 }
 
+// WithContext returns a shallow copy of h with its context changed to ctx.
+func (h *Helper) WithContext(ctx context.Context) *Helper {
+	return &Helper{
+		logger: WithContext(ctx, h.logger),
+	}
+}
+
+// Log print the kv pairs log.
+func (h *Helper) Log(level Level, keyvals ...interface{}) {
+	h.logger.Log(level, keyvals...)
+}
+
 // Debug logs a message at debug level.
 func (h *Helper) Debug(a ...interface{}) {
-	h.debug.Print("message", fmt.Sprint(a...))
+	h.logger.Log(LevelDebug, DefaultMessageKey, fmt.Sprint(a...))
 }
 
 // Debugf logs a message at debug level.
 func (h *Helper) Debugf(format string, a ...interface{}) {
-	h.debug.Print("message", fmt.Sprintf(format, a...))
+	h.logger.Log(LevelDebug, DefaultMessageKey, fmt.Sprintf(format, a...))
 }
 
 // Debugw logs a message at debug level.
 func (h *Helper) Debugw(kvpair ...interface{}) {
-	h.debug.Print(kvpair...)
+	h.logger.Log(LevelDebug, kvpair...)
 }
 
 // Info logs a message at info level.
 func (h *Helper) Info(a ...interface{}) {
-	h.info.Print("message", fmt.Sprint(a...))
+	h.logger.Log(LevelInfo, DefaultMessageKey, fmt.Sprint(a...))
 }
 
 // Infof logs a message at info level.
 func (h *Helper) Infof(format string, a ...interface{}) {
-	h.info.Print("message", fmt.Sprintf(format, a...))
+	h.logger.Log(LevelInfo, DefaultMessageKey, fmt.Sprintf(format, a...))
 }
 
 // Infow logs a message at info level.
 func (h *Helper) Infow(kvpair ...interface{}) {
-	h.info.Print(kvpair...)
+	h.logger.Log(LevelInfo, kvpair...)
 }
 
 // Warn logs a message at warn level.
 func (h *Helper) Warn(a ...interface{}) {
-	h.warn.Print("message", fmt.Sprint(a...))
+	h.logger.Log(LevelWarn, DefaultMessageKey, fmt.Sprint(a...))
 }
 
 // Warnf logs a message at warnf level.
 func (h *Helper) Warnf(format string, a ...interface{}) {
-	h.warn.Print("message", fmt.Sprintf(format, a...))
+	h.logger.Log(LevelWarn, DefaultMessageKey, fmt.Sprintf(format, a...))
 }
 
 // Warnw logs a message at warnf level.
 func (h *Helper) Warnw(kvpair ...interface{}) {
-	h.warn.Print(kvpair...)
+	h.logger.Log(LevelWarn, kvpair...)
 }
 
 // Error logs a message at error level.
 func (h *Helper) Error(a ...interface{}) {
-	h.err.Print("message", fmt.Sprint(a...))
+	h.logger.Log(LevelError, DefaultMessageKey, fmt.Sprint(a...))
 }
 
 // Errorf logs a message at error level.
 func (h *Helper) Errorf(format string, a ...interface{}) {
-	h.err.Print("message", fmt.Sprintf(format, a...))
+	h.logger.Log(LevelError, DefaultMessageKey, fmt.Sprintf(format, a...))
 }
 
 // Errorw logs a message at error level.
 func (h *Helper) Errorw(kvpair ...interface{}) {
-	h.err.Print(kvpair...)
+	h.logger.Log(LevelError, kvpair...)
+}
+
+// Fatal logs a message at fatal level, and exits the process.
+func (h *Helper) Fatal(a ...interface{}) {
+	h.logger.Log(LevelFatal, DefaultMessageKey, fmt.Sprint(a...))
+	os.Exit(1)
+}
+
+// Fatalf logs a message at fatal level, and exits the process.
+func (h *Helper) Fatalf(format string, a ...interface{}) {
+	h.logger.Log(LevelFatal, DefaultMessageKey, fmt.Sprintf(format, a...))
+	os.Exit(1)
+}
+
+// Fatalw logs a message at fatal level, and exits the process.
+func (h *Helper) Fatalw(kvpair ...interface{}) {
+	h.logger.Log(LevelFatal, kvpair...)
+	os.Exit(1)
 }
@@ -1,5 +1,7 @@
 package log
 
+import "strings"
+
 // Level is a logger level.
 type Level int8
 
@@ -12,6 +14,8 @@ const (
 	LevelWarn
 	// LevelError is logger error level.
 	LevelError
+	// LevelFatal is logger fatal level.
+	LevelFatal
 )
 
 const (
@@ -34,7 +38,27 @@ func (l Level) String() string {
 		return "WARN"
 	case LevelError:
 		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
 	default:
 		return ""
 	}
 }
+
+// ParseLevel parses a level string into a logger Level value,
+// an unknown level string returns LevelInfo.
+func ParseLevel(s string) Level {
+	switch strings.ToUpper(s) {
+	case "DEBUG":
+		return LevelDebug
+	case "INFO":
+		return LevelInfo
+	case "WARN":
+		return LevelWarn
+	case "ERROR":
+		return LevelError
+	case "FATAL":
+		return LevelFatal
+	}
+	return LevelInfo
+}
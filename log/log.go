@@ -1,48 +1,68 @@
 package log
 
+import (
+	"context"
+	"log"
+)
+
 var (
 	// DefaultLogger is default logger.
-	DefaultLogger Logger = NewStdLogger()
+	DefaultLogger Logger = With(NewStdLogger(log.Writer()), "ts", DefaultTimestamp, "caller", DefaultCaller)
 )
 
 // Logger is a logger interface.
 type Logger interface {
-	Print(pairs ...interface{})
+	Log(level Level, keyvals ...interface{}) error
 }
 
 type logger struct {
-	log   Logger
-	pairs []interface{}
-}
-
-func (l *logger) Print(pairs ...interface{}) {
-	l.log.Print(append(pairs, l.pairs...)...)
+	logger    Logger
+	prefix    []interface{}
+	hasValuer bool
+	ctx       context.Context
 }
 
-// With with logger kv pairs.
-func With(log Logger, pairs ...interface{}) Logger {
-	if len(pairs) == 0 {
-		return log
+func (c *logger) Log(level Level, keyvals ...interface{}) error {
+	kvs := make([]interface{}, 0, len(c.prefix)+len(keyvals))
+	kvs = append(kvs, c.prefix...)
+	if c.hasValuer {
+		bindValues(c.ctx, kvs)
 	}
-	return &logger{log: log, pairs: pairs}
+	kvs = append(kvs, keyvals...)
+	return c.logger.Log(level, kvs...)
 }
 
-// Debug returns a debug logger.
-func Debug(log Logger) Logger {
-	return With(log, LevelKey, LevelDebug)
-}
-
-// Info returns a info logger.
-func Info(log Logger) Logger {
-	return With(log, LevelKey, LevelInfo)
-}
-
-// Warn return a warn logger.
-func Warn(log Logger) Logger {
-	return With(log, LevelKey, LevelWarn)
+// With with logger kv pairs, a Valuer value is evaluated on every log.
+func With(l Logger, kv ...interface{}) Logger {
+	if len(kv) == 0 {
+		return l
+	}
+	c, ok := l.(*logger)
+	if !ok {
+		return &logger{logger: l, prefix: kv, hasValuer: containsValuer(kv), ctx: context.Background()}
+	}
+	kvs := make([]interface{}, 0, len(c.prefix)+len(kv))
+	kvs = append(kvs, c.prefix...)
+	kvs = append(kvs, kv...)
+	return &logger{
+		logger:    c.logger,
+		prefix:    kvs,
+		hasValuer: containsValuer(kvs),
+		ctx:       c.ctx,
+	}
 }
 
-// Error returns a error logger.
-func Error(log Logger) Logger {
-	return With(log, LevelKey, LevelError)
+// WithContext returns a shallow copy of l with its context changed to ctx,
+// the valuers of l are evaluated with ctx.
+func WithContext(ctx context.Context, l Logger) Logger {
+	c, ok := l.(*logger)
+	if !ok {
+		return &logger{logger: l, ctx: ctx}
+	}
+	return &logger{
+		logger:    c.logger,
+		prefix:    c.prefix,
+		hasValuer: c.hasValuer,
+		ctx:       ctx,
+	}
 }
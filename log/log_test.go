@@ -1,13 +1,50 @@
 package log
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
 )
 
 func TestLogger(t *testing.T) {
-	logger := NewStdLogger()
-	Debug(logger).Print("log", "test debug")
-	Info(logger).Print("log", "test info")
-	Warn(logger).Print("log", "test warn")
-	Error(logger).Print("log", "test error")
+	logger := DefaultLogger
+	logger.Log(LevelDebug, "log", "test debug")
+	logger.Log(LevelInfo, "log", "test info")
+	logger.Log(LevelWarn, "log", "test warn")
+	logger.Log(LevelError, "log", "test error")
+}
+
+func TestWith(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := With(NewStdLogger(buf), "service", "kratos")
+	logger = With(logger, "version", "v2")
+	logger.Log(LevelInfo, "key", "value")
+	if got, want := buf.String(), "INFO service=kratos version=v2 key=value\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+type ctxKey struct{}
+
+func TestWithContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := With(NewStdLogger(buf), "user", Valuer(func(ctx context.Context) interface{} {
+		return ctx.Value(ctxKey{})
+	}))
+	WithContext(context.WithValue(context.Background(), ctxKey{}, "tony"), logger).Log(LevelInfo)
+	if got, want := buf.String(), "INFO user=tony\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestGlobalLogger(t *testing.T) {
+	defer SetLogger(DefaultLogger)
+	log := NewHelper("test", GetLogger())
+	buf := new(bytes.Buffer)
+	SetLogger(NewStdLogger(buf))
+	log.Info("after set")
+	if got := buf.String(); !strings.Contains(got, "module=test message=after set") {
+		t.Fatalf("global logger is not replaced: %q", got)
+	}
 }
@@ -1,5 +0,0 @@
-package log
-
-type nopLogger struct{}
-
-func (l *nopLogger) Print(kvpair ...interface{}) {}
@@ -3,8 +3,8 @@ package log
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
-	"os"
 	"sync"
 )
 
@@ -15,10 +15,10 @@ type stdLogger struct {
 	pool *sync.Pool
 }
 
-// NewStdLogger new a std logger with options.
-func NewStdLogger() Logger {
+// NewStdLogger new a logger with writer.
+func NewStdLogger(w io.Writer) Logger {
 	return &stdLogger{
-		log: log.New(os.Stderr, "", log.LstdFlags),
+		log: log.New(w, "", 0),
 		pool: &sync.Pool{
 			New: func() interface{} {
 				return new(bytes.Buffer)
@@ -27,19 +27,21 @@ func NewStdLogger() Logger {
 	}
 }
 
-// Print print the kv pairs log.
-func (s *stdLogger) Print(kvpair ...interface{}) {
-	if len(kvpair) == 0 {
-		return
+// Log print the kv pairs log.
+func (s *stdLogger) Log(level Level, keyvals ...interface{}) error {
+	if len(keyvals) == 0 {
+		return nil
 	}
-	if len(kvpair)%2 != 0 {
-		kvpair = append(kvpair, "")
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, "")
 	}
 	buf := s.pool.Get().(*bytes.Buffer)
-	for i := 0; i < len(kvpair); i += 2 {
-		fmt.Fprintf(buf, "%s=%v ", kvpair[i], kvpair[i+1])
+	buf.WriteString(level.String())
+	for i := 0; i < len(keyvals); i += 2 {
+		fmt.Fprintf(buf, " %s=%v", keyvals[i], keyvals[i+1])
 	}
-	s.log.Println(buf.String())
+	err := s.log.Output(4, buf.String())
 	buf.Reset()
 	s.pool.Put(buf)
+	return err
 }
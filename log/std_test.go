@@ -1,12 +1,18 @@
 package log
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
-func TestFmtLogger(t *testing.T) {
-	logger := NewStdLogger()
-
-	Debug(logger).Print("log", "test debug")
-	Info(logger).Print("log", "test info")
-	Warn(logger).Print("log", "test warn")
-	Error(logger).Print("log", "test error")
+func TestStdLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := NewStdLogger(buf)
+	logger.Log(LevelDebug, "log", "test debug")
+	logger.Log(LevelInfo, "log", "test info")
+	logger.Log(LevelWarn, "log")
+	want := "DEBUG log=test debug\nINFO log=test info\nWARN log=\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
 }
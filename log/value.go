@@ -0,0 +1,83 @@
+package log
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultCaller is a Valuer that returns the file and line of the log call.
+	DefaultCaller = Caller(0)
+
+	// DefaultTimestamp is a Valuer that returns the current wallclock time.
+	DefaultTimestamp = Timestamp(time.RFC3339)
+
+	// logDir is the directory of this package, its frames are skipped by Caller.
+	logDir string
+)
+
+func init() {
+	if _, file, _, ok := runtime.Caller(0); ok {
+		logDir = filepath.Dir(file)
+	}
+}
+
+// Valuer is returns a log value.
+type Valuer func(ctx context.Context) interface{}
+
+// Value return the function value.
+func Value(ctx context.Context, v interface{}) interface{} {
+	if v, ok := v.(Valuer); ok {
+		return v(ctx)
+	}
+	return v
+}
+
+// Caller returns a Valuer that returns a pkg/file:line description of the caller,
+// depth is the number of frames to skip above the first caller outside this package.
+func Caller(depth int) Valuer {
+	return func(context.Context) interface{} {
+		pcs := make([]uintptr, 32)
+		frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+		for {
+			frame, more := frames.Next()
+			if filepath.Dir(frame.File) != logDir || strings.HasSuffix(frame.File, "_test.go") {
+				if depth == 0 {
+					return filepath.Base(filepath.Dir(frame.File)) + "/" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+				}
+				depth--
+			}
+			if !more {
+				return ""
+			}
+		}
+	}
+}
+
+// Timestamp returns a timestamp Valuer with a custom time format.
+func Timestamp(layout string) Valuer {
+	return func(context.Context) interface{} {
+		return time.Now().Format(layout)
+	}
+}
+
+func bindValues(ctx context.Context, keyvals []interface{}) {
+	for i := 1; i < len(keyvals); i += 2 {
+		if v, ok := keyvals[i].(Valuer); ok {
+			keyvals[i] = v(ctx)
+		}
+	}
+}
+
+func containsValuer(keyvals []interface{}) bool {
+	for i := 1; i < len(keyvals); i += 2 {
+		if _, ok := keyvals[i].(Valuer); ok {
+			return true
+		}
+	}
+	return false
+}
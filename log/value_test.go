@@ -0,0 +1,20 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := With(NewStdLogger(buf), "caller", DefaultCaller, "ts", DefaultTimestamp)
+	NewHelper("test", logger).Info("value") // the caller is this line
+	got := buf.String()
+	if !strings.Contains(got, "caller=log/value_test.go:12 ") {
+		t.Fatalf("unexpected caller: %q", got)
+	}
+	if strings.Contains(got, "ts= ") {
+		t.Fatalf("unexpected timestamp: %q", got)
+	}
+}
@@ -0,0 +1,101 @@
+package metadata
+
+import (
+	"context"
+	"strings"
+)
+
+// Metadata is the transport-agnostic request metadata, the keys are lowercase.
+type Metadata map[string]string
+
+// New creates a metadata from the given key-value maps.
+func New(mds ...map[string]string) Metadata {
+	md := Metadata{}
+	for _, m := range mds {
+		for k, v := range m {
+			md.Set(k, v)
+		}
+	}
+	return md
+}
+
+// Get returns the value associated with the key.
+func (m Metadata) Get(key string) string {
+	return m[strings.ToLower(key)]
+}
+
+// Set stores the key-value pair.
+func (m Metadata) Set(key string, value string) {
+	if key == "" || value == "" {
+		return
+	}
+	m[strings.ToLower(key)] = value
+}
+
+// Range iterates over the element in metadata.
+func (m Metadata) Range(f func(k, v string) bool) {
+	for k, v := range m {
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+// Clone returns a deep copy of Metadata.
+func (m Metadata) Clone() Metadata {
+	md := Metadata{}
+	for k, v := range m {
+		md[k] = v
+	}
+	return md
+}
+
+type serverMetadataKey struct{}
+
+// NewServerContext creates a new context with the incoming metadata attached.
+func NewServerContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, serverMetadataKey{}, md)
+}
+
+// FromServerContext returns the incoming metadata in ctx if it exists.
+func FromServerContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(serverMetadataKey{}).(Metadata)
+	return md, ok
+}
+
+type clientMetadataKey struct{}
+
+// NewClientContext creates a new context with the outgoing metadata attached.
+func NewClientContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, clientMetadataKey{}, md)
+}
+
+// FromClientContext returns the outgoing metadata in ctx if it exists.
+func FromClientContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(clientMetadataKey{}).(Metadata)
+	return md, ok
+}
+
+// AppendToClientContext returns a new context with the provided kv merged
+// with any existing outgoing metadata in the context.
+func AppendToClientContext(ctx context.Context, kv ...string) context.Context {
+	if len(kv)%2 == 1 {
+		panic("metadata: AppendToClientContext got an odd number of input pairs for metadata")
+	}
+	md, _ := FromClientContext(ctx)
+	md = md.Clone()
+	for i := 0; i < len(kv); i += 2 {
+		md.Set(kv[i], kv[i+1])
+	}
+	return NewClientContext(ctx, md)
+}
+
+// MergeToClientContext merges the new metadata into the existing outgoing metadata in ctx.
+func MergeToClientContext(ctx context.Context, cmd Metadata) context.Context {
+	md, _ := FromClientContext(ctx)
+	md = md.Clone()
+	for k, v := range cmd {
+		md[k] = v
+	}
+	return NewClientContext(ctx, md)
+}
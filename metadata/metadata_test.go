@@ -0,0 +1,35 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	md := New(map[string]string{"X-MD-Foo": "foo"}, map[string]string{"x-md-bar": "bar"})
+	if md.Get("x-md-foo") != "foo" || md.Get("X-Md-Bar") != "bar" {
+		t.Errorf("unexpected metadata: %v", md)
+	}
+	md.Set("", "empty")
+	md.Set("empty", "")
+	if len(md) != 2 {
+		t.Errorf("empty key or value is set: %v", md)
+	}
+	clone := md.Clone()
+	clone.Set("x-md-foo", "changed")
+	if md.Get("x-md-foo") != "foo" {
+		t.Error("clone is not a deep copy")
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	ctx := AppendToClientContext(context.Background(), "x-md-foo", "foo")
+	ctx = MergeToClientContext(ctx, Metadata{"x-md-bar": "bar"})
+	md, ok := FromClientContext(ctx)
+	if !ok || md.Get("x-md-foo") != "foo" || md.Get("x-md-bar") != "bar" {
+		t.Errorf("unexpected client metadata: %v", md)
+	}
+	if _, ok := FromServerContext(ctx); ok {
+		t.Error("client metadata is visible as server metadata")
+	}
+}
@@ -15,7 +15,7 @@ type Gauge interface {
 	Sub(delta float64)
 }
 
-// Observer is metrics observer.
+// Observer is metrics observer, such as a histogram or a summary.
 type Observer interface {
 	With(lvs ...string) Observer
 	Observe(float64)
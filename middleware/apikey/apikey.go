@@ -0,0 +1,219 @@
+// Package apikey authenticates the requests by their API keys of a key store, enforces
+// the rate and the quota of every key, and counts the usage of the keys. The rate is
+// enforced by every instance, and so is the quota unless it is shared by WithQuota:
+//
+//	m, err := apikey.Server(
+//		apikey.WithStore(apikey.NewCache(store, time.Minute)),
+//		apikey.WithQuota(store),
+//		apikey.WithQuery("api_key"),
+//		apikey.WithUsage(prometheus.NewCounter(usageVec)),
+//	)
+//	if err != nil {
+//		panic(err)
+//	}
+//	srv := http.NewServer(http.Middleware(m))
+package apikey
+
+import (
+	"context"
+	stderrors "errors"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/ratelimit"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+const defaultHeader = "X-API-Key"
+
+var (
+	// ErrMissingKey is returned when the request has no API key.
+	ErrMissingKey = errors.Unauthorized("UNAUTHORIZED", "missing api key")
+	// ErrInvalidKey is returned when the API key is unknown or expired.
+	ErrInvalidKey = errors.Unauthorized("UNAUTHORIZED", "invalid api key")
+	// ErrRateLimited is returned when the requests of the API key exceed its rate.
+	ErrRateLimited = errors.ResourceExhausted("RATELIMIT", "api key rate limit exceeded")
+	// ErrQuotaExceeded is returned when the requests of the API key exceed its quota.
+	ErrQuotaExceeded = errors.ResourceExhausted("QUOTA", "api key quota exceeded")
+	// ErrStoreUnavailable is returned when the key store fails to look up the API key.
+	ErrStoreUnavailable = errors.Unavailable("APIKEY", "api key store unavailable")
+)
+
+// Option is apikey option.
+type Option func(*options)
+
+// WithStore with the key store of the API keys.
+func WithStore(s Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+// WithHeader with the request header of the API key, X-API-Key by default.
+// The header of the grpc requests is their metadata.
+func WithHeader(name string) Option {
+	return func(o *options) {
+		o.header = name
+	}
+}
+
+// WithQuery with the query parameter of the API key of the http requests, which is
+// looked up when the request header is empty, the query is not used by default.
+func WithQuery(name string) Option {
+	return func(o *options) {
+		o.query = name
+	}
+}
+
+// WithLimiter with the limiter of the key ids, i.e. a distributed limiter shared by
+// the instances, which is enforced besides the rate and the quota of the keys.
+func WithLimiter(l ratelimit.KeyLimiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithQuota with the quota counts of the keys, i.e. the Redis store of contrib/apikey/redis
+// shared by the instances, the local quota of NewLocalQuota by default.
+func WithQuota(q Quota) Option {
+	return func(o *options) {
+		o.quota = q
+	}
+}
+
+// WithUsage with the usage counter, labeled by key id, operation, code and reason.
+func WithUsage(c metrics.Counter) Option {
+	return func(o *options) {
+		o.usage = c
+	}
+}
+
+type options struct {
+	store   Store
+	header  string
+	query   string
+	limiter ratelimit.KeyLimiter
+	// counter: apikey_requests_total{key, operation, code, reason}
+	usage   metrics.Counter
+	quota   Quota
+	buckets *buckets
+}
+
+// Server is a server middleware that authenticates the API key of the requests,
+// and rejects the requests beyond the rate or the quota of the key. The rate limited
+// requests are rejected before their quota is counted. The key store is required, and
+// the requests without the server transport are rejected.
+func Server(opts ...Option) (middleware.Middleware, error) {
+	options := options{
+		header:  defaultHeader,
+		quota:   NewLocalQuota(),
+		buckets: newBuckets(time.Now),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.store == nil {
+		return nil, stderrors.New("apikey: the key store is required")
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrMissingKey
+			}
+			var id string
+			defer func() { options.record(id, tr.Operation(), err) }()
+			key, err := options.authenticate(ctx, tr)
+			if err != nil {
+				return nil, err
+			}
+			id = key.ID
+			if !options.buckets.allow(key) {
+				return nil, ErrRateLimited
+			}
+			if options.limiter != nil {
+				done, e := options.limiter.AllowKey(ctx, key.ID)
+				if e != nil {
+					return nil, ErrRateLimited
+				}
+				defer func() { done(ratelimit.DoneInfo{Err: err}) }()
+			}
+			if err = options.allowQuota(ctx, key); err != nil {
+				return nil, err
+			}
+			return handler(NewContext(ctx, key), req)
+		}
+	}, nil
+}
+
+func (o *options) authenticate(ctx context.Context, tr transport.Transporter) (*Key, error) {
+	secret := tr.RequestHeader().Get(o.header)
+	if secret == "" && o.query != "" {
+		if ht, ok := tr.(*http.Transport); ok && ht.Request() != nil {
+			secret = ht.Request().URL.Query().Get(o.query)
+		}
+	}
+	if secret == "" {
+		return nil, ErrMissingKey
+	}
+	key, err := o.store.Lookup(ctx, secret)
+	if err == ErrKeyNotFound {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, ErrStoreUnavailable
+	}
+	if key.Expired(time.Now()) {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+func (o *options) allowQuota(ctx context.Context, key *Key) error {
+	if key.Quota <= 0 || key.QuotaPeriod <= 0 {
+		return nil
+	}
+	n, err := o.quota.Incr(ctx, key, time.Now().Truncate(key.QuotaPeriod))
+	if err != nil {
+		return ErrStoreUnavailable
+	}
+	if n > key.Quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (o *options) record(id, operation string, err error) {
+	if o.usage != nil {
+		code := strconv.Itoa(int(errors.Code(err)))
+		o.usage.With(id, operation, code, errors.Reason(err)).Inc()
+	}
+}
+
+type keyKey struct{}
+
+// NewContext returns a new Context that carries the API key.
+func NewContext(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromContext returns the API key stored in ctx by the server middleware, if any.
+func FromContext(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(keyKey{}).(*Key)
+	return key, ok
+}
+
+// Valuer returns an API key id valuer.
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if key, ok := FromContext(ctx); ok {
+			return key.ID
+		}
+		return ""
+	}
+}
@@ -0,0 +1,300 @@
+package apikey
+
+import (
+	"context"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/ratelimit"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type headerCarrier stdhttp.Header
+
+func (hc headerCarrier) Get(key string) string { return stdhttp.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { stdhttp.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	operation string
+	reqHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+type mockCounter struct {
+	lvs   []string
+	value float64
+}
+
+func (c *mockCounter) With(lvs ...string) metrics.Counter {
+	c.lvs = lvs
+	return c
+}
+func (c *mockCounter) Inc()              { c.value++ }
+func (c *mockCounter) Add(delta float64) { c.value += delta }
+
+type mockLimiter struct {
+	allow bool
+	done  int
+}
+
+func (l *mockLimiter) AllowKey(ctx context.Context, key string) (ratelimit.DoneFunc, error) {
+	if !l.allow {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return func(ratelimit.DoneInfo) { l.done++ }, nil
+}
+
+func call(m func(context.Context, interface{}) (interface{}, error), secret string) error {
+	tr := &mockTransport{operation: "/helloworld.Greeter/SayHello", reqHeader: headerCarrier{}}
+	if secret != "" {
+		tr.reqHeader.Set("X-API-Key", secret)
+	}
+	_, err := m(transport.NewServerContext(context.Background(), tr), nil)
+	return err
+}
+
+func server(t *testing.T, opts ...Option) middleware.Middleware {
+	m, err := Server(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestServer(t *testing.T) {
+	store := NewStaticStore(map[string]*Key{
+		"s3cret":  {ID: "checkout", Owner: "payments"},
+		"expired": {ID: "legacy", ExpiresAt: time.Now().Add(-time.Hour)},
+	})
+	failing := StoreFunc(func(ctx context.Context, secret string) (*Key, error) {
+		return nil, context.DeadlineExceeded
+	})
+	var id string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		key, _ := FromContext(ctx)
+		id = key.ID
+		return nil, nil
+	}
+	usage := &mockCounter{}
+	m := server(t, WithStore(store), WithUsage(usage))(handler)
+	if err := call(m, "s3cret"); err != nil || id != "checkout" {
+		t.Errorf("got %q %v want the key", id, err)
+	}
+	if want := []string{"checkout", "/helloworld.Greeter/SayHello", "0", ""}; !reflect.DeepEqual(usage.lvs, want) {
+		t.Errorf("got %v want %v", usage.lvs, want)
+	}
+	tests := []struct {
+		secret string
+		m      func(context.Context, interface{}) (interface{}, error)
+		err    error
+	}{
+		{"", m, ErrMissingKey},
+		{"wrong", m, ErrInvalidKey},
+		{"expired", m, ErrInvalidKey},
+		{"s3cret", server(t, WithStore(failing))(handler), ErrStoreUnavailable},
+		{"s3cret", server(t, WithStore(store), WithLimiter(&mockLimiter{}))(handler), ErrRateLimited},
+	}
+	for _, test := range tests {
+		if err := call(test.m, test.secret); err != test.err {
+			t.Errorf("%q: got %v want %v", test.secret, err, test.err)
+		}
+	}
+	if want := []string{"", "/helloworld.Greeter/SayHello", "16", "UNAUTHORIZED"}; !reflect.DeepEqual(usage.lvs, want) {
+		t.Errorf("got %v want %v", usage.lvs, want)
+	}
+	if usage.value != 4 {
+		t.Errorf("got %v want 4 requests", usage.value)
+	}
+
+	l := &mockLimiter{allow: true}
+	if err := call(server(t, WithStore(store), WithLimiter(l))(handler), "s3cret"); err != nil || l.done != 1 {
+		t.Errorf("got %v %d want the done limiter", err, l.done)
+	}
+	if _, err := m(context.Background(), nil); err != ErrMissingKey {
+		t.Errorf("got %v want %v without the server transport", err, ErrMissingKey)
+	}
+}
+
+func TestServerStore(t *testing.T) {
+	if _, err := Server(); err == nil {
+		t.Error("got no error want the error of the missing store")
+	}
+}
+
+func TestRate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newBuckets(func() time.Time { return now })
+	allow := func(key *Key, n int) (allowed int) {
+		for i := 0; i < n; i++ {
+			if b.allow(key) {
+				allowed++
+			}
+		}
+		return allowed
+	}
+	rate := &Key{ID: "rate", Rate: 10, Burst: 5}
+	if n := allow(rate, 10); n != 5 {
+		t.Errorf("got %d want the burst 5", n)
+	}
+	now = now.Add(200 * time.Millisecond)
+	if n := allow(rate, 10); n != 2 {
+		t.Errorf("got %d want 2 refilled tokens", n)
+	}
+	if n := allow(&Key{ID: "unlimited"}, 100); n != 100 {
+		t.Errorf("got %d want unlimited", n)
+	}
+}
+
+type mockQuota struct {
+	counts map[string]int64
+	err    error
+}
+
+func (q *mockQuota) Incr(ctx context.Context, key *Key, start time.Time) (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	q.counts[key.ID+"/"+start.String()]++
+	return q.counts[key.ID+"/"+start.String()], nil
+}
+
+func TestQuota(t *testing.T) {
+	store := NewStaticStore(map[string]*Key{
+		"daily":   {ID: "daily", Quota: 3, QuotaPeriod: 24 * time.Hour},
+		"limited": {ID: "limited", Rate: 1, Burst: 1, Quota: 3, QuotaPeriod: 24 * time.Hour},
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	allow := func(m func(context.Context, interface{}) (interface{}, error), secret string, n int) (allowed int, err error) {
+		for i := 0; i < n; i++ {
+			if err = call(m, secret); err == nil {
+				allowed++
+			}
+		}
+		return allowed, err
+	}
+	if n, err := allow(server(t, WithStore(store))(handler), "daily", 5); n != 3 || err != ErrQuotaExceeded {
+		t.Errorf("got %d %v want the local quota 3", n, err)
+	}
+
+	// the quota is shared by the servers of the same quota.
+	q := &mockQuota{counts: make(map[string]int64)}
+	if n, _ := allow(server(t, WithStore(store), WithQuota(q))(handler), "daily", 2); n != 2 {
+		t.Errorf("got %d want 2", n)
+	}
+	if n, err := allow(server(t, WithStore(store), WithQuota(q))(handler), "daily", 2); n != 1 || err != ErrQuotaExceeded {
+		t.Errorf("got %d %v want the rest of the shared quota", n, err)
+	}
+
+	// the rate limited requests are not counted by the quota.
+	q = &mockQuota{counts: make(map[string]int64)}
+	if n, err := allow(server(t, WithStore(store), WithQuota(q))(handler), "limited", 5); n != 1 || err != ErrRateLimited {
+		t.Errorf("got %d %v want the burst 1", n, err)
+	}
+	if n, err := allow(server(t, WithStore(store), WithQuota(q), WithLimiter(&mockLimiter{}))(handler), "daily", 5); n != 0 || err != ErrRateLimited {
+		t.Errorf("got %d %v want the limited requests", n, err)
+	}
+	var total int64
+	for _, c := range q.counts {
+		total += c
+	}
+	if total != 1 {
+		t.Errorf("got %d counted requests want only the allowed one", total)
+	}
+
+	if err := call(server(t, WithStore(store), WithQuota(&mockQuota{err: context.DeadlineExceeded}))(handler), "daily"); err != ErrStoreUnavailable {
+		t.Errorf("got %v want %v", err, ErrStoreUnavailable)
+	}
+}
+
+func TestLocalQuota(t *testing.T) {
+	q := NewLocalQuota()
+	key := &Key{ID: "daily", Quota: 3, QuotaPeriod: 24 * time.Hour}
+	start := time.Unix(0, 0)
+	for i := int64(1); i <= 3; i++ {
+		if n, _ := q.Incr(context.Background(), key, start); n != i {
+			t.Errorf("got %d want %d", n, i)
+		}
+	}
+	if n, _ := q.Incr(context.Background(), key, start.Add(24*time.Hour)); n != 1 {
+		t.Errorf("got %d want the count of the next period", n)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	srv := http.NewServer()
+	srv.RouteGroup("/").GET("/hello", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		_, err := server(t, 
+			WithStore(NewStaticStore(map[string]*Key{"s3cret": {ID: "checkout"}})),
+			WithQuery("api_key"),
+		)(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})(r.Context(), nil)
+		if err != nil {
+			w.WriteHeader(stdhttp.StatusUnauthorized)
+		}
+	})
+	tests := []struct {
+		url  string
+		code int
+	}{
+		{"/hello?api_key=s3cret", stdhttp.StatusOK},
+		{"/hello?api_key=wrong", stdhttp.StatusUnauthorized},
+		{"/hello", stdhttp.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(stdhttp.MethodGet, test.url, nil))
+		if w.Code != test.code {
+			t.Errorf("%s: got %d want %d", test.url, w.Code, test.code)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	var lookups int
+	store := NewStaticStore(map[string]*Key{"s3cret": {ID: "checkout"}})
+	c := NewCache(StoreFunc(func(ctx context.Context, secret string) (*Key, error) {
+		lookups++
+		return store.Lookup(ctx, secret)
+	}), time.Minute).(*cache)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if key, err := c.Lookup(context.Background(), "s3cret"); err != nil || key.ID != "checkout" {
+			t.Errorf("got %v %v want the key", key, err)
+		}
+		if _, err := c.Lookup(context.Background(), "wrong"); err != ErrKeyNotFound {
+			t.Errorf("got %v want %v", err, ErrKeyNotFound)
+		}
+	}
+	if lookups != 3 {
+		t.Errorf("got %d lookups want the cached key and the uncached misses", lookups)
+	}
+	now = now.Add(time.Hour)
+	if _, err := c.Lookup(context.Background(), "s3cret"); err != nil || lookups != 4 {
+		t.Errorf("got %v %d lookups want the expired key looked up", err, lookups)
+	}
+	if len(c.keys) != 1 {
+		t.Errorf("got %d keys want the expired evicted", len(c.keys))
+	}
+}
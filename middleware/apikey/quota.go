@@ -0,0 +1,86 @@
+package apikey
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Quota counts the requests of the keys in their quota periods, i.e. in Redis, so that
+// the quota of a key is shared by the instances.
+type Quota interface {
+	// Incr increments the count of the requests of the key in the quota period which
+	// starts at start, and returns the count including the request.
+	Incr(ctx context.Context, key *Key, start time.Time) (int64, error)
+}
+
+type periodCount struct {
+	start time.Time
+	count int64
+}
+
+// localQuota is a Quota in the memory of the instance.
+type localQuota struct {
+	mu     sync.Mutex
+	counts map[string]*periodCount
+}
+
+// NewLocalQuota returns a quota counted in the memory of the instance, so that every
+// instance allows the quota of a key on its own.
+func NewLocalQuota() Quota {
+	return &localQuota{counts: make(map[string]*periodCount)}
+}
+
+func (q *localQuota) Incr(ctx context.Context, key *Key, start time.Time) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	c, ok := q.counts[key.ID]
+	if !ok || !c.start.Equal(start) {
+		c = &periodCount{start: start}
+		q.counts[key.ID] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// buckets enforce the rate of the keys by token buckets in the memory of the instance.
+type buckets struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newBuckets(now func() time.Time) *buckets {
+	return &buckets{now: now, buckets: make(map[string]*bucket)}
+}
+
+func (b *buckets) allow(key *Key) bool {
+	if key.Rate <= 0 {
+		return true
+	}
+	burst := math.Max(float64(key.Burst), 1)
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bk, ok := b.buckets[key.ID]
+	if !ok {
+		bk = &bucket{tokens: burst, last: now}
+		b.buckets[key.ID] = bk
+	}
+	if elapsed := now.Sub(bk.last); elapsed > 0 {
+		bk.tokens = math.Min(burst, bk.tokens+elapsed.Seconds()*key.Rate)
+		bk.last = now
+	}
+	if bk.tokens < 1 {
+		return false
+	}
+	bk.tokens--
+	return true
+}
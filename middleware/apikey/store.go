@@ -0,0 +1,126 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned by the stores when the API key is unknown.
+var ErrKeyNotFound = errors.New("apikey: key not found")
+
+// Key is an API key, which is identified by its id rather than its secret in the
+// limits, the metrics and the logs.
+type Key struct {
+	ID    string
+	Owner string
+	// Rate is the requests per second of the key, with the Burst, unlimited if zero.
+	Rate  float64
+	Burst int
+	// Quota is the requests per QuotaPeriod of the key, i.e. 10000 per 24h, the
+	// periods are aligned to the unix epoch, unlimited if zero.
+	Quota       int64
+	QuotaPeriod time.Duration
+	// ExpiresAt is when the key expires, never if zero.
+	ExpiresAt time.Time
+	Metadata  map[string]string
+}
+
+// Expired reports whether the key is expired at the time.
+func (k *Key) Expired(t time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !t.Before(k.ExpiresAt)
+}
+
+// Store is the key store of the API keys, such as a static config, Redis or a database.
+type Store interface {
+	// Lookup returns the key of the secret, or ErrKeyNotFound if the secret is unknown.
+	Lookup(ctx context.Context, secret string) (*Key, error)
+}
+
+// StoreFunc is an adapter of a function as a Store, i.e. a query of a database by the
+// Hash of the secret.
+type StoreFunc func(ctx context.Context, secret string) (*Key, error)
+
+// Lookup calls f(ctx, secret).
+func (f StoreFunc) Lookup(ctx context.Context, secret string) (*Key, error) {
+	return f(ctx, secret)
+}
+
+// Hash returns the hex SHA-256 hash of the secret, the stores are expected to keep the
+// hashes of the secrets rather than the secrets.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewStaticStore returns a store of the keys by their secrets, i.e. of the config.
+func NewStaticStore(keys map[string]*Key) Store {
+	hashed := make(map[string]*Key, len(keys))
+	for secret, key := range keys {
+		hashed[Hash(secret)] = key
+	}
+	return StoreFunc(func(ctx context.Context, secret string) (*Key, error) {
+		if key, ok := hashed[Hash(secret)]; ok {
+			return key, nil
+		}
+		return nil, ErrKeyNotFound
+	})
+}
+
+type cachedKey struct {
+	key    *Key
+	expiry time.Time
+}
+
+type cache struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	keys  map[string]cachedKey
+	swept time.Time
+}
+
+// NewCache returns a store that caches the keys of the store for the ttl, so that a
+// remote store is not looked up on every request. The unknown secrets and the failures
+// of the store are not cached, so the cache is bounded by the keys of the store.
+func NewCache(s Store, ttl time.Duration) Store {
+	return &cache{
+		store: s,
+		ttl:   ttl,
+		now:   time.Now,
+		keys:  make(map[string]cachedKey),
+	}
+}
+
+func (c *cache) Lookup(ctx context.Context, secret string) (*Key, error) {
+	hash := Hash(secret)
+	c.mu.Lock()
+	cached, ok := c.keys[hash]
+	c.mu.Unlock()
+	if ok && c.now().Before(cached.expiry) {
+		return cached.key, nil
+	}
+	key, err := c.store.Lookup(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the expired keys are evicted once per ttl.
+	if now.Sub(c.swept) > c.ttl {
+		for k, v := range c.keys {
+			if !now.Before(v.expiry) {
+				delete(c.keys, k)
+			}
+		}
+		c.swept = now
+	}
+	c.keys[hash] = cachedKey{key: key, expiry: now.Add(c.ttl)}
+	return key, nil
+}
@@ -0,0 +1,111 @@
+// Package caller stamps the outgoing calls with the name, version and environment of the
+// calling service as the metadata headers, so that the downstream services and their
+// access logs always know who is calling.
+package caller
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// The metadata headers of the caller, which are propagated by the metadata middleware.
+const (
+	ServiceHeader = "x-md-caller-service"
+	VersionHeader = "x-md-caller-version"
+	EnvHeader     = "x-md-caller-env"
+)
+
+// Caller is the calling service of a request.
+type Caller struct {
+	Service string
+	Version string
+	Env     string
+}
+
+// Option is caller option.
+type Option func(*Caller)
+
+// WithVersion with the service version of the caller.
+func WithVersion(version string) Option {
+	return func(c *Caller) {
+		c.Version = version
+	}
+}
+
+// WithEnv with the environment of the caller, i.e. prod.
+func WithEnv(env string) Option {
+	return func(c *Caller) {
+		c.Env = env
+	}
+}
+
+// Client is a client middleware that sends the caller headers of the service name, i.e.
+// caller.Client(info.Name(), caller.WithVersion(info.Version())), the headers of the
+// fields which are not set are not sent.
+func Client(service string, opts ...Option) middleware.Middleware {
+	caller := Caller{Service: service}
+	for _, o := range opts {
+		o(&caller)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				header := tr.RequestHeader()
+				for k, v := range map[string]string{
+					ServiceHeader: caller.Service,
+					VersionHeader: caller.Version,
+					EnvHeader:     caller.Env,
+				} {
+					if v != "" {
+						header.Set(k, v)
+					}
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Server is a server middleware that extracts the caller from the request headers.
+func Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				header := tr.RequestHeader()
+				c := Caller{
+					Service: header.Get(ServiceHeader),
+					Version: header.Get(VersionHeader),
+					Env:     header.Get(EnvHeader),
+				}
+				if c != (Caller{}) {
+					ctx = NewContext(ctx, c)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+type callerKey struct{}
+
+// NewContext returns a new Context that carries the caller.
+func NewContext(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// FromContext returns the caller stored in ctx by the server middleware, if any.
+func FromContext(ctx context.Context) (Caller, bool) {
+	c, ok := ctx.Value(callerKey{}).(Caller)
+	return c, ok
+}
+
+// Valuer returns a caller service valuer.
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		c, _ := FromContext(ctx)
+		return c.Service
+	}
+}
@@ -0,0 +1,79 @@
+package caller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return "" }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+func TestClient(t *testing.T) {
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	tests := []struct {
+		service string
+		opts    []Option
+		want    Caller
+	}{
+		{"helloworld", []Option{WithVersion("v1"), WithEnv("prod")}, Caller{"helloworld", "v1", "prod"}},
+		{"helloworld", nil, Caller{Service: "helloworld"}},
+		{"", nil, Caller{}},
+	}
+	for _, test := range tests {
+		tr := &mockTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+		Client(test.service, test.opts...)(h)(transport.NewClientContext(context.Background(), tr), nil)
+		got := Caller{
+			Service: tr.reqHeader.Get(ServiceHeader),
+			Version: tr.reqHeader.Get(VersionHeader),
+			Env:     tr.reqHeader.Get(EnvHeader),
+		}
+		if got != test.want {
+			t.Errorf("got %+v want %+v", got, test.want)
+		}
+		if test.want == (Caller{}) && len(tr.reqHeader) != 0 {
+			t.Errorf("unexpected headers: %v", tr.reqHeader)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	tr := &mockTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+	tr.reqHeader.Set(ServiceHeader, "helloworld")
+	tr.reqHeader.Set(VersionHeader, "v1")
+	var caller Caller
+	Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		caller, _ = FromContext(ctx)
+		return nil, nil
+	})(transport.NewServerContext(context.Background(), tr), nil)
+	if caller != (Caller{Service: "helloworld", Version: "v1"}) {
+		t.Errorf("unexpected caller: %+v", caller)
+	}
+	if v := Valuer()(NewContext(context.Background(), caller)); v != "helloworld" {
+		t.Errorf("got %v want helloworld", v)
+	}
+}
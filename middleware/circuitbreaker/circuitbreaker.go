@@ -0,0 +1,100 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/circuitbreaker"
+	"github.com/go-kratos/kratos/v2/circuitbreaker/sre"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// ErrNotAllowed is request failed due to circuit breaker triggered.
+var ErrNotAllowed = errors.Unavailable("CIRCUITBREAKER", "request failed due to circuit breaker triggered")
+
+// Option is circuit breaker option.
+type Option func(*options)
+
+// WithBreaker with the circuit breaker factory, a breaker is created for each operation.
+// The default is a sre breaker.
+func WithBreaker(fn func() circuitbreaker.CircuitBreaker) Option {
+	return func(o *options) {
+		o.breaker = fn
+	}
+}
+
+type options struct {
+	breaker func() circuitbreaker.CircuitBreaker
+}
+
+// group is the breakers keyed by operation.
+type group struct {
+	mu       sync.RWMutex
+	new      func() circuitbreaker.CircuitBreaker
+	breakers map[string]circuitbreaker.CircuitBreaker
+}
+
+func (g *group) get(key string) circuitbreaker.CircuitBreaker {
+	g.mu.RLock()
+	b, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok = g.breakers[key]; !ok {
+		b = g.new()
+		g.breakers[key] = b
+	}
+	return b
+}
+
+// Client is a client middleware that rejects requests while the circuit breaker
+// of the operation is open, the operation is the endpoint and operation of the transport.
+func Client(opts ...Option) middleware.Middleware {
+	options := options{
+		breaker: func() circuitbreaker.CircuitBreaker {
+			return sre.NewBreaker()
+		},
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	g := &group{
+		new:      options.breaker,
+		breakers: make(map[string]circuitbreaker.CircuitBreaker),
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var operation string
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				operation = tr.Endpoint() + tr.Operation()
+			}
+			breaker := g.get(operation)
+			if err := breaker.Allow(); err != nil {
+				// rejected requests are counted as failures to keep the breaker open.
+				breaker.MarkFailed()
+				return nil, ErrNotAllowed
+			}
+			reply, err := handler(ctx, req)
+			if err != nil && isServerError(err) {
+				breaker.MarkFailed()
+			} else {
+				breaker.MarkSuccess()
+			}
+			return reply, err
+		}
+	}
+}
+
+// isServerError reports whether the error is caused by the server, the client
+// errors such as invalid arguments do not trip the breaker.
+func isServerError(err error) bool {
+	return errors.IsUnknown(err) ||
+		errors.IsInternal(err) ||
+		errors.IsUnavailable(err) ||
+		errors.IsDeadlineExceeded(err)
+}
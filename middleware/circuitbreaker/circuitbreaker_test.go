@@ -0,0 +1,75 @@
+package circuitbreaker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/circuitbreaker"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type mockTransport struct {
+	endpoint  string
+	operation string
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *mockTransport) Endpoint() string                { return tr.endpoint }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return nil }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return nil }
+
+type testBreaker struct {
+	open             bool
+	success, failure int
+}
+
+func (b *testBreaker) Allow() error {
+	if b.open {
+		return circuitbreaker.ErrNotAllowed
+	}
+	return nil
+}
+
+func (b *testBreaker) MarkSuccess() { b.success++ }
+
+func (b *testBreaker) MarkFailed() { b.failure++ }
+
+func TestClient(t *testing.T) {
+	breakers := make([]*testBreaker, 0)
+	m := Client(WithBreaker(func() circuitbreaker.CircuitBreaker {
+		b := &testBreaker{}
+		breakers = append(breakers, b)
+		return b
+	}))
+	var handlerErr error
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", handlerErr
+	})
+	foo := transport.NewClientContext(context.Background(), &mockTransport{endpoint: "127.0.0.1:9000", operation: "/test.Service/Foo"})
+	bar := transport.NewClientContext(context.Background(), &mockTransport{endpoint: "127.0.0.1:9000", operation: "/test.Service/Bar"})
+
+	if _, err := h(foo, nil); err != nil {
+		t.Fatal(err)
+	}
+	handlerErr = errors.InvalidArgument("TEST", "invalid")
+	h(foo, nil)
+	handlerErr = errors.Unavailable("TEST", "unavailable")
+	h(foo, nil)
+	h(bar, nil)
+	if len(breakers) != 2 {
+		t.Fatalf("expected a breaker per operation, got %d", len(breakers))
+	}
+	if breakers[0].success != 2 || breakers[0].failure != 1 {
+		t.Errorf("got success=%d failure=%d want success=2 failure=1", breakers[0].success, breakers[0].failure)
+	}
+
+	breakers[0].open = true
+	if _, err := h(foo, nil); err != ErrNotAllowed {
+		t.Errorf("got %v want %v", err, ErrNotAllowed)
+	}
+	if breakers[0].failure != 2 {
+		t.Errorf("rejected request is not marked as failed")
+	}
+}
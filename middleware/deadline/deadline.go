@@ -0,0 +1,150 @@
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const (
+	// DefaultHeader is the header carries the remaining budget of the request.
+	DefaultHeader = "x-request-timeout"
+
+	grpcTimeoutHeader = "grpc-timeout"
+)
+
+// ErrDeadlineExceeded is returned when the budget of the request is exhausted.
+var ErrDeadlineExceeded = errors.DeadlineExceeded("DEADLINE", "request deadline exceeded")
+
+// Option is deadline option.
+type Option func(*options)
+
+// WithHeader with the header of the request budget, x-request-timeout by default.
+func WithHeader(key string) Option {
+	return func(o *options) {
+		o.header = key
+	}
+}
+
+type options struct {
+	header string
+}
+
+// Server is a server middleware that clamps the deadline of the request context to the
+// budget of the request header, or the grpc-timeout header. The budget is encoded the
+// same as grpc-timeout, i.e. 500m for 500 milliseconds.
+func Server(opts ...Option) middleware.Middleware {
+	options := options{
+		header: DefaultHeader,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				if d, ok := budget(tr.RequestHeader(), options.header); ok {
+					if d <= 0 {
+						return nil, ErrDeadlineExceeded
+					}
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, d)
+					defer cancel()
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Client is a client middleware that forwards the remaining budget of the context
+// deadline by the request header, so that the timeouts cascade across the hops.
+func Client(opts ...Option) middleware.Middleware {
+	options := options{
+		header: DefaultHeader,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if deadline, ok := ctx.Deadline(); ok {
+				d := time.Until(deadline)
+				if d <= 0 {
+					return nil, ErrDeadlineExceeded
+				}
+				if tr, ok := transport.FromClientContext(ctx); ok {
+					tr.RequestHeader().Set(options.header, encodeTimeout(d))
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+func budget(header transport.Header, key string) (time.Duration, bool) {
+	for _, k := range []string{key, grpcTimeoutHeader} {
+		if v := header.Get(k); v != "" {
+			if d, err := decodeTimeout(v); err == nil {
+				return d, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// encodeTimeout encodes the duration in the grpc-timeout format, which has at most 8 digits.
+func encodeTimeout(d time.Duration) string {
+	const maxTimeoutValue int64 = 100000000 - 1
+	units := []struct {
+		unit string
+		d    time.Duration
+	}{
+		{"n", time.Nanosecond},
+		{"u", time.Microsecond},
+		{"m", time.Millisecond},
+		{"S", time.Second},
+		{"M", time.Minute},
+	}
+	for _, u := range units {
+		// round up, so that the budget is not exhausted before the deadline.
+		if v := (int64(d) + int64(u.d) - 1) / int64(u.d); v <= maxTimeoutValue {
+			return strconv.FormatInt(v, 10) + u.unit
+		}
+	}
+	return strconv.FormatInt((int64(d)+int64(time.Hour)-1)/int64(time.Hour), 10) + "H"
+}
+
+// decodeTimeout decodes the duration in the grpc-timeout format.
+func decodeTimeout(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("deadline: invalid timeout %q", s)
+	}
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, fmt.Errorf("deadline: invalid timeout unit %q", s)
+	}
+	v, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || v < 0 || len(s) > 9 {
+		return 0, fmt.Errorf("deadline: invalid timeout %q", s)
+	}
+	return time.Duration(v) * unit, nil
+}
@@ -0,0 +1,144 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	header headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return "" }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestTimeoutCodec(t *testing.T) {
+	tests := []struct {
+		d time.Duration
+		s string
+	}{
+		{500 * time.Millisecond, "500000u"},
+		{time.Millisecond, "1000000n"},
+		{time.Second, "1000000u"},
+		{200 * time.Second, "200000m"},
+		{100000 * time.Second, "100000S"},
+	}
+	for _, test := range tests {
+		if s := encodeTimeout(test.d); s != test.s {
+			t.Errorf("encode %v: got %s want %s", test.d, s, test.s)
+		}
+		if d, err := decodeTimeout(test.s); err != nil || d != test.d {
+			t.Errorf("decode %s: got %v %v want %v", test.s, d, err, test.d)
+		}
+	}
+	for _, s := range []string{"", "1", "1x", "-1m", "123456789m"} {
+		if _, err := decodeTimeout(s); err == nil {
+			t.Errorf("decode %q: expected an error", s)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return nil, nil
+		}
+		return time.Until(deadline), nil
+	}
+	tests := []struct {
+		header  string
+		value   string
+		timeout time.Duration
+	}{
+		{DefaultHeader, "200m", 200 * time.Millisecond},
+		{"grpc-timeout", "300m", 300 * time.Millisecond},
+		{DefaultHeader, "invalid", 0},
+		{"", "", 0},
+	}
+	for _, test := range tests {
+		header := headerCarrier{}
+		if test.header != "" {
+			header.Set(test.header, test.value)
+		}
+		ctx := transport.NewServerContext(context.Background(), &mockTransport{header: header})
+		reply, err := Server()(next)(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.timeout == 0 {
+			if reply != nil {
+				t.Errorf("%s %s: unexpected deadline %v", test.header, test.value, reply)
+			}
+			continue
+		}
+		if d, _ := reply.(time.Duration); d > test.timeout || d < test.timeout-50*time.Millisecond {
+			t.Errorf("%s %s: got timeout %v want %v", test.header, test.value, d, test.timeout)
+		}
+	}
+
+	// the budget does not extend the existing deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ctx = transport.NewServerContext(ctx, &mockTransport{header: headerCarrier{"X-Request-Timeout": {"10S"}}})
+	reply, _ := Server()(next)(ctx, nil)
+	if d, _ := reply.(time.Duration); d > 100*time.Millisecond {
+		t.Errorf("got timeout %v want at most 100ms", d)
+	}
+
+	// the exhausted budget is rejected
+	ctx = transport.NewServerContext(context.Background(), &mockTransport{header: headerCarrier{"X-Request-Timeout": {"0m"}}})
+	if _, err := Server()(next)(ctx, nil); !errors.IsDeadlineExceeded(err) {
+		t.Errorf("got %v want deadline exceeded", err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	header := headerCarrier{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = transport.NewClientContext(ctx, &mockTransport{header: header})
+	if _, err := Client(WithHeader("x-budget"))(next)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	d, err := decodeTimeout(header.Get("x-budget"))
+	if err != nil || d > time.Second || d < 900*time.Millisecond {
+		t.Errorf("got budget %v %v want about 1s", d, err)
+	}
+
+	// no deadline, no budget
+	header = headerCarrier{}
+	ctx = transport.NewClientContext(context.Background(), &mockTransport{header: header})
+	if _, err := Client()(next)(ctx, nil); err != nil || header.Get(DefaultHeader) != "" {
+		t.Errorf("unexpected budget %q %v", header.Get(DefaultHeader), err)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := Client()(next)(ctx, nil); !errors.IsDeadlineExceeded(err) {
+		t.Errorf("got %v want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,225 @@
+// Package dump provides the debugging middleware which dumps the serialized requests
+// and replies, the dumped operations can be switched on and off at runtime by the config.
+package dump
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/internal/matcher"
+	"github.com/go-kratos/kratos/v2/internal/reload"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	// init json encoding
+	_ "github.com/go-kratos/kratos/v2/encoding/json"
+)
+
+// Record is a dumped request.
+type Record struct {
+	// Kind is server or client.
+	Kind      string
+	Operation string
+	// Request and Reply are the serialized messages, which are truncated to the max size.
+	Request []byte
+	Reply   []byte
+	Err     error
+}
+
+// Sink receives the dumped records.
+type Sink interface {
+	Dump(ctx context.Context, r *Record)
+}
+
+// SinkFunc is a func sink.
+type SinkFunc func(ctx context.Context, r *Record)
+
+// Dump calls f(ctx, r).
+func (f SinkFunc) Dump(ctx context.Context, r *Record) {
+	f(ctx, r)
+}
+
+// Option is dump option.
+type Option func(*options)
+
+type options struct {
+	sink    Sink
+	codec   encoding.Codec
+	maxSize int
+	sw      *Switch
+	logger  log.Logger
+}
+
+// WithSink with the sink of the records, which logs them at DEBUG by default.
+func WithSink(s Sink) Option {
+	return func(o *options) {
+		o.sink = s
+	}
+}
+
+// WithLogger with the logger of the default sink.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithCodec with the codec serializing the messages, json by default.
+func WithCodec(c encoding.Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithMaxSize with the max bytes of the dumped message, 4KB by default.
+func WithMaxSize(n int) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// WithSwitch with the switch of the dumped operations, all the operations are
+// dumped if it is not set.
+func WithSwitch(s *Switch) Option {
+	return func(o *options) {
+		o.sw = s
+	}
+}
+
+// Server is a server middleware which dumps the requests and replies.
+func Server(opts ...Option) middleware.Middleware {
+	return newMiddleware("server", opts)
+}
+
+// Client is a client middleware which dumps the requests and replies.
+func Client(opts ...Option) middleware.Middleware {
+	return newMiddleware("client", opts)
+}
+
+func newMiddleware(kind string, opts []Option) middleware.Middleware {
+	options := options{
+		codec:   encoding.GetCodec("json"),
+		maxSize: 4 << 10,
+		logger:  log.GetLogger(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.sink == nil {
+		options.sink = logSink(log.NewHelper("middleware/dump", options.logger))
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var (
+				tr transport.Transporter
+				ok bool
+			)
+			if kind == "server" {
+				tr, ok = transport.FromServerContext(ctx)
+			} else {
+				tr, ok = transport.FromClientContext(ctx)
+			}
+			if !ok || (options.sw != nil && !options.sw.Enabled(tr.Operation())) {
+				return handler(ctx, req)
+			}
+			reply, err := handler(ctx, req)
+			options.sink.Dump(ctx, &Record{
+				Kind:      kind,
+				Operation: tr.Operation(),
+				Request:   options.marshal(req),
+				Reply:     options.marshal(reply),
+				Err:       err,
+			})
+			return reply, err
+		}
+	}
+}
+
+func (o *options) marshal(v interface{}) []byte {
+	if v == nil {
+		return nil
+	}
+	data, err := o.codec.Marshal(v)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%+v", v))
+	}
+	if len(data) > o.maxSize {
+		data = data[:o.maxSize]
+	}
+	return data
+}
+
+func logSink(log *log.Helper) Sink {
+	return SinkFunc(func(ctx context.Context, r *Record) {
+		kvs := []interface{}{
+			"kind", r.Kind,
+			"operation", r.Operation,
+			"request", string(r.Request),
+			"reply", string(r.Reply),
+		}
+		if r.Err != nil {
+			kvs = append(kvs, "error", r.Err.Error())
+		}
+		log.WithContext(ctx).Debugw(kvs...)
+	})
+}
+
+// Switch maps the operations to whether they are dumped. An operation ends with "*"
+// matches the operations of the prefix, i.e. /helloworld.Greeter/*, and the longest
+// one wins.
+type Switch struct {
+	mu      sync.RWMutex
+	flags   map[string]bool
+	matcher *matcher.Matcher
+}
+
+// NewSwitch returns a switch of the operations.
+func NewSwitch(flags map[string]bool) *Switch {
+	s := &Switch{}
+	s.Update(flags)
+	return s
+}
+
+// Update replaces the flags of the switch.
+func (s *Switch) Update(flags map[string]bool) {
+	m := make(map[string]bool, len(flags))
+	patterns := make([]string, 0, len(flags))
+	for k, v := range flags {
+		m[k] = v
+		patterns = append(patterns, k)
+	}
+	s.mu.Lock()
+	s.flags, s.matcher = m, matcher.New(patterns...)
+	s.mu.Unlock()
+}
+
+// Enabled reports whether the operation is dumped, false if no entry matches.
+func (s *Switch) Enabled(operation string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.matcher.Match(operation)
+	return ok && s.flags[k]
+}
+
+// Load returns a switch loaded from the config key, which is a map of the operations
+// to the flags, i.e. {"/helloworld.Greeter/*": true}, and reloads it whenever the key
+// changes.
+func Load(c config.Config, key string) (*Switch, error) {
+	s := NewSwitch(nil)
+	err := reload.Load(c, key, func(v config.Value) error {
+		var flags map[string]bool
+		if err := v.Scan(&flags); err != nil {
+			return err
+		}
+		s.Update(flags)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
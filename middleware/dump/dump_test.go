@@ -0,0 +1,142 @@
+package dump
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type mockTransport struct {
+	kind      transport.Kind
+	operation string
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return nil }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return nil }
+
+func serverCtx(operation string) context.Context {
+	return transport.NewServerContext(context.Background(), &mockTransport{kind: transport.KindGRPC, operation: operation})
+}
+
+type message struct {
+	Name string `json:"name"`
+}
+
+func TestServer(t *testing.T) {
+	var records []*Record
+	sink := SinkFunc(func(ctx context.Context, r *Record) {
+		records = append(records, r)
+	})
+	sw := NewSwitch(map[string]bool{
+		"/helloworld.Greeter/*":       true,
+		"/helloworld.Greeter/Private": false,
+	})
+	m := Server(WithSink(sink), WithSwitch(sw), WithMaxSize(17))
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if req.(*message).Name == "" {
+			return nil, errors.InvalidArgument("EMPTY_NAME", "empty name")
+		}
+		return &message{Name: "hello " + req.(*message).Name}, nil
+	})
+
+	h(serverCtx("/helloworld.Greeter/SayHello"), &message{Name: "kratos"})
+	h(serverCtx("/helloworld.Greeter/SayHello"), &message{})
+	h(serverCtx("/helloworld.Greeter/Private"), &message{Name: "kratos"})
+	h(serverCtx("/helloworld.Other/SayHello"), &message{Name: "kratos"})
+	if len(records) != 2 {
+		t.Fatalf("got %d records want 2", len(records))
+	}
+	r := records[0]
+	if r.Kind != "server" || r.Operation != "/helloworld.Greeter/SayHello" ||
+		string(r.Request) != `{"name":"kratos"}` || string(r.Reply) != `{"name":"hello kr` {
+		t.Errorf("unexpected record: %s %s %s %s", r.Kind, r.Operation, r.Request, r.Reply)
+	}
+	if r := records[1]; r.Reply != nil || !errors.IsInvalidArgument(r.Err) {
+		t.Errorf("unexpected record: %s %v", r.Reply, r.Err)
+	}
+}
+
+func TestClientWithoutSwitch(t *testing.T) {
+	var records []*Record
+	m := Client(WithSink(SinkFunc(func(ctx context.Context, r *Record) {
+		records = append(records, r)
+	})))
+	var h middleware.Handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	ctx := transport.NewClientContext(context.Background(), &mockTransport{kind: transport.KindHTTP, operation: "/v1/users"})
+	m(h)(ctx, &message{Name: "kratos"})
+	// the requests without the transport are not dumped.
+	m(h)(context.Background(), &message{Name: "kratos"})
+	if len(records) != 1 || records[0].Kind != "client" || records[0].Operation != "/v1/users" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+}
+
+type testSource struct {
+	kvs chan []*config.KeyValue
+}
+
+func (s *testSource) Load() ([]*config.KeyValue, error) {
+	return <-s.kvs, nil
+}
+
+func (s *testSource) Watch() (config.Watcher, error) {
+	return s, nil
+}
+
+func (s *testSource) Next() ([]*config.KeyValue, error) {
+	kvs, ok := <-s.kvs
+	if !ok {
+		return nil, context.Canceled
+	}
+	return kvs, nil
+}
+
+func (s *testSource) Close() error {
+	return nil
+}
+
+func flags(text string) []*config.KeyValue {
+	return []*config.KeyValue{{
+		Key:    "debug",
+		Value:  []byte(`{"debug":{"dump":` + text + `}}`),
+		Format: "json",
+	}}
+}
+
+func TestLoad(t *testing.T) {
+	source := &testSource{kvs: make(chan []*config.KeyValue, 1)}
+	source.kvs <- flags(`{"/helloworld.Greeter/*":true}`)
+	c := config.New(config.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	sw, err := Load(c, "debug.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sw.Enabled("/helloworld.Greeter/SayHello") {
+		t.Fatal("expected the operation is enabled")
+	}
+
+	// switch off the operations from the config
+	source.kvs <- flags(`{"/helloworld.Greeter/*":false}`)
+	deadline := time.Now().Add(time.Second)
+	for sw.Enabled("/helloworld.Greeter/SayHello") {
+		if time.Now().After(deadline) {
+			t.Fatal("the switch is not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
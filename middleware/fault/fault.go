@@ -0,0 +1,205 @@
+// Package fault provides the chaos testing middleware which injects the latency and
+// the errors into a percentage of the requests of the operations, the rules can be
+// reloaded from the config at runtime to validate the resilience in staging.
+package fault
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/matcher"
+	"github.com/go-kratos/kratos/v2/internal/reload"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Reason is the default reason of the injected errors.
+const Reason = "FAULT_INJECTED"
+
+// Delay injects the latency before the request is handled.
+type Delay struct {
+	Duration time.Duration
+	// Percentage of the delayed requests, from 0 to 100.
+	Percentage float64
+}
+
+// Abort fails the request with the error of the code, the reason and the message.
+type Abort struct {
+	Code    int32
+	Reason  string
+	Message string
+	// Percentage of the failed requests, from 0 to 100.
+	Percentage float64
+}
+
+func (a *Abort) err() error {
+	reason := a.Reason
+	if reason == "" {
+		reason = Reason
+	}
+	return errors.Error(a.Code, reason, a.Message)
+}
+
+// Rule is the faults injected into the requests of an operation.
+type Rule struct {
+	Delay *Delay
+	// Abort fails the request before it is handled.
+	Abort *Abort
+	// Error fails the request after it is handled, as if the reply was lost.
+	Error *Abort
+}
+
+// Rules maps the operations to their rules. An operation ends with "*" matches the
+// operations of the prefix, i.e. /helloworld.Greeter/*, and the longest one wins.
+type Rules struct {
+	mu      sync.RWMutex
+	rules   map[string]Rule
+	matcher *matcher.Matcher
+}
+
+// NewRules returns the rules of the operations.
+func NewRules(rules map[string]Rule) *Rules {
+	r := &Rules{}
+	r.Update(rules)
+	return r
+}
+
+// Update replaces the rules of the operations.
+func (r *Rules) Update(rules map[string]Rule) {
+	m := make(map[string]Rule, len(rules))
+	patterns := make([]string, 0, len(rules))
+	for k, v := range rules {
+		m[k] = v
+		patterns = append(patterns, k)
+	}
+	r.mu.Lock()
+	r.rules, r.matcher = m, matcher.New(patterns...)
+	r.mu.Unlock()
+}
+
+// Lookup returns the rule of the operation, it reports false if no entry matches.
+func (r *Rules) Lookup(operation string) (Rule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if k, ok := r.matcher.Match(operation); ok {
+		return r.rules[k], true
+	}
+	return Rule{}, false
+}
+
+// Load returns the rules loaded from the config key, and reloads them whenever the
+// key changes. The key is a map of the operations to the rules, i.e.
+//
+//	{"/helloworld.Greeter/*": {
+//		"delay": {"duration": "100ms", "percentage": 10},
+//		"abort": {"code": 14, "message": "chaos", "percentage": 5}
+//	}}
+//
+// A fault is stopped by a zero percentage.
+func Load(c config.Config, key string) (*Rules, error) {
+	r := NewRules(nil)
+	err := reload.Load(c, key, func(v config.Value) error {
+		rules, err := scan(v)
+		if err == nil {
+			r.Update(rules)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+type delayConfig struct {
+	Duration   string  `json:"duration"`
+	Percentage float64 `json:"percentage"`
+}
+
+type abortConfig struct {
+	Code       int32   `json:"code"`
+	Reason     string  `json:"reason"`
+	Message    string  `json:"message"`
+	Percentage float64 `json:"percentage"`
+}
+
+type ruleConfig struct {
+	Delay *delayConfig `json:"delay"`
+	Abort *abortConfig `json:"abort"`
+	Error *abortConfig `json:"error"`
+}
+
+func scan(v config.Value) (map[string]Rule, error) {
+	var m map[string]ruleConfig
+	if err := v.Scan(&m); err != nil {
+		return nil, err
+	}
+	rules := make(map[string]Rule, len(m))
+	for k, c := range m {
+		var rule Rule
+		if c.Delay != nil {
+			d, err := time.ParseDuration(c.Delay.Duration)
+			if err != nil {
+				return nil, err
+			}
+			rule.Delay = &Delay{Duration: d, Percentage: c.Delay.Percentage}
+		}
+		if c.Abort != nil {
+			rule.Abort = (*Abort)(c.Abort)
+		}
+		if c.Error != nil {
+			rule.Error = (*Abort)(c.Error)
+		}
+		rules[k] = rule
+	}
+	return rules, nil
+}
+
+// Server is a server middleware that injects the faults of the rules.
+func Server(rules *Rules) middleware.Middleware {
+	return newMiddleware(rules, transport.FromServerContext, rand.Float64)
+}
+
+// Client is a client middleware that injects the faults of the rules.
+func Client(rules *Rules) middleware.Middleware {
+	return newMiddleware(rules, transport.FromClientContext, rand.Float64)
+}
+
+func newMiddleware(rules *Rules, from func(context.Context) (transport.Transporter, bool), random func() float64) middleware.Middleware {
+	hit := func(percentage float64) bool {
+		return percentage > 0 && random()*100 < percentage
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := from(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			rule, ok := rules.Lookup(tr.Operation())
+			if !ok {
+				return handler(ctx, req)
+			}
+			if rule.Delay != nil && hit(rule.Delay.Percentage) {
+				t := time.NewTimer(rule.Delay.Duration)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return nil, ctx.Err()
+				}
+			}
+			if rule.Abort != nil && hit(rule.Abort.Percentage) {
+				return nil, rule.Abort.err()
+			}
+			reply, err := handler(ctx, req)
+			if err == nil && rule.Error != nil && hit(rule.Error.Percentage) {
+				return nil, rule.Error.err()
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package fault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type mockTransport struct {
+	operation string
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return nil }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return nil }
+
+func serverCtx(operation string) context.Context {
+	return transport.NewServerContext(context.Background(), &mockTransport{operation: operation})
+}
+
+func TestServer(t *testing.T) {
+	rules := NewRules(map[string]Rule{
+		"/test.Service/Slow":  {Delay: &Delay{Duration: 50 * time.Millisecond, Percentage: 100}},
+		"/test.Service/Abort": {Abort: &Abort{Code: 14, Message: "chaos", Percentage: 50}},
+		"/test.Service/Lost":  {Error: &Abort{Code: 4, Reason: "LOST", Percentage: 100}},
+	})
+	// the random numbers of the requests, which are hit below the percentage.
+	random := 0.2
+	m := newMiddleware(rules, transport.FromServerContext, func() float64 { return random })
+	var handled int
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		handled++
+		return "reply", nil
+	})
+
+	start := time.Now()
+	if reply, err := h(serverCtx("/test.Service/Slow"), nil); reply != "reply" || err != nil {
+		t.Errorf("got %v %v", reply, err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("got latency %s want the injected delay", d)
+	}
+
+	_, err := h(serverCtx("/test.Service/Abort"), nil)
+	if errors.Code(err) != 14 || errors.Reason(err) != Reason || handled != 1 {
+		t.Errorf("got %v handled %d, want the request aborted", err, handled)
+	}
+	random = 0.6
+	if _, err := h(serverCtx("/test.Service/Abort"), nil); err != nil || handled != 2 {
+		t.Errorf("got %v handled %d, want the request passed", err, handled)
+	}
+
+	reply, err := h(serverCtx("/test.Service/Lost"), nil)
+	if reply != nil || errors.Reason(err) != "LOST" || handled != 3 {
+		t.Errorf("got %v %v handled %d, want the reply lost", reply, err, handled)
+	}
+
+	if _, err := h(serverCtx("/test.Other/Method"), nil); err != nil {
+		t.Errorf("got %v want no fault", err)
+	}
+}
+
+func TestDelayCanceled(t *testing.T) {
+	rules := NewRules(map[string]Rule{
+		"*": {Delay: &Delay{Duration: time.Hour, Percentage: 100}},
+	})
+	ctx, cancel := context.WithTimeout(serverCtx("/test.Service/Method"), 10*time.Millisecond)
+	defer cancel()
+	_, err := Server(rules)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})(ctx, nil)
+	if err != context.DeadlineExceeded {
+		t.Errorf("got %v want %v", err, context.DeadlineExceeded)
+	}
+}
+
+type testSource struct {
+	kvs chan []*config.KeyValue
+}
+
+func (s *testSource) Load() ([]*config.KeyValue, error) {
+	return <-s.kvs, nil
+}
+
+func (s *testSource) Watch() (config.Watcher, error) {
+	return s, nil
+}
+
+func (s *testSource) Next() ([]*config.KeyValue, error) {
+	kvs, ok := <-s.kvs
+	if !ok {
+		return nil, context.Canceled
+	}
+	return kvs, nil
+}
+
+func (s *testSource) Close() error {
+	return nil
+}
+
+func faults(text string) []*config.KeyValue {
+	return []*config.KeyValue{{
+		Key:    "chaos",
+		Value:  []byte(`{"chaos":{"faults":` + text + `}}`),
+		Format: "json",
+	}}
+}
+
+func TestLoad(t *testing.T) {
+	source := &testSource{kvs: make(chan []*config.KeyValue, 1)}
+	source.kvs <- faults(`{"/helloworld.Greeter/*":{"delay":{"duration":"100ms","percentage":10},"abort":{"code":14,"percentage":5}}}`)
+	c := config.New(config.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	rules, err := Load(c, "chaos.faults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, ok := rules.Lookup("/helloworld.Greeter/SayHello")
+	if !ok || rule.Delay.Duration != 100*time.Millisecond || rule.Delay.Percentage != 10 ||
+		rule.Abort.Code != 14 || rule.Abort.Percentage != 5 || rule.Error != nil {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	// stop the aborts from the config
+	source.kvs <- faults(`{"/helloworld.Greeter/*":{"abort":{"code":14,"percentage":0}}}`)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if rule, _ := rules.Lookup("/helloworld.Greeter/SayHello"); rule.Abort.Percentage == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the rules are not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,331 @@
+// Package hedging provides the client middleware of the hedged requests, a slow call is
+// sent again to another node after a delay and the first reply wins, which cuts the
+// tail latency of the idempotent calls at the cost of a bounded extra load.
+package hedging
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/window"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Option is hedging option.
+type Option func(*options)
+
+// WithAttempts with the max number of attempts, including the first call, 2 by default.
+func WithAttempts(n int) Option {
+	return func(o *options) {
+		o.attempts = n
+	}
+}
+
+// WithDelay with the delay before the hedged attempt, which is used until the latency
+// percentile is known, 100ms by default.
+func WithDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.delay = d
+	}
+}
+
+// WithPercentile with the latency percentile of the operation as the delay, 95 by
+// default, zero always uses the delay of WithDelay.
+func WithPercentile(p float64) Option {
+	return func(o *options) {
+		o.percentile = p
+	}
+}
+
+// WithCodes with the error codes of the attempts which do not win while the other
+// attempts may succeed, Unavailable by default.
+func WithCodes(codes ...int32) Option {
+	return func(o *options) {
+		o.codes = codes
+	}
+}
+
+// WithBudget with the hedging budget, nil disables the budget.
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithIdempotent with the func reports whether the call is idempotent, only the
+// idempotent calls are hedged, so no call is hedged without it.
+func WithIdempotent(fn func(ctx context.Context, req interface{}) bool) Option {
+	return func(o *options) {
+		o.idempotent = fn
+	}
+}
+
+type options struct {
+	attempts   int
+	delay      time.Duration
+	percentile float64
+	codes      []int32
+	budget     *Budget
+	idempotent func(ctx context.Context, req interface{}) bool
+}
+
+func (o *options) retryable(err error) bool {
+	code := errors.Code(err)
+	for _, c := range o.codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Budget limits the hedged attempts to a ratio of the requests in a 10 seconds window,
+// so that the hedging cannot amplify the load of a slow service.
+type Budget struct {
+	ratio    float64
+	min      int64
+	requests *window.Window
+	hedges   *window.Window
+}
+
+// NewBudget returns a hedging budget which allows the hedged attempts up to ratio of the
+// requests, plus min attempts in the window to allow hedging with low traffic.
+func NewBudget(ratio float64, min int64) *Budget {
+	return &Budget{
+		ratio:    ratio,
+		min:      min,
+		requests: window.New(10, time.Second),
+		hedges:   window.New(10, time.Second),
+	}
+}
+
+func sum(w *window.Window) (n int64) {
+	w.Reduce(func(b window.Bucket) {
+		n += b.Count
+	})
+	return
+}
+
+func (b *Budget) request() {
+	b.requests.Add(1)
+}
+
+// hedge withdraws a hedged attempt from the budget, it reports false if the budget is exhausted.
+func (b *Budget) hedge() bool {
+	if float64(sum(b.hedges)) >= b.ratio*float64(sum(b.requests))+float64(b.min) {
+		return false
+	}
+	b.hedges.Add(1)
+	return true
+}
+
+type result struct {
+	reply interface{}
+	err   error
+}
+
+// Client is a client middleware that hedges the slow idempotent calls reported by
+// WithIdempotent, it should be the last client middleware so that only the transport
+// call is repeated. The hedged attempts are sent to the nodes which are not picked by
+// the previous attempts if possible, and the attempts which lose are canceled.
+func Client(opts ...Option) middleware.Middleware {
+	options := options{
+		attempts:   2,
+		delay:      100 * time.Millisecond,
+		percentile: 95,
+		// Unavailable
+		codes:  []int32{14},
+		budget: NewBudget(0.1, 10),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	stats := &latencies{ops: make(map[string]*latency)}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if options.attempts <= 1 || options.idempotent == nil || !options.idempotent(ctx, req) {
+				return handler(ctx, req)
+			}
+			if options.budget != nil {
+				options.budget.request()
+			}
+			var operation string
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				operation = tr.Operation()
+			}
+			stat := stats.get(operation)
+			delay := options.delay
+			if options.percentile > 0 {
+				if d, ok := stat.percentile(options.percentile); ok {
+					delay = d
+				}
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			var (
+				start   = time.Now()
+				results = make(chan result, options.attempts)
+				peers   []*selector.Peer
+			)
+			launch := func() {
+				prev := append([]*selector.Peer(nil), peers...)
+				p := &selector.Peer{}
+				peers = append(peers, p)
+				actx := selector.NewPeerContext(ctx, p)
+				if len(prev) > 0 {
+					actx = selector.NewFilterContext(actx, exclude(prev))
+				}
+				go func() {
+					reply, err := handler(actx, req)
+					results <- result{reply, err}
+				}()
+			}
+			launch()
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			var (
+				last    result
+				pending = 1
+			)
+			for {
+				select {
+				case r := <-results:
+					pending--
+					if r.err == nil || !options.retryable(r.err) ||
+						(pending == 0 && !hedgeable(&options, len(peers))) {
+						if r.err == nil {
+							stat.add(time.Since(start))
+						}
+						return r.reply, r.err
+					}
+					last = r
+					if pending == 0 {
+						// the failed attempt is hedged at once.
+						launch()
+						pending++
+						if !timer.Stop() {
+							select {
+							case <-timer.C:
+							default:
+							}
+						}
+						timer.Reset(delay)
+					}
+				case <-timer.C:
+					if !hedgeable(&options, len(peers)) {
+						continue
+					}
+					launch()
+					pending++
+					timer.Reset(delay)
+				case <-ctx.Done():
+					if last.err != nil {
+						return last.reply, last.err
+					}
+					return nil, ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// hedgeable reports whether another attempt is allowed after the attempts.
+func hedgeable(o *options, attempts int) bool {
+	return attempts < o.attempts && (o.budget == nil || o.budget.hedge())
+}
+
+// exclude returns a filter of the nodes which are not picked by the peers, all the
+// nodes are returned if none is left.
+func exclude(peers []*selector.Peer) selector.Filter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		picked := make(map[string]bool, len(peers))
+		for _, p := range peers {
+			if n := p.Node(); n != nil {
+				picked[n.Address()] = true
+			}
+		}
+		filtered := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if !picked[n.Address()] {
+				filtered = append(filtered, n)
+			}
+		}
+		if len(filtered) == 0 {
+			return nodes
+		}
+		return filtered
+	}
+}
+
+// minSamples is the min latency samples of an operation to compute the percentile.
+const minSamples = 100
+
+type latencies struct {
+	mu  sync.Mutex
+	ops map[string]*latency
+}
+
+func (l *latencies) get(operation string) *latency {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.ops[operation]
+	if !ok {
+		s = &latency{}
+		l.ops[operation] = s
+	}
+	return s
+}
+
+// latency keeps the recent latencies of an operation in a ring.
+type latency struct {
+	mu      sync.Mutex
+	samples [1024]time.Duration
+	n       int
+	// the percentiles which are computed since the latest samples.
+	cache map[float64]time.Duration
+}
+
+func (l *latency) add(d time.Duration) {
+	l.mu.Lock()
+	l.samples[l.n%len(l.samples)] = d
+	l.n++
+	if l.n%minSamples == 0 {
+		l.cache = nil
+	}
+	l.mu.Unlock()
+}
+
+func (l *latency) percentile(p float64) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.n < minSamples {
+		return 0, false
+	}
+	if d, ok := l.cache[p]; ok {
+		return d, true
+	}
+	n := l.n
+	if n > len(l.samples) {
+		n = len(l.samples)
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, l.samples[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(float64(n)*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= n {
+		i = n - 1
+	}
+	if l.cache == nil {
+		l.cache = make(map[float64]time.Duration)
+	}
+	l.cache[p] = sorted[i]
+	return sorted[i], true
+}
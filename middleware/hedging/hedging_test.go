@@ -0,0 +1,132 @@
+package hedging
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+var nodes = []selector.Node{
+	selector.NewNode("127.0.0.1:8001", &registry.ServiceInstance{}),
+	selector.NewNode("127.0.0.1:8002", &registry.ServiceInstance{}),
+}
+
+func idempotent(ctx context.Context, req interface{}) bool { return true }
+
+// pick picks the first node of the filters as the selector of the transport.
+func pick(ctx context.Context) string {
+	candidates := nodes
+	for _, f := range selector.FromFilterContext(ctx) {
+		candidates = f(ctx, candidates)
+	}
+	selector.Picked(ctx, candidates[0])
+	return candidates[0].Address()
+}
+
+func TestClient(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		picked   []string
+		canceled int32
+	)
+	h := Client(WithDelay(20*time.Millisecond), WithBudget(nil), WithIdempotent(idempotent))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		addr := pick(ctx)
+		mu.Lock()
+		picked = append(picked, addr)
+		mu.Unlock()
+		if addr == "127.0.0.1:8001" {
+			select {
+			case <-ctx.Done():
+				atomic.AddInt32(&canceled, 1)
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				return "slow", nil
+			}
+		}
+		return "fast", nil
+	})
+	start := time.Now()
+	reply, err := h(context.Background(), nil)
+	if reply != "fast" || err != nil {
+		t.Fatalf("got %v %v want the hedged reply", reply, err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("got latency %s want the latency of the hedged attempt", d)
+	}
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(picked) != 2 || picked[0] == picked[1] {
+		t.Errorf("got the nodes %v want two different nodes", picked)
+	}
+	if atomic.LoadInt32(&canceled) != 1 {
+		t.Error("the losing attempt is not canceled")
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	var calls int32
+	h := Client(WithDelay(time.Hour), WithAttempts(3), WithBudget(nil), WithIdempotent(idempotent))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return nil, errors.Unavailable("UNAVAILABLE", "node is down")
+		}
+		return "reply", nil
+	})
+	// the unavailable attempts are hedged at once.
+	if reply, err := h(context.Background(), nil); reply != "reply" || err != nil {
+		t.Errorf("got %v %v", reply, err)
+	}
+
+	calls = 0
+	h = Client(WithDelay(time.Hour), WithBudget(nil), WithIdempotent(idempotent))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.NotFound("NOT_FOUND", "not found")
+	})
+	if _, err := h(context.Background(), nil); !errors.IsNotFound(err) || calls != 1 {
+		t.Errorf("got %v after %d calls want the error of the first call", err, calls)
+	}
+}
+
+func TestClientBudget(t *testing.T) {
+	var calls int32
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "reply", nil
+	}
+	h := Client(WithDelay(time.Millisecond), WithBudget(NewBudget(0, 0)), WithIdempotent(idempotent))(handler)
+	if _, err := h(context.Background(), nil); err != nil || calls != 1 {
+		t.Errorf("got %v after %d calls want no hedged attempt", err, calls)
+	}
+	for _, opts := range [][]Option{
+		{WithDelay(time.Millisecond)},
+		{WithDelay(time.Millisecond), WithIdempotent(func(ctx context.Context, req interface{}) bool { return false })},
+	} {
+		calls = 0
+		if _, err := Client(opts...)(handler)(context.Background(), nil); err != nil || calls != 1 {
+			t.Errorf("got %v after %d calls want no hedged attempt", err, calls)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	l := &latency{}
+	if _, ok := l.percentile(95); ok {
+		t.Fatal("want no percentile without the samples")
+	}
+	for i := 1; i <= 100; i++ {
+		l.add(time.Duration(i) * time.Millisecond)
+	}
+	if d, ok := l.percentile(95); !ok || d != 95*time.Millisecond {
+		t.Errorf("got %s want 95ms", d)
+	}
+	if d, _ := l.percentile(50); d != 50*time.Millisecond {
+		t.Errorf("got %s want 50ms", d)
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// node is the static description of a middleware recorded by building the chain around
+// a probe, the handlers of the chain are never invoked by the inspection.
+type node struct {
+	name string
+	// match and inner describe a selected middleware, inner applies if match reports true.
+	match func(ctx context.Context) bool
+	inner *node
+	next  *node
+}
+
+// serve is the probe handler of the node, it returns the node to the inspection.
+func (n *node) serve(context.Context, interface{}) (interface{}, error) {
+	return n, nil
+}
+
+// probeCode is the code of the probe handlers, which are the method values of node.serve.
+var probeCode = reflect.ValueOf((&node{}).serve).Pointer()
+
+// probeOf returns the node of a probe handler.
+func probeOf(h Handler) (*node, bool) {
+	if h == nil || reflect.ValueOf(h).Pointer() != probeCode {
+		return nil, false
+	}
+	n, _ := h(context.Background(), nil)
+	return n.(*node), true
+}
+
+// probe builds the middleware around the probe next, the middleware that are not aware
+// of the inspection are described by their function names.
+func probe(m Middleware, next *node) Handler {
+	if h := m(next.serve); h != nil {
+		if _, ok := probeOf(h); ok {
+			return h
+		}
+	}
+	return (&node{name: funcName(m), next: next}).serve
+}
+
+func funcName(m Middleware) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(m).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// Named returns the middleware with the name reported by Inspect.
+func Named(name string, m Middleware) Middleware {
+	return func(next Handler) Handler {
+		if n, ok := probeOf(next); ok {
+			return (&node{name: name, next: n}).serve
+		}
+		return m(next)
+	}
+}
+
+// Select returns the middleware that only applies m when match reports true for the
+// context of the request, the match is evaluated by Inspect without invoking m.
+func Select(match func(ctx context.Context) bool, m Middleware) Middleware {
+	return func(next Handler) Handler {
+		if n, ok := probeOf(next); ok {
+			inner, _ := probeOf(probe(m, n))
+			return (&node{match: match, inner: inner, next: n}).serve
+		}
+		h := m(next)
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if match(ctx) {
+				return h(ctx, req)
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// Inspect returns the names of the middleware applied to the operation of the context,
+// which carries the transport of the operation. The chain is only built around a probe,
+// so no middleware is invoked, the middleware that are not Named are reported by their
+// function names, and the selectors report their middleware if they match the context.
+func Inspect(ctx context.Context, m Middleware) []string {
+	if m == nil {
+		return nil
+	}
+	end := &node{}
+	n, _ := probeOf(probe(m, end))
+	var names []string
+	for n != nil && n != end {
+		switch {
+		case n.match == nil:
+			names, n = append(names, n.name), n.next
+		case n.match(ctx):
+			n = n.inner
+		default:
+			n = n.next
+		}
+	}
+	return names
+}
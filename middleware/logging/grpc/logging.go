@@ -25,9 +25,11 @@ func WithLogger(logger log.Logger) Option {
 }
 
 // Server is a gRPC logging middleware.
+//
+// Deprecated: use logging.Server of github.com/go-kratos/kratos/v2/middleware/logging instead.
 func Server(opts ...Option) middleware.Middleware {
 	options := options{
-		logger: log.DefaultLogger,
+		logger: log.GetLogger(),
 	}
 	for _, o := range opts {
 		o(&options)
@@ -46,7 +48,7 @@ func Server(opts ...Option) middleware.Middleware {
 			}
 			reply, err := handler(ctx, req)
 			if err != nil {
-				log.Errorw(
+				log.WithContext(ctx).Errorw(
 					"kind", "server",
 					"grpc.service", service,
 					"grpc.method", method,
@@ -55,7 +57,7 @@ func Server(opts ...Option) middleware.Middleware {
 				)
 				return nil, err
 			}
-			log.Infow(
+			log.WithContext(ctx).Infow(
 				"kind", "server",
 				"grpc.service", service,
 				"grpc.method", method,
@@ -24,9 +24,11 @@ func WithLogger(logger log.Logger) Option {
 }
 
 // Server is an HTTP logging middleware.
+//
+// Deprecated: use logging.Server of github.com/go-kratos/kratos/v2/middleware/logging instead.
 func Server(opts ...Option) middleware.Middleware {
 	options := options{
-		logger: log.DefaultLogger,
+		logger: log.GetLogger(),
 	}
 	for _, o := range opts {
 		o(&options)
@@ -45,7 +47,7 @@ func Server(opts ...Option) middleware.Middleware {
 			}
 			reply, err := handler(ctx, req)
 			if err != nil {
-				log.Errorw(
+				log.WithContext(ctx).Errorw(
 					"kind", "server",
 					"http.path", path,
 					"http.method", method,
@@ -54,7 +56,7 @@ func Server(opts ...Option) middleware.Middleware {
 				)
 				return nil, err
 			}
-			log.Infow(
+			log.WithContext(ctx).Infow(
 				"kind", "server",
 				"http.path", path,
 				"http.method", method,
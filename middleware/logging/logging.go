@@ -0,0 +1,206 @@
+// Package logging provides the access logging middleware of both transports, every
+// request is logged at INFO, or at WARN if it is slower than the threshold, or at ERROR
+// if it fails.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+
+	"google.golang.org/grpc/peer"
+)
+
+// Entry is the access log entry of a request.
+type Entry struct {
+	// Kind is server or client.
+	Kind      string
+	Transport transport.Kind
+	Operation string
+	// Peer is the remote address of the server request, or the endpoint of the client request.
+	Peer    string
+	Request interface{}
+	Reply   interface{}
+	Err     error
+	Latency time.Duration
+}
+
+// Field returns the key and the value of a log field of the entry.
+type Field func(ctx context.Context, e *Entry) (key string, value interface{})
+
+// Redacter is implemented by the requests which redact their sensitive fields in the logs.
+type Redacter interface {
+	Redact() string
+}
+
+// Redactor returns the logged value of the request.
+type Redactor func(req interface{}) interface{}
+
+// Option is logging option.
+type Option func(*options)
+
+type options struct {
+	logger   log.Logger
+	fields   []Field
+	redactor Redactor
+	slow     time.Duration
+}
+
+// WithLogger with middleware logger.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithFields with the fields of the log, DefaultFields by default.
+func WithFields(fields ...Field) Option {
+	return func(o *options) {
+		o.fields = fields
+	}
+}
+
+// WithRedactor with the redactor of the logged requests, the requests implementing
+// Redacter are redacted by themselves by default.
+func WithRedactor(r Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// WithSlowThreshold with the latency threshold above which the request is logged at WARN.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slow = d
+	}
+}
+
+// DefaultFields is the default fields of the log.
+var DefaultFields = []Field{KindField, TransportField, OperationField, PeerField, ArgsField, CodeField, ReasonField, LatencyField}
+
+// KindField logs the kind, server or client.
+func KindField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "kind", e.Kind
+}
+
+// TransportField logs the transport kind, grpc or http.
+func TransportField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "component", e.Transport.String()
+}
+
+// OperationField logs the operation.
+func OperationField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "operation", e.Operation
+}
+
+// PeerField logs the peer address.
+func PeerField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "peer", e.Peer
+}
+
+// ArgsField logs the redacted request.
+func ArgsField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "args", fmt.Sprintf("%+v", e.Request)
+}
+
+// CodeField logs the code of the error, zero on success.
+func CodeField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "code", errors.Code(e.Err)
+}
+
+// ReasonField logs the reason of the error.
+func ReasonField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "reason", errors.Reason(e.Err)
+}
+
+// ErrorField logs the error message.
+func ErrorField(ctx context.Context, e *Entry) (string, interface{}) {
+	if e.Err == nil {
+		return "error", ""
+	}
+	return "error", e.Err.Error()
+}
+
+// LatencyField logs the latency in seconds.
+func LatencyField(ctx context.Context, e *Entry) (string, interface{}) {
+	return "latency", e.Latency.Seconds()
+}
+
+// Server is a server access logging middleware.
+func Server(opts ...Option) middleware.Middleware {
+	return newMiddleware("server", opts)
+}
+
+// Client is a client access logging middleware.
+func Client(opts ...Option) middleware.Middleware {
+	return newMiddleware("client", opts)
+}
+
+func newMiddleware(kind string, opts []Option) middleware.Middleware {
+	options := options{
+		logger:   log.GetLogger(),
+		fields:   DefaultFields,
+		redactor: redact,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	log := log.NewHelper("middleware/logging", options.logger)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			e := &Entry{Kind: kind, Request: options.redactor(req)}
+			if kind == "server" {
+				if tr, ok := transport.FromServerContext(ctx); ok {
+					e.Transport, e.Operation, e.Peer = tr.Kind(), tr.Operation(), serverPeer(ctx, tr)
+				}
+			} else if tr, ok := transport.FromClientContext(ctx); ok {
+				e.Transport, e.Operation, e.Peer = tr.Kind(), tr.Operation(), tr.Endpoint()
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			e.Reply, e.Err, e.Latency = reply, err, time.Since(start)
+
+			level := options.level(e)
+			kvs := make([]interface{}, 0, len(options.fields)*2)
+			for _, f := range options.fields {
+				k, v := f(ctx, e)
+				kvs = append(kvs, k, v)
+			}
+			log.WithContext(ctx).Log(level, kvs...)
+			return reply, err
+		}
+	}
+}
+
+func (o *options) level(e *Entry) log.Level {
+	if e.Err != nil {
+		return log.LevelError
+	}
+	if o.slow > 0 && e.Latency > o.slow {
+		return log.LevelWarn
+	}
+	return log.LevelInfo
+}
+
+func redact(req interface{}) interface{} {
+	if r, ok := req.(Redacter); ok {
+		return r.Redact()
+	}
+	return req
+}
+
+func serverPeer(ctx context.Context, tr transport.Transporter) string {
+	if ht, ok := tr.(*http.Transport); ok && ht.Request() != nil {
+		return ht.Request().RemoteAddr
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
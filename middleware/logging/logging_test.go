@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type mockTransport struct {
+	kind      transport.Kind
+	endpoint  string
+	operation string
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *mockTransport) Endpoint() string                { return tr.endpoint }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return nil }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return nil }
+
+type mockLogger struct {
+	level   log.Level
+	keyvals map[string]interface{}
+}
+
+func (l *mockLogger) Log(level log.Level, keyvals ...interface{}) error {
+	l.level = level
+	l.keyvals = make(map[string]interface{})
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		l.keyvals[fmt.Sprint(keyvals[i])] = keyvals[i+1]
+	}
+	return nil
+}
+
+type loginRequest struct {
+	User     string
+	Password string
+}
+
+func (r *loginRequest) Redact() string {
+	return "user:" + r.User
+}
+
+func TestServer(t *testing.T) {
+	ctx := transport.NewServerContext(context.Background(), &mockTransport{
+		kind:      transport.KindGRPC,
+		operation: "/api.v1.Auth/Login",
+	})
+	logger := &mockLogger{}
+	handler := func(err error, d time.Duration) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			time.Sleep(d)
+			return "reply", err
+		}
+	}
+	m := Server(WithLogger(logger), WithSlowThreshold(50*time.Millisecond))
+
+	req := &loginRequest{User: "kratos", Password: "secret"}
+	if _, err := m(handler(nil, 0))(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if logger.level != log.LevelInfo {
+		t.Errorf("got %s want INFO", logger.level)
+	}
+	want := map[string]interface{}{
+		"kind":      "server",
+		"component": "grpc",
+		"operation": "/api.v1.Auth/Login",
+		"args":      "user:kratos",
+		"code":      int32(0),
+		"reason":    "",
+	}
+	for k, v := range want {
+		if logger.keyvals[k] != v {
+			t.Errorf("%s: got %v want %v", k, logger.keyvals[k], v)
+		}
+	}
+
+	m(handler(nil, 60*time.Millisecond))(ctx, req)
+	if logger.level != log.LevelWarn {
+		t.Errorf("got %s want WARN", logger.level)
+	}
+	m(handler(errors.Unauthorized("UNAUTHORIZED", "bad password"), 0))(ctx, req)
+	if logger.level != log.LevelError || logger.keyvals["reason"] != "UNAUTHORIZED" || logger.keyvals["code"] != int32(16) {
+		t.Errorf("unexpected log: %s %v", logger.level, logger.keyvals)
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := transport.NewClientContext(context.Background(), &mockTransport{
+		kind:      transport.KindHTTP,
+		endpoint:  "http://127.0.0.1:8000",
+		operation: "/v1/users",
+	})
+	logger := &mockLogger{}
+	m := Client(
+		WithLogger(logger),
+		WithFields(KindField, PeerField, ArgsField),
+		WithRedactor(func(req interface{}) interface{} { return "***" }),
+	)
+	m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, "secret")
+	want := map[string]interface{}{
+		"kind":   "client",
+		"peer":   "http://127.0.0.1:8000",
+		"args":   "***",
+		"module": "middleware/logging",
+	}
+	if len(logger.keyvals) != len(want) {
+		t.Fatalf("unexpected fields: %v", logger.keyvals)
+	}
+	for k, v := range want {
+		if logger.keyvals[k] != v {
+			t.Errorf("%s: got %v want %v", k, logger.keyvals[k], v)
+		}
+	}
+}
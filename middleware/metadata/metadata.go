@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Option is metadata option.
+type Option func(*options)
+
+// WithPropagatedPrefix with the key prefixes of the metadata to propagate, which are
+// case insensitive, a full key can be used to whitelist a single key.
+func WithPropagatedPrefix(prefix ...string) Option {
+	return func(o *options) {
+		o.prefix = make([]string, 0, len(prefix))
+		for _, p := range prefix {
+			o.prefix = append(o.prefix, strings.ToLower(p))
+		}
+	}
+}
+
+// WithConstants with the constant metadata sent by every client call.
+func WithConstants(md metadata.Metadata) Option {
+	return func(o *options) {
+		o.md = md
+	}
+}
+
+type options struct {
+	prefix []string
+	md     metadata.Metadata
+}
+
+func (o *options) hasPrefix(key string) bool {
+	k := strings.ToLower(key)
+	for _, prefix := range o.prefix {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Server is a server middleware that extracts the incoming metadata with the
+// propagated prefixes, "x-md-" by default, from the request header.
+func Server(opts ...Option) middleware.Middleware {
+	options := options{
+		prefix: []string{"x-md-"},
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			md := metadata.Metadata{}
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				header := tr.RequestHeader()
+				for _, k := range header.Keys() {
+					if options.hasPrefix(k) {
+						md.Set(k, header.Get(k))
+					}
+				}
+			}
+			if len(md) > 0 {
+				ctx = metadata.NewServerContext(ctx, md)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Client is a client middleware that sends the constant metadata, the outgoing metadata of the
+// client context and the incoming metadata with the propagated prefixes, "x-md-global-" by default.
+func Client(opts ...Option) middleware.Middleware {
+	options := options{
+		prefix: []string{"x-md-global-"},
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			md := options.md.Clone()
+			if smd, ok := metadata.FromServerContext(ctx); ok {
+				for k, v := range smd {
+					if options.hasPrefix(k) {
+						md.Set(k, v)
+					}
+				}
+			}
+			if cmd, ok := metadata.FromClientContext(ctx); ok {
+				for k, v := range cmd {
+					md.Set(k, v)
+				}
+			}
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				header := tr.RequestHeader()
+				for k, v := range md {
+					header.Set(k, v)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	header headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return "" }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestServer(t *testing.T) {
+	header := headerCarrier{}
+	header.Set("X-Md-Global-Uid", "1")
+	header.Set("X-Md-Local", "2")
+	header.Set("Authorization", "secret")
+	ctx := transport.NewServerContext(context.Background(), &mockTransport{header: header})
+	Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		md, ok := metadata.FromServerContext(ctx)
+		if !ok || len(md) != 2 || md.Get("x-md-global-uid") != "1" || md.Get("x-md-local") != "2" {
+			t.Errorf("unexpected server metadata: %v", md)
+		}
+		return nil, nil
+	})(ctx, nil)
+}
+
+func TestServerPrefix(t *testing.T) {
+	header := headerCarrier{}
+	header.Set("X-Md-Global-Uid", "1")
+	header.Set("X-Tenant", "kratos")
+	ctx := transport.NewServerContext(context.Background(), &mockTransport{header: header})
+	Server(WithPropagatedPrefix("X-Md-", "x-tenant"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		md, _ := metadata.FromServerContext(ctx)
+		if len(md) != 2 || md.Get("x-md-global-uid") != "1" || md.Get("x-tenant") != "kratos" {
+			t.Errorf("unexpected server metadata: %v", md)
+		}
+		return nil, nil
+	})(ctx, nil)
+}
+
+func TestClient(t *testing.T) {
+	tr := &mockTransport{header: headerCarrier{}}
+	ctx := metadata.NewServerContext(context.Background(), metadata.Metadata{"x-md-global-uid": "1", "x-md-local": "2"})
+	ctx = metadata.AppendToClientContext(ctx, "x-md-call", "3")
+	ctx = transport.NewClientContext(ctx, tr)
+	Client(WithConstants(metadata.Metadata{"x-md-app": "kratos"}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, nil)
+	if len(tr.header) != 3 {
+		t.Errorf("unexpected request header: %v", tr.header)
+	}
+	for k, v := range map[string]string{"x-md-global-uid": "1", "x-md-call": "3", "x-md-app": "kratos"} {
+		if got := tr.header.Get(k); got != v {
+			t.Errorf("%s: got %v want %s", k, got, v)
+		}
+	}
+}
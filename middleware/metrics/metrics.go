@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// Option is metrics option.
+type Option func(*options)
+
+// WithRequests with requests counter, labeled by kind, operation, code and reason.
+func WithRequests(c metrics.Counter) Option {
+	return func(o *options) {
+		o.requests = c
+	}
+}
+
+// WithSeconds with seconds histogram, labeled by kind and operation.
+func WithSeconds(c metrics.Observer) Option {
+	return func(o *options) {
+		o.seconds = c
+	}
+}
+
+type options struct {
+	// counter: <client/server>_requests_code_total{kind, operation, code, reason}
+	requests metrics.Counter
+	// histogram: <client/server>_requests_seconds_bucket{kind, operation}
+	seconds metrics.Observer
+}
+
+// Server is middleware server-side metrics.
+func Server(opts ...Option) middleware.Middleware {
+	options := options{}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var kind, operation string
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				kind, operation = tr.Kind().String(), tr.Operation()
+				if ht, ok := tr.(*http.Transport); ok {
+					operation = ht.Request().Method + " " + operation
+				}
+			}
+			startTime := time.Now()
+			reply, err := handler(ctx, req)
+			options.record(kind, operation, startTime, err)
+			return reply, err
+		}
+	}
+}
+
+// Client is middleware client-side metrics.
+func Client(opts ...Option) middleware.Middleware {
+	options := options{}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var kind, operation string
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				kind, operation = tr.Kind().String(), tr.Operation()
+			}
+			startTime := time.Now()
+			reply, err := handler(ctx, req)
+			options.record(kind, operation, startTime, err)
+			return reply, err
+		}
+	}
+}
+
+func (o *options) record(kind, operation string, startTime time.Time, err error) {
+	if o.requests != nil {
+		code := strconv.Itoa(int(errors.Code(err)))
+		o.requests.With(kind, operation, code, errors.Reason(err)).Inc()
+	}
+	if o.seconds != nil {
+		o.seconds.With(kind, operation).Observe(time.Since(startTime).Seconds())
+	}
+}
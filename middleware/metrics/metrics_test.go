@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type mockTransport struct {
+	kind      transport.Kind
+	operation string
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return nil }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return nil }
+
+type mockCounter struct {
+	lvs   []string
+	value float64
+}
+
+func (c *mockCounter) With(lvs ...string) metrics.Counter {
+	c.lvs = lvs
+	return c
+}
+func (c *mockCounter) Inc()              { c.value++ }
+func (c *mockCounter) Add(delta float64) { c.value += delta }
+
+type mockObserver struct {
+	lvs    []string
+	values []float64
+}
+
+func (o *mockObserver) With(lvs ...string) metrics.Observer {
+	o.lvs = lvs
+	return o
+}
+func (o *mockObserver) Observe(v float64) { o.values = append(o.values, v) }
+
+func TestServer(t *testing.T) {
+	requests := &mockCounter{}
+	seconds := &mockObserver{}
+	ctx := transport.NewServerContext(context.Background(), &mockTransport{kind: transport.KindGRPC, operation: "/helloworld.Greeter/SayHello"})
+
+	m := Server(WithRequests(requests), WithSeconds(seconds))
+	_, err := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.NotFound("USER_NOT_FOUND", "user not found")
+	})(ctx, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if want := []string{"grpc", "/helloworld.Greeter/SayHello", "5", "USER_NOT_FOUND"}; !reflect.DeepEqual(requests.lvs, want) {
+		t.Errorf("requests labels: got %v want %v", requests.lvs, want)
+	}
+	if requests.value != 1 {
+		t.Errorf("requests: got %v want 1", requests.value)
+	}
+	if want := []string{"grpc", "/helloworld.Greeter/SayHello"}; !reflect.DeepEqual(seconds.lvs, want) {
+		t.Errorf("seconds labels: got %v want %v", seconds.lvs, want)
+	}
+	if len(seconds.values) != 1 {
+		t.Errorf("seconds: got %v", seconds.values)
+	}
+}
+
+func TestClient(t *testing.T) {
+	requests := &mockCounter{}
+	ctx := transport.NewClientContext(context.Background(), &mockTransport{kind: transport.KindGRPC, operation: "/helloworld.Greeter/SayHello"})
+
+	m := Client(WithRequests(requests))
+	if _, err := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"grpc", "/helloworld.Greeter/SayHello", "0", ""}; !reflect.DeepEqual(requests.lvs, want) {
+		t.Errorf("requests labels: got %v want %v", requests.lvs, want)
+	}
+}
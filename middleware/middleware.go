@@ -13,6 +13,14 @@ type Middleware func(Handler) Handler
 // Chain .
 func Chain(outer Middleware, others ...Middleware) Middleware {
 	return func(next Handler) Handler {
+		if n, ok := probeOf(next); ok {
+			// every middleware of the chain is described by the inspection.
+			for i := len(others) - 1; i >= 0; i-- {
+				next = probe(others[i], n)
+				n, _ = probeOf(next)
+			}
+			return probe(outer, n)
+		}
 		for i := len(others) - 1; i >= 0; i-- {
 			next = others[i](next)
 		}
@@ -0,0 +1,282 @@
+// Package mirror mirrors a percentage of the requests to a shadow endpoint
+// asynchronously, the shadow replies are ignored, so that a new version of a service
+// can be validated against the production traffic.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	transhttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Shadow sends the mirrored request of the operation to the shadow endpoint.
+type Shadow func(ctx context.Context, operation string, req interface{}) error
+
+// GRPC returns a shadow which invokes the operation on the shadow connection, with the
+// headers of the client transport, and discards the reply.
+func GRPC(conn grpc.ClientConnInterface) Shadow {
+	return func(ctx context.Context, operation string, req interface{}) error {
+		if tr, ok := transport.FromClientContext(ctx); ok {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			md = md.Copy()
+			for _, k := range tr.RequestHeader().Keys() {
+				md.Set(k, tr.RequestHeader().Get(k))
+			}
+			ctx = metadata.NewOutgoingContext(ctx, md)
+		}
+		// the unknown fields of the reply are kept by the empty message.
+		return conn.Invoke(ctx, operation, req, &emptypb.Empty{})
+	}
+}
+
+// Option is mirror option.
+type Option func(*options)
+
+type options struct {
+	percentage  float64
+	timeout     time.Duration
+	maxInFlight int64
+	maxBodySize int64
+	client      *http.Client
+	logger      log.Logger
+	random      func() float64
+}
+
+// WithPercentage with the percentage of the mirrored requests, from 0 to 100, 100 by default.
+func WithPercentage(p float64) Option {
+	return func(o *options) {
+		o.percentage = p
+	}
+}
+
+// WithTimeout with the timeout of the mirrored requests, 1s by default.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithMaxInFlight with the max in-flight mirrored requests, 100 by default, the requests
+// beyond it are not mirrored.
+func WithMaxInFlight(n int64) Option {
+	return func(o *options) {
+		o.maxInFlight = n
+	}
+}
+
+// WithMaxBodySize with the max bytes of the mirrored HTTP request body, 1MB by default,
+// the requests of the larger bodies are not mirrored.
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBodySize = n
+	}
+}
+
+// WithHTTPClient with the client of the mirrored HTTP requests, http.DefaultClient by default.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
+
+// WithLogger with the logger of the failed mirrored requests.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+type mirror struct {
+	opts     options
+	log      *log.Helper
+	inFlight int64
+}
+
+func newMirror(opts []Option) *mirror {
+	options := options{
+		percentage:  100,
+		timeout:     time.Second,
+		maxInFlight: 100,
+		maxBodySize: 1 << 20,
+		client:      http.DefaultClient,
+		logger:      log.GetLogger(),
+		random:      rand.Float64,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &mirror{opts: options, log: log.NewHelper("middleware/mirror", options.logger)}
+}
+
+func (m *mirror) sampled() bool {
+	return m.opts.percentage > 0 && m.opts.random()*100 < m.opts.percentage
+}
+
+// run runs the mirrored request in the background, with the values of ctx but not
+// its cancellation, so that it does not delay or fail the request.
+func (m *mirror) run(ctx context.Context, fn func(ctx context.Context) error) {
+	if atomic.AddInt64(&m.inFlight, 1) > m.opts.maxInFlight {
+		atomic.AddInt64(&m.inFlight, -1)
+		return
+	}
+	go func() {
+		defer atomic.AddInt64(&m.inFlight, -1)
+		ctx, cancel := context.WithTimeout(detach{ctx}, m.opts.timeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			m.log.WithContext(ctx).Debugf("mirror failed: %v", err)
+		}
+	}()
+}
+
+// Client is a client middleware that mirrors the requests to the shadow, it should be
+// the last client middleware so that the shadow receives the same headers. The shadow
+// receives the copies of the proto requests and the headers, so the caller may reuse
+// them once the call returns.
+func Client(shadow Shadow, opts ...Option) middleware.Middleware {
+	m := newMirror(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok && m.sampled() {
+				operation := tr.Operation()
+				mreq := clone(req)
+				mctx := transport.NewClientContext(ctx, &snapshot{Transporter: tr, header: copyHeader(tr.RequestHeader())})
+				m.run(mctx, func(ctx context.Context) error {
+					return shadow(ctx, operation, mreq)
+				})
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+func clone(req interface{}) interface{} {
+	if msg, ok := req.(proto.Message); ok {
+		return proto.Clone(msg)
+	}
+	return req
+}
+
+func copyHeader(h transport.Header) headerCopy {
+	c := headerCopy{}
+	for _, k := range h.Keys() {
+		http.Header(c).Set(k, h.Get(k))
+	}
+	return c
+}
+
+// headerCopy is the copy of the request headers, whose keys are case insensitive.
+type headerCopy http.Header
+
+func (h headerCopy) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+func (h headerCopy) Set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+func (h headerCopy) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// snapshot is the client transport of the mirrored request, with a copy of the headers.
+type snapshot struct {
+	transport.Transporter
+	header headerCopy
+}
+
+func (s *snapshot) RequestHeader() transport.Header {
+	return s.header
+}
+
+// Filter is a HTTP server filter that mirrors the requests to the target URL, the
+// path and the query of the requests are joined to the target. An error is returned
+// if the target is not an absolute URL.
+func Filter(target string, opts ...Option) (transhttp.FilterFunc, error) {
+	m := newMirror(opts)
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("mirror: the target %q is not an absolute URL", target)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !m.sampled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, m.opts.maxBodySize+1))
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			if err == nil && int64(len(body)) <= m.opts.maxBodySize {
+				mr := r.Clone(context.Background())
+				mu := *u
+				mu.Path, mu.RawPath, mu.RawQuery = singleJoin(u.Path, r.URL.Path), "", r.URL.RawQuery
+				mr.URL = &mu
+				mr.Host = ""
+				mr.RequestURI = ""
+				m.run(r.Context(), func(ctx context.Context) error {
+					mr = mr.WithContext(ctx)
+					mr.Body = ioutil.NopCloser(bytes.NewReader(body))
+					res, err := m.opts.client.Do(mr)
+					if err != nil {
+						return err
+					}
+					_, _ = io.Copy(ioutil.Discard, res.Body)
+					return res.Body.Close()
+				})
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func singleJoin(a, b string) string {
+	switch {
+	case a == "" || a == "/":
+		return b
+	case a[len(a)-1] == '/' && len(b) > 0 && b[0] == '/':
+		return a + b[1:]
+	case a[len(a)-1] != '/' && (len(b) == 0 || b[0] != '/'):
+		return a + "/" + b
+	}
+	return a + b
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// detach is a context with the values of the parent but not its deadline and cancellation.
+type detach struct {
+	parent context.Context
+}
+
+func (detach) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detach) Done() <-chan struct{}               { return nil }
+func (detach) Err() error                          { return nil }
+func (d detach) Value(key interface{}) interface{} { return d.parent.Value(key) }
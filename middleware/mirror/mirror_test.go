@@ -0,0 +1,207 @@
+package mirror
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	operation string
+	reqHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+type mirrored struct {
+	operation string
+	req       interface{}
+	err       error
+}
+
+func TestClient(t *testing.T) {
+	ch := make(chan mirrored, 10)
+	shadow := func(ctx context.Context, operation string, req interface{}) error {
+		// the mirrored request is not canceled with the request.
+		time.Sleep(10 * time.Millisecond)
+		ch <- mirrored{operation, req, ctx.Err()}
+		return nil
+	}
+	h := Client(shadow)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	ctx, cancel := context.WithCancel(transport.NewClientContext(context.Background(), &mockTransport{operation: "/test.Service/Method"}))
+	if reply, err := h(ctx, "req"); reply != "reply" || err != nil {
+		t.Fatalf("got %v %v", reply, err)
+	}
+	cancel()
+	select {
+	case m := <-ch:
+		if m.operation != "/test.Service/Method" || m.req != "req" || m.err != nil {
+			t.Errorf("unexpected mirrored request: %+v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the request is not mirrored")
+	}
+}
+
+func TestClientCopy(t *testing.T) {
+	ch := make(chan string, 1)
+	block := make(chan struct{})
+	shadow := func(ctx context.Context, operation string, req interface{}) error {
+		<-block
+		tr, _ := transport.FromClientContext(ctx)
+		ch <- req.(*grpc_health_v1.HealthCheckRequest).Service + " " + tr.RequestHeader().Get("x-md-test")
+		return nil
+	}
+	h := Client(shadow)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	tr := &mockTransport{operation: "/grpc.health.v1.Health/Check", reqHeader: headerCarrier{}}
+	tr.reqHeader.Set("x-md-test", "test")
+	req := &grpc_health_v1.HealthCheckRequest{Service: "helloworld"}
+	if _, err := h(transport.NewClientContext(context.Background(), tr), req); err != nil {
+		t.Fatal(err)
+	}
+	// the caller reuses the request and the headers once the call returns.
+	req.Service = "reused"
+	tr.reqHeader.Set("x-md-test", "reused")
+	close(block)
+	if got := <-ch; got != "helloworld test" {
+		t.Errorf("got %s want the copies of the request and the headers", got)
+	}
+}
+
+func TestClientSampled(t *testing.T) {
+	block := make(chan struct{})
+	calls := make(chan struct{}, 10)
+	shadow := func(ctx context.Context, operation string, req interface{}) error {
+		calls <- struct{}{}
+		<-block
+		return nil
+	}
+	m := newMirror([]Option{WithPercentage(50), WithMaxInFlight(1)})
+	random := 0.6
+	m.opts.random = func() float64 { return random }
+	ctx := transport.NewClientContext(context.Background(), &mockTransport{operation: "/test.Service/Method"})
+	for _, r := range []float64{0.6, 0.2, 0.2} {
+		random = r
+		if m.sampled() {
+			m.run(ctx, func(ctx context.Context) error { return shadow(ctx, "", nil) })
+		}
+	}
+	<-calls
+	close(block)
+	// the first is not sampled, and the last is beyond the max in-flight requests.
+	if n := len(calls); n != 0 {
+		t.Errorf("got %d more mirrored requests want 1", n)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	ch := make(chan string, 1)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		ch <- r.Method + " " + r.URL.String() + " " + r.Header.Get("X-Test") + " " + string(body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer shadow.Close()
+
+	filter, err := Filter(shadow.URL + "/shadow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := filter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	req := httptest.NewRequest("POST", "/v1/users?name=kratos", strings.NewReader(`{"id":1}`))
+	req.Header.Set("X-Test", "test")
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	if res.Body.String() != `{"id":1}` {
+		t.Errorf("got %s want the body of the request", res.Body.String())
+	}
+	select {
+	case got := <-ch:
+		if want := `POST /shadow/v1/users?name=kratos test {"id":1}`; got != want {
+			t.Errorf("got %s want %s", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the request is not mirrored")
+	}
+}
+
+func TestFilterTarget(t *testing.T) {
+	for _, target := range []string{"://shadow", "/shadow", "shadow:8000"} {
+		if _, err := Filter(target); err == nil {
+			t.Errorf("%s: got nil want the error of the invalid target", target)
+		}
+	}
+}
+
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	ch chan string
+}
+
+func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.ch <- req.Service + " " + strings.Join(md.Get("x-md-test"), ",")
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func TestGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := &healthServer{ch: make(chan string, 1)}
+	grpc_health_v1.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tr := &mockTransport{operation: "/grpc.health.v1.Health/Check", reqHeader: headerCarrier{}}
+	tr.reqHeader.Set("x-md-test", "test")
+	ctx := transport.NewClientContext(context.Background(), tr)
+	if err := GRPC(conn)(ctx, tr.operation, &grpc_health_v1.HealthCheckRequest{Service: "helloworld"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-hs.ch; got != "helloworld test" {
+		t.Errorf("got %s want the request with the transport headers", got)
+	}
+}
@@ -0,0 +1,139 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Validator = (*Introspection)(nil)
+
+// IntrospectionOption is introspection option.
+type IntrospectionOption func(*Introspection)
+
+// WithIntrospectionClient with the HTTP client calling the endpoint, http.DefaultClient by default.
+func WithIntrospectionClient(c *http.Client) IntrospectionOption {
+	return func(i *Introspection) {
+		i.client = c
+	}
+}
+
+// WithCache with how long the active tokens are cached, limited by their expiry,
+// the tokens are introspected on every request by default.
+func WithCache(ttl time.Duration) IntrospectionOption {
+	return func(i *Introspection) {
+		i.ttl = ttl
+	}
+}
+
+// Introspection validates the opaque access tokens by the token introspection endpoint
+// of the provider of RFC 7662, which authenticates the resource server by its client
+// credentials.
+type Introspection struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+	ttl          time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedPrincipal
+	swept time.Time
+}
+
+type cachedPrincipal struct {
+	principal *Principal
+	expiry    time.Time
+}
+
+// NewIntrospection returns a validator of the introspection endpoint.
+func NewIntrospection(endpoint, clientID, clientSecret string, opts ...IntrospectionOption) *Introspection {
+	i := &Introspection{
+		endpoint:     endpoint,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       http.DefaultClient,
+		cache:        make(map[string]cachedPrincipal),
+	}
+	for _, o := range opts {
+		o(i)
+	}
+	return i
+}
+
+// Validate introspects the token, and returns the principal of the active token.
+func (i *Introspection) Validate(ctx context.Context, token string) (*Principal, error) {
+	if p, ok := i.cached(token); ok {
+		return p, nil
+	}
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(i.clientID), url.QueryEscape(i.clientSecret))
+	res, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: introspect: %s", res.Status)
+	}
+	claims := make(map[string]interface{})
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, err
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, errors.New("oidc: inactive token")
+	}
+	p := newPrincipal(claims)
+	i.store(token, p)
+	return p, nil
+}
+
+func (i *Introspection) cached(token string) (*Principal, bool) {
+	if i.ttl <= 0 {
+		return nil, false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	c, ok := i.cache[token]
+	if !ok || time.Now().After(c.expiry) {
+		return nil, false
+	}
+	return c.principal, true
+}
+
+// store caches the principal, and evicts the expired ones once per ttl.
+func (i *Introspection) store(token string, p *Principal) {
+	if i.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	expiry := now.Add(i.ttl)
+	if !p.Expiry.IsZero() && p.Expiry.Before(expiry) {
+		expiry = p.Expiry
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if now.Sub(i.swept) > i.ttl {
+		for k, c := range i.cache {
+			if now.After(c.expiry) {
+				delete(i.cache, k)
+			}
+		}
+		i.swept = now
+	}
+	i.cache[token] = cachedPrincipal{principal: p, expiry: expiry}
+}
@@ -0,0 +1,54 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIntrospection(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "billing" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.PostFormValue("token") != "opaque" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"active": false})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"active":    true,
+			"sub":       "alice",
+			"client_id": "checkout",
+			"scope":     "billing:read billing:write",
+			"exp":       time.Now().Add(time.Hour).Unix(),
+		})
+	}))
+	defer ts.Close()
+
+	v := NewIntrospection(ts.URL, "billing", "secret", WithCache(time.Minute))
+	for i := 0; i < 2; i++ {
+		p, err := v.Validate(context.Background(), "opaque")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Subject != "alice" || p.ClientID != "checkout" || !p.HasScope("billing:write") {
+			t.Errorf("got %+v want the principal", p)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("got %d calls want the cached principal", n)
+	}
+	if _, err := v.Validate(context.Background(), "revoked"); err == nil {
+		t.Error("got the inactive token validated")
+	}
+	if _, err := NewIntrospection(ts.URL, "billing", "wrong").Validate(context.Background(), "opaque"); err == nil {
+		t.Error("got validated by the wrong credentials")
+	}
+}
@@ -0,0 +1,317 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Validator = (*JWKS)(nil)
+
+// JWKSOption is JWKS option.
+type JWKSOption func(*JWKS)
+
+// WithJWKSClient with the HTTP client fetching the keys, a client of 10s timeout by default.
+func WithJWKSClient(c *http.Client) JWKSOption {
+	return func(j *JWKS) {
+		j.client = c
+	}
+}
+
+// WithRefresh with how long the keys are cached, 1h by default, the keys are also
+// refreshed once a token is signed by an unknown key, at most once per minute.
+func WithRefresh(d time.Duration) JWKSOption {
+	return func(j *JWKS) {
+		j.refresh = d
+	}
+}
+
+// JWKS validates the JWT access tokens by the keys of the JSON web key set of the
+// provider, i.e. the jwks_uri of the discovery document. The claims of the token,
+// such as the issuer and the expiry, are verified by the middleware.
+type JWKS struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+	// retry is the least interval of the fetches, so a failing provider is not flooded.
+	retry time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetched   time.Time
+	attempted time.Time
+	inflight  *fetchCall
+}
+
+// fetchCall is a fetch of the keys shared by the requests waiting for it.
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS returns a validator of the keys at the url.
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	j := &JWKS{
+		url:     url,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		refresh: time.Hour,
+		retry:   time.Minute,
+	}
+	for _, o := range opts {
+		o(j)
+	}
+	return j
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Validate verifies the signature of the token, and returns the principal of its claims.
+func (j *JWKS) Validate(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("oidc: malformed jwt")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	key, err := j.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+	claims := make(map[string]interface{})
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	p := newPrincipal(claims)
+	// the access tokens must expire, see RFC 9068.
+	if p.Expiry.IsZero() {
+		return nil, errors.New("oidc: missing exp claim")
+	}
+	return p, nil
+}
+
+// key returns the key of the id. The keys are fetched in the background when they expire,
+// while the known keys keep validating the tokens, and the requests of an unknown key
+// wait for a fetch, at most one per retry interval. The last keys are kept when a
+// fetch fails.
+func (j *JWKS) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	key, ok := j.keys[kid]
+	c := j.inflight
+	if c == nil && (!ok || time.Since(j.fetched) > j.refresh) && time.Since(j.attempted) > j.retry {
+		c = &fetchCall{done: make(chan struct{})}
+		j.inflight, j.attempted = c, time.Now()
+		go j.fetch(c)
+	}
+	j.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if c == nil {
+		return nil, fmt.Errorf("oidc: unknown key %q", kid)
+	}
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	j.mu.Lock()
+	key, ok = j.keys[kid]
+	j.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("oidc: unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func (j *JWKS) fetch(c *fetchCall) {
+	keys, err := j.fetchKeys(context.Background())
+	j.mu.Lock()
+	if err == nil {
+		j.keys, j.fetched = keys, time.Now()
+	}
+	j.inflight = nil
+	j.mu.Unlock()
+	c.err = err
+	close(c.done)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j *JWKS) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: fetch jwks: %s", res.Status)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// the unsupported keys are skipped.
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("oidc: invalid ec key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+	}
+}
+
+// verify verifies the signature of the RSA, RSA-PSS or ECDSA algorithms, the symmetric
+// and the none algorithms are rejected, and the ECDSA algorithms are bound to their curves.
+func verify(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("oidc: unsupported algorithm %q", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("oidc: unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		case "PS":
+			return rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" || pub.Curve != curves[alg] {
+			break
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("oidc: invalid signature")
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("oidc: invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("oidc: unsupported algorithm %q of the key", alg)
+}
+
+// curves are the curves of the ECDSA algorithms, see RFC 7518.
+var curves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,87 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJWKSRefresh(t *testing.T) {
+	s := newSigner(t)
+	var (
+		failing int32
+		release = make(chan struct{})
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.LoadInt32(&failing) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			<-release
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			s.jwks(w, r)
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	j := NewJWKS(ts.URL, WithRefresh(time.Millisecond))
+	j.retry = 0
+	token := s.sign(t, "RS256", "rsa", map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := j.Validate(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+
+	// the expired keys keep validating the tokens while the provider fails.
+	atomic.StoreInt32(&failing, 1)
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if _, err := j.Validate(context.Background(), token); err != nil {
+			t.Fatalf("got %v want the last keys", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// a slow provider does not block the tokens of the known keys.
+	atomic.StoreInt32(&failing, 2)
+	time.Sleep(10 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := j.Validate(context.Background(), token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("got blocked %v by the fetch", d)
+	}
+	// the requests of an unknown key wait for the fetch until their deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := j.Validate(ctx, s.sign(t, "RS256", "other", nil)); err != context.DeadlineExceeded {
+		t.Errorf("got %v want the deadline", err)
+	}
+}
+
+func TestJWKSRetry(t *testing.T) {
+	var fetches int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	j := NewJWKS(ts.URL)
+	s := newSigner(t)
+	for i := 0; i < 3; i++ {
+		if _, err := j.Validate(context.Background(), s.sign(t, "RS256", "rsa", nil)); err == nil {
+			t.Fatal("got validated without the keys")
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("got %d fetches want 1 per retry interval", n)
+	}
+}
@@ -0,0 +1,307 @@
+// Package oidc validates the OAuth2 bearer access tokens of the requests against an OIDC
+// provider, by its JWKS for the JWT tokens or its introspection endpoint for the opaque
+// ones, enforces the scopes per operation, and carries the principal in the context:
+//
+//	p, err := oidc.Discover(ctx, "https://accounts.example.com")
+//	m, err := oidc.Server(
+//		oidc.WithValidator(oidc.NewJWKS(p.JWKSURI)),
+//		oidc.WithIssuer(p.Issuer),
+//		oidc.WithAudience("billing"),
+//		oidc.WithScopes("/billing.v1.Billing/*", "billing:write"),
+//	)
+//	srv := grpc.NewServer(grpc.Middleware(m))
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/matcher"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const authorizationHeader = "Authorization"
+
+var (
+	// ErrMissingToken is returned when the request has no bearer token.
+	ErrMissingToken = errors.Unauthorized("UNAUTHORIZED", "missing bearer token")
+	// ErrInvalidToken is returned when the token is invalid, expired or not issued for the service.
+	ErrInvalidToken = errors.Unauthorized("UNAUTHORIZED", "invalid bearer token")
+	// ErrInsufficientScope is returned when the token lacks the scopes of the operation.
+	ErrInsufficientScope = errors.PermissionDenied("FORBIDDEN", "insufficient scope")
+)
+
+// Validator validates the access token, and returns its principal.
+type Validator interface {
+	Validate(ctx context.Context, token string) (*Principal, error)
+}
+
+// Principal is the authenticated principal of an access token.
+type Principal struct {
+	Subject   string
+	Issuer    string
+	ClientID  string
+	Audience  []string
+	Scopes    []string
+	Expiry    time.Time
+	NotBefore time.Time
+	// Claims are all the claims of the token or the introspection response.
+	Claims map[string]interface{}
+}
+
+// HasScope reports whether the principal is granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// newPrincipal returns the principal of the registered claims, the scopes are of the
+// scope claim of RFC 9068 or the scp claim of some providers.
+func newPrincipal(claims map[string]interface{}) *Principal {
+	p := &Principal{Claims: claims}
+	p.Subject, _ = claims["sub"].(string)
+	p.Issuer, _ = claims["iss"].(string)
+	p.ClientID, _ = claims["client_id"].(string)
+	if p.ClientID == "" {
+		p.ClientID, _ = claims["azp"].(string)
+	}
+	p.Audience = stringList(claims["aud"])
+	if scope, ok := claims["scope"].(string); ok {
+		p.Scopes = strings.Fields(scope)
+	} else {
+		p.Scopes = stringList(claims["scp"])
+	}
+	p.Expiry = unixTime(claims["exp"])
+	p.NotBefore = unixTime(claims["nbf"])
+	return p
+}
+
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func unixTime(v interface{}) time.Time {
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return time.Unix(int64(f), 0)
+		}
+	}
+	return time.Time{}
+}
+
+// Option is oidc option.
+type Option func(*options)
+
+// WithValidator with the validator of the tokens, i.e. NewJWKS or NewIntrospection.
+func WithValidator(v Validator) Option {
+	return func(o *options) {
+		o.validator = v
+	}
+}
+
+// WithIssuer with the issuer of the tokens, which is required.
+func WithIssuer(issuer string) Option {
+	return func(o *options) {
+		o.issuer = issuer
+	}
+}
+
+// WithAudience with the audiences of the service, the token must be issued for one
+// of them, at least one audience is required.
+func WithAudience(audience ...string) Option {
+	return func(o *options) {
+		o.audience = audience
+	}
+}
+
+// WithScopes with the required scopes of the operation, the operations without scopes
+// only require a valid token. An operation ends with "*" matches the operations of the
+// prefix, i.e. /helloworld.Greeter/*, and the longest one wins.
+func WithScopes(operation string, scopes ...string) Option {
+	return func(o *options) {
+		o.scopes[operation] = append(o.scopes[operation], scopes...)
+	}
+}
+
+// WithLeeway with the clock skew allowed by the expiry and the not before, 1m by default.
+func WithLeeway(d time.Duration) Option {
+	return func(o *options) {
+		o.leeway = d
+	}
+}
+
+type options struct {
+	validator Validator
+	issuer    string
+	audience  []string
+	scopes    map[string][]string
+	matcher   *matcher.Matcher
+	leeway    time.Duration
+}
+
+// valid reports whether the principal is issued for the service and not expired.
+func (o *options) valid(p *Principal) bool {
+	now := time.Now()
+	if !p.Expiry.IsZero() && now.After(p.Expiry.Add(o.leeway)) {
+		return false
+	}
+	if !p.NotBefore.IsZero() && now.Add(o.leeway).Before(p.NotBefore) {
+		return false
+	}
+	if p.Issuer != o.issuer {
+		return false
+	}
+	for _, aud := range o.audience {
+		for _, a := range p.Audience {
+			if a == aud {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requiredScopes returns the scopes of the operation.
+func (o *options) requiredScopes(operation string) []string {
+	if k, ok := o.matcher.Match(operation); ok {
+		return o.scopes[k]
+	}
+	return nil
+}
+
+// Server is a server middleware that validates the bearer token of the Authorization
+// header, and enforces the scopes of the operations. The validator, the issuer and the
+// audience are required, and the requests without the server transport are rejected.
+func Server(opts ...Option) (middleware.Middleware, error) {
+	options := options{
+		scopes: make(map[string][]string),
+		leeway: time.Minute,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	switch {
+	case options.validator == nil:
+		return nil, stderrors.New("oidc: the validator is required")
+	case options.issuer == "":
+		return nil, stderrors.New("oidc: the issuer is required")
+	case len(options.audience) == 0:
+		return nil, stderrors.New("oidc: the audience is required")
+	}
+	operations := make([]string, 0, len(options.scopes))
+	for k := range options.scopes {
+		operations = append(operations, k)
+	}
+	options.matcher = matcher.New(operations...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrMissingToken
+			}
+			token, ok := bearerToken(tr.RequestHeader().Get(authorizationHeader))
+			if !ok {
+				return nil, ErrMissingToken
+			}
+			p, err := options.validator.Validate(ctx, token)
+			if err != nil || !options.valid(p) {
+				return nil, ErrInvalidToken
+			}
+			for _, scope := range options.requiredScopes(tr.Operation()) {
+				if !p.HasScope(scope) {
+					return nil, ErrInsufficientScope
+				}
+			}
+			return handler(NewContext(ctx, p), req)
+		}
+	}, nil
+}
+
+func bearerToken(auth string) (string, bool) {
+	const prefix = "bearer "
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(auth[len(prefix):])
+	return token, token != ""
+}
+
+type principalKey struct{}
+
+// NewContext returns a new Context that carries the principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx by the server middleware, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Valuer returns a principal subject valuer.
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if p, ok := FromContext(ctx); ok {
+			return p.Subject
+		}
+		return ""
+	}
+}
+
+// Provider is the metadata of an OIDC provider.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	JWKSURI               string `json:"jwks_uri"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+}
+
+// Discover returns the metadata of the provider by its discovery document, the
+// /.well-known/openid-configuration of the issuer.
+func Discover(ctx context.Context, issuer string) (*Provider, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: discover: %s", res.Status)
+	}
+	var p Provider
+	if err := json.NewDecoder(res.Body).Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Issuer != issuer {
+		return nil, fmt.Errorf("oidc: issuer %q of the discovery document is not %q", p.Issuer, issuer)
+	}
+	return &p, nil
+}
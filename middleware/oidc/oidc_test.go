@@ -0,0 +1,249 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	operation string
+	reqHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+// signer signs the JWT tokens, and serves its keys as a JWKS.
+type signer struct {
+	rsa   *rsa.PrivateKey
+	ec    *ecdsa.PrivateKey
+	ec384 *ecdsa.PrivateKey
+}
+
+func newSigner(t *testing.T) *signer {
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &signer{rsa: rk, ec: ek, ec384: ek384}
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (s *signer) jwks(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(s.rsa.N.Bytes()), "e": encode(big.NewInt(int64(s.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(s.ec.X.Bytes()), "y": encode(s.ec.Y.Bytes())},
+		{"kty": "EC", "kid": "ec384", "crv": "P-384", "x": encode(s.ec384.X.Bytes()), "y": encode(s.ec384.Y.Bytes())},
+		{"kty": "oct", "kid": "hmac", "k": encode([]byte("secret"))},
+	}})
+}
+
+func (s *signer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "at+jwt"})
+	payload, _ := json.Marshal(claims)
+	signed := encode(header) + "." + encode(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var (
+		sig []byte
+		err error
+	)
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, s.rsa, crypto.SHA256, digest[:])
+	case "PS256":
+		sig, err = rsa.SignPSS(rand.Reader, s.rsa, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256":
+		// the kid selects the curve, so that the binding of the algorithm is tested.
+		key := s.ec
+		if kid == "ec384" {
+			key = s.ec384
+		}
+		var r, ss *big.Int
+		r, ss, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		size := (key.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		ss.FillBytes(sig[size:])
+	default:
+		sig = []byte("unsigned")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + encode(sig)
+}
+
+func TestServer(t *testing.T) {
+	s := newSigner(t)
+	ts := httptest.NewServer(http.HandlerFunc(s.jwks))
+	defer ts.Close()
+
+	m, err := Server(
+		WithValidator(NewJWKS(ts.URL)),
+		WithIssuer("https://accounts.example.com"),
+		WithAudience("billing"),
+		WithScopes("/billing.v1.Billing/*", "billing:read"),
+		WithScopes("/billing.v1.Billing/Refund", "billing:read", "billing:write"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := func(mutate func(map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   "https://accounts.example.com",
+			"sub":   "alice",
+			"aud":   []string{"billing", "other"},
+			"scope": "openid billing:read",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+	tests := []struct {
+		name      string
+		operation string
+		auth      string
+		err       error
+	}{
+		{"rsa", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "RS256", "rsa", claims(nil)), nil},
+		{"pss", "/billing.v1.Billing/Charge", "bearer " + s.sign(t, "PS256", "rsa", claims(nil)), nil},
+		{"ec", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "ES256", "ec", claims(nil)), nil},
+		{"missing", "/billing.v1.Billing/Charge", "", ErrMissingToken},
+		{"basic", "/billing.v1.Billing/Charge", "Basic YWxpY2U6c2VjcmV0", ErrMissingToken},
+		{"none", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "none", "rsa", claims(nil)), ErrInvalidToken},
+		{"hmac", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "HS256", "hmac", claims(nil)), ErrInvalidToken},
+		{"unknown key", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "RS256", "other", claims(nil)), ErrInvalidToken},
+		{"curve", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "ES256", "ec384", claims(nil)), ErrInvalidToken},
+		{"no expiry", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) {
+			delete(c, "exp")
+		})), ErrInvalidToken},
+		{"expired", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) {
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+		})), ErrInvalidToken},
+		{"issuer", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example.com"
+		})), ErrInvalidToken},
+		{"audience", "/billing.v1.Billing/Charge", "Bearer " + s.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) {
+			c["aud"] = "other"
+		})), ErrInvalidToken},
+		{"scope", "/billing.v1.Billing/Refund", "Bearer " + s.sign(t, "RS256", "rsa", claims(nil)), ErrInsufficientScope},
+		{"scp", "/billing.v1.Billing/Refund", "Bearer " + s.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) {
+			delete(c, "scope")
+			c["scp"] = []string{"billing:read", "billing:write"}
+		})), nil},
+		{"no scopes", "/helloworld.Greeter/SayHello", "Bearer " + s.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) {
+			delete(c, "scope")
+		})), nil},
+	}
+	for _, test := range tests {
+		var subject string
+		h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+			p, _ := FromContext(ctx)
+			subject = p.Subject
+			return nil, nil
+		})
+		header := headerCarrier{}
+		if test.auth != "" {
+			header.Set("Authorization", test.auth)
+		}
+		ctx := transport.NewServerContext(context.Background(), &mockTransport{operation: test.operation, reqHeader: header})
+		_, err := h(ctx, nil)
+		if err != test.err {
+			t.Errorf("%s: got %v want %v", test.name, err, test.err)
+		}
+		if test.err == nil && subject != "alice" {
+			t.Errorf("%s: got %q want the principal", test.name, subject)
+		}
+	}
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if _, err := h(context.Background(), nil); err != ErrMissingToken {
+		t.Errorf("got %v want %v without the server transport", err, ErrMissingToken)
+	}
+}
+
+func TestServerOptions(t *testing.T) {
+	v := NewJWKS("https://accounts.example.com/keys")
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"validator", []Option{WithIssuer("https://accounts.example.com"), WithAudience("billing")}},
+		{"issuer", []Option{WithValidator(v), WithAudience("billing")}},
+		{"audience", []Option{WithValidator(v), WithIssuer("https://accounts.example.com")}},
+	}
+	for _, test := range tests {
+		if _, err := Server(test.opts...); err == nil {
+			t.Errorf("%s: got the middleware want the error", test.name)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 ts.URL,
+			"jwks_uri":               ts.URL + "/keys",
+			"introspection_endpoint": ts.URL + "/introspect",
+		})
+	}))
+	defer ts.Close()
+	p, err := Discover(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.JWKSURI != ts.URL+"/keys" || p.IntrospectionEndpoint != ts.URL+"/introspect" {
+		t.Errorf("got %+v want the provider", p)
+	}
+	if _, err := Discover(context.Background(), ts.URL+"/other"); err == nil {
+		t.Error("got discovered want the error")
+	}
+}
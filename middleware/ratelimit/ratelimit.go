@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/ratelimit"
+	"github.com/go-kratos/kratos/v2/ratelimit/bbr"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// ErrLimitExceed is service unavailable due to rate limit exceeded.
+var ErrLimitExceed = errors.ResourceExhausted("RATELIMIT", "service unavailable due to rate limit exceeded")
+
+// KeyFunc returns the rate limited key of the request, the request is not limited by
+// the key limiter if the key is empty.
+type KeyFunc func(ctx context.Context) string
+
+// Option is ratelimit option.
+type Option func(*options)
+
+// WithLimiter with the rate limiter, the default is a BBR limiter.
+func WithLimiter(limiter ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
+
+// WithKeyLimiter with the key rate limiter and the key of the requests, i.e. a distributed
+// limiter shared by the instances. The default BBR limiter is not used with a key limiter
+// unless WithLimiter is set as well.
+func WithKeyLimiter(limiter ratelimit.KeyLimiter, key KeyFunc) Option {
+	return func(o *options) {
+		o.keyLimiter = limiter
+		o.key = key
+	}
+}
+
+type options struct {
+	limiter    ratelimit.Limiter
+	keyLimiter ratelimit.KeyLimiter
+	key        KeyFunc
+}
+
+// Server is a server middleware that rejects requests when the limiter is triggered.
+func Server(opts ...Option) middleware.Middleware {
+	options := options{}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.limiter == nil && options.keyLimiter == nil {
+		options.limiter = bbr.NewLimiter()
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			if options.limiter != nil {
+				done, e := options.limiter.Allow()
+				if e != nil {
+					return nil, ErrLimitExceed
+				}
+				defer func() { done(ratelimit.DoneInfo{Err: err}) }()
+			}
+			if options.keyLimiter != nil {
+				if key := options.key(ctx); key != "" {
+					done, e := options.keyLimiter.AllowKey(ctx, key)
+					if e != nil {
+						return nil, ErrLimitExceed
+					}
+					defer func() { done(ratelimit.DoneInfo{Err: err}) }()
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// ByOperation limits the requests by the operation.
+func ByOperation() KeyFunc {
+	return func(ctx context.Context) string {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return tr.Operation()
+		}
+		return ""
+	}
+}
+
+// ByHeader limits the requests by the value of the request header, i.e. the API key.
+func ByHeader(name string) KeyFunc {
+	return func(ctx context.Context) string {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return tr.RequestHeader().Get(name)
+		}
+		return ""
+	}
+}
+
+// ByClientIP limits the requests by the client IP, which is the remote address of the
+// http request or the peer address of the grpc request. The X-Forwarded-For header is
+// only trusted if the remote address is one of the trusted proxy CIDRs, i.e. "10.0.0.0/8",
+// then the client IP is the right-most address of the header which is not a trusted
+// proxy, so the addresses spoofed by the clients are skipped. It panics if a CIDR is invalid.
+func ByClientIP(trustedProxies ...string) KeyFunc {
+	trusted := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, cidr := range trustedProxies {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("ratelimit: invalid trusted proxy %q: %v", cidr, err))
+		}
+		trusted = append(trusted, n)
+	}
+	isTrusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		for _, n := range trusted {
+			if ip != nil && n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(ctx context.Context) string {
+		tr, ok := transport.FromServerContext(ctx)
+		if !ok {
+			return ""
+		}
+		addr, xff := remoteAddr(ctx, tr)
+		if addr == "" || !isTrusted(addr) {
+			return addr
+		}
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			if addr = hop; !isTrusted(hop) {
+				break
+			}
+		}
+		return addr
+	}
+}
+
+// remoteAddr returns the remote address and the X-Forwarded-For headers of the request.
+func remoteAddr(ctx context.Context, tr transport.Transporter) (string, []string) {
+	if rt, ok := tr.(interface{ Request() *http.Request }); ok {
+		if r := rt.Request(); r != nil {
+			return host(r.RemoteAddr), r.Header.Values("X-Forwarded-For")
+		}
+		return "", nil
+	}
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = host(p.Addr.String())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return addr, md.Get("x-forwarded-for")
+}
+
+func host(addr string) string {
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		return h
+	}
+	return addr
+}
@@ -0,0 +1,200 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/ratelimit"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	kind      transport.Kind
+	operation string
+	reqHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+type mockHTTPTransport struct {
+	mockTransport
+	request *http.Request
+}
+
+func (tr *mockHTTPTransport) Request() *http.Request { return tr.request }
+
+type testLimiter struct {
+	allow bool
+	done  []ratelimit.DoneInfo
+}
+
+func (l *testLimiter) Allow() (ratelimit.DoneFunc, error) {
+	if !l.allow {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return func(di ratelimit.DoneInfo) {
+		l.done = append(l.done, di)
+	}, nil
+}
+
+type testKeyLimiter struct {
+	quota map[string]int
+	done  []ratelimit.DoneInfo
+}
+
+func (l *testKeyLimiter) AllowKey(ctx context.Context, key string) (ratelimit.DoneFunc, error) {
+	if l.quota[key] <= 0 {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.quota[key]--
+	return func(di ratelimit.DoneInfo) {
+		l.done = append(l.done, di)
+	}, nil
+}
+
+func TestServer(t *testing.T) {
+	handlerErr := errors.New("handler")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", handlerErr
+	}
+
+	l := &testLimiter{allow: true}
+	reply, err := Server(WithLimiter(l))(handler)(context.Background(), nil)
+	if reply != "reply" || err != handlerErr {
+		t.Errorf("got %v %v", reply, err)
+	}
+	if len(l.done) != 1 || l.done[0].Err != handlerErr {
+		t.Errorf("done is not called with the handler error: %v", l.done)
+	}
+
+	l.allow = false
+	if _, err := Server(WithLimiter(l))(handler)(context.Background(), nil); err != ErrLimitExceed {
+		t.Errorf("got %v want %v", err, ErrLimitExceed)
+	}
+}
+
+func TestServerKeyLimiter(t *testing.T) {
+	handlerErr := errors.New("handler")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", handlerErr
+	}
+	l := &testKeyLimiter{quota: map[string]int{"key1": 1}}
+	m := Server(WithKeyLimiter(l, ByHeader("x-api-key")))(handler)
+
+	call := func(key string) error {
+		tr := &mockTransport{reqHeader: headerCarrier{}}
+		if key != "" {
+			tr.reqHeader.Set("x-api-key", key)
+		}
+		_, err := m(transport.NewServerContext(context.Background(), tr), nil)
+		return err
+	}
+	if err := call("key1"); err != handlerErr {
+		t.Errorf("got %v want %v", err, handlerErr)
+	}
+	if len(l.done) != 1 || l.done[0].Err != handlerErr {
+		t.Errorf("done is not called with the handler error: %v", l.done)
+	}
+	if err := call("key1"); err != ErrLimitExceed {
+		t.Errorf("got %v want %v", err, ErrLimitExceed)
+	}
+	if err := call("key2"); err != ErrLimitExceed {
+		t.Errorf("got %v want %v", err, ErrLimitExceed)
+	}
+	// the requests without a key are not limited.
+	if err := call(""); err != handlerErr {
+		t.Errorf("got %v want %v", err, handlerErr)
+	}
+}
+
+func TestKeyFunc(t *testing.T) {
+	tr := &mockTransport{kind: transport.KindGRPC, operation: "/helloworld.Greeter/SayHello", reqHeader: headerCarrier{}}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5678}})
+	tests := []struct {
+		key  KeyFunc
+		want string
+	}{
+		{ByOperation(), "/helloworld.Greeter/SayHello"},
+		{ByHeader("x-api-key"), ""},
+		{ByClientIP(), "10.0.0.1"},
+	}
+	for _, test := range tests {
+		if got := test.key(ctx); got != test.want {
+			t.Errorf("got %s want %s", got, test.want)
+		}
+		if got := test.key(context.Background()); got != "" {
+			t.Errorf("got %s want empty key without the transport", got)
+		}
+	}
+}
+
+func TestByClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		remote  string
+		xff     []string
+		want    string
+	}{
+		{"remote", nil, "203.0.113.7:5678", nil, "203.0.113.7"},
+		{"untrusted xff", nil, "203.0.113.7:5678", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"untrusted proxy", []string{"10.0.0.0/8"}, "203.0.113.7:5678", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.2:5678", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed", []string{"10.0.0.0/8"}, "10.0.0.2:5678", []string{"192.0.2.9, 198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+		{"headers", []string{"10.0.0.0/8"}, "10.0.0.2:5678", []string{"192.0.2.9", "198.51.100.1"}, "198.51.100.1"},
+		{"all trusted", []string{"10.0.0.0/8"}, "10.0.0.2:5678", []string{"10.0.0.4, 10.0.0.3"}, "10.0.0.4"},
+		{"no xff", []string{"10.0.0.0/8"}, "10.0.0.2:5678", nil, "10.0.0.2"},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = test.remote
+		for _, v := range test.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		tr := &mockHTTPTransport{mockTransport: mockTransport{kind: transport.KindHTTP}, request: r}
+		if got := ByClientIP(test.trusted...)(transport.NewServerContext(context.Background(), tr)); got != test.want {
+			t.Errorf("%s: got %s want %s", test.name, got, test.want)
+		}
+	}
+
+	tr := &mockTransport{kind: transport.KindGRPC, reqHeader: headerCarrier{}}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5678}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "198.51.100.1"))
+	if got := ByClientIP()(ctx); got != "10.0.0.2" {
+		t.Errorf("got %s want the peer address", got)
+	}
+	if got := ByClientIP("10.0.0.0/8")(ctx); got != "198.51.100.1" {
+		t.Errorf("got %s want the forwarded address", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("want a panic for the invalid CIDR")
+		}
+	}()
+	ByClientIP("10.0.0.0")
+}
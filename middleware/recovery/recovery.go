@@ -1,12 +1,16 @@
 package recovery
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"runtime"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metrics"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
 )
 
 // HandlerFunc is recovery handler func.
@@ -16,11 +20,15 @@ type HandlerFunc func(ctx context.Context, req, err interface{}) error
 type Option func(*options)
 
 type options struct {
-	handler HandlerFunc
-	logger  log.Logger
+	handler    HandlerFunc
+	logger     log.Logger
+	stackSize  int
+	stackDepth int
+	panics     metrics.Counter
+	repanic    bool
 }
 
-// WithHandler with recovery handler.
+// WithHandler with recovery handler, which translates the panic into the returned error.
 func WithHandler(h HandlerFunc) Option {
 	return func(o *options) {
 		o.handler = h
@@ -34,10 +42,41 @@ func WithLogger(logger log.Logger) Option {
 	}
 }
 
+// WithStackSize with the max bytes of the logged stack, 64KB by default, zero disables the stack.
+func WithStackSize(size int) Option {
+	return func(o *options) {
+		o.stackSize = size
+	}
+}
+
+// WithStackDepth with the max frames of the logged stack from the frame which panics,
+// zero means no limit.
+func WithStackDepth(depth int) Option {
+	return func(o *options) {
+		o.stackDepth = depth
+	}
+}
+
+// WithPanics with panics counter, labeled by kind and operation.
+func WithPanics(c metrics.Counter) Option {
+	return func(o *options) {
+		o.panics = c
+	}
+}
+
+// WithRepanic panics again once the panic is logged and counted, so that it crashes
+// the process in development rather than being replied as an error.
+func WithRepanic(repanic bool) Option {
+	return func(o *options) {
+		o.repanic = repanic
+	}
+}
+
 // Recovery is a server middleware that recovers from any panics.
 func Recovery(opts ...Option) middleware.Middleware {
 	options := options{
-		logger: log.DefaultLogger,
+		logger:    log.GetLogger(),
+		stackSize: 64 << 10,
 		handler: func(ctx context.Context, req, err interface{}) error {
 			return errors.Unknown("Unknown", "panic triggered: %v", err)
 		},
@@ -45,16 +84,26 @@ func Recovery(opts ...Option) middleware.Middleware {
 	for _, o := range opts {
 		o(&options)
 	}
-	log := log.NewHelper("middleware/recovery", log.DefaultLogger)
+	log := log.NewHelper("middleware/recovery", options.logger)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			defer func() {
 				if rerr := recover(); rerr != nil {
-					buf := make([]byte, 64<<10)
-					n := runtime.Stack(buf, false)
-					buf = buf[:n]
-					log.Errorf("%v: %+v\n%s\n", rerr, req, buf)
-
+					var buf []byte
+					if options.stackSize > 0 {
+						buf = stack(options.stackDepth, options.stackSize)
+					}
+					log.WithContext(ctx).Errorf("%v: %+v\n%s\n", rerr, req, buf)
+					if options.panics != nil {
+						var kind, operation string
+						if tr, ok := transport.FromServerContext(ctx); ok {
+							kind, operation = tr.Kind().String(), tr.Operation()
+						}
+						options.panics.With(kind, operation).Inc()
+					}
+					if options.repanic {
+						panic(rerr)
+					}
 					err = options.handler(ctx, req, rerr)
 				}
 			}()
@@ -62,3 +111,38 @@ func Recovery(opts ...Option) middleware.Middleware {
 		}
 	}
 }
+
+// stack returns the stack of the panicking goroutine from the frame which panics,
+// with at most depth frames and size bytes.
+func stack(depth, size int) []byte {
+	pcs := make([]uintptr, 64+depth)
+	// skip runtime.Callers, stack and the deferred func of the middleware.
+	n := runtime.Callers(3, pcs)
+	var (
+		frames []runtime.Frame
+		start  int
+	)
+	it := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := it.Next()
+		frames = append(frames, f)
+		if f.Function == "runtime.gopanic" {
+			start = len(frames)
+		}
+		if !more {
+			break
+		}
+	}
+	frames = frames[start:]
+	if depth > 0 && len(frames) > depth {
+		frames = frames[:depth]
+	}
+	var buf bytes.Buffer
+	for _, f := range frames {
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+	if buf.Len() > size {
+		buf.Truncate(size)
+	}
+	return buf.Bytes()
+}
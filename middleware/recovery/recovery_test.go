@@ -0,0 +1,90 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metrics"
+)
+
+type mockCounter struct {
+	lvs   []string
+	value float64
+}
+
+func (c *mockCounter) With(lvs ...string) metrics.Counter {
+	c.lvs = lvs
+	return c
+}
+
+func (c *mockCounter) Inc()              { c.value++ }
+func (c *mockCounter) Add(delta float64) { c.value += delta }
+
+type mockLogger struct {
+	messages []string
+}
+
+func (l *mockLogger) Log(level log.Level, keyvals ...interface{}) error {
+	l.messages = append(l.messages, fmt.Sprint(keyvals...))
+	return nil
+}
+
+func panicking(ctx context.Context, req interface{}) (interface{}, error) {
+	panic("boom")
+}
+
+func TestRecovery(t *testing.T) {
+	logger := &mockLogger{}
+	counter := &mockCounter{}
+	m := Recovery(
+		WithLogger(logger),
+		WithPanics(counter),
+		WithHandler(func(ctx context.Context, req, err interface{}) error {
+			return errors.Internal("PANIC", "%v", err)
+		}),
+	)
+	_, err := m(panicking)(context.Background(), "req")
+	if !errors.IsInternal(err) || errors.Reason(err) != "PANIC" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counter.value != 1 || len(counter.lvs) != 2 {
+		t.Errorf("unexpected panics: %v %v", counter.value, counter.lvs)
+	}
+	if len(logger.messages) != 1 || !strings.Contains(logger.messages[0], "recovery.panicking") {
+		t.Errorf("unexpected log: %v", logger.messages)
+	}
+	if strings.Contains(logger.messages[0], "runtime.gopanic") {
+		t.Errorf("unexpected runtime frames: %s", logger.messages[0])
+	}
+}
+
+func TestRecoveryStack(t *testing.T) {
+	logger := &mockLogger{}
+	_, err := Recovery(WithLogger(logger), WithStackDepth(1))(panicking)(context.Background(), nil)
+	if errors.Code(err) == 0 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(logger.messages[0], "\n\t") != 1 {
+		t.Errorf("unexpected stack depth: %s", logger.messages[0])
+	}
+
+	logger = &mockLogger{}
+	Recovery(WithLogger(logger), WithStackSize(0))(panicking)(context.Background(), nil)
+	if strings.Contains(logger.messages[0], "\n\t") {
+		t.Errorf("unexpected stack: %s", logger.messages[0])
+	}
+}
+
+func TestRecoveryRepanic(t *testing.T) {
+	defer func() {
+		if err := recover(); err != "boom" {
+			t.Errorf("got %v want boom", err)
+		}
+	}()
+	Recovery(WithLogger(&mockLogger{}), WithRepanic(true))(panicking)(context.Background(), nil)
+	t.Error("expected the panic")
+}
@@ -0,0 +1,109 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/google/uuid"
+)
+
+// DefaultHeader is the default header of the request id.
+const DefaultHeader = "x-request-id"
+
+// Option is request id option.
+type Option func(*options)
+
+type options struct {
+	header    string
+	generator func() string
+}
+
+// WithHeader with the header of the request id, x-request-id by default.
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithGenerator with the generator of the request id, a random UUID by default.
+func WithGenerator(fn func() string) Option {
+	return func(o *options) {
+		o.generator = fn
+	}
+}
+
+type requestIDKey struct{}
+
+// NewContext returns a new Context that carries the request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request id stored in ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// Valuer returns a request id valuer.
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		id, _ := FromContext(ctx)
+		return id
+	}
+}
+
+func newOptions(opts []Option) options {
+	options := options{
+		header:    DefaultHeader,
+		generator: uuid.NewString,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
+
+// Server is a server middleware that propagates the request id of the request header,
+// or generates one if it is missing, and echoes it in the reply header.
+func Server(opts ...Option) middleware.Middleware {
+	options := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			id := tr.RequestHeader().Get(options.header)
+			if id == "" {
+				id = options.generator()
+			}
+			tr.ReplyHeader().Set(options.header, id)
+			return handler(NewContext(ctx, id), req)
+		}
+	}
+}
+
+// Client is a client middleware that sends the request id of the context,
+// or generates one if it is missing.
+func Client(opts ...Option) middleware.Middleware {
+	options := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			id, ok := FromContext(ctx)
+			if !ok {
+				id = options.generator()
+				ctx = NewContext(ctx, id)
+			}
+			tr.RequestHeader().Set(options.header, id)
+			return handler(ctx, req)
+		}
+	}
+}
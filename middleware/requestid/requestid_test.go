@@ -0,0 +1,89 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return "" }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"abc", "abc"},
+		{"", "generated"},
+	}
+	for _, test := range tests {
+		tr := &mockTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+		if test.header != "" {
+			tr.reqHeader.Set("X-Request-ID", test.header)
+		}
+		var id string
+		m := Server(WithGenerator(func() string { return "generated" }))
+		m(func(ctx context.Context, req interface{}) (interface{}, error) {
+			id, _ = FromContext(ctx)
+			return nil, nil
+		})(transport.NewServerContext(context.Background(), tr), nil)
+		if id != test.want {
+			t.Errorf("got %s want %s", id, test.want)
+		}
+		if got := tr.replyHeader.Get("X-Request-ID"); got != test.want {
+			t.Errorf("reply header: got %s want %s", got, test.want)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	tr := &mockTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+	ctx := transport.NewClientContext(NewContext(context.Background(), "abc"), tr)
+	h := Client()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	h(ctx, nil)
+	if got := tr.reqHeader.Get("X-Request-ID"); got != "abc" {
+		t.Errorf("got %s want abc", got)
+	}
+
+	tr = &mockTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+	h(transport.NewClientContext(context.Background(), tr), nil)
+	if got := tr.reqHeader.Get("X-Request-ID"); len(got) != 36 {
+		t.Errorf("expected a generated uuid, got %s", got)
+	}
+}
+
+func TestValuer(t *testing.T) {
+	v := Valuer()
+	if got := log.Value(NewContext(context.Background(), "abc"), v); got != "abc" {
+		t.Errorf("got %v want abc", got)
+	}
+	if got := log.Value(context.Background(), v); got != "" {
+		t.Errorf("got %v want empty", got)
+	}
+}
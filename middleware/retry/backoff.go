@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff returns the delay before the retry attempt, the attempt starts from 1.
+type Backoff func(attempt int) time.Duration
+
+var (
+	mu = sync.Mutex{}
+	r  = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Constant returns a backoff that always waits for the delay.
+func Constant(delay time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		return delay
+	}
+}
+
+// Exponential returns an exponential backoff which doubles the delay every attempt
+// from base up to max, the delay is randomized by the jitter factor in [0, 1],
+// i.e. a jitter of 0.2 makes the delay vary between 80% and 120%.
+func Exponential(base, max time.Duration, jitter float64) Backoff {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		if jitter > 0 {
+			mu.Lock()
+			f := 1 + jitter*(r.Float64()*2-1)
+			mu.Unlock()
+			delay = time.Duration(float64(delay) * f)
+		}
+		return delay
+	}
+}
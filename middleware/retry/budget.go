@@ -0,0 +1,48 @@
+package retry
+
+import (
+	"time"
+
+	"github.com/go-kratos/kratos/v2/internal/window"
+)
+
+// Budget limits the retries to a ratio of the requests in a 10 seconds window,
+// so that the retries cannot amplify the load of an overloaded service.
+type Budget struct {
+	ratio    float64
+	min      int64
+	requests *window.Window
+	retries  *window.Window
+}
+
+// NewBudget returns a retry budget which allows the retries up to ratio of the requests,
+// plus min retries in the window to allow retrying with low traffic.
+func NewBudget(ratio float64, min int64) *Budget {
+	return &Budget{
+		ratio:    ratio,
+		min:      min,
+		requests: window.New(10, time.Second),
+		retries:  window.New(10, time.Second),
+	}
+}
+
+func sum(w *window.Window) (n int64) {
+	w.Reduce(func(b window.Bucket) {
+		n += b.Count
+	})
+	return
+}
+
+// request records a request.
+func (b *Budget) request() {
+	b.requests.Add(1)
+}
+
+// retry withdraws a retry from the budget, it reports false if the budget is exhausted.
+func (b *Budget) retry() bool {
+	if float64(sum(b.retries)) >= b.ratio*float64(sum(b.requests))+float64(b.min) {
+		return false
+	}
+	b.retries.Add(1)
+	return true
+}
@@ -0,0 +1,121 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+// Option is retry option.
+type Option func(*options)
+
+// WithAttempts with the max number of attempts, including the first call.
+func WithAttempts(n int) Option {
+	return func(o *options) {
+		o.attempts = n
+	}
+}
+
+// WithCodes with the error codes to retry on.
+func WithCodes(codes ...int32) Option {
+	return func(o *options) {
+		o.codes = codes
+	}
+}
+
+// WithBackoff with the backoff between attempts.
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithPerAttemptTimeout with the timeout of each attempt, zero means no timeout.
+func WithPerAttemptTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithBudget with the retry budget, nil disables the budget.
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithIdempotent with the func reports whether the call is idempotent,
+// only idempotent calls are retried, all calls are by default.
+func WithIdempotent(fn func(ctx context.Context, req interface{}) bool) Option {
+	return func(o *options) {
+		o.idempotent = fn
+	}
+}
+
+type options struct {
+	attempts   int
+	codes      []int32
+	backoff    Backoff
+	timeout    time.Duration
+	budget     *Budget
+	idempotent func(ctx context.Context, req interface{}) bool
+}
+
+func (o *options) retryable(err error) bool {
+	code := errors.Code(err)
+	for _, c := range o.codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Client is a client middleware that retries the failed calls.
+func Client(opts ...Option) middleware.Middleware {
+	options := options{
+		attempts: 3,
+		// Unavailable
+		codes:   []int32{14},
+		backoff: Exponential(100*time.Millisecond, time.Second, 0.2),
+		budget:  NewBudget(0.2, 10),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			if options.budget != nil {
+				options.budget.request()
+			}
+			retry := options.idempotent == nil || options.idempotent(ctx, req)
+			for attempt := 1; ; attempt++ {
+				reply, err = call(ctx, handler, req, options.timeout)
+				if err == nil || !retry || attempt >= options.attempts || !options.retryable(err) {
+					return
+				}
+				if options.budget != nil && !options.budget.retry() {
+					return
+				}
+				timer := time.NewTimer(options.backoff(attempt))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+		}
+	}
+}
+
+func call(ctx context.Context, handler middleware.Handler, req interface{}, timeout time.Duration) (interface{}, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,137 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+func TestRetry(t *testing.T) {
+	var calls int
+	h := Client(WithBackoff(Constant(0)))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.Unavailable("TEST", "unavailable")
+		}
+		return "reply", nil
+	})
+	reply, err := h(context.Background(), nil)
+	if err != nil || reply != "reply" || calls != 3 {
+		t.Errorf("got reply=%v err=%v calls=%d", reply, err, calls)
+	}
+}
+
+func TestRetryAttempts(t *testing.T) {
+	var calls int
+	h := Client(WithAttempts(2), WithBackoff(Constant(0)))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, errors.Unavailable("TEST", "unavailable")
+	})
+	if _, err := h(context.Background(), nil); !errors.IsUnavailable(err) || calls != 2 {
+		t.Errorf("got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestRetryCodes(t *testing.T) {
+	var calls int
+	h := Client(WithBackoff(Constant(0)))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, errors.InvalidArgument("TEST", "invalid")
+	})
+	if _, err := h(context.Background(), nil); err == nil || calls != 1 {
+		t.Errorf("non-retryable error is retried: calls=%d", calls)
+	}
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	var calls int
+	h := Client(WithBackoff(Constant(0)), WithIdempotent(func(ctx context.Context, req interface{}) bool {
+		return req == "get"
+	}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, errors.Unavailable("TEST", "unavailable")
+	})
+	h(context.Background(), "post")
+	if calls != 1 {
+		t.Errorf("non-idempotent call is retried: calls=%d", calls)
+	}
+	calls = 0
+	h(context.Background(), "get")
+	if calls != 3 {
+		t.Errorf("idempotent call is not retried: calls=%d", calls)
+	}
+}
+
+func TestRetryPerAttemptTimeout(t *testing.T) {
+	var calls int
+	h := Client(
+		WithBackoff(Constant(0)),
+		WithCodes(4),
+		WithPerAttemptTimeout(10*time.Millisecond),
+	)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		if calls == 1 {
+			<-ctx.Done()
+			return nil, errors.DeadlineExceeded("TEST", "timeout")
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("attempt has no deadline")
+		}
+		return "reply", nil
+	})
+	if reply, err := h(context.Background(), nil); err != nil || reply != "reply" || calls != 2 {
+		t.Errorf("got reply=%v err=%v calls=%d", reply, err, calls)
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	h := Client(WithBackoff(Constant(time.Hour)))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		cancel()
+		return nil, errors.Unavailable("TEST", "unavailable")
+	})
+	if _, err := h(ctx, nil); !errors.IsUnavailable(err) || calls != 1 {
+		t.Errorf("got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(0.5, 1)
+	for i := 0; i < 4; i++ {
+		b.request()
+	}
+	// 4 * 0.5 + 1
+	for i := 0; i < 3; i++ {
+		if !b.retry() {
+			t.Fatalf("retry %d is not allowed", i)
+		}
+	}
+	if b.retry() {
+		t.Error("retry is allowed beyond the budget")
+	}
+}
+
+func TestExponential(t *testing.T) {
+	b := Exponential(100*time.Millisecond, time.Second, 0)
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		if got := b(attempt); got != want {
+			t.Errorf("attempt %d: got %s want %s", attempt, got, want)
+		}
+	}
+	j := Exponential(100*time.Millisecond, time.Second, 0.2)
+	for i := 0; i < 100; i++ {
+		if d := j(1); d < 80*time.Millisecond || d > 120*time.Millisecond {
+			t.Fatalf("jitter out of range: %s", d)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package selector
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// MatchFunc reports whether the middleware applies to the operation.
+type MatchFunc func(ctx context.Context, operation string) bool
+
+// Builder is a selector builder.
+type Builder struct {
+	client bool
+
+	prefix []string
+	regex  []*regexp.Regexp
+	path   []string
+	match  MatchFunc
+
+	ms []middleware.Middleware
+}
+
+// Server returns a selector builder of server middleware.
+func Server(ms ...middleware.Middleware) *Builder {
+	return &Builder{ms: ms}
+}
+
+// Client returns a selector builder of client middleware.
+func Client(ms ...middleware.Middleware) *Builder {
+	return &Builder{client: true, ms: ms}
+}
+
+// Prefix selects the operations with the prefixes.
+func (b *Builder) Prefix(prefix ...string) *Builder {
+	b.prefix = append(b.prefix, prefix...)
+	return b
+}
+
+// Regex selects the operations matching the regular expressions, it panics if an expression is invalid.
+func (b *Builder) Regex(regex ...string) *Builder {
+	for _, r := range regex {
+		b.regex = append(b.regex, regexp.MustCompile(r))
+	}
+	return b
+}
+
+// Path selects the operations equal to the paths.
+func (b *Builder) Path(path ...string) *Builder {
+	b.path = append(b.path, path...)
+	return b
+}
+
+// Match selects the operations with the match func.
+func (b *Builder) Match(fn MatchFunc) *Builder {
+	b.match = fn
+	return b
+}
+
+// Build builds the middleware, the chain only applies to the selected operations.
+// The operation is the gRPC full method, i.e. /helloworld.Greeter/SayHello,
+// or the HTTP route path, i.e. /v1/users/{id}, see transport.Transporter.
+func (b *Builder) Build() middleware.Middleware {
+	var chain middleware.Middleware
+	if len(b.ms) > 0 {
+		chain = middleware.Chain(b.ms[0], b.ms[1:]...)
+	}
+	if chain == nil {
+		return func(handler middleware.Handler) middleware.Handler {
+			return handler
+		}
+	}
+	return middleware.Select(func(ctx context.Context) bool {
+		return b.matches(ctx, b.operation(ctx))
+	}, chain)
+}
+
+func (b *Builder) operation(ctx context.Context) string {
+	var (
+		tr transport.Transporter
+		ok bool
+	)
+	if b.client {
+		tr, ok = transport.FromClientContext(ctx)
+	} else {
+		tr, ok = transport.FromServerContext(ctx)
+	}
+	if !ok {
+		return ""
+	}
+	return tr.Operation()
+}
+
+func (b *Builder) matches(ctx context.Context, operation string) bool {
+	for _, path := range b.path {
+		if operation == path {
+			return true
+		}
+	}
+	for _, prefix := range b.prefix {
+		if strings.HasPrefix(operation, prefix) {
+			return true
+		}
+	}
+	for _, regex := range b.regex {
+		if regex.MatchString(operation) {
+			return true
+		}
+	}
+	if b.match != nil {
+		return b.match(ctx, operation)
+	}
+	return false
+}
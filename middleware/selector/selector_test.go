@@ -0,0 +1,131 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type mockTransport struct {
+	kind      transport.Kind
+	operation string
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return nil }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return nil }
+
+func mark(called *bool) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			*called = true
+			return handler(ctx, req)
+		}
+	}
+}
+
+func grpcCtx(method string) context.Context {
+	return transport.NewServerContext(context.Background(), &mockTransport{kind: transport.KindGRPC, operation: method})
+}
+
+func httpCtx(path string) context.Context {
+	return transport.NewServerContext(context.Background(), &mockTransport{kind: transport.KindHTTP, operation: path})
+}
+
+func TestServer(t *testing.T) {
+	var called bool
+	m := Server(mark(&called)).
+		Path("/api.v1.Auth/Login").
+		Prefix("/api.v1.Admin/").
+		Regex(`^/api\.v1\.User/(Get|List)User$`).
+		Match(func(ctx context.Context, operation string) bool {
+			return operation == "/v1/users/{id}"
+		}).
+		Build()
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+
+	tests := []struct {
+		ctx  context.Context
+		want bool
+	}{
+		{grpcCtx("/api.v1.Auth/Login"), true},
+		{grpcCtx("/api.v1.Auth/Logout"), false},
+		{grpcCtx("/api.v1.Admin/Delete"), true},
+		{grpcCtx("/api.v1.User/GetUser"), true},
+		{grpcCtx("/api.v1.User/DeleteUser"), false},
+		{httpCtx("/v1/users/{id}"), true},
+		{httpCtx("/v1/users/1"), false},
+	}
+	for i, test := range tests {
+		called = false
+		if reply, err := h(test.ctx, nil); err != nil || reply != "reply" {
+			t.Errorf("%d: got reply=%v err=%v", i, reply, err)
+		}
+		if called != test.want {
+			t.Errorf("%d: got called=%t want %t", i, called, test.want)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	var called bool
+	h := Client(mark(&called)).Prefix("/helloworld.").Build()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	h(grpcCtx("/helloworld.Greeter/SayHello"), nil)
+	if called {
+		t.Error("client selector matches the server operation")
+	}
+	h(transport.NewClientContext(context.Background(), &mockTransport{kind: transport.KindGRPC, operation: "/helloworld.Greeter/SayHello"}), nil)
+	if !called {
+		t.Error("client operation is not matched")
+	}
+}
+
+func reject(called *bool) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			*called = true
+			return nil, errors.New("unauthorized")
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var called bool
+	m := middleware.Chain(
+		middleware.Named("recovery", mark(&called)),
+		reject(&called),
+		Server(middleware.Named("auth", mark(&called)), mark(&called)).Prefix("/api.v1.Admin/").Build(),
+		middleware.Named("logging", mark(&called)),
+	)
+	tests := []struct {
+		ctx  context.Context
+		want []string
+	}{
+		{grpcCtx("/api.v1.Admin/Delete"), []string{"recovery", "selector.reject.func1", "auth", "selector.mark.func1", "logging"}},
+		{grpcCtx("/api.v1.User/GetUser"), []string{"recovery", "selector.reject.func1", "logging"}},
+	}
+	for _, test := range tests {
+		if names := middleware.Inspect(test.ctx, m); !reflect.DeepEqual(names, test.want) {
+			t.Errorf("got %v want %v", names, test.want)
+		}
+	}
+	if names := middleware.Inspect(grpcCtx("/api.v1.User/GetUser"), middleware.Named("recovery", mark(&called))); !reflect.DeepEqual(names, []string{"recovery"}) {
+		t.Errorf("got %v want the named middleware", names)
+	}
+	if names := middleware.Inspect(grpcCtx("/api.v1.User/GetUser"), mark(&called)); !reflect.DeepEqual(names, []string{"selector.mark.func1"}) {
+		t.Errorf("got %v want the unnamed middleware", names)
+	}
+	if called {
+		t.Error("the middleware is invoked by the inspection")
+	}
+}
@@ -0,0 +1,255 @@
+// Package spiffe authenticates the mutual TLS peers by the SPIFFE IDs of their certificates,
+// and enforces the allowed peer identities per operation:
+//
+//	srv := grpc.NewServer(
+//		grpc.TLSConfig(conf),
+//		grpc.ClientCAs(bundle),
+//		grpc.Middleware(spiffe.Server(
+//			spiffe.WithTrustDomain("example.org"),
+//			spiffe.WithPolicy("/billing.v1.Billing/*", "spiffe://example.org/ns/prod/sa/checkout"),
+//			spiffe.WithPolicy("/grpc.health.v1.Health/*", "*"),
+//		)),
+//	)
+package spiffe
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/matcher"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+var (
+	// ErrUnauthorized is returned when the peer has no certificate of a SPIFFE ID.
+	ErrUnauthorized = errors.Unauthorized("UNAUTHORIZED", "no SPIFFE ID of the peer")
+	// ErrForbidden is returned when the SPIFFE ID of the peer is not allowed.
+	ErrForbidden = errors.PermissionDenied("FORBIDDEN", "SPIFFE ID of the peer is not allowed")
+)
+
+// ID is a SPIFFE ID, i.e. spiffe://example.org/ns/prod/sa/billing.
+type ID struct {
+	TrustDomain string
+	Path        string
+}
+
+// ParseID parses the SPIFFE ID.
+func ParseID(s string) (ID, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ID{}, err
+	}
+	if u.Scheme != "spiffe" || u.Host == "" || u.User != nil || u.Port() != "" || u.RawQuery != "" || u.Fragment != "" {
+		return ID{}, fmt.Errorf("spiffe: invalid ID %q", s)
+	}
+	return ID{TrustDomain: strings.ToLower(u.Host), Path: u.Path}, nil
+}
+
+// FromCertificate returns the SPIFFE ID of the certificate, which is its only URI SAN.
+func FromCertificate(cert *x509.Certificate) (ID, error) {
+	if len(cert.URIs) != 1 {
+		return ID{}, fmt.Errorf("spiffe: certificate has %d URI SANs, want 1", len(cert.URIs))
+	}
+	return ParseID(cert.URIs[0].String())
+}
+
+// String returns the SPIFFE ID of the URI form.
+func (id ID) String() string {
+	return "spiffe://" + id.TrustDomain + id.Path
+}
+
+// Option is spiffe option.
+type Option func(*options)
+
+// WithTrustDomain with the trust domains of the peers, all the trust domains trusted by
+// the client CAs by default.
+func WithTrustDomain(domains ...string) Option {
+	return func(o *options) {
+		for _, d := range domains {
+			o.domains = append(o.domains, strings.ToLower(d))
+		}
+	}
+}
+
+// WithPolicy with the allowed peer identities of the operation, the operations without
+// a policy are denied, so the policy of "*" is the policy of the other operations. An
+// operation ends with "*" matches the operations of the prefix, i.e. /helloworld.Greeter/*,
+// and the longest one wins. An identity ends with "/*" matches the identities of the path,
+// i.e. spiffe://example.org/ns/prod/*, and "*" matches all the identities of the trust
+// domains, i.e. WithPolicy("*", "*") allows all the peers explicitly.
+func WithPolicy(operation string, ids ...string) Option {
+	return func(o *options) {
+		o.policies[operation] = append(o.policies[operation], ids...)
+	}
+}
+
+type options struct {
+	domains  []string
+	policies map[string][]string
+	matcher  *matcher.Matcher
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		policies: make(map[string][]string),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	operations := make([]string, 0, len(o.policies))
+	for k := range o.policies {
+		operations = append(operations, k)
+	}
+	o.matcher = matcher.New(operations...)
+	return o
+}
+
+// allowed reports whether the peer identity is allowed to call the operation, the
+// operations without a policy are denied.
+func (o *options) allowed(operation string, id ID) bool {
+	if len(o.domains) > 0 && !contains(o.domains, id.TrustDomain) {
+		return false
+	}
+	k, ok := o.matcher.Match(operation)
+	return ok && match(o.policies[k], id.String())
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the identity matches one of the patterns, a pattern ends with "/*"
+// matches the identities of the path only, so spiffe://example.org/ns/prod/* does not match
+// spiffe://example.org/ns/prod-evil/sa/any.
+func match(patterns []string, id string) bool {
+	for _, p := range patterns {
+		if p == id || p == "*" || strings.HasSuffix(p, "/*") && strings.HasPrefix(id, p[:len(p)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Server is a server middleware that authenticates the peer by the SPIFFE ID of its
+// certificate verified by the mutual TLS, and enforces the policies of the operations.
+// The requests without the server transport are rejected.
+func Server(opts ...Option) middleware.Middleware {
+	options := newOptions(opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrUnauthorized
+			}
+			certs := PeerCertificates(ctx)
+			if len(certs) == 0 {
+				return nil, ErrUnauthorized
+			}
+			id, err := FromCertificate(certs[0])
+			if err != nil {
+				return nil, ErrUnauthorized
+			}
+			if !options.allowed(tr.Operation(), id) {
+				return nil, ErrForbidden
+			}
+			return handler(NewContext(ctx, id), req)
+		}
+	}
+}
+
+// PeerCertificates returns the verified certificates of the peer of the server request,
+// the leaf one is the first.
+func PeerCertificates(ctx context.Context) []*x509.Certificate {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if ht, ok := tr.(*http.Transport); ok {
+			if req := ht.Request(); req != nil && req.TLS != nil {
+				return req.TLS.PeerCertificates
+			}
+			return nil
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			return info.State.PeerCertificates
+		}
+	}
+	return nil
+}
+
+// VerifyPeer returns the func of tls.Config.VerifyPeerCertificate, which verifies the peer
+// certificate by the roots and its SPIFFE ID against the allowed identities, i.e. the client
+// verifies the server identity. It is used with the InsecureSkipVerify of the config, since
+// the SPIFFE certificates have no DNS names to verify. An identity ends with "/*" matches
+// the identities of the path.
+func VerifyPeer(roots *x509.CertPool, ids ...string) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("spiffe: no peer certificate")
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, cert)
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return err
+		}
+		id, err := FromCertificate(certs[0])
+		if err != nil {
+			return err
+		}
+		if !match(ids, id.String()) {
+			return fmt.Errorf("spiffe: peer ID %s is not allowed", id)
+		}
+		return nil
+	}
+}
+
+type idKey struct{}
+
+// NewContext returns a new Context that carries the SPIFFE ID of the peer.
+func NewContext(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the SPIFFE ID of the peer stored in ctx by the server middleware, if any.
+func FromContext(ctx context.Context) (ID, bool) {
+	id, ok := ctx.Value(idKey{}).(ID)
+	return id, ok
+}
+
+// Valuer returns a peer SPIFFE ID valuer.
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if id, ok := FromContext(ctx); ok {
+			return id.String()
+		}
+		return ""
+	}
+}
@@ -0,0 +1,249 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	stdhttp "net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newAuthority(t *testing.T) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &authority{cert: cert, key: key, pool: pool}
+}
+
+// issue issues the certificate of the SPIFFE ID for both the server and the client.
+func (a *authority) issue(t *testing.T, id string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{u},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"spiffe://Example.org/ns/prod/sa/billing", true},
+		{"spiffe://example.org", true},
+		{"https://example.org/billing", false},
+		{"spiffe:///billing", false},
+		{"spiffe://example.org:8080/billing", false},
+		{"spiffe://example.org/billing?q=1", false},
+	}
+	for _, test := range tests {
+		id, err := ParseID(test.id)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got %v want valid %t", test.id, err, test.valid)
+		}
+		if err == nil && id.String() != strings.Replace(test.id, "Example", "example", 1) {
+			t.Errorf("got %s want %s", id, test.id)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	o := newOptions(
+		WithTrustDomain("example.org"),
+		WithPolicy("/billing.v1.Billing/*", "spiffe://example.org/ns/prod/*", "spiffe://example.org/ns/ops*"),
+		WithPolicy("/billing.v1.Billing/Refund", "spiffe://example.org/ns/prod/sa/admin"),
+		WithPolicy("/helloworld.Greeter/*", "*"),
+	)
+	tests := []struct {
+		operation string
+		id        string
+		allowed   bool
+	}{
+		{"/billing.v1.Billing/Charge", "spiffe://example.org/ns/prod/sa/checkout", true},
+		{"/billing.v1.Billing/Charge", "spiffe://example.org/ns/dev/sa/checkout", false},
+		{"/billing.v1.Billing/Charge", "spiffe://example.org/ns/prod-evil/sa/checkout", false},
+		{"/billing.v1.Billing/Charge", "spiffe://example.org/ns/ops/sa/any", false},
+		{"/billing.v1.Billing/Refund", "spiffe://example.org/ns/prod/sa/checkout", false},
+		{"/billing.v1.Billing/Refund", "spiffe://example.org/ns/prod/sa/admin", true},
+		{"/helloworld.Greeter/SayHello", "spiffe://example.org/ns/dev/sa/any", true},
+		{"/helloworld.Greeter/SayHello", "spiffe://other.org/ns/prod/sa/any", false},
+		// the operations without a policy are denied.
+		{"/admin.v1.Admin/Reset", "spiffe://example.org/ns/prod/sa/admin", false},
+	}
+	for _, test := range tests {
+		id, err := ParseID(test.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := o.allowed(test.operation, id); got != test.allowed {
+			t.Errorf("%s %s: got %t want %t", test.operation, test.id, got, test.allowed)
+		}
+	}
+}
+
+func TestServerContext(t *testing.T) {
+	h := Server(WithPolicy("*", "*"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if _, err := h(context.Background(), nil); err != ErrUnauthorized {
+		t.Errorf("got %v want %v without the server transport", err, ErrUnauthorized)
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	ca := newAuthority(t)
+	srv := grpc.NewServer(
+		grpc.Address("127.0.0.1:0"),
+		grpc.TLSConfig(&tls.Config{Certificates: []tls.Certificate{ca.issue(t, "spiffe://example.org/ns/prod/sa/health")}}),
+		grpc.ClientCAs(ca.pool),
+		grpc.Middleware(Server(WithPolicy("/grpc.health.v1.Health/*", "spiffe://example.org/ns/prod/sa/checkout"))),
+	)
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(); err != nil {
+			t.Error(err)
+		}
+	}()
+	defer srv.Stop()
+
+	tests := []struct {
+		client string
+		server string
+		code   int
+	}{
+		{"spiffe://example.org/ns/prod/sa/checkout", "spiffe://example.org/ns/prod/sa/health", 200},
+		{"spiffe://example.org/ns/prod/sa/other", "spiffe://example.org/ns/prod/sa/health", 403},
+		// the client rejects the server of the unexpected identity.
+		{"spiffe://example.org/ns/prod/sa/checkout", "spiffe://example.org/ns/prod/sa/billing", 0},
+	}
+	for _, test := range tests {
+		conn, err := grpc.Dial(context.Background(),
+			grpc.WithEndpoint(strings.TrimSuffix(strings.TrimPrefix(endpoint, "grpc://"), "?isSecure=true")),
+			grpc.WithTLSConfig(&tls.Config{
+				Certificates:          []tls.Certificate{ca.issue(t, test.client)},
+				InsecureSkipVerify:    true,
+				VerifyPeerCertificate: VerifyPeer(ca.pool, test.server),
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		conn.Close()
+		switch {
+		case test.code == 200 && err != nil:
+			t.Errorf("%s: got %v want served", test.client, err)
+		case test.code == 403 && errors.Reason(err) != "FORBIDDEN":
+			t.Errorf("%s: got %v want forbidden", test.client, err)
+		case test.code == 0 && err == nil:
+			t.Errorf("%s: got served want the handshake error", test.server)
+		}
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	ca := newAuthority(t)
+	var got ID
+	srv := http.NewServer(
+		http.Address("127.0.0.1:0"),
+		http.TLSConfig(&tls.Config{Certificates: []tls.Certificate{ca.issue(t, "spiffe://example.org/ns/prod/sa/web")}}),
+		http.ClientCAs(ca.pool),
+	)
+	srv.RouteGroup("/").GET("/hello", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		_, err := Server(WithPolicy("*", "*"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+			got, _ = FromContext(ctx)
+			return nil, nil
+		})(r.Context(), nil)
+		if err != nil {
+			w.WriteHeader(stdhttp.StatusUnauthorized)
+		}
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(); err != nil {
+			t.Error(err)
+		}
+	}()
+	defer srv.Stop()
+
+	client := &stdhttp.Client{Transport: &stdhttp.Transport{TLSClientConfig: &tls.Config{
+		Certificates:          []tls.Certificate{ca.issue(t, "spiffe://example.org/ns/prod/sa/checkout")},
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: VerifyPeer(ca.pool, "spiffe://example.org/ns/prod/*"),
+	}}}
+	res, err := client.Get(endpoint + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != stdhttp.StatusOK || got.String() != "spiffe://example.org/ns/prod/sa/checkout" {
+		t.Errorf("got %d %s want the client identity", res.StatusCode, got)
+	}
+
+	// the client without a certificate is rejected by the handshake.
+	client = &stdhttp.Client{Transport: &stdhttp.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	if res, err := client.Get(endpoint + "/hello"); err == nil {
+		res.Body.Close()
+		t.Error("got served want the handshake error")
+	}
+}
@@ -0,0 +1,45 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTenantNotFound is returned by the stores when the tenant is unknown.
+var ErrTenantNotFound = errors.New("tenant: tenant not found")
+
+// Tenant is a tenant of the service.
+type Tenant struct {
+	ID       string
+	Name     string
+	Disabled bool
+	Metadata map[string]string
+}
+
+// Store is the tenant store.
+type Store interface {
+	// Lookup returns the tenant of the id, or ErrTenantNotFound if the id is unknown.
+	Lookup(ctx context.Context, id string) (*Tenant, error)
+}
+
+// StoreFunc is an adapter of a function as a Store.
+type StoreFunc func(ctx context.Context, id string) (*Tenant, error)
+
+// Lookup calls f(ctx, id).
+func (f StoreFunc) Lookup(ctx context.Context, id string) (*Tenant, error) {
+	return f(ctx, id)
+}
+
+// NewStaticStore returns a store of the tenants, i.e. of the config.
+func NewStaticStore(tenants ...*Tenant) Store {
+	m := make(map[string]*Tenant, len(tenants))
+	for _, t := range tenants {
+		m[t.ID] = t
+	}
+	return StoreFunc(func(ctx context.Context, id string) (*Tenant, error) {
+		if t, ok := m[id]; ok {
+			return t, nil
+		}
+		return nil, ErrTenantNotFound
+	})
+}
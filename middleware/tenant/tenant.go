@@ -0,0 +1,247 @@
+// Package tenant resolves the tenant of the requests by a header or a token claim,
+// validates it against the tenant store, carries it in the context for the handlers
+// and the logs, and propagates it to the downstream calls. The tenant of an authenticated
+// request is the tenant claim of its principal, so a principal can't pick another tenant
+// by the header. The server middleware also carries the tenant in the server metadata by
+// MetadataKey, so the metadata client middleware propagates it to the downstream calls
+// without the Client middleware, and the downstream tenant middleware resolves it:
+//
+//	auth, err := oidc.Server(oidc.WithValidator(jwks), oidc.WithIssuer(issuer), oidc.WithAudience("billing"))
+//	srv := grpc.NewServer(grpc.Middleware(middleware.Chain(
+//		metadata.Server(),
+//		auth,
+//		tenant.Server(
+//			tenant.WithStore(store),
+//			tenant.WithClaim("tenant_id"),
+//		),
+//	)))
+//	conn, err := grpc.DialInsecure(ctx, grpc.WithMiddleware(metadata.Client()))
+//	logger = log.With(logger, "tenant", tenant.Valuer())
+package tenant
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/oidc"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const (
+	defaultHeader = "X-Tenant-ID"
+	defaultClaim  = "tenant_id"
+)
+
+// MetadataKey is the metadata key of the tenant, its global prefix is propagated by the
+// metadata client middleware by default.
+const MetadataKey = "x-md-global-tenant-id"
+
+var (
+	// ErrMissingTenant is returned when the request has no tenant.
+	ErrMissingTenant = errors.InvalidArgument("TENANT_MISSING", "missing tenant")
+	// ErrUnknownTenant is returned when the tenant is unknown or disabled.
+	ErrUnknownTenant = errors.PermissionDenied("TENANT_UNKNOWN", "unknown tenant")
+	// ErrTenantMismatch is returned when the tenant of the request is not the tenant of
+	// its principal.
+	ErrTenantMismatch = errors.PermissionDenied("TENANT_MISMATCH", "tenant mismatch")
+	// ErrStoreUnavailable is returned when the tenant store fails to look up the tenant.
+	ErrStoreUnavailable = errors.Unavailable("TENANT", "tenant store unavailable")
+)
+
+// Extractor returns the tenant id of the request, or an empty string if it has none.
+type Extractor func(ctx context.Context) string
+
+// FromHeader extracts the tenant id from the request header, the header of the grpc
+// requests is their metadata.
+func FromHeader(name string) Extractor {
+	return func(ctx context.Context) string {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return tr.RequestHeader().Get(name)
+		}
+		return ""
+	}
+}
+
+// FromClaim extracts the tenant id from the claim of the token validated by the oidc
+// middleware, which must run before the tenant middleware.
+func FromClaim(name string) Extractor {
+	return func(ctx context.Context) string {
+		if p, ok := oidc.FromContext(ctx); ok {
+			id, _ := p.Claims[name].(string)
+			return id
+		}
+		return ""
+	}
+}
+
+// Option is tenant option.
+type Option func(*options)
+
+// WithStore with the tenant store, the tenants are not validated by default.
+func WithStore(s Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+// WithExtractor with the extractors of the tenant id of the requests without a principal,
+// the first non-empty id wins, the X-Tenant-ID header and then the MetadataKey header
+// by default.
+func WithExtractor(e ...Extractor) Option {
+	return func(o *options) {
+		o.extractors = e
+	}
+}
+
+// WithClaim with the claim of the tenant id of the principals validated by the oidc
+// middleware, tenant_id by default. The requests of a principal without the claim are
+// rejected, and so are the requests whose header names another tenant.
+func WithClaim(name string) Option {
+	return func(o *options) {
+		o.claim = name
+	}
+}
+
+// WithHeader with the request header the tenant id is propagated by, X-Tenant-ID by default.
+func WithHeader(name string) Option {
+	return func(o *options) {
+		o.header = name
+	}
+}
+
+type options struct {
+	store      Store
+	extractors []Extractor
+	header     string
+	claim      string
+}
+
+// Server is a server middleware that resolves the tenant of the requests, and rejects
+// the requests without a known tenant. It should run after the oidc middleware, so the
+// tenant of the authenticated requests is the tenant of their principal, and after the
+// metadata middleware, so the resolved tenant replaces the MetadataKey of the request.
+// The requests without the server transport are rejected.
+func Server(opts ...Option) middleware.Middleware {
+	options := options{
+		header: defaultHeader,
+		claim:  defaultClaim,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if len(options.extractors) == 0 {
+		options.extractors = []Extractor{FromHeader(options.header), FromHeader(MetadataKey)}
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := transport.FromServerContext(ctx); !ok {
+				return nil, ErrMissingTenant
+			}
+			id, err := resolve(ctx, &options)
+			if err != nil {
+				return nil, err
+			}
+			t := &Tenant{ID: id}
+			if options.store != nil {
+				if t, err = options.store.Lookup(ctx, id); err != nil {
+					if err == ErrTenantNotFound {
+						return nil, ErrUnknownTenant
+					}
+					return nil, ErrStoreUnavailable
+				}
+				if t.Disabled {
+					return nil, ErrUnknownTenant
+				}
+			}
+			return handler(withMetadata(NewContext(ctx, t), t.ID), req)
+		}
+	}
+}
+
+// withMetadata returns a new Context whose server metadata carries the tenant id.
+func withMetadata(ctx context.Context, id string) context.Context {
+	md, ok := metadata.FromServerContext(ctx)
+	if ok {
+		md = md.Clone()
+	} else {
+		md = metadata.Metadata{}
+	}
+	md.Set(MetadataKey, id)
+	return metadata.NewServerContext(ctx, md)
+}
+
+// resolve returns the tenant id of the request, which is the claim of the principal if
+// the request is authenticated.
+func resolve(ctx context.Context, o *options) (string, error) {
+	if _, ok := oidc.FromContext(ctx); ok {
+		id := FromClaim(o.claim)(ctx)
+		if id == "" {
+			return "", ErrMissingTenant
+		}
+		for _, name := range []string{o.header, MetadataKey} {
+			if h := FromHeader(name)(ctx); h != "" && h != id {
+				return "", ErrTenantMismatch
+			}
+		}
+		return id, nil
+	}
+	for _, e := range o.extractors {
+		if id := e(ctx); id != "" {
+			return id, nil
+		}
+	}
+	return "", ErrMissingTenant
+}
+
+// Client is a client middleware that propagates the tenant of the context to the
+// downstream services by the request header of WithHeader, i.e. the services resolving
+// the tenant by another header than MetadataKey.
+func Client(opts ...Option) middleware.Middleware {
+	options := options{
+		header: defaultHeader,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if t, ok := FromContext(ctx); ok {
+				if tr, ok := transport.FromClientContext(ctx); ok {
+					tr.RequestHeader().Set(options.header, t.ID)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+type tenantKey struct{}
+
+// NewContext returns a new Context that carries the tenant.
+func NewContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// FromContext returns the tenant stored in ctx by the server middleware, if any.
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(tenantKey{}).(*Tenant)
+	return t, ok
+}
+
+// ID returns the tenant id stored in ctx, or an empty string if it has none.
+func ID(ctx context.Context) string {
+	if t, ok := FromContext(ctx); ok {
+		return t.ID
+	}
+	return ""
+}
+
+// Valuer returns a tenant id valuer.
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		return ID(ctx)
+	}
+}
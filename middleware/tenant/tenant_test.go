@@ -0,0 +1,156 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	mmd "github.com/go-kratos/kratos/v2/middleware/metadata"
+	"github.com/go-kratos/kratos/v2/middleware/oidc"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	reqHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return "/helloworld.Greeter/SayHello" }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestServer(t *testing.T) {
+	store := NewStaticStore(
+		&Tenant{ID: "acme", Name: "ACME"},
+		&Tenant{ID: "globex", Disabled: true},
+	)
+	failing := StoreFunc(func(ctx context.Context, id string) (*Tenant, error) {
+		return nil, context.DeadlineExceeded
+	})
+	claims := &oidc.Principal{Claims: map[string]interface{}{"tenant_id": "acme", "org": "acme"}}
+	noClaim := &oidc.Principal{Claims: map[string]interface{}{"sub": "alice"}}
+	tests := []struct {
+		name   string
+		opts   []Option
+		header string
+		claims *oidc.Principal
+		want   string
+		err    error
+	}{
+		{"header", []Option{WithStore(store)}, "acme", nil, "ACME", nil},
+		{"unvalidated", nil, "initech", nil, "", nil},
+		{"missing", []Option{WithStore(store)}, "", nil, "", ErrMissingTenant},
+		{"unknown", []Option{WithStore(store)}, "initech", nil, "", ErrUnknownTenant},
+		{"disabled", []Option{WithStore(store)}, "globex", nil, "", ErrUnknownTenant},
+		{"store", []Option{WithStore(failing)}, "acme", nil, "", ErrStoreUnavailable},
+		{"claim", []Option{WithStore(store)}, "", claims, "ACME", nil},
+		{"claim and header", []Option{WithStore(store)}, "acme", claims, "ACME", nil},
+		{"custom claim", []Option{WithStore(store), WithClaim("org")}, "", claims, "ACME", nil},
+		{"mismatch", []Option{WithStore(store)}, "globex", claims, "", ErrTenantMismatch},
+		{"principal without claim", []Option{WithStore(store)}, "acme", noClaim, "", ErrMissingTenant},
+		{"extractor", []Option{WithStore(store), WithExtractor(FromClaim("tenant_id"))}, "acme", nil, "", ErrMissingTenant},
+	}
+	for _, test := range tests {
+		var got *Tenant
+		h := Server(test.opts...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+			got, _ = FromContext(ctx)
+			return nil, nil
+		})
+		tr := &mockTransport{reqHeader: headerCarrier{}}
+		if test.header != "" {
+			tr.reqHeader.Set("X-Tenant-ID", test.header)
+		}
+		ctx := transport.NewServerContext(context.Background(), tr)
+		if test.claims != nil {
+			ctx = oidc.NewContext(ctx, test.claims)
+		}
+		_, err := h(ctx, nil)
+		if err != test.err {
+			t.Errorf("%s: got %v want %v", test.name, err, test.err)
+		}
+		if test.err == nil && (got == nil || got.Name != test.want) {
+			t.Errorf("%s: got %+v want %q", test.name, got, test.want)
+		}
+	}
+	h := Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if _, err := h(context.Background(), nil); err != ErrMissingTenant {
+		t.Errorf("got %v want %v without the server transport", err, ErrMissingTenant)
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := NewContext(context.Background(), &Tenant{ID: "acme"})
+	if got := Valuer()(ctx); got != "acme" {
+		t.Errorf("got %v want the tenant id", got)
+	}
+	tr := &mockTransport{reqHeader: headerCarrier{}}
+	h := Client(WithHeader("X-Md-Global-Tenant"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if _, err := h(transport.NewClientContext(ctx, tr), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := tr.reqHeader.Get("X-Md-Global-Tenant"); got != "acme" {
+		t.Errorf("got %q want the propagated tenant", got)
+	}
+	tr = &mockTransport{reqHeader: headerCarrier{}}
+	if _, err := h(transport.NewClientContext(context.Background(), tr), nil); err != nil || len(tr.reqHeader) != 0 {
+		t.Errorf("got %v %v want no tenant", err, tr.reqHeader)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	tr := &mockTransport{reqHeader: headerCarrier{}}
+	tr.reqHeader.Set("X-Tenant-ID", "acme")
+	// the tenant of the request replaces the one of the incoming metadata.
+	tr.reqHeader.Set(MetadataKey, "globex")
+	ctx := transport.NewServerContext(context.Background(), tr)
+	ctx = metadata.NewServerContext(ctx, metadata.Metadata{"x-md-global-uid": "1", MetadataKey: "globex"})
+	down := &mockTransport{reqHeader: headerCarrier{}}
+	h := Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		call := mmd.Client()(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return call(transport.NewClientContext(ctx, down), req)
+	})
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{MetadataKey: "acme", "x-md-global-uid": "1"} {
+		if got := down.reqHeader.Get(k); got != v {
+			t.Errorf("%s: got %q want %q", k, got, v)
+		}
+	}
+
+	// the downstream service resolves the propagated tenant.
+	var got string
+	h = Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = ID(ctx)
+		return nil, nil
+	})
+	if _, err := h(transport.NewServerContext(context.Background(), down), nil); err != nil || got != "acme" {
+		t.Errorf("got %q %v want the propagated tenant", got, err)
+	}
+	ctx = oidc.NewContext(transport.NewServerContext(context.Background(), down), &oidc.Principal{
+		Claims: map[string]interface{}{"tenant_id": "globex"},
+	})
+	if _, err := h(ctx, nil); err != ErrTenantMismatch {
+		t.Errorf("got %v want %v", err, ErrTenantMismatch)
+	}
+}
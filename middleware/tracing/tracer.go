@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer is otel span tracer.
+type Tracer struct {
+	tracer trace.Tracer
+	kind   trace.SpanKind
+	opt    *options
+}
+
+// NewTracer create tracer instance.
+func NewTracer(kind trace.SpanKind, opts ...Option) *Tracer {
+	options := options{
+		tracerProvider: otel.GetTracerProvider(),
+		propagator:     propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Tracer{
+		tracer: options.tracerProvider.Tracer("kratos"),
+		kind:   kind,
+		opt:    &options,
+	}
+}
+
+// Start start tracing span,
+// a server span continues the trace extracted from carrier, a client span injects its trace into carrier.
+func (t *Tracer) Start(ctx context.Context, operation string, carrier propagation.TextMapCarrier, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t.kind == trace.SpanKindServer {
+		ctx = t.opt.propagator.Extract(ctx, carrier)
+	}
+	ctx, span := t.tracer.Start(ctx,
+		operation,
+		trace.WithAttributes(attrs...),
+		trace.WithSpanKind(t.kind),
+	)
+	if t.kind == trace.SpanKindClient {
+		t.opt.propagator.Inject(ctx, carrier)
+	}
+	return ctx, span
+}
+
+// End finish tracing span, recording err if any.
+func (t *Tracer) End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(
+			attribute.Int64("kratos.status_code", int64(errors.Code(err))),
+			attribute.String("kratos.reason", errors.Reason(err)),
+		)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "OK")
+	}
+	span.End()
+}
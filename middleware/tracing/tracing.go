@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"path"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option is tracing option.
+type Option func(*options)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithPropagator with tracer propagator, i.e. b3.New() for B3 headers.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(opts *options) {
+		opts.propagator = propagator
+	}
+}
+
+// WithTracerProvider with tracer provider.
+// By default, it uses the global provider that is set by otel.SetTracerProvider(provider).
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(opts *options) {
+		opts.tracerProvider = provider
+	}
+}
+
+// Server returns a new server middleware for OpenTelemetry.
+func Server(opts ...Option) middleware.Middleware {
+	tracer := NewTracer(trace.SpanKindServer, opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			operation := tr.Operation()
+			var attrs []attribute.KeyValue
+			if tr.Kind() == transport.KindGRPC {
+				attrs = rpcAttributes(operation)
+			} else if ht, ok := tr.(*http.Transport); ok {
+				operation = ht.Request().Method + " " + operation
+				attrs = []attribute.KeyValue{
+					attribute.String("http.method", ht.Request().Method),
+					attribute.String("http.route", tr.Operation()),
+					attribute.String("http.target", ht.Request().URL.RequestURI()),
+				}
+			}
+			var span trace.Span
+			ctx, span = tracer.Start(ctx, operation, tr.RequestHeader(), attrs...)
+			defer func() { tracer.End(span, err) }()
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Client returns a new client middleware for OpenTelemetry.
+func Client(opts ...Option) middleware.Middleware {
+	tracer := NewTracer(trace.SpanKindClient, opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			var attrs []attribute.KeyValue
+			if tr.Kind() == transport.KindGRPC {
+				attrs = rpcAttributes(tr.Operation())
+			}
+			var span trace.Span
+			ctx, span = tracer.Start(ctx, tr.Operation(), tr.RequestHeader(), attrs...)
+			defer func() { tracer.End(span, err) }()
+			return handler(ctx, req)
+		}
+	}
+}
+
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", path.Dir(fullMethod)[1:]),
+		attribute.String("rpc.method", path.Base(fullMethod)),
+	}
+}
+
+// TraceID returns a traceid valuer.
+func TraceID() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+			return span.TraceID().String()
+		}
+		return ""
+	}
+}
+
+// SpanID returns a spanid valuer.
+func SpanID() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if span := trace.SpanContextFromContext(ctx); span.HasSpanID() {
+			return span.SpanID().String()
+		}
+		return ""
+	}
+}
@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	operation string
+	header    headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return tr.operation }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+const (
+	traceparent = "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01"
+	traceID     = "0102030405060708090a0b0c0d0e0f10"
+)
+
+func TestTraceValuer(t *testing.T) {
+	tid, _ := trace.TraceIDFromHex(traceID)
+	sid, _ := trace.SpanIDFromHex("0102030405060708")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: tid,
+		SpanID:  sid,
+	}))
+
+	buf := new(bytes.Buffer)
+	logger := log.With(log.NewStdLogger(buf), "trace_id", TraceID(), "span_id", SpanID())
+	h := log.NewHelper("test", logger)
+	h.WithContext(ctx).Info("traced")
+	h.Info("untraced")
+
+	want := "INFO trace_id=0102030405060708090a0b0c0d0e0f10 span_id=0102030405060708 module=test message=traced\n" +
+		"INFO trace_id= span_id= module=test message=untraced\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestServerGRPC(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	ctx := transport.NewServerContext(context.Background(), &mockTransport{
+		operation: "/helloworld.Greeter/SayHello",
+		header:    headerCarrier{"traceparent": traceparent},
+	})
+	_, err := Server(WithTracerProvider(tp))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != traceID {
+			t.Errorf("handler trace id: got %s want %s", got, traceID)
+		}
+		return nil, errors.New("failed")
+	})(ctx, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "/helloworld.Greeter/SayHello" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("unexpected span: %s %s", span.Name(), span.SpanKind())
+	}
+	if span.Parent().TraceID().String() != traceID || !span.Parent().IsRemote() {
+		t.Errorf("unexpected parent: %+v", span.Parent())
+	}
+	if span.Status().Code != codes.Error || len(span.Events()) != 1 {
+		t.Errorf("error is not recorded: %+v", span.Status())
+	}
+}
+
+func TestServerHTTPB3(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	srv := khttp.NewServer(khttp.Middleware(Server(WithTracerProvider(tp), WithPropagator(b3.New()))))
+	srv.RegisterService(&khttp.ServiceDesc{
+		ServiceName: "test.Users",
+		Methods: []khttp.MethodDesc{{
+			Path:   "/v1/users/{id}",
+			Method: "GET",
+			Handler: func(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
+				return m(func(ctx context.Context, req interface{}) (interface{}, error) {
+					return "reply", nil
+				})(ctx, nil)
+			},
+		}},
+	}, nil)
+	req := httptest.NewRequest("GET", "/v1/users/1", nil)
+	req.Header.Set("b3", traceID+"-0102030405060708-1")
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", res.Code)
+	}
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "GET /v1/users/{id}" || spans[0].Parent().TraceID().String() != traceID {
+		t.Errorf("unexpected span: %s %+v", spans[0].Name(), spans[0].Parent())
+	}
+	if spans[0].Status().Code != codes.Ok {
+		t.Errorf("unexpected status: %+v", spans[0].Status())
+	}
+}
+
+func TestClient(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	tr := &mockTransport{operation: "/helloworld.Greeter/SayHello", header: headerCarrier{"x-md": "value"}}
+	ctx := transport.NewClientContext(context.Background(), tr)
+	if _, err := Client(WithTracerProvider(tp))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	spans := sr.Ended()
+	if len(spans) != 1 || spans[0].SpanKind() != trace.SpanKindClient {
+		t.Fatalf("unexpected spans: %v", spans)
+	}
+	want := "00-" + spans[0].SpanContext().TraceID().String() + "-" + spans[0].SpanContext().SpanID().String() + "-01"
+	if got := tr.header.Get("traceparent"); got != want {
+		t.Errorf("traceparent: got %v want %s", got, want)
+	}
+	if got := tr.header.Get("x-md"); got != "value" {
+		t.Errorf("request header is lost: %v", tr.header)
+	}
+}
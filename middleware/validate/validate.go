@@ -0,0 +1,53 @@
+package validate
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+// Validator is a message validator, such as the messages generated by protoc-gen-validate.
+type Validator interface {
+	Validate() error
+}
+
+// fieldError is the validation error of a field generated by protoc-gen-validate.
+type fieldError interface {
+	Field() string
+	Reason() string
+}
+
+// multiError is the error of all the violations returned by ValidateAll of protoc-gen-validate.
+type multiError interface {
+	AllErrors() []error
+}
+
+// Server is a server middleware that validates the requests, the failures
+// are returned as InvalidArgument errors with the field metadata.
+func Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if v, ok := req.(Validator); ok {
+				if err := v.Validate(); err != nil {
+					return nil, convert(err)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+func convert(err error) error {
+	se := errors.InvalidArgument("VALIDATOR", err.Error()).(*errors.StatusError)
+	if me, ok := err.(multiError); ok && len(me.AllErrors()) > 0 {
+		err = me.AllErrors()[0]
+	}
+	if fe, ok := err.(fieldError); ok {
+		return se.WithMetadata(map[string]string{
+			"field":  fe.Field(),
+			"reason": fe.Reason(),
+		})
+	}
+	return se
+}
@@ -0,0 +1,54 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+type validationError struct {
+	field  string
+	reason string
+}
+
+func (e validationError) Field() string  { return e.field }
+func (e validationError) Reason() string { return e.reason }
+func (e validationError) Error() string {
+	return "invalid HelloRequest." + e.field + ": " + e.reason
+}
+
+type request struct {
+	err error
+}
+
+func (r *request) Validate() error {
+	return r.err
+}
+
+func TestServer(t *testing.T) {
+	h := Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	if reply, err := h(context.Background(), &request{}); err != nil || reply != "reply" {
+		t.Errorf("got reply=%v err=%v", reply, err)
+	}
+	if reply, err := h(context.Background(), "not a validator"); err != nil || reply != "reply" {
+		t.Errorf("got reply=%v err=%v", reply, err)
+	}
+
+	_, err := h(context.Background(), &request{err: validationError{field: "Name", reason: "value length must be at least 1 runes"}})
+	se, ok := kerrors.FromError(err)
+	if !ok || !kerrors.IsInvalidArgument(err) {
+		t.Fatalf("expected an invalid argument error, got %v", err)
+	}
+	if se.Metadata["field"] != "Name" || se.Metadata["reason"] != "value length must be at least 1 runes" {
+		t.Errorf("unexpected metadata: %v", se.Metadata)
+	}
+
+	_, err = h(context.Background(), &request{err: errors.New("invalid")})
+	if se, ok := kerrors.FromError(err); !ok || se.Message != "invalid" || len(se.Metadata) != 0 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
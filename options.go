@@ -3,9 +3,12 @@ package kratos
 import (
 	"context"
 	"os"
+	"time"
 
+	"github.com/go-kratos/kratos/v2/health"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/registry/multi"
 	"github.com/go-kratos/kratos/v2/transport"
 )
 
@@ -20,12 +23,22 @@ type options struct {
 	metadata  map[string]string
 	endpoints []string
 
-	ctx  context.Context
-	sigs []os.Signal
-
-	logger   log.Logger
-	registry registry.Registry
-	servers  []transport.Server
+	ctx         context.Context
+	sigs        []os.Signal
+	stopTimeout time.Duration
+	drainPeriod time.Duration
+
+	logger           log.Logger
+	health           *health.Health
+	registrar        registry.Registrar
+	registrarTimeout time.Duration
+	servers          []transport.Server
+
+	beforeStart []func(context.Context) error
+	warmups     []func(context.Context) error
+	beforeStop  []func(context.Context) error
+	afterStart  []func(context.Context) error
+	afterStop   []func(context.Context) error
 }
 
 // ID with service id.
@@ -58,22 +71,86 @@ func Context(ctx context.Context) Option {
 	return func(o *options) { o.ctx = ctx }
 }
 
-// Signal with exit signals.
+// Signal with exit signals, no signals disables the signal handling.
 func Signal(sigs ...os.Signal) Option {
 	return func(o *options) { o.sigs = sigs }
 }
 
+// StopTimeout with app stop timeout, Run returns ErrStopTimeout
+// if the servers do not stop in time.
+func StopTimeout(t time.Duration) Option {
+	return func(o *options) { o.stopTimeout = t }
+}
+
+// DrainPeriod with the period between the deregistration and the stop of the servers,
+// in which the servers keep serving the requests which are still routed to them.
+func DrainPeriod(d time.Duration) Option {
+	return func(o *options) { o.drainPeriod = d }
+}
+
 // Logger with service logger.
 func Logger(logger log.Logger) Option {
 	return func(o *options) { o.logger = logger }
 }
 
-// Registry with service registry.
-func Registry(r registry.Registry) Option {
-	return func(o *options) { o.registry = r }
+// Health with the health registry, the app registers the service instance
+// once the service is ready and sets it not serving before stopping.
+func Health(h *health.Health) Option {
+	return func(o *options) { o.health = h }
+}
+
+// Registrar with service registries, the app registers with all of them and any failure
+// is fatal, use the registry/multi package for the soft failures.
+func Registrar(r ...registry.Registrar) Option {
+	return func(o *options) {
+		switch len(r) {
+		case 0:
+			o.registrar = nil
+		case 1:
+			o.registrar = r[0]
+		default:
+			opts := make([]multi.Option, 0, len(r))
+			for _, rr := range r {
+				opts = append(opts, multi.With(rr))
+			}
+			o.registrar = multi.New(opts...)
+		}
+	}
+}
+
+// RegistrarTimeout with registrar timeout.
+func RegistrarTimeout(t time.Duration) Option {
+	return func(o *options) { o.registrarTimeout = t }
 }
 
 // Server with transport servers.
 func Server(srv ...transport.Server) Option {
 	return func(o *options) { o.servers = srv }
 }
+
+// BeforeStart run funcs before app starts, an error aborts the start.
+func BeforeStart(fn func(context.Context) error) Option {
+	return func(o *options) { o.beforeStart = append(o.beforeStart, fn) }
+}
+
+// Warmup run funcs once the servers are started and before the instance is registered,
+// i.e. priming the caches or sending the requests to the servers themselves, an error
+// stops the app and is returned by Run. The health is not serving until they are done.
+func Warmup(fn func(context.Context) error) Option {
+	return func(o *options) { o.warmups = append(o.warmups, fn) }
+}
+
+// BeforeStop run funcs before app stops, the first error is returned by Stop.
+func BeforeStop(fn func(context.Context) error) Option {
+	return func(o *options) { o.beforeStop = append(o.beforeStop, fn) }
+}
+
+// AfterStart run funcs after app starts, an error stops the app and is returned by Run.
+func AfterStart(fn func(context.Context) error) Option {
+	return func(o *options) { o.afterStart = append(o.afterStart, fn) }
+}
+
+// AfterStop run funcs after app stops.
+func AfterStop(fn func(context.Context) error) Option {
+	return func(o *options) { o.afterStop = append(o.afterStop, fn) }
+}
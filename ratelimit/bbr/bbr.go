@@ -0,0 +1,181 @@
+package bbr
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/internal/cpu"
+	"github.com/go-kratos/kratos/v2/internal/window"
+	"github.com/go-kratos/kratos/v2/ratelimit"
+)
+
+var _ ratelimit.Limiter = (*BBR)(nil)
+
+// Option is BBR limiter option.
+type Option func(*options)
+
+// WithWindow with the duration of the statistic window, 10s by default.
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithBucket with the number of buckets in the statistic window, 100 by default, it
+// must be positive and no more than the nanoseconds of the window.
+func WithBucket(n int) Option {
+	return func(o *options) {
+		o.bucket = n
+	}
+}
+
+// WithCPUThreshold with the cpu usage threshold in permille, the limiter
+// starts to reject requests when the cpu usage exceeds it.
+func WithCPUThreshold(threshold int64) Option {
+	return func(o *options) {
+		o.cpuThreshold = threshold
+	}
+}
+
+type options struct {
+	window       time.Duration
+	bucket       int
+	cpuThreshold int64
+}
+
+type cache struct {
+	mu    sync.Mutex
+	value float64
+	time  time.Time
+}
+
+// BBR is an adaptive limiter inspired by TCP BBR, it estimates the max in-flight
+// requests from the max pass rate and the min response time of the window,
+// and rejects requests beyond it while the cpu is overloaded.
+type BBR struct {
+	cpu          func() int64
+	cpuThreshold int64
+	passStat     *window.Window
+	rtStat       *window.Window
+	bucketDur    time.Duration
+	inFlight     int64
+	// the time of the latest drop since the process started, zero if none
+	prevDrop int64
+	start    time.Time
+
+	maxPassCache  cache
+	minRTCache    cache
+	bucketsPerSec float64
+}
+
+// NewLimiter returns a BBR limiter.
+func NewLimiter(opts ...Option) *BBR {
+	options := options{
+		window:       10 * time.Second,
+		bucket:       100,
+		cpuThreshold: 800,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.bucket <= 0 || options.window < time.Duration(options.bucket) {
+		panic("bbr: the buckets must be positive and no more than the nanoseconds of the window")
+	}
+	bucketDur := options.window / time.Duration(options.bucket)
+	return &BBR{
+		cpu:           cpu.Usage,
+		cpuThreshold:  options.cpuThreshold,
+		passStat:      window.New(options.bucket, bucketDur),
+		rtStat:        window.New(options.bucket, bucketDur),
+		bucketDur:     bucketDur,
+		bucketsPerSec: float64(time.Second) / float64(bucketDur),
+		start:         time.Now(),
+	}
+}
+
+// cached returns the cached value which is refreshed once per bucket.
+func (l *BBR) cached(c *cache, fn func() float64) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.time.IsZero() || now.Sub(c.time) >= l.bucketDur {
+		c.value = fn()
+		c.time = now
+	}
+	return c.value
+}
+
+// maxPass returns the max number of requests passed in a bucket.
+func (l *BBR) maxPass() float64 {
+	return l.cached(&l.maxPassCache, func() float64 {
+		var max int64 = 1
+		l.passStat.ReduceCompleted(func(b window.Bucket) {
+			if b.Sum > max {
+				max = b.Sum
+			}
+		})
+		return float64(max)
+	})
+}
+
+// minRT returns the min average response time of a bucket in microseconds.
+func (l *BBR) minRT() float64 {
+	return l.cached(&l.minRTCache, func() float64 {
+		min := math.MaxFloat64
+		l.rtStat.ReduceCompleted(func(b window.Bucket) {
+			if b.Count == 0 {
+				return
+			}
+			if avg := float64(b.Sum) / float64(b.Count); avg < min {
+				min = avg
+			}
+		})
+		if min == math.MaxFloat64 || min <= 0 {
+			min = 1
+		}
+		return min
+	})
+}
+
+func (l *BBR) maxInFlight() int64 {
+	return int64(math.Floor(l.maxPass()*l.bucketsPerSec*l.minRT()/1e6 + 0.5))
+}
+
+func (l *BBR) shouldDrop() bool {
+	now := int64(time.Since(l.start))
+	if l.cpu() < l.cpuThreshold {
+		prevDrop := atomic.LoadInt64(&l.prevDrop)
+		if prevDrop == 0 {
+			return false
+		}
+		// keep dropping for a second after the cpu cools down to avoid jitter.
+		if time.Duration(now-prevDrop) <= time.Second {
+			inFlight := atomic.LoadInt64(&l.inFlight)
+			return inFlight > 1 && inFlight > l.maxInFlight()
+		}
+		atomic.StoreInt64(&l.prevDrop, 0)
+		return false
+	}
+	inFlight := atomic.LoadInt64(&l.inFlight)
+	drop := inFlight > 1 && inFlight > l.maxInFlight()
+	if drop && atomic.LoadInt64(&l.prevDrop) == 0 {
+		atomic.StoreInt64(&l.prevDrop, now)
+	}
+	return drop
+}
+
+// Allow checks whether the request is allowed.
+func (l *BBR) Allow() (ratelimit.DoneFunc, error) {
+	if l.shouldDrop() {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	atomic.AddInt64(&l.inFlight, 1)
+	start := time.Now()
+	return func(ratelimit.DoneInfo) {
+		l.rtStat.Add(int64(time.Since(start) / time.Microsecond))
+		atomic.AddInt64(&l.inFlight, -1)
+		l.passStat.Add(1)
+	}, nil
+}
@@ -0,0 +1,79 @@
+package bbr
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/ratelimit"
+)
+
+func TestAllowCPUIdle(t *testing.T) {
+	l := NewLimiter()
+	l.cpu = func() int64 { return 100 }
+	for i := 0; i < 100; i++ {
+		if _, err := l.Allow(); err != nil {
+			t.Fatalf("request %d rejected while cpu is idle: %v", i, err)
+		}
+	}
+}
+
+func TestAllowCPUOverload(t *testing.T) {
+	l := NewLimiter(WithWindow(time.Second), WithBucket(10))
+	var cpu int64 = 900
+	var mu sync.Mutex
+	l.cpu = func() int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return cpu
+	}
+	var dones []ratelimit.DoneFunc
+	var dropped int
+	for i := 0; i < 10; i++ {
+		done, err := l.Allow()
+		if err != nil {
+			if err != ratelimit.ErrLimitExceed {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dropped++
+			continue
+		}
+		dones = append(dones, done)
+	}
+	// no requests passed in the window yet, so the in-flight limit is at minimum.
+	if len(dones) != 2 || dropped != 8 {
+		t.Errorf("got passed=%d dropped=%d want passed=2 dropped=8", len(dones), dropped)
+	}
+	for _, done := range dones {
+		done(ratelimit.DoneInfo{})
+	}
+
+	mu.Lock()
+	cpu = 100
+	mu.Unlock()
+	if _, err := l.Allow(); err != nil {
+		t.Errorf("request rejected without in-flight requests: %v", err)
+	}
+}
+
+func TestNewLimiterInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"zero buckets", []Option{WithBucket(0)}},
+		{"negative buckets", []Option{WithBucket(-1)}},
+		{"zero window", []Option{WithWindow(0)}},
+		{"too many buckets", []Option{WithWindow(10), WithBucket(100)}},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: got no panic", test.name)
+				}
+			}()
+			NewLimiter(test.opts...)
+		}()
+	}
+}
@@ -0,0 +1,68 @@
+// Package concurrency provides a limiter of the max in-flight requests, which is a
+// cheaper alternative to the adaptive limiters when the bound is known.
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/ratelimit"
+)
+
+var (
+	_ ratelimit.Limiter    = (*Limiter)(nil)
+	_ ratelimit.KeyLimiter = (*Limiter)(nil)
+)
+
+// Limiter rejects the requests beyond the max in-flight requests, as a Limiter the bound
+// is of all the requests, and as a KeyLimiter it is of the requests of every key, i.e.
+// by the operation.
+type Limiter struct {
+	max      int64
+	inFlight int64
+
+	mu   sync.Mutex
+	keys map[string]int64
+}
+
+// NewLimiter returns a limiter of at most max in-flight requests.
+func NewLimiter(max int64) *Limiter {
+	return &Limiter{
+		max:  max,
+		keys: make(map[string]int64),
+	}
+}
+
+// Allow checks whether the in-flight requests are below the bound.
+func (l *Limiter) Allow() (ratelimit.DoneFunc, error) {
+	if atomic.AddInt64(&l.inFlight, 1) > l.max {
+		atomic.AddInt64(&l.inFlight, -1)
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return func(ratelimit.DoneInfo) {
+		atomic.AddInt64(&l.inFlight, -1)
+	}, nil
+}
+
+// AllowKey checks whether the in-flight requests of the key are below the bound.
+func (l *Limiter) AllowKey(_ context.Context, key string) (ratelimit.DoneFunc, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.keys[key] >= l.max {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.keys[key]++
+	return func(ratelimit.DoneInfo) {
+		l.mu.Lock()
+		if l.keys[key]--; l.keys[key] <= 0 {
+			delete(l.keys, key)
+		}
+		l.mu.Unlock()
+	}, nil
+}
+
+// InFlight returns the in-flight requests of Allow.
+func (l *Limiter) InFlight() int64 {
+	return atomic.LoadInt64(&l.inFlight)
+}
@@ -0,0 +1,60 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/ratelimit"
+)
+
+func TestAllow(t *testing.T) {
+	l := NewLimiter(2)
+	done1, err := l.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done2, err := l.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Allow(); err != ratelimit.ErrLimitExceed {
+		t.Errorf("got %v want %v", err, ratelimit.ErrLimitExceed)
+	}
+	if l.InFlight() != 2 {
+		t.Errorf("got %d in-flight want 2", l.InFlight())
+	}
+	done1(ratelimit.DoneInfo{})
+	done3, err := l.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done2(ratelimit.DoneInfo{})
+	done3(ratelimit.DoneInfo{})
+	if l.InFlight() != 0 {
+		t.Errorf("got %d in-flight want 0", l.InFlight())
+	}
+}
+
+func TestAllowKey(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(1)
+	done, err := l.AllowKey(ctx, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.AllowKey(ctx, "/a"); err != ratelimit.ErrLimitExceed {
+		t.Errorf("got %v want %v", err, ratelimit.ErrLimitExceed)
+	}
+	doneB, err := l.AllowKey(ctx, "/b")
+	if err != nil {
+		t.Fatalf("got %v want the other key allowed", err)
+	}
+	done(ratelimit.DoneInfo{})
+	doneB(ratelimit.DoneInfo{})
+	if len(l.keys) != 0 {
+		t.Errorf("got %v want the finished keys removed", l.keys)
+	}
+	if _, err := l.AllowKey(ctx, "/a"); err != nil {
+		t.Errorf("got %v want allowed", err)
+	}
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrLimitExceed is returned when the rate limiter is triggered and the request is rejected.
+var ErrLimitExceed = errors.New("rate limit exceeded")
+
+// Limiter is a rate limiter.
+type Limiter interface {
+	// Allow checks whether the request is allowed,
+	// if err == nil, done must be called when the request is finished.
+	Allow() (done DoneFunc, err error)
+}
+
+// KeyLimiter is a rate limiter of the keys, such as the operations, the API keys or the
+// client IPs, the quota of a key can be shared by the instances of a distributed limiter.
+type KeyLimiter interface {
+	// AllowKey checks whether the request of the key is allowed,
+	// if err == nil, done must be called when the request is finished.
+	AllowKey(ctx context.Context, key string) (done DoneFunc, err error)
+}
+
+// DoneInfo is callback info when the request is finished.
+type DoneInfo struct {
+	// Response Error
+	Err error
+}
+
+// DoneFunc is callback function when the request is finished.
+type DoneFunc func(DoneInfo)
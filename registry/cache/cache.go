@@ -0,0 +1,199 @@
+// Package cache provides a discovery which keeps the last known good instances of the
+// services in memory and optionally on disk, and keeps serving them when the registry
+// becomes unreachable, so that the clients are not left without the nodes to pick.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Discovery = (*Discovery)(nil)
+
+// Option is cache option.
+type Option func(o *options)
+
+type options struct {
+	dir       string
+	maxAge    time.Duration
+	staleness metrics.Gauge
+	logger    log.Logger
+}
+
+// WithDir with the directory persisting the instances, so that they survive the restarts
+// of the process, the instances are only kept in memory by default.
+func WithDir(dir string) Option {
+	return func(o *options) { o.dir = dir }
+}
+
+// WithMaxAge with the max age of the served instances, zero means no limit.
+func WithMaxAge(d time.Duration) Option {
+	return func(o *options) { o.maxAge = d }
+}
+
+// WithStaleness with the staleness gauge labeled by service, which is the seconds since
+// the instances are last refreshed while the registry fails, and zero once it recovers.
+func WithStaleness(g metrics.Gauge) Option {
+	return func(o *options) { o.staleness = g }
+}
+
+// WithLogger with the logger of the disk failures.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+type entry struct {
+	Instances []*registry.ServiceInstance `json:"instances"`
+	Updated   time.Time                   `json:"updated"`
+}
+
+// Discovery is a discovery caching the last known good instances. An empty instance list
+// is never cached, as the resolvers refuse to apply it.
+type Discovery struct {
+	d    registry.Discovery
+	opts options
+	log  *log.Helper
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	now     func() time.Time
+}
+
+// New returns a discovery caching the instances of d.
+func New(d registry.Discovery, opts ...Option) *Discovery {
+	options := options{
+		logger: log.GetLogger(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Discovery{
+		d:       d,
+		opts:    options,
+		log:     log.NewHelper("registry/cache", options.logger),
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+// GetService returns the instances of the service, or the cached ones if the registry
+// fails or returns no instances.
+func (d *Discovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	ins, err := d.d.GetService(ctx, name)
+	if err == nil && len(ins) > 0 {
+		d.store(name, ins)
+		return ins, nil
+	}
+	if cached, ok := d.load(name); ok {
+		d.stale(name)
+		return cached, nil
+	}
+	return ins, err
+}
+
+// Watch returns a watcher which serves the cached instances at first if the registry fails.
+func (d *Discovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	w := &watcher{d: d, name: name, first: true}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	if err := w.watch(); err != nil {
+		if _, ok := d.load(name); !ok {
+			w.cancel()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (d *Discovery) store(name string, ins []*registry.ServiceInstance) {
+	e := &entry{Instances: ins, Updated: d.now()}
+	d.mu.Lock()
+	d.entries[name] = e
+	d.mu.Unlock()
+	if d.opts.staleness != nil {
+		d.opts.staleness.With(name).Set(0)
+	}
+	if d.opts.dir == "" {
+		return
+	}
+	if err := d.save(name, e); err != nil {
+		d.log.Warnf("failed to save the instances of %s: %v", name, err)
+	}
+}
+
+// load returns the cached instances of the service from memory, or from disk once the
+// process restarts.
+func (d *Discovery) load(name string) ([]*registry.ServiceInstance, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[name]
+	if !ok && d.opts.dir != "" {
+		data, err := ioutil.ReadFile(d.file(name))
+		if err == nil {
+			e = new(entry)
+			if err = json.Unmarshal(data, e); err == nil && len(e.Instances) > 0 {
+				d.entries[name], ok = e, true
+			}
+		}
+		if err != nil && !os.IsNotExist(err) {
+			d.log.Warnf("failed to load the instances of %s: %v", name, err)
+		}
+	}
+	if !ok || (d.opts.maxAge > 0 && d.now().Sub(e.Updated) > d.opts.maxAge) {
+		return nil, false
+	}
+	return e.Instances, true
+}
+
+// stale reports the staleness of the cached instances of the service.
+func (d *Discovery) stale(name string) {
+	if d.opts.staleness == nil {
+		return
+	}
+	d.mu.Lock()
+	e, ok := d.entries[name]
+	d.mu.Unlock()
+	if ok {
+		d.opts.staleness.With(name).Set(d.now().Sub(e.Updated).Seconds())
+	}
+}
+
+func (d *Discovery) file(name string) string {
+	return filepath.Join(d.opts.dir, url.PathEscape(name)+".json")
+}
+
+// save writes the file atomically, so that a crash never leaves a partial file.
+func (d *Discovery) save(name string, e *entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(d.opts.dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(d.opts.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), d.file(name))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var errUnavailable = errors.New("registry unavailable")
+
+type mockDiscovery struct {
+	ins      []*registry.ServiceInstance
+	err      error
+	watchErr error
+	w        *mockWatcher
+}
+
+func (d *mockDiscovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	return d.ins, d.err
+}
+
+func (d *mockDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	if d.watchErr != nil {
+		return nil, d.watchErr
+	}
+	return d.w, nil
+}
+
+type result struct {
+	ins []*registry.ServiceInstance
+	err error
+}
+
+type mockWatcher struct {
+	ch chan result
+}
+
+func (w *mockWatcher) Next() ([]*registry.ServiceInstance, error) {
+	r := <-w.ch
+	return r.ins, r.err
+}
+
+func (w *mockWatcher) Close() error { return nil }
+
+type mockGauge struct {
+	values map[string]float64
+	lvs    []string
+}
+
+func (g *mockGauge) With(lvs ...string) metrics.Gauge {
+	return &mockGauge{values: g.values, lvs: lvs}
+}
+
+func (g *mockGauge) Set(value float64) { g.values[strings.Join(g.lvs, ",")] = value }
+func (g *mockGauge) Add(delta float64) { g.values[strings.Join(g.lvs, ",")] += delta }
+func (g *mockGauge) Sub(delta float64) { g.values[strings.Join(g.lvs, ",")] -= delta }
+
+func instances(ids ...string) []*registry.ServiceInstance {
+	ins := make([]*registry.ServiceInstance, 0, len(ids))
+	for _, id := range ids {
+		ins = append(ins, &registry.ServiceInstance{ID: id, Name: "helloworld", Endpoints: []string{"grpc://" + id}})
+	}
+	return ins
+}
+
+func TestGetService(t *testing.T) {
+	ctx := context.Background()
+	md := &mockDiscovery{ins: instances("127.0.0.1:9000")}
+	staleness := &mockGauge{values: map[string]float64{}}
+	d := New(md, WithStaleness(staleness), WithMaxAge(time.Minute))
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	if ins, err := d.GetService(ctx, "helloworld"); err != nil || len(ins) != 1 {
+		t.Fatalf("got %v %v", ins, err)
+	}
+	md.ins, md.err = nil, errUnavailable
+	now = now.Add(30 * time.Second)
+	ins, err := d.GetService(ctx, "helloworld")
+	if err != nil || len(ins) != 1 || ins[0].ID != "127.0.0.1:9000" {
+		t.Fatalf("want the cached instances, got %v %v", ins, err)
+	}
+	if v := staleness.values["helloworld"]; v != 30 {
+		t.Errorf("got staleness %v want 30", v)
+	}
+	// an empty list does not replace the cache.
+	md.err = nil
+	if ins, _ = d.GetService(ctx, "helloworld"); len(ins) != 1 {
+		t.Fatalf("want the cached instances, got %v", ins)
+	}
+
+	md.err = errUnavailable
+	now = now.Add(time.Minute)
+	if _, err = d.GetService(ctx, "helloworld"); err != errUnavailable {
+		t.Fatalf("want the expired cache not served, got %v", err)
+	}
+	if _, err = d.GetService(ctx, "unknown"); err != errUnavailable {
+		t.Fatalf("got %v want %v", err, errUnavailable)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kratos-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	if _, err = New(&mockDiscovery{ins: instances("127.0.0.1:9000")}, WithDir(dir)).GetService(ctx, "helloworld"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the restarted process loads the instances from disk.
+	md := &mockDiscovery{err: errUnavailable, watchErr: errUnavailable}
+	d := New(md, WithDir(dir))
+	ins, err := d.GetService(ctx, "helloworld")
+	if err != nil || len(ins) != 1 || ins[0].Endpoints[0] != "grpc://127.0.0.1:9000" {
+		t.Fatalf("want the instances of disk, got %v %v", ins, err)
+	}
+	w, err := d.Watch(ctx, "helloworld")
+	if err != nil {
+		t.Fatalf("want the watcher of the cached instances, got %v", err)
+	}
+	defer w.Close()
+	if ins, err = w.Next(); err != nil || len(ins) != 1 {
+		t.Fatalf("got %v %v", ins, err)
+	}
+	if _, err = w.Next(); err != errUnavailable {
+		t.Fatalf("got %v want %v", err, errUnavailable)
+	}
+	if _, err = New(md, WithDir(dir)).Watch(ctx, "unknown"); err != errUnavailable {
+		t.Fatalf("got %v want %v", err, errUnavailable)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	md := &mockDiscovery{ins: instances("127.0.0.1:9000"), w: &mockWatcher{ch: make(chan result, 1)}}
+	staleness := &mockGauge{values: map[string]float64{}}
+	d := New(md, WithStaleness(staleness))
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	if _, err := d.GetService(ctx, "helloworld"); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := d.Watch(ctx, "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// the registry fails at first, the cached instances are served.
+	md.w.ch <- result{err: errUnavailable}
+	now = now.Add(time.Second)
+	if ins, err := w.Next(); err != nil || len(ins) != 1 {
+		t.Fatalf("got %v %v", ins, err)
+	}
+	if v := staleness.values["helloworld"]; v != 1 {
+		t.Errorf("got staleness %v want 1", v)
+	}
+
+	md.w.ch <- result{ins: instances("127.0.0.1:9000", "127.0.0.2:9000")}
+	if ins, err := w.Next(); err != nil || len(ins) != 2 {
+		t.Fatalf("got %v %v", ins, err)
+	}
+	if v := staleness.values["helloworld"]; v != 0 {
+		t.Errorf("got staleness %v want 0", v)
+	}
+	md.w.ch <- result{err: errUnavailable}
+	if _, err := w.Next(); err != errUnavailable {
+		t.Fatalf("got %v want %v", err, errUnavailable)
+	}
+	md.ins, md.err = nil, errUnavailable
+	if ins, _ := d.GetService(ctx, "helloworld"); len(ins) != 2 {
+		t.Fatalf("want the watched instances cached, got %v", ins)
+	}
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	d      *Discovery
+	name   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	first  bool
+
+	mu sync.Mutex
+	w  registry.Watcher
+}
+
+// watch creates the watcher of the registry, if it is not created yet.
+func (w *watcher) watch() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.w != nil {
+		return nil
+	}
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	ww, err := w.d.d.Watch(w.ctx, w.name)
+	if err != nil {
+		return err
+	}
+	w.w = ww
+	return nil
+}
+
+// Next returns the instances of the registry, if the registry fails before any instances
+// are returned, it returns the cached instances once, and then the errors, so that the
+// resolvers keep the cached instances and retry.
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	err := w.watch()
+	if err == nil {
+		var ins []*registry.ServiceInstance
+		if ins, err = w.w.Next(); err == nil {
+			w.first = false
+			if len(ins) > 0 {
+				w.d.store(w.name, ins)
+			}
+			return ins, nil
+		}
+	}
+	if w.ctx.Err() != nil {
+		return nil, err
+	}
+	if w.first {
+		w.first = false
+		if ins, ok := w.d.load(w.name); ok {
+			w.d.stale(w.name)
+			return ins, nil
+		}
+	}
+	w.d.stale(w.name)
+	return nil, err
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.w != nil {
+		return w.w.Close()
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package dns provides a discovery of the DNS records, for the environments without a
+// registry such as the ECS or the headless Services of kubernetes. A service name with
+// a port, i.e. helloworld.default.svc.cluster.local:9000, is resolved by the A/AAAA
+// records, and a name without a port, i.e. _grpc._tcp.helloworld.default.svc.cluster.local,
+// is resolved by the SRV records.
+package dns
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Discovery = (*Discovery)(nil)
+
+// Option is dns discovery option.
+type Option func(o *options)
+
+type options struct {
+	resolver *net.Resolver
+	refresh  time.Duration
+	schemes  []string
+}
+
+// WithResolver with the DNS resolver, net.DefaultResolver by default.
+func WithResolver(r *net.Resolver) Option {
+	return func(o *options) { o.resolver = r }
+}
+
+// WithRefresh with the interval of the watchers resolving the records, 30s by default.
+func WithRefresh(d time.Duration) Option {
+	return func(o *options) { o.refresh = d }
+}
+
+// WithSchemes with the schemes of the endpoints of the instances, grpc and http by default.
+func WithSchemes(schemes ...string) Option {
+	return func(o *options) { o.schemes = schemes }
+}
+
+// Discovery is dns discovery.
+type Discovery struct {
+	opts *options
+
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	lookupSRV  func(ctx context.Context, name string) ([]*net.SRV, error)
+}
+
+// New creates dns discovery.
+func New(opts ...Option) *Discovery {
+	options := &options{
+		resolver: net.DefaultResolver,
+		refresh:  30 * time.Second,
+		schemes:  []string{"grpc", "http"},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	return &Discovery{
+		opts:       options,
+		lookupHost: options.resolver.LookupHost,
+		lookupSRV: func(ctx context.Context, name string) ([]*net.SRV, error) {
+			_, srvs, err := options.resolver.LookupSRV(ctx, "", "", name)
+			return srvs, err
+		},
+	}
+}
+
+// GetService resolves the instances of the service name.
+func (d *Discovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	if host, port, err := net.SplitHostPort(name); err == nil {
+		addrs, err := d.lookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]*registry.ServiceInstance, 0, len(addrs))
+		for _, addr := range addrs {
+			items = append(items, d.instance(name, net.JoinHostPort(addr, port), nil))
+		}
+		return sorted(items), nil
+	}
+	srvs, err := d.lookupSRV(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*registry.ServiceInstance, 0, len(srvs))
+	for _, srv := range srvs {
+		target := srv.Target
+		if n := len(target); n > 0 && target[n-1] == '.' {
+			target = target[:n-1]
+		}
+		items = append(items, d.instance(name, net.JoinHostPort(target, strconv.Itoa(int(srv.Port))), map[string]string{
+			"weight":   strconv.Itoa(int(srv.Weight)),
+			"priority": strconv.Itoa(int(srv.Priority)),
+		}))
+	}
+	return sorted(items), nil
+}
+
+// Watch creates a watcher which resolves the service name every refresh interval.
+func (d *Discovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return newWatcher(ctx, d, name), nil
+}
+
+func (d *Discovery) instance(name, addr string, md map[string]string) *registry.ServiceInstance {
+	endpoints := make([]string, 0, len(d.opts.schemes))
+	for _, scheme := range d.opts.schemes {
+		endpoints = append(endpoints, scheme+"://"+addr)
+	}
+	return &registry.ServiceInstance{
+		ID:        addr,
+		Name:      name,
+		Metadata:  md,
+		Endpoints: endpoints,
+	}
+}
+
+func sorted(items []*registry.ServiceInstance) []*registry.ServiceInstance {
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
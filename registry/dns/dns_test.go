@@ -0,0 +1,132 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeResolver struct {
+	mu    sync.Mutex
+	hosts map[string][]string
+	srvs  map[string][]*net.SRV
+}
+
+func (r *fakeResolver) lookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (r *fakeResolver) lookupSRV(ctx context.Context, name string) ([]*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if srvs, ok := r.srvs[name]; ok {
+		return srvs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (r *fakeResolver) setHosts(host string, addrs ...string) {
+	r.mu.Lock()
+	r.hosts[host] = addrs
+	r.mu.Unlock()
+}
+
+func newDiscovery(r *fakeResolver, opts ...Option) *Discovery {
+	d := New(opts...)
+	d.lookupHost, d.lookupSRV = r.lookupHost, r.lookupSRV
+	return d
+}
+
+func TestGetServiceA(t *testing.T) {
+	r := &fakeResolver{hosts: map[string][]string{"helloworld": {"10.0.0.2", "10.0.0.1"}}}
+	d := newDiscovery(r)
+	items, err := d.GetService(context.Background(), "helloworld:9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d instances want 2", len(items))
+	}
+	if items[0].ID != "10.0.0.1:9000" || items[0].Name != "helloworld:9000" {
+		t.Errorf("got instance %+v", items[0])
+	}
+	want := []string{"grpc://10.0.0.1:9000", "http://10.0.0.1:9000"}
+	if !reflect.DeepEqual(items[0].Endpoints, want) {
+		t.Errorf("got endpoints %v want %v", items[0].Endpoints, want)
+	}
+	if _, err := d.GetService(context.Background(), "unknown:9000"); err == nil {
+		t.Error("want an error")
+	}
+}
+
+func TestGetServiceSRV(t *testing.T) {
+	name := "_grpc._tcp.helloworld"
+	r := &fakeResolver{srvs: map[string][]*net.SRV{name: {
+		{Target: "b.helloworld.", Port: 9000, Priority: 1, Weight: 20},
+		{Target: "a.helloworld.", Port: 9001, Priority: 1, Weight: 10},
+	}}}
+	d := newDiscovery(r, WithSchemes("grpc"))
+	items, err := d.GetService(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].ID != "a.helloworld:9001" {
+		t.Fatalf("got instances %+v", items)
+	}
+	if !reflect.DeepEqual(items[0].Endpoints, []string{"grpc://a.helloworld:9001"}) {
+		t.Errorf("got endpoints %v", items[0].Endpoints)
+	}
+	if items[0].Metadata["weight"] != "10" || items[1].Metadata["weight"] != "20" {
+		t.Errorf("got metadata %v %v", items[0].Metadata, items[1].Metadata)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	r := &fakeResolver{hosts: map[string][]string{"helloworld": {"10.0.0.1"}}}
+	d := newDiscovery(r, WithRefresh(10*time.Millisecond))
+	w, err := d.Watch(context.Background(), "helloworld:9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := w.Next()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("got %v %v", items, err)
+	}
+
+	r.setHosts("helloworld", "10.0.0.1", "10.0.0.2")
+	items, err = w.Next()
+	if err != nil || len(items) != 2 {
+		t.Fatalf("got %v %v", items, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("want no change, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("want an error after closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the watcher is not closed")
+	}
+}
@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	d      *Discovery
+	name   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	ticker *time.Ticker
+	last   []*registry.ServiceInstance
+	first  bool
+}
+
+func newWatcher(ctx context.Context, d *Discovery, name string) *watcher {
+	w := &watcher{
+		d:      d,
+		name:   name,
+		ticker: time.NewTicker(d.opts.refresh),
+		first:  true,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w
+}
+
+// Next returns the instances at the first time, and then whenever the records change,
+// the failed lookups are retried at the next refresh.
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	if w.first {
+		w.first = false
+		items, err := w.d.GetService(w.ctx, w.name)
+		if err != nil {
+			return nil, err
+		}
+		w.last = items
+		return items, nil
+	}
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-w.ticker.C:
+		}
+		items, err := w.d.GetService(w.ctx, w.name)
+		if err != nil || len(items) == 0 || reflect.DeepEqual(items, w.last) {
+			continue
+		}
+		w.last = items
+		return items, nil
+	}
+}
+
+func (w *watcher) Close() error {
+	w.cancel()
+	w.ticker.Stop()
+	return nil
+}
@@ -0,0 +1,128 @@
+// Package multi provides a registrar which registers the service instance with several
+// registries at the same time, i.e. both consul and etcd during a migration.
+package multi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Registrar = (*Registrar)(nil)
+
+// Option is multi registrar option.
+type Option func(o *options)
+
+type options struct {
+	registrars []entry
+	logger     log.Logger
+}
+
+type entry struct {
+	registry.Registrar
+	soft bool
+}
+
+// With with a registrar whose failures are fatal, the registration fails if it fails.
+func With(r registry.Registrar) Option {
+	return func(o *options) {
+		o.registrars = append(o.registrars, entry{Registrar: r})
+	}
+}
+
+// WithSoft with a registrar whose failures are soft, they are logged and ignored.
+func WithSoft(r registry.Registrar) Option {
+	return func(o *options) {
+		o.registrars = append(o.registrars, entry{Registrar: r, soft: true})
+	}
+}
+
+// WithLogger with the logger of the soft failures.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Registrar registers with all the registrars concurrently.
+type Registrar struct {
+	registrars []entry
+	log        *log.Helper
+}
+
+// New creates a multi registrar.
+func New(opts ...Option) *Registrar {
+	options := options{
+		logger: log.GetLogger(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Registrar{
+		registrars: options.registrars,
+		log:        log.NewHelper("registry/multi", options.logger),
+	}
+}
+
+// Register registers with all the registrars, if any fatal one fails, the registered ones
+// are deregistered and the first fatal error is returned.
+func (r *Registrar) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	errs := r.each(func(_ int, e entry) error {
+		return e.Register(ctx, service)
+	})
+	err := r.check(ctx, "register", service, errs)
+	if err != nil {
+		r.each(func(i int, e entry) error {
+			if errs[i] != nil {
+				return nil
+			}
+			if derr := e.Deregister(ctx, service); derr != nil {
+				r.log.WithContext(ctx).Warnf("failed to deregister %s with %T: %v", service.ID, e.Registrar, derr)
+			}
+			return nil
+		})
+	}
+	return err
+}
+
+// Deregister deregisters with all the registrars, the first fatal error is returned.
+func (r *Registrar) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	errs := r.each(func(_ int, e entry) error {
+		return e.Deregister(ctx, service)
+	})
+	return r.check(ctx, "deregister", service, errs)
+}
+
+// check logs the soft errors and returns the first fatal one.
+func (r *Registrar) check(ctx context.Context, action string, service *registry.ServiceInstance, errs []error) (err error) {
+	for i, e := range r.registrars {
+		if errs[i] == nil {
+			continue
+		}
+		if e.soft {
+			r.log.WithContext(ctx).Warnf("failed to %s %s with %T: %v", action, service.ID, e.Registrar, errs[i])
+			continue
+		}
+		if err == nil {
+			err = errs[i]
+		}
+	}
+	return err
+}
+
+// each calls fn on all the registrars concurrently, and returns the errors by index.
+func (r *Registrar) each(fn func(i int, e entry) error) []error {
+	errs := make([]error, len(r.registrars))
+	var wg sync.WaitGroup
+	for i, e := range r.registrars {
+		wg.Add(1)
+		go func(i int, e entry) {
+			defer wg.Done()
+			errs[i] = fn(i, e)
+		}(i, e)
+	}
+	wg.Wait()
+	return errs
+}
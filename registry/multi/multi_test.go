@@ -0,0 +1,76 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+type mockRegistrar struct {
+	mu    sync.Mutex
+	err   error
+	calls []string
+}
+
+func (r *mockRegistrar) Register(ctx context.Context, ins *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "register")
+	return r.err
+}
+
+func (r *mockRegistrar) Deregister(ctx context.Context, ins *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "deregister")
+	return r.err
+}
+
+func TestRegistrar(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		ins  = &registry.ServiceInstance{ID: "1", Name: "helloworld"}
+		a, b = &mockRegistrar{}, &mockRegistrar{}
+		soft = &mockRegistrar{err: errors.New("soft")}
+	)
+	r := New(With(a), With(b), WithSoft(soft))
+	if err := r.Register(ctx, ins); err != nil {
+		t.Fatalf("want the soft failure ignored, got %v", err)
+	}
+	if err := r.Deregister(ctx, ins); err != nil {
+		t.Fatalf("want the soft failure ignored, got %v", err)
+	}
+	for _, m := range []*mockRegistrar{a, b, soft} {
+		if !reflect.DeepEqual(m.calls, []string{"register", "deregister"}) {
+			t.Errorf("unexpected calls: %v", m.calls)
+		}
+	}
+}
+
+func TestRegistrarFatal(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		ins   = &registry.ServiceInstance{ID: "1", Name: "helloworld"}
+		want  = errors.New("fatal")
+		ok    = &mockRegistrar{}
+		fatal = &mockRegistrar{err: want}
+	)
+	r := New(With(ok), With(fatal))
+	if err := r.Register(ctx, ins); err != want {
+		t.Fatalf("got %v want %v", err, want)
+	}
+	// the registered one is rolled back, the failed one is not deregistered.
+	if !reflect.DeepEqual(ok.calls, []string{"register", "deregister"}) {
+		t.Errorf("unexpected calls: %v", ok.calls)
+	}
+	if !reflect.DeepEqual(fatal.calls, []string{"register"}) {
+		t.Errorf("unexpected calls: %v", fatal.calls)
+	}
+	if err := r.Deregister(ctx, ins); err != want {
+		t.Fatalf("got %v want %v", err, want)
+	}
+}
@@ -0,0 +1,119 @@
+// Package subset provides the deterministic subsetting of the discovery, every client
+// picks a stable subset of the instances of a large service, which bounds the connections
+// of the clients and the servers while the load is still evenly distributed.
+package subset
+
+import (
+	"context"
+	"hash/fnv"
+	"os"
+	"sort"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var _ registry.Discovery = (*Discovery)(nil)
+
+// Option is subset option.
+type Option func(o *options)
+
+type options struct {
+	key string
+}
+
+// WithKey with the key of the client which determines its subset, the hostname by default,
+// the clients of the same key pick the same subset.
+func WithKey(key string) Option {
+	return func(o *options) { o.key = key }
+}
+
+// Discovery is a discovery which returns a subset of the instances of the services.
+type Discovery struct {
+	d    registry.Discovery
+	size int
+	key  string
+}
+
+// New returns a discovery of at most size instances of every service. The instances are
+// picked by rendezvous hashing of the client key and the instance ID, so that a change
+// of the instances only changes the subsets which contain them.
+func New(d registry.Discovery, size int, opts ...Option) *Discovery {
+	options := options{}
+	options.key, _ = os.Hostname()
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Discovery{d: d, size: size, key: options.key}
+}
+
+// GetService returns the subset of the instances of the service.
+func (d *Discovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	ins, err := d.d.GetService(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return Subset(d.key, ins, d.size), nil
+}
+
+// Watch returns a watcher of the subset of the instances of the service.
+func (d *Discovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	w, err := d.d.Watch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{Watcher: w, d: d}, nil
+}
+
+type watcher struct {
+	registry.Watcher
+	d *Discovery
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	ins, err := w.Watcher.Next()
+	if err != nil {
+		return nil, err
+	}
+	return Subset(w.d.key, ins, w.d.size), nil
+}
+
+// Subset returns at most size instances with the highest scores of the key, all the
+// instances are returned if size is not positive.
+func Subset(key string, ins []*registry.ServiceInstance, size int) []*registry.ServiceInstance {
+	if size <= 0 || len(ins) <= size {
+		return ins
+	}
+	type scored struct {
+		in    *registry.ServiceInstance
+		score uint64
+	}
+	ss := make([]scored, 0, len(ins))
+	for _, in := range ins {
+		id := in.ID
+		if id == "" && len(in.Endpoints) > 0 {
+			id = in.Endpoints[0]
+		}
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(id))
+		ss = append(ss, scored{in, mix(h.Sum64())})
+	}
+	sort.Slice(ss, func(i, j int) bool { return ss[i].score > ss[j].score })
+	subset := make([]*registry.ServiceInstance, 0, size)
+	for _, s := range ss[:size] {
+		subset = append(subset, s.in)
+	}
+	return subset
+}
+
+// mix is the finalizer of murmur3, which spreads the similar fnv hashes of the
+// similar keys.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
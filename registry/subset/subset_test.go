@@ -0,0 +1,115 @@
+package subset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+func instances(n int) []*registry.ServiceInstance {
+	ins := make([]*registry.ServiceInstance, 0, n)
+	for i := 0; i < n; i++ {
+		ins = append(ins, &registry.ServiceInstance{ID: fmt.Sprintf("instance-%d", i)})
+	}
+	return ins
+}
+
+func ids(ins []*registry.ServiceInstance) map[string]bool {
+	m := make(map[string]bool, len(ins))
+	for _, in := range ins {
+		m[in.ID] = true
+	}
+	return m
+}
+
+func TestSubset(t *testing.T) {
+	ins := instances(100)
+	a := Subset("client-1", ins, 10)
+	if len(a) != 10 {
+		t.Fatalf("got %d instances want 10", len(a))
+	}
+	if fmt.Sprint(ids(a)) != fmt.Sprint(ids(Subset("client-1", instances(100), 10))) {
+		t.Error("the subset is not deterministic")
+	}
+	if len(Subset("client-1", ins[:5], 10)) != 5 || len(Subset("client-1", ins, 0)) != 100 {
+		t.Error("want all the instances")
+	}
+
+	// removing an instance out of the subset does not change it.
+	subset := ids(a)
+	for _, in := range ins {
+		if !subset[in.ID] {
+			if got := ids(Subset("client-1", removed(ins, in.ID), 10)); fmt.Sprint(got) != fmt.Sprint(subset) {
+				t.Errorf("the subset changes when %s is removed", in.ID)
+			}
+			break
+		}
+	}
+}
+
+func removed(ins []*registry.ServiceInstance, id string) []*registry.ServiceInstance {
+	var rest []*registry.ServiceInstance
+	for _, in := range ins {
+		if in.ID != id {
+			rest = append(rest, in)
+		}
+	}
+	return rest
+}
+
+func TestSubsetDistribution(t *testing.T) {
+	ins := instances(50)
+	conns := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		for _, in := range Subset(fmt.Sprintf("client-%d", i), ins, 10) {
+			conns[in.ID]++
+		}
+	}
+	// every instance is picked by 1000*10/50 = 200 clients in average.
+	for _, in := range ins {
+		if n := conns[in.ID]; n < 140 || n > 260 {
+			t.Errorf("%s is picked by %d clients want about 200", in.ID, n)
+		}
+	}
+}
+
+type testDiscovery struct {
+	ins []*registry.ServiceInstance
+	ch  chan []*registry.ServiceInstance
+}
+
+func (d *testDiscovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	return d.ins, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return d, nil
+}
+
+func (d *testDiscovery) Next() ([]*registry.ServiceInstance, error) {
+	return <-d.ch, nil
+}
+
+func (d *testDiscovery) Close() error {
+	return nil
+}
+
+func TestDiscovery(t *testing.T) {
+	td := &testDiscovery{ins: instances(20), ch: make(chan []*registry.ServiceInstance, 1)}
+	d := New(td, 3, WithKey("client-1"))
+	ins, err := d.GetService(context.Background(), "helloworld")
+	if err != nil || len(ins) != 3 {
+		t.Fatalf("got %d %v want 3 instances", len(ins), err)
+	}
+	w, err := d.Watch(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	td.ch <- instances(20)
+	next, err := w.Next()
+	if err != nil || fmt.Sprint(ids(next)) != fmt.Sprint(ids(ins)) {
+		t.Errorf("got %v %v want the same subset %v", ids(next), err, ids(ins))
+	}
+}
@@ -0,0 +1,166 @@
+// Package breaker provides the per node circuit breakers of the selector, a node whose
+// breaker is open is skipped by the pick rather than failing the request.
+package breaker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/circuitbreaker"
+	"github.com/go-kratos/kratos/v2/circuitbreaker/sre"
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+var (
+	_ selector.Selector = (*Selector)(nil)
+	_ selector.Builder  = (*Builder)(nil)
+)
+
+// Option is breaker selector option.
+type Option func(*options)
+
+type options struct {
+	breaker func() circuitbreaker.CircuitBreaker
+	failure func(err error) bool
+	state   metrics.Gauge
+}
+
+// WithBreaker with the circuit breaker factory, a breaker is created for each node.
+// The default is a sre breaker.
+func WithBreaker(fn func() circuitbreaker.CircuitBreaker) Option {
+	return func(o *options) {
+		o.breaker = fn
+	}
+}
+
+// WithFailure with the func reporting whether the error is a failure of the node,
+// selector.IsFailure by default.
+func WithFailure(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.failure = fn
+	}
+}
+
+// WithState with the breaker state gauge labeled by service and node address, which is
+// 1 while the node is skipped by its open breaker, and 0 otherwise.
+func WithState(g metrics.Gauge) Option {
+	return func(o *options) {
+		o.state = g
+	}
+}
+
+// Builder builds the breaker selectors of the selectors of the builder.
+type Builder struct {
+	builder selector.Builder
+	opts    []Option
+}
+
+// NewBuilder returns a builder of the breaker selectors, i.e.
+// selector.SetGlobalSelector(breaker.NewBuilder(p2c.NewBuilder())).
+func NewBuilder(b selector.Builder, opts ...Option) *Builder {
+	return &Builder{builder: b, opts: opts}
+}
+
+// Build creates a selector.
+func (b *Builder) Build() selector.Selector {
+	return New(b.builder.Build(), b.opts...)
+}
+
+// Selector is a selector which skips the nodes of the open breakers, the request fails
+// with selector.ErrNoAvailable only if the breakers of all the nodes are open.
+type Selector struct {
+	selector selector.Selector
+	opts     options
+
+	mu       sync.RWMutex
+	breakers map[string]circuitbreaker.CircuitBreaker
+}
+
+// New returns a selector which skips the nodes of s whose breakers are open.
+func New(s selector.Selector, opts ...Option) *Selector {
+	options := options{
+		breaker: func() circuitbreaker.CircuitBreaker {
+			return sre.NewBreaker()
+		},
+		failure: selector.IsFailure,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Selector{
+		selector: s,
+		opts:     options,
+		breakers: make(map[string]circuitbreaker.CircuitBreaker),
+	}
+}
+
+// Apply applies the nodes to the selector, the breakers of the removed nodes are dropped.
+func (s *Selector) Apply(nodes []selector.Node) {
+	breakers := make(map[string]circuitbreaker.CircuitBreaker, len(nodes))
+	s.mu.Lock()
+	for _, n := range nodes {
+		b, ok := s.breakers[n.Address()]
+		if !ok {
+			b = s.opts.breaker()
+		}
+		breakers[n.Address()] = b
+	}
+	s.breakers = breakers
+	s.mu.Unlock()
+	s.selector.Apply(nodes)
+}
+
+func (s *Selector) breaker(addr string) circuitbreaker.CircuitBreaker {
+	s.mu.RLock()
+	b, ok := s.breakers[addr]
+	s.mu.RUnlock()
+	if ok {
+		return b
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok = s.breakers[addr]; !ok {
+		b = s.opts.breaker()
+		s.breakers[addr] = b
+	}
+	return b
+}
+
+// Select selects a node whose breaker allows the request.
+func (s *Selector) Select(ctx context.Context, opts ...selector.SelectOption) (selector.Node, selector.DoneFunc, error) {
+	opts = append(opts, func(o *selector.SelectOptions) {
+		o.Filters = append(o.Filters, s.filter)
+	})
+	n, done, err := s.selector.Select(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := s.breaker(n.Address())
+	return n, func(ctx context.Context, di selector.DoneInfo) {
+		if di.Err != nil && s.opts.failure(di.Err) {
+			b.MarkFailed()
+		} else {
+			b.MarkSuccess()
+		}
+		done(ctx, di)
+	}, nil
+}
+
+func (s *Selector) filter(_ context.Context, nodes []selector.Node) []selector.Node {
+	filtered := make([]selector.Node, 0, len(nodes))
+	for _, n := range nodes {
+		open := s.breaker(n.Address()).Allow() != nil
+		if s.opts.state != nil {
+			var v float64
+			if open {
+				v = 1
+			}
+			s.opts.state.With(n.ServiceName(), n.Address()).Set(v)
+		}
+		if !open {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
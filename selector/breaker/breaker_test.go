@@ -0,0 +1,94 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/circuitbreaker"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/metrics"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/random"
+)
+
+// testBreaker opens after the consecutive failures.
+type testBreaker struct {
+	failures int
+}
+
+func (b *testBreaker) Allow() error {
+	if b.failures >= 3 {
+		return circuitbreaker.ErrNotAllowed
+	}
+	return nil
+}
+
+func (b *testBreaker) MarkSuccess() { b.failures = 0 }
+func (b *testBreaker) MarkFailed()  { b.failures++ }
+
+type mockGauge struct {
+	values map[string]float64
+	lvs    []string
+}
+
+func (g *mockGauge) With(lvs ...string) metrics.Gauge {
+	return &mockGauge{values: g.values, lvs: lvs}
+}
+
+func (g *mockGauge) Set(value float64) { g.values[strings.Join(g.lvs, ",")] = value }
+func (g *mockGauge) Add(delta float64) { g.values[strings.Join(g.lvs, ",")] += delta }
+func (g *mockGauge) Sub(delta float64) { g.values[strings.Join(g.lvs, ",")] -= delta }
+
+func TestSelector(t *testing.T) {
+	state := &mockGauge{values: map[string]float64{}}
+	s := New(random.New(), WithBreaker(func() circuitbreaker.CircuitBreaker { return &testBreaker{} }), WithState(state))
+	var nodes []selector.Node
+	for i := 0; i < 2; i++ {
+		addr := fmt.Sprintf("127.0.0.1:%d", 8000+i)
+		nodes = append(nodes, selector.NewNode(addr, &registry.ServiceInstance{ID: addr, Name: "helloworld"}))
+	}
+	s.Apply(nodes)
+
+	for i := 0; i < 50; i++ {
+		n, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var derr error
+		if n.Address() == "127.0.0.1:8000" {
+			derr = errors.Unavailable("UNAVAILABLE", "node is down")
+		}
+		done(context.Background(), selector.DoneInfo{Err: derr})
+	}
+	for i := 0; i < 20; i++ {
+		n, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Address() != "127.0.0.1:8001" {
+			t.Fatalf("the node of the open breaker is picked")
+		}
+		done(context.Background(), selector.DoneInfo{Err: errors.NotFound("NOT_FOUND", "not found")})
+	}
+	if state.values["helloworld,127.0.0.1:8000"] != 1 || state.values["helloworld,127.0.0.1:8001"] != 0 {
+		t.Errorf("unexpected breaker states: %v", state.values)
+	}
+
+	// the breakers of all the nodes are open.
+	for i := 0; i < 3; i++ {
+		_, done, _ := s.Select(context.Background())
+		done(context.Background(), selector.DoneInfo{Err: errors.Internal("INTERNAL", "internal")})
+	}
+	if _, _, err := s.Select(context.Background()); err != selector.ErrNoAvailable {
+		t.Errorf("got %v want %v", err, selector.ErrNoAvailable)
+	}
+
+	// the breakers of the reapplied nodes are kept.
+	s.Apply(nodes[:1])
+	if len(s.breakers) != 1 || s.breakers["127.0.0.1:8000"].Allow() == nil {
+		t.Errorf("unexpected breakers: %v", s.breakers)
+	}
+}
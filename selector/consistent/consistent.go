@@ -0,0 +1,259 @@
+// Package consistent provides a consistent hashing balancer, the requests of the same
+// key, i.e. the user or the tenant ID, are sent to the same node for the cache locality,
+// and the load of the nodes is bounded so that a hot key does not overload its node.
+package consistent
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/node/direct"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const (
+	// Name is consistent hashing balancer name
+	Name = "consistent_hash"
+)
+
+var _ selector.Balancer = (*Balancer)(nil)
+
+// KeyFunc returns the hash key of the request, the request is sent to a random node if
+// the key is empty.
+type KeyFunc func(ctx context.Context) string
+
+// Header returns the key of the request header of the client transport.
+func Header(name string) KeyFunc {
+	return func(ctx context.Context) string {
+		if tr, ok := transport.FromClientContext(ctx); ok {
+			return tr.RequestHeader().Get(name)
+		}
+		return ""
+	}
+}
+
+type keyKey struct{}
+
+// NewKeyContext returns a new Context that carries the hash key of the requests.
+func NewKeyContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromKeyContext returns the hash key of the context, if any.
+func FromKeyContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyKey{}).(string)
+	return key
+}
+
+// Option is consistent hashing balancer option.
+type Option func(o *options)
+
+type options struct {
+	key        KeyFunc
+	replicas   int
+	loadFactor float64
+}
+
+// WithKey with the hash key of the requests, the key of NewKeyContext by default.
+func WithKey(key KeyFunc) Option {
+	return func(o *options) { o.key = key }
+}
+
+// WithReplicas with the virtual nodes of every node on the ring, 160 by default, it
+// must be positive.
+func WithReplicas(n int) Option {
+	return func(o *options) { o.replicas = n }
+}
+
+// WithLoadFactor with the max in-flight requests of a node relative to the average,
+// 1.25 by default, a node above it is skipped for the next one on the ring. Zero
+// disables the bound.
+func WithLoadFactor(c float64) Option {
+	return func(o *options) { o.loadFactor = c }
+}
+
+// maxRings is the max cached rings, since the node filters of the requests may pick
+// the different subsets of the nodes, each of which has its own ring.
+const maxRings = 16
+
+// Balancer is a consistent hashing balancer.
+type Balancer struct {
+	opts options
+
+	mu sync.Mutex
+	// the rings of the nodes by the fingerprint of their addresses.
+	rings map[string]*ring
+	// the in-flight requests of the nodes by the address.
+	loads map[string]*int64
+	total int64
+}
+
+type ring struct {
+	hashes []uint64
+	nodes  []int
+}
+
+// New a consistent hashing selector.
+func New(opts ...Option) selector.Selector {
+	return NewBuilder(opts...).Build()
+}
+
+// Pick picks the node of the hash key.
+func (b *Balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, selector.ErrNoAvailable
+	}
+	key := b.opts.key(ctx)
+	if key == "" {
+		return b.picked(nodes[rand.Intn(len(nodes))])
+	}
+	r := b.ringOf(nodes)
+	h := hash(key)
+	start := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if b.opts.loadFactor <= 0 {
+		return b.picked(nodes[r.nodes[start%len(r.nodes)]])
+	}
+	capacity := int64(math.Ceil(b.opts.loadFactor * float64(atomic.LoadInt64(&b.total)+1) / float64(len(nodes))))
+	for i := 0; i < len(r.nodes); i++ {
+		n := nodes[r.nodes[(start+i)%len(r.nodes)]]
+		if atomic.LoadInt64(b.load(n.Address())) < capacity {
+			return b.picked(n)
+		}
+	}
+	return b.picked(nodes[r.nodes[start%len(r.nodes)]])
+}
+
+func (b *Balancer) picked(n selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	load := b.load(n.Address())
+	atomic.AddInt64(load, 1)
+	atomic.AddInt64(&b.total, 1)
+	done := n.Pick()
+	return n, func(ctx context.Context, di selector.DoneInfo) {
+		atomic.AddInt64(load, -1)
+		atomic.AddInt64(&b.total, -1)
+		done(ctx, di)
+	}, nil
+}
+
+func (b *Balancer) load(addr string) *int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	l, ok := b.loads[addr]
+	if !ok {
+		l = new(int64)
+		b.loads[addr] = l
+	}
+	return l
+}
+
+// ringOf returns the cached ring of the nodes, the ring of the new nodes is built outside
+// of the lock, so that it does not block the picks of the other rings.
+func (b *Balancer) ringOf(nodes []selector.WeightedNode) *ring {
+	addrs := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		addrs = append(addrs, n.Address())
+	}
+	fingerprint := strings.Join(addrs, ",")
+	b.mu.Lock()
+	r, ok := b.rings[fingerprint]
+	b.mu.Unlock()
+	if ok {
+		return r
+	}
+	r = newRing(addrs, b.opts.replicas)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.rings) >= maxRings {
+		// the nodes are changed or the filters pick too many subsets, start over
+		// and forget the loads of the removed nodes.
+		b.rings = make(map[string]*ring, maxRings)
+		present := make(map[string]bool, len(addrs))
+		for _, addr := range addrs {
+			present[addr] = true
+		}
+		for addr, l := range b.loads {
+			if !present[addr] && atomic.LoadInt64(l) == 0 {
+				delete(b.loads, addr)
+			}
+		}
+	}
+	b.rings[fingerprint] = r
+	return r
+}
+
+func newRing(addrs []string, replicas int) *ring {
+	r := &ring{
+		hashes: make([]uint64, 0, len(addrs)*replicas),
+		nodes:  make([]int, 0, len(addrs)*replicas),
+	}
+	type point struct {
+		hash uint64
+		node int
+	}
+	points := make([]point, 0, len(addrs)*replicas)
+	for i, addr := range addrs {
+		for j := 0; j < replicas; j++ {
+			points = append(points, point{hash(addr + "#" + strconv.Itoa(j)), i})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+	for _, p := range points {
+		r.hashes = append(r.hashes, p.hash)
+		r.nodes = append(r.nodes, p.node)
+	}
+	return r
+}
+
+// hash is fnv-1a with the finalizer of murmur3, which spreads the similar keys.
+func hash(key string) uint64 {
+	f := fnv.New64a()
+	f.Write([]byte(key))
+	h := f.Sum64()
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// NewBuilder returns a selector builder with consistent hashing balancer
+func NewBuilder(opts ...Option) selector.Builder {
+	return &selector.DefaultBuilder{
+		Balancer: &Builder{opts: opts},
+		Node:     &direct.Builder{},
+	}
+}
+
+// Builder is consistent hashing builder
+type Builder struct {
+	opts []Option
+}
+
+// Build creates Balancer
+func (b *Builder) Build() selector.Balancer {
+	options := options{
+		key:        FromKeyContext,
+		replicas:   160,
+		loadFactor: 1.25,
+	}
+	for _, o := range b.opts {
+		o(&options)
+	}
+	if options.replicas <= 0 {
+		panic("consistent: the replicas must be positive")
+	}
+	return &Balancer{
+		opts:  options,
+		rings: make(map[string]*ring, maxRings),
+		loads: make(map[string]*int64),
+	}
+}
@@ -0,0 +1,134 @@
+package consistent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+func testNodes(n int) []selector.Node {
+	nodes := make([]selector.Node, 0, n)
+	for i := 0; i < n; i++ {
+		addr := fmt.Sprintf("127.0.0.1:%d", 8000+i)
+		nodes = append(nodes, selector.NewNode(addr, &registry.ServiceInstance{ID: addr}))
+	}
+	return nodes
+}
+
+func pick(t *testing.T, s selector.Selector, key string) (string, selector.DoneFunc) {
+	n, done, err := s.Select(NewKeyContext(context.Background(), key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n.Address(), done
+}
+
+func TestAffinity(t *testing.T) {
+	s := New()
+	s.Apply(testNodes(10))
+	before := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		addr, done := pick(t, s, key)
+		done(context.Background(), selector.DoneInfo{})
+		if again, done := pick(t, s, key); again != addr {
+			t.Fatalf("the key %s is sent to %s and %s", key, addr, again)
+		} else {
+			done(context.Background(), selector.DoneInfo{})
+		}
+		before[key] = addr
+		counts[addr]++
+	}
+	for addr, n := range counts {
+		if n < 50 || n > 150 {
+			t.Errorf("%s takes %d keys want about 100", addr, n)
+		}
+	}
+
+	// only the keys of the removed node are moved.
+	s.Apply(testNodes(9))
+	for key, addr := range before {
+		got, done := pick(t, s, key)
+		done(context.Background(), selector.DoneInfo{})
+		if addr != "127.0.0.1:8009" && got != addr {
+			t.Errorf("the key %s is moved from %s to %s", key, addr, got)
+		}
+	}
+}
+
+func TestBoundedLoad(t *testing.T) {
+	s := New(WithLoadFactor(1.25))
+	s.Apply(testNodes(4))
+	first, _ := pick(t, s, "hot")
+	// the in-flight requests of the hot key spill over once the node is above
+	// 1.25 times of the average.
+	spilled := false
+	for i := 0; i < 10; i++ {
+		if addr, _ := pick(t, s, "hot"); addr != first {
+			spilled = true
+		}
+	}
+	if !spilled {
+		t.Error("the hot key is not spilled over")
+	}
+
+	s = New(WithLoadFactor(0))
+	s.Apply(testNodes(4))
+	first, _ = pick(t, s, "hot")
+	for i := 0; i < 10; i++ {
+		if addr, _ := pick(t, s, "hot"); addr != first {
+			t.Fatalf("the key %s is sent to %s without the bound", "hot", addr)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	s := New(WithKey(func(ctx context.Context) string { return "" }))
+	s.Apply(testNodes(4))
+	if _, _, err := s.Select(context.Background()); err != nil {
+		t.Fatalf("got %v want a random node", err)
+	}
+	if key := Header("x-md-user")(context.Background()); key != "" {
+		t.Errorf("got %s want empty key without the transport", key)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	s := New()
+	s.Apply(testNodes(4))
+	subsets := []selector.Filter{
+		func(ctx context.Context, nodes []selector.Node) []selector.Node { return nodes[:2] },
+		func(ctx context.Context, nodes []selector.Node) []selector.Node { return nodes[2:] },
+	}
+	picked := make(map[int]string)
+	for i := 0; i < 10; i++ {
+		for j, f := range subsets {
+			n, done, err := s.Select(NewKeyContext(context.Background(), "user"), selector.WithFilter(f))
+			if err != nil {
+				t.Fatal(err)
+			}
+			done(context.Background(), selector.DoneInfo{})
+			if addr, ok := picked[j]; ok && addr != n.Address() {
+				t.Fatalf("the key is sent to %s and %s of the subset %d", addr, n.Address(), j)
+			}
+			picked[j] = n.Address()
+		}
+	}
+	b := s.(*selector.Default).Balancer.(*Balancer)
+	if len(b.rings) != 2 {
+		t.Errorf("got %d rings want 2 of the subsets", len(b.rings))
+	}
+}
+
+func TestReplicas(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic want the panic of the replicas")
+		}
+	}()
+	New(WithReplicas(0))
+}
@@ -0,0 +1,61 @@
+package selector
+
+import (
+	"context"
+	"sync"
+)
+
+// Peer records the node picked for a request by the selector of the transport client.
+type Peer struct {
+	mu   sync.RWMutex
+	node Node
+}
+
+// Node returns the picked node, nil if no node is picked yet.
+func (p *Peer) Node() Node {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.node
+}
+
+// SetNode sets the picked node.
+func (p *Peer) SetNode(n Node) {
+	p.mu.Lock()
+	p.node = n
+	p.mu.Unlock()
+}
+
+type peerKey struct{}
+
+// NewPeerContext returns a new Context that carries the peer of the request.
+func NewPeerContext(ctx context.Context, p *Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, p)
+}
+
+// FromPeerContext returns the peer of the request stored in ctx, if any.
+func FromPeerContext(ctx context.Context) (*Peer, bool) {
+	p, ok := ctx.Value(peerKey{}).(*Peer)
+	return p, ok
+}
+
+type filtersKey struct{}
+
+// NewFilterContext returns a new Context that carries the node filters of the request,
+// which are applied after the node filters of the transport client.
+func NewFilterContext(ctx context.Context, filters ...Filter) context.Context {
+	return context.WithValue(ctx, filtersKey{}, append(FromFilterContext(ctx), filters...))
+}
+
+// FromFilterContext returns the node filters of the request stored in ctx, if any.
+func FromFilterContext(ctx context.Context) []Filter {
+	filters, _ := ctx.Value(filtersKey{}).([]Filter)
+	return filters[:len(filters):len(filters)]
+}
+
+// Picked sets the node of the peer of the request, if any, it is called by the transport
+// clients once a node is picked.
+func Picked(ctx context.Context, n Node) {
+	if p, ok := FromPeerContext(ctx); ok {
+		p.SetNode(n)
+	}
+}
@@ -0,0 +1,76 @@
+package selector
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+var (
+	_ Selector = (*Default)(nil)
+	_ Builder  = (*DefaultBuilder)(nil)
+)
+
+// Default is composite selector.
+type Default struct {
+	NodeBuilder WeightedNodeBuilder
+	Balancer    Balancer
+
+	nodes atomic.Value
+}
+
+// Select select one node.
+func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected Node, done DoneFunc, err error) {
+	var options SelectOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	nodes, ok := d.nodes.Load().([]WeightedNode)
+	if !ok {
+		return nil, nil, ErrNoAvailable
+	}
+	candidates := nodes
+	if len(options.Filters) > 0 {
+		filtered := make([]Node, 0, len(nodes))
+		for _, n := range nodes {
+			filtered = append(filtered, n)
+		}
+		for _, f := range options.Filters {
+			filtered = f(ctx, filtered)
+		}
+		candidates = make([]WeightedNode, 0, len(filtered))
+		for _, n := range filtered {
+			candidates = append(candidates, n.(WeightedNode))
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+	wn, done, err := d.Balancer.Pick(ctx, candidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wn.Raw(), done, nil
+}
+
+// Apply update nodes info.
+func (d *Default) Apply(nodes []Node) {
+	weightedNodes := make([]WeightedNode, 0, len(nodes))
+	for _, n := range nodes {
+		weightedNodes = append(weightedNodes, d.NodeBuilder.Build(n))
+	}
+	d.nodes.Store(weightedNodes)
+}
+
+// DefaultBuilder is the builder of Default selector.
+type DefaultBuilder struct {
+	Node     WeightedNodeBuilder
+	Balancer BalancerBuilder
+}
+
+// Build create builder
+func (db *DefaultBuilder) Build() Selector {
+	return &Default{
+		NodeBuilder: db.Node,
+		Balancer:    db.Balancer.Build(),
+	}
+}
@@ -0,0 +1,74 @@
+// Package filter provides the node filters of the selector, which route the requests
+// to the nodes of the version or the metadata, i.e. version=canary or zone=local,
+// statically or by the request headers and the routing rules.
+package filter
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Version returns a filter of the nodes of the version.
+func Version(version string) selector.Filter {
+	return Metadata(map[string]string{"version": version})
+}
+
+// Metadata returns a filter of the nodes with all the metadata, the "version" key is
+// matched by the node version as well.
+func Metadata(md map[string]string) selector.Filter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		return matched(nodes, md)
+	}
+}
+
+// Header returns a filter of the nodes whose metadata key is the value of the request
+// header, i.e. Header("x-md-canary", "version"), the nodes are not filtered if the
+// request has no such header.
+func Header(header, key string) selector.Filter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		tr, ok := transport.FromClientContext(ctx)
+		if !ok {
+			return nodes
+		}
+		v := tr.RequestHeader().Get(header)
+		if v == "" {
+			return nodes
+		}
+		return matched(nodes, map[string]string{key: v})
+	}
+}
+
+// Fallback returns a filter which returns all the nodes if f filters out all of them,
+// i.e. Fallback(Metadata(map[string]string{"zone": "local"})).
+func Fallback(f selector.Filter) selector.Filter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		if filtered := f(ctx, nodes); len(filtered) > 0 {
+			return filtered
+		}
+		return nodes
+	}
+}
+
+func match(n selector.Node, md map[string]string) bool {
+	for k, v := range md {
+		if k == "version" && n.Version() == v {
+			continue
+		}
+		if n.Metadata()[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func matched(nodes []selector.Node, md map[string]string) []selector.Node {
+	filtered := make([]selector.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if match(n, md) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
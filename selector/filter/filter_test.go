@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockTransport struct {
+	reqHeader headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return "" }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func clientCtx(kvs ...string) context.Context {
+	tr := &mockTransport{reqHeader: headerCarrier{}}
+	for i := 0; i < len(kvs); i += 2 {
+		tr.reqHeader.Set(kvs[i], kvs[i+1])
+	}
+	return transport.NewClientContext(context.Background(), tr)
+}
+
+func testNodes() []selector.Node {
+	return []selector.Node{
+		selector.NewNode("127.0.0.1:8001", &registry.ServiceInstance{Version: "v1", Metadata: map[string]string{"zone": "a"}}),
+		selector.NewNode("127.0.0.1:8002", &registry.ServiceInstance{Version: "v1", Metadata: map[string]string{"zone": "b"}}),
+		selector.NewNode("127.0.0.1:8003", &registry.ServiceInstance{Version: "canary", Metadata: map[string]string{"zone": "a"}}),
+	}
+}
+
+func addrs(nodes []selector.Node) []string {
+	addrs := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		addrs = append(addrs, n.Address())
+	}
+	return addrs
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		filter selector.Filter
+		want   []string
+	}{
+		{"version", context.Background(), Version("canary"), []string{"127.0.0.1:8003"}},
+		{"metadata", context.Background(), Metadata(map[string]string{"version": "v1", "zone": "a"}), []string{"127.0.0.1:8001"}},
+		{"metadata not found", context.Background(), Metadata(map[string]string{"zone": "c"}), []string{}},
+		{"fallback", context.Background(), Fallback(Metadata(map[string]string{"zone": "c"})), []string{"127.0.0.1:8001", "127.0.0.1:8002", "127.0.0.1:8003"}},
+		{"header", clientCtx("x-md-zone", "b"), Header("x-md-zone", "zone"), []string{"127.0.0.1:8002"}},
+		{"without header", clientCtx(), Header("x-md-zone", "zone"), []string{"127.0.0.1:8001", "127.0.0.1:8002", "127.0.0.1:8003"}},
+	}
+	for _, test := range tests {
+		if got := addrs(test.filter(test.ctx, testNodes())); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
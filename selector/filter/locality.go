@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+// LocalityOption is locality filter option.
+type LocalityOption func(*localityOptions)
+
+type localityOptions struct {
+	zoneKey   string
+	regionKey string
+	minNodes  int
+	random    func() float64
+}
+
+// WithLocalityKeys with the metadata keys of the region and the zone of the nodes,
+// "region" and "zone" by default.
+func WithLocalityKeys(region, zone string) LocalityOption {
+	return func(o *localityOptions) {
+		o.regionKey = region
+		o.zoneKey = zone
+	}
+}
+
+// WithMinNodes with the nodes a locality needs to take all the requests, 1 by default.
+// A locality of fewer nodes takes the share of the requests in proportion to its nodes,
+// and the rest spill over to the next locality, so that a few local nodes are not
+// overloaded.
+func WithMinNodes(n int) LocalityOption {
+	return func(o *localityOptions) {
+		o.minNodes = n
+	}
+}
+
+// Locality returns a filter which prefers the nodes of the zone, then of the region,
+// then all the nodes, by the region and the zone metadata of the nodes, to reduce the
+// cross zone latency and cost. An empty zone or region skips its locality.
+func Locality(region, zone string, opts ...LocalityOption) selector.Filter {
+	options := localityOptions{
+		zoneKey:   "zone",
+		regionKey: "region",
+		minNodes:  1,
+		random:    rand.Float64,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	var tiers []map[string]string
+	if zone != "" {
+		md := map[string]string{options.zoneKey: zone}
+		if region != "" {
+			md[options.regionKey] = region
+		}
+		tiers = append(tiers, md)
+	}
+	if region != "" {
+		tiers = append(tiers, map[string]string{options.regionKey: region})
+	}
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		for _, md := range tiers {
+			local := matched(nodes, md)
+			if len(local) == 0 {
+				continue
+			}
+			if len(local) >= options.minNodes || options.random()*float64(options.minNodes) < float64(len(local)) {
+				return local
+			}
+		}
+		return nodes
+	}
+}
@@ -0,0 +1,53 @@
+package filter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+func localityNodes() []selector.Node {
+	return []selector.Node{
+		selector.NewNode("127.0.0.1:8001", &registry.ServiceInstance{Metadata: map[string]string{"region": "r1", "zone": "z1"}}),
+		selector.NewNode("127.0.0.1:8002", &registry.ServiceInstance{Metadata: map[string]string{"region": "r1", "zone": "z2"}}),
+		selector.NewNode("127.0.0.1:8003", &registry.ServiceInstance{Metadata: map[string]string{"region": "r1", "zone": "z2"}}),
+		selector.NewNode("127.0.0.1:8004", &registry.ServiceInstance{Metadata: map[string]string{"region": "r2", "zone": "z1"}}),
+	}
+}
+
+func TestLocality(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter selector.Filter
+		want   []string
+	}{
+		{"zone", Locality("r1", "z1"), []string{"127.0.0.1:8001"}},
+		{"zone of another region", Locality("r2", "z1"), []string{"127.0.0.1:8004"}},
+		{"region", Locality("r1", "z3"), []string{"127.0.0.1:8001", "127.0.0.1:8002", "127.0.0.1:8003"}},
+		{"all", Locality("r3", "z1"), []string{"127.0.0.1:8001", "127.0.0.1:8002", "127.0.0.1:8003", "127.0.0.1:8004"}},
+		{"min nodes", Locality("r1", "z2", WithMinNodes(2)), []string{"127.0.0.1:8002", "127.0.0.1:8003"}},
+	}
+	for _, test := range tests {
+		if got := addrs(test.filter(context.Background(), localityNodes())); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLocalitySpillover(t *testing.T) {
+	// with 4 min nodes, the zone of 1 node takes a quarter of the requests, the region
+	// of 3 nodes takes 3/4 of the rest, and the others spill over to all the nodes.
+	counts := map[int]int{}
+	f := Locality("r1", "z1", WithMinNodes(4))
+	for i := 0; i < 10000; i++ {
+		counts[len(f(context.Background(), localityNodes()))]++
+	}
+	for n, want := range map[int]int{1: 2500, 3: 5625, 4: 1875} {
+		if got := counts[n]; got < want-500 || got > want+500 {
+			t.Errorf("got %d requests of %d nodes want about %d", got, n, want)
+		}
+	}
+}
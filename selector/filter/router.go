@@ -0,0 +1,121 @@
+package filter
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/internal/reload"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Rule routes the matched requests to the nodes of the metadata.
+type Rule struct {
+	// Header and Value match the requests by the request header, all the requests
+	// are matched if Header is empty.
+	Header string `json:"header"`
+	Value  string `json:"value"`
+	// Metadata is the metadata of the nodes, i.e. {"version": "canary"}.
+	Metadata map[string]string `json:"metadata"`
+	// Percentage of the matched requests which are routed, from 0 to 100, all of them
+	// if it is not set.
+	Percentage *float64 `json:"percentage"`
+}
+
+// Router routes the requests by the rules in order, the first rule which matches the
+// request and has the nodes wins. The nodes of the rules only receive the requests
+// routed to them, the other requests are balanced among the rest of the nodes, or
+// among all of them if there are no others.
+type Router struct {
+	mu     sync.RWMutex
+	rules  []Rule
+	random func() float64
+}
+
+// NewRouter returns a router of the rules.
+func NewRouter(rules []Rule) *Router {
+	r := &Router{random: rand.Float64}
+	r.Update(rules)
+	return r
+}
+
+// Update replaces the rules of the router.
+func (r *Router) Update(rules []Rule) {
+	rules = append([]Rule(nil), rules...)
+	r.mu.Lock()
+	r.rules = rules
+	r.mu.Unlock()
+}
+
+// Rules returns the rules of the router.
+func (r *Router) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rules
+}
+
+// Filter returns the node filter of the router.
+func (r *Router) Filter() selector.Filter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		rules := r.Rules()
+		if len(rules) == 0 {
+			return nodes
+		}
+		var header transport.Header
+		if tr, ok := transport.FromClientContext(ctx); ok {
+			header = tr.RequestHeader()
+		}
+		for _, rule := range rules {
+			if rule.Header != "" && (header == nil || header.Get(rule.Header) != rule.Value) {
+				continue
+			}
+			if rule.Percentage != nil && r.random()*100 >= *rule.Percentage {
+				continue
+			}
+			if filtered := matched(nodes, rule.Metadata); len(filtered) > 0 {
+				return filtered
+			}
+		}
+		rest := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			routed := false
+			for _, rule := range rules {
+				if match(n, rule.Metadata) {
+					routed = true
+					break
+				}
+			}
+			if !routed {
+				rest = append(rest, n)
+			}
+		}
+		if len(rest) == 0 {
+			return nodes
+		}
+		return rest
+	}
+}
+
+// Load returns a router loaded from the config key, which is a list of the rules, i.e.
+//
+//	[{"header": "x-md-canary", "value": "true", "metadata": {"version": "canary"}},
+//	 {"metadata": {"version": "canary"}, "percentage": 5}]
+//
+// and reloads it whenever the key changes.
+func Load(c config.Config, key string) (*Router, error) {
+	r := NewRouter(nil)
+	err := reload.Load(c, key, func(v config.Value) error {
+		var rules []Rule
+		if err := v.Scan(&rules); err != nil {
+			return err
+		}
+		r.Update(rules)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+func TestRouter(t *testing.T) {
+	five := 5.0
+	r := NewRouter([]Rule{
+		{Header: "x-md-canary", Value: "true", Metadata: map[string]string{"version": "canary"}},
+		{Metadata: map[string]string{"version": "canary"}, Percentage: &five},
+	})
+	random := 0.5
+	r.random = func() float64 { return random }
+	f := r.Filter()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		random float64
+		want   []string
+	}{
+		{"header", clientCtx("x-md-canary", "true"), 0.5, []string{"127.0.0.1:8003"}},
+		{"percentage", clientCtx(), 0.01, []string{"127.0.0.1:8003"}},
+		{"default", clientCtx(), 0.5, []string{"127.0.0.1:8001", "127.0.0.1:8002"}},
+		{"without transport", context.Background(), 0.5, []string{"127.0.0.1:8001", "127.0.0.1:8002"}},
+	}
+	for _, test := range tests {
+		random = test.random
+		if got := addrs(f(test.ctx, testNodes())); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+
+	// all the nodes are balanced if there are only the routed nodes.
+	nodes := testNodes()[2:]
+	if got := addrs(f(clientCtx(), nodes)); !reflect.DeepEqual(got, []string{"127.0.0.1:8003"}) {
+		t.Errorf("got %v want all the nodes", got)
+	}
+}
+
+type testSource struct {
+	kvs chan []*config.KeyValue
+}
+
+func (s *testSource) Load() ([]*config.KeyValue, error) {
+	return <-s.kvs, nil
+}
+
+func (s *testSource) Watch() (config.Watcher, error) {
+	return s, nil
+}
+
+func (s *testSource) Next() ([]*config.KeyValue, error) {
+	kvs, ok := <-s.kvs
+	if !ok {
+		return nil, context.Canceled
+	}
+	return kvs, nil
+}
+
+func (s *testSource) Close() error {
+	return nil
+}
+
+func routes(text string) []*config.KeyValue {
+	return []*config.KeyValue{{
+		Key:    "routes",
+		Value:  []byte(`{"routes":{"helloworld":` + text + `}}`),
+		Format: "json",
+	}}
+}
+
+func TestLoad(t *testing.T) {
+	source := &testSource{kvs: make(chan []*config.KeyValue, 1)}
+	source.kvs <- routes(`[{"header":"x-md-canary","value":"true","metadata":{"version":"canary"}}]`)
+	c := config.New(config.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	r, err := Load(c, "routes.helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules := r.Rules(); len(rules) != 1 || rules[0].Header != "x-md-canary" || rules[0].Metadata["version"] != "canary" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	// roll out the canary to 10% of the requests
+	source.kvs <- routes(`[{"metadata":{"version":"canary"},"percentage":10}]`)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if rules := r.Rules(); rules[0].Percentage != nil && *rules[0].Percentage == 10 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the router is not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
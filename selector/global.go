@@ -0,0 +1,22 @@
+package selector
+
+import "sync"
+
+var (
+	globalSelector Builder
+	globalLock     sync.RWMutex
+)
+
+// GlobalSelector returns global selector builder.
+func GlobalSelector() Builder {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
+	return globalSelector
+}
+
+// SetGlobalSelector set global selector builder.
+func SetGlobalSelector(builder Builder) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	globalSelector = builder
+}
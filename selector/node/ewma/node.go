@@ -0,0 +1,115 @@
+package ewma
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+const (
+	// The mean lifetime of `cost`, it reaches its half-life after Tau*ln(2).
+	tau = int64(time.Millisecond * 600)
+	// if statistic not collected,we add a big lag penalty to endpoint
+	penalty = uint64(time.Microsecond * 100)
+)
+
+var (
+	_ selector.WeightedNode        = (*Node)(nil)
+	_ selector.WeightedNodeBuilder = (*Builder)(nil)
+)
+
+// Node is endpoint instance
+type Node struct {
+	selector.Node
+
+	// client statistic data
+	lag      int64
+	success  uint64
+	inflight int64
+	// last collected timestamp
+	stamp int64
+	// last lastPick timestamp
+	lastPick int64
+}
+
+// Builder is ewma node builder.
+type Builder struct{}
+
+// Build create a weighted node.
+func (b *Builder) Build(n selector.Node) selector.WeightedNode {
+	return &Node{
+		Node:    n,
+		lag:     0,
+		success: 1000,
+		stamp:   time.Now().UnixNano(),
+	}
+}
+
+func (n *Node) load() (load uint64) {
+	lag := uint64(atomic.LoadInt64(&n.lag))
+	inflight := uint64(atomic.LoadInt64(&n.inflight))
+	if lag == 0 {
+		// penalize the node which has no statistic data yet
+		return penalty * (inflight + 1)
+	}
+	return lag * (inflight + 1)
+}
+
+// Pick pick a node.
+func (n *Node) Pick() selector.DoneFunc {
+	start := time.Now().UnixNano()
+	atomic.StoreInt64(&n.lastPick, start)
+	atomic.AddInt64(&n.inflight, 1)
+	return func(ctx context.Context, di selector.DoneInfo) {
+		atomic.AddInt64(&n.inflight, -1)
+		now := time.Now().UnixNano()
+		// get moving average ratio w
+		stamp := atomic.SwapInt64(&n.stamp, now)
+		td := now - stamp
+		if td < 0 {
+			td = 0
+		}
+		w := math.Exp(float64(-td) / float64(tau))
+
+		lag := now - start
+		if lag < 0 {
+			lag = 0
+		}
+		oldLag := atomic.LoadInt64(&n.lag)
+		if oldLag == 0 {
+			w = 0.0
+		}
+		lag = int64(float64(oldLag)*w + float64(lag)*(1.0-w))
+		atomic.StoreInt64(&n.lag, lag)
+
+		success := uint64(1000) // error value ,if error set 1
+		if di.Err != nil {
+			success = 0
+		}
+		oldSuc := atomic.LoadUint64(&n.success)
+		success = uint64(float64(oldSuc)*w + float64(success)*(1.0-w))
+		atomic.StoreUint64(&n.success, success)
+	}
+}
+
+// Weight is node effective weight.
+func (n *Node) Weight() (weight float64) {
+	weight = float64(atomic.LoadUint64(&n.success)) * float64(time.Second) / float64(n.load())
+	if n.InitialWeight() != nil {
+		weight = weight * float64(*n.InitialWeight())
+	}
+	return
+}
+
+// PickElapsed is time elapsed since the latest pick
+func (n *Node) PickElapsed() time.Duration {
+	return time.Duration(time.Now().UnixNano() - atomic.LoadInt64(&n.lastPick))
+}
+
+// Raw returns the original node
+func (n *Node) Raw() selector.Node {
+	return n.Node
+}
@@ -0,0 +1,21 @@
+package selector
+
+import "context"
+
+// Filter is node filter function.
+type Filter func(context.Context, []Node) []Node
+
+// SelectOptions is Select Options.
+type SelectOptions struct {
+	Filters []Filter
+}
+
+// SelectOption is Selector option.
+type SelectOption func(*SelectOptions)
+
+// WithFilter with filter options
+func WithFilter(fn ...Filter) SelectOption {
+	return func(opts *SelectOptions) {
+		opts.Filters = fn
+	}
+}
@@ -0,0 +1,299 @@
+// Package outlier provides the passive health checking of the selector, the nodes of
+// the consecutive failures, the high failure ratio or the high latency are ejected
+// from the pick set for an exponentially growing time. An ejected node is on probation
+// once its ejection ends, which takes one request at a time until a request succeeds,
+// and a failed one ejects it again.
+package outlier
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+var (
+	_ selector.Selector = (*Selector)(nil)
+	_ selector.Builder  = (*Builder)(nil)
+)
+
+// Option is outlier detection option.
+type Option func(o *options)
+
+type options struct {
+	consecutiveFailures int
+	failureRatio        float64
+	latency             time.Duration
+	minRequests         int
+	interval            time.Duration
+	baseEjection        time.Duration
+	maxEjection         time.Duration
+	maxEjectionPercent  float64
+	failure             func(err error) bool
+	now                 func() time.Time
+}
+
+// WithConsecutiveFailures with the consecutive failures which eject a node, 5 by default,
+// zero disables it.
+func WithConsecutiveFailures(n int) Option {
+	return func(o *options) { o.consecutiveFailures = n }
+}
+
+// WithFailureRatio with the failure ratio of an interval which ejects a node, 0.5 by
+// default, zero disables it.
+func WithFailureRatio(ratio float64) Option {
+	return func(o *options) { o.failureRatio = ratio }
+}
+
+// WithLatency with the average latency of an interval which ejects a node, zero by
+// default which disables it.
+func WithLatency(d time.Duration) Option {
+	return func(o *options) { o.latency = d }
+}
+
+// WithMinRequests with the min requests of an interval to check the failure ratio and
+// the latency, 10 by default, and at least 1.
+func WithMinRequests(n int) Option {
+	return func(o *options) { o.minRequests = n }
+}
+
+// WithInterval with the interval of the statistics, 10s by default.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) { o.interval = d }
+}
+
+// WithEjectionTime with the base and the max ejection time, 30s and 5m by default,
+// the ejection time doubles every time the node is ejected again.
+func WithEjectionTime(base, max time.Duration) Option {
+	return func(o *options) {
+		o.baseEjection = base
+		o.maxEjection = max
+	}
+}
+
+// WithMaxEjectionPercent with the max percentage of the ejected nodes, 50 by default,
+// a node can always be ejected if no node is ejected.
+func WithMaxEjectionPercent(p float64) Option {
+	return func(o *options) { o.maxEjectionPercent = p }
+}
+
+// WithFailure with the func reporting whether the error is a failure of the node,
+// selector.IsFailure by default.
+func WithFailure(fn func(err error) bool) Option {
+	return func(o *options) { o.failure = fn }
+}
+
+// Builder builds the outlier detecting selectors of the selectors of the builder.
+type Builder struct {
+	builder selector.Builder
+	opts    []Option
+}
+
+// NewBuilder returns a builder of the outlier detecting selectors, i.e.
+// selector.SetGlobalSelector(outlier.NewBuilder(wrr.NewBuilder())).
+func NewBuilder(b selector.Builder, opts ...Option) *Builder {
+	return &Builder{builder: b, opts: opts}
+}
+
+// Build creates a selector.
+func (b *Builder) Build() selector.Selector {
+	return New(b.builder.Build(), b.opts...)
+}
+
+// Selector is a selector which ejects the outlier nodes of the selector.
+type Selector struct {
+	selector selector.Selector
+	opts     options
+
+	mu    sync.Mutex
+	nodes map[string]*node
+	// the number of the applied nodes.
+	total int
+}
+
+type node struct {
+	// the statistics of the current interval.
+	start    time.Time
+	requests int
+	failures int
+	latency  time.Duration
+
+	consecutive int
+	ejections   int
+	until       time.Time
+	// probation is set once the node is ejected, and cleared by a succeeded request
+	// after the ejection, probing is set while the request is in flight.
+	probation bool
+	probing   bool
+}
+
+// New returns a selector which ejects the outlier nodes of s.
+func New(s selector.Selector, opts ...Option) *Selector {
+	options := options{
+		consecutiveFailures: 5,
+		failureRatio:        0.5,
+		minRequests:         10,
+		interval:            10 * time.Second,
+		baseEjection:        30 * time.Second,
+		maxEjection:         5 * time.Minute,
+		maxEjectionPercent:  50,
+		failure:             selector.IsFailure,
+		now:                 time.Now,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.minRequests < 1 {
+		options.minRequests = 1
+	}
+	return &Selector{selector: s, opts: options, nodes: make(map[string]*node)}
+}
+
+// Apply applies the nodes to the selector, the statistics of the removed nodes are dropped.
+func (s *Selector) Apply(nodes []selector.Node) {
+	s.mu.Lock()
+	present := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		present[n.Address()] = true
+	}
+	for addr := range s.nodes {
+		if !present[addr] {
+			delete(s.nodes, addr)
+		}
+	}
+	s.total = len(nodes)
+	s.mu.Unlock()
+	s.selector.Apply(nodes)
+}
+
+// Select selects a node which is not ejected.
+func (s *Selector) Select(ctx context.Context, opts ...selector.SelectOption) (selector.Node, selector.DoneFunc, error) {
+	opts = append(opts, func(o *selector.SelectOptions) {
+		o.Filters = append(o.Filters, s.filter)
+	})
+	n, done, err := s.selector.Select(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := s.opts.now()
+	addr := n.Address()
+	probe := s.probe(addr)
+	return n, func(ctx context.Context, di selector.DoneInfo) {
+		s.report(addr, s.opts.failure(di.Err), s.opts.now().Sub(start), probe)
+		done(ctx, di)
+	}, nil
+}
+
+// probe reports whether the request is the probe of the node on probation, which is
+// the only one in flight of the node.
+func (s *Selector) probe(addr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.nodes[addr]
+	if !ok || !n.probation || s.opts.now().Before(n.until) {
+		return false
+	}
+	n.probing = true
+	return true
+}
+
+// Ejected reports whether the node of the address is ejected.
+func (s *Selector) Ejected(addr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.nodes[addr]
+	return ok && s.opts.now().Before(n.until)
+}
+
+func (s *Selector) filter(_ context.Context, nodes []selector.Node) []selector.Node {
+	now := s.opts.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filtered := make([]selector.Node, 0, len(nodes))
+	for _, n := range nodes {
+		st, ok := s.nodes[n.Address()]
+		if !ok || !now.Before(st.until) && !(st.probation && st.probing) {
+			filtered = append(filtered, n)
+		}
+	}
+	if len(filtered) == 0 {
+		// all the nodes are ejected, which is more likely an outage of the callee.
+		return nodes
+	}
+	return filtered
+}
+
+func (s *Selector) report(addr string, failure bool, latency time.Duration, probe bool) {
+	now := s.opts.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.nodes[addr]
+	if !ok {
+		n = &node{start: now}
+		s.nodes[addr] = n
+	}
+	if probe {
+		n.probing = false
+		if failure {
+			s.eject(n, now)
+			return
+		}
+		n.probation = false
+	}
+	if now.Before(n.until) || n.probation {
+		// the requests which were sent before the ejection or the probe.
+		return
+	}
+	if now.Sub(n.start) >= s.opts.interval {
+		eject := n.requests >= s.opts.minRequests &&
+			((s.opts.failureRatio > 0 && float64(n.failures) >= s.opts.failureRatio*float64(n.requests)) ||
+				(s.opts.latency > 0 && n.latency/time.Duration(n.requests) >= s.opts.latency))
+		if eject {
+			s.eject(n, now)
+			return
+		}
+		// a healthy interval after the ejection decreases the ejection time.
+		if n.ejections > 0 && now.Sub(n.until) >= s.opts.interval {
+			n.ejections--
+		}
+		n.start, n.requests, n.failures, n.latency = now, 0, 0, 0
+	}
+	n.requests++
+	n.latency += latency
+	if failure {
+		n.failures++
+		n.consecutive++
+	} else {
+		n.consecutive = 0
+	}
+	if s.opts.consecutiveFailures > 0 && n.consecutive >= s.opts.consecutiveFailures {
+		s.eject(n, now)
+	}
+}
+
+// eject ejects the node unless there are too many ejected nodes.
+func (s *Selector) eject(n *node, now time.Time) {
+	n.start, n.requests, n.failures, n.latency, n.consecutive = now, 0, 0, 0, 0
+	ejected := 0
+	for _, o := range s.nodes {
+		if now.Before(o.until) {
+			ejected++
+		}
+	}
+	total := s.total
+	if total < len(s.nodes) {
+		total = len(s.nodes)
+	}
+	if ejected > 0 && float64(ejected+1) > s.opts.maxEjectionPercent*float64(total)/100 {
+		return
+	}
+	n.ejections++
+	d := time.Duration(float64(s.opts.baseEjection) * math.Pow(2, float64(n.ejections-1)))
+	if d > s.opts.maxEjection || d <= 0 {
+		d = s.opts.maxEjection
+	}
+	n.until = now.Add(d)
+	n.probation = true
+}
@@ -0,0 +1,193 @@
+package outlier
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/random"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) add(d time.Duration) { c.t = c.t.Add(d) }
+
+func newSelector(c *clock, n int, opts ...Option) *Selector {
+	s := New(random.New(), opts...)
+	s.opts.now = c.now
+	nodes := make([]selector.Node, 0, n)
+	for i := 0; i < n; i++ {
+		addr := fmt.Sprintf("127.0.0.1:%d", 8000+i)
+		nodes = append(nodes, selector.NewNode(addr, &registry.ServiceInstance{ID: addr}))
+	}
+	s.Apply(nodes)
+	return s
+}
+
+// call calls the nodes by the selector, the bad nodes fail.
+func call(t *testing.T, s *Selector, times int, bad map[string]bool) map[string]int {
+	picked := make(map[string]int)
+	for i := 0; i < times; i++ {
+		n, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[n.Address()]++
+		var err2 error
+		if bad[n.Address()] {
+			err2 = errors.Unavailable("UNAVAILABLE", "node is down")
+		}
+		done(context.Background(), selector.DoneInfo{Err: err2})
+	}
+	return picked
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	s := newSelector(c, 3)
+	bad := map[string]bool{"127.0.0.1:8000": true}
+	call(t, s, 100, bad)
+	if !s.Ejected("127.0.0.1:8000") {
+		t.Fatal("the failing node is not ejected")
+	}
+	if picked := call(t, s, 100, bad); picked["127.0.0.1:8000"] != 0 {
+		t.Errorf("the ejected node is picked %d times", picked["127.0.0.1:8000"])
+	}
+
+	// recovered after the ejection time, and ejected again for twice the time.
+	c.add(30 * time.Second)
+	if s.Ejected("127.0.0.1:8000") {
+		t.Fatal("the node is not recovered")
+	}
+	call(t, s, 100, bad)
+	c.add(59 * time.Second)
+	if !s.Ejected("127.0.0.1:8000") {
+		t.Fatal("the ejection time is not doubled")
+	}
+	c.add(time.Second)
+	if s.Ejected("127.0.0.1:8000") {
+		t.Fatal("the node is not recovered")
+	}
+}
+
+func TestFailureRatio(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	s := newSelector(c, 1, WithConsecutiveFailures(0), WithInterval(time.Second))
+	// the node fails a half of the requests without the consecutive failures.
+	for i := 0; i < 20; i++ {
+		_, done, _ := s.Select(context.Background())
+		var err error
+		if i%2 == 0 {
+			err = errors.Internal("INTERNAL", "internal")
+		}
+		done(context.Background(), selector.DoneInfo{Err: err})
+	}
+	if s.Ejected("127.0.0.1:8000") {
+		t.Fatal("the node is ejected before the interval ends")
+	}
+	c.add(time.Second)
+	call(t, s, 1, nil)
+	if !s.Ejected("127.0.0.1:8000") {
+		t.Error("the node of the high failure ratio is not ejected")
+	}
+}
+
+func TestLatency(t *testing.T) {
+	for _, test := range []struct {
+		latency time.Duration
+		ejected bool
+	}{
+		{50 * time.Millisecond, false},
+		{200 * time.Millisecond, true},
+	} {
+		c := &clock{t: time.Unix(1000, 0)}
+		s := newSelector(c, 1, WithLatency(100*time.Millisecond))
+		for i := 0; i < 10; i++ {
+			_, done, _ := s.Select(context.Background())
+			c.add(test.latency)
+			done(context.Background(), selector.DoneInfo{})
+		}
+		c.add(10 * time.Second)
+		call(t, s, 1, nil)
+		if s.Ejected("127.0.0.1:8000") != test.ejected {
+			t.Errorf("latency %s: got ejected %v want %v", test.latency, !test.ejected, test.ejected)
+		}
+	}
+}
+
+func TestMaxEjectionPercent(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	s := newSelector(c, 4)
+	bad := map[string]bool{"127.0.0.1:8000": true, "127.0.0.1:8001": true, "127.0.0.1:8002": true, "127.0.0.1:8003": true}
+	call(t, s, 200, bad)
+	ejected := 0
+	for addr := range bad {
+		if s.Ejected(addr) {
+			ejected++
+		}
+	}
+	if ejected != 2 {
+		t.Errorf("got %d ejected nodes want 2 of 50%%", ejected)
+	}
+}
+
+func TestBusinessErrors(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	s := newSelector(c, 1)
+	for i := 0; i < 10; i++ {
+		_, done, _ := s.Select(context.Background())
+		done(context.Background(), selector.DoneInfo{Err: errors.NotFound("USER_NOT_FOUND", "user not found")})
+	}
+	if s.Ejected("127.0.0.1:8000") {
+		t.Error("the node is ejected by the business errors")
+	}
+}
+
+func TestProbation(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	s := newSelector(c, 3)
+	bad := map[string]bool{"127.0.0.1:8000": true}
+	call(t, s, 100, bad)
+	c.add(30 * time.Second)
+
+	// the node on probation takes one request at a time.
+	var probe selector.DoneFunc
+	for probe == nil {
+		n, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Address() == "127.0.0.1:8000" {
+			probe = done
+			continue
+		}
+		done(context.Background(), selector.DoneInfo{})
+	}
+	if picked := call(t, s, 100, nil); picked["127.0.0.1:8000"] != 0 {
+		t.Errorf("the node on probation is picked %d times along with the probe", picked["127.0.0.1:8000"])
+	}
+	probe(context.Background(), selector.DoneInfo{})
+	if picked := call(t, s, 100, nil); picked["127.0.0.1:8000"] == 0 {
+		t.Error("the node is not recovered after the probe succeeded")
+	}
+}
+
+func TestMinRequests(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	s := newSelector(c, 1, WithMinRequests(0), WithLatency(time.Millisecond))
+	call(t, s, 5, map[string]bool{"127.0.0.1:8000": true})
+	c.add(30 * time.Second)
+	// the interval of no requests after the ejection.
+	call(t, s, 1, nil)
+	if s.Ejected("127.0.0.1:8000") {
+		t.Error("the node is ejected without the requests")
+	}
+}
@@ -0,0 +1,37 @@
+package p2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+func TestP2C(t *testing.T) {
+	p2c := New()
+	var nodes []selector.Node
+	for _, addr := range []string{"127.0.0.1:8080", "127.0.0.1:9090"} {
+		nodes = append(nodes, selector.NewNode(addr, &registry.ServiceInstance{ID: addr}))
+	}
+	p2c.Apply(nodes)
+	var count1, count2 int
+	for i := 0; i < 1000; i++ {
+		n, done, err := p2c.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var di selector.DoneInfo
+		if n.Address() == "127.0.0.1:9090" {
+			count2++
+			di.Err = errors.New("unavailable")
+		} else {
+			count1++
+		}
+		done(context.Background(), di)
+	}
+	if count1 <= count2 {
+		t.Fatalf("expected healthy node to be preferred, got %d/%d", count1, count2)
+	}
+}
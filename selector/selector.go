@@ -0,0 +1,99 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+// ErrNoAvailable is no available node.
+var ErrNoAvailable = errors.New("no_available_node")
+
+// Selector is node pick balancer.
+type Selector interface {
+	Rebalancer
+	// Select nodes
+	// if err == nil, selected and done must not be empty.
+	Select(ctx context.Context, opts ...SelectOption) (selected Node, done DoneFunc, err error)
+}
+
+// Rebalancer is nodes rebalancer.
+type Rebalancer interface {
+	// Apply is apply all nodes when any changes happen
+	Apply(nodes []Node)
+}
+
+// Builder build selector
+type Builder interface {
+	Build() Selector
+}
+
+// Node is node interface.
+type Node interface {
+	// Address is the unique address under the same service
+	Address() string
+	// ServiceName is service name
+	ServiceName() string
+	// InitialWeight is the initial value of scheduling weight
+	// if not set return nil
+	InitialWeight() *int64
+	// Version is service node version
+	Version() string
+	// Metadata is the kv pair metadata associated with the service instance.
+	// version,namespace,region,zone etc..
+	Metadata() map[string]string
+}
+
+// WeightedNode calculates scheduling weight in real time
+type WeightedNode interface {
+	Node
+	// Raw returns the original node
+	Raw() Node
+	// Weight is the runtime calculated weight
+	Weight() float64
+	// Pick the node
+	Pick() DoneFunc
+	// PickElapsed is time elapsed since the latest pick
+	PickElapsed() time.Duration
+}
+
+// WeightedNodeBuilder is WeightedNode Builder
+type WeightedNodeBuilder interface {
+	Build(Node) WeightedNode
+}
+
+// Balancer is balancer interface
+type Balancer interface {
+	Pick(ctx context.Context, nodes []WeightedNode) (selected WeightedNode, done DoneFunc, err error)
+}
+
+// BalancerBuilder build balancer
+type BalancerBuilder interface {
+	Build() Balancer
+}
+
+// DoneInfo is callback info when RPC invoke done.
+type DoneInfo struct {
+	// Response Error
+	Err error
+}
+
+// DoneFunc is callback function when RPC invoke done.
+type DoneFunc func(ctx context.Context, di DoneInfo)
+
+// IsFailure reports whether the error of a call is a failure of the node, i.e. the errors
+// of the codes Unknown, DeadlineExceeded, Internal and Unavailable, along with the errors
+// of the transport which are not the status errors. The canceled calls, i.e. the losing
+// hedged attempts, are not the failures of the node.
+func IsFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch kerrors.Code(err) {
+	case 2, 4, 13, 14:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package selector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		err     error
+		failure bool
+	}{
+		{nil, false},
+		{fmt.Errorf("dial: %w", context.Canceled), false},
+		{kerrors.Cancelled("CANCELLED", "canceled"), false},
+		{context.DeadlineExceeded, true},
+		{kerrors.DeadlineExceeded("DEADLINE_EXCEEDED", "timeout"), true},
+		{kerrors.Unavailable("UNAVAILABLE", "down"), true},
+		{kerrors.NotFound("USER_NOT_FOUND", "user not found"), false},
+	}
+	for _, test := range tests {
+		if got := IsFailure(test.err); got != test.failure {
+			t.Errorf("%v: got %t want %t", test.err, got, test.failure)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package wrr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+func TestWrr(t *testing.T) {
+	wrr := New()
+	var nodes []selector.Node
+	nodes = append(nodes, selector.NewNode("127.0.0.1:8080", &registry.ServiceInstance{
+		ID:       "127.0.0.1:8080",
+		Version:  "v2.0.0",
+		Metadata: map[string]string{"weight": "10"},
+	}))
+	nodes = append(nodes, selector.NewNode("127.0.0.1:9090", &registry.ServiceInstance{
+		ID:       "127.0.0.1:9090",
+		Version:  "v2.0.0",
+		Metadata: map[string]string{"weight": "20"},
+	}))
+	wrr.Apply(nodes)
+	var count1, count2 int
+	for i := 0; i < 90; i++ {
+		n, done, err := wrr.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(context.Background(), selector.DoneInfo{})
+		if n.Address() == "127.0.0.1:8080" {
+			count1++
+		} else if n.Address() == "127.0.0.1:9090" {
+			count2++
+		}
+	}
+	if count1 != 30 || count2 != 60 {
+		t.Fatalf("expected 30/60 picks, got %d/%d", count1, count2)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	_, _, err := New().Select(context.Background())
+	if err != selector.ErrNoAvailable {
+		t.Fatalf("expected %v, got %v", selector.ErrNoAvailable, err)
+	}
+}
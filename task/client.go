@@ -0,0 +1,146 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/internal/httputil"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/google/uuid"
+
+	// init json encoding
+	_ "github.com/go-kratos/kratos/v2/encoding/json"
+)
+
+// ContentTypeHeader is the header of the content type of the task payload.
+const ContentTypeHeader = "content-type"
+
+// ClientOption is client option.
+type ClientOption func(*Client)
+
+// WithCodec with the codec of the payloads, json by default.
+func WithCodec(codec encoding.Codec) ClientOption {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// WithMiddleware with client middleware.
+func WithMiddleware(m middleware.Middleware) ClientOption {
+	return func(c *Client) {
+		c.middleware = m
+	}
+}
+
+// WithMaxRetry with the default max retries of the tasks, 3 by default.
+func WithMaxRetry(n int) ClientOption {
+	return func(c *Client) {
+		c.maxRetry = n
+	}
+}
+
+// EnqueueOption is the option of an enqueued task.
+type EnqueueOption func(*enqueueOptions)
+
+type enqueueOptions struct {
+	t  *Task
+	at time.Time
+}
+
+// ID with the id of the task, a random UUID by default, the tasks of the same id are
+// enqueued once until they are processed.
+func ID(id string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.t.ID = id
+	}
+}
+
+// Header with a header of the task.
+func Header(key, value string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.t.Header[key] = value
+	}
+}
+
+// MaxRetry with the max retries of the task.
+func MaxRetry(n int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.t.MaxRetry = n
+	}
+}
+
+// ProcessAt with the time the task is processed at.
+func ProcessAt(at time.Time) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.at = at
+	}
+}
+
+// ProcessIn with the delay the task is processed in.
+func ProcessIn(d time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.at = time.Now().Add(d)
+	}
+}
+
+// Client encodes the tasks and enqueues them through the client middleware.
+type Client struct {
+	q          Queue
+	codec      encoding.Codec
+	middleware middleware.Middleware
+	maxRetry   int
+}
+
+// NewClient returns a client of the queue.
+func NewClient(q Queue, opts ...ClientOption) *Client {
+	c := &Client{
+		q:        q,
+		codec:    encoding.GetCodec("json"),
+		maxRetry: 3,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Enqueue encodes v as the payload of a task of the type, and returns the id of the task.
+func (c *Client) Enqueue(ctx context.Context, typ string, v interface{}, opts ...EnqueueOption) (string, error) {
+	t := &Task{
+		ID:       uuid.NewString(),
+		Type:     typ,
+		Header:   map[string]string{},
+		MaxRetry: c.maxRetry,
+	}
+	o := &enqueueOptions{t: t}
+	for _, opt := range opts {
+		opt(o)
+	}
+	ctx = transport.NewClientContext(ctx, &Transport{
+		endpoint:    c.q.Endpoint(),
+		operation:   typ,
+		reqHeader:   carrier.Header(t.Header),
+		replyHeader: carrier.Header{},
+		task:        t,
+	})
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		payload, err := c.codec.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		t.Payload = payload
+		t.Header[ContentTypeHeader] = httputil.ContentType(c.codec.Name())
+		return nil, c.q.Enqueue(ctx, t, o.at)
+	}
+	if c.middleware != nil {
+		h = c.middleware(h)
+	}
+	if _, err := h(ctx, v); err != nil {
+		return "", err
+	}
+	return t.ID, nil
+}
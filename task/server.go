@@ -0,0 +1,264 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/internal/httputil"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const loggerName = "task"
+
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
+
+// ServerOption is worker server option.
+type ServerOption func(*Server)
+
+// Codec with the codec of the payloads without a known content type, json by default.
+func Codec(c encoding.Codec) ServerOption {
+	return func(s *Server) {
+		s.codec = c
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Middleware with server middleware.
+func Middleware(m middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = m
+	}
+}
+
+// Concurrency with the number of the workers, 1 by default.
+func Concurrency(n int) ServerOption {
+	return func(s *Server) {
+		s.concurrency = n
+	}
+}
+
+// Backoff with the delay of the nth retry, DefaultBackoff by default.
+func Backoff(fn func(retried int) time.Duration) ServerOption {
+	return func(s *Server) {
+		s.backoff = fn
+	}
+}
+
+// DefaultBackoff doubles the delay from a second for each retry, up to an hour.
+func DefaultBackoff(retried int) time.Duration {
+	if retried < 1 {
+		retried = 1
+	}
+	if d := time.Second << uint(retried-1); retried <= 12 && d < time.Hour {
+		return d
+	}
+	return time.Hour
+}
+
+// HandlerFunc processes a decoded task payload.
+type HandlerFunc func(ctx context.Context, req interface{}) error
+
+type handler struct {
+	newReq func() interface{}
+	fn     HandlerFunc
+}
+
+// Server is a worker server, which dequeues the tasks and processes them through the
+// server middleware, the failed tasks are retried with the backoff until their max
+// retries, and then killed.
+type Server struct {
+	q           Queue
+	codec       encoding.Codec
+	log         *log.Helper
+	middleware  middleware.Middleware
+	concurrency int
+	backoff     func(retried int) time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.RWMutex
+	handlers map[string]*handler
+}
+
+// NewServer creates a worker server by options.
+func NewServer(q Queue, opts ...ServerOption) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &Server{
+		q:           q,
+		codec:       encoding.GetCodec("json"),
+		log:         log.NewHelper(loggerName, log.GetLogger()),
+		middleware:  middleware.Named("recovery", recovery.Recovery()),
+		concurrency: 1,
+		backoff:     DefaultBackoff,
+		ctx:         ctx,
+		cancel:      cancel,
+		handlers:    map[string]*handler{},
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	return srv
+}
+
+// Handle handles the tasks of the type, each payload is decoded into the request returned
+// by newReq, i.e. func() interface{} { return new(pb.SendEmail) }.
+func (s *Server) Handle(typ string, newReq func() interface{}, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[typ] = &handler{newReq: newReq, fn: h}
+}
+
+// Endpoint returns an empty endpoint, the workers are not registered.
+func (s *Server) Endpoint() (string, error) {
+	return "", nil
+}
+
+// Start starts the workers, and blocks until the server is stopped.
+func (s *Server) Start() error {
+	for i := 0; i < s.concurrency; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	s.log.Infof("[Task] server processing on: %s", s.q.Endpoint())
+	<-s.ctx.Done()
+	return nil
+}
+
+// Stop stops dequeuing the tasks, and waits the tasks in process.
+func (s *Server) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	s.log.Info("[Task] server stopping")
+	return nil
+}
+
+// Routes returns the task types and their middleware.
+func (s *Server) Routes() []transport.Route {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	routes := make([]transport.Route, 0, len(s.handlers))
+	for typ := range s.handlers {
+		ctx := transport.NewServerContext(context.Background(), &Transport{
+			endpoint:    s.q.Endpoint(),
+			operation:   typ,
+			reqHeader:   carrier.Header{},
+			replyHeader: carrier.Header{},
+		})
+		ms := middleware.Inspect(ctx, s.middleware)
+		routes = append(routes, transport.Route{
+			Kind:       transport.KindTask,
+			Operation:  typ,
+			Middleware: ms,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Operation < routes[j].Operation
+	})
+	return routes
+}
+
+func (s *Server) work() {
+	defer s.wg.Done()
+	for {
+		t, err := s.q.Dequeue(s.ctx)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.log.Errorf("failed to dequeue the task: %v", err)
+			select {
+			case <-time.After(time.Second):
+			case <-s.ctx.Done():
+				return
+			}
+			continue
+		}
+		s.settle(t, s.process(t))
+	}
+}
+
+// process processes the task with a background context, so that it is not interrupted
+// by the stopping.
+func (s *Server) process(t *Task) error {
+	s.mu.RLock()
+	h, ok := s.handlers[t.Type]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("task: unknown task type %s", t.Type)
+	}
+	if t.Header == nil {
+		t.Header = map[string]string{}
+	}
+	req := h.newReq()
+	if err := s.codecFor(t).Unmarshal(t.Payload, req); err != nil {
+		return fmt.Errorf("%w: decode the payload of %s: %v", ErrSkipRetry, t.Type, err)
+	}
+	ctx := transport.NewServerContext(context.Background(), &Transport{
+		endpoint:    s.q.Endpoint(),
+		operation:   t.Type,
+		reqHeader:   carrier.Header(t.Header),
+		replyHeader: carrier.Header{},
+		task:        t,
+	})
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, h.fn(ctx, req)
+	}
+	if s.middleware != nil {
+		next = s.middleware(next)
+	}
+	_, err := next(ctx, req)
+	return err
+}
+
+// settle acks the processed task, or retries or kills the failed one.
+func (s *Server) settle(t *Task, err error) {
+	ctx := context.Background()
+	if err == nil {
+		if err := s.q.Ack(ctx, t); err != nil {
+			s.log.Errorf("failed to ack the task %s/%s: %v", t.Type, t.ID, err)
+		}
+		return
+	}
+	t.LastError = err.Error()
+	if t.Retried < t.MaxRetry && !errors.Is(err, ErrSkipRetry) {
+		t.Retried++
+		s.log.Warnf("failed to process the task %s/%s, retry %d/%d: %v", t.Type, t.ID, t.Retried, t.MaxRetry, err)
+		if err := s.q.Retry(ctx, t, time.Now().Add(s.backoff(t.Retried))); err != nil {
+			s.log.Errorf("failed to retry the task %s/%s: %v", t.Type, t.ID, err)
+		}
+		return
+	}
+	s.log.Errorf("failed to process the task %s/%s, killed: %v", t.Type, t.ID, err)
+	if err := s.q.Kill(ctx, t); err != nil {
+		s.log.Errorf("failed to kill the task %s/%s: %v", t.Type, t.ID, err)
+	}
+}
+
+// codecFor returns the codec of the content type of the task, or the server codec if
+// it is unknown.
+func (s *Server) codecFor(t *Task) encoding.Codec {
+	if codec := encoding.GetCodec(httputil.ContentSubtype(t.Header[ContentTypeHeader])); codec != nil {
+		return codec
+	}
+	return s.codec
+}
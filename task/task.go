@@ -0,0 +1,49 @@
+// Package task provides the asynchronous tasks, which are enqueued by the client and
+// processed by the worker server through the middleware, the payloads are encoded by the
+// codecs and the failed tasks are retried with backoff.
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrDuplicateTask is returned by enqueuing a task of an existing id.
+	ErrDuplicateTask = errors.New("task: duplicate task")
+	// ErrSkipRetry is wrapped by the handlers to fail a task without the retries.
+	ErrSkipRetry = errors.New("task: skip retry")
+)
+
+// Task is an enqueued task.
+type Task struct {
+	ID string `json:"id"`
+	// Type is the type of the task, which selects the handler.
+	Type    string            `json:"type"`
+	Header  map[string]string `json:"header,omitempty"`
+	Payload []byte            `json:"payload"`
+	// Retried is the times the task has been retried, it is dead once Retried reaches MaxRetry.
+	Retried  int `json:"retried"`
+	MaxRetry int `json:"max_retry"`
+	// LastError is the error of the last failed run, if any.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Queue stores the tasks, which is implemented by the backends.
+type Queue interface {
+	// Endpoint returns the address of the queue, i.e. redis://127.0.0.1:6379.
+	Endpoint() string
+	// Enqueue enqueues the task processed at the time, at once if it is not later than now.
+	Enqueue(ctx context.Context, t *Task, at time.Time) error
+	// Dequeue blocks until a task is due or ctx is done, the task is leased to the caller
+	// until it is acked, retried or killed.
+	Dequeue(ctx context.Context) (*Task, error)
+	// Ack removes the processed task.
+	Ack(ctx context.Context, t *Task) error
+	// Retry enqueues the failed task again at the time.
+	Retry(ctx context.Context, t *Task, at time.Time) error
+	// Kill moves the failed task to the dead tasks, which are no longer processed.
+	Kill(ctx context.Context, t *Task) error
+	Close() error
+}
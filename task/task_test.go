@@ -0,0 +1,186 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type testQueue struct {
+	mu      sync.Mutex
+	tasks   chan *Task
+	at      []time.Time
+	acked   []string
+	retried []*Task
+	killed  []*Task
+}
+
+func newTestQueue() *testQueue {
+	return &testQueue{tasks: make(chan *Task, 10)}
+}
+
+func (q *testQueue) Endpoint() string {
+	return "test://queue"
+}
+
+func (q *testQueue) Enqueue(ctx context.Context, t *Task, at time.Time) error {
+	q.mu.Lock()
+	q.at = append(q.at, at)
+	q.mu.Unlock()
+	q.tasks <- t
+	return nil
+}
+
+func (q *testQueue) Dequeue(ctx context.Context) (*Task, error) {
+	select {
+	case t := <-q.tasks:
+		return t, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (q *testQueue) Ack(ctx context.Context, t *Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.acked = append(q.acked, t.ID)
+	return nil
+}
+
+func (q *testQueue) Retry(ctx context.Context, t *Task, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	c := *t
+	q.retried = append(q.retried, &c)
+	return nil
+}
+
+func (q *testQueue) Kill(ctx context.Context, t *Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.killed = append(q.killed, t)
+	return nil
+}
+
+func (q *testQueue) Close() error {
+	return nil
+}
+
+type email struct {
+	To string `json:"to"`
+}
+
+func TestEnqueue(t *testing.T) {
+	q := newTestQueue()
+	var operation string
+	m := func(h middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok && tr.Kind() == transport.KindTask {
+				operation = tr.Operation()
+				tr.RequestHeader().Set("x-md-trace", "1")
+			}
+			return h(ctx, req)
+		}
+	}
+	c := NewClient(q, WithMiddleware(m), WithMaxRetry(5))
+	at := time.Now().Add(time.Hour)
+	id, err := c.Enqueue(context.Background(), "email:send", &email{To: "a@b.c"}, ID("1"), ProcessAt(at), Header("x-md-a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Task{
+		ID:       "1",
+		Type:     "email:send",
+		Header:   map[string]string{"x-md-a": "b", "x-md-trace": "1", ContentTypeHeader: "application/json"},
+		Payload:  []byte(`{"to":"a@b.c"}`),
+		MaxRetry: 5,
+	}
+	if got := <-q.tasks; id != "1" || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %s %+v", want, id, got)
+	}
+	if operation != "email:send" || !q.at[0].Equal(at) {
+		t.Errorf("unexpected operation %s and time %v", operation, q.at[0])
+	}
+	if id, _ := c.Enqueue(context.Background(), "email:send", &email{}, MaxRetry(1)); id == "" {
+		t.Error("expected a random id")
+	}
+	if got := <-q.tasks; got.MaxRetry != 1 {
+		t.Errorf("expected the max retry of the task, got %d", got.MaxRetry)
+	}
+}
+
+func TestServer(t *testing.T) {
+	q := newTestQueue()
+	srv := NewServer(q, Concurrency(2))
+	done := make(chan string, 10)
+	srv.Handle("email:send", func() interface{} { return new(email) }, func(ctx context.Context, req interface{}) error {
+		e := req.(*email)
+		defer func() { done <- e.To }()
+		switch e.To {
+		case "retry":
+			return errors.New("retry")
+		case "skip":
+			return fmt.Errorf("invalid address: %w", ErrSkipRetry)
+		}
+		return nil
+	})
+	go func() {
+		_ = srv.Start()
+	}()
+	c := NewClient(q)
+	for _, to := range []string{"ok", "retry", "skip"} {
+		if _, err := c.Enqueue(context.Background(), "email:send", &email{To: to}, ID(to)); err != nil {
+			t.Fatal(err)
+		}
+		<-done
+	}
+	// the exhausted and the unknown tasks are killed.
+	q.tasks <- &Task{ID: "dead", Type: "email:send", Payload: []byte(`{"to":"retry"}`), Retried: 3, MaxRetry: 3}
+	<-done
+	q.tasks <- &Task{ID: "unknown", Type: "unknown", MaxRetry: 0}
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !reflect.DeepEqual(q.acked, []string{"ok"}) {
+		t.Errorf("unexpected acked %v", q.acked)
+	}
+	if len(q.retried) != 1 || q.retried[0].ID != "retry" || q.retried[0].Retried != 1 || q.retried[0].LastError != "retry" {
+		t.Errorf("unexpected retried %+v", q.retried)
+	}
+	var killed []string
+	for _, t := range q.killed {
+		killed = append(killed, t.ID)
+	}
+	if len(killed) < 2 || killed[0] != "skip" || killed[1] != "dead" {
+		t.Errorf("unexpected killed %v", killed)
+	}
+	routes := srv.Routes()
+	if len(routes) != 1 || routes[0].Kind != transport.KindTask || routes[0].Operation != "email:send" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	for retried, want := range map[int]time.Duration{
+		0:   time.Second,
+		1:   time.Second,
+		2:   2 * time.Second,
+		5:   16 * time.Second,
+		12:  2048 * time.Second,
+		13:  time.Hour,
+		100: time.Hour,
+	} {
+		if got := DefaultBackoff(retried); got != want {
+			t.Errorf("%d: expected %v, got %v", retried, want, got)
+		}
+	}
+}
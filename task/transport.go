@@ -0,0 +1,48 @@
+package task
+
+import (
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is a task transport, the operation is the type of the task, and the request
+// header is the header of the task.
+type Transport struct {
+	endpoint    string
+	operation   string
+	reqHeader   carrier.Header
+	replyHeader carrier.Header
+	task        *Task
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindTask
+}
+
+// Endpoint returns the endpoint of the queue.
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+// Operation returns the type of the task.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// RequestHeader returns the header of the task.
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+// ReplyHeader returns the reply header, which is not stored.
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+// Task returns the raw task, whose payload is encoded once the enqueuing middleware is done.
+func (tr *Transport) Task() *Task {
+	return tr.task
+}
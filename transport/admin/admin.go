@@ -0,0 +1,208 @@
+// Package admin provides the server of the operational endpoints on a separate port,
+// so that they never mix with the business traffic and can be firewalled independently:
+//
+//	/debug/pprof/    the runtime profiles of net/http/pprof
+//	/debug/vars      the variables of expvar
+//	/debug/buildinfo the service version and the module versions of the binary
+//	/debug/routes    the routes and their middleware of the inspected servers
+//	/metrics         the metrics handler, i.e. promhttp.Handler()
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const loggerName = "transport/admin"
+
+var _ transport.Server = (*Server)(nil)
+
+// ServerOption is admin server option.
+type ServerOption func(o *Server)
+
+// Network with server network.
+func Network(network string) ServerOption {
+	return func(s *Server) {
+		s.network = network
+	}
+}
+
+// Address with server address.
+func Address(addr string) ServerOption {
+	return func(s *Server) {
+		s.address = addr
+	}
+}
+
+// Listener with a pre-created server listener, the server serves on it instead of
+// listening on the address.
+func Listener(lis net.Listener) ServerOption {
+	return func(s *Server) {
+		s.lis = lis
+	}
+}
+
+// Metrics with the handler of the /metrics endpoint, which is not served if nil.
+func Metrics(h http.Handler) ServerOption {
+	return func(s *Server) {
+		s.metrics = h
+	}
+}
+
+// Inspect with the servers whose routes are reported by /debug/routes,
+// i.e. the HTTP and gRPC servers.
+func Inspect(inspectors ...transport.Inspector) ServerOption {
+	return func(s *Server) {
+		s.inspectors = inspectors
+	}
+}
+
+// Name with the service name reported by the build info.
+func Name(name string) ServerOption {
+	return func(s *Server) {
+		s.name = name
+	}
+}
+
+// Version with the service version reported by the build info.
+func Version(version string) ServerOption {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Server is an admin server.
+type Server struct {
+	*http.Server
+	lis        net.Listener
+	network    string
+	address    string
+	metrics    http.Handler
+	inspectors []transport.Inspector
+	name       string
+	version    string
+	log        *log.Helper
+}
+
+// NewServer creates an admin server by options.
+func NewServer(opts ...ServerOption) *Server {
+	srv := &Server{
+		network: "tcp",
+		address: ":0",
+		log:     log.NewHelper(loggerName, log.GetLogger()),
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/buildinfo", srv.buildInfo)
+	mux.HandleFunc("/debug/routes", srv.routes)
+	if srv.metrics != nil {
+		mux.Handle("/metrics", srv.metrics)
+	}
+	srv.Server = &http.Server{Handler: mux}
+	return srv
+}
+
+// Endpoint returns empty, the admin server is not registered as a service endpoint.
+func (s *Server) Endpoint() (string, error) {
+	return "", nil
+}
+
+// Start start the admin server.
+func (s *Server) Start() error {
+	if s.lis == nil {
+		lis, err := net.Listen(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.lis = lis
+	}
+	s.log.Infof("[ADMIN] server listening on: %s", s.lis.Addr().String())
+	if err := s.Serve(s.lis); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop stop the admin server.
+func (s *Server) Stop() error {
+	s.log.Info("[ADMIN] server stopping")
+	return s.Shutdown(context.Background())
+}
+
+func (s *Server) routes(w http.ResponseWriter, r *http.Request) {
+	routes := []transport.Route{}
+	for _, i := range s.inspectors {
+		routes = append(routes, i.Routes()...)
+	}
+	writeJSON(w, routes)
+}
+
+type module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+}
+
+type buildInfo struct {
+	Name      string    `json:"name,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	GoVersion string    `json:"go_version"`
+	Path      string    `json:"path,omitempty"`
+	Main      *module   `json:"main,omitempty"`
+	Deps      []*module `json:"deps,omitempty"`
+}
+
+func (s *Server) buildInfo(w http.ResponseWriter, r *http.Request) {
+	info := buildInfo{
+		Name:      s.name,
+		Version:   s.version,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Path = bi.Path
+		info.Main = &module{Path: bi.Main.Path, Version: bi.Main.Version, Sum: bi.Main.Sum}
+		for _, dep := range bi.Deps {
+			m := &module{Path: dep.Path, Version: dep.Version, Sum: dep.Sum}
+			if dep.Replace != nil {
+				m.Version, m.Sum = dep.Replace.Version, dep.Replace.Sum
+			}
+			info.Deps = append(info.Deps, m)
+		}
+	}
+	writeJSON(w, info)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
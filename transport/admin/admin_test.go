@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("requests_total 1\n"))
+	})
+	srv := NewServer(Listener(lis), Metrics(metrics), Name("helloworld"), Version("v1.0.0"))
+	if endpoint, err := srv.Endpoint(); err != nil || endpoint != "" {
+		t.Fatalf("unexpected endpoint: %s %v", endpoint, err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	get := func(path string) (int, []byte) {
+		res, err := http.Get("http://" + lis.Addr().String() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		data, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, data
+	}
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/vars"} {
+		if code, _ := get(path); code != http.StatusOK {
+			t.Errorf("%s: got %d want 200", path, code)
+		}
+	}
+	if code, data := get("/metrics"); code != http.StatusOK || string(data) != "requests_total 1\n" {
+		t.Errorf("metrics: got %d %s", code, data)
+	}
+	code, data := get("/debug/buildinfo")
+	if code != http.StatusOK {
+		t.Fatalf("buildinfo: got %d", code)
+	}
+	var info buildInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "helloworld" || info.Version != "v1.0.0" || info.GoVersion == "" {
+		t.Errorf("unexpected build info: %s", data)
+	}
+}
+
+func TestServerWithoutMetrics(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(Listener(lis))
+	go srv.Start()
+	defer srv.Stop()
+
+	res, err := http.Get("http://" + lis.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("got %d want 404", res.StatusCode)
+	}
+}
+
+type testInspector []transport.Route
+
+func (i testInspector) Routes() []transport.Route {
+	return i
+}
+
+func TestServerRoutes(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := testInspector{
+		{Kind: transport.KindGRPC, Operation: "/helloworld.Greeter/SayHello", Middleware: []string{"recovery"}},
+	}
+	srv := NewServer(Listener(lis), Inspect(routes))
+	go srv.Start()
+	defer srv.Stop()
+
+	res, err := http.Get("http://" + lis.Addr().String() + "/debug/routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var reply []transport.Route
+	if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reply, []transport.Route(routes)) {
+		t.Errorf("got %+v want %+v", reply, routes)
+	}
+}
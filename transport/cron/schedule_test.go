@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	from := time.Date(2021, 7, 30, 10, 7, 30, 0, loc) // friday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2021, 7, 30, 10, 8, 0, 0, loc)},
+		{"*/15 * * * *", time.Date(2021, 7, 30, 10, 15, 0, 0, loc)},
+		{"0 9-17 * * MON-FRI", time.Date(2021, 7, 30, 11, 0, 0, 0, loc)},
+		{"30 8 * * mon", time.Date(2021, 8, 2, 8, 30, 0, 0, loc)},
+		{"0 0 1,15 * *", time.Date(2021, 8, 1, 0, 0, 0, 0, loc)},
+		{"0 0 * Feb 7", time.Date(2022, 2, 6, 0, 0, 0, 0, loc)},
+		// either of the restricted days matches.
+		{"0 0 13 * 5", time.Date(2021, 8, 6, 0, 0, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, loc)},
+		{"@daily", time.Date(2021, 7, 31, 0, 0, 0, 0, loc)},
+		{"@hourly", time.Date(2021, 7, 30, 11, 0, 0, 0, loc)},
+		{"@every 90s", from.Add(90 * time.Second)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec, loc)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.spec, tt.want, got)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every 1x",
+		"@every -1s",
+	} {
+		if _, err := Parse(spec, time.UTC); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package cron provides a server running the scheduled jobs through the server middleware,
+// so that the jobs are traced, measured and recovered like the RPC handlers.
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const loggerName = "transport/cron"
+
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
+
+// Job is a scheduled job.
+type Job func(ctx context.Context) error
+
+// ServerOption is cron server option.
+type ServerOption func(*Server)
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Middleware with server middleware.
+func Middleware(m middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = m
+	}
+}
+
+// Location with the location of the cron specs, time.Local by default.
+func Location(loc *time.Location) ServerOption {
+	return func(s *Server) {
+		s.loc = loc
+	}
+}
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       Job
+}
+
+// Server is a cron server, each job runs in its own goroutine, and a run is skipped if the
+// previous run of the job is not finished.
+type Server struct {
+	log        *log.Helper
+	middleware middleware.Middleware
+	loc        *time.Location
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	started bool
+}
+
+// NewServer creates a cron server by options.
+func NewServer(opts ...ServerOption) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &Server{
+		log:        log.NewHelper(loggerName, log.GetLogger()),
+		middleware: middleware.Named("recovery", recovery.Recovery()),
+		loc:        time.Local,
+		ctx:        ctx,
+		cancel:     cancel,
+		jobs:       map[string]*job{},
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	return srv
+}
+
+// Add adds the job of the cron spec, see Parse, the name is the operation of the job
+// and must be unique.
+func (s *Server) Add(name, spec string, fn Job) error {
+	schedule, err := Parse(spec, s.loc)
+	if err != nil {
+		return err
+	}
+	return s.Schedule(name, schedule, fn)
+}
+
+// Every adds the job run once per the interval.
+func (s *Server) Every(name string, d time.Duration, fn Job) error {
+	if d <= 0 {
+		return fmt.Errorf("cron: the interval of %s must be positive", name)
+	}
+	return s.Schedule(name, Every(d), fn)
+}
+
+// Schedule adds the job of the schedule, it is started at once if the server is started.
+func (s *Server) Schedule(name string, schedule Schedule, fn Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("cron: duplicate job %s", name)
+	}
+	j := &job{name: name, schedule: schedule, fn: fn}
+	s.jobs[name] = j
+	if s.started && s.ctx.Err() == nil {
+		s.wg.Add(1)
+		go s.run(j)
+	}
+	return nil
+}
+
+// Endpoint returns an empty endpoint, the jobs are not registered.
+func (s *Server) Endpoint() (string, error) {
+	return "", nil
+}
+
+// Start starts the jobs, and blocks until the server is stopped.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = true
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.run(j)
+	}
+	n := len(s.jobs)
+	s.mu.Unlock()
+	s.log.Infof("[Cron] server started %d jobs", n)
+	<-s.ctx.Done()
+	return nil
+}
+
+// Stop stops scheduling the jobs, and waits the running ones.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+	s.wg.Wait()
+	s.log.Info("[Cron] server stopping")
+	return nil
+}
+
+// Routes returns the jobs and their middleware.
+func (s *Server) Routes() []transport.Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	routes := make([]transport.Route, 0, len(s.jobs))
+	for name := range s.jobs {
+		ctx := transport.NewServerContext(context.Background(), &Transport{
+			operation:   name,
+			reqHeader:   carrier.Header{},
+			replyHeader: carrier.Header{},
+		})
+		ms := middleware.Inspect(ctx, s.middleware)
+		routes = append(routes, transport.Route{
+			Kind:       transport.KindCron,
+			Operation:  name,
+			Middleware: ms,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Operation < routes[j].Operation
+	})
+	return routes
+}
+
+// run runs the job at its activations, the activations passed while it is running are skipped.
+func (s *Server) run(j *job) {
+	defer s.wg.Done()
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := s.exec(j, next); err != nil {
+			s.log.Errorf("failed to run the job %s: %v", j.name, err)
+		}
+	}
+}
+
+func (s *Server) exec(j *job, scheduled time.Time) error {
+	ctx := transport.NewServerContext(context.Background(), &Transport{
+		operation:   j.name,
+		scheduled:   scheduled,
+		reqHeader:   carrier.Header{},
+		replyHeader: carrier.Header{},
+	})
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, j.fn(ctx)
+	}
+	if s.middleware != nil {
+		h = s.middleware(h)
+	}
+	_, err := h(ctx, nil)
+	return err
+}
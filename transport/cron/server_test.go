@@ -0,0 +1,89 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestServer(t *testing.T) {
+	operations := make(chan string, 10)
+	m := func(h middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok && tr.Kind() == transport.KindCron {
+				select {
+				case operations <- tr.Operation():
+				default:
+				}
+			}
+			return h(ctx, req)
+		}
+	}
+	srv := NewServer(Middleware(m))
+	var runs int32
+	if err := srv.Every("cleanup", 10*time.Millisecond, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return errors.New("cleanup")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Every("cleanup", time.Second, nil); err == nil {
+		t.Error("expected an error of the duplicate job")
+	}
+	if err := srv.Add("report", "@every 1x", nil); err == nil {
+		t.Error("expected an error of the invalid spec")
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start()
+	}()
+	select {
+	case op := <-operations:
+		if op != "cleanup" {
+			t.Errorf("expected the operation of the job, got %s", op)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the job")
+	}
+	// the jobs added after starting are run too.
+	if err := srv.Every("report", 10*time.Millisecond, func(ctx context.Context) error {
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for op := range operations {
+		if op == "report" {
+			break
+		}
+	}
+	routes := srv.Routes()
+	if len(routes) != 2 || routes[0].Operation != "cleanup" || routes[1].Operation != "report" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	n := atomic.LoadInt32(&runs)
+	time.Sleep(30 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != n {
+		t.Error("expected the jobs to be stopped")
+	}
+}
+
+func TestServerRecovery(t *testing.T) {
+	srv := NewServer()
+	err := srv.exec(&job{name: "panic", fn: func(ctx context.Context) error {
+		panic("boom")
+	}}, time.Now())
+	if err == nil {
+		t.Error("expected the panic to be recovered as an error")
+	}
+}
@@ -0,0 +1,48 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/go-kratos/kratos/v2/internal/carrier"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is a cron transport, the operation is the name of the job.
+type Transport struct {
+	operation   string
+	scheduled   time.Time
+	reqHeader   carrier.Header
+	replyHeader carrier.Header
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindCron
+}
+
+// Endpoint returns an empty endpoint, the jobs are not served remotely.
+func (tr *Transport) Endpoint() string {
+	return ""
+}
+
+// Operation returns the name of the job.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// RequestHeader returns an empty header, which may be set by the middleware.
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+// ReplyHeader returns the reply header.
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+// Scheduled returns the scheduled time of the run.
+func (tr *Transport) Scheduled() time.Time {
+	return tr.scheduled
+}
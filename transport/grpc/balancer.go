@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/wrr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+const (
+	balancerName = "selector"
+	// serviceInstanceKey is the address attribute set by the discovery resolver.
+	serviceInstanceKey = "rawServiceInstance"
+)
+
+var (
+	_ base.PickerBuilder = (*balancerBuilder)(nil)
+	_ balancer.Picker    = (*balancerPicker)(nil)
+)
+
+func init() {
+	if selector.GlobalSelector() == nil {
+		selector.SetGlobalSelector(wrr.NewBuilder())
+	}
+	b := base.NewBalancerBuilder(
+		balancerName,
+		&balancerBuilder{},
+		base.Config{HealthCheck: true},
+	)
+	balancer.Register(b)
+}
+
+type balancerBuilder struct{}
+
+// Build creates a grpc Picker.
+func (b *balancerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		// Block the RPC until a new picker is available via UpdateState().
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes := make([]selector.Node, 0, len(info.ReadySCs))
+	for conn, info := range info.ReadySCs {
+		var ins *registry.ServiceInstance
+		if info.Address.Attributes != nil {
+			ins, _ = info.Address.Attributes.Value(serviceInstanceKey).(*registry.ServiceInstance)
+		}
+		nodes = append(nodes, &grpcNode{
+			Node:    selector.NewNode(info.Address.Addr, ins),
+			subConn: conn,
+		})
+	}
+	p := &balancerPicker{
+		selector: selector.GlobalSelector().Build(),
+	}
+	p.selector.Apply(nodes)
+	return p
+}
+
+// balancerPicker is a grpc picker.
+type balancerPicker struct {
+	selector selector.Selector
+}
+
+// Pick pick instances.
+func (p *balancerPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	filters, _ := info.Ctx.Value(filtersKey{}).([]selector.Filter)
+	filters = append(filters, selector.FromFilterContext(info.Ctx)...)
+	n, done, err := p.selector.Select(info.Ctx, selector.WithFilter(filters...))
+	if err != nil {
+		return balancer.PickResult{}, err
+	}
+	selector.Picked(info.Ctx, n)
+	return balancer.PickResult{
+		SubConn: n.(*grpcNode).subConn,
+		Done: func(di balancer.DoneInfo) {
+			done(info.Ctx, selector.DoneInfo{Err: di.Err})
+		},
+	}, nil
+}
+
+type grpcNode struct {
+	selector.Node
+	subConn balancer.SubConn
+}
+
+// filtersKey is the context key of the node filters of the client, which are picked
+// up by the picker of the call.
+type filtersKey struct{}
+
+func unaryFilterInterceptor(filters []selector.Filter) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(context.WithValue(ctx, filtersKey{}, filters[:len(filters):len(filters)]), method, req, reply, cc, opts...)
+	}
+}
+
+func streamFilterInterceptor(filters []selector.Filter) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(context.WithValue(ctx, filtersKey{}, filters[:len(filters):len(filters)]), desc, cc, method, opts...)
+	}
+}
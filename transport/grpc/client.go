@@ -2,15 +2,25 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/host"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
-	"github.com/go-kratos/kratos/v2/middleware/status"
 	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/go-kratos/kratos/v2/transport/grpc/resolver/discovery"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 )
 
 // ClientOption is gRPC client option.
@@ -37,10 +47,24 @@ func WithMiddleware(m middleware.Middleware) ClientOption {
 	}
 }
 
-// WithRegistry with client registry.
-func WithRegistry(r registry.Registry) ClientOption {
+// WithDiscovery with client discovery.
+func WithDiscovery(d registry.Discovery) ClientOption {
 	return func(o *clientOptions) {
-		o.registry = r
+		o.discovery = d
+	}
+}
+
+// WithTLSConfig with client tls config.
+func WithTLSConfig(c *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConf = c
+	}
+}
+
+// WithNodeFilter with the node filters of the selector, i.e. the canary routing.
+func WithNodeFilter(filters ...selector.Filter) ClientOption {
+	return func(o *clientOptions) {
+		o.filters = filters
 	}
 }
 
@@ -56,11 +80,16 @@ type clientOptions struct {
 	endpoint   string
 	timeout    time.Duration
 	middleware middleware.Middleware
-	registry   registry.Registry
+	discovery  registry.Discovery
+	tlsConf    *tls.Config
+	filters    []selector.Filter
 	grpcOpts   []grpc.DialOption
 }
 
 // Dial returns a GRPC connection.
+// The endpoint can be a direct address or a discovery target such as
+// discovery:///helloworld when a discovery is provided, or any target of the
+// registered grpc resolvers, i.e. xds:///helloworld of the xDS resolver.
 func Dial(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn, error) {
 	return dial(ctx, false, opts...)
 }
@@ -72,41 +101,116 @@ func DialInsecure(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn,
 
 func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.ClientConn, error) {
 	options := clientOptions{
-		timeout: 500 * time.Millisecond,
-		middleware: middleware.Chain(
-			recovery.Recovery(),
-			status.Client(),
-		),
+		timeout:    500 * time.Millisecond,
+		middleware: recovery.Recovery(),
 	}
 	for _, o := range opts {
 		o(&options)
 	}
 	var grpcOpts = []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy":"%s"}`, balancerName)),
 		grpc.WithTimeout(options.timeout),
 		grpc.WithUnaryInterceptor(UnaryClientInterceptor(options.middleware)),
 	}
-	if options.registry != nil {
-		grpc.WithResolvers(discovery.NewBuilder(options.registry))
+	if len(options.filters) > 0 {
+		grpcOpts = append(grpcOpts,
+			grpc.WithChainUnaryInterceptor(unaryFilterInterceptor(options.filters)),
+			grpc.WithChainStreamInterceptor(streamFilterInterceptor(options.filters)),
+		)
+	}
+	if options.discovery != nil {
+		grpcOpts = append(grpcOpts, grpc.WithResolvers(discovery.NewBuilder(options.discovery)))
 	}
 	if insecure {
 		grpcOpts = append(grpcOpts, grpc.WithInsecure())
+	} else {
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(options.tlsConf)))
 	}
 	if len(options.grpcOpts) > 0 {
 		grpcOpts = append(grpcOpts, options.grpcOpts...)
 	}
-	return grpc.DialContext(ctx, options.endpoint, grpcOpts...)
+	endpoint := options.endpoint
+	if path, ok := host.UnixPath(endpoint); ok {
+		// the unix resolver of grpc dials unix:///tmp/app.sock.
+		endpoint = "unix://" + path
+	}
+	return grpc.DialContext(ctx, endpoint, grpcOpts...)
 }
 
 // UnaryClientInterceptor retruns a unary client interceptor.
 func UnaryClientInterceptor(m middleware.Middleware) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var endpoint string
+		if cc != nil {
+			endpoint = cc.Target()
+		}
+		tr := &Transport{
+			endpoint:    endpoint,
+			operation:   method,
+			reqHeader:   headerCarrier{},
+			replyHeader: headerCarrier{},
+		}
+		ctx = transport.NewClientContext(ctx, tr)
+		ctx = NewClientContext(ctx, ClientInfo{FullMethod: method})
+		var (
+			mu    sync.Mutex
+			calls int32
+		)
 		h := func(ctx context.Context, req interface{}) (interface{}, error) {
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			if len(tr.reqHeader) > 0 {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				ctx = metadata.NewOutgoingContext(ctx, metadata.Join(md, metadata.MD(tr.reqHeader)))
+			}
+			// the handler can be called again by the middleware, i.e. the retries and
+			// the hedged calls, the later calls are decoded into their own replies.
+			out := reply
+			if atomic.AddInt32(&calls, 1) > 1 {
+				out = newReply(reply)
+			}
+			var header metadata.MD
+			err := invoker(ctx, method, req, out, cc, append(opts[:len(opts):len(opts)], grpc.Header(&header))...)
+			mu.Lock()
+			for k, v := range header {
+				tr.replyHeader[k] = v
+			}
+			mu.Unlock()
+			if err != nil {
+				// convert the gRPC status into a kratos error
+				if se, ok := errors.FromError(err); ok {
+					return out, se
+				}
+				return out, err
+			}
+			return out, nil
 		}
 		if m != nil {
 			h = m(h)
 		}
-		_, err := h(ctx, req)
+		out, err := h(ctx, req)
+		if err == nil {
+			setReply(reply, out)
+		}
 		return err
 	}
 }
+
+// newReply returns an empty reply of the type of the proto reply, or the reply itself
+// if it is not a proto message.
+func newReply(reply interface{}) interface{} {
+	if m, ok := reply.(proto.Message); ok {
+		return m.ProtoReflect().New().Interface()
+	}
+	return reply
+}
+
+// setReply sets the reply of the call to the reply of the handler, if they differ.
+func setReply(reply, out interface{}) {
+	dst, ok := reply.(proto.Message)
+	if !ok {
+		return
+	}
+	if src, ok := out.(proto.Message); ok && src != dst {
+		proto.Reset(dst)
+		proto.Merge(dst, src)
+	}
+}
@@ -0,0 +1,179 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type testDiscovery struct{}
+
+func (d *testDiscovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	return nil, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return &testWatcher{ch: make(chan struct{})}, nil
+}
+
+type testWatcher struct {
+	ch chan struct{}
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	<-w.ch
+	return nil, context.Canceled
+}
+
+func (w *testWatcher) Close() error {
+	close(w.ch)
+	return nil
+}
+
+func TestDialDiscovery(t *testing.T) {
+	conn, err := DialInsecure(context.Background(),
+		WithEndpoint("discovery:///helloworld"),
+		WithDiscovery(&testDiscovery{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var method string
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if info, ok := FromClientContext(ctx); ok {
+				method = info.FullMethod
+			}
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok || tr.Kind() != transport.KindGRPC || tr.Operation() != "/helloworld.Greeter/SayHello" {
+				t.Fatalf("expected grpc transport, got %v", tr)
+			}
+			tr.RequestHeader().Set("x-md-uid", "1")
+			reply, err := handler(ctx, req)
+			if v := tr.ReplyHeader().Get("x-md-reply"); v != "2" {
+				t.Errorf("expected reply header, got %q", v)
+			}
+			return reply, err
+		}
+	}
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if v := md.Get("x-md-uid"); len(v) != 1 || v[0] != "1" {
+			t.Errorf("expected outgoing metadata, got %v", md)
+		}
+		for _, opt := range opts {
+			if h, ok := opt.(grpc.HeaderCallOption); ok {
+				*h.HeaderAddr = metadata.Pairs("x-md-reply", "2")
+			}
+		}
+		return nil
+	}
+	if err := UnaryClientInterceptor(m)(context.Background(), "/helloworld.Greeter/SayHello", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if method != "/helloworld.Greeter/SayHello" {
+		t.Fatalf("expected full method, got %s", method)
+	}
+}
+
+func TestUnaryClientInterceptorReentrant(t *testing.T) {
+	// the middleware calls the handler twice concurrently and returns the second reply.
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ch := make(chan interface{}, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					reply, _ := handler(ctx, req)
+					ch <- reply
+				}()
+			}
+			first, second := <-ch, <-ch
+			if first == second {
+				t.Error("the calls are decoded into the same reply")
+			}
+			if first.(*wrapperspb.StringValue).Value == "1" {
+				return second, nil
+			}
+			return first, nil
+		}
+	}
+	var calls int32
+	var mu sync.Mutex
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		mu.Lock()
+		calls++
+		reply.(*wrapperspb.StringValue).Value = fmt.Sprint(calls)
+		mu.Unlock()
+		return nil
+	}
+	reply := &wrapperspb.StringValue{}
+	if err := UnaryClientInterceptor(m)(context.Background(), "/helloworld.Greeter/SayHello", nil, reply, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Value != "2" {
+		t.Errorf("got %q want the reply of the second call", reply.Value)
+	}
+}
+
+func TestUnaryClientInterceptorReply(t *testing.T) {
+	retry := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if reply, err := handler(ctx, req); err == nil {
+				return reply, nil
+			}
+			return handler(ctx, req)
+		}
+	}
+	tests := []struct {
+		name  string
+		m     middleware.Middleware
+		fails int
+		want  string
+		err   bool
+	}{
+		{"no middleware", nil, 0, "1", false},
+		{"single call", retry, 0, "1", false},
+		{"retried", retry, 1, "2", false},
+		{"failed", retry, 2, "", true},
+	}
+	for _, test := range tests {
+		var (
+			calls   int
+			replies []interface{}
+		)
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			calls++
+			replies = append(replies, reply)
+			if calls <= test.fails {
+				reply.(*wrapperspb.StringValue).Value = "partial"
+				return fmt.Errorf("call %d failed", calls)
+			}
+			reply.(*wrapperspb.StringValue).Value = fmt.Sprint(calls)
+			return nil
+		}
+		reply := &wrapperspb.StringValue{}
+		err := UnaryClientInterceptor(test.m)(context.Background(), "/helloworld.Greeter/SayHello", nil, reply, nil, invoker)
+		if (err != nil) != test.err {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+		if replies[0] != reply {
+			t.Errorf("%s: the first call is not decoded into the reply", test.name)
+		}
+		if !test.err && reply.Value != test.want {
+			t.Errorf("%s: got %q want %q", test.name, reply.Value, test.want)
+		}
+	}
+}
@@ -2,7 +2,7 @@ package grpc
 
 import "context"
 
-// ServerInfo is HTTP server infomation.
+// ServerInfo is gRPC server infomation.
 type ServerInfo struct {
 	// Server is the service implementation the user provides. This is read-only.
 	Server interface{}
@@ -22,3 +22,22 @@ func FromContext(ctx context.Context) (s ServerInfo, ok bool) {
 	s, ok = ctx.Value(serverKey{}).(ServerInfo)
 	return
 }
+
+// ClientInfo is gRPC client infomation.
+type ClientInfo struct {
+	// FullMethod is the full RPC method string, i.e., /package.service/method.
+	FullMethod string
+}
+
+type clientKey struct{}
+
+// NewClientContext returns a new Context that carries value.
+func NewClientContext(ctx context.Context, c ClientInfo) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// FromClientContext returns the ClientInfo value stored in ctx, if any.
+func FromClientContext(ctx context.Context) (c ClientInfo, ok bool) {
+	c, ok = ctx.Value(clientKey{}).(ClientInfo)
+	return
+}
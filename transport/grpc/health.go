@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"github.com/go-kratos/kratos/v2/health"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// registerHealth registers the grpc.health.v1 service which reports the status of the
+// health registry, the empty service name is the overall status and every dependency
+// is reported as a service of its name.
+func (s *Server) registerHealth() {
+	s.healthServer = grpchealth.NewServer()
+	healthpb.RegisterHealthServer(s.Server, s.healthServer)
+	update := func() {
+		s.healthServer.SetServingStatus("", servingStatus(s.health.Status()))
+		for _, name := range s.health.Dependencies() {
+			s.healthServer.SetServingStatus(name, servingStatus(s.health.Get(name)))
+		}
+	}
+	s.health.Watch(update)
+	update()
+}
+
+// HealthServer returns the grpc.health.v1 service of the health registry, for another
+// gRPC server serving the registered services.
+func (s *Server) HealthServer() healthpb.HealthServer {
+	return s.healthServer
+}
+
+func servingStatus(status health.Status) healthpb.HealthCheckResponse_ServingStatus {
+	switch status {
+	case health.StatusServing:
+		return healthpb.HealthCheckResponse_SERVING
+	case health.StatusNotServing:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	default:
+		return healthpb.HealthCheckResponse_UNKNOWN
+	}
+}
@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/health"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealth(t *testing.T) {
+	h := health.New()
+	h.Set("db", health.StatusNotServing)
+	srv := NewServer(Health(h))
+	if _, err := srv.Endpoint(); err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	conn, err := DialInsecure(context.Background(), WithEndpoint(srv.lis.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		reply, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.Status
+	}
+	if s := check(""); s != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("got %s want NOT_SERVING", s)
+	}
+	h.Set("db", health.StatusServing)
+	if s := check(""); s != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("got %s want SERVING", s)
+	}
+	if s := check("db"); s != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("db: got %s want SERVING", s)
+	}
+}
+
+func TestHealthNil(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic want the panic of the nil health registry")
+		}
+	}()
+	Health(nil)
+}
@@ -1,12 +1,18 @@
 package discovery
 
 import (
+	"context"
+
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
 	"google.golang.org/grpc/resolver"
 )
 
-const name = "discovery"
+const (
+	name = "discovery"
+	// serviceInstanceKey is the address attribute read by the selector balancer.
+	serviceInstanceKey = "rawServiceInstance"
+)
 
 // Option is builder option.
 type Option func(o *builder)
@@ -19,15 +25,15 @@ func WithLogger(logger log.Logger) Option {
 }
 
 type builder struct {
-	registry registry.Registry
-	logger   log.Logger
+	discoverer registry.Discovery
+	logger     log.Logger
 }
 
 // NewBuilder creates a builder which is used to factory registry resolvers.
-func NewBuilder(r registry.Registry, opts ...Option) resolver.Builder {
+func NewBuilder(d registry.Discovery, opts ...Option) resolver.Builder {
 	b := &builder{
-		registry: r,
-		logger:   log.DefaultLogger,
+		discoverer: d,
+		logger:     log.GetLogger(),
 	}
 	for _, o := range opts {
 		o(b)
@@ -36,14 +42,18 @@ func NewBuilder(r registry.Registry, opts ...Option) resolver.Builder {
 }
 
 func (d *builder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
-	w, err := d.registry.Watch(target.Endpoint)
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := d.discoverer.Watch(ctx, target.Endpoint)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 	r := &discoveryResolver{
-		w:   w,
-		cc:  cc,
-		log: log.NewHelper("grpc/resolver/discovery", d.logger),
+		w:      w,
+		cc:     cc,
+		ctx:    ctx,
+		cancel: cancel,
+		log:    log.NewHelper("grpc/resolver/discovery", d.logger),
 	}
 	go r.watch()
 	return r, nil
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -11,15 +12,25 @@ import (
 )
 
 type discoveryResolver struct {
-	w   registry.Watcher
-	cc  resolver.ClientConn
-	log *log.Helper
+	w      registry.Watcher
+	cc     resolver.ClientConn
+	ctx    context.Context
+	cancel context.CancelFunc
+	log    *log.Helper
 }
 
 func (r *discoveryResolver) watch() {
 	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		default:
+		}
 		ins, err := r.w.Next()
 		if err != nil {
+			if r.ctx.Err() != nil {
+				return
+			}
 			r.log.Errorf("Failed to watch discovery endpoint: %v", err)
 			time.Sleep(time.Second)
 			continue
@@ -36,18 +47,28 @@ func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
 			r.log.Errorf("Failed to parse discovery endpoint: %v", err)
 			continue
 		}
+		if endpoint == "" {
+			continue
+		}
 		addr := resolver.Address{
 			ServerName: in.Name,
-			Attributes: parseAttributes(in.Metadata),
+			Attributes: attributes.New(serviceInstanceKey, in),
 			Addr:       endpoint,
 		}
 		addrs = append(addrs, addr)
 	}
+	if len(addrs) == 0 {
+		r.log.Warnf("Zero endpoint found, refused to write, instances: %v", ins)
+		return
+	}
 	r.cc.UpdateState(resolver.State{Addresses: addrs})
 }
 
 func (r *discoveryResolver) Close() {
-	r.w.Close()
+	r.cancel()
+	if err := r.w.Close(); err != nil {
+		r.log.Errorf("Failed to watch close: %v", err)
+	}
 }
 
 func (r *discoveryResolver) ResolveNow(options resolver.ResolveNowOptions) {}
@@ -64,11 +85,3 @@ func parseEndpoint(endpoints []string) (string, error) {
 	}
 	return "", nil
 }
-
-func parseAttributes(md map[string]string) *attributes.Attributes {
-	var pairs []interface{}
-	for k, v := range md {
-		pairs = append(pairs, k, v)
-	}
-	return attributes.New(pairs)
-}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"google.golang.org/grpc/resolver"
+)
+
+type testDiscovery struct {
+	w *testWatcher
+}
+
+func (d *testDiscovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	return nil, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return d.w, nil
+}
+
+type testWatcher struct {
+	ch chan []*registry.ServiceInstance
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	ins, ok := <-w.ch
+	if !ok {
+		return nil, context.Canceled
+	}
+	return ins, nil
+}
+
+func (w *testWatcher) Close() error {
+	close(w.ch)
+	return nil
+}
+
+type testClientConn struct {
+	resolver.ClientConn
+	ch chan resolver.State
+}
+
+func (cc *testClientConn) UpdateState(s resolver.State) {
+	cc.ch <- s
+}
+
+func TestResolver(t *testing.T) {
+	w := &testWatcher{ch: make(chan []*registry.ServiceInstance)}
+	cc := &testClientConn{ch: make(chan resolver.State, 1)}
+	b := NewBuilder(&testDiscovery{w: w})
+	r, err := b.Build(resolver.Target{Endpoint: "helloworld"}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	w.ch <- []*registry.ServiceInstance{
+		{ID: "1", Name: "helloworld", Endpoints: []string{"http://127.0.0.1:8000", "grpc://127.0.0.1:9000"}},
+		{ID: "2", Name: "helloworld", Endpoints: []string{"grpc://127.0.0.2:9000"}},
+	}
+	select {
+	case s := <-cc.ch:
+		if len(s.Addresses) != 2 {
+			t.Fatalf("expected 2 addresses, got %d", len(s.Addresses))
+		}
+		if s.Addresses[0].Addr != "127.0.0.1:9000" {
+			t.Fatalf("unexpected address: %s", s.Addresses[0].Addr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for resolver state")
+	}
+}
@@ -2,23 +2,36 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/health"
 	"github.com/go-kratos/kratos/v2/internal/host"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
-	"github.com/go-kratos/kratos/v2/middleware/status"
 	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/timeout"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
 )
 
 const loggerName = "transport/grpc"
 
-var _ transport.Server = (*Server)(nil)
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
 
 // ServerOption is gRPC server option.
 type ServerOption func(o *Server)
@@ -30,13 +43,55 @@ func Network(network string) ServerOption {
 	}
 }
 
-// Address with server address.
+// Endpoint with the registered endpoint of the server, which overrides the extracted one
+// when the reachable address differs from the listened one, i.e. a LB VIP or a mapped port,
+// such as grpc://203.0.113.10:9000, or the host and port only.
+func Endpoint(endpoint string) ServerOption {
+	return func(s *Server) {
+		s.advertised = endpoint
+	}
+}
+
+// EndpointInterface with the preferred interfaces of the endpoint IP in order, such as
+// eth0, when the server listens on all the addresses.
+func EndpointInterface(names ...string) ServerOption {
+	return func(s *Server) {
+		s.hostOpts = append(s.hostOpts, host.WithInterface(names...))
+	}
+}
+
+// EndpointCIDR with the preferred ranges of the endpoint IP in order, such as 10.0.0.0/8,
+// when the server listens on all the addresses, the private ranges by default.
+func EndpointCIDR(cidrs ...string) ServerOption {
+	return func(s *Server) {
+		s.hostOpts = append(s.hostOpts, host.WithCIDR(cidrs...))
+	}
+}
+
+// EndpointIPv6 prefers the IPv6 endpoint IP to the IPv4 one.
+func EndpointIPv6(prefer bool) ServerOption {
+	return func(s *Server) {
+		s.hostOpts = append(s.hostOpts, host.WithIPv6(prefer))
+	}
+}
+
+// Address with server address, such as :8000, the unix socket of unix:///tmp/app.sock,
+// or the systemd socket of systemd://name, which is the FileDescriptorName of the socket.
 func Address(addr string) ServerOption {
 	return func(s *Server) {
 		s.address = addr
 	}
 }
 
+// Listener with a pre-created server listener, such as a socket activated
+// by systemd, the server serves on it instead of listening on the address.
+func Listener(lis net.Listener) ServerOption {
+	return func(s *Server) {
+		s.lis = lis
+		s.address = lis.Addr().String()
+	}
+}
+
 // Timeout with server timeout.
 func Timeout(timeout time.Duration) ServerOption {
 	return func(s *Server) {
@@ -44,6 +99,14 @@ func Timeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// Timeouts with the per operation timeouts, which override the server timeout,
+// the streaming operations only have the timeouts of the table.
+func Timeouts(t *timeout.Table) ServerOption {
+	return func(s *Server) {
+		s.timeouts = t
+	}
+}
+
 // Logger with server logger.
 func Logger(logger log.Logger) ServerOption {
 	return func(s *Server) {
@@ -58,6 +121,89 @@ func Middleware(m middleware.Middleware) ServerOption {
 	}
 }
 
+// TLSConfig with server tls config.
+func TLSConfig(c *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConf = c
+	}
+}
+
+// ClientCAs with the CAs verifying the client certificates, which enables the mutual TLS
+// of the TLSConfig, which is required, and the identities of the clients are the SPIFFE
+// IDs of their certificates, see the spiffe middleware.
+func ClientCAs(pool *x509.CertPool) ServerOption {
+	return func(s *Server) {
+		s.clientCAs = pool
+	}
+}
+
+// Health with the health registry reported by the grpc.health.v1 service.
+func Health(h *health.Health) ServerOption {
+	if h == nil {
+		panic("grpc: nil health registry")
+	}
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
+// DisableReflection disables the server reflection service, which is registered by
+// default so that tools such as grpcurl work out of the box, production servers may
+// disable it to hide the service definitions.
+func DisableReflection() ServerOption {
+	return func(s *Server) {
+		s.disableReflection = true
+	}
+}
+
+// KeepaliveParams with server keepalive parameters, it replaces the connection max age
+// set by MaxConnectionAge.
+func KeepaliveParams(p keepalive.ServerParameters) ServerOption {
+	return func(s *Server) {
+		s.keepalive = &p
+	}
+}
+
+// KeepaliveEnforcementPolicy with server keepalive enforcement policy.
+func KeepaliveEnforcementPolicy(p keepalive.EnforcementPolicy) ServerOption {
+	return func(s *Server) {
+		s.enforcement = &p
+	}
+}
+
+// MaxConnectionAge with the maximum age of a connection, the connection is closed
+// gracefully after age and forcibly after the additional grace period.
+func MaxConnectionAge(age, grace time.Duration) ServerOption {
+	return func(s *Server) {
+		if s.keepalive == nil {
+			s.keepalive = &keepalive.ServerParameters{}
+		}
+		s.keepalive.MaxConnectionAge = age
+		s.keepalive.MaxConnectionAgeGrace = grace
+	}
+}
+
+// MaxConcurrentStreams with the maximum number of concurrent streams of each connection.
+func MaxConcurrentStreams(n uint32) ServerOption {
+	return func(s *Server) {
+		s.maxConcurrentStreams = n
+	}
+}
+
+// MaxRecvMsgSize with the maximum message size in bytes the server can receive.
+func MaxRecvMsgSize(n int) ServerOption {
+	return func(s *Server) {
+		s.maxRecvMsgSize = n
+	}
+}
+
+// MaxSendMsgSize with the maximum message size in bytes the server can send.
+func MaxSendMsgSize(n int) ServerOption {
+	return func(s *Server) {
+		s.maxSendMsgSize = n
+	}
+}
+
 // Options with grpc options.
 func Options(opts ...grpc.ServerOption) ServerOption {
 	return func(s *Server) {
@@ -69,71 +215,196 @@ func Options(opts ...grpc.ServerOption) ServerOption {
 type Server struct {
 	*grpc.Server
 	lis        net.Listener
+	endpoint   string
 	network    string
 	address    string
+	hostOpts   []host.Option
+	advertised string
 	timeout    time.Duration
+	timeouts   *timeout.Table
+	tlsConf    *tls.Config
+	clientCAs  *x509.CertPool
+	err        error
 	log        *log.Helper
 	middleware middleware.Middleware
 	grpcOpts   []grpc.ServerOption
+
+	health       *health.Health
+	healthServer *grpchealth.Server
+
+	disableReflection bool
+
+	keepalive            *keepalive.ServerParameters
+	enforcement          *keepalive.EnforcementPolicy
+	maxConcurrentStreams uint32
+	maxRecvMsgSize       int
+	maxSendMsgSize       int
 }
 
 // NewServer creates a gRPC server by options.
 func NewServer(opts ...ServerOption) *Server {
 	srv := &Server{
-		network: "tcp",
-		address: ":0",
-		timeout: time.Second,
-		log:     log.NewHelper(loggerName, log.DefaultLogger),
-		middleware: middleware.Chain(
-			recovery.Recovery(),
-			status.Server(),
-		),
+		network:    "tcp",
+		address:    ":0",
+		timeout:    time.Second,
+		log:        log.NewHelper(loggerName, log.GetLogger()),
+		middleware: middleware.Named("recovery", recovery.Recovery()),
+		health:     health.New(),
 	}
 	for _, o := range opts {
 		o(srv)
 	}
 	var grpcOpts = []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(
-			UnaryServerInterceptor(srv.middleware),
-			UnaryTimeoutInterceptor(srv.timeout),
+			srv.unaryServerInterceptor(),
+			srv.unaryTimeoutInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			srv.streamServerInterceptor(),
+			srv.streamTimeoutInterceptor(),
 		),
 	}
+	if srv.clientCAs != nil {
+		srv.tlsConf, srv.err = mutualTLS(srv.tlsConf, srv.clientCAs)
+	}
+	if srv.tlsConf != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(srv.tlsConf)))
+	}
+	grpcOpts = append(grpcOpts, srv.connOptions()...)
 	if len(srv.grpcOpts) > 0 {
 		grpcOpts = append(grpcOpts, srv.grpcOpts...)
 	}
 	srv.Server = grpc.NewServer(grpcOpts...)
+	srv.registerHealth()
+	if !srv.disableReflection {
+		reflection.Register(srv.Server)
+	}
 	return srv
 }
 
+// connOptions returns the grpc options of keepalive and connection limits.
+func (s *Server) connOptions() []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if s.keepalive != nil {
+		opts = append(opts, grpc.KeepaliveParams(*s.keepalive))
+	}
+	if s.enforcement != nil {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(*s.enforcement))
+	}
+	if s.maxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(s.maxConcurrentStreams))
+	}
+	if s.maxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(s.maxRecvMsgSize))
+	}
+	if s.maxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(s.maxSendMsgSize))
+	}
+	return opts
+}
+
 // Endpoint return a real address to registry endpoint.
 // examples:
 //   grpc://127.0.0.1:9000?isSecure=false
+//   grpc+unix:///tmp/app.sock?isSecure=false
 func (s *Server) Endpoint() (string, error) {
-	addr, err := host.Extract(s.address, s.lis)
+	if err := s.listen(); err != nil {
+		return "", err
+	}
+	endpoint, err := s.buildEndpoint()
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("grpc://%s", addr), nil
+	s.endpoint = endpoint
+	return s.endpoint, nil
+}
+
+func (s *Server) buildEndpoint() (string, error) {
+	if s.advertised != "" {
+		return host.Endpoint("grpc", s.advertised, fmt.Sprintf("isSecure=%t", s.tlsConf != nil))
+	}
+	if endpoint, ok := host.UnixEndpoint("grpc", s.lis); ok {
+		return fmt.Sprintf("%s?isSecure=%t", endpoint, s.tlsConf != nil), nil
+	}
+	addr, err := host.Extract(s.address, s.lis, s.hostOpts...)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("grpc://%s?isSecure=%t", addr, s.tlsConf != nil), nil
 }
 
 // Start start the gRPC server.
 func (s *Server) Start() error {
-	lis, err := net.Listen(s.network, s.address)
+	if _, err := s.Endpoint(); err != nil {
+		return err
+	}
+	s.log.Infof("[gRPC] server listening on: %s", s.lis.Addr().String())
+	return s.Serve(s.lis)
+}
+
+// Listener returns the listener of the server, which is created once, so that another
+// gRPC server serves the registered services on it, i.e. the xDS server.
+func (s *Server) Listener() (net.Listener, error) {
+	if err := s.listen(); err != nil {
+		return nil, err
+	}
+	return s.lis, nil
+}
+
+// listen creates the listener once, so that Endpoint reports the real port before Start.
+func (s *Server) listen() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.lis != nil {
+		return nil
+	}
+	lis, err := host.Listen(s.network, s.address)
 	if err != nil {
 		return err
 	}
 	s.lis = lis
-	s.log.Infof("[gRPC] server listening on: %s", lis.Addr().String())
-	return s.Serve(lis)
+	return nil
 }
 
 // Stop stop the gRPC server.
 func (s *Server) Stop() error {
+	s.healthServer.Shutdown()
 	s.GracefulStop()
+	// the listener is not closed by the graceful stop if the server is not started.
+	if s.lis != nil {
+		_ = s.lis.Close()
+	}
 	s.log.Info("[gRPC] server stopping")
 	return nil
 }
 
+// Routes returns the methods of the registered services and their middleware.
+func (s *Server) Routes() []transport.Route {
+	var routes []transport.Route
+	for name, info := range s.GetServiceInfo() {
+		for _, m := range info.Methods {
+			operation := fmt.Sprintf("/%s/%s", name, m.Name)
+			ctx := transport.NewServerContext(context.Background(), &Transport{
+				endpoint:    s.endpoint,
+				operation:   operation,
+				reqHeader:   headerCarrier{},
+				replyHeader: headerCarrier{},
+			})
+			ms := middleware.Inspect(ctx, s.middleware)
+			routes = append(routes, transport.Route{
+				Kind:       transport.KindGRPC,
+				Operation:  operation,
+				Middleware: ms,
+			})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Operation < routes[j].Operation
+	})
+	return routes
+}
+
 // UnaryTimeoutInterceptor returns a unary timeout interceptor.
 func UnaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
@@ -143,17 +414,142 @@ func UnaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor
 	}
 }
 
-// UnaryServerInterceptor returns a unary server interceptor.
+// UnaryInterceptor returns the unary interceptor of the middleware and the timeouts of
+// the server, for another gRPC server serving the registered services.
+func (s *Server) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	unary, timeout := s.unaryServerInterceptor(), s.unaryTimeoutInterceptor()
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return unary(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return timeout(ctx, req, info, handler)
+		})
+	}
+}
+
+// StreamInterceptor returns the stream interceptor of the middleware and the timeouts of
+// the server, for another gRPC server serving the registered services.
+func (s *Server) StreamInterceptor() grpc.StreamServerInterceptor {
+	stream, timeout := s.streamServerInterceptor(), s.streamTimeoutInterceptor()
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return stream(srv, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+			return timeout(srv, ss, info, handler)
+		})
+	}
+}
+
+// UnaryServerInterceptor returns a unary server interceptor of the middleware.
+//
+// Deprecated: use the UnaryInterceptor of the Server, which sets the endpoint of the
+// transport and the timeouts of the server as well.
 func UnaryServerInterceptor(m middleware.Middleware) grpc.UnaryServerInterceptor {
+	return (&Server{middleware: m}).unaryServerInterceptor()
+}
+
+// StreamServerInterceptor returns a stream server interceptor of the middleware.
+//
+// Deprecated: use the StreamInterceptor of the Server, which sets the endpoint of the
+// transport and the timeouts of the server as well.
+func StreamServerInterceptor(m middleware.Middleware) grpc.StreamServerInterceptor {
+	return (&Server{middleware: m}).streamServerInterceptor()
+}
+
+// operationTimeout returns the timeout of the operation, zero means no timeout.
+func (s *Server) operationTimeout(operation string) time.Duration {
+	if s.timeouts != nil {
+		if d, ok := s.timeouts.Lookup(operation); ok {
+			return d
+		}
+	}
+	return s.timeout
+}
+
+// unaryTimeoutInterceptor returns a unary interceptor of the operation timeouts.
+func (s *Server) unaryTimeoutInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if d := s.operationTimeout(info.FullMethod); d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamTimeoutInterceptor returns a stream interceptor of the operation timeouts.
+func (s *Server) streamTimeoutInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if s.timeouts == nil {
+			return handler(srv, ss)
+		}
+		d, ok := s.timeouts.Lookup(info.FullMethod)
+		if !ok || d <= 0 {
+			return handler(srv, ss)
+		}
+		ctx, cancel := context.WithTimeout(ss.Context(), d)
+		defer cancel()
+		return handler(srv, NewWrappedStream(ctx, ss))
+	}
+}
+
+// unaryServerInterceptor returns a unary server interceptor.
+func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		ctx = transport.NewContext(ctx, transport.Transport{Kind: "GRPC"})
+		tr := s.transport(ctx, info.FullMethod)
+		ctx = transport.NewServerContext(ctx, tr)
 		ctx = NewContext(ctx, ServerInfo{Server: info.Server, FullMethod: info.FullMethod})
 		h := func(ctx context.Context, req interface{}) (interface{}, error) {
 			return handler(ctx, req)
 		}
-		if m != nil {
-			h = m(h)
+		if s.middleware != nil {
+			h = s.middleware(h)
+		}
+		reply, err := h(ctx, req)
+		if len(tr.replyHeader) > 0 {
+			_ = grpc.SetHeader(ctx, metadata.MD(tr.replyHeader))
+		}
+		return reply, err
+	}
+}
+
+// streamServerInterceptor returns a stream server interceptor.
+func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		tr := s.transport(ss.Context(), info.FullMethod)
+		ctx := transport.NewServerContext(ss.Context(), tr)
+		ctx = NewContext(ctx, ServerInfo{Server: srv, FullMethod: info.FullMethod})
+		h := func(ctx context.Context, req interface{}) (interface{}, error) {
+			if len(tr.replyHeader) > 0 {
+				if err := ss.SetHeader(metadata.MD(tr.replyHeader)); err != nil {
+					return nil, err
+				}
+			}
+			return nil, handler(srv, NewWrappedStream(ctx, ss))
+		}
+		if s.middleware != nil {
+			h = s.middleware(h)
 		}
-		return h(ctx, req)
+		_, err := h(ctx, nil)
+		return err
+	}
+}
+
+func (s *Server) transport(ctx context.Context, operation string) *Transport {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &Transport{
+		endpoint:    s.endpoint,
+		operation:   operation,
+		reqHeader:   headerCarrier(md.Copy()),
+		replyHeader: headerCarrier{},
+	}
+}
+
+// mutualTLS returns a copy of the TLS config requiring the client certificates verified
+// by the CAs, the config of the server certificate is required.
+func mutualTLS(c *tls.Config, pool *x509.CertPool) (*tls.Config, error) {
+	if c == nil {
+		return nil, errors.New("grpc: ClientCAs requires the TLSConfig of the server certificate")
 	}
+	c = c.Clone()
+	c.ClientCAs = pool
+	c.ClientAuth = tls.RequireAndVerifyClientCert
+	return c, nil
 }
@@ -1,8 +1,22 @@
 package grpc
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/timeout"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 )
 
 func TestServer(t *testing.T) {
@@ -19,3 +33,208 @@ func TestServer(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestServerTLS(t *testing.T) {
+	srv := NewServer(TLSConfig(&tls.Config{}))
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("isSecure") != "true" {
+		t.Fatalf("expected secure endpoint, got %s", endpoint)
+	}
+}
+
+func TestServerClientCAs(t *testing.T) {
+	conf := &tls.Config{}
+	pool := x509.NewCertPool()
+	srv := NewServer(ClientCAs(pool), TLSConfig(conf))
+	if srv.tlsConf.ClientCAs != pool || srv.tlsConf.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("got %+v want the mutual TLS", srv.tlsConf)
+	}
+	if conf.ClientCAs != nil {
+		t.Error("got the TLS config modified want it copied")
+	}
+	if _, err := NewServer(ClientCAs(pool)).Endpoint(); err == nil {
+		t.Error("got nil want the error of the missing TLS config")
+	}
+}
+
+func TestServerReflection(t *testing.T) {
+	if _, ok := NewServer().GetServiceInfo()["grpc.reflection.v1alpha.ServerReflection"]; !ok {
+		t.Error("reflection service is not registered by default")
+	}
+	if _, ok := NewServer(DisableReflection()).GetServiceInfo()["grpc.reflection.v1alpha.ServerReflection"]; ok {
+		t.Error("reflection service is registered after disabled")
+	}
+}
+
+func TestServerConnOptions(t *testing.T) {
+	srv := NewServer(
+		KeepaliveParams(keepalive.ServerParameters{Time: time.Minute}),
+		MaxConnectionAge(time.Hour, time.Minute),
+		KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: time.Second}),
+		MaxConcurrentStreams(100),
+		MaxRecvMsgSize(1<<20),
+		MaxSendMsgSize(1<<20),
+	)
+	if srv.keepalive.Time != time.Minute || srv.keepalive.MaxConnectionAge != time.Hour || srv.keepalive.MaxConnectionAgeGrace != time.Minute {
+		t.Errorf("unexpected keepalive params: %+v", srv.keepalive)
+	}
+	if n := len(srv.connOptions()); n != 5 {
+		t.Errorf("expected 5 options, got %d", n)
+	}
+	if n := len(NewServer().connOptions()); n != 0 {
+		t.Errorf("expected no options by default, got %d", n)
+	}
+}
+
+func TestServerListener(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(Listener(lis))
+	if l, err := srv.Listener(); err != nil || l != lis {
+		t.Fatalf("got %v %v want the listener", l, err)
+	}
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "grpc://" + lis.Addr().String() + "?isSecure=false"; endpoint != expected {
+		t.Fatalf("expected %s got %s", expected, endpoint)
+	}
+	time.AfterFunc(100*time.Millisecond, func() {
+		srv.Stop()
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerTimeouts(t *testing.T) {
+	srv := NewServer(Timeout(time.Second), Timeouts(timeout.New(map[string]time.Duration{
+		"/helloworld.Greeter/*":     2 * time.Second,
+		"/helloworld.Greeter/Watch": 0,
+	})))
+	tests := []struct {
+		method   string
+		deadline bool
+		timeout  time.Duration
+	}{
+		{"/helloworld.Greeter/SayHello", true, 2 * time.Second},
+		{"/helloworld.Greeter/Watch", false, 0},
+		{"/other.Greeter/SayHello", true, time.Second},
+	}
+	for _, test := range tests {
+		_, err := srv.unaryTimeoutInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: test.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			deadline, ok := ctx.Deadline()
+			if ok != test.deadline {
+				t.Errorf("%s: got deadline %t want %t", test.method, ok, test.deadline)
+			}
+			if d := time.Until(deadline); ok && (d > test.timeout || d < test.timeout-100*time.Millisecond) {
+				t.Errorf("%s: got timeout %v want %v", test.method, d, test.timeout)
+			}
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestServerInterceptor(t *testing.T) {
+	var kind transport.Kind
+	m := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, _ := transport.FromServerContext(ctx)
+			kind = tr.Kind()
+			return next(ctx, req)
+		}
+	}
+	srv := NewServer(Middleware(m), Timeout(time.Second))
+	_, err := srv.UnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("got no deadline want the timeout")
+		}
+		return srv.HealthServer().Check(ctx, &healthpb.HealthCheckRequest{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind != transport.KindGRPC {
+		t.Errorf("got %q want the middleware of grpc", kind)
+	}
+
+	// the deprecated interceptors run the middleware as well.
+	kind = ""
+	_, err = UnaryServerInterceptor(m)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil || kind != transport.KindGRPC {
+		t.Errorf("got %v %q want the middleware of the unary interceptor", err, kind)
+	}
+	if StreamServerInterceptor(m) == nil {
+		t.Error("got no stream interceptor")
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	srv := NewServer()
+	var found bool
+	for _, r := range srv.Routes() {
+		if r.Operation == "/grpc.health.v1.Health/Check" {
+			found = true
+			if r.Kind != transport.KindGRPC || len(r.Middleware) != 1 || r.Middleware[0] != "recovery" {
+				t.Errorf("unexpected route: %+v", r)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("missing the health route: %+v", srv.Routes())
+	}
+}
+
+func TestServerUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grpc.sock")
+	srv := NewServer(Address("unix://" + path))
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint != "grpc+unix://"+path+"?isSecure=false" {
+		t.Fatalf("got endpoint %s", endpoint)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	conn, err := DialInsecure(context.Background(), WithEndpoint(endpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reply, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("got %s want SERVING", reply.Status)
+	}
+}
+
+func TestServerEndpoint(t *testing.T) {
+	srv := NewServer(Address("127.0.0.1:0"), Endpoint("203.0.113.10:9000"))
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.lis.Close()
+	if endpoint != "grpc://203.0.113.10:9000?isSecure=false" {
+		t.Fatalf("got endpoint %s", endpoint)
+	}
+}
@@ -0,0 +1,23 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// wrappedStream is a grpc.ServerStream carrying the middleware context.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// NewWrappedStream returns a grpc.ServerStream whose Context returns ctx.
+func NewWrappedStream(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
+	return &wrappedStream{ServerStream: stream, ctx: ctx}
+}
+
+// Context returns the context for this stream.
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
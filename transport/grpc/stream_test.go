@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testKey struct{}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			return handler(context.WithValue(ctx, testKey{}, "kratos"), req)
+		}
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		ctx := ss.Context()
+		if v := ctx.Value(testKey{}); v != "kratos" {
+			t.Errorf("expected middleware value, got %v", v)
+		}
+		tr, ok := transport.FromServerContext(ctx)
+		if !ok || tr.Kind() != transport.KindGRPC || tr.Operation() != info.FullMethod {
+			t.Fatalf("expected grpc transport, got %v", tr)
+		}
+		if v := tr.RequestHeader().Get("x-md-uid"); v != "1" {
+			t.Errorf("expected request header, got %q", v)
+		}
+		if s, ok := FromContext(ctx); !ok || s.FullMethod != info.FullMethod {
+			t.Errorf("expected full method %s, got %s", info.FullMethod, s.FullMethod)
+		}
+		return nil
+	}
+	ss := &testStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-md-uid", "1"))}
+	srv := NewServer(Middleware(m))
+	if err := srv.streamServerInterceptor()(nil, ss, info, handler); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/go-kratos/kratos/v2/internal/host"
+
+	"google.golang.org/grpc"
+)
+
+// Warmup returns a warmup func of the app which dials the server itself and calls fn with
+// the connection, i.e. the requests of the hot methods, before the instance is registered.
+// The listener is dialed rather than the advertised endpoint, which may be a load balancer.
+func Warmup(srv *Server, fn func(ctx context.Context, conn *grpc.ClientConn) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := srv.listen(); err != nil {
+			return err
+		}
+		network, endpoint := host.Dialable(srv.lis)
+		if network == "unix" {
+			endpoint = "unix://" + endpoint
+		}
+		var (
+			conn *grpc.ClientConn
+			err  error
+		)
+		if srv.tlsConf != nil {
+			// the certificate of the server may not be issued for its address.
+			conn, err = Dial(ctx, WithEndpoint(endpoint), WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+		} else {
+			conn, err = DialInsecure(ctx, WithEndpoint(endpoint))
+		}
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		return fn(ctx, conn)
+	}
+}
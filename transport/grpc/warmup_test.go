@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWarmup(t *testing.T) {
+	// the advertised endpoint of a load balancer is not dialed by the warmup.
+	srv := NewServer(Endpoint("203.0.113.10:443"))
+	if _, err := srv.Endpoint(); err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	var status healthpb.HealthCheckResponse_ServingStatus
+	err := Warmup(srv, func(ctx context.Context, conn *grpc.ClientConn) error {
+		reply, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		status = reply.Status
+		return nil
+	})(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("got %s want SERVING", status)
+	}
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestBindQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?seconds=10&nanos=5", nil)
+	var d durationpb.Duration
+	if err := BindQuery(req, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Seconds != 10 || d.Nanos != 5 {
+		t.Errorf("unexpected duration: %v", &d)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?paths=name&paths=age", nil)
+	var fm fieldmaskpb.FieldMask
+	if err := BindQuery(req, &fm); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(fm.Paths, ",") != "name,age" {
+		t.Errorf("unexpected field mask: %v", &fm)
+	}
+}
+
+func TestBindVars(t *testing.T) {
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/durations/10", nil), map[string]string{"seconds": "10"})
+	var d durationpb.Duration
+	if err := BindVars(req, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Seconds != 10 {
+		t.Errorf("unexpected duration: %v", &d)
+	}
+}
+
+func TestBindBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		seconds     int64
+	}{
+		{"application/json", `"10s"`, 10},
+		{"application/x-www-form-urlencoded", "seconds=20", 20},
+		{"application/json", "", 0},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		var d durationpb.Duration
+		if err := BindBody(req, &d); err != nil {
+			t.Fatalf("%s: %v", test.contentType, err)
+		}
+		if d.Seconds != test.seconds {
+			t.Errorf("%s: got %d want %d", test.contentType, d.Seconds, test.seconds)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("x"))
+	req.Header.Set("Content-Type", "application/unknown")
+	if err := BindBody(req, &durationpb.Duration{}); err == nil {
+		t.Error("expected an error of the unknown content type")
+	}
+}
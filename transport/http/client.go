@@ -1,18 +1,42 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/host"
+	"github.com/go-kratos/kratos/v2/internal/httputil"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"google.golang.org/protobuf/proto"
 )
 
 // DecodeResponseFunc decode response func.
 type DecodeResponseFunc func(res *http.Response, v interface{}) error
 
+// EncodeRequestFunc encodes the request body of the content type.
+type EncodeRequestFunc func(ctx context.Context, contentType string, in interface{}) (body []byte, err error)
+
+// DecodeErrorFunc decodes the error of the response, nil is returned for the success response.
+type DecodeErrorFunc func(ctx context.Context, res *http.Response) error
+
 // ClientOption is HTTP client option.
 type ClientOption func(*clientOptions)
 
@@ -37,20 +61,430 @@ func WithTransport(trans http.RoundTripper) ClientOption {
 	}
 }
 
+// WithEndpoint with client endpoint, which is a direct address such as 127.0.0.1:8000,
+// or a discovery target such as discovery:///helloworld when a discovery is provided.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(o *clientOptions) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithMiddleware with client middleware.
+func WithMiddleware(m middleware.Middleware) ClientOption {
+	return func(o *clientOptions) {
+		o.middleware = m
+	}
+}
+
+// WithDiscovery with client discovery.
+func WithDiscovery(d registry.Discovery) ClientOption {
+	return func(o *clientOptions) {
+		o.discovery = d
+	}
+}
+
+// WithTLSConfig with client tls config, the requests are sent by https.
+func WithTLSConfig(c *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConf = c
+	}
+}
+
+// WithNodeFilter with the node filters of the selector, i.e. the canary routing.
+func WithNodeFilter(filters ...selector.Filter) ClientOption {
+	return func(o *clientOptions) {
+		o.filters = filters
+	}
+}
+
+// WithRequestEncoder with client request encoder.
+func WithRequestEncoder(encoder EncodeRequestFunc) ClientOption {
+	return func(o *clientOptions) {
+		o.encoder = encoder
+	}
+}
+
+// WithResponseDecoder with client response decoder.
+func WithResponseDecoder(decoder DecodeResponseFunc) ClientOption {
+	return func(o *clientOptions) {
+		o.decoder = decoder
+	}
+}
+
+// WithErrorDecoder with client error decoder.
+func WithErrorDecoder(decoder DecodeErrorFunc) ClientOption {
+	return func(o *clientOptions) {
+		o.errorDecoder = decoder
+	}
+}
+
+// WithLogger with client logger.
+func WithLogger(logger log.Logger) ClientOption {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
 // Client is a HTTP transport client.
 type clientOptions struct {
-	timeout   time.Duration
-	userAgent string
-	transport http.RoundTripper
+	endpoint     string
+	timeout      time.Duration
+	userAgent    string
+	transport    http.RoundTripper
+	middleware   middleware.Middleware
+	discovery    registry.Discovery
+	tlsConf      *tls.Config
+	filters      []selector.Filter
+	encoder      EncodeRequestFunc
+	decoder      DecodeResponseFunc
+	errorDecoder DecodeErrorFunc
+	logger       log.Logger
+}
+
+// Client is a HTTP client which resolves the endpoint by the discovery, selects
+// the nodes by the selector, and runs the client middleware for every call.
+type Client struct {
+	opts     clientOptions
+	resolver *resolver
+	selector selector.Selector
+	cc       *http.Client
+	scheme   string
 }
 
 // NewClient returns an HTTP client.
-func NewClient(ctx context.Context, opts ...ClientOption) (*http.Client, error) {
-	trans, err := NewTransport(ctx, opts...)
+func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
+	options := clientOptions{
+		timeout:      500 * time.Millisecond,
+		transport:    http.DefaultTransport,
+		encoder:      defaultRequestEncoder,
+		decoder:      DecodeResponse,
+		errorDecoder: defaultErrorDecoder,
+		logger:       log.GetLogger(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	target, err := parseTarget(options.endpoint)
 	if err != nil {
 		return nil, err
 	}
-	return &http.Client{Transport: trans}, nil
+	scheme := "http"
+	if target.scheme == "https" || target.scheme == "https+unix" {
+		scheme = "https"
+	}
+	if path, ok := host.UnixPath(options.endpoint); ok {
+		tr, ok := options.transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("http: the unix endpoint %s requires an *http.Transport", options.endpoint)
+		}
+		tr = tr.Clone()
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+		options.transport = tr
+		// the requests are sent to the socket whatever the host is.
+		target.authority = "localhost"
+	}
+	if options.tlsConf != nil {
+		scheme = "https"
+		if tr, ok := options.transport.(*http.Transport); ok {
+			tr = tr.Clone()
+			tr.TLSClientConfig = options.tlsConf
+			options.transport = tr
+		}
+	}
+	c := &Client{
+		opts:     options,
+		selector: selector.GlobalSelector().Build(),
+		cc:       &http.Client{Transport: options.transport},
+		scheme:   scheme,
+	}
+	if target.scheme == "discovery" {
+		if options.discovery == nil {
+			return nil, fmt.Errorf("http: discovery is required for the endpoint %s", options.endpoint)
+		}
+		r, err := newResolver(ctx, options.discovery, target.authority, scheme, c.selector, options.logger)
+		if err != nil {
+			return nil, err
+		}
+		c.resolver = r
+	} else {
+		c.selector.Apply([]selector.Node{selector.NewNode(target.authority, nil)})
+	}
+	return c, nil
+}
+
+// Invoke makes a call of the method and path, the args is encoded by the codec of
+// the content type, json by default, and the reply is decoded by the codec of the
+// response Content-Type.
+func (c *Client) Invoke(ctx context.Context, method, path string, args interface{}, reply interface{}, opts ...CallOption) error {
+	info := callInfo{
+		contentType: "application/json",
+		operation:   path,
+	}
+	for _, o := range opts {
+		o(&info)
+	}
+	var body io.Reader
+	if args != nil {
+		data, err := c.opts.encoder(ctx, info.contentType, args)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	if args != nil {
+		req.Header.Set("Content-Type", info.contentType)
+	}
+	var calls int32
+	h := func(ctx context.Context, in interface{}) (interface{}, error) {
+		// the handler can be called again by the middleware, i.e. the retries and
+		// the hedged calls, the later calls are decoded into their own replies.
+		out := reply
+		if atomic.AddInt32(&calls, 1) > 1 {
+			out = newReply(reply)
+		}
+		res, err := c.do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if err := c.opts.decoder(res, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+	out, err := c.invoke(ctx, req, args, info, h)
+	if err == nil {
+		setReply(reply, out)
+	}
+	return err
+}
+
+func setReplyHeader(tr transport.Transporter, h http.Header) {
+	if ht, ok := tr.(*Transport); ok {
+		ht.mu.Lock()
+		defer ht.mu.Unlock()
+	}
+	header := tr.ReplyHeader()
+	for k, v := range h {
+		header.Set(k, strings.Join(v, ","))
+	}
+}
+
+// newReply returns an empty reply of the type of the pointer reply, or the reply itself.
+func newReply(reply interface{}) interface{} {
+	if m, ok := reply.(proto.Message); ok {
+		return m.ProtoReflect().New().Interface()
+	}
+	if v := reflect.ValueOf(reply); v.Kind() == reflect.Ptr && !v.IsNil() {
+		return reflect.New(v.Elem().Type()).Interface()
+	}
+	return reply
+}
+
+// setReply sets the reply of the call to the reply of the handler, if they differ.
+func setReply(reply, out interface{}) {
+	if dst, ok := reply.(proto.Message); ok {
+		if src, ok := out.(proto.Message); ok && src != dst {
+			proto.Reset(dst)
+			proto.Merge(dst, src)
+		}
+		return
+	}
+	dst, src := reflect.ValueOf(reply), reflect.ValueOf(out)
+	if dst.Kind() == reflect.Ptr && src.Kind() == reflect.Ptr && !dst.IsNil() && !src.IsNil() &&
+		src.Type() == dst.Type() && src.Pointer() != dst.Pointer() {
+		dst.Elem().Set(src.Elem())
+	}
+}
+
+// Do sends the HTTP request to the selected node, the client middleware runs with the
+// request, and the response body must be closed by the caller.
+func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, error) {
+	info := callInfo{operation: req.URL.Path}
+	for _, o := range opts {
+		o(&info)
+	}
+	h := func(ctx context.Context, in interface{}) (interface{}, error) {
+		return c.do(ctx, req)
+	}
+	res, err := c.invoke(req.Context(), req, req, info, h)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*http.Response), nil
+}
+
+// Close closes the watcher of the discovery.
+func (c *Client) Close() error {
+	if c.resolver != nil {
+		return c.resolver.Close()
+	}
+	return nil
+}
+
+func (c *Client) invoke(ctx context.Context, req *http.Request, args interface{}, info callInfo, h middleware.Handler) (interface{}, error) {
+	if c.opts.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.opts.userAgent)
+	}
+	tr := &Transport{
+		endpoint:     c.opts.endpoint,
+		operation:    info.operation,
+		pathTemplate: info.pathTemplate,
+		request:      req,
+		reqHeader:    headerCarrier(req.Header),
+		replyHeader:  headerCarrier{},
+	}
+	ctx = transport.NewClientContext(ctx, tr)
+	next := func(ctx context.Context, in interface{}) (interface{}, error) {
+		reply, err := h(ctx, in)
+		if info.header != nil {
+			*info.header = http.Header(tr.replyHeader)
+		}
+		return reply, err
+	}
+	if c.opts.middleware != nil {
+		next = c.opts.middleware(next)
+	}
+	return next(ctx, args)
+}
+
+// do sends the request to a node of the selector.
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if c.opts.timeout <= 0 {
+		return c.send(ctx, req)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.opts.timeout)
+	res, err := c.send(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the body of the response is read after do returns, so cancel on close.
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	filters := append(c.opts.filters[:len(c.opts.filters):len(c.opts.filters)], selector.FromFilterContext(ctx)...)
+	node, done, err := c.selector.Select(ctx, selector.WithFilter(filters...))
+	if err != nil {
+		return nil, errors.Unavailable("NODE_NOT_FOUND", err.Error())
+	}
+	selector.Picked(ctx, node)
+	req = req.WithContext(ctx)
+	u := *req.URL
+	req.URL = &u
+	req.URL.Scheme = c.scheme
+	req.URL.Host = node.Address()
+	req.Host = node.Address()
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			req.Body = body
+		}
+	}
+	res, err := c.cc.Do(req)
+	if err != nil {
+		err = contextError(ctx, err)
+	}
+	// the errors of the transport and the 5xx replies are the failures of the node,
+	// the 4xx replies are the errors of the request.
+	nodeErr := err
+	if err == nil {
+		if tr, ok := transport.FromClientContext(ctx); ok {
+			setReplyHeader(tr, res.Header)
+		}
+		if err = c.opts.errorDecoder(ctx, res); err != nil {
+			res.Body.Close()
+			if res.StatusCode >= http.StatusInternalServerError {
+				nodeErr = err
+			}
+		}
+	}
+	done(ctx, selector.DoneInfo{Err: nodeErr})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// contextError converts the error of the done context into the kratos error of the
+// matching code, as the gRPC client does, or returns the error itself.
+func contextError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return errors.Cancelled("CANCELLED", err.Error())
+	case context.DeadlineExceeded:
+		return errors.DeadlineExceeded("DEADLINE_EXCEEDED", err.Error())
+	}
+	return err
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// CallOption configures a call of the client.
+type CallOption func(*callInfo)
+
+type callInfo struct {
+	contentType  string
+	operation    string
+	pathTemplate string
+	header       *http.Header
+}
+
+// ContentType with the content type of the request body, application/json by default.
+func ContentType(contentType string) CallOption {
+	return func(c *callInfo) {
+		c.contentType = contentType
+	}
+}
+
+// Operation with the operation of the call, the request path by default.
+func Operation(operation string) CallOption {
+	return func(c *callInfo) {
+		c.operation = operation
+	}
+}
+
+// PathTemplate with the path template of the call, i.e. /v1/users/{id}.
+func PathTemplate(pattern string) CallOption {
+	return func(c *callInfo) {
+		c.pathTemplate = pattern
+	}
+}
+
+// Header with the reply header of the call.
+func Header(header *http.Header) CallOption {
+	return func(c *callInfo) {
+		c.header = header
+	}
+}
+
+func defaultRequestEncoder(ctx context.Context, contentType string, in interface{}) ([]byte, error) {
+	codec := encoding.GetCodec(httputil.ContentSubtype(contentType))
+	if codec == nil {
+		return nil, fmt.Errorf("unknown content-type error: %s", contentType)
+	}
+	return codec.Marshal(in)
+}
+
+func defaultErrorDecoder(ctx context.Context, res *http.Response) error {
+	return CheckResponse(res)
 }
 
 // NewTransport creates an http.RoundTripper.
@@ -95,8 +529,12 @@ func CheckResponse(res *http.Response) error {
 		return nil
 	}
 	se := &errors.StatusError{}
-	if err := DecodeResponse(res, se); err != nil {
-		return err
+	if err := DecodeResponse(res, se); err != nil || se.Code == 0 {
+		// not a status error, such as the errors of the proxies.
+		se = &errors.StatusError{Code: 2, Message: http.StatusText(res.StatusCode)}
+		if code, ok := statusMapping[res.StatusCode]; ok {
+			se.Code = code
+		}
 	}
 	return se
 }
@@ -108,10 +546,36 @@ func DecodeResponse(res *http.Response, v interface{}) error {
 		return err
 	}
 	defer res.Body.Close()
-	subtype := contentSubtype(res.Header.Get("content-type"))
+	if len(data) == 0 || v == nil {
+		return nil
+	}
+	subtype := httputil.ContentSubtype(res.Header.Get("content-type"))
 	codec := encoding.GetCodec(subtype)
 	if codec == nil {
 		codec = encoding.GetCodec("json")
 	}
 	return codec.Unmarshal(data, v)
 }
+
+// target is the parsed endpoint of the client.
+type target struct {
+	scheme    string
+	authority string
+}
+
+// parseTarget parses the endpoint, such as 127.0.0.1:8000, http://127.0.0.1:8000
+// or discovery:///helloworld.
+func parseTarget(endpoint string) (*target, error) {
+	if !strings.Contains(endpoint, "://") {
+		return &target{scheme: "http", authority: endpoint}, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	t := &target{scheme: u.Scheme, authority: u.Host}
+	if u.Scheme == "discovery" {
+		t.authority = strings.TrimPrefix(u.Path, "/")
+	}
+	return t, nil
+}
@@ -0,0 +1,398 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/hedging"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type testDiscovery struct {
+	ins []*registry.ServiceInstance
+	w   *testWatcher
+}
+
+func (d *testDiscovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	return d.ins, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return d.w, nil
+}
+
+type testWatcher struct {
+	ch chan []*registry.ServiceInstance
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	ins, ok := <-w.ch
+	if !ok {
+		return nil, context.Canceled
+	}
+	return ins, nil
+}
+
+func (w *testWatcher) Close() error {
+	close(w.ch)
+	return nil
+}
+
+func newTestServer(t *testing.T) (*Server, string) {
+	srv := NewServer(Address("127.0.0.1:0"))
+	srv.HandleFunc("/v1/echo", func(w http.ResponseWriter, r *http.Request) {
+		var msg testMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			srv.errorEncoder(w, r, errors.InvalidArgument("BAD_REQUEST", err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Reply", "echo")
+		json.NewEncoder(w).Encode(&testMessage{Name: msg.Name + ":" + r.Header.Get("X-Token")})
+	})
+	srv.HandleFunc("/v1/missing", func(w http.ResponseWriter, r *http.Request) {
+		srv.errorEncoder(w, r, errors.NotFound("USER_NOT_FOUND", "user not found"))
+	})
+	var flaky int32
+	srv.HandleFunc("/v1/flaky", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&flaky, 1)%2 == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var msg testMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&testMessage{Name: msg.Name})
+	})
+	srv.HandleFunc("/v1/proxy", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusServiceUnavailable)
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	return srv, endpoint
+}
+
+func TestClient(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	defer srv.Stop()
+
+	var operation string
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				operation = tr.Operation()
+				tr.RequestHeader().Set("X-Token", "secret")
+			}
+			return handler(ctx, req)
+		}
+	}
+	client, err := NewClient(context.Background(), WithEndpoint(endpoint), WithMiddleware(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var (
+		reply  testMessage
+		header http.Header
+	)
+	err = client.Invoke(context.Background(), http.MethodPost, "/v1/echo", &testMessage{Name: "kratos"}, &reply, Operation("/echo.Echo/Echo"), Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != "kratos:secret" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+	if operation != "/echo.Echo/Echo" {
+		t.Errorf("unexpected operation: %s", operation)
+	}
+	if header.Get("X-Reply") != "echo" {
+		t.Errorf("unexpected reply header: %v", header)
+	}
+
+	err = client.Invoke(context.Background(), http.MethodGet, "/v1/missing", nil, &reply)
+	if !errors.IsNotFound(err) {
+		t.Errorf("got %v want not found", err)
+	}
+	err = client.Invoke(context.Background(), http.MethodGet, "/v1/proxy", nil, &reply)
+	if !errors.IsUnavailable(err) {
+		t.Errorf("got %v want unavailable", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/v1/echo", strings.NewReader(`{"name":"do"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecodeResponse(res, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != "do:secret" || operation != "/v1/echo" {
+		t.Errorf("unexpected reply: %+v %s", reply, operation)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	defer srv.Stop()
+
+	var first interface{}
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := handler(ctx, req)
+			if first == nil {
+				first = reply
+			}
+			if err == nil {
+				return reply, nil
+			}
+			return handler(ctx, req)
+		}
+	}
+	client, err := NewClient(context.Background(), WithEndpoint(endpoint), WithMiddleware(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// the first call fails, and the body of the request is sent again by the retry.
+	var reply testMessage
+	if err := client.Invoke(context.Background(), http.MethodPost, "/v1/flaky", &testMessage{Name: "kratos"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != "kratos" {
+		t.Errorf("got %+v want the reply of the retry", reply)
+	}
+	// the single call is decoded into the reply itself.
+	first = nil
+	if err := client.Invoke(context.Background(), http.MethodPost, "/v1/echo", &testMessage{Name: "kratos"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if first != &reply || reply.Name != "kratos:" {
+		t.Errorf("got %+v want the reply decoded in place", reply)
+	}
+}
+
+func TestClientDiscovery(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	defer srv.Stop()
+
+	d := &testDiscovery{
+		ins: []*registry.ServiceInstance{
+			{ID: "1", Name: "echo", Endpoints: []string{"grpc://127.0.0.1:9000", endpoint}},
+		},
+		w: &testWatcher{ch: make(chan []*registry.ServiceInstance)},
+	}
+	client, err := NewClient(context.Background(), WithEndpoint("discovery:///echo"), WithDiscovery(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply testMessage
+	if err := client.Invoke(context.Background(), http.MethodPost, "/v1/echo", &testMessage{Name: "kratos"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != "kratos:" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+
+	if _, err := NewClient(context.Background(), WithEndpoint("discovery:///echo")); err == nil {
+		t.Error("expected an error without discovery")
+	}
+}
+
+func TestClientNodeFilter(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	defer srv.Stop()
+
+	d := &testDiscovery{
+		ins: []*registry.ServiceInstance{
+			{ID: "1", Name: "echo", Version: "v1", Endpoints: []string{endpoint}},
+			{ID: "2", Name: "echo", Version: "canary", Endpoints: []string{"http://127.0.0.1:1"}},
+		},
+	}
+	version := func(v string) selector.Filter {
+		return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+			var filtered []selector.Node
+			for _, n := range nodes {
+				if n.Version() == v {
+					filtered = append(filtered, n)
+				}
+			}
+			return filtered
+		}
+	}
+	for _, test := range []struct {
+		version string
+		ok      bool
+	}{
+		{"v1", true},
+		{"v2", false},
+	} {
+		d.w = &testWatcher{ch: make(chan []*registry.ServiceInstance)}
+		client, err := NewClient(context.Background(), WithEndpoint("discovery:///echo"), WithDiscovery(d), WithNodeFilter(version(test.version)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			var reply testMessage
+			err := client.Invoke(context.Background(), http.MethodPost, "/v1/echo", &testMessage{Name: "kratos"}, &reply)
+			if test.ok && err != nil {
+				t.Errorf("got %v want the requests routed to %s", err, test.version)
+			}
+			if !test.ok && errors.Reason(err) != "NODE_NOT_FOUND" {
+				t.Errorf("got %v want NODE_NOT_FOUND", err)
+			}
+		}
+		client.Close()
+	}
+}
+
+type doneSelector struct {
+	selector.Selector
+	errs []error
+}
+
+func (s *doneSelector) Select(ctx context.Context, opts ...selector.SelectOption) (selector.Node, selector.DoneFunc, error) {
+	n, done, err := s.Selector.Select(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, func(ctx context.Context, di selector.DoneInfo) {
+		s.errs = append(s.errs, di.Err)
+		done(ctx, di)
+	}, nil
+}
+
+func TestClientDone(t *testing.T) {
+	srv, endpoint := newTestServer(t)
+	defer srv.Stop()
+
+	tests := []struct {
+		path    string
+		failure bool
+	}{
+		{"/v1/echo", false},
+		{"/v1/missing", false},
+		{"/v1/proxy", true},
+	}
+	for _, test := range tests {
+		client, err := NewClient(context.Background(), WithEndpoint(strings.TrimPrefix(endpoint, "http://")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &doneSelector{Selector: client.selector}
+		client.selector = s
+		var reply testMessage
+		_ = client.Invoke(context.Background(), http.MethodPost, test.path, &testMessage{Name: "kratos"}, &reply)
+		if len(s.errs) != 1 || (s.errs[0] != nil) != test.failure {
+			t.Errorf("%s: got %v want the failure %v of the node", test.path, s.errs, test.failure)
+		}
+		client.Close()
+	}
+}
+
+// firstSelector picks the first node of the filters, and records the errors of the nodes.
+type firstSelector struct {
+	nodes []selector.Node
+
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func (s *firstSelector) Apply(nodes []selector.Node) {}
+
+func (s *firstSelector) Select(ctx context.Context, opts ...selector.SelectOption) (selector.Node, selector.DoneFunc, error) {
+	var options selector.SelectOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	candidates := s.nodes
+	for _, f := range options.Filters {
+		candidates = f(ctx, candidates)
+	}
+	if len(candidates) == 0 {
+		return nil, nil, selector.ErrNoAvailable
+	}
+	n := candidates[0]
+	return n, func(ctx context.Context, di selector.DoneInfo) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.errs[n.Address()] = di.Err
+	}, nil
+}
+
+func (s *firstSelector) done() map[string]error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := make(map[string]error, len(s.errs))
+	for k, v := range s.errs {
+		errs[k] = v
+	}
+	return errs
+}
+
+func TestClientHedging(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&testMessage{Name: "fast"})
+	}))
+	defer fast.Close()
+
+	slowAddr, fastAddr := strings.TrimPrefix(slow.URL, "http://"), strings.TrimPrefix(fast.URL, "http://")
+	client, err := NewClient(context.Background(), WithEndpoint(slowAddr), WithMiddleware(hedging.Client(
+		hedging.WithDelay(20*time.Millisecond),
+		hedging.WithBudget(nil),
+		hedging.WithIdempotent(func(ctx context.Context, req interface{}) bool { return true }),
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	s := &firstSelector{
+		nodes: []selector.Node{selector.NewNode(slowAddr, nil), selector.NewNode(fastAddr, nil)},
+		errs:  make(map[string]error),
+	}
+	client.selector = s
+	var reply testMessage
+	if err := client.Invoke(context.Background(), http.MethodGet, "/v1/hello", nil, &reply); err != nil || reply.Name != "fast" {
+		t.Fatalf("got %v %v want the reply of the hedged attempt", reply, err)
+	}
+	// the losing attempt is canceled, which is not a failure of the slow node.
+	deadline := time.Now().Add(time.Second)
+	for len(s.done()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	errs := s.done()
+	if err, ok := errs[slowAddr]; !ok || !errors.IsCancelled(err) || selector.IsFailure(err) {
+		t.Errorf("got %v of the losing attempt want the canceled error", err)
+	}
+	if err := errs[fastAddr]; err != nil {
+		t.Errorf("got %v of the winning attempt want nil", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/go-kratos/kratos/v2/transport"
+
 	"github.com/gorilla/mux"
 )
 
@@ -11,6 +13,8 @@ import (
 type ServerInfo struct {
 	Request  *http.Request
 	Response http.ResponseWriter
+	// PathTemplate is the route path of a registered service method, i.e. /v1/users/{id}.
+	PathTemplate string
 }
 
 type serverKey struct{}
@@ -30,3 +34,13 @@ func FromContext(ctx context.Context) (s ServerInfo, ok bool) {
 func Vars(req *http.Request) map[string]string {
 	return mux.Vars(req)
 }
+
+// VarsFromContext returns the route variables of the request in ctx, if any.
+func VarsFromContext(ctx context.Context) map[string]string {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if ht, ok := tr.(*Transport); ok && ht.request != nil {
+			return mux.Vars(ht.request)
+		}
+	}
+	return nil
+}
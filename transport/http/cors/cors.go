@@ -0,0 +1,199 @@
+// Package cors provides a HTTP server filter of the cross-origin resource sharing,
+// so that the browser applications can call the services directly:
+//
+//	srv := http.NewServer(http.Filter(cors.Filter(
+//		cors.WithAllowedOrigins("https://*.example.com"),
+//		cors.WithAllowCredentials(),
+//	)))
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option is cors option.
+type Option func(*options)
+
+// WithAllowedOrigins with the allowed origins, "*" allows all origins and a
+// single "*" in an origin matches any characters, i.e. https://*.example.com.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(o *options) {
+		o.origins = origins
+	}
+}
+
+// WithAllowedMethods with the allowed methods of the preflight requests.
+func WithAllowedMethods(methods ...string) Option {
+	return func(o *options) {
+		o.methods = methods
+	}
+}
+
+// WithAllowedHeaders with the allowed request headers of the preflight requests, "*" allows all headers.
+func WithAllowedHeaders(headers ...string) Option {
+	return func(o *options) {
+		o.headers = headers
+	}
+}
+
+// WithExposedHeaders with the reply headers exposed to the browser applications.
+func WithExposedHeaders(headers ...string) Option {
+	return func(o *options) {
+		o.exposed = headers
+	}
+}
+
+// WithAllowCredentials allows the requests with credentials, such as cookies, the origins
+// are required to be allowed explicitly, so it is refused with the "*" origin.
+func WithAllowCredentials() Option {
+	return func(o *options) {
+		o.credentials = true
+	}
+}
+
+// WithMaxAge with how long the results of a preflight request can be cached.
+func WithMaxAge(age time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = age
+	}
+}
+
+type options struct {
+	origins     []string
+	methods     []string
+	headers     []string
+	exposed     []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+func (o *options) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range o.origins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if i := strings.Index(allowed, "*"); i >= 0 {
+			prefix, suffix := allowed[:i], allowed[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowAll reports whether all the origins are allowed.
+func (o *options) allowAll() bool {
+	for _, allowed := range o.origins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *options) allowMethod(method string) bool {
+	for _, allowed := range o.methods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *options) allowHeaders(headers []string) bool {
+	for _, h := range headers {
+		allowed := false
+		for _, a := range o.headers {
+			if a == "*" || strings.EqualFold(a, h) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns a HTTP server filter of the cross-origin resource sharing, it answers
+// the preflight requests and adds the access control headers to the actual requests. It
+// panics if the credentials are allowed for all the origins, which would allow any site
+// to call the services with the cookies of the users.
+func Filter(opts ...Option) func(http.Handler) http.Handler {
+	options := options{
+		origins: []string{"*"},
+		methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		headers: []string{"Origin", "Accept", "Content-Type", "Authorization", "X-Requested-With"},
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.credentials && options.allowAll() {
+		panic("cors: WithAllowCredentials requires the explicit allowed origins instead of \"*\"")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			header := w.Header()
+			header.Add("Vary", "Origin")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				preflight(&options, w, r, origin)
+				return
+			}
+			if options.allowOrigin(origin) {
+				setOrigin(&options, header, origin)
+				if len(options.exposed) > 0 {
+					header.Set("Access-Control-Expose-Headers", strings.Join(options.exposed, ", "))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func preflight(o *options, w http.ResponseWriter, r *http.Request, origin string) {
+	header := w.Header()
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	var headers []string
+	for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, h)
+		}
+	}
+	if !o.allowOrigin(origin) || !o.allowMethod(r.Header.Get("Access-Control-Request-Method")) || !o.allowHeaders(headers) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	setOrigin(o, header, origin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(o.methods, ", "))
+	if len(headers) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if o.maxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(o.maxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setOrigin(o *options, header http.Header, origin string) {
+	if len(o.origins) == 1 && o.origins[0] == "*" {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if o.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
@@ -0,0 +1,138 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	transhttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+func TestFilter(t *testing.T) {
+	srv := transhttp.NewServer(transhttp.Filter(Filter(
+		WithAllowedOrigins("https://*.example.com"),
+		WithAllowedMethods(http.MethodGet, http.MethodPost),
+		WithAllowedHeaders("Content-Type", "X-Token"),
+		WithExposedHeaders("X-Request-Id"),
+		WithAllowCredentials(),
+		WithMaxAge(time.Hour),
+	)))
+	srv.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		code    int
+		expect  map[string]string
+	}{
+		{
+			name:   "preflight",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type, x-token",
+			},
+			code: http.StatusNoContent,
+			expect: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "content-type, x-token",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "3600",
+			},
+		},
+		{
+			name:   "preflight method not allowed",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			code:   http.StatusForbidden,
+			expect: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "preflight header not allowed",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "x-secret",
+			},
+			code:   http.StatusForbidden,
+			expect: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:    "actual",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			code:    http.StatusOK,
+			expect: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Expose-Headers":    "X-Request-Id",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:    "origin not allowed",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://evil.com"},
+			code:    http.StatusOK,
+			expect:  map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "same origin",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			expect: map[string]string{"Access-Control-Allow-Origin": "", "Vary": ""},
+		},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/users", nil)
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, req)
+		if res.Code != test.code {
+			t.Errorf("%s: got code %d want %d", test.name, res.Code, test.code)
+		}
+		for k, v := range test.expect {
+			if got := res.Header().Get(k); got != v {
+				t.Errorf("%s: got %s %q want %q", test.name, k, got, v)
+			}
+		}
+	}
+}
+
+func TestFilterWildcard(t *testing.T) {
+	h := Filter()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://any.com")
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	if got := res.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("got %q want *", got)
+	}
+}
+
+func TestFilterCredentials(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithAllowCredentials()},
+		{WithAllowedOrigins("https://example.com", "*"), WithAllowCredentials()},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic want the credentials of all the origins refused")
+				}
+			}()
+			Filter(opts...)
+		}()
+	}
+}
@@ -7,27 +7,18 @@ import (
 	"strings"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/internal/httputil"
 )
 
-const baseContentType = "application"
-
-func contentSubtype(contentType string) string {
-	if contentType == baseContentType {
-		return ""
-	}
-	if !strings.HasPrefix(contentType, baseContentType) {
-		return ""
-	}
-	// guaranteed since != baseContentType and has baseContentType prefix
-	switch contentType[len(baseContentType)] {
-	case '/', ';':
-		// this will return true for "application/grpc+" or "application/grpc;"
-		// which the previous validContentType function tested to be valid, so we
-		// just say that no content-subtype is specified in this case
-		return contentType[len(baseContentType)+1:]
-	default:
-		return ""
-	}
+// codecForRequest returns the codec of the first supported media type in the header,
+// the json codec is returned if none is supported.
+func codecForRequest(req *http.Request, name string) (encoding.Codec, bool) {
+	for _, mediaType := range strings.Split(req.Header.Get(name), ",") {
+		if codec := encoding.GetCodec(httputil.ContentSubtype(mediaType)); codec != nil {
+			return codec, true
+		}
+	}
+	return encoding.GetCodec("json"), false
 }
 
 func defaultRequestDecoder(req *http.Request, v interface{}) error {
@@ -36,40 +27,33 @@ func defaultRequestDecoder(req *http.Request, v interface{}) error {
 		return err
 	}
 	defer req.Body.Close()
-	subtype := contentSubtype(req.Header.Get("content-type"))
-	codec := encoding.GetCodec(subtype)
-	if codec == nil {
-		return fmt.Errorf("unknown content-type error: %s", subtype)
+	codec, ok := codecForRequest(req, "Content-Type")
+	if !ok {
+		return fmt.Errorf("unknown content-type error: %s", req.Header.Get("Content-Type"))
 	}
 	return codec.Unmarshal(data, v)
 }
 
 func defaultResponseEncoder(res http.ResponseWriter, req *http.Request, v interface{}) error {
-	subtype := contentSubtype(req.Header.Get("accept"))
-	codec := encoding.GetCodec(subtype)
-	if codec == nil {
-		codec = encoding.GetCodec("json")
-	}
+	codec, _ := codecForRequest(req, "Accept")
 	data, err := codec.Marshal(v)
 	if err != nil {
 		return err
 	}
+	res.Header().Set("Content-Type", httputil.ContentType(codec.Name()))
 	res.Write(data)
 	return nil
 }
 
 func defaultErrorEncoder(res http.ResponseWriter, req *http.Request, err error) {
 	code, se := StatusError(err)
-	subtype := contentSubtype(req.Header.Get("accept"))
-	codec := encoding.GetCodec(subtype)
-	if codec == nil {
-		codec = encoding.GetCodec("json")
-	}
+	codec, _ := codecForRequest(req, "Accept")
 	data, err := codec.Marshal(se)
 	if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	res.Header().Set("Content-Type", httputil.ContentType(codec.Name()))
 	res.WriteHeader(code)
 	res.Write(data)
 }
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testMessage struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func TestDefaultRequestDecoder(t *testing.T) {
+	for contentType, body := range map[string]string{
+		"application/json; charset=utf-8":   `{"name":"kratos"}`,
+		"application/xml":                   `<testMessage><name>kratos</name></testMessage>`,
+		"application/x-www-form-urlencoded": `name=kratos`,
+	} {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		var v testMessage
+		if err := defaultRequestDecoder(req, &v); err != nil {
+			t.Fatalf("%s: %v", contentType, err)
+		}
+		if v.Name != "kratos" {
+			t.Errorf("%s: got %+v", contentType, v)
+		}
+	}
+	req := httptest.NewRequest("POST", "/", strings.NewReader("name"))
+	req.Header.Set("Content-Type", "text/plain")
+	if err := defaultRequestDecoder(req, &testMessage{}); err == nil {
+		t.Error("expected unknown content-type error")
+	}
+}
+
+func TestDefaultResponseEncoder(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json", `{"name":"kratos"}`},
+		{"text/html, application/xml;q=0.9, */*", "application/xml", `<testMessage><name>kratos</name></testMessage>`},
+		{"application/x-www-form-urlencoded", "application/x-www-form-urlencoded", `name=kratos`},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", test.accept)
+		res := httptest.NewRecorder()
+		if err := defaultResponseEncoder(res, req, &testMessage{Name: "kratos"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := res.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("%q: got content type %s want %s", test.accept, got, test.contentType)
+		}
+		if got := res.Body.String(); got != test.body {
+			t.Errorf("%q: got body %s want %s", test.accept, got, test.body)
+		}
+	}
+}
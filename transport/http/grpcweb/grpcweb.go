@@ -0,0 +1,270 @@
+// Package grpcweb translates the gRPC-Web requests into the calls of a gRPC server, and
+// serves them by the HTTP transport, so that the browser clients can call the services
+// without a proxy such as Envoy:
+//
+//	gs := grpc.NewServer()
+//	helloworld.RegisterGreeterServer(gs, greeter)
+//	hs := http.NewServer()
+//	grpcweb.Register(hs, gs.Server, grpcweb.WithAllowedOrigins("https://*.example.com"))
+//
+// The calls run through the middleware of the gRPC server, and the HTTP server timeout
+// applies to them, so the server streaming methods need a longer one by http.Timeouts.
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	transhttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/go-kratos/kratos/v2/transport/http/cors"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+)
+
+const (
+	contentTypeGRPC    = "application/grpc"
+	contentTypeWeb     = "application/grpc-web"
+	contentTypeWebText = "application/grpc-web-text"
+
+	// trailerFlag is the flag of the frame carrying the trailers in the body.
+	trailerFlag byte = 0x80
+)
+
+// Option is grpc-web option.
+type Option func(*options)
+
+// WithAllowedOrigins with the allowed origins of the browser applications, "*" allows all
+// origins and a single "*" in an origin matches any characters, i.e. https://*.example.com.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(o *options) {
+		o.origins = origins
+	}
+}
+
+// WithExposedHeaders with the reply metadata exposed to the browser applications, besides
+// the grpc-status and grpc-message.
+func WithExposedHeaders(headers ...string) Option {
+	return func(o *options) {
+		o.exposed = append(o.exposed, headers...)
+	}
+}
+
+// WithAllowCredentials allows the requests with credentials, such as cookies, which
+// requires the explicit allowed origins instead of the default "*".
+func WithAllowCredentials() Option {
+	return func(o *options) {
+		o.credentials = true
+	}
+}
+
+// WithMaxAge with how long the results of a preflight request can be cached.
+func WithMaxAge(age time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = age
+	}
+}
+
+type options struct {
+	origins     []string
+	exposed     []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+// Handler is a HTTP handler which serves the gRPC-Web requests by a gRPC server, it also
+// serves the native gRPC requests of HTTP/2, and answers the CORS preflight requests.
+type Handler struct {
+	server  *grpc.Server
+	handler http.Handler
+}
+
+// NewHandler returns a handler which serves the requests by the gRPC server, it panics if
+// the credentials are allowed for all the origins.
+func NewHandler(srv *grpc.Server, opts ...Option) *Handler {
+	o := options{
+		origins: []string{"*"},
+		exposed: []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	corsOpts := []cors.Option{
+		cors.WithAllowedOrigins(o.origins...),
+		cors.WithAllowedMethods(http.MethodPost),
+		// the request metadata are arbitrary headers, the preflight echoes them.
+		cors.WithAllowedHeaders("*"),
+		cors.WithExposedHeaders(o.exposed...),
+		cors.WithMaxAge(o.maxAge),
+	}
+	if o.credentials {
+		corsOpts = append(corsOpts, cors.WithAllowCredentials())
+	}
+	h := &Handler{server: srv}
+	h.handler = cors.Filter(corsOpts...)(http.HandlerFunc(h.serve))
+	return h
+}
+
+// Register registers the handler on the paths of the services of the gRPC server, which
+// must be called after the services are registered.
+func Register(hs *transhttp.Server, gs *grpc.Server, opts ...Option) *Handler {
+	h := NewHandler(gs, opts...)
+	for name := range gs.GetServiceInfo() {
+		hs.HandlePrefix("/"+name+"/", h)
+	}
+	return h
+}
+
+// IsGRPCWebRequest reports whether the request is a gRPC-Web request.
+func IsGRPCWebRequest(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.HasPrefix(req.Header.Get("Content-Type"), contentTypeWeb)
+}
+
+// ServeHTTP serves the gRPC-Web request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.handler.ServeHTTP(w, req)
+}
+
+func (h *Handler) serve(w http.ResponseWriter, req *http.Request) {
+	if req.ProtoMajor == 2 && strings.HasPrefix(req.Header.Get("Content-Type"), contentTypeGRPC) {
+		h.server.ServeHTTP(w, req)
+		return
+	}
+	if !IsGRPCWebRequest(req) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	webType := req.Header.Get("Content-Type")
+	text := strings.HasPrefix(webType, contentTypeWebText)
+	prefix := contentTypeWeb
+	if text {
+		prefix = contentTypeWebText
+	}
+
+	r := req.Clone(req.Context())
+	r.ProtoMajor, r.ProtoMinor, r.Proto = 2, 0, "HTTP/2"
+	r.Header.Set("Content-Type", contentTypeGRPC+strings.TrimPrefix(webType, prefix))
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+	if text {
+		r.Body = readCloser{Reader: base64.NewDecoder(base64.StdEncoding, req.Body), Closer: req.Body}
+	}
+	rw := newResponseWriter(w, prefix, text)
+	h.server.ServeHTTP(rw, r)
+	rw.finish()
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseWriter translates the gRPC response into the gRPC-Web one, the trailers are
+// written as the last frame of the body.
+type responseWriter struct {
+	w        http.ResponseWriter
+	header   http.Header
+	prefix   string
+	text     bool
+	body     io.Writer
+	encoder  io.WriteCloser
+	trailers []string
+	written  bool
+	grpc     bool
+}
+
+func newResponseWriter(w http.ResponseWriter, prefix string, text bool) *responseWriter {
+	rw := &responseWriter{w: w, header: make(http.Header), prefix: prefix, text: text, body: w}
+	if text {
+		rw.encoder = base64.NewEncoder(base64.StdEncoding, w)
+		rw.body = rw.encoder
+	}
+	return rw
+}
+
+func (rw *responseWriter) Header() http.Header {
+	return rw.header
+}
+
+// WriteHeader writes the headers, the declared trailers are written in the body later.
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.written {
+		return
+	}
+	rw.written = true
+	for _, v := range rw.header["Trailer"] {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				rw.trailers = append(rw.trailers, textproto.CanonicalMIMEHeaderKey(k))
+			}
+		}
+	}
+	header := rw.w.Header()
+	for k, vv := range rw.header {
+		if k == "Trailer" || strings.HasPrefix(k, http2.TrailerPrefix) {
+			continue
+		}
+		header[k] = append([]string(nil), vv...)
+	}
+	if ct := header.Get("Content-Type"); strings.HasPrefix(ct, contentTypeGRPC) {
+		header.Set("Content-Type", rw.prefix+strings.TrimPrefix(ct, contentTypeGRPC))
+		rw.grpc = true
+	}
+	rw.w.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+	return rw.body.Write(b)
+}
+
+// Flush flushes the written frames, each flushed chunk of the text is padded.
+func (rw *responseWriter) Flush() {
+	rw.WriteHeader(http.StatusOK)
+	if rw.text {
+		_ = rw.encoder.Close()
+		rw.encoder = base64.NewEncoder(base64.StdEncoding, rw.w)
+		rw.body = rw.encoder
+	}
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the trailer frame of the declared and the prefixed trailers, unless
+// the request is rejected before the call.
+func (rw *responseWriter) finish() {
+	rw.WriteHeader(http.StatusOK)
+	if !rw.grpc {
+		return
+	}
+	trailers := make(http.Header)
+	for _, k := range rw.trailers {
+		if vv, ok := rw.header[k]; ok {
+			trailers[k] = vv
+		}
+	}
+	for k, vv := range rw.header {
+		if strings.HasPrefix(k, http2.TrailerPrefix) {
+			k = textproto.CanonicalMIMEHeaderKey(strings.TrimPrefix(k, http2.TrailerPrefix))
+			trailers[k] = append(trailers[k], vv...)
+		}
+	}
+	var buf bytes.Buffer
+	for k, vv := range trailers {
+		for _, v := range vv {
+			buf.WriteString(strings.ToLower(k) + ": " + v + "\r\n")
+		}
+	}
+	frame := make([]byte, 5, 5+buf.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(buf.Len()))
+	_, _ = rw.body.Write(append(frame, buf.Bytes()...))
+	rw.Flush()
+}
@@ -0,0 +1,184 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	transhttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func newServer(t *testing.T, opts ...Option) string {
+	gs := grpc.NewServer()
+	hs := transhttp.NewServer(transhttp.Address("127.0.0.1:0"))
+	Register(hs, gs.Server, opts...)
+	endpoint, err := hs.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := hs.Start(); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() { _ = hs.Stop() })
+	return endpoint
+}
+
+func frame(t *testing.T, m proto.Message) []byte {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := make([]byte, 5, 5+len(b))
+	binary.BigEndian.PutUint32(f[1:], uint32(len(b)))
+	return append(f, b...)
+}
+
+// call calls the health check, and returns the reply message and the trailers.
+func call(t *testing.T, endpoint, contentType, service string) (*http.Response, *healthpb.HealthCheckResponse, map[string]string) {
+	body := frame(t, &healthpb.HealthCheckRequest{Service: service})
+	if strings.HasPrefix(contentType, contentTypeWebText) {
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint+"/grpc.health.v1.Health/Check", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Origin", "https://app.example.com")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(contentType, contentTypeWebText) {
+		// the flushed chunks are padded separately, so the quanta are decoded one by one.
+		var decoded []byte
+		for i := 0; i+4 <= len(data); i += 4 {
+			quantum, err := base64.StdEncoding.DecodeString(string(data[i : i+4]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded = append(decoded, quantum...)
+		}
+		data = decoded
+	}
+	var (
+		reply    *healthpb.HealthCheckResponse
+		trailers = make(map[string]string)
+	)
+	for len(data) >= 5 {
+		flag, n := data[0], binary.BigEndian.Uint32(data[1:5])
+		payload := data[5 : 5+n]
+		data = data[5+n:]
+		if flag&trailerFlag != 0 {
+			for _, line := range strings.Split(strings.TrimSpace(string(payload)), "\r\n") {
+				if kv := strings.SplitN(line, ": ", 2); len(kv) == 2 {
+					trailers[kv[0]] = kv[1]
+				}
+			}
+			continue
+		}
+		reply = new(healthpb.HealthCheckResponse)
+		if err := proto.Unmarshal(payload, reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res, reply, trailers
+}
+
+func TestHandler(t *testing.T) {
+	endpoint := newServer(t)
+	for _, contentType := range []string{"application/grpc-web", "application/grpc-web+proto", "application/grpc-web-text"} {
+		res, reply, trailers := call(t, endpoint, contentType, "")
+		if got := res.Header.Get("Content-Type"); got != contentType {
+			t.Errorf("got %s want %s", got, contentType)
+		}
+		if res.Header.Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("got %v want the allowed origin", res.Header)
+		}
+		if reply.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("%s: got %v want serving", contentType, reply)
+		}
+		if trailers["grpc-status"] != "0" {
+			t.Errorf("%s: got %v want the ok status", contentType, trailers)
+		}
+	}
+
+	// the error status is carried by the trailers.
+	_, reply, trailers := call(t, endpoint, "application/grpc-web+proto", "unknown")
+	if reply != nil || trailers["grpc-status"] != "5" || trailers["grpc-message"] != "unknown service" {
+		t.Errorf("got %v %v want the not found status", reply, trailers)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	endpoint := newServer(t, WithAllowedOrigins("https://*.example.com"), WithExposedHeaders("x-trace-id"))
+	tests := []struct {
+		origin string
+		code   int
+	}{
+		{"https://app.example.com", http.StatusNoContent},
+		{"https://example.org", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodOptions, endpoint+"/grpc.health.v1.Health/Check", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", test.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type, x-grpc-web, x-user-agent")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != test.code {
+			t.Errorf("%s: got %d want %d", test.origin, res.StatusCode, test.code)
+		}
+		if test.code == http.StatusNoContent && res.Header.Get("Access-Control-Allow-Headers") != "content-type, x-grpc-web, x-user-agent" {
+			t.Errorf("got %v want the allowed headers", res.Header)
+		}
+	}
+
+	res, _, _ := call(t, endpoint, "application/grpc-web+proto", "")
+	if got := res.Header.Get("Access-Control-Expose-Headers"); !strings.Contains(got, "Grpc-Status") || !strings.Contains(got, "x-trace-id") {
+		t.Errorf("got %s want the exposed headers", got)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	endpoint := newServer(t)
+	res, err := http.Post(endpoint+"/grpc.health.v1.Health/Check", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("got %d want unsupported media type", res.StatusCode)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	NewHandler(grpc.NewServer().Server, WithAllowedOrigins("https://app.example.com"), WithAllowCredentials())
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic want the credentials of all the origins refused")
+		}
+	}()
+	NewHandler(grpc.NewServer().Server, WithAllowCredentials())
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kratos/kratos/v2/health"
+)
+
+// healthReply is the reply of the readiness probe.
+type healthReply struct {
+	Status       string            `json:"status"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// handleHealth registers the liveness probe /healthz and the readiness probe /readyz if
+// the health registry is set, the readiness probe replies 503 unless all the dependencies
+// are serving.
+func (s *Server) handleHealth() {
+	if s.health == nil {
+		return
+	}
+	s.router.HandleFunc("/healthz", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		res.Write([]byte("ok"))
+	}).Methods("GET", "HEAD")
+	s.router.HandleFunc("/readyz", func(res http.ResponseWriter, req *http.Request) {
+		status := s.health.Status()
+		reply := healthReply{Status: status.String()}
+		if deps := s.health.Dependencies(); len(deps) > 0 {
+			reply.Dependencies = make(map[string]string, len(deps))
+			for _, name := range deps {
+				reply.Dependencies[name] = s.health.Get(name).String()
+			}
+		}
+		data, _ := json.Marshal(reply)
+		res.Header().Set("Content-Type", "application/json")
+		if status != health.StatusServing {
+			res.WriteHeader(http.StatusServiceUnavailable)
+		}
+		res.Write(data)
+	}).Methods("GET", "HEAD")
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/health"
+)
+
+func TestHealth(t *testing.T) {
+	h := health.New()
+	h.Set("db", health.StatusNotServing)
+	srv := NewServer(Health(h))
+
+	probe := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		return res
+	}
+	if res := probe("/healthz"); res.Code != http.StatusOK {
+		t.Errorf("healthz: got %d want 200", res.Code)
+	}
+	res := probe("/readyz")
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz: got %d want 503", res.Code)
+	}
+	if want := `{"status":"NOT_SERVING","dependencies":{"db":"NOT_SERVING"}}`; res.Body.String() != want {
+		t.Errorf("readyz: got %s want %s", res.Body.String(), want)
+	}
+
+	h.Set("db", health.StatusServing)
+	if res := probe("/readyz"); res.Code != http.StatusOK {
+		t.Errorf("readyz: got %d want 200", res.Code)
+	}
+}
+
+func TestHealthOptIn(t *testing.T) {
+	for _, path := range []string{"/healthz", "/readyz"} {
+		res := httptest.NewRecorder()
+		NewServer().ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		if res.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d want 404 without the health registry", path, res.Code)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic want the panic of the nil health registry")
+		}
+	}()
+	Health(nil)
+}
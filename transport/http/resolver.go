@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/wrr"
+)
+
+func init() {
+	if selector.GlobalSelector() == nil {
+		selector.SetGlobalSelector(wrr.NewBuilder())
+	}
+}
+
+// resolver watches the instances of the service and applies their nodes to the selector.
+type resolver struct {
+	w        registry.Watcher
+	selector selector.Selector
+	scheme   string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	log      *log.Helper
+}
+
+func newResolver(ctx context.Context, d registry.Discovery, name, scheme string, s selector.Selector, logger log.Logger) (*resolver, error) {
+	w, err := d.Watch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	r := &resolver{
+		w:        w,
+		selector: s,
+		scheme:   scheme,
+		log:      log.NewHelper("http/resolver", logger),
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	ins, err := d.GetService(ctx, name)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	r.update(ins)
+	go r.watch()
+	return r, nil
+}
+
+func (r *resolver) watch() {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		default:
+		}
+		ins, err := r.w.Next()
+		if err != nil {
+			if r.ctx.Err() != nil {
+				return
+			}
+			r.log.Errorf("Failed to watch discovery endpoint: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		r.update(ins)
+	}
+}
+
+func (r *resolver) update(ins []*registry.ServiceInstance) {
+	nodes := make([]selector.Node, 0, len(ins))
+	for _, in := range ins {
+		addr, err := parseEndpoint(in.Endpoints, r.scheme)
+		if err != nil {
+			r.log.Errorf("Failed to parse discovery endpoint: %v", err)
+			continue
+		}
+		if addr == "" {
+			continue
+		}
+		nodes = append(nodes, selector.NewNode(addr, in))
+	}
+	if len(nodes) == 0 {
+		r.log.Warnf("Zero endpoint found, refused to write, instances: %v", ins)
+		return
+	}
+	r.selector.Apply(nodes)
+}
+
+func (r *resolver) Close() error {
+	r.cancel()
+	return r.w.Close()
+}
+
+// parseEndpoint returns the address of the first endpoint of the scheme.
+func parseEndpoint(endpoints []string, scheme string) (string, error) {
+	for _, e := range endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			return "", err
+		}
+		if u.Scheme == scheme {
+			return u.Host, nil
+		}
+	}
+	return "", nil
+}
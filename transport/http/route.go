@@ -1,49 +1,145 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"path"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/gorilla/mux"
 )
 
-// RouteGroup .
+// FilterFunc is a HTTP middleware of the route group.
+type FilterFunc func(http.Handler) http.Handler
+
+// RouteGroup is a group of the routes with the path prefix and filters, the route
+// path supports the {id} and {id:[0-9]+} variables, and a trailing wildcard such as
+// /static/* or /static/*filepath, which matches the rest of the path.
 type RouteGroup struct {
-	root   string
-	router *mux.Router
+	root    string
+	router  *mux.Router
+	filters []FilterFunc
+}
+
+// Group returns a sub group with the path prefix, the filters are applied after
+// the filters of the parent group.
+func (r *RouteGroup) Group(prefix string, filters ...FilterFunc) *RouteGroup {
+	fs := make([]FilterFunc, 0, len(r.filters)+len(filters))
+	fs = append(fs, r.filters...)
+	fs = append(fs, filters...)
+	return &RouteGroup{root: path.Join(r.root, prefix), router: r.router, filters: fs}
 }
 
-// GET .
+// Handle registers a new route with the method and path.
+func (r *RouteGroup) Handle(method, p string, h http.HandlerFunc) {
+	tpl := routePath(path.Join(r.root, p))
+	next := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		setRoute(req, tpl)
+		h(res, req)
+	})
+	r.router.Handle(tpl, filterChain(next, r.filters)).Methods(method)
+}
+
+// GET registers a new GET route with the path.
 func (r *RouteGroup) GET(p string, h http.HandlerFunc) {
-	r.router.HandleFunc(path.Join(r.root, p), h).Methods("GET")
+	r.Handle(http.MethodGet, p, h)
 }
 
-// HEAD .
+// HEAD registers a new HEAD route with the path.
 func (r *RouteGroup) HEAD(p string, h http.HandlerFunc) {
-	r.router.HandleFunc(path.Join(r.root, p), h).Methods("HEAD")
+	r.Handle(http.MethodHead, p, h)
 }
 
-// POST .
+// POST registers a new POST route with the path.
 func (r *RouteGroup) POST(p string, h http.HandlerFunc) {
-	r.router.HandleFunc(path.Join(r.root, p), h).Methods("POST")
+	r.Handle(http.MethodPost, p, h)
 }
 
-// PUT .
+// PUT registers a new PUT route with the path.
 func (r *RouteGroup) PUT(p string, h http.HandlerFunc) {
-	r.router.HandleFunc(path.Join(r.root, p), h).Methods("PUT")
+	r.Handle(http.MethodPut, p, h)
 }
 
-// DELETE .
+// DELETE registers a new DELETE route with the path.
 func (r *RouteGroup) DELETE(p string, h http.HandlerFunc) {
-	r.router.HandleFunc(path.Join(r.root, p), h).Methods("DELETE")
+	r.Handle(http.MethodDelete, p, h)
 }
 
-// PATCH .
+// PATCH registers a new PATCH route with the path.
 func (r *RouteGroup) PATCH(p string, h http.HandlerFunc) {
-	r.router.HandleFunc(path.Join(r.root, p), h).Methods("PATCH")
+	r.Handle(http.MethodPatch, p, h)
 }
 
-// OPTIONS .
+// OPTIONS registers a new OPTIONS route with the path.
 func (r *RouteGroup) OPTIONS(p string, h http.HandlerFunc) {
-	r.router.HandleFunc(path.Join(r.root, p), h).Methods("OPTIONS")
+	r.Handle(http.MethodOptions, p, h)
+}
+
+// filterChain wraps the handler with the filters, the first filter is the outermost.
+func filterChain(h http.Handler, filters []FilterFunc) http.Handler {
+	for i := len(filters) - 1; i >= 0; i-- {
+		h = filters[i](h)
+	}
+	return h
+}
+
+// routePath converts the trailing wildcard of the path into a mux variable.
+func routePath(p string) string {
+	i := strings.LastIndex(p, "/*")
+	if i < 0 {
+		return p
+	}
+	name := p[i+2:]
+	if strings.Contains(name, "/") {
+		return p
+	}
+	if name == "" {
+		name = "wildcard"
+	}
+	return p[:i+1] + "{" + name + ":.*}"
+}
+
+// setRoute records the route of the matched request into the transport of the context.
+func setRoute(req *http.Request, pathTemplate string) {
+	if tr, ok := transport.FromServerContext(req.Context()); ok {
+		if ht, ok := tr.(*Transport); ok {
+			ht.operation = pathTemplate
+			ht.pathTemplate = pathTemplate
+			ht.request = req
+		}
+	}
+}
+
+// Routes returns the registered routes, the middleware are only reported for the
+// routes of the services and the WebSocket handlers, which are served with them.
+func (s *Server) Routes() []transport.Route {
+	var routes []transport.Route
+	_ = s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		r := transport.Route{
+			Kind:      transport.KindHTTP,
+			Operation: tpl,
+			Methods:   methods,
+		}
+		if s.routes[route] {
+			ctx := transport.NewServerContext(context.Background(), &Transport{
+				endpoint:     s.endpoint,
+				operation:    tpl,
+				pathTemplate: tpl,
+				reqHeader:    headerCarrier{},
+				replyHeader:  headerCarrier{},
+			})
+			r.Middleware = middleware.Inspect(ctx, s.middleware)
+		}
+		routes = append(routes, r)
+		return nil
+	})
+	return routes
 }
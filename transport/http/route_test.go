@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/health"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestRouteGroup(t *testing.T) {
+	filter := func(name string) FilterFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Filter", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	srv := NewServer()
+	api := srv.RouteGroup("/api", filter("api"))
+	api.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", VarsFromContext(r.Context())["id"])
+	})
+	v1 := api.Group("/v1", filter("v1"))
+	v1.POST("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "created %s", Vars(r)["id"])
+	})
+	srv.RouteGroup("/static").GET("/*filepath", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "file %s", Vars(r)["filepath"])
+	})
+	srv.RouteGroup("/assets").GET("/*", func(w http.ResponseWriter, r *http.Request) {
+		tr, _ := transport.FromServerContext(r.Context())
+		fmt.Fprintf(w, "asset %s %s", Vars(r)["wildcard"], tr.(*Transport).PathTemplate())
+	})
+
+	tests := []struct {
+		method  string
+		path    string
+		code    int
+		body    string
+		filters []string
+	}{
+		{http.MethodGet, "/api/users/1", 200, "user 1", []string{"api"}},
+		{http.MethodPost, "/api/v1/users/2", 200, "created 2", []string{"api", "v1"}},
+		{http.MethodPost, "/api/v1/users/abc", 404, "", nil},
+		{http.MethodDelete, "/api/users/1", 405, "", nil},
+		{http.MethodGet, "/static/css/main.css", 200, "file css/main.css", nil},
+		{http.MethodGet, "/assets/logo.png", 200, "asset logo.png /assets/{wildcard:.*}", nil},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, req)
+		if res.Code != test.code {
+			t.Errorf("%s %s: got code %d want %d", test.method, test.path, res.Code, test.code)
+			continue
+		}
+		if test.code != 200 {
+			continue
+		}
+		if body := res.Body.String(); body != test.body {
+			t.Errorf("%s %s: got body %q want %q", test.method, test.path, body, test.body)
+		}
+		if filters := res.Header()["X-Filter"]; fmt.Sprint(filters) != fmt.Sprint(test.filters) {
+			t.Errorf("%s %s: got filters %v want %v", test.method, test.path, filters, test.filters)
+		}
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	auth := middleware.Named("auth", func(handler middleware.Handler) middleware.Handler {
+		return handler
+	})
+	srv := NewServer(Middleware(auth), Health(health.New()))
+	srv.RegisterService(&ServiceDesc{
+		ServiceName: "helloworld.Greeter",
+		Methods: []MethodDesc{{
+			Path:   "/helloworld/{name}",
+			Method: http.MethodGet,
+			Handler: func(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
+				return nil, nil
+			},
+		}},
+	}, nil)
+	srv.RouteGroup("/static").GET("/*", func(w http.ResponseWriter, r *http.Request) {})
+
+	routes := make(map[string]transport.Route)
+	for _, r := range srv.Routes() {
+		routes[r.Operation] = r
+	}
+	if r := routes["/helloworld/{name}"]; r.Kind != transport.KindHTTP ||
+		!reflect.DeepEqual(r.Methods, []string{"GET"}) || !reflect.DeepEqual(r.Middleware, []string{"auth"}) {
+		t.Errorf("unexpected service route: %+v", r)
+	}
+	if r, ok := routes["/static/{wildcard:.*}"]; !ok || len(r.Middleware) != 0 {
+		t.Errorf("unexpected static route: %+v", r)
+	}
+	if _, ok := routes["/healthz"]; !ok {
+		t.Errorf("missing the health route: %v", routes)
+	}
+}
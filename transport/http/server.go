@@ -2,18 +2,26 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/health"
 	"github.com/go-kratos/kratos/v2/internal/host"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/timeout"
 
 	"github.com/gorilla/mux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 const (
@@ -23,7 +31,10 @@ const (
 	loggerName = "transport/http"
 )
 
-var _ transport.Server = (*Server)(nil)
+var (
+	_ transport.Server    = (*Server)(nil)
+	_ transport.Inspector = (*Server)(nil)
+)
 
 // DecodeRequestFunc deocder request func.
 type DecodeRequestFunc func(req *http.Request, v interface{}) error
@@ -44,13 +55,55 @@ func Network(network string) ServerOption {
 	}
 }
 
-// Address with server address.
+// Endpoint with the registered endpoint of the server, which overrides the extracted one
+// when the reachable address differs from the listened one, i.e. a LB VIP or a mapped port,
+// such as http://203.0.113.10:8000, or the host and port only.
+func Endpoint(endpoint string) ServerOption {
+	return func(s *Server) {
+		s.advertised = endpoint
+	}
+}
+
+// EndpointInterface with the preferred interfaces of the endpoint IP in order, such as
+// eth0, when the server listens on all the addresses.
+func EndpointInterface(names ...string) ServerOption {
+	return func(s *Server) {
+		s.hostOpts = append(s.hostOpts, host.WithInterface(names...))
+	}
+}
+
+// EndpointCIDR with the preferred ranges of the endpoint IP in order, such as 10.0.0.0/8,
+// when the server listens on all the addresses, the private ranges by default.
+func EndpointCIDR(cidrs ...string) ServerOption {
+	return func(s *Server) {
+		s.hostOpts = append(s.hostOpts, host.WithCIDR(cidrs...))
+	}
+}
+
+// EndpointIPv6 prefers the IPv6 endpoint IP to the IPv4 one.
+func EndpointIPv6(prefer bool) ServerOption {
+	return func(s *Server) {
+		s.hostOpts = append(s.hostOpts, host.WithIPv6(prefer))
+	}
+}
+
+// Address with server address, such as :8000, the unix socket of unix:///tmp/app.sock,
+// or the systemd socket of systemd://name, which is the FileDescriptorName of the socket.
 func Address(addr string) ServerOption {
 	return func(s *Server) {
 		s.address = addr
 	}
 }
 
+// Listener with a pre-created server listener, such as a socket activated
+// by systemd, the server serves on it instead of listening on the address.
+func Listener(lis net.Listener) ServerOption {
+	return func(s *Server) {
+		s.lis = lis
+		s.address = lis.Addr().String()
+	}
+}
+
 // Timeout with server timeout.
 func Timeout(timeout time.Duration) ServerOption {
 	return func(s *Server) {
@@ -58,6 +111,48 @@ func Timeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// Timeouts with the per operation timeouts, which override the server timeout,
+// the operation of a request is the path template of the matched route, or the
+// URL path if none is matched.
+func Timeouts(t *timeout.Table) ServerOption {
+	return func(s *Server) {
+		s.timeouts = t
+	}
+}
+
+// Filter with the HTTP middlewares of the server, which are applied to all the
+// requests before the routing, i.e. CORS.
+func Filter(filters ...FilterFunc) ServerOption {
+	return func(s *Server) {
+		s.filters = append(s.filters, filters...)
+	}
+}
+
+// TLSConfig with server tls config, the server serves HTTPS and the endpoint
+// reports https://.
+func TLSConfig(c *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConf = c
+	}
+}
+
+// ClientCAs with the CAs verifying the client certificates, which enables the mutual TLS
+// of the TLSConfig, which is required, and the identities of the clients are the SPIFFE
+// IDs of their certificates, see the spiffe middleware.
+func ClientCAs(pool *x509.CertPool) ServerOption {
+	return func(s *Server) {
+		s.clientCAs = pool
+	}
+}
+
+// H2C enables the HTTP/2 cleartext of the server without TLS, the HTTP/1
+// requests are served as usual.
+func H2C() ServerOption {
+	return func(s *Server) {
+		s.h2c = true
+	}
+}
+
 // Logger with server logger.
 func Logger(logger log.Logger) ServerOption {
 	return func(s *Server) {
@@ -72,6 +167,33 @@ func Middleware(m middleware.Middleware) ServerOption {
 	}
 }
 
+// RequestDecoder with request decoder option, the default decodes the body by the codec
+// of the Content-Type header.
+func RequestDecoder(fn DecodeRequestFunc) ServerOption {
+	return func(s *Server) {
+		s.requestDecoder = fn
+	}
+}
+
+// ResponseEncoder with response encoder option, the default encodes the reply by the codec
+// of the Accept header, or json if none is supported.
+func ResponseEncoder(fn EncodeResponseFunc) ServerOption {
+	return func(s *Server) {
+		s.responseEncoder = fn
+	}
+}
+
+// Health with the health registry reported by the /healthz and /readyz probes,
+// the probes are not served without the health registry.
+func Health(h *health.Health) ServerOption {
+	if h == nil {
+		panic("http: nil health registry")
+	}
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
 // ErrorEncoder with error handler option.
 func ErrorEncoder(fn EncodeErrorFunc) ServerOption {
 	return func(s *Server) {
@@ -83,15 +205,38 @@ func ErrorEncoder(fn EncodeErrorFunc) ServerOption {
 type Server struct {
 	*http.Server
 	lis             net.Listener
+	endpoint        string
 	network         string
 	address         string
+	hostOpts        []host.Option
+	advertised      string
 	timeout         time.Duration
+	tlsConf         *tls.Config
+	clientCAs       *x509.CertPool
+	err             error
+	h2c             bool
+	timeouts        *timeout.Table
 	middleware      middleware.Middleware
 	requestDecoder  DecodeRequestFunc
 	responseEncoder EncodeResponseFunc
 	errorEncoder    EncodeErrorFunc
 	router          *mux.Router
+	routes          map[*mux.Route]bool
+	filters         []FilterFunc
+	handler         http.Handler
+	health          *health.Health
+	openapi         []byte
+	swaggerCSS      SwaggerUIAsset
+	swaggerJS       SwaggerUIAsset
+	swaggerFS       http.FileSystem
 	log             *log.Helper
+
+	wsCheckOrigin func(*http.Request) bool
+	wsCtx         context.Context
+	wsCancel      context.CancelFunc
+	wsMu          sync.Mutex
+	wsConns       map[*WebSocketConn]struct{}
+	wsWG          sync.WaitGroup
 }
 
 // NewServer creates a HTTP server by options.
@@ -103,20 +248,36 @@ func NewServer(opts ...ServerOption) *Server {
 		requestDecoder:  defaultRequestDecoder,
 		responseEncoder: defaultResponseEncoder,
 		errorEncoder:    defaultErrorEncoder,
-		middleware:      recovery.Recovery(),
-		log:             log.NewHelper(loggerName, log.DefaultLogger),
+		middleware:      middleware.Named("recovery", recovery.Recovery()),
+		log:             log.NewHelper(loggerName, log.GetLogger()),
 	}
 	for _, o := range opts {
 		o(srv)
 	}
+	if srv.clientCAs != nil {
+		srv.tlsConf, srv.err = mutualTLS(srv.tlsConf, srv.clientCAs)
+	}
+	srv.wsCtx, srv.wsCancel = context.WithCancel(context.Background())
+	srv.wsConns = make(map[*WebSocketConn]struct{})
 	srv.router = mux.NewRouter()
-	srv.Server = &http.Server{Handler: srv}
+	if srv.timeouts != nil {
+		srv.router.Use(srv.operationTimeout)
+	}
+	srv.routes = make(map[*mux.Route]bool)
+	srv.handler = filterChain(srv.router, srv.filters)
+	srv.handleHealth()
+	srv.handleOpenAPI()
+	var handler http.Handler = srv
+	if srv.h2c {
+		handler = h2c.NewHandler(srv, &http2.Server{})
+	}
+	srv.Server = &http.Server{Handler: handler, TLSConfig: srv.tlsConf}
 	return srv
 }
 
-// RouteGroup .
-func (s *Server) RouteGroup(path string) *RouteGroup {
-	return &RouteGroup{root: path, router: s.router}
+// RouteGroup returns a route group with the path prefix and filters.
+func (s *Server) RouteGroup(path string, filters ...FilterFunc) *RouteGroup {
+	return &RouteGroup{root: path, router: s.router, filters: filters}
 }
 
 // Handle registers a new route with a matcher for the URL path.
@@ -129,39 +290,149 @@ func (s *Server) HandleFunc(path string, h http.HandlerFunc) {
 	s.router.HandleFunc(path, h)
 }
 
+// HandlePrefix registers a new route with a matcher for the URL path prefix.
+func (s *Server) HandlePrefix(prefix string, h http.Handler) {
+	s.router.PathPrefix(prefix).Handler(h)
+}
+
 // ServeHTTP should write reply headers and data to the ResponseWriter and then return.
 func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	ctx, cancel := context.WithTimeout(req.Context(), s.timeout)
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	// the timeouts of the operations are applied once the route is matched.
+	if s.timeouts == nil && s.timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), s.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
 	defer cancel()
-	ctx = transport.NewContext(ctx, transport.Transport{Kind: "HTTP"})
+	ctx = transport.NewServerContext(ctx, &Transport{
+		endpoint:    s.endpoint,
+		operation:   req.URL.Path,
+		request:     req,
+		reqHeader:   headerCarrier(req.Header),
+		replyHeader: headerCarrier(res.Header()),
+	})
 	ctx = NewContext(ctx, ServerInfo{Request: req, Response: res})
-	s.router.ServeHTTP(res, req.WithContext(ctx))
+	s.handler.ServeHTTP(res, req.WithContext(ctx))
+}
+
+// operationTimeout applies the timeout of the operation of the matched route, which is
+// its path template, so the router matches the request once.
+func (s *Server) operationTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		operation := req.URL.Path
+		if route := mux.CurrentRoute(req); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				operation = tpl
+			}
+		}
+		d, ok := s.timeouts.Lookup(operation)
+		if !ok {
+			d = s.timeout
+		}
+		if d > 0 {
+			ctx, cancel := context.WithTimeout(req.Context(), d)
+			defer cancel()
+			req = req.WithContext(ctx)
+		}
+		next.ServeHTTP(res, req)
+	})
 }
 
 // Endpoint return a real address to registry endpoint.
 // examples:
-//   http://127.0.0.1:8000?isSecure=false
+//   http://127.0.0.1:8000
+//   https://127.0.0.1:8000
+//   http+unix:///tmp/app.sock
 func (s *Server) Endpoint() (string, error) {
-	addr, err := host.Extract(s.address, s.lis)
+	if err := s.listen(); err != nil {
+		return "", err
+	}
+	endpoint, err := s.buildEndpoint()
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("http://%s", addr), nil
+	s.endpoint = endpoint
+	return s.endpoint, nil
+}
+
+func (s *Server) buildEndpoint() (string, error) {
+	scheme := "http"
+	if s.tlsConf != nil {
+		scheme = "https"
+	}
+	if s.advertised != "" {
+		return host.Endpoint(scheme, s.advertised, "")
+	}
+	if endpoint, ok := host.UnixEndpoint(scheme, s.lis); ok {
+		return endpoint, nil
+	}
+	addr, err := host.Extract(s.address, s.lis, s.hostOpts...)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s://%s", scheme, addr), nil
 }
 
 // Start start the HTTP server.
 func (s *Server) Start() error {
-	lis, err := net.Listen(s.network, s.address)
+	if _, err := s.Endpoint(); err != nil {
+		return err
+	}
+	s.log.Infof("[HTTP] server listening on: %s", s.lis.Addr().String())
+	var err error
+	if s.tlsConf != nil {
+		err = s.ServeTLS(s.lis, "", "")
+	} else {
+		err = s.Serve(s.lis)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listen creates the listener once, so that Endpoint reports the real port before Start.
+func (s *Server) listen() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.lis != nil {
+		return nil
+	}
+	lis, err := host.Listen(s.network, s.address)
 	if err != nil {
 		return err
 	}
 	s.lis = lis
-	s.log.Infof("[HTTP] server listening on: %s", lis.Addr().String())
-	return s.Serve(lis)
+	return nil
 }
 
 // Stop stop the HTTP server.
 func (s *Server) Stop() error {
 	s.log.Info("[HTTP] server stopping")
-	return s.Shutdown(context.Background())
+	err := s.Shutdown(context.Background())
+	// the listener is not closed by the shutdown if the server is not started.
+	if s.lis != nil {
+		_ = s.lis.Close()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*wsDrainTimeout)
+	defer cancel()
+	s.drainWebSockets(ctx)
+	return err
+}
+
+// mutualTLS returns a copy of the TLS config requiring the client certificates verified
+// by the CAs, the config of the server certificate is required.
+func mutualTLS(c *tls.Config, pool *x509.CertPool) (*tls.Config, error) {
+	if c == nil {
+		return nil, errors.New("http: ClientCAs requires the TLSConfig of the server certificate")
+	}
+	c = c.Clone()
+	c.ClientCAs = pool
+	c.ClientAuth = tls.RequireAndVerifyClientCert
+	return c, nil
 }
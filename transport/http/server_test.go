@@ -1,11 +1,22 @@
 package http
 
 import (
-	"errors"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/go-kratos/kratos/v2/transport/timeout"
+
+	"golang.org/x/net/http2"
 )
 
 func TestServer(t *testing.T) {
@@ -28,7 +39,159 @@ func TestServer(t *testing.T) {
 		srv.Stop()
 	})
 
-	if err := srv.Start(); !errors.Is(err, http.ErrServerClosed) {
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerListener(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(Listener(lis))
+	srv.HandleFunc("/index", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "OK")
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "http://" + lis.Addr().String(); endpoint != expected {
+		t.Fatalf("expected %s got %s", expected, endpoint)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	res, err := http.Get(endpoint + "/index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 got %d", res.StatusCode)
+	}
+}
+
+func TestServerTimeouts(t *testing.T) {
+	srv := NewServer(Timeout(time.Second), Timeouts(timeout.New(map[string]time.Duration{
+		"/v1/users/{id}": 2 * time.Second,
+		"/v1/watch":      0,
+	})))
+	var got time.Duration
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		got = 0
+		if deadline, ok := r.Context().Deadline(); ok {
+			got = time.Until(deadline).Round(time.Second)
+		}
+	}
+	srv.HandleFunc("/v1/users/{id}", fn)
+	srv.HandleFunc("/v1/watch", fn)
+	srv.HandleFunc("/v1/other", fn)
+	tests := []struct {
+		path    string
+		timeout time.Duration
+	}{
+		{"/v1/users/1", 2 * time.Second},
+		{"/v1/watch", 0},
+		{"/v1/other", time.Second},
+	}
+	for _, test := range tests {
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.path, nil))
+		if got != test.timeout {
+			t.Errorf("%s: got %v want %v", test.path, got, test.timeout)
+		}
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	srv := NewServer(TLSConfig(&tls.Config{}))
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(endpoint, "https://") {
+		t.Fatalf("expected https endpoint, got %s", endpoint)
+	}
+	pool := x509.NewCertPool()
+	srv = NewServer(TLSConfig(&tls.Config{}), ClientCAs(pool))
+	if srv.tlsConf.ClientCAs != pool || srv.tlsConf.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("got %+v want the mutual TLS", srv.tlsConf)
+	}
+	if _, err := NewServer(ClientCAs(pool)).Endpoint(); err == nil {
+		t.Error("expected the error of the missing TLS config")
+	}
+}
+
+func TestServerH2C(t *testing.T) {
+	srv := NewServer(Address("127.0.0.1:0"), H2C())
+	srv.HandleFunc("/index", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s", r.Proto)
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	for c, expected := range map[*http.Client]string{client: "HTTP/2.0", http.DefaultClient: "HTTP/1.1"} {
+		res, err := c.Get(endpoint + "/index")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if proto := string(b); proto != expected {
+			t.Errorf("got proto %s want %s", proto, expected)
+		}
+	}
+}
+
+func TestServerUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "http.sock")
+	srv := NewServer(Address("unix://" + path))
+	srv.HandleFunc("/unix", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"host":"`+r.Host+`"}`)
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint != "http+unix://"+path {
+		t.Fatalf("got endpoint %s", endpoint)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	client, err := NewClient(context.Background(), WithEndpoint(endpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var reply map[string]string
+	if err = client.Invoke(context.Background(), "GET", "/unix", nil, &reply); err != nil {
 		t.Fatal(err)
 	}
+	if reply["host"] != "localhost" {
+		t.Errorf("unexpected reply: %v", reply)
+	}
+}
+
+func TestServerEndpoint(t *testing.T) {
+	srv := NewServer(Address("127.0.0.1:0"), Endpoint("203.0.113.10:8000"))
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.lis.Close()
+	if endpoint != "http://203.0.113.10:8000" {
+		t.Fatalf("got endpoint %s", endpoint)
+	}
 }
@@ -32,8 +32,11 @@ type ServiceRegistrar interface {
 func (s *Server) RegisterService(desc *ServiceDesc, impl interface{}) {
 	for _, m := range desc.Methods {
 		h := m.Handler
-		s.router.HandleFunc(m.Path, func(res http.ResponseWriter, req *http.Request) {
-			out, err := h(impl, req.Context(), req, func(v interface{}) error {
+		path := m.Path
+		route := s.router.HandleFunc(path, func(res http.ResponseWriter, req *http.Request) {
+			ctx := NewContext(req.Context(), ServerInfo{Request: req, Response: res, PathTemplate: path})
+			setRoute(req, path)
+			out, err := h(impl, ctx, req, func(v interface{}) error {
 				return s.requestDecoder(req, v)
 			}, s.middleware)
 			if err != nil {
@@ -44,5 +47,6 @@ func (s *Server) RegisterService(desc *ServiceDesc, impl interface{}) {
 				s.errorEncoder(res, req, err)
 			}
 		}).Methods(m.Method)
+		s.routes[route] = true
 	}
 }
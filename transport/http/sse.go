@@ -0,0 +1,173 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+)
+
+// ErrStreamingUnsupported is returned when the response writer does not support flushing.
+var ErrStreamingUnsupported = errors.New("http: streaming unsupported")
+
+// Event is a server-sent event.
+type Event struct {
+	// ID is the event id, the client reconnects with it in the Last-Event-ID header.
+	ID string
+	// Event is the event type, the client dispatches the "message" event if empty.
+	Event string
+	// Data is the event data, the strings and bytes are written as is, others
+	// are encoded by the codec of the stream.
+	Data interface{}
+	// Retry is the reconnection time of the client.
+	Retry time.Duration
+}
+
+// EventStreamOption is event stream option.
+type EventStreamOption func(*EventStream)
+
+// EventStreamCodec with the codec of the event data, json by default.
+func EventStreamCodec(c encoding.Codec) EventStreamOption {
+	return func(s *EventStream) {
+		s.codec = c
+	}
+}
+
+// EventStreamHeartbeat with the interval of the heartbeat comments, which keep the
+// idle connection through the proxies.
+func EventStreamHeartbeat(d time.Duration) EventStreamOption {
+	return func(s *EventStream) {
+		s.heartbeat = d
+	}
+}
+
+// EventStream writes the server-sent events to a response, every event is flushed
+// once it is written.
+type EventStream struct {
+	mu        sync.Mutex
+	w         http.ResponseWriter
+	flusher   http.Flusher
+	codec     encoding.Codec
+	heartbeat time.Duration
+	lastID    string
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewEventStream starts an event stream of the response, the stream must be closed
+// before the handler returns. The long-lived streams should disable the timeout of
+// the operation by the Timeouts option.
+func NewEventStream(w http.ResponseWriter, req *http.Request, opts ...EventStreamOption) (*EventStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+	s := &EventStream{
+		w:       w,
+		flusher: flusher,
+		codec:   encoding.GetCodec("json"),
+		lastID:  req.Header.Get("Last-Event-ID"),
+		done:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	if s.heartbeat > 0 {
+		go s.heartbeatLoop(req)
+	}
+	return s, nil
+}
+
+// LastEventID returns the id of the last event received by the reconnecting client, if any.
+func (s *EventStream) LastEventID() string {
+	return s.lastID
+}
+
+// Send writes the event and flushes it.
+func (s *EventStream) Send(e Event) error {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", oneLine(e.ID))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", oneLine(e.Event))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	var data []byte
+	switch v := e.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = s.codec.Marshal(v); err != nil {
+			return err
+		}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Close stops the heartbeat of the stream, no event is written after it returns.
+func (s *EventStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.once.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+func (s *EventStream) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return errors.New("http: event stream closed")
+	default:
+	}
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *EventStream) heartbeatLoop(req *http.Request) {
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.write([]byte(": heartbeat\n\n")); err != nil {
+				return
+			}
+		case <-req.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventStream(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	res := httptest.NewRecorder()
+	stream, err := NewEventStream(res, req, EventStreamHeartbeat(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := stream.LastEventID(); id != "41" {
+		t.Errorf("got last event id %s want 41", id)
+	}
+	events := []Event{
+		{ID: "42", Event: "user", Data: map[string]string{"name": "kratos"}, Retry: time.Second},
+		{Data: "line1\nline2"},
+	}
+	for _, e := range events {
+		if err := stream.Send(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	stream.Close()
+	if err := stream.Send(Event{Data: "closed"}); err == nil {
+		t.Error("expected an error of the closed stream")
+	}
+
+	if ct := res.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("got content type %s", ct)
+	}
+	body := res.Body.String()
+	expected := "id: 42\nevent: user\nretry: 1000\ndata: {\"name\":\"kratos\"}\n\ndata: line1\ndata: line2\n\n"
+	if !strings.HasPrefix(body, expected) {
+		t.Errorf("got body %q want prefix %q", body, expected)
+	}
+	if !strings.Contains(body, ": heartbeat\n\n") {
+		t.Errorf("expected the heartbeat comments, got %q", body)
+	}
+}
+
+type noFlushWriter struct {
+	http.ResponseWriter
+}
+
+func TestEventStreamUnsupported(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	if _, err := NewEventStream(noFlushWriter{httptest.NewRecorder()}, req); err != ErrStreamingUnsupported {
+		t.Errorf("got %v want %v", err, ErrStreamingUnsupported)
+	}
+}
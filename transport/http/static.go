@@ -0,0 +1,111 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Static serves the files of the root under the path prefix, i.e. Static("/static", http.Dir("./public")),
+// the files are served with the ETag and Last-Modified headers, so that the conditional
+// requests are answered with 304 Not Modified.
+func (r *RouteGroup) Static(prefix string, root http.FileSystem) {
+	r.serveFiles(prefix, &fileHandler{root: root})
+}
+
+// SPA serves a single page application of the root under the path prefix, the paths
+// of no file fall back to /index.html, so that the application handles the routing.
+func (r *RouteGroup) SPA(prefix string, root http.FileSystem) {
+	r.serveFiles(prefix, &fileHandler{root: root, fallback: "/index.html"})
+}
+
+func (r *RouteGroup) serveFiles(prefix string, h *fileHandler) {
+	p := path.Join("/", r.root, prefix)
+	h.prefix = strings.TrimSuffix(p, "/")
+	next := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		setRoute(req, p)
+		h.ServeHTTP(res, req)
+	})
+	handler := filterChain(next, r.filters)
+	if p != "/" {
+		r.router.Handle(p, handler).Methods(http.MethodGet, http.MethodHead)
+	}
+	r.router.PathPrefix(h.prefix+"/").Handler(handler).Methods(http.MethodGet, http.MethodHead)
+}
+
+type fileHandler struct {
+	root     http.FileSystem
+	prefix   string
+	fallback string
+	// etags caches the content hashes of the files without modification time, such as the embedded files.
+	etags sync.Map
+}
+
+func (h *fileHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := path.Clean("/" + strings.TrimPrefix(req.URL.Path, h.prefix))
+	f, st, err := h.open(name)
+	if os.IsNotExist(err) && h.fallback != "" {
+		name = h.fallback
+		f, st, err = h.open(name)
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, req)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	if etag, err := h.etag(name, f, st); err == nil {
+		w.Header().Set("ETag", etag)
+	}
+	http.ServeContent(w, req, st.Name(), st.ModTime(), f)
+}
+
+// open opens the file of the name, or the index.html if it is a directory.
+func (h *fileHandler) open(name string) (http.File, os.FileInfo, error) {
+	f, err := h.root.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if st.IsDir() {
+		f.Close()
+		if name == "/index.html" || strings.HasSuffix(name, "/index.html") {
+			return nil, nil, os.ErrNotExist
+		}
+		return h.open(path.Join(name, "index.html"))
+	}
+	return f, st, nil
+}
+
+func (h *fileHandler) etag(name string, f http.File, st os.FileInfo) (string, error) {
+	if !st.ModTime().IsZero() {
+		return fmt.Sprintf(`W/"%x-%x"`, st.Size(), st.ModTime().UnixNano()), nil
+	}
+	if etag, ok := h.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	h.etags.Store(name, etag)
+	return etag, nil
+}
@@ -0,0 +1,19 @@
+//go:build go1.16
+// +build go1.16
+
+package http
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// StaticFS serves the files of the fsys under the path prefix, such as an embed.FS.
+func (r *RouteGroup) StaticFS(prefix string, fsys fs.FS) {
+	r.Static(prefix, http.FS(fsys))
+}
+
+// SPAFS serves a single page application of the fsys under the path prefix, such as an embed.FS.
+func (r *RouteGroup) SPAFS(prefix string, fsys fs.FS) {
+	r.SPA(prefix, http.FS(fsys))
+}
@@ -0,0 +1,58 @@
+//go:build go1.16
+// +build go1.16
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPAFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte("index")},
+		"js/main.js": {Data: []byte("main")},
+	}
+	srv := NewServer()
+	srv.RouteGroup("/ui").SPAFS("/", fsys)
+	srv.RouteGroup("/assets").StaticFS("/", fsys)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/ui/js/main.js", 200, "main"},
+		{"/ui/users/1", 200, "index"},
+		{"/ui", 200, "index"},
+		{"/assets/js/main.js", 200, "main"},
+		{"/assets/users/1", 404, ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if res.Code != test.code {
+			t.Errorf("%s: got code %d want %d", test.path, res.Code, test.code)
+			continue
+		}
+		if test.code == 200 && res.Body.String() != test.body {
+			t.Errorf("%s: got body %q want %q", test.path, res.Body.String(), test.body)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/ui/js/main.js", nil))
+	etag := res.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected the content ETag of the file without modification time")
+	}
+	req := httptest.NewRequest(http.MethodGet, "/ui/js/main.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	srv.ServeHTTP(res, req)
+	if res.Code != http.StatusNotModified {
+		t.Errorf("got code %d want 304", res.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatic(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "css"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "css", "main.css"), []byte("body{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer()
+	srv.RouteGroup("/").Static("/static", http.Dir(dir))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/static", 200, "index"},
+		{"/static/", 200, "index"},
+		{"/static/css/main.css", 200, "body{}"},
+		{"/static/css/", 404, ""},
+		{"/static/../server.go", 301, ""},
+		{"/staticfile", 404, ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if res.Code != test.code {
+			t.Errorf("%s: got code %d want %d", test.path, res.Code, test.code)
+			continue
+		}
+		if test.code == 200 && res.Body.String() != test.body {
+			t.Errorf("%s: got body %q want %q", test.path, res.Body.String(), test.body)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/static/css/main.css", nil))
+	etag, modified := res.Header().Get("ETag"), res.Header().Get("Last-Modified")
+	if etag == "" || modified == "" {
+		t.Fatalf("expected ETag and Last-Modified, got %q %q", etag, modified)
+	}
+	for k, v := range map[string]string{"If-None-Match": etag, "If-Modified-Since": modified} {
+		req := httptest.NewRequest(http.MethodGet, "/static/css/main.css", nil)
+		req.Header.Set(k, v)
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, req)
+		if res.Code != http.StatusNotModified {
+			t.Errorf("%s: got code %d want 304", k, res.Code)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+)
+
+// swaggerUIVersion is the pinned version of the default swagger-ui-dist assets.
+const swaggerUIVersion = "3.52.5"
+
+// swaggerUI is the Swagger UI page of the OpenAPI document.
+var swaggerUI = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Swagger UI</title>
+  <link rel="stylesheet" href="{{.CSS.URL}}"{{with .CSS.Integrity}} integrity="{{.}}" crossorigin="anonymous"{{end}}>
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.JS.URL}}"{{with .JS.Integrity}} integrity="{{.}}" crossorigin="anonymous"{{end}}></script>
+  <script>
+    window.onload = function() {
+      window.ui = SwaggerUIBundle({url: "../openapi.json", dom_id: "#swagger-ui", deepLinking: true});
+    };
+  </script>
+</body>
+</html>
+`))
+
+// SwaggerUIAsset is the stylesheet or the script of the Swagger UI, the browsers verify
+// the asset by its subresource integrity, i.e. sha384-..., if it is set.
+type SwaggerUIAsset struct {
+	URL       string
+	Integrity string
+}
+
+// SwaggerUI with the stylesheet and the script of the Swagger UI, i.e. the assets served
+// by the service itself or the CDN assets of their integrity, the assets of SwaggerUIFS,
+// or else of the pinned swagger-ui-dist version on unpkg.com by default.
+func SwaggerUI(css, js SwaggerUIAsset) ServerOption {
+	return func(s *Server) {
+		s.swaggerCSS, s.swaggerJS = css, js
+	}
+}
+
+// OpenAPI with the OpenAPI document generated by protoc-gen-openapi, which is served
+// at /q/openapi.json along with the Swagger UI at /q/swagger/.
+func OpenAPI(spec []byte) ServerOption {
+	return func(s *Server) {
+		s.openapi = spec
+	}
+}
+
+// handleOpenAPI registers the OpenAPI document and the Swagger UI if the document is set.
+func (s *Server) handleOpenAPI() {
+	if s.openapi == nil {
+		return
+	}
+	if s.swaggerFS != nil {
+		if s.swaggerCSS.URL == "" {
+			s.swaggerCSS.URL = "swagger-ui.css"
+		}
+		if s.swaggerJS.URL == "" {
+			s.swaggerJS.URL = "swagger-ui-bundle.js"
+		}
+	}
+	if s.swaggerCSS.URL == "" {
+		s.swaggerCSS.URL = "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion + "/swagger-ui.css"
+	}
+	if s.swaggerJS.URL == "" {
+		s.swaggerJS.URL = "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion + "/swagger-ui-bundle.js"
+	}
+	var page bytes.Buffer
+	if err := swaggerUI.Execute(&page, struct{ CSS, JS SwaggerUIAsset }{s.swaggerCSS, s.swaggerJS}); err != nil {
+		panic(err)
+	}
+	s.router.HandleFunc("/q/openapi.json", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(s.openapi)
+	}).Methods("GET", "HEAD")
+	s.router.Handle("/q/swagger", http.RedirectHandler("/q/swagger/", http.StatusMovedPermanently)).Methods("GET", "HEAD")
+	s.router.HandleFunc("/q/swagger/", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		res.Write(page.Bytes())
+	}).Methods("GET", "HEAD")
+	if s.swaggerFS != nil {
+		s.router.PathPrefix("/q/swagger/").Handler(&fileHandler{root: s.swaggerFS, prefix: "/q/swagger"}).Methods("GET", "HEAD")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build go1.16
+// +build go1.16
+
+package http
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// SwaggerUIFS with the swagger-ui-dist assets of the fsys, such as an embed.FS, which
+// are served under /q/swagger/ instead of the CDN assets, i.e. for the air-gapped
+// environments. The fsys has the swagger-ui.css and the swagger-ui-bundle.js files:
+//
+//	//go:embed swagger-ui.css swagger-ui-bundle.js
+//	var swaggerUI embed.FS
+//
+//	srv := http.NewServer(http.OpenAPI(spec), http.SwaggerUIFS(swaggerUI))
+func SwaggerUIFS(fsys fs.FS) ServerOption {
+	return func(s *Server) {
+		s.swaggerFS = http.FS(fsys)
+	}
+}
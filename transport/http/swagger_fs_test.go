@@ -0,0 +1,45 @@
+//go:build go1.16
+// +build go1.16
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSwaggerUIFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"swagger-ui.css":       {Data: []byte("css")},
+		"swagger-ui-bundle.js": {Data: []byte("js")},
+	}
+	srv := NewServer(OpenAPI([]byte(`{"openapi":"3.0.3"}`)), SwaggerUIFS(fsys))
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/q/swagger/swagger-ui.css", 200, "css"},
+		{"/q/swagger/swagger-ui-bundle.js", 200, "js"},
+		{"/q/swagger/missing.js", 404, ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if res.Code != test.code {
+			t.Errorf("%s: got code %d want %d", test.path, res.Code, test.code)
+			continue
+		}
+		if test.code == 200 && res.Body.String() != test.body {
+			t.Errorf("%s: got body %q want %q", test.path, res.Body.String(), test.body)
+		}
+	}
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/q/swagger/", nil))
+	if body := res.Body.String(); !strings.Contains(body, `href="swagger-ui.css"`) || !strings.Contains(body, `src="swagger-ui-bundle.js"`) || strings.Contains(body, "unpkg.com") {
+		t.Errorf("swagger: got %s want the assets of the fsys", body)
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	spec := []byte(`{"openapi":"3.0.3"}`)
+	srv := NewServer(OpenAPI(spec))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		return res
+	}
+	res := get("/q/openapi.json")
+	if res.Code != http.StatusOK || res.Body.String() != string(spec) {
+		t.Errorf("openapi: got %d %s", res.Code, res.Body.String())
+	}
+	if res := get("/q/swagger"); res.Code != http.StatusMovedPermanently || res.Header().Get("Location") != "/q/swagger/" {
+		t.Errorf("swagger: got %d %s", res.Code, res.Header().Get("Location"))
+	}
+	res = get("/q/swagger/")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "../openapi.json") {
+		t.Errorf("swagger: got %d %s", res.Code, res.Body.String())
+	}
+	if body := res.Body.String(); !strings.Contains(body, "swagger-ui-dist@"+swaggerUIVersion+"/") || strings.Contains(body, "integrity") {
+		t.Errorf("swagger: got %s want the pinned assets", body)
+	}
+
+	srv = NewServer(OpenAPI(spec), SwaggerUI(
+		SwaggerUIAsset{URL: "/static/swagger-ui.css"},
+		SwaggerUIAsset{URL: "https://cdn.example.com/swagger-ui-bundle.js", Integrity: "sha384-abc"},
+	))
+	body := get("/q/swagger/").Body.String()
+	if !strings.Contains(body, `href="/static/swagger-ui.css">`) ||
+		!strings.Contains(body, `src="https://cdn.example.com/swagger-ui-bundle.js" integrity="sha384-abc" crossorigin="anonymous">`) {
+		t.Errorf("swagger: got %s want the assets of the integrity", body)
+	}
+
+	res = httptest.NewRecorder()
+	NewServer().ServeHTTP(res, httptest.NewRequest("GET", "/q/openapi.json", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("openapi without document: got %d want 404", res.Code)
+	}
+}
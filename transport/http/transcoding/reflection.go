@@ -0,0 +1,103 @@
+package transcoding
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ReflectionFiles loads the files of all the services from the server reflection
+// of the connection, including their dependencies.
+func ReflectionFiles(ctx context.Context, conn grpc.ClientConnInterface) (*protoregistry.Files, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+	r := &reflectionLoader{
+		stream: stream,
+		files:  make(map[string]*descriptorpb.FileDescriptorProto),
+	}
+	res, err := r.call(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range res.GetListServicesResponse().GetService() {
+		if err := r.load(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: s.Name},
+		}); err != nil {
+			return nil, err
+		}
+	}
+	// fetch the dependencies which are not returned along with the service files.
+	for missing := r.missing(); len(missing) > 0; missing = r.missing() {
+		for _, name := range missing {
+			if err := r.load(&rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range r.files {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
+
+type reflectionLoader struct {
+	stream rpb.ServerReflection_ServerReflectionInfoClient
+	files  map[string]*descriptorpb.FileDescriptorProto
+}
+
+func (r *reflectionLoader) call(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := r.stream.Send(req); err != nil {
+		return nil, err
+	}
+	res, err := r.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := res.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("transcoding: reflection error %d: %s", e.ErrorCode, e.ErrorMessage)
+	}
+	return res, nil
+}
+
+func (r *reflectionLoader) load(req *rpb.ServerReflectionRequest) error {
+	res, err := r.call(req)
+	if err != nil {
+		return err
+	}
+	for _, b := range res.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(b, fd); err != nil {
+			return err
+		}
+		r.files[fd.GetName()] = fd
+	}
+	return nil
+}
+
+func (r *reflectionLoader) missing() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, fd := range r.files {
+		for _, dep := range fd.Dependency {
+			if _, ok := r.files[dep]; !ok && !seen[dep] {
+				seen[dep] = true
+				names = append(names, dep)
+			}
+		}
+	}
+	return names
+}
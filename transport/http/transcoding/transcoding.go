@@ -0,0 +1,346 @@
+// Package transcoding translates the HTTP/JSON requests into the gRPC unary calls at
+// runtime by the google.api.http annotations of the service descriptors, so that the
+// services can be called by REST without the generated HTTP stubs:
+//
+//	conn, _ := grpc.DialInsecure(ctx, grpc.WithEndpoint("127.0.0.1:9000"))
+//	h, _ := transcoding.NewHandler(conn)
+//	srv := http.NewServer()
+//	srv.HandlePrefix("/", h)
+package transcoding
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/encoding/form"
+	transhttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Option is transcoding option.
+type Option func(*options)
+
+// WithFiles with the files of the service descriptors, protoregistry.GlobalFiles by
+// default, the files can be loaded from the server reflection by ReflectionFiles.
+func WithFiles(files *protoregistry.Files) Option {
+	return func(o *options) {
+		o.files = files
+	}
+}
+
+// WithServices with the full names of the transcoded services, i.e. helloworld.Greeter,
+// all the services with the http annotations by default.
+func WithServices(services ...string) Option {
+	return func(o *options) {
+		o.services = services
+	}
+}
+
+type options struct {
+	files    *protoregistry.Files
+	services []string
+}
+
+func (o *options) hasService(name protoreflect.FullName) bool {
+	if len(o.services) == 0 {
+		return true
+	}
+	for _, s := range o.services {
+		if protoreflect.FullName(s) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler is a HTTP handler of the transcoded methods.
+type Handler struct {
+	conn   grpc.ClientConnInterface
+	router *mux.Router
+	codec  encoding.Codec
+}
+
+// NewHandler returns a handler which calls the methods on the connection.
+func NewHandler(conn grpc.ClientConnInterface, opts ...Option) (*Handler, error) {
+	options := options{
+		files: protoregistry.GlobalFiles,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	h := &Handler{
+		conn:   conn,
+		router: mux.NewRouter(),
+		codec:  encoding.GetCodec("json"),
+	}
+	var err error
+	options.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			sd := services.Get(i)
+			if !options.hasService(sd.FullName()) {
+				continue
+			}
+			if err = h.registerService(sd); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *Handler) registerService(sd protoreflect.ServiceDescriptor) error {
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		if md.IsStreamingClient() || md.IsStreamingServer() {
+			continue
+		}
+		rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		fullMethod := fmt.Sprintf("/%s/%s", sd.FullName(), md.Name())
+		for _, r := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+			method, template := httpPattern(r)
+			if method == "" {
+				continue
+			}
+			path, err := routePath(template)
+			if err != nil {
+				return fmt.Errorf("transcoding: %s: %v", fullMethod, err)
+			}
+			h.router.Handle(path, &methodHandler{
+				Handler:      h,
+				fullMethod:   fullMethod,
+				desc:         md,
+				body:         r.Body,
+				responseBody: r.ResponseBody,
+			}).Methods(method)
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the request by the method of the matched route.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.router.ServeHTTP(w, req)
+}
+
+type methodHandler struct {
+	*Handler
+	fullMethod   string
+	desc         protoreflect.MethodDescriptor
+	body         string
+	responseBody string
+}
+
+func (h *methodHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	in := dynamicpb.NewMessage(h.desc.Input())
+	if err := h.decode(req, in); err != nil {
+		h.encodeError(w, err)
+		return
+	}
+	out := dynamicpb.NewMessage(h.desc.Output())
+	var header metadata.MD
+	ctx := metadata.NewOutgoingContext(req.Context(), outgoingMetadata(req.Header))
+	if err := h.conn.Invoke(ctx, h.fullMethod, in, out, grpc.Header(&header)); err != nil {
+		h.encodeError(w, err)
+		return
+	}
+	var reply interface{} = out
+	if h.responseBody != "" {
+		fd := h.desc.Output().Fields().ByName(protoreflect.Name(h.responseBody))
+		if fd == nil || fd.Message() == nil {
+			h.encodeError(w, fmt.Errorf("transcoding: invalid response body %q", h.responseBody))
+			return
+		}
+		reply = out.Get(fd).Message().Interface()
+	}
+	data, err := h.codec.Marshal(reply)
+	if err != nil {
+		h.encodeError(w, err)
+		return
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// decode populates the message by the body, the path variables and the query parameters.
+func (h *methodHandler) decode(req *http.Request, in *dynamicpb.Message) error {
+	if h.body != "" {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 {
+			var target proto.Message = in
+			if h.body != "*" {
+				fd := in.Descriptor().Fields().ByName(protoreflect.Name(h.body))
+				if fd == nil || fd.Message() == nil {
+					return fmt.Errorf("transcoding: invalid body %q", h.body)
+				}
+				target = in.Mutable(fd).Message().Interface()
+			}
+			if err := h.codec.Unmarshal(data, target); err != nil {
+				return err
+			}
+		}
+	}
+	if h.body != "*" {
+		if err := form.DecodeValues(in, req.URL.Query()); err != nil {
+			return err
+		}
+	}
+	vars := make(url.Values)
+	for k, v := range mux.Vars(req) {
+		if !strings.HasPrefix(k, "_") {
+			vars.Set(k, v)
+		}
+	}
+	return form.DecodeValues(in, vars)
+}
+
+func (h *methodHandler) encodeError(w http.ResponseWriter, err error) {
+	code, se := transhttp.StatusError(err)
+	data, err := h.codec.Marshal(se)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// outgoingMetadata forwards the request headers except the connection specific ones.
+func outgoingMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, vs := range header {
+		switch strings.ToLower(k) {
+		case "connection", "content-length", "content-type", "host", "keep-alive", "proxy-connection",
+			"te", "trailer", "transfer-encoding", "upgrade", "accept-encoding":
+			continue
+		}
+		md.Append(k, vs...)
+	}
+	return md
+}
+
+func httpPattern(r *annotations.HttpRule) (method string, template string) {
+	switch p := r.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		if p.Custom != nil {
+			return p.Custom.Kind, p.Custom.Path
+		}
+	}
+	return "", ""
+}
+
+// routePath converts the path template of the http rule into a mux route path,
+// i.e. /v1/{name=shelves/*}/books/* into /v1/{name:shelves/[^/]+}/books/{_1:[^/]+}.
+func routePath(template string) (string, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", fmt.Errorf("invalid path template %q", template)
+	}
+	var (
+		b      strings.Builder
+		anon   int
+		suffix string
+	)
+	// the verb, i.e. :cancel, follows the last segment.
+	if i := strings.LastIndex(template, ":"); i > strings.LastIndex(template, "}") && i > strings.LastIndex(template, "/") {
+		template, suffix = template[:i], regexp.QuoteMeta(template[i:])
+	}
+	for _, seg := range splitSegments(template[1:]) {
+		b.WriteByte('/')
+		switch {
+		case strings.HasPrefix(seg, "{"):
+			if !strings.HasSuffix(seg, "}") {
+				return "", fmt.Errorf("invalid path template %q", template)
+			}
+			field, pattern := seg[1:len(seg)-1], "*"
+			if i := strings.Index(field, "="); i >= 0 {
+				field, pattern = field[:i], field[i+1:]
+			}
+			b.WriteString("{" + field + ":" + segmentsRegexp(pattern) + "}")
+		case seg == "*" || seg == "**":
+			anon++
+			b.WriteString("{_" + strconv.Itoa(anon) + ":" + segmentsRegexp(seg) + "}")
+		default:
+			b.WriteString(seg)
+		}
+	}
+	return b.String() + suffix, nil
+}
+
+// splitSegments splits the path by slashes outside of the variables.
+func splitSegments(path string) []string {
+	var (
+		segs  []string
+		depth int
+		start int
+	)
+	for i, c := range path {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 {
+				segs = append(segs, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segs, path[start:])
+}
+
+func segmentsRegexp(pattern string) string {
+	segs := strings.Split(pattern, "/")
+	for i, s := range segs {
+		switch s {
+		case "*":
+			segs[i] = "[^/]+"
+		case "**":
+			segs[i] = ".+"
+		default:
+			segs[i] = regexp.QuoteMeta(s)
+		}
+	}
+	return strings.Join(segs, "/")
+}
@@ -0,0 +1,177 @@
+package transcoding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	_ "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// testFiles annotates the grpc.health.v1.Health service with the http rules.
+func testFiles(t *testing.T) *protoregistry.Files {
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/health"},
+		AdditionalBindings: []*annotations.HttpRule{
+			{Pattern: &annotations.HttpRule_Get{Get: "/v1/health/{service}"}},
+			{Pattern: &annotations.HttpRule_Post{Post: "/v1/health:check"}, Body: "*"},
+		},
+	})
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("transcoding/health.proto"),
+		Package:    proto.String("grpc.health.v1"),
+		Dependency: []string{"grpc/health/v1/health.proto", "google/api/annotations.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Health"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Check"),
+				InputType:  proto.String(".grpc.health.v1.HealthCheckRequest"),
+				OutputType: proto.String(".grpc.health.v1.HealthCheckResponse"),
+				Options:    opts,
+			}},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestRoutePath(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+	}{
+		{"/v1/health", "/v1/health"},
+		{"/v1/{name}", "/v1/{name:[^/]+}"},
+		{"/v1/{name=shelves/*}/books/*", "/v1/{name:shelves/[^/]+}/books/{_1:[^/]+}"},
+		{"/v1/{name=files/**}", "/v1/{name:files/.+}"},
+		{"/v1/{name}:cancel", "/v1/{name:[^/]+}:cancel"},
+	}
+	for _, test := range tests {
+		path, err := routePath(test.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != test.path {
+			t.Errorf("%s: got %s want %s", test.template, path, test.path)
+		}
+	}
+	if _, err := routePath("v1/health"); err == nil {
+		t.Error("expected an error of the relative template")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	srv := grpc.NewServer(grpc.Address("127.0.0.1:0"))
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	ctx := context.Background()
+	conn, err := grpc.DialInsecure(ctx, grpc.WithEndpoint(u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h, err := NewHandler(conn, WithFiles(testFiles(t)), WithServices("grpc.health.v1.Health"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		status string
+	}{
+		{http.MethodGet, "/v1/health", http.StatusOK, "SERVING"},
+		{http.MethodGet, "/v1/health?service=", http.StatusOK, "SERVING"},
+		{http.MethodGet, "/v1/health/unknown", http.StatusNotFound, ""},
+		{http.MethodPost, "/v1/health:check", http.StatusOK, "SERVING"},
+		{http.MethodDelete, "/v1/health", http.StatusMethodNotAllowed, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s %s: got %d want %d: %s", test.method, test.path, w.Code, test.code, w.Body)
+			continue
+		}
+		if test.status == "" {
+			continue
+		}
+		var reply struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Status != test.status {
+			t.Errorf("%s %s: got %s want %s", test.method, test.path, reply.Status, test.status)
+		}
+	}
+}
+
+func TestReflectionFiles(t *testing.T) {
+	srv := grpc.NewServer(grpc.Address("127.0.0.1:0"))
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	ctx := context.Background()
+	conn, err := grpc.DialInsecure(ctx, grpc.WithEndpoint(u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	files, err := ReflectionFiles(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := files.FindDescriptorByName("grpc.health.v1.Health"); err != nil {
+		t.Fatal(err)
+	}
+	// the services without the http rules are not transcoded.
+	h, err := NewHandler(conn, WithFiles(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/health", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got %d want %d", w.Code, http.StatusNotFound)
+	}
+}
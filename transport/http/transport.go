@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is an HTTP transport.
+type Transport struct {
+	endpoint     string
+	operation    string
+	pathTemplate string
+	request      *http.Request
+	reqHeader    headerCarrier
+	replyHeader  headerCarrier
+	// mu guards the reply header of the client calls, which can be concurrent.
+	mu sync.Mutex
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindHTTP
+}
+
+// Endpoint returns the transport endpoint.
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+// Operation returns the transport operation.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// Request returns the HTTP request.
+func (tr *Transport) Request() *http.Request {
+	return tr.request
+}
+
+// PathTemplate returns the route path of the registered service method, i.e. /v1/users/{id}.
+func (tr *Transport) PathTemplate() string {
+	return tr.pathTemplate
+}
+
+// RequestHeader returns the request header.
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+// ReplyHeader returns the reply header.
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+type headerCarrier http.Header
+
+// Get returns the value associated with the passed key.
+func (hc headerCarrier) Get(key string) string {
+	return http.Header(hc).Get(key)
+}
+
+// Set stores the key-value pair.
+func (hc headerCarrier) Set(key string, value string) {
+	http.Header(hc).Set(key, value)
+}
+
+// Keys lists the keys stored in this carrier.
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestTransport(t *testing.T) {
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok || tr.Kind() != transport.KindHTTP || tr.Operation() != "/v1/users/{id}" {
+				t.Fatalf("expected http transport, got %v", tr)
+			}
+			ht := tr.(*Transport)
+			if ht.PathTemplate() != "/v1/users/{id}" || Vars(ht.Request())["id"] != "1" {
+				t.Errorf("unexpected request: %s %v", ht.PathTemplate(), Vars(ht.Request()))
+			}
+			if v := tr.RequestHeader().Get("X-Md-Uid"); v != "1" {
+				t.Errorf("expected request header, got %q", v)
+			}
+			tr.ReplyHeader().Set("X-Md-Reply", "2")
+			return handler(ctx, req)
+		}
+	}
+	srv := NewServer(Middleware(m))
+	srv.RegisterService(&ServiceDesc{
+		ServiceName: "test.Users",
+		Methods: []MethodDesc{{
+			Path:   "/v1/users/{id}",
+			Method: "GET",
+			Handler: func(srv interface{}, ctx context.Context, req *http.Request, dec func(interface{}) error, m middleware.Middleware) (interface{}, error) {
+				return m(func(ctx context.Context, req interface{}) (interface{}, error) {
+					return map[string]string{"id": "1"}, nil
+				})(ctx, nil)
+			},
+		}},
+	}, nil)
+	req := httptest.NewRequest("GET", "/v1/users/1", nil)
+	req.Header.Set("X-Md-Uid", "1")
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", res.Code)
+	}
+	if v := res.Header().Get("X-Md-Reply"); v != "2" {
+		t.Errorf("expected reply header, got %q", v)
+	}
+}
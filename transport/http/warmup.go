@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/internal/host"
+)
+
+// Warmup returns a warmup func of the app which sends the GET requests of the paths to
+// the server itself, so that the hot paths are run before the instance is registered.
+// The requests are sent to the listener rather than the advertised endpoint, which may
+// be a load balancer. It fails if any request fails or replies a server error.
+func Warmup(srv *Server, paths ...string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := srv.listen(); err != nil {
+			return err
+		}
+		network, address := host.Dialable(srv.lis)
+		scheme, authority := "http", address
+		if srv.tlsConf != nil {
+			scheme = "https"
+		}
+		if network == "unix" {
+			authority = "localhost"
+		}
+		endpoint := scheme + "://" + authority
+		var d net.Dialer
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return d.DialContext(ctx, network, address)
+			},
+			// the certificate of the server may not be issued for its address.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}}
+		defer client.CloseIdleConnections()
+		for _, path := range paths {
+			if !strings.HasPrefix(path, "/") {
+				path = "/" + path
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path, nil)
+			if err != nil {
+				return err
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+			if res.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("http: warmup %s replied %d", path, res.StatusCode)
+			}
+		}
+		return nil
+	}
+}
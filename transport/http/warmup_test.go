@@ -0,0 +1,42 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestWarmup(t *testing.T) {
+	// the advertised endpoint of a load balancer is not dialed by the warmup.
+	srv := NewServer(Endpoint("203.0.113.10:443"))
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	srv.HandleFunc("/cache", func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		paths = append(paths, req.URL.Path)
+		mu.Unlock()
+	})
+	srv.HandleFunc("/fail", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	if _, err := srv.Endpoint(); err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	if err := Warmup(srv, "cache", "/cache")(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != "/cache" {
+		t.Errorf("unexpected warmup requests: %v", paths)
+	}
+	if err := Warmup(srv, "/fail")(context.Background()); err == nil {
+		t.Error("want an error of the server error")
+	}
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsDrainTimeout is how long the server waits for the websocket handlers to return after
+// the close messages are sent on Stop, the connections are closed forcibly after it.
+const wsDrainTimeout = 5 * time.Second
+
+// WebSocketHandler handles a websocket connection, the connection is closed when it returns.
+type WebSocketHandler func(conn *WebSocketConn) error
+
+// WebSocketCheckOrigin with the origin check of the websocket upgrade requests,
+// the default rejects the cross-origin requests.
+func WebSocketCheckOrigin(fn func(req *http.Request) bool) ServerOption {
+	return func(s *Server) {
+		s.wsCheckOrigin = fn
+	}
+}
+
+// WebSocketConn is a websocket connection, the messages are encoded by the codec of
+// the negotiated subprotocol, i.e. "json" or "proto", or json by default.
+type WebSocketConn struct {
+	ctx   context.Context
+	conn  *websocket.Conn
+	codec encoding.Codec
+	mu    sync.Mutex
+}
+
+// Context returns the context of the connection, which carries the values of the
+// upgrade request and is done when the server is stopping.
+func (c *WebSocketConn) Context() context.Context {
+	return c.ctx
+}
+
+// Codec returns the codec of the messages.
+func (c *WebSocketConn) Codec() encoding.Codec {
+	return c.codec
+}
+
+// ReadMessage reads a message and decodes it into v.
+func (c *WebSocketConn) ReadMessage(v interface{}) error {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(data, v)
+}
+
+// WriteMessage encodes v and writes it as a message, it is safe for concurrent use.
+func (c *WebSocketConn) WriteMessage(v interface{}) error {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	messageType := websocket.TextMessage
+	if c.codec.Name() == "proto" {
+		messageType = websocket.BinaryMessage
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func (c *WebSocketConn) close(code int, text string) {
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+}
+
+// HandleWebSocket registers a websocket handler for the URL path, the server middleware
+// runs on the upgrade request, so that the errors such as unauthorized are replied
+// before the connection is upgraded.
+func (s *Server) HandleWebSocket(path string, h WebSocketHandler) {
+	route := s.router.HandleFunc(path, func(res http.ResponseWriter, req *http.Request) {
+		setRoute(req, path)
+		var upgraded bool
+		next := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			upgraded = true
+			return nil, s.serveWebSocket(res, req.WithContext(ctx), h)
+		}
+		if s.middleware != nil {
+			next = s.middleware(next)
+		}
+		if _, err := next(req.Context(), req); err != nil && !upgraded {
+			s.errorEncoder(res, req, err)
+		}
+	}).Methods(http.MethodGet)
+	s.routes[route] = true
+}
+
+func (s *Server) serveWebSocket(res http.ResponseWriter, req *http.Request, h WebSocketHandler) error {
+	codec := encoding.GetCodec("json")
+	upgrader := websocket.Upgrader{CheckOrigin: s.wsCheckOrigin}
+	for _, p := range websocket.Subprotocols(req) {
+		if c := encoding.GetCodec(p); c != nil {
+			codec = c
+			upgrader.Subprotocols = []string{p}
+			break
+		}
+	}
+	// the upgrader replies the errors itself.
+	conn, err := upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		return err
+	}
+	wc := &WebSocketConn{
+		ctx:   &wsContext{Context: s.wsCtx, values: req.Context()},
+		conn:  conn,
+		codec: codec,
+	}
+	if !s.addWebSocket(wc) {
+		wc.close(websocket.CloseGoingAway, "server stopping")
+		return conn.Close()
+	}
+	defer s.removeWebSocket(wc)
+	if err = h(wc); err != nil {
+		wc.close(websocket.CloseInternalServerErr, err.Error())
+	} else {
+		wc.close(websocket.CloseNormalClosure, "")
+	}
+	conn.Close()
+	return err
+}
+
+func (s *Server) addWebSocket(c *WebSocketConn) bool {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	if s.wsCtx.Err() != nil {
+		return false
+	}
+	s.wsConns[c] = struct{}{}
+	s.wsWG.Add(1)
+	return true
+}
+
+func (s *Server) removeWebSocket(c *WebSocketConn) {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	delete(s.wsConns, c)
+	s.wsWG.Done()
+}
+
+// drainWebSockets sends the close messages to the websocket connections and waits for
+// the handlers to return, the connections are closed after the drain timeout and the
+// wait gives up once the ctx is done.
+func (s *Server) drainWebSockets(ctx context.Context) {
+	s.wsMu.Lock()
+	s.wsCancel()
+	conns := make([]*WebSocketConn, 0, len(s.wsConns))
+	for c := range s.wsConns {
+		conns = append(conns, c)
+	}
+	s.wsMu.Unlock()
+	for _, c := range conns {
+		c.close(websocket.CloseGoingAway, "server stopping")
+	}
+	done := make(chan struct{})
+	go func() {
+		s.wsWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return
+	case <-time.After(wsDrainTimeout):
+	case <-ctx.Done():
+	}
+	for _, c := range conns {
+		c.conn.Close()
+	}
+	// the handlers blocked on anything but the connection are left behind.
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// wsContext is done when the server is stopping, instead of the deadline of the
+// upgrade request, and carries the values of the upgrade request.
+type wsContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c *wsContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
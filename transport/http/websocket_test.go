@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	auth := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if req.(*http.Request).Header.Get("Authorization") == "" {
+				return nil, errors.Unauthorized("WEBSOCKET", "missing token")
+			}
+			return handler(ctx, req)
+		}
+	}
+	srv := NewServer(Address("127.0.0.1:0"), Middleware(auth))
+	srv.HandleWebSocket("/echo", func(conn *WebSocketConn) error {
+		for {
+			var msg map[string]string
+			if err := conn.ReadMessage(&msg); err != nil {
+				return nil
+			}
+			if conn.Context().Err() != nil {
+				t.Error("unexpected done context of the connection")
+			}
+			if err := conn.WriteMessage(msg); err != nil {
+				return err
+			}
+		}
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	url := "ws" + strings.TrimPrefix(endpoint, "http") + "/echo"
+
+	if _, res, err := websocket.DefaultDialer.Dial(url, nil); err == nil || res == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+
+	header := http.Header{"Authorization": {"token"}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// wait for the handler's message loop while the request timeout elapses.
+	time.Sleep(1100 * time.Millisecond)
+	if err := conn.WriteJSON(map[string]string{"message": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	var reply map[string]string
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply["message"] != "hello" {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+
+	// the connection is closed with going away on stop
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		done <- err
+	}()
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+			t.Fatalf("expected close error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the connection is not closed on stop")
+	}
+}
+
+func TestWebSocketDrain(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := NewServer(Address("127.0.0.1:0"))
+	started := make(chan struct{})
+	// the handler ignores both the close message and the connection.
+	srv.HandleWebSocket("/block", func(conn *WebSocketConn) error {
+		close(started)
+		<-release
+		return nil
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(endpoint, "http")+"/block", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	<-started
+	_ = srv.Shutdown(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		srv.drainWebSockets(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the drain is not given up once the context is done")
+	}
+}
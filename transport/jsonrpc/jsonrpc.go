@@ -0,0 +1,88 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+// Version is the version of the JSON-RPC messages.
+const Version = "2.0"
+
+// The error codes defined by JSON-RPC 2.0.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error is the error object of the responses, the kratos errors are mapped to
+// their code and message, with the reason and the metadata in the data.
+type Error struct {
+	Code    int32      `json:"code"`
+	Message string     `json:"message"`
+	Data    *ErrorData `json:"data,omitempty"`
+}
+
+// ErrorData is the data of the error object of a kratos error.
+type ErrorData struct {
+	Reason   string            `json:"reason,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FromError converts the error to the error object, the handlers may return an
+// error object of their own code, and the others than the kratos errors are unknown.
+func FromError(err error) *Error {
+	if e := new(Error); errors.As(err, &e) {
+		return e
+	}
+	se, ok := kerrors.FromError(err)
+	if !ok {
+		se = &kerrors.StatusError{
+			Code:    2,
+			Reason:  "Unknown",
+			Message: "Unknown: " + err.Error(),
+		}
+	}
+	e := &Error{Code: se.Code, Message: se.Message}
+	if se.Reason != "" || len(se.Metadata) > 0 {
+		e.Data = &ErrorData{Reason: se.Reason, Metadata: se.Metadata}
+	}
+	return e
+}
+
+// request is a request object, the notifications have no id.
+type request struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// response is a response object.
+type response struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+var null = json.RawMessage("null")
+
+func errorResponse(id json.RawMessage, code int32, message string) *response {
+	if len(id) == 0 {
+		id = null
+	}
+	return &response{
+		Version: Version,
+		Error:   &Error{Code: code, Message: message},
+		ID:      id,
+	}
+}
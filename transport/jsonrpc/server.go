@@ -0,0 +1,460 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+)
+
+const loggerName = "transport/jsonrpc"
+
+var (
+	_ transport.Server      = (*Server)(nil)
+	_ transport.Inspector   = (*Server)(nil)
+	_ grpc.ServiceRegistrar = (*Server)(nil)
+)
+
+var errNotificationsOnly = errors.New("jsonrpc: notifications only")
+
+// HandlerFunc is the handler of a JSON-RPC method, which is called with the
+// decoded params and returns the result.
+type HandlerFunc func(ctx context.Context, req interface{}) (interface{}, error)
+
+// ServerOption is JSON-RPC server option.
+type ServerOption func(*Server)
+
+// Path with the path of the JSON-RPC endpoint, /rpc by default.
+func Path(path string) ServerOption {
+	return func(s *Server) {
+		s.path = path
+	}
+}
+
+// HTTPOptions with the options of the HTTP server which serves the JSON-RPC
+// endpoint, i.e. the address and the tls config.
+func HTTPOptions(opts ...khttp.ServerOption) ServerOption {
+	return func(s *Server) {
+		s.httpOpts = append(s.httpOpts, opts...)
+	}
+}
+
+// Timeout with the timeout of each call, 1s by default.
+func Timeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// CheckOrigin with the origin check of the WebSocket upgrade requests, the
+// origin of the same host is allowed by default.
+func CheckOrigin(fn func(req *http.Request) bool) ServerOption {
+	return func(s *Server) {
+		s.checkOrigin = fn
+	}
+}
+
+// MaxMessageSize with the max bytes of a request or a batch, either the body of a
+// POST request or a WebSocket message, 1MB by default.
+func MaxMessageSize(n int64) ServerOption {
+	return func(s *Server) {
+		s.maxMessageSize = n
+	}
+}
+
+// MaxBatchSize with the max requests of a batch, 100 by default.
+func MaxBatchSize(n int) ServerOption {
+	return func(s *Server) {
+		s.maxBatchSize = n
+	}
+}
+
+// MaxConcurrency with the max messages served concurrently of a WebSocket connection,
+// 16 by default, the next message is not read until one of them is done.
+func MaxConcurrency(n int) ServerOption {
+	return func(s *Server) {
+		s.maxConcurrency = n
+	}
+}
+
+// Logger with server logger.
+func Logger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Middleware with server middleware option.
+func Middleware(m middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = m
+	}
+}
+
+type method struct {
+	operation string
+	call      func(ctx context.Context, dec func(interface{}) error) (interface{}, error)
+}
+
+// Server is a JSON-RPC 2.0 server, which serves the calls over HTTP POST and
+// WebSocket, so the clients without gRPC can call the registered services.
+type Server struct {
+	*khttp.Server
+	path        string
+	httpOpts    []khttp.ServerOption
+	timeout     time.Duration
+	checkOrigin func(req *http.Request) bool
+	middleware  middleware.Middleware
+	methods     map[string]*method
+	endpoint    string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	log         *log.Helper
+
+	maxMessageSize int64
+	maxBatchSize   int
+	maxConcurrency int
+}
+
+// NewServer creates a JSON-RPC server by options.
+func NewServer(opts ...ServerOption) *Server {
+	srv := &Server{
+		path:           "/rpc",
+		timeout:        time.Second,
+		middleware:     middleware.Named("recovery", recovery.Recovery()),
+		methods:        make(map[string]*method),
+		log:            log.NewHelper(loggerName, log.GetLogger()),
+		maxMessageSize: 1 << 20,
+		maxBatchSize:   100,
+		maxConcurrency: 16,
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	if srv.maxMessageSize <= 0 || srv.maxBatchSize <= 0 || srv.maxConcurrency <= 0 {
+		panic("jsonrpc: the max message size, batch size and concurrency must be positive")
+	}
+	srv.ctx, srv.cancel = context.WithCancel(context.Background())
+	// the calls have their own timeout.
+	srv.Server = khttp.NewServer(append([]khttp.ServerOption{khttp.Timeout(0)}, srv.httpOpts...)...)
+	srv.Server.Handle(srv.path, http.HandlerFunc(srv.serveJSONRPC))
+	return srv
+}
+
+// RegisterService registers the unary methods of a gRPC service, which are called
+// by the method of the service name and the method name, i.e. helloworld.Greeter.SayHello,
+// the streaming methods are not supported.
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	for i := range desc.Methods {
+		md := desc.Methods[i]
+		operation := "/" + desc.ServiceName + "/" + md.MethodName
+		s.methods[desc.ServiceName+"."+md.MethodName] = &method{
+			operation: operation,
+			call: func(ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+				return md.Handler(impl, ctx, dec, func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (interface{}, error) {
+					return s.invoke(ctx, req, middleware.Handler(h))
+				})
+			},
+		}
+	}
+}
+
+// Handle registers the handler of the method, the params are decoded into the
+// value created by newReq.
+func (s *Server) Handle(name string, newReq func() interface{}, h HandlerFunc) {
+	s.methods[name] = &method{
+		operation: name,
+		call: func(ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := newReq()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.invoke(ctx, req, middleware.Handler(h))
+		},
+	}
+}
+
+func (s *Server) invoke(ctx context.Context, req interface{}, h middleware.Handler) (interface{}, error) {
+	if s.middleware != nil {
+		h = s.middleware(h)
+	}
+	return h(ctx, req)
+}
+
+// serveJSONRPC serves a request or a batch of the POST requests, and upgrades the
+// WebSocket requests.
+func (s *Server) serveJSONRPC(res http.ResponseWriter, req *http.Request) {
+	if websocket.IsWebSocketUpgrade(req) {
+		s.serveWebSocket(res, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(res, req.Body, s.maxMessageSize))
+	if err != nil {
+		// the body is read up to the limit if it is too large.
+		if int64(len(data)) == s.maxMessageSize {
+			res.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	reply, err := s.serve(req.Context(), req, data, headerCarrier(res.Header()))
+	if errors.Is(err, errNotificationsOnly) {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(reply)
+}
+
+// serveWebSocket serves the messages of the connection concurrently up to the max
+// concurrency, each of them is a request or a batch, the connection is closed when
+// it sends a message larger than the max message size or the server stops.
+func (s *Server) serveWebSocket(res http.ResponseWriter, req *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	conn, err := upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		return
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	conn.SetReadLimit(s.maxMessageSize)
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, s.maxConcurrency)
+	)
+	defer wg.Wait()
+	for {
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if typ != websocket.TextMessage && typ != websocket.BinaryMessage {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			reply, err := s.serve(ctx, req, data, headerCarrier{})
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			_ = conn.WriteMessage(typ, reply)
+		}()
+	}
+}
+
+// serve returns the response of the request, or the responses of the batch,
+// errNotificationsOnly if there is nothing to respond.
+func (s *Server) serve(ctx context.Context, req *http.Request, data []byte, reply headerCarrier) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		return json.Marshal(errorResponse(nil, CodeParseError, "Parse error"))
+	}
+	if data[0] != '[' {
+		res := s.call(ctx, req, data, reply)
+		if res == nil {
+			return nil, errNotificationsOnly
+		}
+		return json.Marshal(res)
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil || len(batch) == 0 {
+		return json.Marshal(errorResponse(nil, CodeInvalidRequest, "Invalid Request"))
+	}
+	if len(batch) > s.maxBatchSize {
+		return json.Marshal(errorResponse(nil, CodeInvalidRequest, "Invalid Request: the batch is too large"))
+	}
+	responses := make([]*response, 0, len(batch))
+	for _, raw := range batch {
+		if res := s.call(ctx, req, raw, reply); res != nil {
+			responses = append(responses, res)
+		}
+	}
+	if len(responses) == 0 {
+		return nil, errNotificationsOnly
+	}
+	return json.Marshal(responses)
+}
+
+// paramsError is the error of decoding the params.
+type paramsError struct {
+	err error
+}
+
+func (e *paramsError) Error() string {
+	return e.err.Error()
+}
+
+// call calls the method of the request, there is no response of the notifications.
+func (s *Server) call(ctx context.Context, req *http.Request, raw json.RawMessage, reply headerCarrier) *response {
+	var r request
+	if err := json.Unmarshal(raw, &r); err != nil || r.Version != Version || r.Method == "" {
+		return errorResponse(r.ID, CodeInvalidRequest, "Invalid Request")
+	}
+	notification := len(r.ID) == 0
+	m, ok := s.methods[r.Method]
+	if !ok {
+		if notification {
+			return nil
+		}
+		return errorResponse(r.ID, CodeMethodNotFound, "Method not found")
+	}
+	var cancel context.CancelFunc
+	if s.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	ctx = transport.NewServerContext(ctx, &Transport{
+		endpoint:    s.endpoint,
+		operation:   m.operation,
+		method:      r.Method,
+		request:     req,
+		reqHeader:   headerCarrier(req.Header.Clone()),
+		replyHeader: reply,
+	})
+	result, err := m.call(ctx, func(v interface{}) error {
+		if err := decodeParams(r.Params, v); err != nil {
+			return &paramsError{err: err}
+		}
+		return nil
+	})
+	if notification {
+		return nil
+	}
+	if err != nil {
+		if pe := new(paramsError); errors.As(err, &pe) {
+			return errorResponse(r.ID, CodeInvalidParams, "Invalid params: "+pe.Error())
+		}
+		return &response{Version: Version, Error: FromError(err), ID: r.ID}
+	}
+	data, err := encoding.GetCodec("json").Marshal(result)
+	if err != nil {
+		return errorResponse(r.ID, CodeInternalError, "Internal error: "+err.Error())
+	}
+	return &response{Version: Version, Result: data, ID: r.ID}
+}
+
+// decodeParams decodes the params by name, or the only one by position.
+func decodeParams(params json.RawMessage, v interface{}) error {
+	params = bytes.TrimSpace(params)
+	if len(params) > 0 && params[0] == '[' {
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil {
+			return err
+		}
+		switch len(positional) {
+		case 0:
+			params = nil
+		case 1:
+			params = positional[0]
+		default:
+			return errors.New("only one param by position is supported")
+		}
+	}
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	return encoding.GetCodec("json").Unmarshal(params, v)
+}
+
+// Endpoint return a real address to registry endpoint.
+// examples:
+//
+//	http://127.0.0.1:8000
+func (s *Server) Endpoint() (string, error) {
+	endpoint, err := s.Server.Endpoint()
+	if err != nil {
+		return "", err
+	}
+	s.endpoint = endpoint
+	return endpoint, nil
+}
+
+// Start start the JSON-RPC server.
+func (s *Server) Start() error {
+	endpoint, err := s.Endpoint()
+	if err != nil {
+		return err
+	}
+	s.log.Infof("[JSON-RPC] server serving on: %s%s", endpoint, s.path)
+	return s.Server.Start()
+}
+
+// Stop stop the JSON-RPC server, the WebSocket connections are closed.
+func (s *Server) Stop() error {
+	s.log.Info("[JSON-RPC] server stopping")
+	s.cancel()
+	err := s.Server.Stop()
+	s.wg.Wait()
+	return err
+}
+
+// Routes returns the registered methods and their middleware, along with the
+// routes of the HTTP server.
+func (s *Server) Routes() []transport.Route {
+	routes := make([]transport.Route, 0, len(s.methods))
+	for name, m := range s.methods {
+		ctx := transport.NewServerContext(context.Background(), &Transport{
+			endpoint:    s.endpoint,
+			operation:   m.operation,
+			method:      name,
+			reqHeader:   headerCarrier{},
+			replyHeader: headerCarrier{},
+		})
+		ms := middleware.Inspect(ctx, s.middleware)
+		routes = append(routes, transport.Route{
+			Kind:       transport.KindJSONRPC,
+			Operation:  m.operation,
+			Middleware: ms,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Operation < routes[j].Operation
+	})
+	return append(routes, s.Server.Routes()...)
+}
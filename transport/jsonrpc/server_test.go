@@ -0,0 +1,276 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+func newServer(t *testing.T, opts ...ServerOption) (*Server, string) {
+	opts = append([]ServerOption{HTTPOptions(khttp.Address("127.0.0.1:0"))}, opts...)
+	srv := NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	srv.Handle("echo", func() interface{} { return new(echoRequest) }, func(ctx context.Context, req interface{}) (interface{}, error) {
+		msg := req.(*echoRequest).Message
+		if msg == "" {
+			return nil, errors.InvalidArgument("EMPTY_MESSAGE", "empty message")
+		}
+		return req, nil
+	})
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() { _ = srv.Stop() })
+	return srv, endpoint
+}
+
+func post(t *testing.T, url, body string) (*http.Response, []byte) {
+	res, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var data json.RawMessage
+	if res.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res, data
+}
+
+func TestBatch(t *testing.T) {
+	var operations []string
+	m := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, _ := transport.FromServerContext(ctx)
+			if tr.Kind() != transport.KindJSONRPC {
+				t.Errorf("got %s want jsonrpc", tr.Kind())
+			}
+			operations = append(operations, tr.Operation())
+			tr.ReplyHeader().Set("x-operation", tr.Operation())
+			return next(ctx, req)
+		}
+	}
+	_, endpoint := newServer(t, Middleware(m))
+
+	res, data := post(t, endpoint+"/rpc", `[
+		{"jsonrpc": "2.0", "method": "grpc.health.v1.Health.Check", "params": {"service": ""}, "id": 1},
+		{"jsonrpc": "2.0", "method": "echo", "params": [{"message": "hello"}], "id": "2"},
+		{"jsonrpc": "2.0", "method": "echo", "params": {"message": "notified"}},
+		{"jsonrpc": "2.0", "method": "echo", "params": {}, "id": 3},
+		{"jsonrpc": "2.0", "method": "echo", "params": "hello", "id": 4},
+		{"jsonrpc": "2.0", "method": "grpc.health.v1.Health.Watch", "id": 5},
+		{"method": "echo", "id": 6}
+	]`)
+	if res.Header.Get("x-operation") == "" {
+		t.Error("got no reply header")
+	}
+	var responses []struct {
+		Version string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		Error   *Error          `json:"error"`
+		ID      json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 6 {
+		t.Fatalf("got %s want 6 responses without the notification", data)
+	}
+	for _, r := range responses {
+		if r.Version != "2.0" {
+			t.Errorf("got version %q", r.Version)
+		}
+	}
+	if got := string(responses[0].Result); got != `{"status":"SERVING"}` {
+		t.Errorf("got %s want the serving status", got)
+	}
+	if got := string(responses[1].Result); got != `{"message":"hello"}` || string(responses[1].ID) != `"2"` {
+		t.Errorf("got %s of %s want the echo", got, responses[1].ID)
+	}
+	want := []*Error{
+		{Code: 3, Message: "empty message", Data: &ErrorData{Reason: "EMPTY_MESSAGE"}},
+		{Code: CodeInvalidParams},
+		{Code: CodeMethodNotFound, Message: "Method not found"},
+		{Code: CodeInvalidRequest, Message: "Invalid Request"},
+	}
+	for i, w := range want {
+		got := responses[i+2].Error
+		if got == nil || got.Code != w.Code {
+			t.Errorf("got %+v want the code %d", got, w.Code)
+			continue
+		}
+		if w.Message != "" && (got.Message != w.Message || !reflect.DeepEqual(got.Data, w.Data)) {
+			t.Errorf("got %+v want %+v", got, w)
+		}
+	}
+	wantOps := []string{"/grpc.health.v1.Health/Check", "echo", "echo", "echo"}
+	if !reflect.DeepEqual(operations, wantOps) {
+		t.Errorf("got %v want %v", operations, wantOps)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	_, endpoint := newServer(t)
+	tests := []struct {
+		body string
+		code int32
+	}{
+		{`{"jsonrpc": "2.0", "method": "echo"`, CodeParseError},
+		{`[]`, CodeInvalidRequest},
+		{`1`, CodeInvalidRequest},
+	}
+	for _, test := range tests {
+		_, data := post(t, endpoint+"/rpc", test.body)
+		var r response
+		if err := json.Unmarshal(data, &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Error == nil || r.Error.Code != test.code || string(r.ID) != "null" {
+			t.Errorf("got %s want the code %d of %s", data, test.code, test.body)
+		}
+	}
+
+	res, _ := post(t, endpoint+"/rpc", `[{"jsonrpc": "2.0", "method": "echo", "params": {"message": "a"}}]`)
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("got %d want no content of the notifications", res.StatusCode)
+	}
+	res, err := http.Get(endpoint + "/rpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("got %d want method not allowed", res.StatusCode)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	srv, endpoint := newServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(endpoint, "http")+"/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	msg := `{"jsonrpc": "2.0", "method": "echo", "params": {"message": "hello"}, "id": 1}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	var r response
+	if err := conn.ReadJSON(&r); err != nil {
+		t.Fatal(err)
+	}
+	if string(r.Result) != `{"message":"hello"}` || string(r.ID) != "1" {
+		t.Errorf("got %+v want the echo", r)
+	}
+
+	// the connection is closed when the server stops.
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("got the connection open want it closed")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	srv := NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	var got []string
+	for _, r := range srv.Routes() {
+		if r.Kind == transport.KindJSONRPC {
+			got = append(got, r.Operation)
+			if !reflect.DeepEqual(r.Middleware, []string{"recovery"}) {
+				t.Errorf("got %v want the recovery", r.Middleware)
+			}
+		}
+	}
+	if want := []string{"/grpc.health.v1.Health/Check"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	srv, endpoint := newServer(t, MaxMessageSize(256), MaxBatchSize(2), MaxConcurrency(1))
+	var (
+		calls   = make(chan struct{}, 2)
+		release = make(chan struct{})
+	)
+	srv.Handle("block", func() interface{} { return new(echoRequest) }, func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls <- struct{}{}
+		<-release
+		return req, nil
+	})
+
+	res, _ := post(t, endpoint+"/rpc", `{"jsonrpc": "2.0", "method": "echo", "params": {"message": "`+strings.Repeat("a", 256)+`"}, "id": 1}`)
+	if res.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("got %d want the body too large", res.StatusCode)
+	}
+	call := `{"jsonrpc": "2.0", "method": "echo", "id": 1}`
+	_, data := post(t, endpoint+"/rpc", "["+call+","+call+","+call+"]")
+	var r response
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Error == nil || r.Error.Code != CodeInvalidRequest {
+		t.Errorf("got %s want the batch too large", data)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(endpoint, "http")+"/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	block := `{"jsonrpc": "2.0", "method": "block", "params": {"message": "hello"}, "id": 1}`
+	for i := 0; i < 2; i++ {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(block)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-calls
+	select {
+	case <-calls:
+		t.Error("got 2 concurrent calls want 1")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release <- struct{}{}
+	<-calls
+	close(release)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	for i := 0; i < 2; i++ {
+		if err := conn.ReadJSON(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat(" ", 257))); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("got the connection open want it closed of the message too large")
+	}
+}
@@ -0,0 +1,78 @@
+package jsonrpc
+
+import (
+	"net/http"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is a JSON-RPC transport.
+type Transport struct {
+	endpoint    string
+	operation   string
+	method      string
+	request     *http.Request
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindJSONRPC
+}
+
+// Endpoint returns the transport endpoint.
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+// Operation returns the full method of the registered service method, i.e.
+// /helloworld.Greeter/SayHello, or the method of the handled one.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// Method returns the JSON-RPC method of the call, i.e. helloworld.Greeter.SayHello.
+func (tr *Transport) Method() string {
+	return tr.method
+}
+
+// Request returns the HTTP request of the call, which is the upgrade request
+// of the calls over WebSocket.
+func (tr *Transport) Request() *http.Request {
+	return tr.request
+}
+
+// RequestHeader returns the request header.
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+// ReplyHeader returns the reply header, which is not sent by the calls over
+// WebSocket.
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+type headerCarrier http.Header
+
+// Get returns the value associated with the passed key.
+func (hc headerCarrier) Get(key string) string {
+	return http.Header(hc).Get(key)
+}
+
+// Set stores the key-value pair.
+func (hc headerCarrier) Set(key string, value string) {
+	http.Header(hc).Set(key, value)
+}
+
+// Keys lists the keys stored in this carrier.
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,226 @@
+// Package mux serves multiple protocols on a single listener by sniffing the
+// first bytes of each connection, i.e. gRPC and HTTP on the same port:
+//
+//	m := mux.New(lis)
+//	grpcSrv := grpc.NewServer(grpc.Listener(m.Match(mux.HTTP2())))
+//	httpSrv := http.NewServer(http.Listener(m.Match(mux.Any())))
+//	app := kratos.New(kratos.Server(m, grpcSrv, httpSrv))
+package mux
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const loggerName = "transport/mux"
+
+var _ transport.Server = (*Mux)(nil)
+
+// ErrListenerClosed is returned by Accept of a closed matched listener.
+var ErrListenerClosed = errors.New("mux: listener closed")
+
+// Matcher matches a connection by reading its first bytes.
+type Matcher func(r io.Reader) bool
+
+// http2Preface is the client connection preface of HTTP/2, gRPC always starts with it.
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// HTTP2 matches the cleartext HTTP/2 connections, which include the gRPC connections.
+func HTTP2() Matcher {
+	return func(r io.Reader) bool {
+		// read byte by byte, so that a short HTTP/1 request does not block on a full preface.
+		b := make([]byte, 1)
+		for _, c := range http2Preface {
+			if _, err := io.ReadFull(r, b); err != nil || b[0] != c {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches any connection.
+func Any() Matcher {
+	return func(r io.Reader) bool {
+		return true
+	}
+}
+
+// Option is mux option.
+type Option func(*Mux)
+
+// ReadTimeout with the timeout of sniffing a connection, the connection is closed
+// if no matcher is decided within it.
+func ReadTimeout(timeout time.Duration) Option {
+	return func(m *Mux) {
+		m.readTimeout = timeout
+	}
+}
+
+// Logger with mux logger.
+func Logger(logger log.Logger) Option {
+	return func(m *Mux) {
+		m.log = log.NewHelper(loggerName, logger)
+	}
+}
+
+// Mux dispatches the connections of a root listener to the matched listeners.
+type Mux struct {
+	root        net.Listener
+	readTimeout time.Duration
+	log         *log.Helper
+	branches    []*listener
+	done        chan struct{}
+	once        sync.Once
+}
+
+// New creates a mux of the root listener.
+func New(root net.Listener, opts ...Option) *Mux {
+	m := &Mux{
+		root:        root,
+		readTimeout: 10 * time.Second,
+		log:         log.NewHelper(loggerName, log.GetLogger()),
+		done:        make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+// Match returns a listener of the connections accepted by any of the matchers,
+// the matchers are tried in the order that Match is called.
+func (m *Mux) Match(matchers ...Matcher) net.Listener {
+	l := &listener{
+		root:     m.root,
+		matchers: matchers,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+	m.branches = append(m.branches, l)
+	return l
+}
+
+// Endpoint returns empty, the endpoints are reported by the servers of the matched listeners.
+func (m *Mux) Endpoint() (string, error) {
+	return "", nil
+}
+
+// Start accepts the connections of the root listener and dispatches them.
+func (m *Mux) Start() error {
+	m.log.Infof("[MUX] server listening on: %s", m.root.Addr().String())
+	for {
+		c, err := m.root.Accept()
+		if err != nil {
+			select {
+			case <-m.done:
+				return nil
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		go m.serve(c)
+	}
+}
+
+// Stop stops accepting the connections, the matched listeners are closed by their servers.
+func (m *Mux) Stop() error {
+	m.log.Info("[MUX] server stopping")
+	var err error
+	m.once.Do(func() {
+		close(m.done)
+		err = m.root.Close()
+	})
+	return err
+}
+
+func (m *Mux) serve(c net.Conn) {
+	sc := &conn{Conn: c}
+	if m.readTimeout > 0 {
+		_ = c.SetReadDeadline(time.Now().Add(m.readTimeout))
+	}
+	for _, l := range m.branches {
+		for _, match := range l.matchers {
+			matched := match(sc.sniff())
+			sc.reset()
+			if !matched {
+				continue
+			}
+			if m.readTimeout > 0 {
+				_ = c.SetReadDeadline(time.Time{})
+			}
+			select {
+			case l.conns <- sc:
+			case <-l.done:
+				c.Close()
+			}
+			return
+		}
+	}
+	m.log.Warnf("[MUX] no listener matches the connection from: %s", c.RemoteAddr())
+	c.Close()
+}
+
+// listener is a net.Listener of the matched connections.
+type listener struct {
+	root     net.Listener
+	matchers []Matcher
+	conns    chan net.Conn
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, ErrListenerClosed
+	}
+}
+
+func (l *listener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *listener) Addr() net.Addr {
+	return l.root.Addr()
+}
+
+// conn replays the sniffed bytes before reading from the connection.
+type conn struct {
+	net.Conn
+	buf    bytes.Buffer
+	replay *bytes.Reader
+}
+
+// sniff returns a reader from the beginning of the connection, which buffers what it reads.
+func (c *conn) sniff() io.Reader {
+	return io.MultiReader(bytes.NewReader(c.buf.Bytes()), io.TeeReader(c.Conn, &c.buf))
+}
+
+// reset rewinds the connection to the beginning for the next sniff or the reads.
+func (c *conn) reset() {
+	c.replay = bytes.NewReader(c.buf.Bytes())
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	if c.replay != nil && c.replay.Len() > 0 {
+		return c.replay.Read(p)
+	}
+	return c.Conn.Read(p)
+}
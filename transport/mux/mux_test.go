@@ -0,0 +1,85 @@
+package mux
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	transhttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMux(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(lis)
+	grpcSrv := grpc.NewServer(grpc.Listener(m.Match(HTTP2())))
+	httpSrv := transhttp.NewServer(transhttp.Listener(m.Match(Any())))
+	httpSrv.HandleFunc("/index", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	go m.Start()
+	go grpcSrv.Start()
+	go httpSrv.Start()
+	defer func() {
+		m.Stop()
+		grpcSrv.Stop()
+		httpSrv.Stop()
+	}()
+
+	res, err := http.Get("http://" + lis.Addr().String() + "/index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(b) != "OK" {
+		t.Fatalf("expected OK got %s", b)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn, err := grpc.DialInsecure(ctx, grpc.WithEndpoint(lis.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reply, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING got %s", reply.Status)
+	}
+}
+
+func TestMuxNoMatch(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(lis)
+	m.Match(HTTP2())
+	go m.Start()
+	defer m.Stop()
+
+	c, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := c.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected the connection to be closed")
+	} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatal("expected the connection to be closed, got timeout")
+	}
+}
@@ -0,0 +1,86 @@
+// Package timeout provides the per operation timeouts of the servers, which override
+// the server timeout and can be reloaded from the config at runtime.
+package timeout
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/internal/matcher"
+	"github.com/go-kratos/kratos/v2/internal/reload"
+)
+
+// Table maps the operations to their timeouts, a zero timeout means no timeout,
+// such as for the streaming or long-poll operations. An operation ends with "*"
+// matches the operations of the prefix, i.e. /helloworld.Greeter/*, and the
+// longest one wins.
+type Table struct {
+	mu       sync.RWMutex
+	timeouts map[string]time.Duration
+	matcher  *matcher.Matcher
+}
+
+// New returns a table of the operation timeouts.
+func New(timeouts map[string]time.Duration) *Table {
+	t := &Table{}
+	t.Update(timeouts)
+	return t
+}
+
+// Update replaces the operation timeouts of the table.
+func (t *Table) Update(timeouts map[string]time.Duration) {
+	m := make(map[string]time.Duration, len(timeouts))
+	patterns := make([]string, 0, len(timeouts))
+	for k, v := range timeouts {
+		m[k] = v
+		patterns = append(patterns, k)
+	}
+	t.mu.Lock()
+	t.timeouts, t.matcher = m, matcher.New(patterns...)
+	t.mu.Unlock()
+}
+
+// Lookup returns the timeout of the operation, it reports false if no entry matches.
+func (t *Table) Lookup(operation string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if k, ok := t.matcher.Match(operation); ok {
+		return t.timeouts[k], true
+	}
+	return 0, false
+}
+
+// Load returns a table loaded from the config key, which is a map of the operations
+// to the durations, i.e. {"/helloworld.Greeter/*": "2s"}, and reloads it whenever
+// the key changes.
+func Load(c config.Config, key string) (*Table, error) {
+	t := New(nil)
+	err := reload.Load(c, key, func(v config.Value) error {
+		timeouts, err := scan(v)
+		if err == nil {
+			t.Update(timeouts)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func scan(v config.Value) (map[string]time.Duration, error) {
+	var m map[string]string
+	if err := v.Scan(&m); err != nil {
+		return nil, err
+	}
+	timeouts := make(map[string]time.Duration, len(m))
+	for k, s := range m {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		timeouts[k] = d
+	}
+	return timeouts, nil
+}
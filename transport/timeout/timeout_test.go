@@ -0,0 +1,107 @@
+package timeout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+func TestLookup(t *testing.T) {
+	table := New(map[string]time.Duration{
+		"/helloworld.Greeter/SayHello": time.Second,
+		"/helloworld.Greeter/*":        2 * time.Second,
+		"/helloworld.*":                3 * time.Second,
+		"/helloworld.Greeter/Watch":    0,
+	})
+	tests := []struct {
+		operation string
+		timeout   time.Duration
+		ok        bool
+	}{
+		{"/helloworld.Greeter/SayHello", time.Second, true},
+		{"/helloworld.Greeter/SayHi", 2 * time.Second, true},
+		{"/helloworld.Other/SayHi", 3 * time.Second, true},
+		{"/helloworld.Greeter/Watch", 0, true},
+		{"/other.Greeter/SayHello", 0, false},
+	}
+	for _, test := range tests {
+		d, ok := table.Lookup(test.operation)
+		if d != test.timeout || ok != test.ok {
+			t.Errorf("%s: got %v %t want %v %t", test.operation, d, ok, test.timeout, test.ok)
+		}
+	}
+
+	table.Update(map[string]time.Duration{"*": time.Minute})
+	if d, ok := table.Lookup("/helloworld.Greeter/SayHello"); d != time.Minute || !ok {
+		t.Errorf("after update: got %v %t want %v true", d, ok, time.Minute)
+	}
+}
+
+type testSource struct {
+	kvs chan []*config.KeyValue
+}
+
+func (s *testSource) Load() ([]*config.KeyValue, error) {
+	return <-s.kvs, nil
+}
+
+func (s *testSource) Watch() (config.Watcher, error) {
+	return s, nil
+}
+
+func (s *testSource) Next() ([]*config.KeyValue, error) {
+	kvs, ok := <-s.kvs
+	if !ok {
+		return nil, context.Canceled
+	}
+	return kvs, nil
+}
+
+func (s *testSource) Close() error {
+	return nil
+}
+
+func timeouts(text string) []*config.KeyValue {
+	return []*config.KeyValue{{
+		Key:    "server",
+		Value:  []byte(`{"server":{"timeouts":` + text + `}}`),
+		Format: "json",
+	}}
+}
+
+func TestLoad(t *testing.T) {
+	source := &testSource{kvs: make(chan []*config.KeyValue, 1)}
+	source.kvs <- timeouts(`{"/helloworld.Greeter/*":"2s"}`)
+	c := config.New(config.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	table, err := Load(c, "server.timeouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := table.Lookup("/helloworld.Greeter/SayHello"); d != 2*time.Second || !ok {
+		t.Fatalf("got %v %t want 2s true", d, ok)
+	}
+
+	// reload the timeouts from the config
+	source.kvs <- timeouts(`{"/helloworld.Greeter/*":"5s"}`)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if d, _ := table.Lookup("/helloworld.Greeter/SayHello"); d == 5*time.Second {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeouts are not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := Load(c, "server.missing"); err == nil {
+		t.Fatal("expected an error of the missing key")
+	}
+}
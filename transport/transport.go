@@ -4,8 +4,11 @@ import (
 	"context"
 
 	// init encoding
+	_ "github.com/go-kratos/kratos/v2/encoding/form"
 	_ "github.com/go-kratos/kratos/v2/encoding/json"
 	_ "github.com/go-kratos/kratos/v2/encoding/proto"
+	_ "github.com/go-kratos/kratos/v2/encoding/xml"
+	_ "github.com/go-kratos/kratos/v2/encoding/yaml"
 )
 
 // Server is transport server.
@@ -15,20 +18,93 @@ type Server interface {
 	Stop() error
 }
 
-// Transport is transport context value.
-type Transport struct {
-	Kind string
+// Route is an operation served by a server, reported by the introspection.
+type Route struct {
+	Kind Kind `json:"kind"`
+	// Operation is the operation of the route, see Transporter.
+	Operation string `json:"operation"`
+	// Methods is the HTTP methods of the route, any method if empty.
+	Methods []string `json:"methods,omitempty"`
+	// Middleware is the names of the middleware applied to the operation,
+	// see middleware.Named.
+	Middleware []string `json:"middleware"`
 }
 
-type transportKey struct{}
+// Inspector is implemented by the servers which report their routes.
+type Inspector interface {
+	Routes() []Route
+}
+
+// Header is the storage medium used by a Transporter.
+type Header interface {
+	Get(key string) string
+	Set(key string, value string)
+	Keys() []string
+}
+
+// Kind defines the type of Transport.
+type Kind string
+
+func (k Kind) String() string { return string(k) }
+
+// Defines a set of transport kind.
+const (
+	KindGRPC    Kind = "grpc"
+	KindHTTP    Kind = "http"
+	KindBroker  Kind = "broker"
+	KindCron    Kind = "cron"
+	KindTask    Kind = "task"
+	KindGraphQL Kind = "graphql"
+	KindJSONRPC Kind = "jsonrpc"
+	KindThrift  Kind = "thrift"
+)
+
+// Transporter is transport context value interface.
+type Transporter interface {
+	// Kind returns the transport kind, i.e. grpc, http, broker, cron, task, graphql,
+	// jsonrpc or thrift.
+	Kind() Kind
+	// Endpoint returns the server endpoint or the client target.
+	// examples:
+	//
+	//	grpc://127.0.0.1:9000?isSecure=false
+	//	http://127.0.0.1:8000
+	Endpoint() string
+	// Operation returns the operation of the request.
+	// gRPC: full method, i.e. /helloworld.Greeter/SayHello
+	// HTTP: route path, i.e. /v1/users/{id}
+	Operation() string
+	// RequestHeader returns the request header, the client middleware
+	// can set the header to send.
+	RequestHeader() Header
+	// ReplyHeader returns the reply header, the server middleware
+	// can set the header to reply.
+	ReplyHeader() Header
+}
+
+type (
+	serverTransportKey struct{}
+	clientTransportKey struct{}
+)
+
+// NewServerContext returns a new Context that carries value.
+func NewServerContext(ctx context.Context, tr Transporter) context.Context {
+	return context.WithValue(ctx, serverTransportKey{}, tr)
+}
+
+// FromServerContext returns the Transport value stored in ctx, if any.
+func FromServerContext(ctx context.Context) (tr Transporter, ok bool) {
+	tr, ok = ctx.Value(serverTransportKey{}).(Transporter)
+	return
+}
 
-// NewContext returns a new Context that carries value.
-func NewContext(ctx context.Context, tr Transport) context.Context {
-	return context.WithValue(ctx, transportKey{}, tr)
+// NewClientContext returns a new Context that carries value.
+func NewClientContext(ctx context.Context, tr Transporter) context.Context {
+	return context.WithValue(ctx, clientTransportKey{}, tr)
 }
 
-// FromContext returns the Transport value stored in ctx, if any.
-func FromContext(ctx context.Context) (tr Transport, ok bool) {
-	tr, ok = ctx.Value(transportKey{}).(Transport)
+// FromClientContext returns the Transport value stored in ctx, if any.
+func FromClientContext(ctx context.Context) (tr Transporter, ok bool) {
+	tr, ok = ctx.Value(clientTransportKey{}).(Transporter)
 	return
 }
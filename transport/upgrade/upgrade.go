@@ -0,0 +1,344 @@
+// Package upgrade provides the zero downtime upgrades of the binary on unix, which is
+// useful for the bare metal deployments without a load balancer. On the upgrade signal,
+// SIGHUP by default, the process starts the new binary with its listeners, and once the
+// new process is ready, the old one stops and drains its requests.
+//
+//	u, err := upgrade.New()
+//	lis, err := u.Listen("tcp", ":8000")
+//	app := kratos.New(
+//		kratos.Server(http.NewServer(http.Listener(lis))),
+//		kratos.AfterStart(func(context.Context) error { return u.Ready() }),
+//	)
+//	go func() {
+//		<-u.Exit()
+//		app.Stop()
+//	}()
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	// listenersEnv is the inherited listeners of the fds from 3, such as tcp:[::]:8000,unix:/tmp/app.sock.
+	listenersEnv = "KRATOS_UPGRADE_LISTENERS"
+	// readyEnv is the fd of the pipe which the new process notifies the old one of ready.
+	readyEnv = "KRATOS_UPGRADE_READY"
+)
+
+// ErrUpgrading is returned by Upgrade if an upgrade is in progress.
+var ErrUpgrading = errors.New("upgrade: upgrade in progress")
+
+// Option is upgrader option.
+type Option func(o *options)
+
+type options struct {
+	sigs    []os.Signal
+	timeout time.Duration
+	pidFile string
+	logger  log.Logger
+}
+
+// WithSignal with the signals which trigger the upgrades, SIGHUP by default, no signals
+// disables the signal handling and the upgrades are only triggered by Upgrade.
+func WithSignal(sigs ...os.Signal) Option {
+	return func(o *options) { o.sigs = sigs }
+}
+
+// WithTimeout with the timeout of the new process being ready, 30s by default, the new
+// process is killed if it is not ready in time.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithPidFile with the file of the pid of the ready process, which is read by kratos upgrade.
+func WithPidFile(path string) Option {
+	return func(o *options) { o.pidFile = path }
+}
+
+// WithLogger with the logger of the signaled upgrades.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// Upgrader passes the listeners to the new process of the upgrades.
+type Upgrader struct {
+	opts options
+	log  *log.Helper
+	sigs chan os.Signal
+
+	mu        sync.Mutex
+	inherited map[string]net.Listener
+	listeners map[string]net.Listener
+	ready     *os.File
+	upgrading bool
+	exit      chan struct{}
+	exited    bool
+
+	// command returns the command of the new process, which is the current binary with
+	// the same arguments and working directory.
+	command func() (*exec.Cmd, error)
+}
+
+// New creates an upgrader, which inherits the listeners of the old process if it is
+// started by an upgrade.
+func New(opts ...Option) (*Upgrader, error) {
+	options := options{
+		sigs:    []os.Signal{syscall.SIGHUP},
+		timeout: 30 * time.Second,
+		logger:  log.GetLogger(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	u := &Upgrader{
+		opts:      options,
+		log:       log.NewHelper("transport/upgrade", options.logger),
+		inherited: make(map[string]net.Listener),
+		listeners: make(map[string]net.Listener),
+		exit:      make(chan struct{}),
+		command:   command,
+	}
+	if err := u.inherit(); err != nil {
+		return nil, err
+	}
+	if u.ready == nil && options.pidFile != "" {
+		if err := writePidFile(options.pidFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(options.sigs) > 0 {
+		u.sigs = make(chan os.Signal, 1)
+		signal.Notify(u.sigs, options.sigs...)
+		go u.watch(u.sigs)
+	}
+	return u, nil
+}
+
+func (u *Upgrader) inherit() error {
+	names := os.Getenv(listenersEnv)
+	fd := os.Getenv(readyEnv)
+	os.Unsetenv(listenersEnv)
+	os.Unsetenv(readyEnv)
+	if fd == "" {
+		return nil
+	}
+	if names != "" {
+		for i, name := range strings.Split(names, ",") {
+			f := os.NewFile(uintptr(3+i), name)
+			lis, err := net.FileListener(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("upgrade: inherit listener %s: %w", name, err)
+			}
+			u.inherited[name] = lis
+		}
+	}
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return fmt.Errorf("upgrade: invalid ready fd %q", fd)
+	}
+	u.ready = os.NewFile(uintptr(n), "ready")
+	return nil
+}
+
+// Listen returns the listener of the network address, which is inherited from the old
+// process if it has the same network and address, or created otherwise.
+func (u *Upgrader) Listen(network, address string) (net.Listener, error) {
+	name := network + ":" + address
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.listeners[name]; ok {
+		return nil, fmt.Errorf("upgrade: listener %s is listened", name)
+	}
+	lis, ok := u.inherited[name]
+	if ok {
+		delete(u.inherited, name)
+	} else {
+		var err error
+		if lis, err = net.Listen(network, address); err != nil {
+			return nil, err
+		}
+	}
+	u.listeners[name] = lis
+	return lis, nil
+}
+
+// Ready notifies the old process that the new process is ready, so that the old one stops.
+// The inherited listeners which are not listened are closed.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for name, lis := range u.inherited {
+		lis.Close()
+		delete(u.inherited, name)
+	}
+	if u.opts.pidFile != "" {
+		if err := writePidFile(u.opts.pidFile); err != nil {
+			return err
+		}
+	}
+	if u.ready == nil {
+		return nil
+	}
+	defer func() {
+		u.ready.Close()
+		u.ready = nil
+	}()
+	_, err := u.ready.Write([]byte{1})
+	return err
+}
+
+// Exit returns a channel which is closed once the new process of an upgrade is ready, the
+// app should stop and drain its requests then.
+func (u *Upgrader) Exit() <-chan struct{} {
+	return u.exit
+}
+
+// Upgrade starts the new process with the listeners, and waits for it to be ready.
+func (u *Upgrader) Upgrade() error {
+	u.mu.Lock()
+	if u.upgrading || u.exited {
+		u.mu.Unlock()
+		return ErrUpgrading
+	}
+	u.upgrading = true
+	names := make([]string, 0, len(u.listeners))
+	files := make([]*os.File, 0, len(u.listeners)+1)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for name, lis := range u.listeners {
+		f, err := file(lis)
+		if err != nil {
+			u.upgrading = false
+			u.mu.Unlock()
+			return fmt.Errorf("upgrade: listener %s: %w", name, err)
+		}
+		names = append(names, name)
+		files = append(files, f)
+	}
+	u.mu.Unlock()
+
+	done := func(exit bool) {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		u.upgrading = false
+		if exit && !u.exited {
+			u.exited = true
+			close(u.exit)
+		}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		done(false)
+		return err
+	}
+	defer r.Close()
+	files = append(files, w)
+	cmd, err := u.command()
+	if err != nil {
+		done(false)
+		return err
+	}
+	cmd.ExtraFiles = files
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env,
+		listenersEnv+"="+strings.Join(names, ","),
+		readyEnv+"="+strconv.Itoa(3+len(names)),
+	)
+	if err = cmd.Start(); err != nil {
+		done(false)
+		return err
+	}
+	// the write end is held by the new process only, so that the read fails once it exits.
+	w.Close()
+	go cmd.Wait()
+
+	ready := make(chan error, 1)
+	go func() {
+		var b [1]byte
+		_, err := r.Read(b[:])
+		ready <- err
+	}()
+	timer := time.NewTimer(u.opts.timeout)
+	defer timer.Stop()
+	select {
+	case err = <-ready:
+		if err != nil {
+			err = fmt.Errorf("upgrade: the new process %d exits before ready: %w", cmd.Process.Pid, err)
+		}
+	case <-timer.C:
+		err = fmt.Errorf("upgrade: the new process %d is not ready in %s", cmd.Process.Pid, u.opts.timeout)
+		cmd.Process.Kill()
+	}
+	done(err == nil)
+	return err
+}
+
+// Stop stops the signal handling of the upgrader.
+func (u *Upgrader) Stop() {
+	u.mu.Lock()
+	sigs := u.sigs
+	u.sigs = nil
+	u.mu.Unlock()
+	if sigs != nil {
+		signal.Stop(sigs)
+		close(sigs)
+	}
+}
+
+func (u *Upgrader) watch(sigs <-chan os.Signal) {
+	for range sigs {
+		u.log.Info("upgrading")
+		if err := u.Upgrade(); err != nil {
+			u.log.Errorf("failed to upgrade: %v", err)
+			continue
+		}
+		u.log.Info("upgraded, the new process is ready")
+	}
+}
+
+func command() (*exec.Cmd, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd, nil
+}
+
+// file returns a dup of the fd of the listener.
+func file(lis net.Listener) (*os.File, error) {
+	switch l := lis.(type) {
+	case *net.TCPListener:
+		return l.File()
+	case *net.UnixListener:
+		// the socket file is shared with the new process.
+		l.SetUnlinkOnClose(false)
+		return l.File()
+	}
+	return nil, fmt.Errorf("unsupported listener %T", lis)
+}
+
+func writePidFile(path string) error {
+	return ioutil.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644)
+}
@@ -0,0 +1,114 @@
+package upgrade
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+const helperEnv = "KRATOS_UPGRADE_HELPER"
+
+// TestHelperProcess is the new process of the upgrades, which replies "new" to a connection.
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+	u, err := New(WithSignal())
+	if err != nil {
+		os.Exit(1)
+	}
+	if mode == "exit" {
+		os.Exit(2)
+	}
+	lis, err := u.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.Exit(3)
+	}
+	if err = u.Ready(); err != nil {
+		os.Exit(4)
+	}
+	lis.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := lis.Accept()
+	if err != nil {
+		os.Exit(5)
+	}
+	conn.Write([]byte("new"))
+	conn.Close()
+	os.Exit(0)
+}
+
+func helper(mode string) func() (*exec.Cmd, error) {
+	return func() (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+		cmd.Env = append(os.Environ(), helperEnv+"="+mode)
+		return cmd, nil
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	u, err := New(WithSignal(), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.command = helper("ready")
+	lis, err := u.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = u.Upgrade(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-u.Exit():
+	default:
+		t.Fatal("want exit once the new process is ready")
+	}
+	if err = u.Upgrade(); err != ErrUpgrading {
+		t.Fatalf("got %v want %v", err, ErrUpgrading)
+	}
+
+	// the old process stops, and the new process accepts on the inherited listener.
+	addr := lis.Addr().String()
+	lis.Close()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Fatalf("got %q want the reply of the new process", data)
+	}
+}
+
+func TestUpgradeFailed(t *testing.T) {
+	u, err := New(WithSignal(), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.command = helper("exit")
+	lis, err := u.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	if err = u.Upgrade(); err == nil {
+		t.Fatal("want an error of the exited process")
+	}
+	select {
+	case <-u.Exit():
+		t.Fatal("want no exit once the upgrade fails")
+	default:
+	}
+	if _, err = u.Listen("tcp", "127.0.0.1:0"); err == nil {
+		t.Error("want an error of listening twice")
+	}
+}